@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/gerunddev/tcr/auth"
+	"github.com/gerunddev/tcr/config"
+)
+
+// authUsage is the help for "tcr auth"
+var authUsage = `Usage:
+  tcr auth login <provider>   Save a token, read from the terminal or stdin
+  tcr auth logout <provider>  Remove a saved token
+  tcr auth status             Show where each provider's token comes from
+
+Providers: ` + auth.Names() + `
+
+Tokens are looked up in the provider's environment variable, then the
+system keychain (macOS keychain or secret-tool), then the credentials
+file, then, deprecated, the provider's *_token config setting; status
+warns about tokens still read from the config.
+`
+
+// runAuth implements "tcr auth"
+func runAuth(args []string) int {
+	switch {
+	case len(args) == 2 && args[0] == "login":
+		return authLogin(args[1])
+	case len(args) == 2 && args[0] == "logout":
+		return authLogout(args[1])
+	case len(args) == 1 && args[0] == "status":
+		return authStatus()
+	}
+	fmt.Fprint(os.Stderr, authUsage)
+	return 1
+}
+
+// authLogin prompts for a provider's token and saves it
+func authLogin(provider string) int {
+	p, ok := auth.Lookup(provider)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown provider %q (want %s)\n", provider, auth.Names())
+		return 1
+	}
+
+	var token string
+	if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Printf("%s.\nPaste the %s token: ", p.Help, p.Name)
+		data, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Println()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		token = string(data)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintf(os.Stderr, "Error: no token on stdin\n")
+			return 1
+		}
+		token = line
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		fmt.Fprintf(os.Stderr, "Error: empty token\n")
+		return 1
+	}
+
+	where, err := auth.Default().Login(p.Name, token)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Saved the %s token in the %s.\n", p.Name, where)
+	return 0
+}
+
+// saveTokens stores the tokens entered during setup, by provider, the
+// way authLogin does
+func saveTokens(tokens map[string]string) {
+	m := auth.Default()
+	for _, p := range auth.Providers {
+		token, ok := tokens[p.Name]
+		if !ok {
+			continue
+		}
+		where, err := m.Login(p.Name, token)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save the %s token: %v\n", p.Name, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Saved the %s token in the %s\n", p.Name, where)
+	}
+}
+
+// authLogout removes a provider's saved token
+func authLogout(provider string) int {
+	removed, err := auth.Default().Logout(provider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(removed) == 0 {
+		fmt.Printf("No saved %s token.\n", provider)
+		return 0
+	}
+	fmt.Printf("Removed the %s token from the %s.\n", provider, strings.Join(removed, " and "))
+	return 0
+}
+
+// authStatus lists each provider's token source
+func authStatus() int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	m := auth.Default()
+	for _, p := range auth.Providers {
+		token, source, err := m.Token(cfg, p.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if token == "" {
+			source = "not set"
+		}
+		fmt.Printf("%-8s %s\n", p.Name, source)
+		if token != "" && source == "config "+p.ConfigKey {
+			fmt.Fprintf(os.Stderr, "Warning: the %s token is stored in plain text in the config; move it with tcr auth login %s and delete %s\n", p.Name, p.Name, p.ConfigKey)
+		}
+	}
+	return 0
+}
+
+// forgeToken returns a provider's token, warning about unreadable stores
+func forgeToken(cfg config.Config, provider string) string {
+	token, _, err := auth.Default().Token(cfg, provider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return token
+}
@@ -0,0 +1,151 @@
+// Package auth finds and stores the access tokens forge integrations use,
+// so each one reads credentials the same way
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gerunddev/tcr/config"
+)
+
+// Provider is a forge tcr can authenticate to
+type Provider struct {
+	Name      string
+	EnvVar    string // Environment variable holding a token
+	ConfigKey string // Legacy config setting holding a token
+	Help      string // Where to create a token
+}
+
+// Providers lists the forges tcr has integrations for
+var Providers = []Provider{
+	{Name: "github", EnvVar: "GITHUB_TOKEN", ConfigKey: "github_token",
+		Help: "Create a token at https://github.com/settings/tokens"},
+	{Name: "gitlab", EnvVar: "GITLAB_TOKEN", ConfigKey: "gitlab_token",
+		Help: "Create a token under User settings > Access tokens, with the api scope"},
+	{Name: "gerrit", EnvVar: "GERRIT_TOKEN", ConfigKey: "gerrit_token",
+		Help: "Generate an HTTP password under Settings > HTTP Credentials, and set gerrit_user"},
+	{Name: "gitea", EnvVar: "GITEA_TOKEN", ConfigKey: "gitea_token",
+		Help: "Create a token under Settings > Applications, with the repository scope"},
+	{Name: "azure", EnvVar: "AZURE_DEVOPS_EXT_PAT", ConfigKey: "azure_token",
+		Help: "Create a personal access token under User settings, with the Code (read & write) scope"},
+}
+
+// Lookup returns the provider with the given name
+func Lookup(name string) (Provider, bool) {
+	for _, p := range Providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Provider{}, false
+}
+
+// Names returns the provider names, for usage messages
+func Names() string {
+	names := make([]string, len(Providers))
+	for i, p := range Providers {
+		names[i] = p.Name
+	}
+	return strings.Join(names, "|")
+}
+
+// ErrNotFound is returned by stores without a token for a provider
+var ErrNotFound = errors.New("no token stored")
+
+// Store keeps tokens by provider name
+type Store interface {
+	Name() string
+	Get(provider string) (string, error)
+	Set(provider, token string) error
+	Delete(provider string) error
+}
+
+// Manager resolves tokens from, in order: the provider's environment
+// variable, each store, and finally the provider's config setting
+type Manager struct {
+	Getenv func(string) string
+	Stores []Store
+}
+
+// Default returns a manager using the environment, the system keychain
+// when one is available, and the credentials file
+func Default() Manager {
+	m := Manager{Getenv: os.Getenv}
+	if k, ok := systemKeychain(); ok {
+		m.Stores = append(m.Stores, k)
+	}
+	if path, err := Path(); err == nil {
+		m.Stores = append(m.Stores, FileStore{Path: path})
+	}
+	return m
+}
+
+// Token returns the token for a provider and where it came from, or ""
+// if there is none. Unreadable stores are skipped; the error of the
+// first one is returned alongside whatever token is found later.
+func (m Manager) Token(cfg config.Config, provider string) (token, source string, err error) {
+	p, ok := Lookup(provider)
+	if !ok {
+		return "", "", fmt.Errorf("unknown provider %q (want %s)", provider, Names())
+	}
+	if t := m.Getenv(p.EnvVar); t != "" {
+		return t, "$" + p.EnvVar, nil
+	}
+	var firstErr error
+	for _, s := range m.Stores {
+		t, err := s.Get(p.Name)
+		if err == nil && t != "" {
+			return t, s.Name(), firstErr
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", s.Name(), err)
+		}
+	}
+	if t := cfg.Get(p.ConfigKey); t != "" {
+		return t, "config " + p.ConfigKey, firstErr
+	}
+	return "", "", firstErr
+}
+
+// Login saves a token in the first store that accepts it and returns
+// that store's name
+func (m Manager) Login(provider, token string) (string, error) {
+	if _, ok := Lookup(provider); !ok {
+		return "", fmt.Errorf("unknown provider %q (want %s)", provider, Names())
+	}
+	var errs []error
+	for _, s := range m.Stores {
+		if err := s.Set(provider, token); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+			continue
+		}
+		return s.Name(), nil
+	}
+	if len(errs) == 0 {
+		return "", fmt.Errorf("nowhere to store credentials")
+	}
+	return "", errors.Join(errs...)
+}
+
+// Logout removes a provider's token from every store, returning the
+// names of the stores that held one
+func (m Manager) Logout(provider string) ([]string, error) {
+	if _, ok := Lookup(provider); !ok {
+		return nil, fmt.Errorf("unknown provider %q (want %s)", provider, Names())
+	}
+	var removed []string
+	var errs []error
+	for _, s := range m.Stores {
+		err := s.Delete(provider)
+		switch {
+		case err == nil:
+			removed = append(removed, s.Name())
+		case !errors.Is(err, ErrNotFound):
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+		}
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/gerunddev/tcr/config"
+)
+
+func testManager(t *testing.T, env map[string]string) (Manager, FileStore) {
+	t.Helper()
+	file := FileStore{Path: filepath.Join(t.TempDir(), "tcr", "credentials.json")}
+	return Manager{Getenv: func(k string) string { return env[k] }, Stores: []Store{file}}, file
+}
+
+func TestManager_TokenOrder(t *testing.T) {
+	cfg := config.Default()
+	cfg.GiteaToken = "from-config"
+	m, _ := testManager(t, map[string]string{})
+
+	if token, source, _ := m.Token(cfg, "gitea"); token != "from-config" || source != "config gitea_token" {
+		t.Errorf("expected the config fallback, got %q from %q", token, source)
+	}
+	if _, err := m.Login("gitea", "from-file"); err != nil {
+		t.Fatal(err)
+	}
+	if token, source, _ := m.Token(cfg, "gitea"); token != "from-file" || source != "credentials file" {
+		t.Errorf("expected the stored token, got %q from %q", token, source)
+	}
+	m.Getenv = func(k string) string {
+		if k == "GITEA_TOKEN" {
+			return "from-env"
+		}
+		return ""
+	}
+	if token, source, _ := m.Token(cfg, "gitea"); token != "from-env" || source != "$GITEA_TOKEN" {
+		t.Errorf("expected the environment to win, got %q from %q", token, source)
+	}
+	if token, _, _ := m.Token(cfg, "github"); token != "" {
+		t.Errorf("expected no github token, got %q", token)
+	}
+	if _, _, err := m.Token(cfg, "bitbucket"); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestManager_Logout(t *testing.T) {
+	m, file := testManager(t, nil)
+	if _, err := m.Login("azure", "pat"); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := m.Logout("azure")
+	if err != nil || len(removed) != 1 {
+		t.Fatalf("expected removal from the file, got %v, %v", removed, err)
+	}
+	if _, err := file.Get("azure"); err != ErrNotFound {
+		t.Errorf("expected the token gone, got %v", err)
+	}
+	if removed, err := m.Logout("azure"); err != nil || len(removed) != 0 {
+		t.Errorf("expected nothing to remove, got %v, %v", removed, err)
+	}
+}
+
+func TestFileStore_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	_, file := testManager(t, nil)
+	if err := file.Set("github", "ghp"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected 0600, got %o", perm)
+	}
+
+	os.Chmod(file.Path, 0o644)
+	if _, err := file.Get("github"); err == nil || !strings.Contains(err.Error(), "chmod 600") {
+		t.Errorf("expected a readable-by-others error, got %v", err)
+	}
+}
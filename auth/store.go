@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// service names tcr's entries in the system keychain
+const service = "tcr"
+
+// Path returns the credentials file location: $TCR_CREDENTIALS if set,
+// otherwise tcr/credentials.json under the user config directory
+func Path() (string, error) {
+	if p := os.Getenv("TCR_CREDENTIALS"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "tcr", "credentials.json"), nil
+}
+
+// FileStore keeps tokens in a JSON file only its owner may read
+type FileStore struct {
+	Path string
+}
+
+// Name describes the store
+func (f FileStore) Name() string {
+	return "credentials file"
+}
+
+// load reads the file, refusing one other users can read
+func (f FileStore) load() (map[string]string, error) {
+	tokens := map[string]string{}
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tokens, nil
+		}
+		return nil, err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("%s is accessible by other users; run chmod 600 on it", f.Path)
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", f.Path, err)
+	}
+	return tokens, nil
+}
+
+// save writes the file with 0600 permissions
+func (f FileStore) save(tokens map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path)
+}
+
+// Get returns a provider's token
+func (f FileStore) Get(provider string) (string, error) {
+	tokens, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	if t, ok := tokens[provider]; ok {
+		return t, nil
+	}
+	return "", ErrNotFound
+}
+
+// Set saves a provider's token
+func (f FileStore) Set(provider, token string) error {
+	tokens, err := f.load()
+	if err != nil {
+		return err
+	}
+	tokens[provider] = token
+	return f.save(tokens)
+}
+
+// Delete removes a provider's token
+func (f FileStore) Delete(provider string) error {
+	tokens, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[provider]; !ok {
+		return ErrNotFound
+	}
+	delete(tokens, provider)
+	return f.save(tokens)
+}
+
+// keychain stores tokens with the platform's secret store command: the
+// macOS security tool or libsecret's secret-tool
+type keychain struct {
+	name string
+	get  func(provider string) *exec.Cmd
+	set  func(provider, token string) *exec.Cmd
+	del  func(provider string) *exec.Cmd
+}
+
+// systemKeychain returns the keychain for this platform, if its command
+// is installed
+func systemKeychain() (Store, bool) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return nil, false
+		}
+		return keychain{
+			name: "macOS keychain",
+			get: func(p string) *exec.Cmd {
+				return exec.Command("security", "find-generic-password", "-s", service, "-a", p, "-w")
+			},
+			set: func(p, t string) *exec.Cmd {
+				// -w last with no value prompts for the token, which is
+				// written on stdin, entered and then retyped, so it never
+				// shows up in ps
+				cmd := exec.Command("security", "add-generic-password", "-U", "-s", service, "-a", p, "-w")
+				cmd.Stdin = strings.NewReader(t + "\n" + t + "\n")
+				return cmd
+			},
+			del: func(p string) *exec.Cmd {
+				return exec.Command("security", "delete-generic-password", "-s", service, "-a", p)
+			},
+		}, true
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, false
+		}
+		return keychain{
+			name: "secret service",
+			get: func(p string) *exec.Cmd {
+				return exec.Command("secret-tool", "lookup", "service", service, "provider", p)
+			},
+			set: func(p, t string) *exec.Cmd {
+				cmd := exec.Command("secret-tool", "store", "--label", "tcr "+p+" token", "service", service, "provider", p)
+				cmd.Stdin = strings.NewReader(t)
+				return cmd
+			},
+			del: func(p string) *exec.Cmd {
+				return exec.Command("secret-tool", "clear", "service", service, "provider", p)
+			},
+		}, true
+	}
+	return nil, false
+}
+
+// Name describes the store
+func (k keychain) Name() string {
+	return k.name
+}
+
+// Get returns a provider's token. Both tools exit non-zero when there's
+// no entry, which can't be told apart from a locked keychain, so any
+// failure counts as not found.
+func (k keychain) Get(provider string) (string, error) {
+	out, err := k.get(provider).Output()
+	if err != nil || len(out) == 0 {
+		return "", ErrNotFound
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// Set saves a provider's token
+func (k keychain) Set(provider, token string) error {
+	return run(k.set(provider, token))
+}
+
+// Delete removes a provider's token
+func (k keychain) Delete(provider string) error {
+	if _, err := k.Get(provider); err != nil {
+		return err
+	}
+	return run(k.del(provider))
+}
+
+// run runs a command, folding its stderr into the error
+func run(cmd *exec.Cmd) error {
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
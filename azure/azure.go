@@ -0,0 +1,161 @@
+// Package azure publishes tcr reviews as Azure DevOps pull request threads
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gerunddev/tcr/httpclient"
+	"github.com/gerunddev/tcr/output"
+)
+
+// apiVersion is the Azure DevOps REST API version requested
+const apiVersion = "7.1"
+
+// PR identifies a pull request
+type PR struct {
+	BaseURL string // Organization root, e.g. https://dev.azure.com/contoso
+	Project string
+	Repo    string
+	ID      int
+}
+
+// String returns the PR's web URL
+func (p PR) String() string {
+	return fmt.Sprintf("%s/%s/_git/%s/pullrequest/%d", p.BaseURL, p.Project, p.Repo, p.ID)
+}
+
+// prURL matches pull request URLs on dev.azure.com and the older
+// *.visualstudio.com hosts
+var prURL = regexp.MustCompile(`^(https://(?:dev\.azure\.com/[^/]+|[^/]+\.visualstudio\.com(?:/DefaultCollection)?))/([^/]+)/_git/([^/]+)/pullrequest/(\d+)`)
+
+// ParsePR reads a pull request from its web URL
+func ParsePR(ref string) (PR, error) {
+	m := prURL.FindStringSubmatch(ref)
+	if m == nil {
+		return PR{}, fmt.Errorf("%q is not an Azure DevOps pull request URL", ref)
+	}
+	project, err := url.PathUnescape(m[2])
+	if err != nil {
+		return PR{}, err
+	}
+	repo, err := url.PathUnescape(m[3])
+	if err != nil {
+		return PR{}, err
+	}
+	id, _ := strconv.Atoi(m[4])
+	return PR{BaseURL: m[1], Project: project, Repo: repo, ID: id}, nil
+}
+
+// Thread is a comment thread on a pull request; ThreadContext places it
+// on a file, or on lines of it, and is nil for general comments
+type Thread struct {
+	Comments      []Comment      `json:"comments"`
+	Status        string         `json:"status"`
+	ThreadContext *ThreadContext `json:"threadContext,omitempty"`
+}
+
+// Comment is the text of a thread's first comment
+type Comment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     int    `json:"commentType"` // 1 is a text comment
+}
+
+// ThreadContext anchors a thread to a file path (with a leading slash)
+// and, optionally, a line of the new version
+type ThreadContext struct {
+	FilePath       string    `json:"filePath"`
+	RightFileStart *Position `json:"rightFileStart,omitempty"`
+	RightFileEnd   *Position `json:"rightFileEnd,omitempty"`
+}
+
+// Position is a 1-based line and character offset
+type Position struct {
+	Line   int `json:"line"`
+	Offset int `json:"offset"`
+}
+
+// Threads turns tcr comments and notes into pull request threads: notes
+// become a general thread, each comment a thread on its file and line.
+// Comments labeled as issues or blockers stay active; others are posted
+// closed, as remarks that need no reply.
+func Threads(comments []output.Feedback, notes string) []Thread {
+	var threads []Thread
+	if notes = strings.TrimSpace(notes); notes != "" {
+		threads = append(threads, newThread(notes, "active", nil))
+	}
+	for _, c := range comments {
+		ctx := &ThreadContext{FilePath: "/" + strings.TrimPrefix(c.FilePath, "/")}
+		if c.Line > 0 {
+			// The range ends at the line's first character, so the whole
+			// line is highlighted without knowing its length
+			ctx.RightFileStart = &Position{Line: c.Line, Offset: 1}
+			ctx.RightFileEnd = &Position{Line: c.Line, Offset: 1}
+		}
+		status := "closed"
+		if output.Classify(c.Comment) >= output.SeverityIssue {
+			status = "active"
+		}
+		threads = append(threads, newThread(c.Comment, status, ctx))
+	}
+	return threads
+}
+
+// newThread returns a thread with one text comment
+func newThread(content, status string, ctx *ThreadContext) Thread {
+	return Thread{
+		Comments:      []Comment{{Content: content, CommentType: 1}},
+		Status:        status,
+		ThreadContext: ctx,
+	}
+}
+
+// Client posts to the Azure DevOps REST API with a personal access token
+type Client struct {
+	Token string
+	HTTP  *http.Client
+}
+
+// Publish creates each thread on the pull request, stopping at the first
+// failure. It returns how many were created.
+func (c Client) Publish(ctx context.Context, pr PR, threads []Thread) (int, error) {
+	endpoint := fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullRequests/%d/threads?api-version=%s",
+		pr.BaseURL, url.PathEscape(pr.Project), url.PathEscape(pr.Repo), pr.ID, apiVersion)
+	for i, t := range threads {
+		body, err := json.Marshal(t)
+		if err != nil {
+			return i, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return i, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.Token != "" {
+			req.SetBasicAuth("", c.Token)
+		}
+		if err := c.do(req); err != nil {
+			return i, fmt.Errorf("failed to publish thread %d of %d: %w", i+1, len(threads), err)
+		}
+	}
+	return len(threads), nil
+}
+
+// do sends a request, returning an error for unsuccessful responses
+func (c Client) do(req *http.Request) error {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return httpclient.CheckResponse(resp)
+}
@@ -0,0 +1,82 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gerunddev/tcr/output"
+)
+
+func TestParsePR(t *testing.T) {
+	tests := map[string]PR{
+		"https://dev.azure.com/contoso/Web%20Shop/_git/api/pullrequest/42":               {"https://dev.azure.com/contoso", "Web Shop", "api", 42},
+		"https://contoso.visualstudio.com/Shop/_git/api/pullrequest/7?_a=files":          {"https://contoso.visualstudio.com", "Shop", "api", 7},
+		"https://contoso.visualstudio.com/DefaultCollection/Shop/_git/api/pullrequest/8": {"https://contoso.visualstudio.com/DefaultCollection", "Shop", "api", 8},
+	}
+	for ref, want := range tests {
+		if got, err := ParsePR(ref); err != nil || got != want {
+			t.Errorf("ParsePR(%q) = %+v, %v; want %+v", ref, got, err, want)
+		}
+	}
+	if _, err := ParsePR("https://github.com/a/b/pull/1"); err == nil {
+		t.Error("expected an error for a non-Azure URL")
+	}
+}
+
+func TestThreads(t *testing.T) {
+	threads := Threads([]output.Feedback{
+		{FilePath: "src/app.cs", Line: 12, Comment: "issue: null check"},
+		{FilePath: "README.md", Comment: "nit: typo"},
+	}, "Overall fine")
+
+	if len(threads) != 3 {
+		t.Fatalf("expected a notes thread and two comment threads, got %d", len(threads))
+	}
+	if threads[0].ThreadContext != nil || threads[0].Comments[0].Content != "Overall fine" {
+		t.Errorf("notes thread %+v", threads[0])
+	}
+	line := threads[1]
+	if line.Status != "active" || line.ThreadContext.FilePath != "/src/app.cs" ||
+		*line.ThreadContext.RightFileStart != (Position{Line: 12, Offset: 1}) {
+		t.Errorf("line thread %+v", line)
+	}
+	file := threads[2]
+	if file.Status != "closed" || file.ThreadContext.RightFileStart != nil {
+		t.Errorf("file thread %+v", file)
+	}
+	data, _ := json.Marshal(file)
+	if strings.Contains(string(data), "rightFileStart") {
+		t.Errorf("file-level threads should have no position: %s", data)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var paths []string
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		_, auth, _ = r.BasicAuth()
+		if len(paths) == 2 {
+			http.Error(w, `{"message":"TF401180: The requested pull request was not found."}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer srv.Close()
+
+	c := Client{Token: "pat", HTTP: srv.Client()}
+	pr := PR{BaseURL: srv.URL, Project: "Web Shop", Repo: "api", ID: 42}
+	threads := Threads([]output.Feedback{{FilePath: "a.go", Line: 1, Comment: "x"}}, "notes")
+
+	n, err := c.Publish(context.Background(), pr, threads)
+	if n != 1 || err == nil || !strings.Contains(err.Error(), "TF401180") {
+		t.Errorf("expected to stop after the first thread with the server's error, got %d, %v", n, err)
+	}
+	if paths[0] != "/Web%20Shop/_apis/git/repositories/api/pullRequests/42/threads?api-version=7.1" || auth != "pat" {
+		t.Errorf("request %q with token %q", paths[0], auth)
+	}
+}
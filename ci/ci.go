@@ -0,0 +1,108 @@
+// Package ci turns tcr reviews into CI annotations: GitHub Actions
+// workflow commands and reviewdog's rdjson
+package ci
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gerunddev/tcr/output"
+)
+
+// title heads each GitHub Actions annotation
+const title = "tcr review"
+
+// level picks the annotation level for a comment: blockers are errors,
+// issues warnings, and everything else a notice
+func level(comment string) (actions, rdjson string) {
+	switch output.Classify(comment) {
+	case output.SeverityBlocker:
+		return "error", "ERROR"
+	case output.SeverityIssue:
+		return "warning", "WARNING"
+	}
+	return "notice", "INFO"
+}
+
+// WorkflowCommands renders comments as GitHub Actions workflow commands,
+// one line each, as in "::error file=main.go,line=12,title=tcr
+// review::message". Printed in a job's log they annotate the files, or
+// the run for a comment without a line. Notes, if any, become a notice
+// on the run.
+func WorkflowCommands(comments []output.Feedback, notes string) string {
+	var b strings.Builder
+	for _, c := range comments {
+		actions, _ := level(c.Comment)
+		props := "file=" + escapeProperty(c.FilePath)
+		if c.Line > 0 {
+			props += fmt.Sprintf(",line=%d", c.Line)
+		}
+		fmt.Fprintf(&b, "::%s %s,title=%s::%s\n", actions, props, escapeProperty(title), escapeData(c.Comment))
+	}
+	if notes = strings.TrimSpace(notes); notes != "" {
+		fmt.Fprintf(&b, "::notice title=%s::%s\n", escapeProperty(title), escapeData(notes))
+	}
+	return b.String()
+}
+
+// escapeData escapes a workflow command's message, which ends at the line
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property, which also ends at
+// a comma or colon
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// Result is a reviewdog diagnostic result, the rdjson format read by
+// reviewdog -f=rdjson
+type Result struct {
+	Source      Source       `json:"source"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Source names the tool the diagnostics came from
+type Source struct {
+	Name string `json:"name"`
+}
+
+// Diagnostic is one comment on a file, or a line of it
+type Diagnostic struct {
+	Message  string   `json:"message"`
+	Location Location `json:"location"`
+	Severity string   `json:"severity"` // ERROR, WARNING or INFO
+}
+
+// Location is a file path, relative to the repository root, and the
+// lines commented on; Range is nil for the whole file
+type Location struct {
+	Path  string `json:"path"`
+	Range *Range `json:"range,omitempty"`
+}
+
+// Range spans the lines of a comment
+type Range struct {
+	Start Position `json:"start"`
+}
+
+// Position is a 1-based line
+type Position struct {
+	Line int `json:"line"`
+}
+
+// RDJSON converts comments to reviewdog diagnostics. rdjson has nowhere
+// to put comments on no file, so notes are left out.
+func RDJSON(comments []output.Feedback) Result {
+	result := Result{Source: Source{Name: "tcr"}, Diagnostics: []Diagnostic{}}
+	for _, c := range comments {
+		_, severity := level(c.Comment)
+		d := Diagnostic{Message: c.Comment, Location: Location{Path: c.FilePath}, Severity: severity}
+		if c.Line > 0 {
+			d.Location.Range = &Range{Start: Position{Line: c.Line}}
+		}
+		result.Diagnostics = append(result.Diagnostics, d)
+	}
+	return result
+}
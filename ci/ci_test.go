@@ -0,0 +1,50 @@
+package ci
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gerunddev/tcr/output"
+)
+
+var comments = []output.Feedback{
+	{FilePath: "db/schema.sql", Line: 4, Comment: "blocker: drops the column"},
+	{FilePath: "main.go", Line: 9, Comment: "issue: 100% wrong,\nsee: the docs"},
+	{FilePath: "dir,1/README.md", Comment: "Mention the migration"},
+}
+
+func TestWorkflowCommands(t *testing.T) {
+	got := WorkflowCommands(comments, "Looks close\n")
+	want := "::error file=db/schema.sql,line=4,title=tcr review::blocker: drops the column\n" +
+		"::warning file=main.go,line=9,title=tcr review::issue: 100%25 wrong,%0Asee: the docs\n" +
+		"::notice file=dir%2C1/README.md,title=tcr review::Mention the migration\n" +
+		"::notice title=tcr review::Looks close\n"
+	if got != want {
+		t.Errorf("WorkflowCommands =\n%s\nwant\n%s", got, want)
+	}
+	if got := WorkflowCommands(nil, " "); got != "" {
+		t.Errorf("expected nothing for an empty review, got %q", got)
+	}
+}
+
+func TestRDJSON(t *testing.T) {
+	data, err := json.Marshal(RDJSON(comments))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"source":{"name":"tcr"}`,
+		`{"message":"blocker: drops the column","location":{"path":"db/schema.sql","range":{"start":{"line":4}}},"severity":"ERROR"}`,
+		`"severity":"WARNING"`,
+		`{"message":"Mention the migration","location":{"path":"dir,1/README.md"},"severity":"INFO"}`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("rdjson missing %s:\n%s", want, data)
+		}
+	}
+
+	if data, _ := json.Marshal(RDJSON(nil)); !strings.Contains(string(data), `"diagnostics":[]`) {
+		t.Errorf("expected an empty list of diagnostics, got %s", data)
+	}
+}
@@ -0,0 +1,499 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/gerunddev/tcr/highlight"
+	"github.com/gerunddev/tcr/httpclient"
+)
+
+// Config holds per-user preferences loaded from config.toml
+type Config struct {
+	Theme        string `toml:"theme"`
+	Layout       string `toml:"layout"`
+	Wrap         bool   `toml:"wrap"`
+	WordDiff     bool   `toml:"word_diff"`
+	LineAge      bool   `toml:"line_age"`
+	FileOrder    string `toml:"file_order"`
+	FormatNoise  string `toml:"format_noise"`
+	Generated    string `toml:"generated_files"`
+	ContextLines int    `toml:"context_lines"`
+	Keymap       string `toml:"keymap"`
+	OutputDir    string `toml:"output_dir"`
+	Stats        bool   `toml:"stats"`
+	CacheMB      int    `toml:"cache_mb"`
+	Compress     bool   `toml:"compress_cache"`
+	Preload      int    `toml:"preload_workers"`
+	DescribeCmd  string `toml:"describe_command"`
+	JJRevset     string `toml:"jj_base_revset,omitempty"`
+	GerritURL    string `toml:"gerrit_url,omitempty"`
+	GerritUser   string `toml:"gerrit_user,omitempty"`
+	GiteaURL     string `toml:"gitea_url,omitempty"`
+	Proxy        string `toml:"proxy,omitempty"`
+	CAFile       string `toml:"ca_file,omitempty"`
+
+	// Deprecated: tokens belong in the credential store, saved with tcr
+	// auth login. These are still read, last, from configs that have
+	// them, but can't be set and aren't in Fields.
+	GitHubToken string `toml:"github_token,omitempty"`
+	GitLabToken string `toml:"gitlab_token,omitempty"`
+	GerritToken string `toml:"gerrit_token,omitempty"`
+	GiteaToken  string `toml:"gitea_token,omitempty"`
+	AzureToken  string `toml:"azure_token,omitempty"`
+
+	// Soft limits that keep feedback digestible; going over is only
+	// pointed out, never prevented. 0 turns a limit off.
+	CommentMaxLines   int `toml:"comment_max_lines"`
+	ReviewMaxComments int `toml:"review_max_comments"`
+
+	// CommentLabels offers Conventional Comments labels in the feedback
+	// modal: off, conventional ("issue: ") or emoji ("🐛 issue: ")
+	CommentLabels string `toml:"comment_labels"`
+
+	// DiffTools maps file extensions to external diff commands, e.g.
+	// ".go" = "difft --color=always {old} {new}". It's a table edited in
+	// the file, so it isn't listed in Fields.
+	DiffTools map[string]string `toml:"diff_tools,omitempty"`
+
+	// CommentTemplates maps an extension (".sql") or a path glob
+	// ("migrations/*.sql") to prompts offered when commenting on matching
+	// files. Like DiffTools, it's only edited in the file.
+	CommentTemplates map[string][]string `toml:"comment_templates,omitempty"`
+
+	// Plugins maps plugin names to the commands that run them, e.g. lint =
+	// "python3 ~/bin/tcr-lint.py". Like DiffTools, it's only edited in the
+	// file.
+	Plugins map[string]string `toml:"plugins,omitempty"`
+
+	// Highlights maps regular expressions to the styles text matching them
+	// is drawn in, e.g. '\bunsafe\b' = "bold red". Like DiffTools, it's
+	// only edited in the file.
+	Highlights map[string]string `toml:"highlights,omitempty"`
+}
+
+// Allowed values for the enumerated settings
+var (
+	Themes           = []string{"monokai", "light"}
+	Layouts          = []string{"split", "stacked"}
+	Keymaps          = []string{"default", "vim"}
+	FileOrders       = []string{"diff", "path", "tree", "risk"}
+	FormatNoiseModes = []string{"show", "dim", "collapse"}
+	GeneratedModes   = []string{"show", "group", "collapse"}
+	LabelStyles      = []string{"off", "conventional", "emoji"}
+)
+
+// Default returns the built-in configuration
+func Default() Config {
+	return Config{
+		Theme:        "monokai",
+		Layout:       "split",
+		Wrap:         false,
+		WordDiff:     true,
+		FileOrder:    "diff",
+		FormatNoise:  "dim",
+		Generated:    "group",
+		ContextLines: 3,
+		Keymap:       "default",
+		OutputDir:    os.TempDir(),
+		CacheMB:      256,
+		Compress:     true,
+		Preload:      4,
+
+		CommentLabels: "off",
+	}
+}
+
+// Field describes one setting for documentation and the preferences UI
+type Field struct {
+	Key         string   // TOML key
+	Description string   // One-line explanation
+	Choices     []string // Allowed values, nil for free-form
+}
+
+// Fields returns the configuration schema in display order
+func Fields() []Field {
+	return []Field{
+		{Key: "theme", Description: "Color theme", Choices: Themes},
+		{Key: "layout", Description: "Panel layout: files beside or above the diff", Choices: Layouts},
+		{Key: "wrap", Description: "Wrap long diff lines instead of truncating", Choices: []string{"false", "true"}},
+		{Key: "word_diff", Description: "Highlight the words changed between a removed line and the added line replacing it", Choices: []string{"false", "true"}},
+		{Key: "line_age", Description: "Tint unchanged lines by age, from git blame: recent code brighter, code untouched for a year faded", Choices: []string{"false", "true"}},
+		{Key: "file_order", Description: "File list order: as in the diff, by path, tree (directories first, like GitHub/GitLab), or risk (riskiest first)", Choices: FileOrders},
+		{Key: "format_noise", Description: "Hunks that only change whitespace (gofmt, prettier): show normally, dim, or collapse to one line", Choices: FormatNoiseModes},
+		{Key: "generated_files", Description: "Code generated from a changed .proto (.pb.go, _pb2.py, ...): list normally, group under the .proto, or collapse into it", Choices: GeneratedModes},
+		{Key: "context_lines", Description: "Unchanged lines shown around each change", Choices: []string{"1", "3", "5", "10", "25"}},
+		{Key: "keymap", Description: "Keybinding profile", Choices: Keymaps},
+		{Key: "output_dir", Description: "Directory for generated feedback files when no path is given"},
+		{Key: "stats", Description: "Keep local usage stats for `tcr stats` (never sent anywhere)", Choices: []string{"false", "true"}},
+		{Key: "cache_mb", Description: "Memory budget for cached diffs in MiB, 0 for unlimited"},
+		{Key: "compress_cache", Description: "Store cached diffs compressed: less memory, a little CPU per access", Choices: []string{"false", "true"}},
+		{Key: "preload_workers", Description: "Diffs loaded at once in the background when searching, 0 to load them one at a time, only once a query is typed (network filesystems, slow backends)", Choices: []string{"0", "1", "4", "8"}},
+		{Key: "comment_max_lines", Description: "Point out comments longer than this many lines, 0 for no limit", Choices: []string{"0", "5", "10", "20"}},
+		{Key: "review_max_comments", Description: "Point out reviews with more comments than this, 0 for no limit", Choices: []string{"0", "10", "25", "50"}},
+		{Key: "comment_labels", Description: "Conventional Comments labels (praise:, nitpick:, issue:, ...) picked with tab in the feedback modal, optionally with emoji", Choices: LabelStyles},
+		{Key: "describe_command", Description: "Shell command that drafts change descriptions from the diff on stdin (e.g. an LLM CLI); empty uses the built-in template"},
+		{Key: "jj_base_revset", Description: "jj revset for the revision changes are diffed against; empty uses the nearest bookmark or trunk()"},
+		{Key: "gerrit_url", Description: "Gerrit server for `tcr export gerrit --publish`, e.g. https://review.example.com"},
+		{Key: "gerrit_user", Description: "Gerrit username (optional); the HTTP password is saved with `tcr auth login gerrit`"},
+		{Key: "gitea_url", Description: "Gitea/Forgejo server for owner/repo#N pull request references, e.g. https://codeberg.org"},
+		{Key: "proxy", Description: "Proxy URL for all API calls; empty honors HTTP_PROXY/HTTPS_PROXY/NO_PROXY"},
+		{Key: "ca_file", Description: "PEM bundle of extra certificate authorities to trust, for self-signed or corporate certificates"},
+	}
+}
+
+// Get returns the value of a setting as a string
+func (c Config) Get(key string) string {
+	switch key {
+	case "theme":
+		return c.Theme
+	case "layout":
+		return c.Layout
+	case "wrap":
+		return strconv.FormatBool(c.Wrap)
+	case "word_diff":
+		return strconv.FormatBool(c.WordDiff)
+	case "line_age":
+		return strconv.FormatBool(c.LineAge)
+	case "file_order":
+		return c.FileOrder
+	case "format_noise":
+		return c.FormatNoise
+	case "generated_files":
+		return c.Generated
+	case "context_lines":
+		return strconv.Itoa(c.ContextLines)
+	case "keymap":
+		return c.Keymap
+	case "output_dir":
+		return c.OutputDir
+	case "stats":
+		return strconv.FormatBool(c.Stats)
+	case "cache_mb":
+		return strconv.Itoa(c.CacheMB)
+	case "compress_cache":
+		return strconv.FormatBool(c.Compress)
+	case "preload_workers":
+		return strconv.Itoa(c.Preload)
+	case "comment_max_lines":
+		return strconv.Itoa(c.CommentMaxLines)
+	case "review_max_comments":
+		return strconv.Itoa(c.ReviewMaxComments)
+	case "comment_labels":
+		return c.CommentLabels
+	case "describe_command":
+		return c.DescribeCmd
+	case "jj_base_revset":
+		return c.JJRevset
+	case "github_token":
+		return c.GitHubToken
+	case "gitlab_token":
+		return c.GitLabToken
+	case "gerrit_url":
+		return c.GerritURL
+	case "gerrit_user":
+		return c.GerritUser
+	case "gerrit_token":
+		return c.GerritToken
+	case "gitea_url":
+		return c.GiteaURL
+	case "gitea_token":
+		return c.GiteaToken
+	case "azure_token":
+		return c.AzureToken
+	case "proxy":
+		return c.Proxy
+	case "ca_file":
+		return c.CAFile
+	}
+	return ""
+}
+
+// Set updates a setting from its string form
+func (c *Config) Set(key, value string) error {
+	switch key {
+	case "theme":
+		c.Theme = value
+	case "layout":
+		c.Layout = value
+	case "wrap":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("wrap must be true or false: %w", err)
+		}
+		c.Wrap = b
+	case "word_diff":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("word_diff must be true or false: %w", err)
+		}
+		c.WordDiff = b
+	case "line_age":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("line_age must be true or false: %w", err)
+		}
+		c.LineAge = b
+	case "file_order":
+		c.FileOrder = value
+	case "format_noise":
+		c.FormatNoise = value
+	case "generated_files":
+		c.Generated = value
+	case "context_lines":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("context_lines must be a number: %w", err)
+		}
+		c.ContextLines = n
+	case "keymap":
+		c.Keymap = value
+	case "output_dir":
+		c.OutputDir = value
+	case "stats":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("stats must be true or false: %w", err)
+		}
+		c.Stats = b
+	case "cache_mb":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("cache_mb must be a number: %w", err)
+		}
+		c.CacheMB = n
+	case "compress_cache":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("compress_cache must be true or false: %w", err)
+		}
+		c.Compress = b
+	case "preload_workers":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("preload_workers must be a number: %w", err)
+		}
+		c.Preload = n
+	case "comment_max_lines":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("comment_max_lines must be a number: %w", err)
+		}
+		c.CommentMaxLines = n
+	case "review_max_comments":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("review_max_comments must be a number: %w", err)
+		}
+		c.ReviewMaxComments = n
+	case "comment_labels":
+		c.CommentLabels = value
+	case "describe_command":
+		c.DescribeCmd = value
+	case "jj_base_revset":
+		c.JJRevset = value
+	case "gerrit_url":
+		c.GerritURL = value
+	case "gerrit_user":
+		c.GerritUser = value
+	case "gitea_url":
+		c.GiteaURL = value
+	case "github_token", "gitlab_token", "gerrit_token", "gitea_token", "azure_token":
+		return fmt.Errorf("%s is no longer a setting; save the token with tcr auth login %s", key, strings.TrimSuffix(key, "_token"))
+	case "proxy":
+		c.Proxy = value
+	case "ca_file":
+		c.CAFile = value
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// Validate checks that enumerated settings hold known values
+func (c Config) Validate() error {
+	if !contains(Themes, c.Theme) {
+		return fmt.Errorf("unknown theme %q (valid: %v)", c.Theme, Themes)
+	}
+	if !contains(Layouts, c.Layout) {
+		return fmt.Errorf("unknown layout %q (valid: %v)", c.Layout, Layouts)
+	}
+	if !contains(FileOrders, c.FileOrder) {
+		return fmt.Errorf("unknown file_order %q (valid: %v)", c.FileOrder, FileOrders)
+	}
+	if !contains(FormatNoiseModes, c.FormatNoise) {
+		return fmt.Errorf("unknown format_noise %q (valid: %v)", c.FormatNoise, FormatNoiseModes)
+	}
+	if !contains(GeneratedModes, c.Generated) {
+		return fmt.Errorf("unknown generated_files %q (valid: %v)", c.Generated, GeneratedModes)
+	}
+	if !contains(LabelStyles, c.CommentLabels) {
+		return fmt.Errorf("unknown comment_labels %q (valid: %v)", c.CommentLabels, LabelStyles)
+	}
+	if !contains(Keymaps, c.Keymap) {
+		return fmt.Errorf("unknown keymap %q (valid: %v)", c.Keymap, Keymaps)
+	}
+	if c.ContextLines < 0 {
+		return fmt.Errorf("context_lines must not be negative")
+	}
+	if c.CacheMB < 0 {
+		return fmt.Errorf("cache_mb must not be negative")
+	}
+	if c.Preload < 0 {
+		return fmt.Errorf("preload_workers must not be negative")
+	}
+	if c.CommentMaxLines < 0 {
+		return fmt.Errorf("comment_max_lines must not be negative")
+	}
+	if c.ReviewMaxComments < 0 {
+		return fmt.Errorf("review_max_comments must not be negative")
+	}
+	if c.Proxy != "" {
+		if _, err := httpclient.ParseProxy(c.Proxy); err != nil {
+			return err
+		}
+	}
+	for pattern := range c.CommentTemplates {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad comment_templates pattern %q: %w", pattern, err)
+		}
+	}
+	if _, err := highlight.Compile(c.Highlights); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TemplatesFor returns the comment prompts for a file: those keyed by its
+// extension, then by globs matching its path or base name, in key order
+// within each group and without repeats
+func (c Config) TemplatesFor(file string) []string {
+	var exts, globs []string
+	for key := range c.CommentTemplates {
+		if strings.HasPrefix(key, ".") && !strings.ContainsAny(key, "*?[/") {
+			if strings.EqualFold(path.Ext(file), key) {
+				exts = append(exts, key)
+			}
+		} else if ok, _ := path.Match(key, file); ok {
+			globs = append(globs, key)
+		} else if ok, _ := path.Match(key, path.Base(file)); ok {
+			globs = append(globs, key)
+		}
+	}
+	sort.Strings(exts)
+	sort.Strings(globs)
+
+	var prompts []string
+	seen := make(map[string]bool)
+	for _, key := range append(exts, globs...) {
+		for _, prompt := range c.CommentTemplates[key] {
+			if !seen[prompt] {
+				seen[prompt] = true
+				prompts = append(prompts, prompt)
+			}
+		}
+	}
+	return prompts
+}
+
+// Path returns the config file location: $TCR_CONFIG if set, otherwise
+// tcr/config.toml under the user config directory
+func Path() (string, error) {
+	if p := os.Getenv("TCR_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "tcr", "config.toml"), nil
+}
+
+// Exists reports whether a config file has been written yet
+func Exists() bool {
+	path, err := Path()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Load reads the config file, returning defaults if it doesn't exist
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Default(), err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads a config file, filling unset keys with defaults
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if _, err := toml.Decode(string(data), &cfg); err != nil {
+		return Default(), fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Default(), fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config to the default location
+func (c Config) Save() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return path, c.SaveFile(path)
+}
+
+// SaveFile writes the config as TOML, creating parent directories
+func (c Config) SaveFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("# tcr configuration (see `tcr help config`)\n")
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	// 0600 because the file may hold API tokens. WriteFile only sets the
+	// mode of a file it creates, so an existing one is narrowed first.
+	if err := os.Chmod(path, 0600); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to restrict config permissions: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
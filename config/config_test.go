@@ -0,0 +1,259 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFileMissingReturnsDefaults(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.toml")
+
+	cfg := Default()
+	cfg.Theme = "light"
+	cfg.Layout = "stacked"
+	cfg.Wrap = true
+	cfg.ContextLines = 10
+	cfg.Keymap = "vim"
+
+	if err := cfg.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("round trip mismatch:\n got: %+v\nwant: %+v", loaded, cfg)
+	}
+}
+
+func TestLoadFilePartialKeepsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("wrap = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Wrap {
+		t.Error("expected wrap to be loaded")
+	}
+	if cfg.Theme != Default().Theme || cfg.ContextLines != Default().ContextLines {
+		t.Errorf("unset keys should keep defaults, got %+v", cfg)
+	}
+}
+
+func TestDiffToolsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "theme = \"light\"\n\n[diff_tools]\n\".go\" = \"difft {old} {new}\"\nipynb = \"nbdiff\"\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if cfg.DiffTools[".go"] != "difft {old} {new}" || cfg.DiffTools["ipynb"] != "nbdiff" {
+		t.Errorf("unexpected diff_tools %v", cfg.DiffTools)
+	}
+
+	if err := cfg.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile failed: %v", err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile after save failed: %v", err)
+	}
+	if len(loaded.DiffTools) != 2 || loaded.Theme != "light" {
+		t.Errorf("round trip lost settings: %+v", loaded)
+	}
+}
+
+func TestTemplatesFor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[comment_templates]
+".sql" = ["Is this backwards compatible?", "Does it need an index?"]
+"migrations/*.sql" = ["Can it run without locking the table?", "Is this backwards compatible?"]
+"Dockerfile" = ["Is the base image pinned?"]
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	tests := map[string][]string{
+		"migrations/001_init.SQL": {"Is this backwards compatible?", "Does it need an index?"},
+		"migrations/002_users.sql": {
+			"Is this backwards compatible?", "Does it need an index?", "Can it run without locking the table?",
+		},
+		"build/Dockerfile": {"Is the base image pinned?"},
+		"main.go":          nil,
+	}
+	for file, want := range tests {
+		if got := cfg.TemplatesFor(file); !reflect.DeepEqual(got, want) {
+			t.Errorf("TemplatesFor(%q) = %q, want %q", file, got, want)
+		}
+	}
+
+	cfg.CommentTemplates["[bad"] = []string{"x"}
+	if cfg.Validate() == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"bad toml", "theme = ", "failed to parse"},
+		{"unknown theme", `theme = "neon"`, "unknown theme"},
+		{"unknown layout", `layout = "grid"`, "unknown layout"},
+		{"negative context", "context_lines = -1", "must not be negative"},
+		{"negative preload", "preload_workers = -2", "must not be negative"},
+		{"bad highlight", "[highlights]\n'panic(' = \"bold\"", "bad highlight pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("expected error containing %q, got %v", tt.errMsg, err)
+			}
+			if !reflect.DeepEqual(cfg, Default()) {
+				t.Errorf("invalid config should fall back to defaults, got %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	cfg := Default()
+
+	for _, f := range Fields() {
+		for _, choice := range f.Choices {
+			if err := cfg.Set(f.Key, choice); err != nil {
+				t.Errorf("Set(%q, %q) failed: %v", f.Key, choice, err)
+			}
+			if got := cfg.Get(f.Key); got != choice {
+				t.Errorf("Get(%q) = %q, want %q", f.Key, got, choice)
+			}
+		}
+	}
+
+	if err := cfg.Set("nope", "x"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	if err := cfg.Set("wrap", "maybe"); err == nil {
+		t.Error("expected error for invalid bool")
+	}
+}
+
+func TestTokensAreNotSettings(t *testing.T) {
+	for _, f := range Fields() {
+		if strings.HasSuffix(f.Key, "_token") {
+			t.Errorf("Fields() lists %s; tokens belong in tcr auth login", f.Key)
+		}
+	}
+
+	cfg := Default()
+	if err := cfg.Set("github_token", "ghp_x"); err == nil || !strings.Contains(err.Error(), "tcr auth login github") {
+		t.Errorf("Set(github_token) = %v, want an error pointing at tcr auth login", err)
+	}
+
+	// Older configs still supply the token through Get
+	cfg.GitHubToken = "ghp_old"
+	if got := cfg.Get("github_token"); got != "ghp_old" {
+		t.Errorf("Get(github_token) = %q, want the legacy value", got)
+	}
+}
+
+func TestPathHonorsEnv(t *testing.T) {
+	t.Setenv("TCR_CONFIG", "/custom/tcr.toml")
+	path, err := Path()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/custom/tcr.toml" {
+		t.Errorf("expected TCR_CONFIG path, got %q", path)
+	}
+}
+
+func TestExists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	t.Setenv("TCR_CONFIG", path)
+
+	if Exists() {
+		t.Error("config should not exist yet")
+	}
+	if err := Default().SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if !Exists() {
+		t.Error("config should exist after saving")
+	}
+}
+
+func TestSaveFileIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+
+	cfg := Default()
+	cfg.GitHubToken = "secret"
+	if err := cfg.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected 0600 permissions, got %o", perm)
+	}
+}
+
+func TestSaveFileNarrowsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Default()
+	cfg.GitHubToken = "secret"
+	if err := cfg.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected an existing config narrowed to 0600, got %o", perm)
+	}
+}
@@ -0,0 +1,167 @@
+// Package crash records panic reports and the session state needed to
+// resume a review after tcr crashes
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resumeFile is the name of the pending-resume marker inside the crash dir
+const resumeFile = "resume.json"
+
+// State is the part of a review session needed to pick it up again
+type State struct {
+	OutputPath  string `json:"output_path"`
+	VCS         string `json:"vcs"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line"`
+	FilterQuery string `json:"filter_query,omitempty"`
+	SearchQuery string `json:"search_query,omitempty"`
+	FileCount   int    `json:"file_count"`
+}
+
+// Report describes a single crash
+type Report struct {
+	Time     time.Time `json:"time"`
+	Version  string    `json:"version"`
+	Panic    string    `json:"panic"`
+	Stack    string    `json:"stack"`
+	Messages []string  `json:"messages"`
+	State    State     `json:"state"`
+
+	// Path is where the human-readable report was written
+	Path string `json:"path"`
+}
+
+// Dir returns the directory crash reports are written to
+func Dir() (string, error) {
+	if dir := os.Getenv("TCR_CRASH_DIR"); dir != "" {
+		return dir, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate cache directory: %w", err)
+	}
+	return filepath.Join(cache, "tcr", "crashes"), nil
+}
+
+// Write saves a human-readable report plus a resume marker in dir and
+// returns the report path
+func Write(dir string, r *Report) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	r.Path = filepath.Join(dir, "crash-"+r.Time.Format("20060102-150405")+".log")
+	if err := os.WriteFile(r.Path, []byte(r.Format()), 0600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, resumeFile), data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write resume state: %w", err)
+	}
+	return r.Path, nil
+}
+
+// Format renders the report as plain text
+func (r *Report) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tcr crash report\n\n")
+	fmt.Fprintf(&b, "Time:    %s\n", r.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version: %s\n", r.Version)
+	fmt.Fprintf(&b, "Panic:   %s\n\n", r.Panic)
+
+	fmt.Fprintf(&b, "Session:\n")
+	fmt.Fprintf(&b, "  vcs:          %s\n", r.State.VCS)
+	fmt.Fprintf(&b, "  output:       %s\n", r.State.OutputPath)
+	fmt.Fprintf(&b, "  files:        %d\n", r.State.FileCount)
+	fmt.Fprintf(&b, "  current file: %s\n", r.State.File)
+	fmt.Fprintf(&b, "  cursor line:  %d\n", r.State.Line)
+	if r.State.FilterQuery != "" {
+		fmt.Fprintf(&b, "  name filter:  %s\n", r.State.FilterQuery)
+	}
+	if r.State.SearchQuery != "" {
+		fmt.Fprintf(&b, "  search:       %s\n", r.State.SearchQuery)
+	}
+
+	fmt.Fprintf(&b, "\nRecent messages (oldest first):\n")
+	for _, m := range r.Messages {
+		fmt.Fprintf(&b, "  %s\n", m)
+	}
+
+	fmt.Fprintf(&b, "\nStack:\n%s\n", r.Stack)
+	return b.String()
+}
+
+// LoadResume returns the report left by the last crash, or nil if the
+// previous session exited cleanly
+func LoadResume(dir string) (*Report, error) {
+	data, err := os.ReadFile(filepath.Join(dir, resumeFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid resume state: %w", err)
+	}
+	return &r, nil
+}
+
+// ClearResume removes the resume marker so the offer is made only once
+func ClearResume(dir string) error {
+	err := os.Remove(filepath.Join(dir, resumeFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Recorder keeps the most recent messages in a fixed-size ring
+type Recorder struct {
+	mu   sync.Mutex
+	buf  []string
+	next int
+	full bool
+}
+
+// NewRecorder creates a recorder holding up to n entries
+func NewRecorder(n int) *Recorder {
+	return &Recorder{buf: make([]string, n)}
+}
+
+// Add records an entry, evicting the oldest when full
+func (r *Recorder) Add(entry string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.buf) == 0 {
+		return
+	}
+	r.buf[r.next] = entry
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Recent returns recorded entries, oldest first
+func (r *Recorder) Recent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]string(nil), r.buf[:r.next]...)
+	}
+	return append(append([]string(nil), r.buf[r.next:]...), r.buf[:r.next]...)
+}
@@ -0,0 +1,79 @@
+package crash
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(3)
+	if got := r.Recent(); len(got) != 0 {
+		t.Fatalf("expected empty recorder, got %v", got)
+	}
+
+	r.Add("a")
+	r.Add("b")
+	if got := r.Recent(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("expected [a b], got %v", got)
+	}
+
+	r.Add("c")
+	r.Add("d")
+	r.Add("e")
+	if got := r.Recent(); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Errorf("expected [c d e], got %v", got)
+	}
+}
+
+func TestWriteAndResume(t *testing.T) {
+	dir := t.TempDir()
+
+	if r, err := LoadResume(dir); err != nil || r != nil {
+		t.Fatalf("expected no pending resume, got %v, %v", r, err)
+	}
+
+	report := &Report{
+		Time:     time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Version:  "v1.0.0",
+		Panic:    "index out of range",
+		Stack:    "goroutine 1 [running]:",
+		Messages: []string{"tea.KeyMsg j"},
+		State:    State{OutputPath: "/tmp/out.md", VCS: "git", File: "main.go", Line: 12},
+	}
+	path, err := Write(dir, report)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"index out of range", "main.go", "tea.KeyMsg j", "goroutine 1"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+
+	got, err := LoadResume(dir)
+	if err != nil {
+		t.Fatalf("LoadResume failed: %v", err)
+	}
+	if got == nil || got.State != report.State || got.Path != path {
+		t.Errorf("unexpected resume state: %+v", got)
+	}
+
+	if err := ClearResume(dir); err != nil {
+		t.Fatalf("ClearResume failed: %v", err)
+	}
+	if r, _ := LoadResume(dir); r != nil {
+		t.Error("expected resume marker to be cleared")
+	}
+	// Clearing twice is fine
+	if err := ClearResume(dir); err != nil {
+		t.Errorf("second ClearResume failed: %v", err)
+	}
+}
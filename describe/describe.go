@@ -0,0 +1,133 @@
+// Package describe drafts a commit or PR description from a set of diffs
+package describe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// File is one changed file's input to a draft
+type File struct {
+	Path   string
+	Status vcs.FileStatus
+	Diff   string
+}
+
+// maxContexts caps how many hunk contexts (enclosing functions) are listed
+// per file
+const maxContexts = 3
+
+// Draft builds a template description: a summary line to edit, then one
+// line per file with its added/removed line counts and the functions its
+// hunks touch
+func Draft(files []File) string {
+	var b strings.Builder
+	b.WriteString(summary(files))
+	b.WriteString("\n\nChanges:\n")
+
+	for _, f := range files {
+		added, removed, contexts := scan(f.Diff)
+		fmt.Fprintf(&b, "- %s %s (+%d -%d)", f.Status, f.Path, added, removed)
+		if len(contexts) > maxContexts {
+			contexts = append(contexts[:maxContexts], "...")
+		}
+		if len(contexts) > 0 {
+			b.WriteString(": " + strings.Join(contexts, ", "))
+		}
+		b.WriteByte('\n')
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// summary names the single file changed, or counts the files and the
+// directories they're in
+func summary(files []File) string {
+	switch len(files) {
+	case 0:
+		return "No changes"
+	case 1:
+		return "Update " + files[0].Path
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, f := range files {
+		dir := path.Dir(f.Path)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	if len(dirs) > 3 {
+		return fmt.Sprintf("Update %d files in %d directories", len(files), len(dirs))
+	}
+	return fmt.Sprintf("Update %d files in %s", len(files), strings.Join(dirs, ", "))
+}
+
+// scan counts added and removed lines in a unified diff and collects the
+// distinct hunk contexts (the text after the closing @@)
+func scan(diff string) (added, removed int, contexts []string) {
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		case strings.HasPrefix(line, "@@"):
+			end := strings.Index(line[2:], "@@")
+			if end < 0 {
+				continue
+			}
+			ctx := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line[end+4:]), "{"))
+			if ctx != "" && !seen[ctx] {
+				seen[ctx] = true
+				contexts = append(contexts, ctx)
+			}
+		}
+	}
+	return added, removed, contexts
+}
+
+// External runs a user-configured command (such as an LLM CLI) through the
+// shell to write the description. It receives the template draft followed
+// by the full diffs on stdin and its stdout becomes the description.
+func External(ctx context.Context, command, draft string, files []File) (string, error) {
+	var input bytes.Buffer
+	input.WriteString(draft)
+	input.WriteString("\n\n")
+	for _, f := range files {
+		input.WriteString(f.Diff)
+		if !strings.HasSuffix(f.Diff, "\n") {
+			input.WriteByte('\n')
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = &input
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", command, msg)
+		}
+		return "", fmt.Errorf("%s: %w", command, err)
+	}
+
+	description := strings.TrimSpace(string(out))
+	if description == "" {
+		return "", fmt.Errorf("%s produced no description", command)
+	}
+	return description, nil
+}
@@ -0,0 +1,65 @@
+package describe
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gerunddev/tcr/vcs"
+)
+
+func TestDraft(t *testing.T) {
+	files := []File{
+		{
+			Path:   "ui/app.go",
+			Status: vcs.StatusModified,
+			Diff: "--- a/ui/app.go\n+++ b/ui/app.go\n" +
+				"@@ -10,3 +10,4 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {\n" +
+				" keep\n-old\n+new\n+more\n" +
+				"@@ -40,2 +41,2 @@ func (a *App) View() string {\n" +
+				"-x\n+y\n" +
+				"@@ -50,2 +51,2 @@ func (a *App) View() string {\n" +
+				"-x\n+y\n",
+		},
+		{
+			Path:   "ui/panels/new.go",
+			Status: vcs.StatusAdded,
+			Diff:   "@@ -0,0 +1,2 @@\n+package panels\n+\n",
+		},
+	}
+
+	want := "Update 2 files in ui, ui/panels\n\n" +
+		"Changes:\n" +
+		"- M ui/app.go (+4 -3): func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd), func (a *App) View() string\n" +
+		"- A ui/panels/new.go (+2 -0)"
+	if got := Draft(files); got != want {
+		t.Errorf("unexpected draft:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDraft_SingleFile(t *testing.T) {
+	got := Draft([]File{{Path: "main.go", Status: vcs.StatusModified}})
+	if !strings.HasPrefix(got, "Update main.go\n") {
+		t.Errorf("expected the file named in the summary, got %q", got)
+	}
+}
+
+func TestExternal(t *testing.T) {
+	files := []File{{Path: "a.go", Diff: "+added line"}}
+
+	// The command sees the draft and the diffs on stdin
+	got, err := External(context.Background(), "grep -c .", "Draft", files)
+	if err != nil {
+		t.Fatalf("External failed: %v", err)
+	}
+	if got != "2" {
+		t.Errorf("expected the command to read 2 non-empty lines, got %q", got)
+	}
+
+	if _, err := External(context.Background(), "echo broken >&2; exit 1", "Draft", files); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected the command's stderr in the error, got %v", err)
+	}
+	if _, err := External(context.Background(), "true", "Draft", files); err == nil {
+		t.Error("expected an error for empty output")
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gerunddev/tcr/azure"
+	"github.com/gerunddev/tcr/ci"
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/gerrit"
+	"github.com/gerunddev/tcr/gitea"
+	"github.com/gerunddev/tcr/github"
+	"github.com/gerunddev/tcr/httpclient"
+	"github.com/gerunddev/tcr/output"
+	"github.com/gerunddev/tcr/queue"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+const exportUsage = `Usage:
+  tcr export gerrit <review.md> [--publish] [--change ID] [--revision REV]
+  tcr export gitea <review.md> [--publish --pr PR]
+  tcr export azure <review.md> [--publish --pr URL]
+  tcr export github-actions <review.md>
+  tcr export rdjson <review.md>
+
+Converts a tcr review file into the forge's review JSON and prints it.
+With --publish, posts it instead:
+  gerrit  to gerrit_url, on the change named by the Change-Id trailer of
+          HEAD unless --change is given
+  gitea   on the pull request given by --pr, as a URL or owner/repo#N
+          on gitea_url
+  azure   on the Azure DevOps pull request at the --pr URL
+
+github-actions prints GitHub Actions workflow commands, which annotate the
+files when printed in a job's log, and rdjson prints reviewdog diagnostics
+for reviewdog -f=rdjson. Blockers are errors, issues warnings, and other
+comments notices.
+
+Tokens come from tcr auth login (see tcr auth). A review that can't be
+published because the network or the server is down is queued; run
+tcr publish --retry to send it later.
+`
+
+// exportFlags are the options shared by the export subcommands
+type exportFlags struct {
+	reviewPath string
+	publish    bool
+	values     map[string]string // --name value options
+}
+
+// parseExportFlags reads "<review.md> [--publish] [--name value]..." where
+// names are the value options the exporter accepts
+func parseExportFlags(args []string, names ...string) (exportFlags, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "--") {
+		return exportFlags{}, false
+	}
+	f := exportFlags{reviewPath: args[0], values: make(map[string]string)}
+	for rest := args[1:]; len(rest) > 0; rest = rest[1:] {
+		name := strings.TrimPrefix(rest[0], "--")
+		switch {
+		case rest[0] == "--publish":
+			f.publish = true
+		case strings.HasPrefix(rest[0], "--") && contains(names, name) && len(rest) > 1:
+			f.values[name] = rest[1]
+			rest = rest[1:]
+		default:
+			return exportFlags{}, false
+		}
+	}
+	return f, true
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// runExport implements "tcr export <forge>": it turns a review file into
+// the forge's inline-comment JSON, and optionally publishes it
+func runExport(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "gerrit":
+			return exportGerrit(args[1:])
+		case "gitea":
+			return exportGitea(args[1:])
+		case "azure":
+			return exportAzure(args[1:])
+		case "github-actions", "rdjson":
+			return exportCI(args[0], args[1:])
+		}
+	}
+	fmt.Fprint(os.Stderr, exportUsage)
+	return 1
+}
+
+// readReview parses a review file, reporting any error
+func readReview(path string) ([]output.Feedback, string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, "", false
+	}
+	comments, notes := output.ParseFeedback(string(data))
+	return comments, notes, true
+}
+
+// printJSON writes v indented to stdout and returns the exit code
+func printJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// exportGerrit implements "tcr export gerrit"
+func exportGerrit(args []string) int {
+	f, ok := parseExportFlags(args, "change", "revision")
+	if !ok {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return 1
+	}
+	comments, notes, ok := readReview(f.reviewPath)
+	if !ok {
+		return 1
+	}
+	review := gerrit.FromFeedback(comments, notes)
+	if !f.publish {
+		return printJSON(review)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if cfg.GerritURL == "" {
+		fmt.Fprintf(os.Stderr, "Error: set gerrit_url in the config to publish (see tcr help config)\n")
+		return 1
+	}
+	change, revision := f.values["change"], f.values["revision"]
+	if revision == "" {
+		revision = "current"
+	}
+	if change == "" {
+		msg, err := exec.Command("git", "log", "-1", "--format=%B").Output()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: reading HEAD's commit message: %v (pass --change)\n", err)
+			return 1
+		}
+		id, ok := gerrit.ChangeID(string(msg))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: HEAD has no Change-Id trailer (pass --change)\n")
+			return 1
+		}
+		change = id
+	}
+
+	r, err := queue.NewReview("gerrit", change, revision, review)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return publishReview(cfg, r, fmt.Sprintf("%d comment(s) to %s/c/%s", len(comments), strings.TrimRight(cfg.GerritURL, "/"), change))
+}
+
+// exportGitea implements "tcr export gitea"
+func exportGitea(args []string) int {
+	f, ok := parseExportFlags(args, "pr")
+	if !ok || (f.publish && f.values["pr"] == "") {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return 1
+	}
+	comments, notes, ok := readReview(f.reviewPath)
+	if !ok {
+		return 1
+	}
+	review := gitea.FromFeedback(comments, notes)
+	if !f.publish {
+		return printJSON(review)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	pr, err := gitea.ParsePR(f.values["pr"], cfg.GiteaURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	r, err := queue.NewReview("gitea", pr.URL(), "", review)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return publishReview(cfg, r, fmt.Sprintf("%d comment(s) on %s", len(comments), pr))
+}
+
+// exportAzure implements "tcr export azure"
+func exportAzure(args []string) int {
+	f, ok := parseExportFlags(args, "pr")
+	if !ok || (f.publish && f.values["pr"] == "") {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return 1
+	}
+	comments, notes, ok := readReview(f.reviewPath)
+	if !ok {
+		return 1
+	}
+	threads := azure.Threads(comments, notes)
+	if !f.publish {
+		return printJSON(threads)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	pr, err := azure.ParsePR(f.values["pr"])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// One part per thread, so a retry resumes after those already posted
+	parts := make([]any, len(threads))
+	for i, t := range threads {
+		parts[i] = t
+	}
+	r, err := queue.NewReview("azure", pr.String(), "", parts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return publishReview(cfg, r, fmt.Sprintf("%d thread(s) on %s", len(threads), pr))
+}
+
+// exportCI implements "tcr export github-actions" and "tcr export
+// rdjson", which only print
+func exportCI(format string, args []string) int {
+	f, ok := parseExportFlags(args)
+	if !ok || f.publish {
+		fmt.Fprint(os.Stderr, exportUsage)
+		return 1
+	}
+	comments, notes, ok := readReview(f.reviewPath)
+	if !ok {
+		return 1
+	}
+	if format == "rdjson" {
+		return printJSON(ci.RDJSON(comments))
+	}
+	fmt.Print(ci.WorkflowCommands(comments, notes))
+	return 0
+}
+
+// fetchGerritChange downloads a change from gerrit_url to review
+func fetchGerritChange(cfg config.Config, change string) (vcs.VCS, error) {
+	if cfg.GerritURL == "" {
+		return nil, fmt.Errorf("set gerrit_url in the config to review Gerrit changes (see tcr help config)")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, err := gerritClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.Fetch(ctx, change)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// fetchGiteaPR downloads a pull request from a Gitea or Forgejo server
+func fetchGiteaPR(cfg config.Config, ref string) (vcs.VCS, error) {
+	pr, err := gitea.ParsePR(ref, cfg.GiteaURL)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, err := giteaClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p, err := client.Fetch(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// fetchGitHubPR downloads a GitHub pull request through gh when it's
+// installed, or else the REST API with the repository of the origin remote
+func fetchGitHubPR(cfg config.Config, ref string) (vcs.VCS, error) {
+	pr, err := github.ParsePR(ref)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if github.Available() {
+		return github.FetchWithGH(ctx, pr)
+	}
+	if pr.Owner == "" {
+		remote, err := exec.Command("git", "remote", "get-url", "origin").Output()
+		if err != nil {
+			return nil, fmt.Errorf("no origin remote to find %s in; give it as owner/repo#%d or install gh", pr, pr.Number)
+		}
+		if pr.Owner, pr.Repo, err = github.ParseRemote(string(remote)); err != nil {
+			return nil, err
+		}
+	}
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := github.Client{BaseURL: github.DefaultAPI, Token: forgeToken(cfg, "github"), HTTP: httpClient}
+	return client.Fetch(ctx, pr)
+}
+
+// gerritClient returns a client for the configured Gerrit server
+func gerritClient(cfg config.Config) (gerrit.Client, error) {
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return gerrit.Client{}, err
+	}
+	return gerrit.Client{
+		BaseURL:  cfg.GerritURL,
+		User:     cfg.GerritUser,
+		Password: forgeToken(cfg, "gerrit"),
+		HTTP:     httpClient,
+	}, nil
+}
+
+// giteaClient returns a Gitea API client with the configured token
+func giteaClient(cfg config.Config) (gitea.Client, error) {
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return gitea.Client{}, err
+	}
+	return gitea.Client{Token: forgeToken(cfg, "gitea"), HTTP: httpClient}, nil
+}
+
+// newHTTPClient returns an HTTP client using the configured proxy and
+// certificate authorities
+func newHTTPClient(cfg config.Config) (*http.Client, error) {
+	return httpclient.New(httpclient.Options{Proxy: cfg.Proxy, CAFile: cfg.CAFile})
+}
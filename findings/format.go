@@ -0,0 +1,117 @@
+package findings
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Range is a span of diff lines, from Start up to but not including End
+type Range struct {
+	Start, End int
+}
+
+// hunkHeader captures the old and new line counts of "@@ -a,b +c,d @@"
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// ansiSGR matches terminal color sequences some VCSes add to diffs
+var ansiSGR = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// FormatOnly returns the hunks of a unified diff whose changes disappear
+// when whitespace is ignored: re-indentation, alignment, re-wrapping and
+// blank lines, as left by gofmt or prettier. Each range runs from the hunk
+// header to the hunk's last line.
+func FormatOnly(diff string) []Range {
+	lines := strings.Split(ansiSGR.ReplaceAllString(diff, ""), "\n")
+
+	var result []Range
+	for i := 0; i < len(lines); i++ {
+		m := hunkHeader.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		oldLeft, newLeft := hunkCount(m[1]), hunkCount(m[2])
+
+		start := i
+		var removed, added strings.Builder
+		changed := false
+		for i+1 < len(lines) && (oldLeft > 0 || newLeft > 0) {
+			line := lines[i+1]
+			if strings.HasPrefix(line, "@@") {
+				break // A hunk folded short of its counts
+			}
+			if line == "" && i+2 == len(lines) {
+				break // Trailing newline of the whole diff
+			}
+			i++
+			switch {
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file" isn't counted
+			case strings.HasPrefix(line, "-"):
+				removed.WriteString(line[1:])
+				oldLeft--
+				changed = true
+			case strings.HasPrefix(line, "+"):
+				added.WriteString(line[1:])
+				newLeft--
+				changed = true
+			default:
+				oldLeft--
+				newLeft--
+			}
+		}
+		// A "\ No newline at end of file" after the last line belongs to the hunk
+		for i+1 < len(lines) && strings.HasPrefix(lines[i+1], `\`) {
+			i++
+		}
+
+		if changed && stripSpace(removed.String()) == stripSpace(added.String()) {
+			result = append(result, Range{Start: start, End: i + 1})
+		}
+	}
+	return result
+}
+
+// hunkCount parses a hunk header line count, which defaults to 1
+func hunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// stripSpace removes all whitespace from s
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// CollapseRanges replaces the body of each range with one line saying how
+// many formatting-only lines were hidden, keeping the hunk header. It
+// returns the new diff and where the collapsed hunks now are.
+func CollapseRanges(diff string, ranges []Range) (string, []Range) {
+	if len(ranges) == 0 {
+		return diff, nil
+	}
+	lines := strings.Split(diff, "\n")
+
+	var out []string
+	var collapsed []Range
+	next := 0
+	for _, r := range ranges {
+		out = append(out, lines[next:r.Start+1]...)
+		start := len(out) - 1
+		out = append(out, fmt.Sprintf(" ⋯ %d formatting-only lines", r.End-r.Start-1))
+		collapsed = append(collapsed, Range{Start: start, End: len(out)})
+		next = r.End
+	}
+	out = append(out, lines[next:]...)
+	return strings.Join(out, "\n"), collapsed
+}
@@ -0,0 +1,86 @@
+package findings
+
+import (
+	"strings"
+	"testing"
+)
+
+const mixedDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@
+ func main() {
+-    x := 1
++	x := 1
+ 	run(x)
+ }
+@@ -10,3 +10,3 @@ func run(x int) {
+ 	if x > 0 {
+-		return
++		panic(x)
+ 	}
+@@ -20,2 +20,3 @@ func wrap() {
+-	call(a, b)
++	call(a,
++		b)
+ }`
+
+func TestFormatOnly(t *testing.T) {
+	got := FormatOnly(mixedDiff)
+	want := []Range{{Start: 3, End: 9}, {Start: 14, End: 19}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("range %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFormatOnly_BlankLinesAndNoNewline(t *testing.T) {
+	diff := "@@ -1,2 +1,3 @@\n a\n+\n b\n\\ No newline at end of file\n@@ -9 +10 @@\n-x\n+y\n"
+	got := FormatOnly(diff)
+	if len(got) != 1 || got[0] != (Range{Start: 0, End: 5}) {
+		t.Errorf("got %v, want only the blank-line hunk", got)
+	}
+}
+
+func TestFormatOnly_FoldedHunk(t *testing.T) {
+	diff := "@@ -1,5 +1,5 @@\n ⋯ 6 lines folded\n@@ -9 +9 @@\n-a  =  1\n+a = 1\n"
+	got := FormatOnly(diff)
+	if len(got) != 1 || got[0] != (Range{Start: 2, End: 5}) {
+		t.Errorf("got %v, want the hunk after the folded one", got)
+	}
+}
+
+func TestFormatOnly_IgnoresColor(t *testing.T) {
+	diff := "@@ -1 +1 @@\n\x1b[31m-a  =  1\x1b[0m\n\x1b[32m+a = 1\x1b[0m"
+	if got := FormatOnly(diff); len(got) != 1 {
+		t.Errorf("expected the colored hunk to be format-only, got %v", got)
+	}
+}
+
+func TestCollapseRanges(t *testing.T) {
+	out, collapsed := CollapseRanges(mixedDiff, FormatOnly(mixedDiff))
+	lines := strings.Split(out, "\n")
+
+	if lines[3] != "@@ -1,4 +1,4 @@" || lines[4] != " ⋯ 5 formatting-only lines" {
+		t.Errorf("first hunk not collapsed:\n%s", out)
+	}
+	if !strings.Contains(out, "+\t\tpanic(x)") {
+		t.Errorf("substantive hunk should be kept:\n%s", out)
+	}
+	if lines[len(lines)-1] != " ⋯ 4 formatting-only lines" {
+		t.Errorf("last hunk not collapsed:\n%s", out)
+	}
+
+	if len(collapsed) != 2 || collapsed[0] != (Range{Start: 3, End: 5}) {
+		t.Errorf("collapsed ranges = %v", collapsed)
+	}
+	for _, r := range collapsed {
+		if !strings.HasPrefix(lines[r.Start], "@@") {
+			t.Errorf("range %v doesn't start at a hunk header", r)
+		}
+	}
+}
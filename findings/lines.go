@@ -0,0 +1,46 @@
+package findings
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkStarts captures the first old and new line numbers of a hunk header
+var hunkStarts = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// LineIndexes maps the file line numbers in a unified diff to the diff
+// lines showing them: old for lines before the change (context and
+// removed), new for lines after it (context and added)
+func LineIndexes(diff string) (old, new map[int]int) {
+	old, new = make(map[int]int), make(map[int]int)
+	oldLine, newLine := 0, 0
+	inHunk := false
+	for i, line := range strings.Split(ansiSGR.ReplaceAllString(diff, ""), "\n") {
+		if m := hunkStarts.FindStringSubmatch(line); m != nil {
+			oldLine, _ = strconv.Atoi(m[1])
+			newLine, _ = strconv.Atoi(m[2])
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			inHunk = false
+		case strings.HasPrefix(line, "-"):
+			old[oldLine] = i
+			oldLine++
+		case strings.HasPrefix(line, "+"):
+			new[newLine] = i
+			newLine++
+		case strings.HasPrefix(line, " "):
+			old[oldLine] = i
+			new[newLine] = i
+			oldLine++
+			newLine++
+		}
+	}
+	return old, new
+}
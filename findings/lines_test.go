@@ -0,0 +1,33 @@
+package findings
+
+import "testing"
+
+func TestLineIndexes(t *testing.T) {
+	diff := "diff --git a/x b/x\n--- a/x\n+++ b/x\n" +
+		"@@ -10,3 +10,3 @@ func f() {\n" + // 3
+		" a\n" + // 4: old 10, new 10
+		"-b\n" + // 5: old 11
+		"+B\n" + // 6: new 11
+		" c\n" + // 7: old 12, new 12
+		"@@ -40 +40,2 @@\n" + // 8
+		" d\n" + // 9: old 40, new 40
+		"+e\n" + // 10: new 41
+		"\\ No newline at end of file"
+	old, new := LineIndexes(diff)
+
+	wantOld := map[int]int{10: 4, 11: 5, 12: 7, 40: 9}
+	wantNew := map[int]int{10: 4, 11: 6, 12: 7, 40: 9, 41: 10}
+	for line, idx := range wantOld {
+		if old[line] != idx {
+			t.Errorf("old line %d at %d, want %d", line, old[line], idx)
+		}
+	}
+	for line, idx := range wantNew {
+		if new[line] != idx {
+			t.Errorf("new line %d at %d, want %d", line, new[line], idx)
+		}
+	}
+	if len(old) != len(wantOld) || len(new) != len(wantNew) {
+		t.Errorf("extra lines mapped: old %v new %v", old, new)
+	}
+}
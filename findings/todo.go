@@ -0,0 +1,45 @@
+package findings
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// todoMarker matches the markers left for work still to do
+var todoMarker = regexp.MustCompile(`\b(?:TODO|FIXME)\b`)
+
+// Markers counts the TODO and FIXME markers a change adds and removes
+type Markers struct {
+	Added, Removed int
+}
+
+// String shows the counts as "TODO +2 −1", or "" when there are none
+func (m Markers) String() string {
+	if m == (Markers{}) {
+		return ""
+	}
+	return fmt.Sprintf("TODO +%d −%d", m.Added, m.Removed)
+}
+
+// TODOs counts the TODO and FIXME markers on the added and removed lines
+// of a unified diff. A marker only moved, or on a line only edited, is
+// counted both ways.
+func TODOs(diff string) Markers {
+	var m Markers
+	inHunk := false
+	for _, line := range strings.Split(ansiSGR.ReplaceAllString(diff, ""), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case strings.HasPrefix(line, "diff "):
+			inHunk = false
+		case !inHunk:
+		case strings.HasPrefix(line, "+"):
+			m.Added += len(todoMarker.FindAllStringIndex(line, -1))
+		case strings.HasPrefix(line, "-"):
+			m.Removed += len(todoMarker.FindAllStringIndex(line, -1))
+		}
+	}
+	return m
+}
@@ -0,0 +1,32 @@
+package findings
+
+import "testing"
+
+func TestTODOs(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ // TODO: context lines aren't counted
+-// TODO: handle errors
+-// FIXME: and this; TODO: that
++handle(err)
++// TODO(ann): retry
+ // TODOS and FIXMEs aren't markers
+diff --git a/notes.md b/notes.md
+--- a/notes.md
++++ b/notes.md
+@@ -1 +1 @@
+-plain
++FIXME later`
+
+	if got, want := TODOs(diff), (Markers{Added: 2, Removed: 3}); got != want {
+		t.Errorf("TODOs = %+v, want %+v", got, want)
+	}
+	if got := TODOs(diff).String(); got != "TODO +2 −3" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Markers{}).String(); got != "" {
+		t.Errorf("expected no markers to show nothing, got %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+// Package findings scans diffs for problems worth flagging to the reviewer
+package findings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Finding flags one diff line
+type Finding struct {
+	Line    int    // Index into the diff's lines
+	Rule    string // Short rule name, e.g. "bidi"
+	Message string
+}
+
+// Rule names reported by Unicode
+const (
+	RuleBidi        = "bidi"
+	RuleInvisible   = "invisible"
+	RuleMixedScript = "mixed-script"
+)
+
+// bidiControls are the characters trojan-source attacks use to reorder how
+// code is displayed without changing how it is compiled
+var bidiControls = map[rune]string{
+	'\u061c': "ARABIC LETTER MARK",
+	'\u200e': "LEFT-TO-RIGHT MARK",
+	'\u200f': "RIGHT-TO-LEFT MARK",
+	'\u202a': "LEFT-TO-RIGHT EMBEDDING",
+	'\u202b': "RIGHT-TO-LEFT EMBEDDING",
+	'\u202c': "POP DIRECTIONAL FORMATTING",
+	'\u202d': "LEFT-TO-RIGHT OVERRIDE",
+	'\u202e': "RIGHT-TO-LEFT OVERRIDE",
+	'\u2066': "LEFT-TO-RIGHT ISOLATE",
+	'\u2067': "RIGHT-TO-LEFT ISOLATE",
+	'\u2068': "FIRST STRONG ISOLATE",
+	'\u2069': "POP DIRECTIONAL ISOLATE",
+}
+
+// invisibles render as nothing at all in most terminals
+var invisibles = map[rune]string{
+	'\u00ad': "SOFT HYPHEN",
+	'\u180e': "MONGOLIAN VOWEL SEPARATOR",
+	'\u200b': "ZERO WIDTH SPACE",
+	'\u200c': "ZERO WIDTH NON-JOINER",
+	'\u200d': "ZERO WIDTH JOINER",
+	'\u2060': "WORD JOINER",
+	'\ufeff': "ZERO WIDTH NO-BREAK SPACE",
+}
+
+// Unicode scans the added lines of a unified diff for bidi control
+// characters, invisible characters and identifiers that mix scripts (such
+// as a Cyrillic "a" inside an otherwise Latin name). Each line is reported
+// at most once, with its most serious problem.
+func Unicode(diff string) []Finding {
+	var result []Finding
+	for i, line := range strings.Split(diff, "\n") {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		if f, ok := scanLine(line[1:]); ok {
+			f.Line = i
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// scanLine checks one line of added text
+func scanLine(text string) (Finding, bool) {
+	// Fast path: plain ASCII can't contain any of these
+	ascii := true
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return Finding{}, false
+	}
+
+	for _, r := range text {
+		if name, ok := bidiControls[r]; ok {
+			return Finding{Rule: RuleBidi, Message: fmt.Sprintf("bidi control U+%04X %s", r, name)}, true
+		}
+	}
+	for _, r := range text {
+		if name, ok := invisibles[r]; ok {
+			return Finding{Rule: RuleInvisible, Message: fmt.Sprintf("invisible U+%04X %s", r, name)}, true
+		}
+	}
+	for _, ident := range identifiers(text) {
+		if scripts := scriptsOf(ident); len(scripts) > 1 {
+			return Finding{
+				Rule:    RuleMixedScript,
+				Message: fmt.Sprintf("%q mixes %s scripts", ident, strings.Join(scripts, "/")),
+			}, true
+		}
+	}
+	return Finding{}, false
+}
+
+// identifiers splits text into runs of letters, digits and underscores
+func identifiers(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// scriptsOf returns the sorted names of the scripts used by the letters in
+// s. Common and inherited characters (digits, combining marks) don't count.
+func scriptsOf(s string) []string {
+	seen := make(map[string]bool)
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if name := scriptOf(r); name != "" {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commonScripts are checked first since confusables almost always come from
+// them
+var commonScripts = []string{"Latin", "Cyrillic", "Greek", "Armenian", "Cherokee"}
+
+// scriptOf returns the Unicode script of r, or "" if unknown
+func scriptOf(r rune) string {
+	if r < 0x80 {
+		return "Latin"
+	}
+	for _, name := range commonScripts {
+		if unicode.Is(unicode.Scripts[name], r) {
+			return name
+		}
+	}
+	for name, table := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" && unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package findings
+
+import "testing"
+
+func TestUnicode(t *testing.T) {
+	diff := "diff --git a/x.go b/x.go\n" +
+		"+++ b/x.go\u202e\n" +
+		"@@ -1,2 +1,6 @@\n" +
+		" unchanged\u202e\n" +
+		"+if isAdmin\u202e {\n" +
+		"+token := \"a\u200bb\"\n" +
+		"+func p\u0430ssword() {}\n" +
+		"+x := \"日本語\" // Non-Latin text alone is fine\n" +
+		"+plain ascii\n" +
+		"-removed\u202e"
+
+	got := Unicode(diff)
+	want := []Finding{
+		{Line: 4, Rule: RuleBidi},
+		{Line: 5, Rule: RuleInvisible},
+		{Line: 6, Rule: RuleMixedScript},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %+v", len(want), len(got), got)
+	}
+	for i, f := range got {
+		if f.Line != want[i].Line || f.Rule != want[i].Rule {
+			t.Errorf("finding %d: expected line %d %s, got line %d %s", i, want[i].Line, want[i].Rule, f.Line, f.Rule)
+		}
+		if f.Message == "" {
+			t.Errorf("finding %d has no message", i)
+		}
+	}
+
+	if msg := got[2].Message; msg != "\"p\u0430ssword\" mixes Cyrillic/Latin scripts" {
+		t.Errorf("unexpected mixed-script message: %q", msg)
+	}
+}
+
+func TestUnicode_CommonCharactersDontMix(t *testing.T) {
+	for _, line := range []string{
+		"+café_count2 := 1", // Accented Latin
+		"+π2 := math.Pi",    // Greek with ASCII digits
+		"+счет := 0",        // All Cyrillic
+	} {
+		if got := Unicode(line); len(got) != 0 {
+			t.Errorf("unexpected finding for %q: %+v", line, got)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Package follow shares where a review is over a local socket: one tcr
+// broadcasts the file and line its cursor is on, and others follow along
+// read-only, as for pair review over tmux or SSH
+package follow
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Position is where the sharing review's cursor is
+type Position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`   // File line number, 0 for none
+	Cursor int    `json:"cursor"` // Diff line index, for when Line is 0 or the diffs differ
+}
+
+// writeTimeout drops a follower that stops reading rather than stalling
+// the others
+const writeTimeout = time.Second
+
+// DefaultSocket returns the socket for sharing the review of the
+// repository at dir, the same for every tcr run there. It's in a
+// directory only the user can enter, $XDG_RUNTIME_DIR/tcr or tcr under
+// the user cache directory, created if need be.
+func DefaultSocket(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	base := os.Getenv("XDG_RUNTIME_DIR")
+	if base == "" {
+		if base, err = os.UserCacheDir(); err != nil {
+			return "", fmt.Errorf("failed to locate a directory for the socket: %w", err)
+		}
+	}
+	sockets, err := privateDir(filepath.Join(base, "tcr"))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(sockets, "follow-"+hex.EncodeToString(sum[:4])+".sock"), nil
+}
+
+// privateDir creates dir with mode 0700, or checks that the one there is
+// the user's own and narrows it to 0700
+func privateDir(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() || !ownedByUser(info) {
+		return "", fmt.Errorf("%s belongs to another user; refusing to share through it", dir)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to restrict %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// Broadcaster sends each new position to every follower. The writes
+// happen on a goroutine of its own, so a slow follower never holds up
+// Send.
+type Broadcaster struct {
+	ln   net.Listener
+	path string
+
+	mu   sync.Mutex
+	last []byte // The latest position sent
+
+	joins     chan net.Conn // Followers accepted, for run to add
+	positions chan []byte   // The position run is to send next
+	done      chan struct{} // Closed by Close
+	stopped   chan struct{} // Closed once run has disconnected everyone
+	closeOnce sync.Once
+	followers atomic.Int32
+}
+
+// Listen starts sharing on the socket at path, readable by the user
+// alone. A socket the user left from a tcr that exited is replaced; one
+// still in use, or anything at path another user owns, is an error.
+func Listen(path string) (*Broadcaster, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if !ownedByUser(info) {
+			return nil, fmt.Errorf("%s belongs to another user; refusing to share on it", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another tcr is already sharing on %s", path)
+		}
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to restrict %s: %w", path, err)
+	}
+	b := &Broadcaster{
+		ln:        ln,
+		path:      path,
+		joins:     make(chan net.Conn),
+		positions: make(chan []byte, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go b.accept()
+	go b.run()
+	return b, nil
+}
+
+// Path returns the socket followers connect to
+func (b *Broadcaster) Path() string {
+	return b.path
+}
+
+// accept hands followers to run until the listener closes
+func (b *Broadcaster) accept() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		select {
+		case b.joins <- conn:
+		case <-b.done:
+			conn.Close()
+			return
+		}
+	}
+}
+
+// run owns the followers' connections: it starts each joining one where
+// the review is and sends them every position, until Close
+func (b *Broadcaster) run() {
+	defer close(b.stopped)
+	conns := make(map[net.Conn]bool)
+	var last []byte
+	for {
+		select {
+		case <-b.done:
+			for conn := range conns {
+				conn.Close()
+			}
+			b.followers.Store(0)
+			return
+		case conn := <-b.joins:
+			if last == nil || write(conn, last) {
+				conns[conn] = true
+			}
+		case line := <-b.positions:
+			last = line
+			for conn := range conns {
+				if !write(conn, line) {
+					delete(conns, conn)
+				}
+			}
+		}
+		b.followers.Store(int32(len(conns)))
+	}
+}
+
+// Send shares pos with every follower, unless it's where they already
+// are. It doesn't wait for the writes: a position not yet sent is
+// replaced by the newer one.
+func (b *Broadcaster) Send(pos Position) {
+	line, err := json.Marshal(pos)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if string(line) == string(b.last) {
+		return
+	}
+	b.last = line
+	// Only Send fills the channel, under mu, so emptying it leaves room
+	select {
+	case <-b.positions:
+	default:
+	}
+	b.positions <- line
+}
+
+// write sends line to one follower, closing its connection on failure
+func write(conn net.Conn, line []byte) bool {
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := conn.Write(line); err != nil {
+		conn.Close()
+		return false
+	}
+	return true
+}
+
+// Followers returns how many followers are connected, as of the last send
+func (b *Broadcaster) Followers() int {
+	return int(b.followers.Load())
+}
+
+// Close stops sharing, disconnecting the followers and removing the socket
+func (b *Broadcaster) Close() error {
+	err := b.ln.Close()
+	b.closeOnce.Do(func() { close(b.done) })
+	<-b.stopped
+	return err
+}
+
+// Follower receives the positions of a shared review
+type Follower struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+// Dial joins the review shared on the socket at path, refusing one another
+// user put there
+func Dial(path string) (*Follower, error) {
+	if info, err := os.Lstat(path); err == nil && !ownedByUser(info) {
+		return nil, fmt.Errorf("%s belongs to another user; refusing to follow it", path)
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("no review is shared on %s (start one there with tcr --share)", path)
+	}
+	return &Follower{conn: conn, scanner: bufio.NewScanner(conn)}, nil
+}
+
+// ErrEnded is returned by Next once the sharing review has quit
+var ErrEnded = errors.New("the shared review ended")
+
+// Next waits for the sharing review to move
+func (f *Follower) Next() (Position, error) {
+	var pos Position
+	if !f.scanner.Scan() {
+		return pos, ErrEnded
+	}
+	if err := json.Unmarshal(f.scanner.Bytes(), &pos); err != nil {
+		return pos, fmt.Errorf("unreadable position from the shared review: %w", err)
+	}
+	return pos, nil
+}
+
+// Close leaves the shared review
+func (f *Follower) Close() error {
+	return f.conn.Close()
+}
@@ -0,0 +1,158 @@
+package follow
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// socketPath returns a socket path short enough for the platform limit,
+// which a nested test temp dir can exceed
+func socketPath(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "tcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "s.sock")
+}
+
+func TestShareAndFollow(t *testing.T) {
+	path := socketPath(t)
+	b, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// A follower joining late starts where the review already is
+	b.Send(Position{File: "a.go", Line: 3, Cursor: 5})
+	f, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if pos, err := f.Next(); err != nil || pos != (Position{File: "a.go", Line: 3, Cursor: 5}) {
+		t.Fatalf("first position = %+v, %v", pos, err)
+	}
+
+	// Wait for the follower to be added before sending more
+	deadline := time.Now().Add(time.Second)
+	for b.Followers() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	b.Send(Position{File: "a.go", Line: 3, Cursor: 5}) // Unchanged, not sent
+	b.Send(Position{File: "b.go", Line: 10, Cursor: 2})
+	if pos, err := f.Next(); err != nil || pos.File != "b.go" {
+		t.Fatalf("expected the move to b.go, got %+v, %v", pos, err)
+	}
+
+	b.Close()
+	if _, err := f.Next(); !errors.Is(err, ErrEnded) {
+		t.Errorf("expected the review to end, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the socket removed")
+	}
+}
+
+func TestListenInUse(t *testing.T) {
+	path := socketPath(t)
+	b, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(path); err == nil || !strings.Contains(err.Error(), "already sharing") {
+		t.Errorf("expected a second share to fail, got %v", err)
+	}
+	b.Close()
+
+	// A socket file left behind is replaced
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, err = Listen(path)
+	if err != nil {
+		t.Fatalf("expected a stale socket replaced: %v", err)
+	}
+	b.Close()
+}
+
+func TestDialNothingShared(t *testing.T) {
+	if _, err := Dial(socketPath(t)); err == nil {
+		t.Error("expected an error with nothing shared")
+	}
+}
+
+func TestDefaultSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Dir(socketPath(t)))
+	a, err := DefaultSocket("/repo/one")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := DefaultSocket("/repo/two")
+	again, _ := DefaultSocket("/repo/one")
+	if a == b || a != again || !strings.HasSuffix(a, ".sock") {
+		t.Errorf("expected a stable socket per repository, got %s, %s, %s", a, b, again)
+	}
+}
+
+func TestSocketIsPrivate(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", filepath.Dir(socketPath(t)))
+	path, err := DefaultSocket("/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("expected the socket directory 0700, got %o", perm)
+	}
+
+	b, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	info, err = os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected the socket 0600, got %o", perm)
+	}
+}
+
+func TestSendDoesNotWaitForFollowers(t *testing.T) {
+	path := socketPath(t)
+	b, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// A follower that never reads fills its socket buffer
+	f, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	deadline := time.Now().Add(time.Second)
+	for b.Followers() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	long := strings.Repeat("x", 64<<10)
+	for i := range 100 {
+		b.Send(Position{File: long, Line: i})
+	}
+	if elapsed := time.Since(start); elapsed > writeTimeout/2 {
+		t.Errorf("expected Send not to wait on a stalled follower, took %v", elapsed)
+	}
+}
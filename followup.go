@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gerunddev/tcr/output"
+)
+
+const followupUsage = `Usage:
+  tcr followup <review.md>               List the open questions
+  tcr followup <review.md> --resolve N   Mark open question N resolved
+
+Questions are comments labeled "question:". Answer one by adding a quoted
+line ("> ...") below it in the review file; it stays open until resolved,
+which adds a "(resolved)" decoration to its label.
+`
+
+// runFollowup implements "tcr followup": it lists the questions in a
+// review file that are still open, and marks them resolved
+func runFollowup(args []string) int {
+	var resolve int
+	switch {
+	case len(args) == 1 && !strings.HasPrefix(args[0], "--"):
+	case len(args) == 3 && args[1] == "--resolve":
+		n, err := strconv.Atoi(args[2])
+		if err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "Error: --resolve needs a question number\n")
+			return 1
+		}
+		resolve = n
+	default:
+		fmt.Fprint(os.Stderr, followupUsage)
+		return 1
+	}
+
+	path := args[0]
+	comments, _, ok := readReview(path)
+	if !ok {
+		return 1
+	}
+	open := output.OpenQuestions(comments)
+
+	if resolve > 0 {
+		if resolve > len(open) {
+			fmt.Fprintf(os.Stderr, "Error: no open question %d (%d open)\n", resolve, len(open))
+			return 1
+		}
+		q := open[resolve-1]
+		if err := output.ReplaceFeedback(path, q.FilePath, q.Line, q.Comment, output.MarkResolved(q.Comment)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Resolved the question on %s.\n", location(q))
+		return 0
+	}
+
+	if len(open) == 0 {
+		fmt.Printf("No open questions in %s.\n", path)
+		return 0
+	}
+	fmt.Printf("%d open question(s) in %s:\n", len(open), path)
+	for i, q := range open {
+		status := "unanswered"
+		if output.IsAnswered(q.Comment) {
+			status = "answered"
+		}
+		fmt.Printf("\n%d. %s (%s)\n", i+1, location(q), status)
+		for _, line := range strings.Split(q.Comment, "\n") {
+			fmt.Printf("   %s\n", line)
+		}
+	}
+	return 0
+}
+
+// location formats where a comment is, as path:line or just the path
+func location(c output.Feedback) string {
+	if c.Line > 0 {
+		return fmt.Sprintf("%s:%d", c.FilePath, c.Line)
+	}
+	return c.FilePath
+}
@@ -0,0 +1,87 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// Change is a Gerrit change's current patch set, reviewed like local
+// changes, with the comments already left on it
+type Change struct {
+	*vcs.Patch
+	comments map[string][]vcs.Annotation
+}
+
+// Annotations returns the inline comments on path
+func (c *Change) Annotations(path string) []vcs.Annotation {
+	return c.comments[path]
+}
+
+// commentInfo is the subset of Gerrit's CommentInfo tcr shows
+type commentInfo struct {
+	Line    int    `json:"line"`
+	Side    string `json:"side"` // "PARENT" for the base, empty for the revision
+	Message string `json:"message"`
+	Author  struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+// Fetch downloads the current patch set of a change (by number, Change-Id
+// or project~number) and the published comments on it
+func (c Client) Fetch(ctx context.Context, change string) (*Change, error) {
+	base := "/changes/" + url.PathEscape(change) + "/revisions/current"
+
+	req, err := c.request(ctx, http.MethodGet, base+"/patch", nil)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download change %s: %w", change, err)
+	}
+	patchText, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode patch: %w", err)
+	}
+	patch, err := vcs.NewPatch("gerrit", string(patchText))
+	if err != nil {
+		return nil, fmt.Errorf("change %s: %w", change, err)
+	}
+
+	req, err = c.request(ctx, http.MethodGet, base+"/comments", nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load comments on %s: %w", change, err)
+	}
+	var infos map[string][]commentInfo
+	if err := json.Unmarshal(data, &infos); err != nil {
+		return nil, fmt.Errorf("failed to parse comments: %w", err)
+	}
+
+	comments := make(map[string][]vcs.Annotation)
+	for path, list := range infos {
+		for _, info := range list {
+			a := vcs.Annotation{Line: info.Line, Side: vcs.RevHead, Author: info.Author.Name, Message: info.Message}
+			if info.Side == "PARENT" {
+				a.Side = vcs.RevBase
+			}
+			if a.Author == "" {
+				a.Author = info.Author.Username
+			}
+			comments[path] = append(comments[path], a)
+		}
+	}
+	return &Change{Patch: patch, comments: comments}, nil
+}
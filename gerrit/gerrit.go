@@ -0,0 +1,124 @@
+// Package gerrit turns tcr feedback into Gerrit reviews and publishes them
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gerunddev/tcr/httpclient"
+	"github.com/gerunddev/tcr/output"
+)
+
+// ReviewInput is the body of Gerrit's "Set Review" endpoint
+type ReviewInput struct {
+	Message  string                    `json:"message,omitempty"`
+	Comments map[string][]CommentInput `json:"comments,omitempty"`
+}
+
+// CommentInput is one inline comment; Line 0 comments on the whole file
+type CommentInput struct {
+	Line       int    `json:"line,omitempty"`
+	Message    string `json:"message"`
+	Unresolved bool   `json:"unresolved"`
+}
+
+// FromFeedback builds a review from tcr comments and notes. Comments
+// labeled as issues or blockers are left unresolved so they must be
+// addressed; the rest are resolved remarks.
+func FromFeedback(comments []output.Feedback, notes string) ReviewInput {
+	review := ReviewInput{Message: notes}
+	if len(comments) > 0 {
+		review.Comments = make(map[string][]CommentInput)
+	}
+	for _, c := range comments {
+		review.Comments[c.FilePath] = append(review.Comments[c.FilePath], CommentInput{
+			Line:       c.Line,
+			Message:    c.Comment,
+			Unresolved: output.Classify(c.Comment) >= output.SeverityIssue,
+		})
+	}
+	return review
+}
+
+// changeIDTrailer matches the Change-Id footer Gerrit's commit-msg hook adds
+var changeIDTrailer = regexp.MustCompile(`(?m)^Change-Id: (I[0-9a-f]{40})\s*$`)
+
+// ChangeID returns the last Change-Id trailer in a commit message
+func ChangeID(message string) (string, bool) {
+	matches := changeIDTrailer.FindAllStringSubmatch(message, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	return matches[len(matches)-1][1], true
+}
+
+// Client publishes reviews to a Gerrit server
+type Client struct {
+	BaseURL  string // e.g. https://review.example.com
+	User     string
+	Password string // HTTP password from the Gerrit settings page
+	HTTP     *http.Client
+}
+
+// Publish posts a review on a revision of a change. revision may be
+// "current" for the latest patch set.
+func (c Client) Publish(ctx context.Context, change, revision string, review ReviewInput) error {
+	body, err := json.Marshal(review)
+	if err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/changes/%s/revisions/%s/review", url.PathEscape(change), url.PathEscape(revision))
+	req, err := c.request(ctx, http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	if _, err := c.do(req); err != nil {
+		return fmt.Errorf("failed to publish review: %w", err)
+	}
+	return nil
+}
+
+// request builds a request for a REST path. With credentials the
+// authenticated /a/ endpoint is used.
+func (c Client) request(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	prefix := ""
+	if c.User != "" {
+		prefix = "/a"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.BaseURL, "/")+prefix+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Password)
+	}
+	return req, nil
+}
+
+// do sends a request and returns the response body, without the ")]}'"
+// line Gerrit puts before JSON to defeat XSSI
+func (c Client) do(req *http.Request) ([]byte, error) {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimPrefix(data, []byte(")]}'\n")), nil
+}
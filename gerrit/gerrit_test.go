@@ -0,0 +1,158 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gerunddev/tcr/output"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+func TestFromFeedback(t *testing.T) {
+	review := FromFeedback([]output.Feedback{
+		{FilePath: "db/schema.sql", Line: 4, Comment: "blocker: drops the column"},
+		{FilePath: "db/schema.sql", Line: 9, Comment: "nit: trailing space"},
+		{FilePath: "README.md", Comment: "Mention the migration"},
+	}, "Looks close")
+
+	if review.Message != "Looks close" {
+		t.Errorf("message = %q", review.Message)
+	}
+	sql := review.Comments["db/schema.sql"]
+	if len(sql) != 2 || !sql[0].Unresolved || sql[1].Unresolved || sql[0].Line != 4 {
+		t.Errorf("unexpected schema.sql comments %+v", sql)
+	}
+	data, _ := json.Marshal(review.Comments["README.md"][0])
+	if strings.Contains(string(data), `"line"`) {
+		t.Errorf("file comments should leave out the line: %s", data)
+	}
+
+	if empty := FromFeedback(nil, ""); empty.Comments != nil {
+		t.Error("no comments should leave the map out")
+	}
+}
+
+func TestChangeID(t *testing.T) {
+	msg := "Fix the thing\n\nChange-Id: I0000000000000000000000000000000000000000\n" +
+		"Signed-off-by: A <a@example.com>\nChange-Id: I1234567890abcdef1234567890abcdef12345678\n"
+	if id, ok := ChangeID(msg); !ok || id != "I1234567890abcdef1234567890abcdef12345678" {
+		t.Errorf("ChangeID = %q, %v", id, ok)
+	}
+	if _, ok := ChangeID("No trailer\n"); ok {
+		t.Error("expected no Change-Id")
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var gotPath, gotUser string
+	var got ReviewInput
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUser, _, _ = r.BasicAuth()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if r.URL.Path == "/a/changes/missing/revisions/current/review" {
+			http.Error(w, "Not found: missing", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(")]}'\n{}"))
+	}))
+	defer srv.Close()
+
+	c := Client{BaseURL: srv.URL + "/", User: "alice", Password: "secret", HTTP: srv.Client()}
+	review := ReviewInput{Message: "LGTM"}
+	if err := c.Publish(context.Background(), "I123", "current", review); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/a/changes/I123/revisions/current/review" || gotUser != "alice" || got.Message != "LGTM" {
+		t.Errorf("request: path %q user %q body %+v", gotPath, gotUser, got)
+	}
+
+	err := c.Publish(context.Background(), "missing", "current", review)
+	if err == nil || !strings.Contains(err.Error(), "Not found: missing") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	patch := "From abc Mon Sep 17 00:00:00 2001\nSubject: [PATCH] Fix\n\n---\n" +
+		"diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2\n"
+	comments := `)]}'
+{"main.go": [
+  {"line": 2, "message": "Why 2?", "author": {"name": "Bob"}},
+  {"line": 2, "side": "PARENT", "message": "Was 1", "author": {"username": "carol"}}
+]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/changes/42/revisions/current/patch":
+			w.Write([]byte(base64.StdEncoding.EncodeToString([]byte(patch))))
+		case "/changes/42/revisions/current/comments":
+			w.Write([]byte(comments))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := Client{BaseURL: srv.URL, HTTP: srv.Client()}
+	change, err := c.Fetch(context.Background(), "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := change.ChangedFiles(context.Background())
+	if len(files) != 1 || files[0].Path != "main.go" {
+		t.Errorf("files = %v", files)
+	}
+	got := change.Annotations("main.go")
+	want := []vcs.Annotation{
+		{Line: 2, Side: vcs.RevHead, Author: "Bob", Message: "Why 2?"},
+		{Line: 2, Side: vcs.RevBase, Author: "carol", Message: "Was 1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("annotations = %+v, want %+v", got, want)
+	}
+
+	if _, err := c.Fetch(context.Background(), "7"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestInbox(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/a/changes/" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query().Get("q")
+		w.Write([]byte(`)]}'
+[{"_number": 12, "project": "infra/tools", "subject": "Add retries", "updated": "2024-03-01 10:20:30.123000000", "owner": {"name": "Bob"}}]`))
+	}))
+	defer srv.Close()
+
+	if _, err := (Client{BaseURL: srv.URL, HTTP: srv.Client()}).Inbox(context.Background()); err == nil {
+		t.Error("expected an error without credentials")
+	}
+
+	c := Client{BaseURL: srv.URL, User: "alice", Password: "pw", HTTP: srv.Client()}
+	changes, err := c.Inbox(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != InboxQuery {
+		t.Errorf("query = %q", query)
+	}
+	if len(changes) != 1 || changes[0].Ref() != "infra/tools~12" || changes[0].Owner.Name != "Bob" {
+		t.Errorf("changes = %+v", changes)
+	}
+	if got := changes[0].UpdatedAt(); got.Year() != 2024 || got.Hour() != 10 {
+		t.Errorf("updated = %v", got)
+	}
+}
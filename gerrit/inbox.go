@@ -0,0 +1,59 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// InboxQuery finds open changes the user was asked to review, leaving out
+// their own and work in progress
+const InboxQuery = "is:open reviewer:self -owner:self -is:wip"
+
+// ChangeInfo is the subset of Gerrit's ChangeInfo listed in the inbox
+type ChangeInfo struct {
+	Number  int    `json:"_number"`
+	Project string `json:"project"`
+	Subject string `json:"subject"`
+	Updated string `json:"updated"` // UTC, "2006-01-02 15:04:05.000000000"
+	Owner   struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"owner"`
+}
+
+// Ref returns project~number, which names the change unambiguously
+func (c ChangeInfo) Ref() string {
+	return c.Project + "~" + strconv.Itoa(c.Number)
+}
+
+// UpdatedAt parses the time the change was last updated
+func (c ChangeInfo) UpdatedAt() time.Time {
+	t, _ := time.Parse("2006-01-02 15:04:05.999999999", c.Updated)
+	return t
+}
+
+// Inbox lists the changes awaiting the user's review. It needs
+// credentials, since "self" is whoever is authenticated.
+func (c Client) Inbox(ctx context.Context) ([]ChangeInfo, error) {
+	if c.User == "" {
+		return nil, fmt.Errorf("set gerrit_user and log in (tcr auth login gerrit) to list changes to review")
+	}
+	req, err := c.request(ctx, http.MethodGet, "/changes/?o=DETAILED_ACCOUNTS&q="+url.QueryEscape(InboxQuery), nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+	var changes []ChangeInfo
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("failed to parse changes: %w", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,233 @@
+// Package gitea reviews pull requests on Gitea and Forgejo servers, such
+// as Codeberg
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gerunddev/tcr/httpclient"
+	"github.com/gerunddev/tcr/output"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// PR identifies a pull request
+type PR struct {
+	BaseURL string // Server root, e.g. https://codeberg.org
+	Owner   string
+	Repo    string
+	Index   int
+}
+
+// String returns the PR as owner/repo#index
+func (p PR) String() string {
+	return fmt.Sprintf("%s/%s#%d", p.Owner, p.Repo, p.Index)
+}
+
+// URL returns the PR's web URL
+func (p PR) URL() string {
+	return fmt.Sprintf("%s/%s/%s/pulls/%d", p.BaseURL, p.Owner, p.Repo, p.Index)
+}
+
+var (
+	prURL   = regexp.MustCompile(`^(https?://.+?)/([^/]+)/([^/]+)/pulls/(\d+)(?:/.*)?$`)
+	prShort = regexp.MustCompile(`^([^/\s]+)/([^/#\s]+)#(\d+)$`)
+)
+
+// ParsePR reads a pull request from its web URL, or from owner/repo#index
+// on the server at defaultBase
+func ParsePR(ref, defaultBase string) (PR, error) {
+	if m := prURL.FindStringSubmatch(ref); m != nil {
+		n, _ := strconv.Atoi(m[4])
+		return PR{BaseURL: m[1], Owner: m[2], Repo: m[3], Index: n}, nil
+	}
+	if m := prShort.FindStringSubmatch(ref); m != nil {
+		if defaultBase == "" {
+			return PR{}, fmt.Errorf("%s has no server; use the PR's URL or set gitea_url", ref)
+		}
+		n, _ := strconv.Atoi(m[3])
+		return PR{BaseURL: strings.TrimRight(defaultBase, "/"), Owner: m[1], Repo: m[2], Index: n}, nil
+	}
+	return PR{}, fmt.Errorf("%q is not a pull request URL or owner/repo#number", ref)
+}
+
+// Client talks to the Gitea API with an optional access token
+type Client struct {
+	Token string
+	HTTP  *http.Client
+}
+
+// PullRequest is a PR's diff, reviewed like local changes, with the
+// review comments already left on it
+type PullRequest struct {
+	*vcs.Patch
+	comments map[string][]vcs.Annotation
+}
+
+// Annotations returns the review comments on path
+func (p *PullRequest) Annotations(path string) []vcs.Annotation {
+	return p.comments[path]
+}
+
+// review and reviewComment are the subsets of Gitea's PullReview and
+// PullReviewComment tcr reads
+type review struct {
+	ID            int64 `json:"id"`
+	CommentsCount int   `json:"comments_count"`
+}
+
+type reviewComment struct {
+	Path             string `json:"path"`
+	Body             string `json:"body"`
+	Position         int    `json:"position"`          // Line in the new file, 0 if on the old side
+	OriginalPosition int    `json:"original_position"` // Line in the old file
+	User             struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// Fetch downloads a pull request's diff and its review comments
+func (c Client) Fetch(ctx context.Context, pr PR) (*PullRequest, error) {
+	base := c.repoPath(pr) + "/pulls/" + strconv.Itoa(pr.Index)
+
+	diff, err := c.get(ctx, pr, base+".diff")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", pr, err)
+	}
+	patch, err := vcs.NewPatch("gitea", string(diff))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pr, err)
+	}
+
+	var reviews []review
+	if err := c.getJSON(ctx, pr, base+"/reviews", &reviews); err != nil {
+		return nil, fmt.Errorf("failed to load reviews on %s: %w", pr, err)
+	}
+	comments := make(map[string][]vcs.Annotation)
+	for _, r := range reviews {
+		if r.CommentsCount == 0 {
+			continue
+		}
+		var list []reviewComment
+		if err := c.getJSON(ctx, pr, fmt.Sprintf("%s/reviews/%d/comments", base, r.ID), &list); err != nil {
+			return nil, fmt.Errorf("failed to load review comments on %s: %w", pr, err)
+		}
+		for _, rc := range list {
+			a := vcs.Annotation{Line: rc.Position, Side: vcs.RevHead, Author: rc.User.Login, Message: rc.Body}
+			if rc.Position == 0 {
+				a.Line, a.Side = rc.OriginalPosition, vcs.RevBase
+			}
+			comments[rc.Path] = append(comments[rc.Path], a)
+		}
+	}
+	return &PullRequest{Patch: patch, comments: comments}, nil
+}
+
+// ReviewInput is the body of the create review endpoint
+type ReviewInput struct {
+	Body     string         `json:"body"`
+	Event    string         `json:"event"`
+	Comments []CommentInput `json:"comments,omitempty"`
+}
+
+// CommentInput is one inline comment, on a line of the new file
+type CommentInput struct {
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	NewPosition int    `json:"new_position"`
+}
+
+// FromFeedback builds a review from tcr comments and notes. Gitea only
+// takes comments on lines, so comments on whole files are listed in the
+// review body after the notes. A blocker asks for changes; otherwise the
+// review is a plain comment.
+func FromFeedback(comments []output.Feedback, notes string) ReviewInput {
+	in := ReviewInput{Event: "COMMENT"}
+	body := []string{strings.TrimSpace(notes)}
+	for _, c := range comments {
+		if output.Classify(c.Comment) == output.SeverityBlocker {
+			in.Event = "REQUEST_CHANGES"
+		}
+		if c.Line == 0 {
+			body = append(body, fmt.Sprintf("**%s**: %s", c.FilePath, c.Comment))
+			continue
+		}
+		in.Comments = append(in.Comments, CommentInput{Path: c.FilePath, Body: c.Comment, NewPosition: c.Line})
+	}
+	in.Body = strings.TrimSpace(strings.Join(body, "\n\n"))
+	return in
+}
+
+// Publish posts a review on a pull request
+func (c Client) Publish(ctx context.Context, pr PR, in ReviewInput) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := c.request(ctx, http.MethodPost, pr, c.repoPath(pr)+"/pulls/"+strconv.Itoa(pr.Index)+"/reviews", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := c.do(req); err != nil {
+		return fmt.Errorf("failed to publish review on %s: %w", pr, err)
+	}
+	return nil
+}
+
+// repoPath returns the API path of the PR's repository
+func (c Client) repoPath(pr PR) string {
+	return "/api/v1/repos/" + url.PathEscape(pr.Owner) + "/" + url.PathEscape(pr.Repo)
+}
+
+// request builds an authenticated request for an API path
+func (c Client) request(ctx context.Context, method string, pr PR, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, pr.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+	return req, nil
+}
+
+// get fetches an API path
+func (c Client) get(ctx context.Context, pr PR, path string) ([]byte, error) {
+	req, err := c.request(ctx, http.MethodGet, pr, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+// getJSON fetches an API path and decodes the JSON response into v
+func (c Client) getJSON(ctx context.Context, pr PR, path string, v any) error {
+	data, err := c.get(ctx, pr, path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// do sends a request and returns the response body
+func (c Client) do(req *http.Request) ([]byte, error) {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
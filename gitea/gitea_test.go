@@ -0,0 +1,135 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gerunddev/tcr/output"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+func TestParsePR(t *testing.T) {
+	tests := []struct {
+		ref  string
+		base string
+		want PR
+	}{
+		{"https://codeberg.org/forgejo/forgejo/pulls/123", "", PR{"https://codeberg.org", "forgejo", "forgejo", 123}},
+		{"https://git.example.com/gitea/ops/tools/pulls/7/files", "", PR{"https://git.example.com/gitea", "ops", "tools", 7}},
+		{"ops/tools#9", "https://git.example.com/", PR{"https://git.example.com", "ops", "tools", 9}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePR(tt.ref, tt.base)
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePR(%q) = %+v, %v; want %+v", tt.ref, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"ops/tools#9", "not a pr", "https://codeberg.org/a/b/issues/1"} {
+		if _, err := ParsePR(bad, ""); err == nil {
+			t.Errorf("ParsePR(%q) should fail", bad)
+		}
+	}
+}
+
+func TestFetchAndPublish(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2\n"
+	var posted ReviewInput
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/repos/ops/tools/pulls/5.diff":
+			w.Write([]byte(diff))
+		case "GET /api/v1/repos/ops/tools/pulls/5/reviews":
+			w.Write([]byte(`[{"id": 1, "comments_count": 2}, {"id": 2, "comments_count": 0}]`))
+		case "GET /api/v1/repos/ops/tools/pulls/5/reviews/1/comments":
+			w.Write([]byte(`[
+			  {"path": "main.go", "body": "Why 2?", "position": 2, "user": {"login": "bob"}},
+			  {"path": "main.go", "body": "Was 1", "position": 0, "original_position": 2, "user": {"login": "carol"}}]`))
+		case "POST /api/v1/repos/ops/tools/pulls/5/reviews":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id": 3}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := Client{Token: "secret", HTTP: srv.Client()}
+	pr := PR{BaseURL: srv.URL, Owner: "ops", Repo: "tools", Index: 5}
+	got, err := c.Fetch(context.Background(), pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "token secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if files, _ := got.ChangedFiles(context.Background()); len(files) != 1 || files[0].Path != "main.go" {
+		t.Errorf("files = %v", files)
+	}
+	want := []vcs.Annotation{
+		{Line: 2, Side: vcs.RevHead, Author: "bob", Message: "Why 2?"},
+		{Line: 2, Side: vcs.RevBase, Author: "carol", Message: "Was 1"},
+	}
+	if !reflect.DeepEqual(got.Annotations("main.go"), want) {
+		t.Errorf("annotations = %+v", got.Annotations("main.go"))
+	}
+
+	in := FromFeedback([]output.Feedback{
+		{FilePath: "main.go", Line: 2, Comment: "blocker: breaks callers"},
+		{FilePath: "README.md", Comment: "Document x"},
+	}, "Notes here")
+	if err := c.Publish(context.Background(), pr, in); err != nil {
+		t.Fatal(err)
+	}
+	if posted.Event != "REQUEST_CHANGES" || posted.Body != "Notes here\n\n**README.md**: Document x" {
+		t.Errorf("posted review %+v", posted)
+	}
+	if len(posted.Comments) != 1 || posted.Comments[0] != (CommentInput{Path: "main.go", Body: "blocker: breaks callers", NewPosition: 2}) {
+		t.Errorf("posted comments %+v", posted.Comments)
+	}
+
+	if _, err := c.Fetch(context.Background(), PR{BaseURL: srv.URL, Owner: "ops", Repo: "tools", Index: 6}); err == nil {
+		t.Error("expected an error for a missing PR")
+	}
+}
+
+func TestInbox(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path != "/api/v1/repos/issues/search" || r.URL.Query().Get("review_requested") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"number": 5, "title": "Bump deps", "updated_at": "2024-03-01T10:00:00Z",
+			"user": {"login": "bob"}, "repository": {"owner": "team", "name": "api"}}]`))
+	}))
+	defer srv.Close()
+
+	c := Client{Token: "tok", HTTP: srv.Client()}
+	items, err := c.Inbox(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []InboxItem{{
+		PR:      PR{BaseURL: srv.URL, Owner: "team", Repo: "api", Index: 5},
+		Title:   "Bump deps",
+		Author:  "bob",
+		Updated: items[0].Updated,
+	}}
+	if !reflect.DeepEqual(items, want) || items[0].Updated.Hour() != 10 {
+		t.Errorf("items = %+v", items)
+	}
+	if auth != "token tok" {
+		t.Errorf("authorization = %q", auth)
+	}
+	if got := items[0].PR.URL(); got != srv.URL+"/team/api/pulls/5" {
+		t.Errorf("URL = %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InboxItem is a pull request awaiting the user's review
+type InboxItem struct {
+	PR      PR
+	Title   string
+	Author  string
+	Updated time.Time
+}
+
+// issue is the subset of Gitea's Issue the inbox reads
+type issue struct {
+	Number  int       `json:"number"`
+	Title   string    `json:"title"`
+	Updated time.Time `json:"updated_at"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Repository struct {
+		Owner string `json:"owner"`
+		Name  string `json:"name"`
+	} `json:"repository"`
+}
+
+// Inbox lists the open pull requests on the server at baseURL whose review
+// was requested from the token's user
+func (c Client) Inbox(ctx context.Context, baseURL string) ([]InboxItem, error) {
+	if c.Token == "" {
+		return nil, fmt.Errorf("log in to %s (tcr auth login gitea) to list pull requests to review", baseURL)
+	}
+	server := PR{BaseURL: strings.TrimRight(baseURL, "/")}
+	var issues []issue
+	if err := c.getJSON(ctx, server, "/api/v1/repos/issues/search?type=pulls&state=open&review_requested=true&limit=50", &issues); err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	items := make([]InboxItem, len(issues))
+	for i, is := range issues {
+		items[i] = InboxItem{
+			PR:      PR{BaseURL: server.BaseURL, Owner: is.Repository.Owner, Repo: is.Repository.Name, Index: is.Number},
+			Title:   is.Title,
+			Author:  is.User.Login,
+			Updated: is.Updated,
+		}
+	}
+	return items, nil
+}
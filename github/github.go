@@ -0,0 +1,203 @@
+// Package github reviews GitHub pull requests without checking them out,
+// through the gh CLI or the REST API
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gerunddev/tcr/httpclient"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// DefaultAPI is the REST API root of github.com
+const DefaultAPI = "https://api.github.com"
+
+// PR identifies a pull request. Owner and Repo are empty for a bare
+// number, leaving gh to find the repository.
+type PR struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// String returns the PR as owner/repo#number, or #number
+func (p PR) String() string {
+	if p.Owner == "" {
+		return "#" + strconv.Itoa(p.Number)
+	}
+	return fmt.Sprintf("%s/%s#%d", p.Owner, p.Repo, p.Number)
+}
+
+var (
+	prURL    = regexp.MustCompile(`^https?://github\.com/([^/]+)/([^/]+)/pull/(\d+)(?:/.*)?$`)
+	prShort  = regexp.MustCompile(`^([^/\s]+)/([^/#\s]+)#(\d+)$`)
+	prNumber = regexp.MustCompile(`^#?(\d+)$`)
+	remote   = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+)
+
+// ParsePR reads a pull request from its number, owner/repo#number or web URL
+func ParsePR(ref string) (PR, error) {
+	if m := prURL.FindStringSubmatch(ref); m != nil {
+		n, _ := strconv.Atoi(m[3])
+		return PR{Owner: m[1], Repo: m[2], Number: n}, nil
+	}
+	if m := prShort.FindStringSubmatch(ref); m != nil {
+		n, _ := strconv.Atoi(m[3])
+		return PR{Owner: m[1], Repo: m[2], Number: n}, nil
+	}
+	if m := prNumber.FindStringSubmatch(ref); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return PR{Number: n}, nil
+	}
+	return PR{}, fmt.Errorf("%q is not a pull request number, owner/repo#number or URL", ref)
+}
+
+// ParseRemote reads the owner and repository from a github.com remote URL,
+// over HTTPS or SSH
+func ParseRemote(remoteURL string) (owner, repo string, err error) {
+	m := remote.FindStringSubmatch(strings.TrimSpace(remoteURL))
+	if m == nil {
+		return "", "", fmt.Errorf("%s is not a GitHub repository", strings.TrimSpace(remoteURL))
+	}
+	return m[1], m[2], nil
+}
+
+// PullRequest is a PR's diff, reviewed like local changes, described by
+// its title and body
+type PullRequest struct {
+	*vcs.Patch
+	title string
+	body  string
+}
+
+// Description returns the PR's title and body
+func (p *PullRequest) Description(ctx context.Context) (string, error) {
+	if strings.TrimSpace(p.body) == "" {
+		return p.title, nil
+	}
+	return p.title + "\n\n" + strings.ReplaceAll(p.body, "\r\n", "\n"), nil
+}
+
+// newPullRequest wraps a PR's diff and description
+func newPullRequest(pr PR, diff, title, body string) (*PullRequest, error) {
+	patch, err := vcs.NewPatch("github", diff)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pr, err)
+	}
+	return &PullRequest{Patch: patch, title: title, body: body}, nil
+}
+
+// pullInfo is the subset of a pull request's JSON tcr reads, from both the
+// REST API and gh pr view
+type pullInfo struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Available reports whether the gh CLI is installed
+func Available() bool {
+	_, err := exec.LookPath("gh")
+	return err == nil
+}
+
+// FetchWithGH downloads a pull request through the gh CLI, which knows the
+// current repository and the user's login
+func FetchWithGH(ctx context.Context, pr PR) (*PullRequest, error) {
+	args := []string{strconv.Itoa(pr.Number)}
+	if pr.Owner != "" {
+		args = append(args, "--repo", pr.Owner+"/"+pr.Repo)
+	}
+	diff, err := gh(ctx, append([]string{"pr", "diff"}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", pr, err)
+	}
+	view, err := gh(ctx, append(append([]string{"pr", "view"}, args...), "--json", "title,body")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", pr, err)
+	}
+	var info pullInfo
+	if err := json.Unmarshal(view, &info); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pr, err)
+	}
+	return newPullRequest(pr, string(diff), info.Title, info.Body)
+}
+
+// gh runs the gh CLI, failing with what it printed
+func gh(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("gh %s: %s", args[0]+" "+args[1], msg)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// Client talks to the GitHub REST API with an optional token; public
+// repositories don't need one
+type Client struct {
+	BaseURL string // API root, DefaultAPI for github.com
+	Token   string
+	HTTP    *http.Client
+}
+
+// Fetch downloads a pull request's diff, title and body. pr needs its
+// owner and repository.
+func (c Client) Fetch(ctx context.Context, pr PR) (*PullRequest, error) {
+	path := "/repos/" + url.PathEscape(pr.Owner) + "/" + url.PathEscape(pr.Repo) + "/pulls/" + strconv.Itoa(pr.Number)
+
+	diff, err := c.get(ctx, path, "application/vnd.github.diff")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", pr, err)
+	}
+	data, err := c.get(ctx, path, "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", pr, err)
+	}
+	var info pullInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pr, err)
+	}
+	return newPullRequest(pr, string(diff), info.Title, info.Body)
+}
+
+// get fetches an API path in the given media type
+func (c Client) get(ctx context.Context, path, accept string) ([]byte, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultAPI
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(base, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := httpclient.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,94 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParsePR(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want PR
+	}{
+		{"42", PR{Number: 42}},
+		{"#42", PR{Number: 42}},
+		{"cli/cli#7", PR{"cli", "cli", 7}},
+		{"https://github.com/cli/cli/pull/123", PR{"cli", "cli", 123}},
+		{"https://github.com/cli/cli/pull/123/files", PR{"cli", "cli", 123}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePR(tt.ref)
+		if err != nil || got != tt.want {
+			t.Errorf("ParsePR(%q) = %+v, %v; want %+v", tt.ref, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "pr", "https://github.com/cli/cli/issues/1"} {
+		if _, err := ParsePR(bad); err == nil {
+			t.Errorf("ParsePR(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseRemote(t *testing.T) {
+	for _, remote := range []string{
+		"https://github.com/cli/cli.git\n",
+		"https://github.com/cli/cli",
+		"git@github.com:cli/cli.git",
+		"ssh://git@github.com/cli/cli.git",
+	} {
+		owner, repo, err := ParseRemote(remote)
+		if err != nil || owner != "cli" || repo != "cli" {
+			t.Errorf("ParseRemote(%q) = %q, %q, %v", remote, owner, repo, err)
+		}
+	}
+	if _, _, err := ParseRemote("https://gitlab.com/a/b.git"); err == nil {
+		t.Error("expected error for a non-GitHub remote")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2\n"
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path != "/repos/cli/cli/pulls/5" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Header.Get("Accept") {
+		case "application/vnd.github.diff":
+			w.Write([]byte(diff))
+		default:
+			w.Write([]byte(`{"title": "Bump x", "body": "Because.\r\nReally."}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := Client{BaseURL: srv.URL, Token: "secret", HTTP: srv.Client()}
+	got, err := c.Fetch(context.Background(), PR{Owner: "cli", Repo: "cli", Number: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if got.Name() != "github" {
+		t.Errorf("Name() = %q", got.Name())
+	}
+	if files, _ := got.ChangedFiles(context.Background()); len(files) != 1 || files[0].Path != "main.go" {
+		t.Errorf("files = %v", files)
+	}
+	if d, _ := got.Diff(context.Background(), "main.go"); !strings.Contains(d, "+var x = 2") {
+		t.Errorf("Diff = %q", d)
+	}
+	if desc, _ := got.Description(context.Background()); desc != "Bump x\n\nBecause.\nReally." {
+		t.Errorf("Description = %q", desc)
+	}
+
+	if _, err := c.Fetch(context.Background(), PR{Owner: "cli", Repo: "cli", Number: 6}); err == nil {
+		t.Error("expected error for a missing PR")
+	}
+}
@@ -0,0 +1,287 @@
+// Package goapi compares the exported API of two versions of a Go file
+package goapi
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Change is one exported declaration that was added, removed or changed
+type Change struct {
+	Kind string // "added", "removed" or "changed"
+	Name string // e.g. "func Parse", "field Config.Name", "method (*Client).Do"
+	Old  string // Declaration before, "" if added
+	New  string // Declaration after, "" if removed
+}
+
+// Diff parses both versions of a file and lists the exported functions,
+// methods, types, fields, constants and variables that differ. Parameter
+// and receiver names are ignored, so only changes callers would notice are
+// reported. Empty source counts as a file with no API, for added and
+// deleted files.
+func Diff(oldSrc, newSrc string) ([]Change, error) {
+	oldAPI, err := exports(oldSrc)
+	if err != nil {
+		return nil, fmt.Errorf("old version: %w", err)
+	}
+	newAPI, err := exports(newSrc)
+	if err != nil {
+		return nil, fmt.Errorf("new version: %w", err)
+	}
+
+	var changes []Change
+	for name, decl := range oldAPI {
+		switch cur, ok := newAPI[name]; {
+		case !ok:
+			changes = append(changes, Change{Kind: "removed", Name: name, Old: decl})
+		case cur != decl:
+			changes = append(changes, Change{Kind: "changed", Name: name, Old: decl, New: cur})
+		}
+	}
+	for name, decl := range newAPI {
+		if _, ok := oldAPI[name]; !ok {
+			changes = append(changes, Change{Kind: "added", Name: name, New: decl})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return kindOrder[changes[i].Kind] < kindOrder[changes[j].Kind]
+		}
+		ki, kj := sortKey(changes[i].Name), sortKey(changes[j].Name)
+		if ki != kj {
+			return ki < kj
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// sortKey orders a type's fields and methods right after the type itself:
+// "type Config", "field Config.Name" and "method (*Config).Load" become
+// "Config", "Config.Name" and "Config.Load"
+func sortKey(name string) string {
+	_, ident, _ := strings.Cut(name, " ")
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(ident)
+}
+
+// kindOrder lists removals first, since they break callers
+var kindOrder = map[string]int{"removed": 0, "changed": 1, "added": 2}
+
+// Format renders changes grouped by kind, one declaration per line
+func Format(changes []Change) string {
+	if len(changes) == 0 {
+		return "No exported API changes"
+	}
+
+	var b strings.Builder
+	kind := ""
+	for _, c := range changes {
+		if c.Kind != kind {
+			if kind != "" {
+				b.WriteByte('\n')
+			}
+			kind = c.Kind
+			b.WriteString(strings.ToUpper(kind[:1]) + kind[1:] + "\n")
+		}
+		switch c.Kind {
+		case "removed":
+			fmt.Fprintf(&b, "  - %s\n", c.Old)
+		case "added":
+			fmt.Fprintf(&b, "  + %s\n", c.New)
+		default:
+			fmt.Fprintf(&b, "  ~ %s\n      was %s\n", c.New, c.Old)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// exports maps each exported declaration in src to its normalized text
+func exports(src string) (map[string]string, error) {
+	api := make(map[string]string)
+	if strings.TrimSpace(src) == "" {
+		return api, nil
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			addFunc(api, d)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					addType(api, s)
+				case *ast.ValueSpec:
+					addValues(api, d.Tok, s)
+				}
+			}
+		}
+	}
+	return api, nil
+}
+
+// addFunc records an exported function, or an exported method on an
+// exported type
+func addFunc(api map[string]string, d *ast.FuncDecl) {
+	if !d.Name.IsExported() {
+		return
+	}
+	sig := strings.TrimPrefix(types.ExprString(unnamedFunc(d.Type)), "func")
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		api["func "+d.Name.Name] = "func " + d.Name.Name + sig
+		return
+	}
+
+	recv := d.Recv.List[0].Type
+	if !ast.IsExported(baseTypeName(recv)) {
+		return
+	}
+	name := "(" + types.ExprString(recv) + ")." + d.Name.Name
+	api["method "+name] = "func " + name + sig
+}
+
+// addType records an exported type plus its exported struct fields or
+// interface methods, each as its own entry
+func addType(api map[string]string, s *ast.TypeSpec) {
+	if !s.Name.IsExported() {
+		return
+	}
+	name := s.Name.Name
+	key, head := "type "+name, "type "+name
+	if s.TypeParams != nil {
+		head += "[" + fieldList(s.TypeParams) + "]"
+	}
+
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		api[key] = head + " struct"
+		for _, f := range t.Fields.List {
+			tag := ""
+			if f.Tag != nil {
+				tag = " " + f.Tag.Value
+			}
+			if len(f.Names) == 0 {
+				// Embedded field
+				if embedded := baseTypeName(f.Type); ast.IsExported(embedded) {
+					api["field "+name+"."+embedded] = "field " + name + "." + types.ExprString(f.Type) + tag
+				}
+				continue
+			}
+			for _, n := range f.Names {
+				if n.IsExported() {
+					api["field "+name+"."+n.Name] = "field " + name + "." + n.Name + " " + types.ExprString(f.Type) + tag
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		api[key] = head + " interface"
+		for _, m := range t.Methods.List {
+			if len(m.Names) == 0 {
+				// Embedded interface or type constraint
+				api["embed "+name+"."+types.ExprString(m.Type)] = "interface " + name + " embeds " + types.ExprString(m.Type)
+				continue
+			}
+			for _, n := range m.Names {
+				if !n.IsExported() {
+					continue
+				}
+				sig := types.ExprString(m.Type)
+				if ft, ok := m.Type.(*ast.FuncType); ok {
+					sig = types.ExprString(unnamedFunc(ft))
+				}
+				api["method "+name+"."+n.Name] = "interface " + name + "." + n.Name + strings.TrimPrefix(sig, "func")
+			}
+		}
+	default:
+		if s.Assign.IsValid() {
+			api[key] = head + " = " + types.ExprString(s.Type)
+		} else {
+			api[key] = head + " " + types.ExprString(s.Type)
+		}
+	}
+}
+
+// addValues records exported constants and variables with their type and,
+// for constants, their value
+func addValues(api map[string]string, tok token.Token, s *ast.ValueSpec) {
+	for i, n := range s.Names {
+		if !n.IsExported() {
+			continue
+		}
+		decl := tok.String() + " " + n.Name
+		if s.Type != nil {
+			decl += " " + types.ExprString(s.Type)
+		}
+		if tok == token.CONST && i < len(s.Values) {
+			decl += " = " + types.ExprString(s.Values[i])
+		}
+		api[tok.String()+" "+n.Name] = decl
+	}
+}
+
+// unnamedFunc copies a function type without its parameter and result
+// names, so renaming a parameter isn't an API change
+func unnamedFunc(ft *ast.FuncType) *ast.FuncType {
+	return &ast.FuncType{
+		TypeParams: ft.TypeParams,
+		Params:     unnamedFields(ft.Params),
+		Results:    unnamedFields(ft.Results),
+	}
+}
+
+// unnamedFields repeats each field's type once per name, without the names
+func unnamedFields(fl *ast.FieldList) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+	out := &ast.FieldList{}
+	for _, f := range fl.List {
+		for i := 0; i < max(len(f.Names), 1); i++ {
+			out.List = append(out.List, &ast.Field{Type: f.Type})
+		}
+	}
+	return out
+}
+
+// fieldList renders a list of fields (such as type parameters) with names
+func fieldList(fl *ast.FieldList) string {
+	var parts []string
+	for _, f := range fl.List {
+		var names []string
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		parts = append(parts, strings.TrimSpace(strings.Join(names, ", ")+" "+types.ExprString(f.Type)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// baseTypeName returns the named type under pointers, qualifiers and type
+// arguments, e.g. "List" for *List[T] and "Reader" for io.Reader
+func baseTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return baseTypeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexListExpr:
+		return baseTypeName(t.X)
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package goapi
+
+import (
+	"strings"
+	"testing"
+)
+
+const oldSrc = `package lib
+
+type Config struct {
+	Name    string
+	Timeout int ` + "`json:\"timeout\"`" + `
+	private bool
+}
+
+type Reader interface {
+	Read(p []byte) (int, error)
+}
+
+type internal struct{ X int }
+
+const Version = "1.0"
+
+func Parse(s string) *Config { return nil }
+func Remove() {}
+func (c *Config) Load(path string) error { return nil }
+func (i internal) Exported() {}
+`
+
+const newSrc = `package lib
+
+type Config struct {
+	Name    string
+	Timeout int ` + "`json:\"timeout_ms\"`" + `
+	Verbose bool
+}
+
+type Reader interface {
+	Read(buf []byte) (n int, err error)
+	Close() error
+}
+
+type List[T any] struct{}
+
+const Version = "1.1"
+
+func Parse(input string) (*Config, error) { return nil, nil }
+func (cfg *Config) Load(p string) error { return nil }
+func (l *List[T]) Push(v T) {}
+`
+
+func TestDiff(t *testing.T) {
+	changes, err := Diff(oldSrc, newSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]Change)
+	for _, c := range changes {
+		got[c.Name] = c
+	}
+
+	want := map[string]string{
+		"func Remove":            "removed",
+		"func Parse":             "changed",
+		"field Config.Timeout":   "changed",
+		"const Version":          "changed",
+		"field Config.Verbose":   "added",
+		"method Reader.Close":    "added",
+		"type List":              "added",
+		"method (*List[T]).Push": "added",
+	}
+	for name, kind := range want {
+		if got[name].Kind != kind {
+			t.Errorf("%s: kind %q, want %q", name, got[name].Kind, kind)
+		}
+	}
+	if len(changes) != len(want) {
+		t.Errorf("got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+
+	// Renamed parameters and receivers aren't API changes
+	for _, name := range []string{"method Reader.Read", "method (*Config).Load"} {
+		if c, ok := got[name]; ok {
+			t.Errorf("%s reported as %s", name, c.Kind)
+		}
+	}
+
+	if c := got["func Parse"]; c.Old != "func Parse(string) *Config" || c.New != "func Parse(string) (*Config, error)" {
+		t.Errorf("Parse: %q -> %q", c.Old, c.New)
+	}
+
+	if c := got["type List"]; c.New != "type List[T any] struct" {
+		t.Errorf("List: %q", c.New)
+	}
+
+	// Removals sort first, and members follow their type
+	wantOrder := []string{
+		"func Remove",
+		"field Config.Timeout", "func Parse", "const Version",
+		"field Config.Verbose", "type List", "method (*List[T]).Push", "method Reader.Close",
+	}
+	for i, c := range changes {
+		if i < len(wantOrder) && c.Name != wantOrder[i] {
+			t.Errorf("change %d is %q, want %q", i, c.Name, wantOrder[i])
+		}
+	}
+}
+
+func TestDiffAddedFile(t *testing.T) {
+	changes, err := Diff("", "package lib\n\nfunc New() {}\nfunc helper() {}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Kind != "added" || changes[0].New != "func New()" {
+		t.Errorf("got %+v", changes)
+	}
+}
+
+func TestDiffParseError(t *testing.T) {
+	if _, err := Diff("package lib", "package lib\nfunc {"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	out := Format([]Change{
+		{Kind: "removed", Name: "func A", Old: "func A()"},
+		{Kind: "changed", Name: "func B", Old: "func B()", New: "func B(int)"},
+		{Kind: "added", Name: "func C", New: "func C()"},
+	})
+	want := strings.Join([]string{
+		"Removed",
+		"  - func A()",
+		"",
+		"Changed",
+		"  ~ func B(int)",
+		"      was func B()",
+		"",
+		"Added",
+		"  + func C()",
+	}, "\n")
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+	if Format(nil) != "No exported API changes" {
+		t.Errorf("empty: %q", Format(nil))
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/ui/keys"
+)
+
+// usage is the top-level help text
+const usage = `tcr - Terminal Code Review
+
+Usage:
+  tcr [output.md]      Review changes, writing feedback to output.md
+  tcr - [output.md]    Review a diff piped in, e.g. git diff | tcr -
+  tcr compare A B [output.md]
+                       Review the differences between directories or
+                       archives A and B
+  tcr gerrit CHANGE [output.md]
+                       Review a change on the Gerrit server in gerrit_url
+  tcr gitea PR [output.md]
+                       Review a Gitea/Forgejo pull request, given by URL
+                       or as owner/repo#N on gitea_url
+  tcr inbox [output.md]
+                       Pick a change awaiting your review on the
+                       configured forges and review it
+  tcr help             Show this help
+  tcr help keys        Show the keybinding reference
+  tcr help config      Show the configuration reference
+  tcr version          Show version and build information
+  tcr stats            Show local usage stats (if enabled)
+  tcr snapshot [dir]   Record dir as the baseline to review outside a VCS
+  tcr export gerrit|gitea|azure <review.md> [--publish]
+                       Convert a review to the forge's JSON, or publish it
+  tcr export github-actions|rdjson <review.md>
+                       Print a review as CI annotations
+  tcr publish [--retry|--drop KEY]
+                       List, retry or discard reviews that failed to publish
+  tcr followup <review.md> [--resolve N]
+                       List a review's open questions, or resolve one
+  tcr hook install|uninstall [pre-commit|pre-push]
+                       Review staged changes before each commit, or the
+                       commits being pushed
+  tcr view [--from REV] [--to REV] [--no-pager]
+                       Print the changes styled, without reviewing them;
+                       with a diff on stdin, style that (a git pager)
+  tcr replay <session.cast> [--speed N] [--markers]
+                       Play back a review recorded with --record, or list
+                       the files it opened and comments it saved
+  tcr auth login|logout <provider>, tcr auth status
+                       Manage the access tokens forge integrations use
+  tcr update [--check] Check for and install the latest release
+
+Options:
+  --from REV, --to REV Review the changes between two git or jj revisions
+                       instead of the working copy's; --to alone reviews
+                       one commit, --from alone diffs it against the
+                       working copy
+  --interdiff A B      Review how a jj change evolved, as across a rebase
+                       or split: jj interdiff between its versions A and B
+  --range-diff OLD NEW Review how a git branch changed between versions,
+                       as across a rebase: each commit git range-diff
+                       finds changed, dropped or added, for ranges like
+                       main..topic-v1 main..topic-v2
+  --patch FILE         Review a unified diff from FILE, without a VCS
+  --pr PR              Review a GitHub pull request, given by number,
+                       owner/repo#N or URL, through gh or the REST API
+  --revset REVSET      Diff jj changes against this revset instead of
+                       jj_base_revset or the nearest bookmark
+  --scope SCOPE        Git: start on all, staged or unstaged changes
+                       (s switches while reviewing)
+  --backend BACKEND    Git: run git (git, the default) or diff in-process
+                       with go-git (native), which needs no git binary and
+                       is faster for many small files
+  --include GLOB       Review only the files matching GLOB, like src/**;
+                       repeat for more than one
+  --exclude GLOB       Leave out the files matching GLOB, like
+                       **/*_test.go or vendor; repeat for more than one
+  --ignore-whitespace  Leave out changes that only touch whitespace, as
+                       git diff -w does (w toggles while reviewing)
+  --previous FILE      Re-review against an earlier review file: its
+                       comments show on the diff, r marks one resolved,
+                       and a report of those addressed is added on exit
+  --record FILE        Record the session to FILE, an asciinema cast of
+                       the screen marking each file opened and comment
+                       saved, to replay with tcr replay
+  --share[=SOCKET]     Share where you are in the review: others run
+                       tcr --follow to watch along (experimental)
+  --follow[=SOCKET]    Follow the review shared from the same directory,
+                       read-only (experimental)
+  --quick              Show every file's diff in one pane, without the
+                       files panel, for small changes and commit hooks
+  --exit-code          Exit 2 if any comment is labeled as an issue
+                       ("issue:", "bug:") and 3 if any is a blocker
+                       ("blocker:", "(blocking)"), instead of 0
+`
+
+// runHelp implements "tcr help [topic]" and returns the exit code
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		fmt.Print(usage)
+		return 0
+	}
+
+	switch args[0] {
+	case "keys":
+		cfg, _ := config.Load()
+		writeKeysHelp(os.Stdout, cfg.Keymap)
+	case "config":
+		writeConfigHelp(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown help topic %q\n\n%s", args[0], usage)
+		return 1
+	}
+	return 0
+}
+
+// writeKeysHelp renders the keymap for a profile plus mode-specific keys
+func writeKeysHelp(w io.Writer, profile string) {
+	fmt.Fprintf(w, "KEYBINDINGS (profile: %s)\n\n", profile)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, b := range keys.ProfileKeymap(profile).Bindings() {
+		fmt.Fprintf(tw, "  %s\t%s\n", strings.Join(b.Keys, ", "), b.Action.Describe())
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w, "\nMovement keys accept a count prefix, e.g. 15j or 3 down.")
+
+	mode := ""
+	for _, mb := range keys.ModeBindings {
+		if mb.Mode != mode {
+			_ = tw.Flush()
+			mode = mb.Mode
+			fmt.Fprintf(w, "\n%s MODE\n\n", strings.ToUpper(mode))
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", mb.Key, mb.Desc)
+	}
+	_ = tw.Flush()
+}
+
+// writeConfigHelp renders the config schema with defaults and allowed values
+func writeConfigHelp(w io.Writer) {
+	path, err := config.Path()
+	if err != nil {
+		path = "(unavailable: " + err.Error() + ")"
+	}
+	fmt.Fprintf(w, "CONFIGURATION\n\nFile: %s\n\n", path)
+
+	defaults := config.Default()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  KEY\tDEFAULT\tVALUES\tDESCRIPTION")
+	for _, f := range config.Fields() {
+		values := "any"
+		if len(f.Choices) > 0 {
+			values = strings.Join(f.Choices, ", ")
+		}
+		def := defaults.Get(f.Key)
+		if def == "" {
+			def = `""`
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", f.Key, def, values, f.Description)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,137 @@
+// Package highlight styles the text in diff lines that matches rules from
+// the config, such as unsafe, panic( or a team's internal markers, so it
+// stands out on top of the diff colors
+package highlight
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Rule styles the text matching a pattern
+type Rule struct {
+	Pattern *regexp.Regexp
+	Style   lipgloss.Style
+}
+
+// Match is a run of a line matched by a rule, as byte offsets from Start up
+// to but not including End
+type Match struct {
+	Start, End int
+	Style      lipgloss.Style
+}
+
+// Compile turns the config's rules, regular expressions mapped to style
+// specs, into rules ordered by pattern
+func Compile(rules map[string]string) ([]Rule, error) {
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var result []Rule
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad highlight pattern %q: %w", pattern, err)
+		}
+		style, err := ParseStyle(rules[pattern])
+		if err != nil {
+			return nil, fmt.Errorf("bad highlight style for %q: %w", pattern, err)
+		}
+		result = append(result, Rule{Pattern: re, Style: style})
+	}
+	return result, nil
+}
+
+// Find returns the runs of line that rules match, in rule order, so where
+// matches overlap the later rule's style is applied over the earlier's
+func Find(rules []Rule, line string) []Match {
+	var matches []Match
+	for _, rule := range rules {
+		for _, loc := range rule.Pattern.FindAllStringIndex(line, -1) {
+			if loc[0] < loc[1] {
+				matches = append(matches, Match{Start: loc[0], End: loc[1], Style: rule.Style})
+			}
+		}
+	}
+	return matches
+}
+
+// attributes are the text attributes a style spec can name
+var attributes = map[string]func(lipgloss.Style) lipgloss.Style{
+	"bold":          func(s lipgloss.Style) lipgloss.Style { return s.Bold(true) },
+	"faint":         func(s lipgloss.Style) lipgloss.Style { return s.Faint(true) },
+	"italic":        func(s lipgloss.Style) lipgloss.Style { return s.Italic(true) },
+	"underline":     func(s lipgloss.Style) lipgloss.Style { return s.Underline(true) },
+	"reverse":       func(s lipgloss.Style) lipgloss.Style { return s.Reverse(true) },
+	"strikethrough": func(s lipgloss.Style) lipgloss.Style { return s.Strikethrough(true) },
+}
+
+// colors are the names of the 16 standard terminal colors, which follow
+// the terminal's own palette
+var colors = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"bright-black", "bright-red", "bright-green", "bright-yellow", "bright-blue", "bright-magenta", "bright-cyan", "bright-white",
+}
+
+// ParseStyle reads a style spec: attributes (bold, faint, italic,
+// underline, reverse, strikethrough), a foreground color and "on" a
+// background color, in any order, e.g. "bold red on #3A2A00". Colors are
+// names, #RGB or #RRGGBB, or ANSI numbers 0-255.
+func ParseStyle(spec string) (lipgloss.Style, error) {
+	style := lipgloss.NewStyle()
+	words := strings.Fields(strings.ToLower(spec))
+	if len(words) == 0 {
+		return style, fmt.Errorf("empty style")
+	}
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if set, ok := attributes[word]; ok {
+			style = set(style)
+			continue
+		}
+		background := word == "on"
+		if background {
+			if i++; i == len(words) {
+				return style, fmt.Errorf("missing color after \"on\"")
+			}
+			word = words[i]
+		}
+		color, ok := parseColor(word)
+		if !ok {
+			return style, fmt.Errorf("unknown color or attribute %q", word)
+		}
+		if background {
+			style = style.Background(color)
+		} else {
+			style = style.Foreground(color)
+		}
+	}
+	return style, nil
+}
+
+// parseColor reads a color name, hex color or ANSI number
+func parseColor(s string) (lipgloss.Color, bool) {
+	for n, name := range colors {
+		if s == name {
+			return lipgloss.Color(strconv.Itoa(n)), true
+		}
+	}
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if _, err := strconv.ParseUint(hex, 16, 32); err == nil && (len(hex) == 3 || len(hex) == 6) {
+			return lipgloss.Color(s), true
+		}
+		return "", false
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return lipgloss.Color(s), true
+	}
+	return "", false
+}
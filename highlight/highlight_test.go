@@ -0,0 +1,74 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestParseStyle(t *testing.T) {
+	style, err := ParseStyle("Bold red on #3A2A00 underline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !style.GetBold() || !style.GetUnderline() || style.GetItalic() {
+		t.Errorf("unexpected attributes in %v", style)
+	}
+	if fg := style.GetForeground(); fg != lipgloss.Color("1") {
+		t.Errorf("foreground = %v, want ANSI red", fg)
+	}
+	if bg := style.GetBackground(); bg != lipgloss.Color("#3a2a00") {
+		t.Errorf("background = %v, want #3a2a00", bg)
+	}
+
+	if style, err := ParseStyle("on 208"); err != nil || style.GetBackground() != lipgloss.Color("208") {
+		t.Errorf("ParseStyle(on 208) = %v, %v", style.GetBackground(), err)
+	}
+
+	for spec, want := range map[string]string{
+		"":           "empty style",
+		"bold on":    `missing color after "on"`,
+		"blinking":   `unknown color or attribute "blinking"`,
+		"#12345":     `unknown color or attribute "#12345"`,
+		"on 256":     `unknown color or attribute "256"`,
+		"red orange": `unknown color or attribute "orange"`,
+	} {
+		if _, err := ParseStyle(spec); err == nil || err.Error() != want {
+			t.Errorf("ParseStyle(%q) error = %v, want %q", spec, err, want)
+		}
+	}
+}
+
+func TestCompileAndFind(t *testing.T) {
+	rules, err := Compile(map[string]string{
+		`\bunsafe\b`: "bold red",
+		`panic\(`:    "reverse",
+		`x*`:         "italic", // Empty matches are dropped
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 || rules[0].Pattern.String() != `\bunsafe\b` {
+		t.Fatalf("expected rules ordered by pattern, got %v", rules)
+	}
+
+	matches := Find(rules, "unsafe.Pointer(p); panic(unsafe)")
+	var got []string
+	for _, m := range matches {
+		got = append(got, "unsafe.Pointer(p); panic(unsafe)"[m.Start:m.End])
+	}
+	if want := "unsafe unsafe panic("; strings.Join(got, " ") != want {
+		t.Errorf("matched %q, want %q", got, want)
+	}
+	if !matches[0].Style.GetBold() || !matches[2].Style.GetReverse() {
+		t.Error("expected each match to carry its rule's style")
+	}
+
+	if _, err := Compile(map[string]string{`panic(`: "bold"}); err == nil || !strings.Contains(err.Error(), "bad highlight pattern \"panic(\"") {
+		t.Errorf("expected a bad pattern error, got %v", err)
+	}
+	if _, err := Compile(map[string]string{`TODO`: "loud"}); err == nil || !strings.Contains(err.Error(), "bad highlight style for \"TODO\"") {
+		t.Errorf("expected a bad style error, got %v", err)
+	}
+}
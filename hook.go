@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/gerunddev/tcr/hook"
+)
+
+const hookUsage = `Usage:
+  tcr hook install [pre-commit|pre-push] [--force]
+                       Review before each commit (the default) or push
+  tcr hook uninstall [pre-commit|pre-push]
+                       Remove a hook tcr installed
+
+The hook opens a quick review of the staged changes, or of the commits
+being pushed. Leaving an issue or blocker comment stops the commit or push;
+set TCR_SKIP_HOOK=1 to skip the review.
+`
+
+// runHook implements "tcr hook": it installs and removes the git hooks
+// that review changes before they're committed or pushed
+func runHook(args []string) int {
+	force, args := boolFlag(args, "--force")
+	if len(args) == 0 || len(args) > 2 || (args[0] != "install" && args[0] != "uninstall") {
+		fmt.Fprint(os.Stderr, hookUsage)
+		return 1
+	}
+	kind := hook.Kinds[0]
+	if len(args) == 2 {
+		kind = args[1]
+	}
+
+	if args[0] == "uninstall" {
+		path, err := hook.Uninstall(".", kind)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Removed %s.\n", path)
+		return 0
+	}
+
+	path, err := hook.Install(".", kind, tcrPath(), force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Installed %s.\nSet %s=1 to skip the review once.\n", path, hook.BypassEnv)
+	return 0
+}
+
+// tcrPath returns how hooks should run tcr: by name if it's on the PATH,
+// so upgrades carry over, or else by this binary's path
+func tcrPath() string {
+	if _, err := exec.LookPath("tcr"); err == nil {
+		return "tcr"
+	}
+	if exe, err := os.Executable(); err == nil {
+		return exe
+	}
+	return "tcr"
+}
@@ -0,0 +1,146 @@
+// Package hook installs git hooks that run a tcr self-review before each
+// commit or push
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// BypassEnv skips the review when set, as in TCR_SKIP_HOOK=1 git commit
+const BypassEnv = "TCR_SKIP_HOOK"
+
+// marker identifies hooks tcr installed, which it may replace or remove
+const marker = "# Installed by tcr hook install"
+
+// Kinds are the hooks tcr can install, the first being the default
+var Kinds = []string{"pre-commit", "pre-push"}
+
+// Valid reports whether kind is one of Kinds
+func Valid(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// preamble starts every hook: it skips the review when bypassed, or when
+// there's no terminal to show it on (commits from editors and scripts)
+const preamble = `#!/bin/sh
+` + marker + `: a quick tcr self-review %s.
+# Leaving an issue or blocker comment stops it; set ` + BypassEnv + `=1 to skip.
+[ -n "$` + BypassEnv + `" ] && exit 0
+(exec </dev/tty) 2>/dev/null || exit 0
+`
+
+// preCommit reviews the staged changes, which are what the commit records
+const preCommit = `git diff --cached --quiet && exit 0
+exec %s --quick --scope staged --exit-code </dev/tty >/dev/tty
+`
+
+// prePush reviews the commits each pushed ref adds, as read from stdin
+const prePush = `zero=$(git hash-object --stdin </dev/null | tr '0-9a-f' '0')
+while read -r local_ref local_sha remote_ref remote_sha; do
+	[ "$local_sha" = "$zero" ] && continue
+	if [ "$remote_sha" = "$zero" ] || ! git cat-file -e "$remote_sha" 2>/dev/null; then
+		set -- --to "$local_sha"
+	else
+		set -- --from "$remote_sha" --to "$local_sha"
+	fi
+	%s --quick "$@" --exit-code </dev/tty >/dev/tty || exit $?
+done
+exit 0
+`
+
+// Script returns the hook script of a kind, running tcr from tcrPath
+func Script(kind, tcrPath string) (string, error) {
+	tcr := shellQuote(tcrPath)
+	switch kind {
+	case "pre-commit":
+		return fmt.Sprintf(preamble, "before each commit") + fmt.Sprintf(preCommit, tcr), nil
+	case "pre-push":
+		return fmt.Sprintf(preamble, "before each push") + fmt.Sprintf(prePush, tcr), nil
+	}
+	return "", fmt.Errorf("unknown hook %q (want %s)", kind, strings.Join(Kinds, " or "))
+}
+
+// shellQuote quotes s for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Path returns where git looks for a hook of a kind in the repository at
+// dir, following core.hooksPath and linked worktrees
+func Path(dir, kind string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks/"+kind)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not in a git repository", dir)
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path, nil
+}
+
+// Install writes the hook of a kind into the repository at dir and returns
+// its path. A hook tcr didn't install is only replaced with force.
+func Install(dir, kind, tcrPath string, force bool) (string, error) {
+	script, err := Script(kind, tcrPath)
+	if err != nil {
+		return "", err
+	}
+	path, err := Path(dir, kind)
+	if err != nil {
+		return "", err
+	}
+	if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), marker) && !force {
+		return "", fmt.Errorf("%s already exists\nHint: pass --force to replace it", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+	// WriteFile keeps an existing file's mode
+	if err := os.Chmod(path, 0755); err != nil {
+		return "", fmt.Errorf("failed to make hook executable: %w", err)
+	}
+	return path, nil
+}
+
+// Uninstall removes the hook of a kind from the repository at dir, if tcr
+// installed it, and returns its path
+func Uninstall(dir, kind string) (string, error) {
+	if !Valid(kind) {
+		return "", fmt.Errorf("unknown hook %q (want %s)", kind, strings.Join(Kinds, " or "))
+	}
+	path, err := Path(dir, kind)
+	if err != nil {
+		return "", err
+	}
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("no %s hook is installed", kind)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read hook: %w", err)
+	}
+	if !strings.Contains(string(existing), marker) {
+		return "", fmt.Errorf("%s wasn't installed by tcr; remove it yourself", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove hook: %w", err)
+	}
+	return path, nil
+}
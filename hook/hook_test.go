@@ -0,0 +1,91 @@
+package hook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	script, err := Script("pre-commit", "/opt/my tools/tcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"#!/bin/sh\n", marker, `[ -n "$TCR_SKIP_HOOK" ] && exit 0`, "exec '/opt/my tools/tcr' --quick --scope staged --exit-code"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("pre-commit script missing %q:\n%s", want, script)
+		}
+	}
+
+	script, err = Script("pre-push", "tcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(script, `'tcr' --quick "$@" --exit-code`) {
+		t.Errorf("pre-push script should review each pushed range:\n%s", script)
+	}
+
+	if _, err := Script("post-merge", "tcr"); err == nil {
+		t.Error("expected an error for an unsupported hook")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote("it's"); got != `'it'\''s'` {
+		t.Errorf("shellQuote = %s", got)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+
+	path, err := Install(dir, "pre-commit", "tcr", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, ".git", "hooks", "pre-commit"); path != want {
+		t.Errorf("installed at %s, want %s", path, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("hook not executable: %v %v", info, err)
+	}
+
+	// Reinstalling replaces tcr's own hook
+	if _, err := Install(dir, "pre-commit", "tcr", false); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	// Someone else's hook needs --force
+	other := filepath.Join(dir, ".git", "hooks", "pre-push")
+	if err := os.WriteFile(other, []byte("#!/bin/sh\nmake lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install(dir, "pre-push", "tcr", false); err == nil {
+		t.Error("expected an existing hook to be kept")
+	}
+	if _, err := Uninstall(dir, "pre-push"); err == nil {
+		t.Error("expected an existing hook not to be removed")
+	}
+	if _, err := Install(dir, "pre-push", "tcr", true); err != nil {
+		t.Errorf("install with force: %v", err)
+	}
+
+	if _, err := Uninstall(dir, "pre-commit"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("uninstall left the hook")
+	}
+	if _, err := Uninstall(dir, "pre-commit"); err == nil {
+		t.Error("expected an error uninstalling a missing hook")
+	}
+}
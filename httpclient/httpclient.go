@@ -0,0 +1,113 @@
+// Package httpclient builds the HTTP client tcr's network features share,
+// so proxy and certificate settings apply to every one of them
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Options configures the client; zero values keep Go's defaults
+type Options struct {
+	// Proxy is used for every request when set; otherwise HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY are honored
+	Proxy string
+	// CAFile is a PEM bundle of certificate authorities trusted in
+	// addition to the system ones, e.g. a corporate root or a server's
+	// self-signed certificate
+	CAFile string
+}
+
+// New returns a client for opts
+func New(opts Options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if opts.Proxy != "" {
+		u, err := ParseProxy(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates in %s", opts.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// ParseProxy checks a proxy URL, which needs a scheme and a host
+func ParseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("proxy %q is not a URL like http://proxy.example.com:3128", proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("proxy %q: unsupported scheme %q (want http, https or socks5)", proxy, u.Scheme)
+}
+
+// StatusError is an unsuccessful API response
+type StatusError struct {
+	Code   int
+	Status string // e.g. "429 Too Many Requests"
+	Detail string // Start of the response body
+}
+
+func (e *StatusError) Error() string {
+	return e.Status + ": " + e.Detail
+}
+
+// CheckResponse returns a StatusError for non-2xx responses
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &StatusError{Code: resp.StatusCode, Status: resp.Status, Detail: strings.TrimSpace(string(detail))}
+}
+
+// Retryable reports whether a request failed in a way that may succeed
+// later: the network was unreachable or timed out, or the server was
+// rate limiting or briefly unavailable
+func Retryable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusTooManyRequests || status.Code == http.StatusRequestTimeout || status.Code >= 500
+	}
+	// Connection and DNS failures, but not certificate errors, which
+	// won't go away by themselves
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	plain, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Get(srv.URL); err == nil {
+		t.Fatal("expected the self-signed certificate to be rejected")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, block, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trusting, err := New(Options{CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := trusting.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected the certificate in ca_file to be trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	os.WriteFile(caFile, []byte("not a certificate"), 0o644)
+	if _, err := New(Options{CAFile: caFile}); err == nil {
+		t.Error("expected an error for a bundle without certificates")
+	}
+}
+
+func TestNew_Proxy(t *testing.T) {
+	var requested string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := New(Options{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get("http://gerrit.internal.example/changes/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if requested != "http://gerrit.internal.example/changes/" {
+		t.Errorf("expected the request to go through the proxy, proxy saw %q", requested)
+	}
+}
+
+func TestParseProxy(t *testing.T) {
+	for _, good := range []string{"http://proxy:3128", "https://user:pw@proxy.example.com", "socks5://127.0.0.1:1080"} {
+		if _, err := ParseProxy(good); err != nil {
+			t.Errorf("ParseProxy(%q): %v", good, err)
+		}
+	}
+	for _, bad := range []string{"proxy:3128", "ftp://proxy", "http://"} {
+		if _, err := ParseProxy(bad); err == nil {
+			t.Errorf("ParseProxy(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.Error(w, "no such PR", http.StatusNotFound)
+		}
+	}))
+	addr := srv.URL
+	client, _ := New(Options{})
+
+	check := func(path string) error {
+		resp, err := client.Get(addr + path)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return CheckResponse(resp)
+	}
+	if err := check("/limited"); !Retryable(err) {
+		t.Errorf("429 should be retryable: %v", err)
+	}
+	if err := check("/down"); !Retryable(err) {
+		t.Errorf("502 should be retryable: %v", err)
+	}
+	err := check("/missing")
+	if Retryable(err) || err.Error() != "404 Not Found: no such PR" {
+		t.Errorf("404 should not be retryable: %v", err)
+	}
+
+	srv.Close()
+	if err := check("/"); !Retryable(err) {
+		t.Errorf("a refused connection should be retryable: %v", err)
+	}
+}
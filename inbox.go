@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/gerrit"
+	"github.com/gerunddev/tcr/gitea"
+	"github.com/gerunddev/tcr/ui"
+)
+
+// inboxEntry is a change awaiting the user's review on some forge
+type inboxEntry struct {
+	forge   string // "gerrit" or "gitea", as in tcr gerrit/gitea
+	ref     string // Argument to review it with
+	repo    string
+	number  int
+	title   string
+	author  string
+	updated time.Time
+}
+
+// loadInbox lists the changes awaiting review on the configured forges,
+// most recently updated first. Forges that fail are reported in err
+// alongside the entries from the others.
+func loadInbox(cfg config.Config) ([]inboxEntry, error) {
+	if cfg.GerritURL == "" && cfg.GiteaURL == "" {
+		return nil, fmt.Errorf("set gerrit_url or gitea_url in the config to list reviews (see tcr help config)")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var entries []inboxEntry
+	var errs []error
+	if cfg.GerritURL != "" {
+		client, err := gerritClient(cfg)
+		if err == nil {
+			var changes []gerrit.ChangeInfo
+			changes, err = client.Inbox(ctx)
+			for _, c := range changes {
+				author := c.Owner.Name
+				if author == "" {
+					author = c.Owner.Username
+				}
+				entries = append(entries, inboxEntry{forge: "gerrit", ref: c.Ref(), repo: c.Project, number: c.Number,
+					title: c.Subject, author: author, updated: c.UpdatedAt()})
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("gerrit: %w", err))
+		}
+	}
+	if cfg.GiteaURL != "" {
+		client, err := giteaClient(cfg)
+		if err == nil {
+			var items []gitea.InboxItem
+			items, err = client.Inbox(ctx, cfg.GiteaURL)
+			for _, it := range items {
+				entries = append(entries, inboxEntry{forge: "gitea", ref: it.PR.URL(), repo: it.PR.Owner + "/" + it.PR.Repo,
+					number: it.PR.Index, title: it.Title, author: it.Author, updated: it.Updated})
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("gitea: %w", err))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].updated.After(entries[j].updated) })
+	return entries, errors.Join(errs...)
+}
+
+// format renders an entry as one line of the inbox
+func (e inboxEntry) format(now time.Time) string {
+	return fmt.Sprintf("%-6s  %s #%d  %s  (%s, %s ago)", e.forge, e.repo, e.number, e.title, e.author, formatAge(now.Sub(e.updated)))
+}
+
+// formatAge renders how long ago something happened, coarsely
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// pickFromInbox lists the changes awaiting review and lets the user pick
+// one, returning its forge and reference, or "" if none was picked.
+// Without a terminal the list is printed instead.
+func pickFromInbox(cfg config.Config) (forge, ref string, err error) {
+	entries, err := loadInbox(cfg)
+	if len(entries) == 0 {
+		if err == nil {
+			fmt.Println("Nothing awaiting your review.")
+		}
+		return "", "", err
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	now := time.Now()
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = e.format(now)
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		fmt.Println(strings.Join(lines, "\n"))
+		return "", "", nil
+	}
+
+	i, chosen, err := ui.RunPicker(fmt.Sprintf("Awaiting your review (%d)", len(entries)), lines)
+	if err != nil || !chosen {
+		return "", "", err
+	}
+	return entries[i].forge, entries[i].ref, nil
+}
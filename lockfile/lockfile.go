@@ -0,0 +1,244 @@
+// Package lockfile summarizes dependency lockfile diffs as package changes
+package lockfile
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Change is one package whose locked versions changed
+type Change struct {
+	Name string
+	Old  string // Versions before, "" if the package was added
+	New  string // Versions after, "" if the package was removed
+}
+
+// Kind describes the change: "added", "removed", "upgraded" or "downgraded"
+func (c Change) Kind() string {
+	switch {
+	case c.Old == "":
+		return "added"
+	case c.New == "":
+		return "removed"
+	case compareVersions(c.Old, c.New) > 0:
+		return "downgraded"
+	}
+	return "upgraded"
+}
+
+// parsers read package names and versions out of diff lines, by lockfile
+// base name
+var parsers = map[string]func() lineParser{
+	"go.sum":            func() lineParser { return parseGoSum },
+	"Cargo.lock":        newBlockParser(cargoName, cargoVersion),
+	"package-lock.json": newBlockParser(npmKey, npmVersion),
+}
+
+// lineParser returns the package and version named by one diff line's text
+// (without its +/-/space prefix), or ok false if it names no version
+type lineParser func(text string) (name, version string, ok bool)
+
+// Recognized reports whether p is a lockfile tcr can summarize
+func Recognized(p string) bool {
+	_, ok := parsers[path.Base(p)]
+	return ok
+}
+
+// Summarize reads the package changes out of a lockfile's unified diff.
+// Packages are matched up by name, so a changed version shows as one
+// upgrade rather than a removal plus an addition. ok is false if p isn't a
+// recognized lockfile or no package versions changed.
+func Summarize(p, diff string) (changes []Change, ok bool) {
+	newParser, known := parsers[path.Base(p)]
+	if !known {
+		return nil, false
+	}
+	parse := newParser()
+
+	removed := make(map[string][]string)
+	added := make(map[string][]string)
+	for _, line := range strings.Split(diff, "\n") {
+		if line == "" || strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		prefix, text := line[0], line[1:]
+		if prefix != '+' && prefix != '-' && prefix != ' ' {
+			continue
+		}
+		name, version, found := parse(text)
+		if !found {
+			continue
+		}
+		switch prefix {
+		case '-':
+			removed[name] = appendUnique(removed[name], version)
+		case '+':
+			added[name] = appendUnique(added[name], version)
+		}
+	}
+
+	names := make(map[string]bool)
+	for name := range removed {
+		names[name] = true
+	}
+	for name := range added {
+		names[name] = true
+	}
+	for name := range names {
+		old, cur := without(removed[name], added[name]), without(added[name], removed[name])
+		if len(old) == 0 && len(cur) == 0 {
+			continue // Only hashes or formatting changed
+		}
+		changes = append(changes, Change{Name: name, Old: strings.Join(old, ", "), New: strings.Join(cur, ", ")})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		ki, kj := kindOrder[changes[i].Kind()], kindOrder[changes[j].Kind()]
+		if ki != kj {
+			return ki < kj
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, len(changes) > 0
+}
+
+// kindOrder lists version changes first, then additions and removals
+var kindOrder = map[string]int{"upgraded": 0, "downgraded": 1, "added": 2, "removed": 3}
+
+// Render lays changes out as diff-like text: a header naming the lockfile
+// and the counts, then one line per package, added ones prefixed "+" and
+// removed ones "-"
+func Render(p string, changes []Change) string {
+	counts := make(map[string]int)
+	width := 0
+	for _, c := range changes {
+		counts[c.Kind()]++
+		width = max(width, len(c.Name))
+	}
+	var parts []string
+	for _, kind := range []string{"upgraded", "downgraded", "added", "removed"} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ %s: %s @@\n", path.Base(p), strings.Join(parts, ", "))
+	for _, c := range changes {
+		switch c.Kind() {
+		case "added":
+			fmt.Fprintf(&b, "+ %-10s %-*s %s\n", "added", width, c.Name, c.New)
+		case "removed":
+			fmt.Fprintf(&b, "- %-10s %-*s %s\n", "removed", width, c.Name, c.Old)
+		default:
+			fmt.Fprintf(&b, "  %-10s %-*s %s → %s\n", c.Kind(), width, c.Name, c.Old, c.New)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// parseGoSum reads "module version[/go.mod] hash" lines
+func parseGoSum(text string) (string, string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) != 3 {
+		return "", "", false
+	}
+	return fields[0], strings.TrimSuffix(fields[1], "/go.mod"), true
+}
+
+var (
+	cargoName    = regexp.MustCompile(`^name = "([^"]+)"`)
+	cargoVersion = regexp.MustCompile(`^version = "([^"]+)"`)
+
+	// npmKey matches a package entry key, e.g. "node_modules/foo": {
+	npmKey     = regexp.MustCompile(`^\s*"([^"]*)": \{\s*$`)
+	npmVersion = regexp.MustCompile(`^\s*"version": "([^"]+)"`)
+)
+
+// newBlockParser handles lockfiles made of per-package blocks, where a line
+// naming the package comes just before its version line. The name may be
+// on an unchanged context line, so every line is read for names.
+func newBlockParser(nameRe, versionRe *regexp.Regexp) func() lineParser {
+	return func() lineParser {
+		var name string
+		return func(text string) (string, string, bool) {
+			if m := nameRe.FindStringSubmatch(text); m != nil {
+				name = packageName(m[1])
+				return "", "", false
+			}
+			if m := versionRe.FindStringSubmatch(text); m != nil && name != "" {
+				return name, m[1], true
+			}
+			return "", "", false
+		}
+	}
+}
+
+// packageName strips the install path from a package-lock.json key, so
+// "node_modules/a/node_modules/@s/b" is "@s/b"
+func packageName(key string) string {
+	if i := strings.LastIndex(key, "node_modules/"); i >= 0 {
+		return key[i+len("node_modules/"):]
+	}
+	return key
+}
+
+// appendUnique appends s unless list already holds it
+func appendUnique(list []string, s string) []string {
+	for _, x := range list {
+		if x == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// without returns the entries of list that aren't in drop
+func without(list, drop []string) []string {
+	var out []string
+	for _, s := range list {
+		if !contains(drop, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// versionPart splits a version into runs of digits and non-digits
+var versionPart = regexp.MustCompile(`\d+|\D+`)
+
+// compareVersions orders versions like 1.9.0 < 1.10.0, comparing digit runs
+// numerically and everything else as text
+func compareVersions(a, b string) int {
+	ap, bp := versionPart.FindAllString(a, -1), versionPart.FindAllString(b, -1)
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		if ap[i] == bp[i] {
+			continue
+		}
+		if isDigits(ap[i]) && isDigits(bp[i]) {
+			x, y := strings.TrimLeft(ap[i], "0"), strings.TrimLeft(bp[i], "0")
+			if len(x) != len(y) {
+				return len(x) - len(y)
+			}
+			return strings.Compare(x, y)
+		}
+		return strings.Compare(ap[i], bp[i])
+	}
+	return len(ap) - len(bp)
+}
+
+func isDigits(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
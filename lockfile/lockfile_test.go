@@ -0,0 +1,163 @@
+package lockfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizeGoSum(t *testing.T) {
+	diff := `diff --git a/go.sum b/go.sum
+--- a/go.sum
++++ b/go.sum
+@@ -1,6 +1,6 @@
+-github.com/a/lib v1.2.0 h1:old=
+-github.com/a/lib v1.2.0/go.mod h1:oldmod=
++github.com/a/lib v1.10.0 h1:new=
++github.com/a/lib v1.10.0/go.mod h1:newmod=
+ github.com/b/same v0.1.0 h1:same=
+-github.com/c/gone v0.3.0 h1:gone=
++github.com/d/new v2.0.0 h1:new=
+-github.com/e/down v1.5.0 h1:x=
++github.com/e/down v1.4.0 h1:y=`
+
+	changes, ok := Summarize("sub/go.sum", diff)
+	if !ok {
+		t.Fatal("expected a summary")
+	}
+	want := []Change{
+		{Name: "github.com/a/lib", Old: "v1.2.0", New: "v1.10.0"},
+		{Name: "github.com/e/down", Old: "v1.5.0", New: "v1.4.0"},
+		{Name: "github.com/d/new", New: "v2.0.0"},
+		{Name: "github.com/c/gone", Old: "v0.3.0"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+
+	kinds := []string{"upgraded", "downgraded", "added", "removed"}
+	for i, c := range changes {
+		if c.Kind() != kinds[i] {
+			t.Errorf("%s kind = %q, want %q", c.Name, c.Kind(), kinds[i])
+		}
+	}
+}
+
+func TestSummarizeCargoLock(t *testing.T) {
+	// The name of an upgraded package is an unchanged context line
+	diff := `@@ -10,12 +10,12 @@
+ [[package]]
+ name = "serde"
+-version = "1.0.150"
++version = "1.0.160"
+ source = "registry+https://github.com/rust-lang/crates.io-index"
+-checksum = "aaa"
++checksum = "bbb"
+
+ [[package]]
++name = "itoa"
++version = "1.0.9"
++
++[[package]]
+ name = "zzz"`
+
+	changes, ok := Summarize("Cargo.lock", diff)
+	if !ok {
+		t.Fatal("expected a summary")
+	}
+	if len(changes) != 2 {
+		t.Fatalf("got %v", changes)
+	}
+	if changes[0] != (Change{Name: "serde", Old: "1.0.150", New: "1.0.160"}) {
+		t.Errorf("serde: %+v", changes[0])
+	}
+	if changes[1] != (Change{Name: "itoa", New: "1.0.9"}) {
+		t.Errorf("itoa: %+v", changes[1])
+	}
+}
+
+func TestSummarizePackageLock(t *testing.T) {
+	diff := `@@ -1,20 +1,20 @@
+ {
+   "name": "app",
+-  "version": "1.0.0",
++  "version": "1.0.1",
+   "packages": {
+     "node_modules/left-pad": {
+-      "version": "1.1.0",
++      "version": "1.3.0",
+       "resolved": "https://registry.npmjs.org/left-pad/-/left-pad-1.3.0.tgz",
+     },
+-    "node_modules/a/node_modules/@scope/b": {
+-      "version": "2.0.0",
+-    },`
+
+	changes, ok := Summarize("package-lock.json", diff)
+	if !ok {
+		t.Fatal("expected a summary")
+	}
+	if len(changes) != 2 {
+		t.Fatalf("root package version should be ignored, got %v", changes)
+	}
+	if changes[0] != (Change{Name: "left-pad", Old: "1.1.0", New: "1.3.0"}) {
+		t.Errorf("left-pad: %+v", changes[0])
+	}
+	if changes[1] != (Change{Name: "@scope/b", Old: "2.0.0"}) {
+		t.Errorf("@scope/b: %+v", changes[1])
+	}
+}
+
+func TestSummarizeHashOnly(t *testing.T) {
+	diff := "@@ -1 +1 @@\n-github.com/a/lib v1.0.0 h1:old=\n+github.com/a/lib v1.0.0 h1:new="
+	if _, ok := Summarize("go.sum", diff); ok {
+		t.Error("a hash-only change has no package changes to summarize")
+	}
+	if _, ok := Summarize("main.go", diff); ok {
+		t.Error("main.go isn't a lockfile")
+	}
+}
+
+func TestRender(t *testing.T) {
+	out := Render("go.sum", []Change{
+		{Name: "a", Old: "v1", New: "v2"},
+		{Name: "bbb", New: "v1"},
+		{Name: "c", Old: "v3"},
+	})
+	lines := strings.Split(out, "\n")
+	want := []string{
+		"@@ go.sum: 1 upgraded, 1 added, 1 removed @@",
+		"  upgraded   a   v1 → v2",
+		"+ added      bbb v1",
+		"- removed    c   v3",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got:\n%s", out)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int // sign
+	}{
+		{"v1.9.0", "v1.10.0", -1},
+		{"1.0.0", "1.0.0", 0},
+		{"2.0", "1.99", 1},
+		{"v0.0.0-2023", "v0.0.0-2024", -1},
+	}
+	for _, tt := range tests {
+		got := compareVersions(tt.a, tt.b)
+		if (got > 0) != (tt.want > 0) || (got < 0) != (tt.want < 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
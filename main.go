@@ -1,32 +1,236 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/crash"
+	"github.com/gerunddev/tcr/follow"
 	"github.com/gerunddev/tcr/output"
+	"github.com/gerunddev/tcr/profile"
+	"github.com/gerunddev/tcr/record"
+	"github.com/gerunddev/tcr/stats"
 	"github.com/gerunddev/tcr/ui"
 	"github.com/gerunddev/tcr/vcs"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "help", "-h", "--help":
+			os.Exit(runHelp(os.Args[2:]))
+		case "version", "--version":
+			os.Exit(runVersion())
+		case "update":
+			os.Exit(runUpdate(os.Args[2:]))
+		case "stats":
+			os.Exit(runStats())
+		case "snapshot":
+			os.Exit(runSnapshot(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "auth":
+			os.Exit(runAuth(os.Args[2:]))
+		case "publish":
+			os.Exit(runPublish(os.Args[2:]))
+		case "followup":
+			os.Exit(runFollowup(os.Args[2:]))
+		case "hook":
+			os.Exit(runHook(os.Args[2:]))
+		case "view":
+			os.Exit(runView(os.Args[2:]))
+		case "replay":
+			os.Exit(runReplay(os.Args[2:]))
+		}
+	}
+
+	profileDir, args := profileFlag(os.Args[1:])
+	share, shareSocket, args := socketFlag(args, "--share")
+	following, followSocket, args := socketFlag(args, "--follow")
+	exitCodes, args := boolFlag(args, "--exit-code")
+	quick, args := boolFlag(args, "--quick")
+	ignoreWhitespace, args := boolFlag(args, "--ignore-whitespace")
+	from, args, fromErr := valueFlag(args, "--from")
+	to, args, toErr := valueFlag(args, "--to")
+	revset, args, revsetErr := valueFlag(args, "--revset")
+	scopeName, args, scopeErr := valueFlag(args, "--scope")
+	previousPath, args, previousErr := valueFlag(args, "--previous")
+	recordPath, args, recordErr := valueFlag(args, "--record")
+	prRef, args, prErr := valueFlag(args, "--pr")
+	patchPath, args, patchErr := valueFlag(args, "--patch")
+	backend, args, backendErr := valueFlag(args, "--backend")
+	interdiff, args, interdiffErr := pairFlag(args, "--interdiff")
+	rangeDiff, args, rangeDiffErr := pairFlag(args, "--range-diff")
+	include, args, includeErr := globFlag(args, "--include")
+	exclude, args, excludeErr := globFlag(args, "--exclude")
+	if err := errors.Join(fromErr, toErr, revsetErr, scopeErr, previousErr, recordErr, prErr, patchErr, backendErr, interdiffErr, rangeDiffErr, includeErr, excludeErr); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if interdiff != nil {
+		if from != "" || to != "" || revset != "" {
+			fmt.Fprintf(os.Stderr, "Error: --interdiff takes both changes itself, without --from, --to or --revset\n")
+			os.Exit(1)
+		}
+		from, to = interdiff[0], interdiff[1]
+	}
+	scope, err := vcs.ParseScope(scopeName)
+	if scopeName != "" && err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --scope: %v\n", err)
+		os.Exit(1)
+	}
+	native, err := parseBackend(backend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --backend: %v\n", err)
+		os.Exit(1)
+	}
+	if share && following {
+		fmt.Fprintf(os.Stderr, "Error: --share and --follow can't be used together\n")
+		os.Exit(1)
+	}
+
+	// "tcr compare A B" reviews the differences between two trees or archives
+	var compareDirs []string
+	if len(args) > 0 && args[0] == "compare" {
+		if len(args) < 3 || len(args) > 4 {
+			fmt.Fprintf(os.Stderr, "Usage: tcr compare A B [output.md]\n")
+			os.Exit(1)
+		}
+		compareDirs, args = args[1:3], args[3:]
+	}
+
+	// "tcr gerrit CHANGE" and "tcr gitea PR" review changes on a server
+	var forge, forgeRef string
+	inbox := len(args) > 0 && args[0] == "inbox"
+	if inbox {
+		if len(args) > 2 {
+			fmt.Fprintf(os.Stderr, "Usage: tcr inbox [output.md]\n")
+			os.Exit(1)
+		}
+		args = args[1:]
+	}
+	if !inbox && len(args) > 0 && (args[0] == "gerrit" || args[0] == "gitea") {
+		if len(args) < 2 || len(args) > 3 {
+			fmt.Fprintf(os.Stderr, "Usage: tcr gerrit CHANGE [output.md]\n       tcr gitea PR [output.md]\n")
+			os.Exit(1)
+		}
+		forge, forgeRef, args = args[0], args[1], args[2:]
+	}
+
+	// "tcr -" reviews a diff piped in, like --patch -
+	if len(args) > 0 && args[0] == "-" && patchPath == "" {
+		patchPath, args = "-", args[1:]
+	}
+	if patchPath != "" && (compareDirs != nil || forge != "" || inbox || prRef != "" || interdiff != nil || rangeDiff != nil || from != "" || to != "" || revset != "" || scopeName != "") {
+		fmt.Fprintf(os.Stderr, "Error: --patch reviews the diff given, and takes no other source of changes\n")
+		os.Exit(1)
+	}
+
+	if prRef != "" {
+		if forge != "" || inbox || compareDirs != nil {
+			fmt.Fprintf(os.Stderr, "Error: --pr reviews a GitHub pull request, not another source of changes\n")
+			os.Exit(1)
+		}
+		forge, forgeRef = "github", prRef
+	}
+
+	if rangeDiff != nil && (compareDirs != nil || forge != "" || inbox || interdiff != nil || from != "" || to != "" || revset != "" || scopeName != "") {
+		fmt.Fprintf(os.Stderr, "Error: --range-diff compares two versions of a git branch, and takes no other revisions\n")
+		os.Exit(1)
+	}
+	if interdiff != nil && (compareDirs != nil || forge != "" || inbox) {
+		fmt.Fprintf(os.Stderr, "Error: --interdiff compares two versions of a change in a jj repository\n")
+		os.Exit(1)
+	}
+	if (from != "" || to != "") && (compareDirs != nil || forge != "" || inbox) {
+		fmt.Fprintf(os.Stderr, "Error: --from and --to review revisions of a git or jj repository\n")
+		os.Exit(1)
+	}
+	if revset != "" && (compareDirs != nil || forge != "" || inbox) {
+		fmt.Fprintf(os.Stderr, "Error: --revset picks the base of a jj repository's changes\n")
+		os.Exit(1)
+	}
+	if scopeName != "" && (compareDirs != nil || forge != "" || inbox || from != "" || to != "") {
+		fmt.Fprintf(os.Stderr, "Error: --scope picks staged or unstaged changes in a git working copy\n")
+		os.Exit(1)
+	}
+
+	// The earlier review to re-review against
+	var previous []output.Feedback
+	if previousPath != "" {
+		var ok bool
+		if previous, _, ok = readReview(previousPath); !ok {
+			os.Exit(1)
+		}
+		if len(previous) == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %s has no comments to re-review\n", previousPath)
+		}
+	}
+
+	// Load preferences (falls back to defaults on error)
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// First launch: walk the user through a short setup
+	if err == nil && !config.Exists() && isTerminal(os.Stdin) {
+		var tokens map[string]string
+		cfg, tokens, err = ui.RunOnboarding()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: setup failed: %v\n", err)
+		}
+		if path, err := cfg.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Saved config to %s\n", path)
+		}
+		saveTokens(tokens)
+	}
+
+	// "tcr inbox" picks a change awaiting review, then reviews it like
+	// "tcr gerrit" or "tcr gitea"
+	if inbox {
+		if forge, forgeRef, err = pickFromInbox(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if forge == "" {
+			os.Exit(0)
+		}
+	}
+
+	// Offer to pick up where a crashed session left off
+	resume := pendingResume()
+
 	var outputPath string
 
-	if len(os.Args) < 2 {
-		// Generate a random filename in /tmp
+	if len(args) == 0 && resume != nil && resume.State.OutputPath != "" {
+		outputPath = resume.State.OutputPath
+		fmt.Fprintf(os.Stderr, "Output file: %s\n", outputPath)
+	} else if len(args) == 0 {
+		// Generate a random filename in the configured output directory
 		randomBytes := make([]byte, 8)
 		if _, err := rand.Read(randomBytes); err != nil {
 			fmt.Fprintf(os.Stderr, "Error generating random filename: %v\n", err)
 			os.Exit(1)
 		}
-		outputPath = filepath.Join("/tmp", "tcr-"+hex.EncodeToString(randomBytes)+".md")
+		outputPath = filepath.Join(cfg.OutputDir, "tcr-"+hex.EncodeToString(randomBytes)+".md")
 		fmt.Fprintf(os.Stderr, "Output file: %s\n", outputPath)
 	} else {
-		outputPath = os.Args[1]
+		outputPath = args[0]
 	}
 
 	if err := output.ValidateOutputPath(outputPath); err != nil {
@@ -34,19 +238,331 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Detect VCS
-	v, err := vcs.Detect(".")
+	// Detect VCS, or use the trees or change given
+	var v vcs.VCS
+	opts := vcs.Options{ContextLines: cfg.ContextLines, DiffTools: cfg.DiffTools, From: from, To: to, BaseRevset: cfg.JJRevset, Scope: scope, Interdiff: interdiff != nil, Native: native, IgnoreWhitespace: ignoreWhitespace, Include: include, Exclude: exclude}
+	if revset != "" {
+		opts.BaseRevset = revset
+	}
+	switch {
+	case patchPath != "":
+		v, err = readPatch(patchPath)
+	case compareDirs != nil:
+		v, err = vcs.NewCompare(compareDirs[0], compareDirs[1], opts)
+	case rangeDiff != nil:
+		v, err = vcs.NewRangeDiff(context.Background(), ".", rangeDiff[0], rangeDiff[1])
+	case forge == "gerrit":
+		v, err = fetchGerritChange(cfg, forgeRef)
+	case forge == "gitea":
+		v, err = fetchGiteaPR(cfg, forgeRef)
+	case forge == "github":
+		v, err = fetchGitHubPR(cfg, forgeRef)
+	default:
+		v, err = vcs.DetectWithOptions(".", opts)
+	}
+	if _, ok := v.(*vcs.Native); err == nil && opts.Native && !ok {
+		err = fmt.Errorf("--backend native reviews a git repository's changes, not a %s", v.Name())
+	}
+	if err == nil && opts.Interdiff && v.Name() != "jj" {
+		err = fmt.Errorf("--interdiff needs a jj repository, not a %s one", v.Name())
+	}
+	if err == nil && opts.HasRange() && v.Name() != "git" && v.Name() != "jj" {
+		err = fmt.Errorf("--from and --to need a git or jj repository, not a %s", v.Name())
+	}
+	if err == nil && revset != "" && v.Name() != "jj" {
+		err = fmt.Errorf("--revset needs a jj repository, not a %s one", v.Name())
+	}
+	if _, scoped := v.(vcs.Scoped); err == nil && scopeName != "" && !scoped {
+		err = fmt.Errorf("--scope needs a git repository; %s has no staging area", v.Name())
+	}
+	if _, ok := v.(vcs.Configurable); err == nil && (include != nil || exclude != nil) && !ok {
+		err = fmt.Errorf("--include and --exclude need a git or jj repository or tcr compare, not a %s", v.Name())
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Create and run app
-	app := ui.NewApp(v, outputPath)
-	p := tea.NewProgram(app, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	app := ui.NewApp(v, outputPath, cfg)
+	if resume != nil {
+		app.Resume(resume.State)
+	}
+	if previousPath != "" {
+		app.SetPrevious(previousPath, previous)
+	}
+	if quick {
+		app.SetQuick()
+	}
+	var sharing *follow.Broadcaster
+	if share || following {
+		socket, err := sharedSocket(shareSocket + followSocket)
+		if err == nil && share {
+			sharing, err = follow.Listen(socket)
+			if err == nil {
+				app.SetShare(sharing)
+			}
+		} else if err == nil {
+			var f *follow.Follower
+			if f, err = follow.Dial(socket); err == nil {
+				app.SetFollow(f)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	guard := ui.NewGuard(app, currentVersion())
+
+	var model tea.Model = guard
+	var prof *profile.Profiler
+	if profileDir != "" {
+		if prof, err = startProfiling(profileDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		model = ui.NewProfiled(guard, prof)
+	}
+	var rec *record.Recorder
+	if recordPath != "" {
+		if rec, err = startRecording(recordPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		model = ui.NewRecorded(model, rec)
+		app.SetRecorder(rec)
+	}
 
-	if _, err := p.Run(); err != nil {
+	programOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if patchPath == "-" {
+		// Stdin was the patch; keys come from the terminal
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(model, programOpts...)
+	start := time.Now()
+
+	_, err = p.Run()
+	// Stop diffs still loading in the background
+	app.Close()
+	if prof != nil {
+		stopProfiling(prof)
+	}
+	if sharing != nil {
+		sharing.Close()
+	}
+	if rec != nil {
+		if err := rec.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Session recorded to %s; replay it with tcr replay %s\n", recordPath, recordPath)
+		}
+	}
+	if c, ok := v.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	if report, err := guard.Crash(); report != nil {
+		fmt.Fprintf(os.Stderr, "tcr crashed: %s\n", report.Panic)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not write crash report: %v\n%s", err, report.Stack)
+		} else {
+			fmt.Fprintf(os.Stderr, "Crash report: %s\nRun tcr again to resume the review.\n", report.Path)
+		}
+		os.Exit(1)
+	}
+
+	// Re-review: record which earlier comments were addressed
+	if earlier, resolved := app.Rereview(); earlier != nil {
+		if err := output.AppendRereview(outputPath, earlier, resolved); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Addressed %d of %d earlier comment(s); report added to %s\n", len(resolved), len(earlier), outputPath)
+		}
+	}
+
+	// Opt-in, local-only usage stats
+	if app.Config().Stats {
+		sess := stats.Session{Start: start, End: time.Now(), Comments: app.CommentCount()}
+		if err := recordStats(sess); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	// Let wrapper scripts branch on the outcome of the review
+	if exitCodes {
+		os.Exit(severityExitCode(app.Severity()))
+	}
+}
+
+// severityExitCode maps the worst comment severity to the exit code used
+// with --exit-code: 0 for none, 2 for issues, 3 for blockers (1 is left
+// for errors)
+func severityExitCode(s output.Severity) int {
+	switch s {
+	case output.SeverityBlocker:
+		return 3
+	case output.SeverityIssue:
+		return 2
+	}
+	return 0
+}
+
+// boolFlag removes every occurrence of flag from args, reporting whether
+// it was present
+func boolFlag(args []string, flag string) (bool, []string) {
+	found := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return found, rest
+}
+
+// valueFlag removes "flag VALUE" or "flag=VALUE" from args and returns
+// the value, the last one winning if repeated
+func valueFlag(args []string, flag string) (string, []string, error) {
+	var value string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == flag:
+			if i+1 == len(args) {
+				return "", nil, fmt.Errorf("%s needs a value", flag)
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, flag+"="):
+			value = strings.TrimPrefix(arg, flag+"=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return value, rest, nil
+}
+
+// globFlag removes every "flag GLOB" or "flag=GLOB" from args and returns
+// the globs, checking each is well formed
+func globFlag(args []string, flag string) ([]string, []string, error) {
+	var globs []string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == flag:
+			if i+1 == len(args) {
+				return nil, nil, fmt.Errorf("%s needs a value", flag)
+			}
+			i++
+			globs = append(globs, args[i])
+		case strings.HasPrefix(arg, flag+"="):
+			globs = append(globs, strings.TrimPrefix(arg, flag+"="))
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	for _, glob := range globs {
+		if err := vcs.CheckGlob(glob); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", flag, err)
+		}
+	}
+	return globs, rest, nil
+}
+
+// parseBackend reports whether --backend picks the native git backend
+func parseBackend(name string) (bool, error) {
+	switch name {
+	case "", "git":
+		return false, nil
+	case "native":
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown backend %q (want git or native)", name)
+}
+
+// pairFlag removes "flag A B" from args and returns the two values, or nil
+// if the flag isn't given
+func pairFlag(args []string, flag string) ([]string, []string, error) {
+	var values []string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] != flag {
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+2 >= len(args) {
+			return nil, nil, fmt.Errorf("%s needs two values", flag)
+		}
+		values = args[i+1 : i+3]
+		i += 2
+	}
+	return values, rest, nil
+}
+
+// pendingResume returns the last crash report if the user wants to resume
+// it. The offer is only made once.
+func pendingResume() *crash.Report {
+	dir, err := crash.Dir()
+	if err != nil {
+		return nil
+	}
+	report, err := crash.LoadResume(dir)
+	if err != nil || report == nil {
+		return nil
+	}
+	_ = crash.ClearResume(dir)
+
+	if !isTerminal(os.Stdin) {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "tcr crashed on %s (report: %s)\n", report.Time.Format("2006-01-02 15:04"), report.Path)
+	if report.State.File != "" {
+		fmt.Fprintf(os.Stderr, "Resume review at %s line %d? [Y/n] ", report.State.File, report.State.Line+1)
+	} else {
+		fmt.Fprintf(os.Stderr, "Resume review? [Y/n] ")
+	}
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return report
+	}
+	return nil
+}
+
+// readPatch loads a diff to review without a VCS, from a file or, for
+// "-", stdin
+func readPatch(path string) (vcs.VCS, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		if isTerminal(os.Stdin) {
+			return nil, fmt.Errorf("no patch piped in; try git diff | tcr -")
+		}
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch: %w", err)
+	}
+	return vcs.NewPatch("patch", string(data))
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
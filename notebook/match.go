@@ -0,0 +1,119 @@
+package notebook
+
+import "strings"
+
+// pair links a cell in the old notebook to one in the new; -1 means the
+// cell exists on only one side
+type pair struct {
+	old, new int
+}
+
+// pairCells matches up the cells of two notebooks, in new-notebook order
+// with removed cells where they used to be. Cells are matched by id when
+// every cell has one, otherwise by type and source; cells left over
+// between two matches are paired in order as edits of each other.
+func pairCells(old, new []Cell) []pair {
+	key := func(c Cell) string { return c.Type + "\x00" + strings.Join(c.Source, "\n") }
+	if hasIDs(old) && hasIDs(new) {
+		key = func(c Cell) string { return c.ID }
+	}
+	oldKeys := make([]string, len(old))
+	for i, c := range old {
+		oldKeys[i] = key(c)
+	}
+	newKeys := make([]string, len(new))
+	for i, c := range new {
+		newKeys[i] = key(c)
+	}
+
+	var pairs []pair
+	i, j := 0, 0
+	flush := func(oldEnd, newEnd int) {
+		for i < oldEnd && j < newEnd {
+			pairs = append(pairs, pair{i, j})
+			i++
+			j++
+		}
+		for ; i < oldEnd; i++ {
+			pairs = append(pairs, pair{i, -1})
+		}
+		for ; j < newEnd; j++ {
+			pairs = append(pairs, pair{-1, j})
+		}
+	}
+	for _, m := range lcs(oldKeys, newKeys) {
+		flush(m.old, m.new)
+		pairs = append(pairs, m)
+		i, j = m.old+1, m.new+1
+	}
+	flush(len(old), len(new))
+	return pairs
+}
+
+// hasIDs reports whether every cell has an id
+func hasIDs(cells []Cell) bool {
+	for _, c := range cells {
+		if c.ID == "" {
+			return false
+		}
+	}
+	return len(cells) > 0
+}
+
+// lcs returns the index pairs of a longest common subsequence of a and b
+func lcs(a, b []string) []pair {
+	// table[i][j] is the LCS length of a[i:] and b[j:]
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var matches []pair
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matches = append(matches, pair{i, j})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+// diffLines returns old and new merged line by line, prefixed " ", "-" or
+// "+" like a unified diff
+func diffLines(old, new []string) []string {
+	var out []string
+	i, j := 0, 0
+	for _, m := range lcs(old, new) {
+		for ; i < m.old; i++ {
+			out = append(out, "-"+old[i])
+		}
+		for ; j < m.new; j++ {
+			out = append(out, "+"+new[j])
+		}
+		out = append(out, " "+old[i])
+		i++
+		j++
+	}
+	for ; i < len(old); i++ {
+		out = append(out, "-"+old[i])
+	}
+	for ; j < len(new); j++ {
+		out = append(out, "+"+new[j])
+	}
+	return out
+}
@@ -0,0 +1,219 @@
+// Package notebook renders Jupyter notebook diffs as cell changes
+package notebook
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Cell is one notebook cell, reduced to what a reviewer reads
+type Cell struct {
+	Type    string // "code", "markdown" or "raw"
+	ID      string // nbformat 4.5 cell id, if any
+	Source  []string
+	Outputs []string // Rendered output text, one entry per line
+}
+
+// Recognized reports whether p is a notebook
+func Recognized(p string) bool {
+	return strings.EqualFold(path.Ext(p), ".ipynb")
+}
+
+// Parse reads the cells of a notebook. An empty src is a notebook with no
+// cells, for files that were added or deleted.
+func Parse(src string) ([]Cell, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	var nb struct {
+		Cells []struct {
+			CellType string          `json:"cell_type"`
+			ID       string          `json:"id"`
+			Source   json.RawMessage `json:"source"`
+			Outputs  []output        `json:"outputs"`
+		} `json:"cells"`
+	}
+	if err := json.Unmarshal([]byte(src), &nb); err != nil {
+		return nil, fmt.Errorf("parsing notebook: %w", err)
+	}
+
+	cells := make([]Cell, 0, len(nb.Cells))
+	for _, c := range nb.Cells {
+		cell := Cell{Type: c.CellType, ID: c.ID, Source: splitLines(multiline(c.Source))}
+		for _, o := range c.Outputs {
+			cell.Outputs = append(cell.Outputs, o.render()...)
+		}
+		cells = append(cells, cell)
+	}
+	return cells, nil
+}
+
+// output is a code cell output. Execution counts are left out on purpose:
+// they change on every run and say nothing about the code.
+type output struct {
+	OutputType string                     `json:"output_type"`
+	Name       string                     `json:"name"`
+	Text       json.RawMessage            `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	Ename      string                     `json:"ename"`
+	Evalue     string                     `json:"evalue"`
+}
+
+// render returns the output's text lines. Rich data shows as its MIME type,
+// since images and HTML can't be read in a terminal diff.
+func (o output) render() []string {
+	switch o.OutputType {
+	case "stream":
+		return splitLines(multiline(o.Text))
+	case "error":
+		return []string{o.Ename + ": " + o.Evalue}
+	}
+	if text, ok := o.Data["text/plain"]; ok {
+		return splitLines(multiline(text))
+	}
+	types := make([]string, 0, len(o.Data))
+	for t := range o.Data {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	var lines []string
+	for _, t := range types {
+		lines = append(lines, "<"+t+">")
+	}
+	return lines
+}
+
+// multiline decodes nbformat text, stored as either a string or a list of
+// lines
+func multiline(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var parts []string
+	if json.Unmarshal(raw, &parts) == nil {
+		return strings.Join(parts, "")
+	}
+	return ""
+}
+
+// splitLines splits text into lines without a trailing empty one
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// Diff renders the cell changes between two notebooks as diff-like text.
+// Each changed cell gets a "@@ cell N ..." header followed by its source
+// with changed lines marked, and a separate "@@ cell N · outputs @@" hunk
+// when its outputs changed. Cells are numbered from 1 in the new notebook,
+// or the old one for removed cells.
+func Diff(oldSrc, newSrc string) (string, error) {
+	oldCells, err := Parse(oldSrc)
+	if err != nil {
+		return "", err
+	}
+	newCells, err := Parse(newSrc)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, p := range pairCells(oldCells, newCells) {
+		switch {
+		case p.old < 0:
+			c := newCells[p.new]
+			fmt.Fprintf(&b, "@@ cell %d · %s · added @@\n", p.new+1, c.Type)
+			writeLines(&b, "+", c.Source)
+			writeOutputs(&b, p.new+1, nil, c.Outputs)
+		case p.new < 0:
+			c := oldCells[p.old]
+			fmt.Fprintf(&b, "@@ cell %d · %s · removed @@\n", p.old+1, c.Type)
+			writeLines(&b, "-", c.Source)
+		default:
+			o, n := oldCells[p.old], newCells[p.new]
+			if !equal(o.Source, n.Source) || o.Type != n.Type {
+				kind := n.Type
+				if o.Type != n.Type {
+					kind = o.Type + " → " + n.Type
+				}
+				fmt.Fprintf(&b, "@@ cell %d · %s · modified @@\n", p.new+1, kind)
+				writeDiff(&b, o.Source, n.Source)
+			}
+			writeOutputs(&b, p.new+1, o.Outputs, n.Outputs)
+		}
+	}
+	if b.Len() == 0 {
+		return "@@ notebook: no cell changes (metadata or execution counts only) @@", nil
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// writeOutputs adds an outputs hunk if a cell's outputs changed
+func writeOutputs(b *strings.Builder, n int, old, new []string) {
+	if equal(old, new) {
+		return
+	}
+	fmt.Fprintf(b, "@@ cell %d · outputs @@\n", n)
+	writeDiff(b, old, new)
+}
+
+// writeLines writes each line with prefix
+func writeLines(b *strings.Builder, prefix string, lines []string) {
+	for _, l := range lines {
+		b.WriteString(prefix + l + "\n")
+	}
+}
+
+// writeDiff writes a whole cell with the lines only in old marked "-" and
+// those only in new marked "+"
+func writeDiff(b *strings.Builder, old, new []string) {
+	for _, op := range diffLines(old, new) {
+		b.WriteString(op + "\n")
+	}
+}
+
+// equal reports whether two line slices match
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// outputsHeader matches the header of an outputs hunk
+var outputsHeader = regexp.MustCompile(`^@@ cell \d+ · outputs @@$`)
+
+// CollapseOutputs replaces the body of each outputs hunk in a rendered
+// notebook diff with one line counting the changed output lines
+func CollapseOutputs(diff string) string {
+	lines := strings.Split(diff, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		out = append(out, lines[i])
+		if !outputsHeader.MatchString(lines[i]) {
+			continue
+		}
+		changed := 0
+		for i+1 < len(lines) && !strings.HasPrefix(lines[i+1], "@@") {
+			i++
+			if strings.HasPrefix(lines[i], "+") || strings.HasPrefix(lines[i], "-") {
+				changed++
+			}
+		}
+		out = append(out, fmt.Sprintf(" ⋯ %d output lines changed", changed))
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,124 @@
+package notebook
+
+import (
+	"strings"
+	"testing"
+)
+
+const oldNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis\n", "Loads the data."]},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "outputs": [],
+   "source": ["import pandas as pd\n", "df = pd.read_csv('a.csv')"]},
+  {"cell_type": "code", "execution_count": 2, "metadata": {},
+   "outputs": [{"output_type": "execute_result", "execution_count": 2,
+     "data": {"text/plain": ["3"]}, "metadata": {}}],
+   "source": "len(df)"},
+  {"cell_type": "code", "execution_count": 3, "metadata": {}, "outputs": [], "source": "old_cell()"}
+ ],
+ "metadata": {}, "nbformat": 4, "nbformat_minor": 4
+}`
+
+const newNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis\n", "Loads the data."]},
+  {"cell_type": "code", "execution_count": 7, "metadata": {}, "outputs": [],
+   "source": ["import pandas as pd\n", "df = pd.read_csv('b.csv')"]},
+  {"cell_type": "code", "execution_count": 8, "metadata": {},
+   "outputs": [{"output_type": "execute_result", "execution_count": 8,
+     "data": {"text/plain": ["5"]}, "metadata": {}},
+    {"output_type": "display_data", "data": {"image/png": "iVBOR..."}, "metadata": {}}],
+   "source": "len(df)"},
+  {"cell_type": "code", "execution_count": 9, "metadata": {},
+   "outputs": [{"output_type": "stream", "name": "stdout", "text": ["done\n"]}],
+   "source": "print('done')"}
+ ],
+ "metadata": {}, "nbformat": 4, "nbformat_minor": 4
+}`
+
+func TestDiff(t *testing.T) {
+	diff, err := Diff(oldNotebook, newNotebook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `@@ cell 2 · code · modified @@
+ import pandas as pd
+-df = pd.read_csv('a.csv')
++df = pd.read_csv('b.csv')
+@@ cell 3 · outputs @@
+-3
++5
++<image/png>
+@@ cell 4 · code · modified @@
+-old_cell()
++print('done')
+@@ cell 4 · outputs @@
++done`
+	if diff != want {
+		t.Errorf("got:\n%s\nwant:\n%s", diff, want)
+	}
+}
+
+func TestDiff_AddedRemovedByID(t *testing.T) {
+	old := `{"cells": [
+	  {"cell_type": "code", "id": "a", "source": "x = 1", "outputs": []},
+	  {"cell_type": "code", "id": "b", "source": "y = 2", "outputs": []}]}`
+	new := `{"cells": [
+	  {"cell_type": "code", "id": "b", "source": "y = 3", "outputs": []},
+	  {"cell_type": "markdown", "id": "c", "source": "Notes"}]}`
+
+	diff, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `@@ cell 1 · code · removed @@
+-x = 1
+@@ cell 1 · code · modified @@
+-y = 2
++y = 3
+@@ cell 2 · markdown · added @@
++Notes`
+	if diff != want {
+		t.Errorf("got:\n%s\nwant:\n%s", diff, want)
+	}
+}
+
+func TestDiff_NewNotebookAndMetadataOnly(t *testing.T) {
+	diff, err := Diff("", oldNotebook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(diff, "@@ cell 1 · markdown · added @@\n+# Analysis") {
+		t.Errorf("added notebook:\n%s", diff)
+	}
+
+	rerun := strings.ReplaceAll(oldNotebook, `"execution_count": 2`, `"execution_count": 12`)
+	diff, _ = Diff(oldNotebook, rerun)
+	if !strings.Contains(diff, "no cell changes") {
+		t.Errorf("execution counts alone should not be a change:\n%s", diff)
+	}
+
+	if _, err := Diff("{not json", oldNotebook); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestCollapseOutputs(t *testing.T) {
+	diff, _ := Diff(oldNotebook, newNotebook)
+	got := CollapseOutputs(diff)
+	if strings.Contains(got, "<image/png>") || strings.Contains(got, "+done") {
+		t.Errorf("outputs should be collapsed:\n%s", got)
+	}
+	if !strings.Contains(got, "@@ cell 3 · outputs @@\n ⋯ 3 output lines changed\n") {
+		t.Errorf("expected a collapsed marker with the count:\n%s", got)
+	}
+	if !strings.Contains(got, "+df = pd.read_csv('b.csv')") {
+		t.Errorf("source changes should be kept:\n%s", got)
+	}
+}
+
+func TestRecognized(t *testing.T) {
+	if !Recognized("nb/Analysis.IPYNB") || Recognized("main.py") {
+		t.Error("Recognized should match .ipynb only")
+	}
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// anchorSlack is how far a commented line may move and still count as
+// unchanged: edits above it shift it without addressing the comment
+const anchorSlack = 20
+
+// Anchors maps each commented line, as path:line, to the text the line had
+// when the comment was written, so a re-review can tell which lines changed
+type Anchors map[string]string
+
+// AnchorsFile returns where the anchors of a review file are kept: a file
+// under the user cache named after the review's absolute path
+func AnchorsFile(reviewPath string) (string, error) {
+	absPath, err := filepath.Abs(reviewPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve review path: %w", err)
+	}
+	base := os.Getenv("TCR_ANCHOR_DIR")
+	if base == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot locate cache directory: %w", err)
+		}
+		base = filepath.Join(cache, "tcr", "anchors")
+	}
+	sum := sha256.Sum256([]byte(absPath))
+	return filepath.Join(base, hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// LoadAnchors reads the anchors recorded for a review file; a review
+// without any has none
+func LoadAnchors(reviewPath string) (Anchors, error) {
+	path, err := AnchorsFile(reviewPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Anchors{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anchors: %w", err)
+	}
+	anchors := Anchors{}
+	if err := json.Unmarshal(data, &anchors); err != nil {
+		return nil, fmt.Errorf("failed to parse anchors %s: %w", path, err)
+	}
+	return anchors, nil
+}
+
+// SaveAnchor records the text of the line a comment in a review file is on
+func SaveAnchor(reviewPath, filePath string, line int, text string) error {
+	anchors, err := LoadAnchors(reviewPath)
+	if err != nil {
+		return err
+	}
+	anchors[location(filePath, line)] = text
+
+	path, err := AnchorsFile(reviewPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create anchors directory: %w", err)
+	}
+	data, err := json.MarshalIndent(anchors, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write anchors: %w", err)
+	}
+	return nil
+}
+
+// Anchor returns the recorded text of a commented line
+func (a Anchors) Anchor(filePath string, line int) (string, bool) {
+	text, ok := a[location(filePath, line)]
+	return text, ok
+}
+
+// LineChanged reports whether the anchored text is gone from around line
+// (1-based) in a file's lines: the line was edited or removed, rather than
+// just moved a little by edits elsewhere
+func LineChanged(anchor string, lines []string, line int) bool {
+	anchor = strings.TrimRight(anchor, " \t\r")
+	for d := 0; d <= anchorSlack; d++ {
+		for _, n := range []int{line - d, line + d} {
+			if n >= 1 && n <= len(lines) && strings.TrimRight(lines[n-1], " \t\r") == anchor {
+				return false
+			}
+		}
+	}
+	return true
+}
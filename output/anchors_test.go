@@ -0,0 +1,59 @@
+package output
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAnchor(t *testing.T) {
+	t.Setenv("TCR_ANCHOR_DIR", t.TempDir())
+	review := filepath.Join(t.TempDir(), "feedback.md")
+
+	anchors, err := LoadAnchors(review)
+	if err != nil || len(anchors) != 0 {
+		t.Fatalf("LoadAnchors before saving = %v, %v; want none", anchors, err)
+	}
+	if err := SaveAnchor(review, "a.go", 3, "\treturn nil"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveAnchor(review, "b.go", 0, "package b"); err != nil {
+		t.Fatal(err)
+	}
+
+	anchors, err = LoadAnchors(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, ok := anchors.Anchor("a.go", 3); !ok || text != "\treturn nil" {
+		t.Errorf("Anchor(a.go, 3) = %q, %v", text, ok)
+	}
+	if _, ok := anchors.Anchor("a.go", 4); ok {
+		t.Error("Anchor(a.go, 4) found a line that wasn't commented")
+	}
+
+	other, err := LoadAnchors(filepath.Join(t.TempDir(), "feedback.md"))
+	if err != nil || len(other) != 0 {
+		t.Errorf("another review's anchors = %v, %v; want none", other, err)
+	}
+}
+
+func TestLineChanged(t *testing.T) {
+	lines := []string{"package a", "", "func f() int {", "\treturn 1 ", "}"}
+	tests := []struct {
+		name   string
+		anchor string
+		line   int
+		want   bool
+	}{
+		{"same line", "\treturn 1", 4, false},
+		{"moved", "\treturn 1", 1, false},
+		{"edited", "\treturn 0", 4, true},
+		{"removed", "\tpanic(err)", 4, true},
+		{"past the end", "}", 30, true},
+	}
+	for _, tt := range tests {
+		if got := LineChanged(tt.anchor, lines, tt.line); got != tt.want {
+			t.Errorf("%s: LineChanged = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package output
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Label is a Conventional Comments label (https://conventionalcomments.org)
+// and the emoji that can stand beside it
+type Label struct {
+	Name  string
+	Emoji string
+}
+
+// Labels are the labels offered when commenting, in the order they cycle
+var Labels = []Label{
+	{Name: "praise", Emoji: "👏"},
+	{Name: "nitpick", Emoji: "🤓"},
+	{Name: "suggestion", Emoji: "💡"},
+	{Name: "issue", Emoji: "🐛"},
+	{Name: "todo", Emoji: "📝"},
+	{Name: "question", Emoji: "❓"},
+	{Name: "thought", Emoji: "💭"},
+	{Name: "chore", Emoji: "🧹"},
+	{Name: "note", Emoji: "📌"},
+}
+
+// LookupLabel finds a label by name
+func LookupLabel(name string) (Label, bool) {
+	for _, l := range Labels {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return Label{}, false
+}
+
+// trimLabelEmoji drops a label's emoji from the start of a comment, so
+// "🐛 issue: ..." reads like "issue: ..."
+func trimLabelEmoji(comment string) string {
+	for _, l := range Labels {
+		if rest, ok := strings.CutPrefix(comment, l.Emoji); ok {
+			return strings.TrimLeft(rest, " ")
+		}
+	}
+	return comment
+}
+
+// labelPrefix matches a label with optional decorations at the start of a
+// comment, as in "suggestion (non-blocking): "
+var labelPrefix = regexp.MustCompile(`^([a-z]+)\s*(?:\(([^)]*)\))?:\s*`)
+
+// SplitLabel separates a comment's leading label, written with or without
+// its emoji, from the rest. The label is "" if the comment doesn't start
+// with one of Labels.
+func SplitLabel(comment string) (label, decorations, body string) {
+	trimmed := trimLabelEmoji(comment)
+	m := labelPrefix.FindStringSubmatch(trimmed)
+	if m == nil {
+		return "", "", comment
+	}
+	if _, ok := LookupLabel(m[1]); !ok {
+		return "", "", comment
+	}
+	return m[1], m[2], trimmed[len(m[0]):]
+}
+
+// WithLabel starts body with a label and its decorations, such as
+// "issue (blocking): ", preceded by the label's emoji if emoji is set.
+// An empty or unknown label leaves body as it is.
+func WithLabel(body, label, decorations string, emoji bool) string {
+	l, ok := LookupLabel(label)
+	if !ok {
+		return body
+	}
+	prefix := l.Name
+	if decorations != "" {
+		prefix += " (" + decorations + ")"
+	}
+	if emoji {
+		prefix = l.Emoji + " " + prefix
+	}
+	return prefix + ": " + body
+}
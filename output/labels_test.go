@@ -0,0 +1,42 @@
+package output
+
+import "testing"
+
+func TestSplitLabel(t *testing.T) {
+	tests := []struct {
+		comment                  string
+		label, decorations, body string
+	}{
+		{"praise: nice test", "praise", "", "nice test"},
+		{"🐛 issue (blocking): leaks\nthe file", "issue", "blocking", "leaks\nthe file"},
+		{"suggestion(non-blocking):use a map", "suggestion", "non-blocking", "use a map"},
+		{"nit: not a conventional label", "", "", "nit: not a conventional label"},
+		{"Plain comment", "", "", "Plain comment"},
+	}
+	for _, tt := range tests {
+		label, decorations, body := SplitLabel(tt.comment)
+		if label != tt.label || decorations != tt.decorations || body != tt.body {
+			t.Errorf("SplitLabel(%q) = %q, %q, %q", tt.comment, label, decorations, body)
+		}
+	}
+}
+
+func TestWithLabel(t *testing.T) {
+	if got := WithLabel("use a map", "suggestion", "", false); got != "suggestion: use a map" {
+		t.Errorf("got %q", got)
+	}
+	if got := WithLabel("leaks", "issue", "blocking", true); got != "🐛 issue (blocking): leaks" {
+		t.Errorf("got %q", got)
+	}
+	if got := WithLabel("text", "", "", true); got != "text" {
+		t.Errorf("no label should leave the text alone, got %q", got)
+	}
+
+	// Labels round-trip through SplitLabel
+	for _, l := range Labels {
+		label, _, body := SplitLabel(WithLabel("body", l.Name, "", true))
+		if label != l.Name || body != "body" {
+			t.Errorf("%s: round trip gave %q, %q", l.Name, label, body)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Feedback is one comment read back from an output file
+type Feedback struct {
+	FilePath string
+	Line     int // 0 for a comment on the whole file
+	Comment  string
+}
+
+// feedbackHeader matches the "@path:line" or "@path" line that starts a
+// comment
+var feedbackHeader = regexp.MustCompile(`^@(\S.*?)(?::(\d+))?$`)
+
+// ParseFeedback reads the comments and notes written by AppendFeedback and
+// AppendNotes. A header only counts after a blank line, so comments can
+// mention "@someone" on lines of their own. Several notes sections are
+// joined with blank lines; re-review reports are skipped.
+func ParseFeedback(text string) (comments []Feedback, notes string) {
+	var current *Feedback
+	var body []string
+	var noteParts []string
+	inNotes := false
+	inReport := false
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(body, "\n"))
+		switch {
+		case current != nil && text != "":
+			current.Comment = text
+			comments = append(comments, *current)
+		case inNotes && text != "":
+			noteParts = append(noteParts, text)
+		}
+		current, body, inNotes, inReport = nil, nil, false, false
+	}
+
+	prevBlank := true
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if prevBlank {
+			if m := feedbackHeader.FindStringSubmatch(line); m != nil {
+				flush()
+				n, _ := strconv.Atoi(m[2])
+				current = &Feedback{FilePath: m[1], Line: n}
+				prevBlank = false
+				continue
+			}
+			if line == "## Notes" {
+				flush()
+				inNotes = true
+				prevBlank = false
+				continue
+			}
+			if line == rereviewHeading {
+				flush()
+				inReport = true
+				prevBlank = false
+				continue
+			}
+		}
+		if inReport {
+			prevBlank = strings.TrimSpace(line) == ""
+			continue
+		}
+		body = append(body, line)
+		prevBlank = strings.TrimSpace(line) == ""
+	}
+	flush()
+	return comments, strings.Join(noteParts, "\n\n")
+}
@@ -0,0 +1,41 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFeedbackRoundTrip(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "feedback.md")
+	writes := []func() error{
+		func() error { return AppendFeedback(outputPath, "src/main.go", 3, "Fix this") },
+		func() error { return AppendFeedback(outputPath, "README.md", 0, "Whole file\n@alice\nmentioned") },
+		func() error { return AppendNotes(outputPath, "Add the thing\n\nDetails") },
+		func() error { return AppendFeedback(outputPath, "a b.go", 7, "Spaces are fine") },
+		func() error { return AppendNotes(outputPath, "More notes") },
+	}
+	for _, write := range writes {
+		if err := write(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comments, notes := ParseFeedback(string(content))
+	want := []Feedback{
+		{FilePath: "src/main.go", Line: 3, Comment: "Fix this"},
+		{FilePath: "README.md", Comment: "Whole file\n@alice\nmentioned"},
+		{FilePath: "a b.go", Line: 7, Comment: "Spaces are fine"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("comments = %+v, want %+v", comments, want)
+	}
+	if notes != "Add the thing\n\nDetails\n\nMore notes" {
+		t.Errorf("notes = %q", notes)
+	}
+}
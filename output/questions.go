@@ -0,0 +1,58 @@
+package output
+
+import "strings"
+
+// IsQuestion reports whether a comment is labeled as a question, as in
+// "question: why not a map?" or "❓ question: ..."
+func IsQuestion(comment string) bool {
+	label, _, _ := SplitLabel(strings.TrimSpace(comment))
+	return label == "question"
+}
+
+// IsResolved reports whether a comment carries a "(resolved)" decoration
+func IsResolved(comment string) bool {
+	_, decorations, _ := SplitLabel(strings.TrimSpace(comment))
+	for _, d := range strings.Split(decorations, ",") {
+		if strings.TrimSpace(d) == "resolved" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAnswered reports whether a question has a reply: a quoted line, as in
+// "> Because the keys are sparse"
+func IsAnswered(comment string) bool {
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenQuestions returns the questions not yet marked resolved, in order
+func OpenQuestions(comments []Feedback) []Feedback {
+	var open []Feedback
+	for _, c := range comments {
+		if IsQuestion(c.Comment) && !IsResolved(c.Comment) {
+			open = append(open, c)
+		}
+	}
+	return open
+}
+
+// MarkResolved adds a "(resolved)" decoration to a labeled comment,
+// keeping its label, emoji and other decorations
+func MarkResolved(comment string) string {
+	label, decorations, body := SplitLabel(comment)
+	if label == "" || IsResolved(comment) {
+		return comment
+	}
+	if decorations != "" {
+		decorations += ", "
+	}
+	l, _ := LookupLabel(label)
+	emoji := strings.HasPrefix(comment, l.Emoji)
+	return WithLabel(body, label, decorations+"resolved", emoji)
+}
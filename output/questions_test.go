@@ -0,0 +1,41 @@
+package output
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenQuestions(t *testing.T) {
+	comments := []Feedback{
+		{FilePath: "a.go", Line: 1, Comment: "question: why a map?"},
+		{FilePath: "a.go", Line: 2, Comment: "issue: leaks"},
+		{FilePath: "b.go", Line: 3, Comment: "question (resolved): why not?\n> because"},
+		{FilePath: "b.go", Line: 4, Comment: "❓ question (non-blocking): is this tested?\n> yes, in b_test.go"},
+		{FilePath: "c.go", Comment: "Is this a question?"},
+	}
+	want := []Feedback{comments[0], comments[3]}
+	if got := OpenQuestions(comments); !reflect.DeepEqual(got, want) {
+		t.Errorf("OpenQuestions = %+v, want %+v", got, want)
+	}
+	if IsAnswered(comments[0].Comment) || !IsAnswered(comments[3].Comment) {
+		t.Error("only the quoted reply counts as an answer")
+	}
+}
+
+func TestMarkResolved(t *testing.T) {
+	tests := map[string]string{
+		"question: why?": "question (resolved): why?",
+		"❓ question (non-blocking): why?\n> ok": "❓ question (non-blocking, resolved): why?\n> ok",
+		"question (resolved): why?":             "question (resolved): why?",
+		"no label":                              "no label",
+	}
+	for in, want := range tests {
+		got := MarkResolved(in)
+		if got != want {
+			t.Errorf("MarkResolved(%q) = %q, want %q", in, got, want)
+		}
+		if in != "no label" && !IsResolved(got) {
+			t.Errorf("%q should be resolved", got)
+		}
+	}
+}
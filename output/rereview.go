@@ -0,0 +1,45 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rereviewHeading starts the re-review report, which ParseFeedback skips
+const rereviewHeading = "## Re-review"
+
+// RereviewReport summarizes a re-review: which of an earlier review's
+// comments were addressed, with the hunk that addressed each, and which
+// are still open. resolved maps an index in comments to its hunk.
+func RereviewReport(comments []Feedback, resolved map[int]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\nAddressed %d of %d earlier comment(s).\n", rereviewHeading, len(resolved), len(comments))
+
+	var addressed, open []string
+	for i, c := range comments {
+		item := fmt.Sprintf("- `%s` %s", location(c.FilePath, c.Line), firstLine(c.Comment))
+		if hunk, ok := resolved[i]; ok {
+			addressed = append(addressed, item+fmt.Sprintf(" (in `%s`)", hunk))
+		} else {
+			open = append(open, item)
+		}
+	}
+	if len(addressed) > 0 {
+		b.WriteString("\n### Addressed\n\n" + strings.Join(addressed, "\n") + "\n")
+	}
+	if len(open) > 0 {
+		b.WriteString("\n### Still open\n\n" + strings.Join(open, "\n") + "\n")
+	}
+	return b.String() + "\n"
+}
+
+// AppendRereview appends a re-review report to the output file
+func AppendRereview(outputPath string, comments []Feedback, resolved map[int]string) error {
+	return appendText(outputPath, RereviewReport(comments, resolved))
+}
+
+// firstLine returns the first line of a comment
+func firstLine(comment string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(comment), "\n")
+	return first
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRereviewReport(t *testing.T) {
+	comments := []Feedback{
+		{FilePath: "a.go", Line: 3, Comment: "issue: off by one\nsee the loop"},
+		{FilePath: "b.go", Comment: "Needs a test"},
+		{FilePath: "c.go", Line: 9, Comment: "nitpick: rename"},
+	}
+	got := RereviewReport(comments, map[int]string{0: "@@ -1,4 +1,4 @@", 2: "@@ -8 +8 @@"})
+	want := "## Re-review\n\nAddressed 2 of 3 earlier comment(s).\n" +
+		"\n### Addressed\n\n" +
+		"- `a.go:3` issue: off by one (in `@@ -1,4 +1,4 @@`)\n" +
+		"- `c.go:9` nitpick: rename (in `@@ -8 +8 @@`)\n" +
+		"\n### Still open\n\n" +
+		"- `b.go` Needs a test\n\n"
+	if got != want {
+		t.Errorf("report:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseFeedbackSkipsRereview(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "feedback.md")
+	earlier := []Feedback{{FilePath: "a.go", Line: 3, Comment: "Fix this"}}
+	if err := AppendFeedback(outputPath, "a.go", 5, "New comment"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendRereview(outputPath, earlier, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendFeedback(outputPath, "b.go", 1, "After the report"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comments, _ := ParseFeedback(string(data))
+	want := []Feedback{
+		{FilePath: "a.go", Line: 5, Comment: "New comment"},
+		{FilePath: "b.go", Line: 1, Comment: "After the report"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("comments = %+v, want %+v", comments, want)
+	}
+}
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Severity ranks how much a comment holds up a change
+type Severity int
+
+const (
+	// SeverityNone covers questions, nits, praise and unlabeled comments
+	SeverityNone Severity = iota
+	// SeverityIssue is a problem that should be fixed
+	SeverityIssue
+	// SeverityBlocker must be fixed before the change can land
+	SeverityBlocker
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityIssue:
+		return "issue"
+	case SeverityBlocker:
+		return "blocker"
+	}
+	return "none"
+}
+
+// commentLabel matches a leading label such as "issue:", "[blocker]" or
+// "suggestion (blocking):", capturing the label and its decorations
+var commentLabel = regexp.MustCompile(`^\[?\s*([a-z][a-z -]*?)\s*(?:\(([^)]*)\))?\s*[:\]]`)
+
+// labelSeverities maps comment labels to their severity; other labels
+// count as SeverityNone
+var labelSeverities = map[string]Severity{
+	"blocker":  SeverityBlocker,
+	"blocking": SeverityBlocker,
+	"must fix": SeverityBlocker,
+	"issue":    SeverityIssue,
+	"bug":      SeverityIssue,
+	"problem":  SeverityIssue,
+	"fix":      SeverityIssue,
+}
+
+// Classify reads a comment's severity from the label it starts with, as
+// in "blocker: this drops user data" or "🐛 issue: ...". A "(blocking)"
+// decoration makes any label a blocker.
+func Classify(comment string) Severity {
+	first, _, _ := strings.Cut(trimLabelEmoji(strings.TrimSpace(comment)), "\n")
+	m := commentLabel.FindStringSubmatch(strings.ToLower(first))
+	if m == nil {
+		return SeverityNone
+	}
+	for _, decoration := range strings.Split(m[2], ",") {
+		if strings.TrimSpace(decoration) == "blocking" {
+			return SeverityBlocker
+		}
+	}
+	return labelSeverities[m[1]]
+}
@@ -0,0 +1,25 @@
+package output
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := map[string]Severity{
+		"blocker: this drops the users table":      SeverityBlocker,
+		"[Blocker] race on shutdown":               SeverityBlocker,
+		"suggestion (blocking): use a transaction": SeverityBlocker,
+		"issue (non-blocking): leaks a goroutine":  SeverityIssue,
+		"Issue: off by one\nsee line 40":           SeverityIssue,
+		"bug: nil map write":                       SeverityIssue,
+		"🐛 issue: unchecked error":                 SeverityIssue,
+		"nit: trailing space":                      SeverityNone,
+		"question: why not a map?":                 SeverityNone,
+		"This looks good":                          SeverityNone,
+		"note the issue: it's fine":                SeverityNone,
+		"":                                         SeverityNone,
+	}
+	for comment, want := range tests {
+		if got := Classify(comment); got != want {
+			t.Errorf("Classify(%q) = %v, want %v", comment, got, want)
+		}
+	}
+}
@@ -14,6 +14,76 @@ import (
 // that can span multiple lines
 //
 func AppendFeedback(outputPath, filePath string, line int, comment string) error {
+	return appendText(outputPath, formatFeedback(filePath, line, comment))
+}
+
+// formatFeedback returns a comment as written to the output file:
+// @path:line (or @path if line is 0), the comment, then a blank line.
+// Several comments on one line are written as separate blocks.
+func formatFeedback(filePath string, line int, comment string) string {
+	if line > 0 {
+		return fmt.Sprintf("@%s:%d\n%s\n\n", filePath, line, strings.TrimSpace(comment))
+	}
+	return fmt.Sprintf("@%s\n%s\n\n", filePath, strings.TrimSpace(comment))
+}
+
+// ReplaceFeedback rewrites a comment previously written by AppendFeedback,
+// or removes it if comment is empty. The last matching block is changed,
+// since this session's comments are at the end of the file.
+func ReplaceFeedback(outputPath, filePath string, line int, old, comment string) error {
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+	text := string(data)
+	block := formatFeedback(filePath, line, old)
+
+	// A block only starts a comment at the top of the file or after a
+	// blank line; elsewhere it's text inside another comment
+	i := len(text)
+	for {
+		i = strings.LastIndex(text[:i], block)
+		if i < 0 {
+			return fmt.Errorf("comment on %s not found in %s; was the file edited?", location(filePath, line), outputPath)
+		}
+		if i == 0 || strings.HasSuffix(text[:i], "\n\n") {
+			break
+		}
+	}
+
+	var replacement string
+	if strings.TrimSpace(comment) != "" {
+		replacement = formatFeedback(filePath, line, comment)
+	}
+	text = text[:i] + replacement + text[i+len(block):]
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read output file: %w", err)
+	}
+	if err := os.WriteFile(outputPath, []byte(text), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// location formats a comment's place as path:line, or path for the file
+func location(filePath string, line int) string {
+	if line > 0 {
+		return fmt.Sprintf("%s:%d", filePath, line)
+	}
+	return filePath
+}
+
+// AppendNotes appends free-form review notes (such as a change
+// description) to the output file under a "## Notes" heading
+func AppendNotes(outputPath, notes string) error {
+	return appendText(outputPath, "## Notes\n\n"+strings.TrimSpace(notes)+"\n\n")
+}
+
+// appendText appends text to the output file, creating it and its
+// directory if needed
+func appendText(outputPath, text string) error {
 	// Ensure directory exists
 	dir := filepath.Dir(outputPath)
 	if dir != "" && dir != "." {
@@ -29,21 +99,9 @@ func AppendFeedback(outputPath, filePath string, line int, comment string) error
 	}
 	defer func() { _ = f.Close() }()
 
-	// Format the feedback
-	// @path:line (or @path if line is 0)
-	// comment
-	//
-	var feedback string
-	if line > 0 {
-		feedback = fmt.Sprintf("@%s:%d\n%s\n\n", filePath, line, strings.TrimSpace(comment))
-	} else {
-		feedback = fmt.Sprintf("@%s\n%s\n\n", filePath, strings.TrimSpace(comment))
+	if _, err := f.WriteString(text); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
 	}
-
-	if _, err := f.WriteString(feedback); err != nil {
-		return fmt.Errorf("failed to write feedback: %w", err)
-	}
-
 	return nil
 }
 
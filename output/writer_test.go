@@ -100,3 +100,61 @@ func TestValidateOutputPath(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendNotes(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "feedback.md")
+
+	if err := AppendFeedback(outputPath, "src/main.go", 3, "Fix this"); err != nil {
+		t.Fatalf("AppendFeedback failed: %v", err)
+	}
+	if err := AppendNotes(outputPath, "Add the thing\n\nDetails\n\n"); err != nil {
+		t.Fatalf("AppendNotes failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	expected := "@src/main.go:3\nFix this\n\n## Notes\n\nAdd the thing\n\nDetails\n\n"
+	if string(content) != expected {
+		t.Errorf("unexpected output:\n%q\nwant:\n%q", content, expected)
+	}
+}
+
+func TestReplaceFeedback(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "feedback.md")
+	for _, c := range []string{"First", "Second", "Third"} {
+		if err := AppendFeedback(outputPath, "src/main.go", 3, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AppendNotes(outputPath, "Notes"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReplaceFeedback(outputPath, "src/main.go", 3, "Second", "Second, revised\nover two lines"); err != nil {
+		t.Fatalf("ReplaceFeedback failed: %v", err)
+	}
+	if err := ReplaceFeedback(outputPath, "src/main.go", 3, "First", ""); err != nil {
+		t.Fatalf("ReplaceFeedback (delete) failed: %v", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "@src/main.go:3\nSecond, revised\nover two lines\n\n@src/main.go:3\nThird\n\n## Notes\n\nNotes\n\n"
+	if string(content) != expected {
+		t.Errorf("unexpected output:\n%q\nwant:\n%q", content, expected)
+	}
+
+	comments, _ := ParseFeedback(string(content))
+	if len(comments) != 2 || comments[0].Line != 3 || comments[1].Comment != "Third" {
+		t.Errorf("stacked comments should parse separately, got %+v", comments)
+	}
+
+	err = ReplaceFeedback(outputPath, "src/main.go", 3, "Gone", "")
+	if err == nil || !strings.Contains(err.Error(), "src/main.go:3 not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
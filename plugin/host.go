@@ -0,0 +1,189 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Host runs the plugins configured and routes tcr's requests to them
+type Host struct {
+	commands map[string]string // Command per plugin name
+
+	mu      sync.Mutex
+	plugins []*Plugin // Those running, by name
+}
+
+// NewHost creates a host for plugins given as name = command
+func NewHost(commands map[string]string) *Host {
+	return &Host{commands: commands}
+}
+
+// Configured reports whether any plugins are configured
+func (h *Host) Configured() bool {
+	return len(h.commands) > 0
+}
+
+// Start starts the plugins by name. Those that fail are left out and
+// their errors returned together.
+func (h *Host) Start(ctx context.Context, info Info) error {
+	names := make([]string, 0, len(h.commands))
+	for name := range h.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	started := make([]*Plugin, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, Timeout)
+			defer cancel()
+			started[i], errs[i] = Start(ctx, name, h.commands[name], info)
+		}()
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, p := range started {
+		if p != nil {
+			h.plugins = append(h.plugins, p)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Running returns the names of the plugins started
+func (h *Host) Running() []string {
+	var names []string
+	for _, p := range h.running() {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Item is an action or panel in the plugin menu
+type Item struct {
+	Plugin string
+	Panel  bool
+	Entry
+}
+
+// Items lists every plugin's actions, then its panels, by plugin. Entries
+// without a title go by their ID.
+func (h *Host) Items() []Item {
+	var items []Item
+	add := func(p *Plugin, panel bool, e Entry) {
+		if e.Title == "" {
+			e.Title = e.ID
+		}
+		items = append(items, Item{Plugin: p.Name, Panel: panel, Entry: e})
+	}
+	for _, p := range h.running() {
+		for _, e := range p.Manifest.Actions {
+			add(p, false, e)
+		}
+		for _, e := range p.Manifest.Panels {
+			add(p, true, e)
+		}
+	}
+	return items
+}
+
+// Run runs an action or renders a panel at loc
+func (h *Host) Run(ctx context.Context, item Item, loc Location) (Result, error) {
+	p := h.plugin(item.Plugin)
+	if p == nil {
+		return Result{}, fmt.Errorf("plugin %s isn't running", item.Plugin)
+	}
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	if item.Panel {
+		return p.Panel(ctx, item.ID, loc)
+	}
+	return p.Action(ctx, item.ID, loc)
+}
+
+// Finder is a finding and the plugin it came from
+type Finder struct {
+	Plugin string
+	Finding
+}
+
+// Findings asks every plugin that flags lines about loc's diff. Findings
+// from the plugins that answered are returned along with the errors of
+// those that didn't.
+func (h *Host) Findings(ctx context.Context, loc Location) ([]Finder, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	var result []Finder
+	var errs []error
+	for _, p := range h.running() {
+		if !p.Manifest.Findings {
+			continue
+		}
+		fs, err := p.Findings(ctx, loc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, f := range fs {
+			result = append(result, Finder{Plugin: p.Name, Finding: f})
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// FindsLines reports whether any plugin flags lines
+func (h *Host) FindsLines() bool {
+	for _, p := range h.running() {
+		if p.Manifest.Findings {
+			return true
+		}
+	}
+	return false
+}
+
+// Commented tells every plugin about a comment saved. Plugins that have
+// quit are skipped.
+func (h *Host) Commented(c Comment) {
+	for _, p := range h.running() {
+		_ = p.notify("comment", c)
+	}
+}
+
+// Close ends the session of every plugin
+func (h *Host) Close(f Finish) {
+	var wg sync.WaitGroup
+	for _, p := range h.running() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Close(f)
+		}()
+	}
+	wg.Wait()
+}
+
+// running returns the plugins started so far
+func (h *Host) running() []*Plugin {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.plugins
+}
+
+// plugin finds a running plugin by name
+func (h *Host) plugin(name string) *Plugin {
+	for _, p := range h.running() {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
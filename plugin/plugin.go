@@ -0,0 +1,330 @@
+// Package plugin runs external programs that extend tcr without forking
+// it: a company lint flagging diff lines, a ticket integration acting on
+// the file under review, a coverage panel. A plugin is any command that
+// reads requests on stdin and writes responses on stdout, one JSON object
+// per line:
+//
+//	{"id":1,"method":"start","params":{"version":1,"vcs":"git",...}}
+//	{"id":1,"result":{"findings":true,"actions":[...],"panels":[...]}}
+//
+// A request without an id is a notification, which gets no response. A
+// failed request is answered with {"id":1,"error":"message"}.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the protocol version sent with start
+const Version = 1
+
+// Timeout bounds each request, so a stuck plugin can't hold up the review
+const Timeout = 10 * time.Second
+
+// closeTimeout is how long a plugin has to exit after finish
+const closeTimeout = 2 * time.Second
+
+// maxLine bounds one response, which may carry a whole panel
+const maxLine = 16 << 20
+
+// Info tells a plugin what is being reviewed, with the start request
+type Info struct {
+	Version int    `json:"version"`
+	VCS     string `json:"vcs"`    // "git", "jj", ...
+	Root    string `json:"root"`   // Directory tcr runs in
+	Output  string `json:"output"` // Review file comments are written to
+}
+
+// Manifest is a plugin's answer to start: what it adds to tcr
+type Manifest struct {
+	Findings bool    `json:"findings"` // Flags lines of each diff shown
+	Actions  []Entry `json:"actions"`  // Run on the cursor's line
+	Panels   []Entry `json:"panels"`   // Show text about the cursor's line
+}
+
+// Entry is an action or panel, picked from tcr's plugin menu
+type Entry struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Location is where the reviewer is: a file, the cursor's line in it as
+// numbered after the change (0 for none), and the file's diff
+type Location struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Diff string `json:"diff"`
+}
+
+// Finding flags a line of a file, numbered as after the change. Lines the
+// diff doesn't show aren't flagged.
+type Finding struct {
+	Line    int    `json:"line"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result is what an action or panel returns; any field may be empty
+type Result struct {
+	Message string `json:"message,omitempty"` // Shown in the status bar
+	Text    string `json:"text,omitempty"`    // Shown in a panel
+	Comment string `json:"comment,omitempty"` // Saved as feedback on the location's line
+}
+
+// Comment is the notification sent for each comment saved
+type Comment struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Comment string `json:"comment"`
+}
+
+// Finish is the notification sent as the review ends
+type Finish struct {
+	Output   string `json:"output"`
+	Comments int    `json:"comments"`
+}
+
+// request is one line sent to a plugin; ID is 0 for a notification
+type request struct {
+	ID     int    `json:"id,omitempty"`
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+// response is one line read from a plugin
+type response struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error"`
+}
+
+// Plugin is a running plugin process
+type Plugin struct {
+	Name     string
+	Manifest Manifest
+
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan response // Closed once stdout is, with waitErr set
+	waitErr   error
+	stderr    tail
+
+	mu     sync.Mutex // One request at a time
+	nextID int
+}
+
+// Start runs command through sh -c and asks it what it adds to tcr
+func Start(ctx context.Context, name, command string, info Info) (*Plugin, error) {
+	p := &Plugin{Name: name, cmd: exec.Command("sh", "-c", command), responses: make(chan response, 16)}
+	p.cmd.Stderr = &p.stderr
+	p.cmd.WaitDelay = closeTimeout
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	p.stdin = stdin
+	go p.read(stdout)
+
+	if err := p.call(ctx, "start", info, &p.Manifest); err != nil {
+		p.kill()
+		return nil, err
+	}
+	return p, nil
+}
+
+// read passes responses on until the plugin closes stdout. Lines that
+// aren't JSON, such as stray debug output, are skipped.
+func (p *Plugin) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for scanner.Scan() {
+		var resp response
+		if json.Unmarshal(scanner.Bytes(), &resp) == nil && resp.ID > 0 {
+			p.responses <- resp
+		}
+	}
+	// Unblock a plugin still writing, such as one whose line was too long
+	_, _ = io.Copy(io.Discard, stdout)
+	p.waitErr = p.cmd.Wait()
+	close(p.responses)
+}
+
+// call sends a request and decodes its result into result, waiting at
+// most until ctx is done. A response that comes too late is skipped by
+// the next call.
+func (p *Plugin) call(ctx context.Context, method string, params, result any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	id := p.nextID
+	if err := p.send(request{ID: id, Method: method, Params: params}); err != nil {
+		return p.sendFailed(err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("plugin %s: %s: %w", p.Name, method, ctx.Err())
+		case resp, ok := <-p.responses:
+			if !ok {
+				return p.exited()
+			}
+			if resp.ID != id {
+				continue
+			}
+			if resp.Error != "" {
+				return fmt.Errorf("plugin %s: %s", p.Name, resp.Error)
+			}
+			if result == nil || len(resp.Result) == 0 || string(resp.Result) == "null" {
+				return nil
+			}
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("plugin %s: malformed %s result: %w", p.Name, method, err)
+			}
+			return nil
+		}
+	}
+}
+
+// notify sends a notification, which gets no response
+func (p *Plugin) notify(method string, params any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.send(request{Method: method, Params: params})
+}
+
+// send writes one request line; the caller holds mu
+func (p *Plugin) send(req request) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// sendFailed explains a request that couldn't be written, usually as the
+// plugin quit, by waiting a moment for it to exit
+func (p *Plugin) sendFailed(err error) error {
+	if p.wait() {
+		return p.exited()
+	}
+	return err
+}
+
+// exited describes a plugin that quit, with the last line it printed to
+// stderr
+func (p *Plugin) exited() error {
+	msg := "exited"
+	if p.waitErr != nil {
+		msg += ": " + p.waitErr.Error()
+	}
+	if last := p.stderr.last(); last != "" {
+		msg += ": " + last
+	}
+	return fmt.Errorf("plugin %s %s", p.Name, msg)
+}
+
+// Findings asks for the lines to flag in loc's diff
+func (p *Plugin) Findings(ctx context.Context, loc Location) ([]Finding, error) {
+	var result struct {
+		Findings []Finding `json:"findings"`
+	}
+	err := p.call(ctx, "findings", loc, &result)
+	return result.Findings, err
+}
+
+// Action runs the action id at loc
+func (p *Plugin) Action(ctx context.Context, id string, loc Location) (Result, error) {
+	return p.run(ctx, "action", id, loc)
+}
+
+// Panel renders the panel id for loc
+func (p *Plugin) Panel(ctx context.Context, id string, loc Location) (Result, error) {
+	return p.run(ctx, "panel", id, loc)
+}
+
+// run calls an action or panel, which take the same params
+func (p *Plugin) run(ctx context.Context, method, id string, loc Location) (Result, error) {
+	params := struct {
+		ID string `json:"id"`
+		Location
+	}{id, loc}
+	var result Result
+	err := p.call(ctx, method, params, &result)
+	return result, err
+}
+
+// Close sends finish and gives the plugin a moment to exit once its stdin
+// closes, before killing it
+func (p *Plugin) Close(f Finish) {
+	_ = p.notify("finish", f)
+	p.stdin.Close()
+	if !p.wait() {
+		p.kill()
+	}
+}
+
+// kill stops the plugin and waits a moment for it to go. A process it
+// started may keep stdout open, and is left behind.
+func (p *Plugin) kill() {
+	_ = p.cmd.Process.Kill()
+	p.wait()
+}
+
+// wait skips responses until the plugin exits, for up to closeTimeout,
+// and reports whether it did
+func (p *Plugin) wait() bool {
+	timer := time.NewTimer(closeTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case _, ok := <-p.responses:
+			if !ok {
+				return true
+			}
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// tail keeps the end of what a plugin writes to stderr, for errors
+type tail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tail) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, b...)
+	if len(t.buf) > 4096 {
+		t.buf = t.buf[len(t.buf)-4096:]
+	}
+	return len(b), nil
+}
+
+// last returns the last non-empty line written
+func (t *tail) last() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(strings.TrimSpace(string(t.buf)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lint is a plugin in sh: it answers each request by method and logs the
+// notifications it gets to $LOG
+const lint = `while read -r line; do
+	id=$(printf '%s' "$line" | sed -n 's/^{"id":\([0-9]*\).*/\1/p')
+	method=$(printf '%s' "$line" | sed -n 's/.*"method":"\([a-z]*\)".*/\1/p')
+	case $method in
+	start) echo '{"id":'$id',"result":{"findings":true,"actions":[{"id":"ticket","title":"File a ticket"}],"panels":[{"id":"owners","title":"Owners"}]}}' ;;
+	findings) echo 'not json'; echo '{"id":'$id',"result":{"findings":[{"line":2,"rule":"todo","message":"TODO without a ticket"}]}}' ;;
+	action) echo '{"id":'$id',"result":{"message":"Filed ABC-1","comment":"Tracked in ABC-1"}}' ;;
+	panel) echo '{"id":'$id',"error":"no OWNERS file"}' ;;
+	*) printf '%s\n' "$line" >>"$LOG" ;;
+	esac
+done`
+
+func TestHost(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "log")
+	t.Setenv("LOG", log)
+
+	h := NewHost(map[string]string{"lint": lint, "broken": "echo 'no such config' >&2; exit 3"})
+	if !h.Configured() {
+		t.Fatal("expected plugins to be configured")
+	}
+	err := h.Start(context.Background(), Info{Version: Version, VCS: "git"})
+	if err == nil || !strings.Contains(err.Error(), "plugin broken exited: exit status 3: no such config") {
+		t.Errorf("expected the broken plugin's error, got %v", err)
+	}
+	if got := h.Running(); len(got) != 1 || got[0] != "lint" {
+		t.Fatalf("Running = %v, want only lint", got)
+	}
+
+	items := h.Items()
+	if len(items) != 2 || items[0].Panel || items[0].Title != "File a ticket" || !items[1].Panel || items[1].ID != "owners" {
+		t.Errorf("unexpected items %+v", items)
+	}
+
+	loc := Location{Path: "main.go", Line: 2, Diff: "@@ -1 +1,2 @@\n a\n+// TODO\n"}
+	fs, err := h.Findings(context.Background(), loc)
+	if err != nil || len(fs) != 1 || fs[0] != (Finder{Plugin: "lint", Finding: Finding{Line: 2, Rule: "todo", Message: "TODO without a ticket"}}) {
+		t.Errorf("Findings = %+v, %v", fs, err)
+	}
+	result, err := h.Run(context.Background(), items[0], loc)
+	if err != nil || result != (Result{Message: "Filed ABC-1", Comment: "Tracked in ABC-1"}) {
+		t.Errorf("action = %+v, %v", result, err)
+	}
+	if _, err := h.Run(context.Background(), items[1], loc); err == nil || err.Error() != "plugin lint: no OWNERS file" {
+		t.Errorf("expected the panel's error, got %v", err)
+	}
+	if _, err := h.Run(context.Background(), Item{Plugin: "broken"}, loc); err == nil {
+		t.Error("expected an error running a plugin that didn't start")
+	}
+
+	h.Commented(Comment{Path: "main.go", Line: 2, Comment: "nit: typo"})
+	h.Close(Finish{Output: "review.md", Comments: 1})
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"method":"comment","params":{"path":"main.go","line":2,"comment":"nit: typo"}}
+{"method":"finish","params":{"output":"review.md","comments":1}}
+`
+	if string(data) != want {
+		t.Errorf("notifications =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestStart_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := Start(ctx, "slow", "exec sleep 10", Info{}); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("a plugin that never answers held up start for %v", elapsed)
+	}
+}
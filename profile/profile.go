@@ -0,0 +1,186 @@
+// Package profile records render, update and subprocess timings plus
+// pprof and execution-trace data for the hidden --profile flag
+package profile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Timing kinds
+const (
+	KindRender     = "render"
+	KindUpdate     = "update"
+	KindSubprocess = "subprocess"
+)
+
+// Profiler collects timings and owns the CPU profile and trace files
+type Profiler struct {
+	dir   string
+	cpu   *os.File
+	trace *os.File
+
+	mu      sync.Mutex
+	timings map[string]map[string][]time.Duration // kind -> name -> samples
+}
+
+// New creates a profiler that only records timings, with no pprof output
+func New() *Profiler {
+	return &Profiler{timings: make(map[string]map[string][]time.Duration)}
+}
+
+// Start creates dir and begins CPU profiling and execution tracing into it
+func Start(dir string) (*Profiler, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	p := New()
+	p.dir = dir
+
+	cpu, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	p.cpu = cpu
+
+	tf, err := os.Create(filepath.Join(dir, "trace.out"))
+	if err != nil {
+		p.Stop()
+		return nil, err
+	}
+	if err := trace.Start(tf); err != nil {
+		tf.Close()
+		p.Stop()
+		return nil, fmt.Errorf("failed to start trace: %w", err)
+	}
+	p.trace = tf
+
+	return p, nil
+}
+
+// Dir returns the output directory
+func (p *Profiler) Dir() string {
+	return p.dir
+}
+
+// Observe records one sample
+func (p *Profiler) Observe(kind, name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	byName := p.timings[kind]
+	if byName == nil {
+		byName = make(map[string][]time.Duration)
+		p.timings[kind] = byName
+	}
+	byName[name] = append(byName[name], d)
+}
+
+// Stop ends tracing and profiling and writes heap.pprof and timings.txt
+func (p *Profiler) Stop() error {
+	if p.trace != nil {
+		trace.Stop()
+		p.trace.Close()
+		p.trace = nil
+	}
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		p.cpu.Close()
+		p.cpu = nil
+	}
+	if p.dir == "" {
+		return nil
+	}
+
+	heap, err := os.Create(filepath.Join(p.dir, "heap.pprof"))
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	err = pprof.WriteHeapProfile(heap)
+	heap.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(p.dir, "timings.txt"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	p.WriteSummary(f)
+	return nil
+}
+
+// Stats summarizes the samples for one kind and name
+type Stats struct {
+	Kind  string
+	Name  string
+	Count int
+	Total time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	Max   time.Duration
+}
+
+// Summary returns stats for every recorded series, sorted by kind then
+// descending total time
+func (p *Profiler) Summary() []Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var out []Stats
+	for kind, byName := range p.timings {
+		for name, samples := range byName {
+			sorted := append([]time.Duration(nil), samples...)
+			sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+			s := Stats{Kind: kind, Name: name, Count: len(sorted), Max: sorted[len(sorted)-1]}
+			for _, d := range sorted {
+				s.Total += d
+			}
+			s.P50 = percentile(sorted, 50)
+			s.P95 = percentile(sorted, 95)
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Total > out[j].Total
+	})
+	return out
+}
+
+// WriteSummary writes the timing table
+func (p *Profiler) WriteSummary(w io.Writer) {
+	fmt.Fprintf(w, "%-10s %-40s %7s %10s %10s %10s %10s\n", "KIND", "NAME", "COUNT", "TOTAL", "P50", "P95", "MAX")
+	for _, s := range p.Summary() {
+		fmt.Fprintf(w, "%-10s %-40s %7d %10s %10s %10s %10s\n",
+			s.Kind, s.Name, s.Count, round(s.Total), round(s.P50), round(s.P95), round(s.Max))
+	}
+}
+
+// percentile returns the pth percentile of sorted samples (nearest rank)
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (p*len(sorted)+99)/100 - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,61 @@
+package profile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	p := New()
+	for i := 1; i <= 100; i++ {
+		p.Observe(KindRender, "frame", time.Duration(i)*time.Millisecond)
+	}
+	p.Observe(KindSubprocess, "git diff", 5*time.Millisecond)
+	p.Observe(KindSubprocess, "git status", time.Second)
+
+	stats := p.Summary()
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 series, got %d", len(stats))
+	}
+
+	render := stats[0]
+	if render.Kind != KindRender || render.Count != 100 {
+		t.Fatalf("unexpected first series: %+v", render)
+	}
+	if render.P50 != 50*time.Millisecond || render.P95 != 95*time.Millisecond || render.Max != 100*time.Millisecond {
+		t.Errorf("unexpected percentiles: %+v", render)
+	}
+
+	// Within a kind, series are sorted by total time
+	if stats[1].Name != "git status" || stats[2].Name != "git diff" {
+		t.Errorf("unexpected subprocess order: %q, %q", stats[1].Name, stats[2].Name)
+	}
+
+	var buf bytes.Buffer
+	p.WriteSummary(&buf)
+	if !strings.Contains(buf.String(), "git status") {
+		t.Errorf("summary missing series:\n%s", buf.String())
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "prof")
+	p, err := Start(dir)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	p.Observe(KindUpdate, "tea.KeyMsg", time.Millisecond)
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	for _, name := range []string{"cpu.pprof", "trace.out", "heap.pprof", "timings.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gerunddev/tcr/profile"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// profileFlag extracts the hidden --profile[=dir] flag from args. It
+// returns the output directory ("" when not profiling) and remaining args.
+func profileFlag(args []string) (string, []string) {
+	var dir string
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case arg == "--profile":
+			dir = filepath.Join(os.TempDir(), "tcr-profile-"+time.Now().Format("20060102-150405"))
+		case strings.HasPrefix(arg, "--profile="):
+			dir = strings.TrimPrefix(arg, "--profile=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return dir, rest
+}
+
+// startProfiling begins CPU/trace capture and times VCS subprocesses
+func startProfiling(dir string) (*profile.Profiler, error) {
+	prof, err := profile.Start(dir)
+	if err != nil {
+		return nil, err
+	}
+	vcs.CommandHook = func(name string, args []string, elapsed time.Duration) {
+		label := name
+		if len(args) > 0 {
+			label += " " + args[0]
+		}
+		prof.Observe(profile.KindSubprocess, label, elapsed)
+	}
+	return prof, nil
+}
+
+// stopProfiling flushes profile data and reports where it went
+func stopProfiling(prof *profile.Profiler) {
+	vcs.CommandHook = nil
+	if err := prof.Stop(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Profile written to %s (cpu.pprof, heap.pprof, trace.out, timings.txt)\n", prof.Dir())
+}
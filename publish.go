@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gerunddev/tcr/azure"
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/gerrit"
+	"github.com/gerunddev/tcr/gitea"
+	"github.com/gerunddev/tcr/httpclient"
+	"github.com/gerunddev/tcr/queue"
+)
+
+const publishUsage = `Usage:
+  tcr publish           List reviews queued after failing to publish
+  tcr publish --retry   Publish the queued reviews
+  tcr publish --drop KEY
+                        Discard a queued review
+`
+
+// runPublish implements "tcr publish"
+func runPublish(args []string) int {
+	q, err := openQueue()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	switch {
+	case len(args) == 0:
+		return listQueue(q)
+	case len(args) == 1 && args[0] == "--retry":
+		return retryQueue(q)
+	case len(args) == 2 && args[0] == "--drop":
+		if err := q.Remove(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Dropped %s.\n", args[1])
+		return 0
+	}
+	fmt.Fprint(os.Stderr, publishUsage)
+	return 1
+}
+
+// openQueue returns the publish queue in its default location
+func openQueue() (queue.Queue, error) {
+	dir, err := queue.Dir()
+	if err != nil {
+		return queue.Queue{}, err
+	}
+	return queue.Queue{Dir: dir}, nil
+}
+
+// listQueue prints the queued reviews
+func listQueue(q queue.Queue) int {
+	reviews, err := q.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(reviews) == 0 {
+		fmt.Println("No reviews queued.")
+		return 0
+	}
+	for _, r := range reviews {
+		fmt.Printf("%s  %s %s, queued %s, %d attempt(s)", r.Key, r.Forge, r.Target, r.Queued.Format("2006-01-02 15:04"), r.Attempts)
+		if r.Sent > 0 {
+			fmt.Printf(", %d of %d parts sent", r.Sent, len(r.Parts))
+		}
+		fmt.Printf("\n    %s\n", r.LastError)
+	}
+	return 0
+}
+
+// retryQueue publishes every queued review, keeping those that fail again
+func retryQueue(q queue.Queue) int {
+	reviews, err := q.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if len(reviews) == 0 {
+		fmt.Println("No reviews queued.")
+		return 0
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	published := 0
+	for _, r := range reviews {
+		if err := deliver(cfg, &r); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", r.Key, err)
+			r.Attempts++
+			r.LastError = err.Error()
+			if err := q.Add(r); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			continue
+		}
+		if err := q.Done(r.Key); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		fmt.Printf("Published %s on %s %s\n", r.Key, r.Forge, r.Target)
+		published++
+	}
+	fmt.Printf("Published %d of %d queued review(s).\n", published, len(reviews))
+	if published < len(reviews) {
+		return 1
+	}
+	return 0
+}
+
+// publishReview publishes a review unless it already was, resuming a
+// queued copy's progress. If publishing fails in a way that may pass, or
+// after part of the review was posted, it's queued for tcr publish --retry.
+func publishReview(cfg config.Config, r queue.Review, what string) int {
+	q, err := openQueue()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if q.Sent(r.Key) {
+		fmt.Printf("Already published %s (review %s)\n", what, r.Key)
+		return 0
+	}
+	if queued, ok, err := q.Get(r.Key); err == nil && ok {
+		r = queued
+	}
+
+	err = deliver(cfg, &r)
+	if err == nil {
+		if err := q.Done(r.Key); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		fmt.Printf("Published %s\n", what)
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if httpclient.Retryable(err) || r.Sent > 0 {
+		r.Attempts++
+		r.LastError = err.Error()
+		if err := q.Add(r); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: could not queue the review: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Queued as %s; run tcr publish --retry to send it later.\n", r.Key)
+	}
+	return 1
+}
+
+// deliver posts a review's remaining parts, counting each one sent
+func deliver(cfg config.Config, r *queue.Review) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, part := range r.Remaining() {
+		if err := sendPart(ctx, cfg, *r, part); err != nil {
+			return err
+		}
+		r.Sent++
+	}
+	return nil
+}
+
+// sendPart posts one request body of a review to its forge
+func sendPart(ctx context.Context, cfg config.Config, r queue.Review, part json.RawMessage) error {
+	switch r.Forge {
+	case "gerrit":
+		var in gerrit.ReviewInput
+		if err := json.Unmarshal(part, &in); err != nil {
+			return err
+		}
+		if cfg.GerritURL == "" {
+			return fmt.Errorf("set gerrit_url in the config to publish (see tcr help config)")
+		}
+		client, err := gerritClient(cfg)
+		if err != nil {
+			return err
+		}
+		return client.Publish(ctx, r.Target, r.Revision, in)
+	case "gitea":
+		var in gitea.ReviewInput
+		if err := json.Unmarshal(part, &in); err != nil {
+			return err
+		}
+		pr, err := gitea.ParsePR(r.Target, cfg.GiteaURL)
+		if err != nil {
+			return err
+		}
+		client, err := giteaClient(cfg)
+		if err != nil {
+			return err
+		}
+		return client.Publish(ctx, pr, in)
+	case "azure":
+		var thread azure.Thread
+		if err := json.Unmarshal(part, &thread); err != nil {
+			return err
+		}
+		pr, err := azure.ParsePR(r.Target)
+		if err != nil {
+			return err
+		}
+		token := forgeToken(cfg, "azure")
+		if token == "" {
+			return fmt.Errorf("run tcr auth login azure, or set $AZURE_DEVOPS_EXT_PAT, to publish")
+		}
+		httpClient, err := newHTTPClient(cfg)
+		if err != nil {
+			return err
+		}
+		_, err = azure.Client{Token: token, HTTP: httpClient}.Publish(ctx, pr, []azure.Thread{thread})
+		return err
+	}
+	return fmt.Errorf("unknown forge %q", r.Forge)
+}
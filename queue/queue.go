@@ -0,0 +1,177 @@
+// Package queue keeps reviews that couldn't be published so they can be
+// retried later, and remembers which were published so none is posted twice
+package queue
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Review is a review waiting to be published to a forge
+type Review struct {
+	// Key identifies the review by its forge, target and contents, so
+	// publishing the same review again is recognized
+	Key      string `json:"key"`
+	Forge    string `json:"forge"`
+	Target   string `json:"target"` // Change, or pull request URL
+	Revision string `json:"revision,omitempty"`
+	// Parts are the request bodies, posted in order; Sent counts those
+	// already posted, so a retry resumes after them
+	Parts     []json.RawMessage `json:"parts"`
+	Sent      int               `json:"sent"`
+	Queued    time.Time         `json:"queued"`
+	Attempts  int               `json:"attempts"`
+	LastError string            `json:"last_error,omitempty"`
+}
+
+// NewReview builds a review from the request bodies to post
+func NewReview(forge, target, revision string, parts ...any) (Review, error) {
+	r := Review{Forge: forge, Target: target, Revision: revision}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", forge, target, revision)
+	for _, p := range parts {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return Review{}, err
+		}
+		h.Write([]byte{0})
+		h.Write(data)
+		r.Parts = append(r.Parts, data)
+	}
+	r.Key = hex.EncodeToString(h.Sum(nil))[:16]
+	return r, nil
+}
+
+// Remaining returns the parts not yet posted
+func (r Review) Remaining() []json.RawMessage {
+	return r.Parts[min(r.Sent, len(r.Parts)):]
+}
+
+// Dir returns the queue location: $TCR_QUEUE if set, otherwise tcr/queue
+// under the user config directory
+func Dir() (string, error) {
+	if d := os.Getenv("TCR_QUEUE"); d != "" {
+		return d, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "tcr", "queue"), nil
+}
+
+// Queue stores pending reviews as one JSON file each, and published ones
+// as empty marker files under sent/
+type Queue struct {
+	Dir string
+}
+
+// Add queues a review, or updates it if it's already queued
+func (q Queue) Add(r Review) error {
+	if r.Queued.IsZero() {
+		r.Queued = time.Now()
+	}
+	if err := os.MkdirAll(q.Dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := q.path(r.Key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path(r.Key))
+}
+
+// Get returns a queued review by key. A review published partway keeps
+// its progress here, so callers should prefer it over a fresh copy.
+func (q Queue) Get(key string) (Review, bool, error) {
+	data, err := os.ReadFile(q.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Review{}, false, nil
+		}
+		return Review{}, false, err
+	}
+	var r Review
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Review{}, false, fmt.Errorf("failed to parse queued review %s: %w", key, err)
+	}
+	return r, true, nil
+}
+
+// List returns the queued reviews, oldest first
+func (q Queue) List() ([]Review, error) {
+	entries, err := os.ReadDir(q.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var reviews []Review
+	for _, e := range entries {
+		key, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		r, ok, err := q.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			reviews = append(reviews, r)
+		}
+	}
+	sort.SliceStable(reviews, func(i, j int) bool { return reviews[i].Queued.Before(reviews[j].Queued) })
+	return reviews, nil
+}
+
+// Remove drops a review from the queue without publishing it
+func (q Queue) Remove(key string) error {
+	err := os.Remove(q.path(key))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no queued review %s", key)
+	}
+	return err
+}
+
+// Done records a review as published and drops it from the queue
+func (q Queue) Done(key string) error {
+	if err := os.MkdirAll(filepath.Join(q.Dir, "sent"), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(q.sentPath(key), nil, 0o600); err != nil {
+		return err
+	}
+	if err := os.Remove(q.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Sent reports whether a review with this key was already published
+func (q Queue) Sent(key string) bool {
+	_, err := os.Stat(q.sentPath(key))
+	return err == nil
+}
+
+// path is where a queued review is stored
+func (q Queue) path(key string) string {
+	return filepath.Join(q.Dir, key+".json")
+}
+
+// sentPath is the marker of a published review
+func (q Queue) sentPath(key string) string {
+	return filepath.Join(q.Dir, "sent", key)
+}
@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewReview_Key(t *testing.T) {
+	a, err := NewReview("gitea", "https://codeberg.org/o/r/pulls/1", "", map[string]string{"body": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewReview("gitea", "https://codeberg.org/o/r/pulls/1", "", map[string]string{"body": "x"})
+	c, _ := NewReview("gitea", "https://codeberg.org/o/r/pulls/2", "", map[string]string{"body": "x"})
+	d, _ := NewReview("gitea", "https://codeberg.org/o/r/pulls/1", "", map[string]string{"body": "y"})
+
+	if a.Key != b.Key {
+		t.Error("the same review should get the same key")
+	}
+	if a.Key == c.Key || a.Key == d.Key {
+		t.Error("a different target or body should change the key")
+	}
+	if len(a.Parts) != 1 || string(a.Parts[0]) != `{"body":"x"}` {
+		t.Errorf("unexpected parts %s", a.Parts)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	q := Queue{Dir: t.TempDir()}
+	older, _ := NewReview("azure", "pr", "", "one", "two", "three")
+	older.Queued = time.Now().Add(-time.Hour)
+	newer, _ := NewReview("gerrit", "123", "current", "review")
+
+	for _, r := range []Review{newer, older} {
+		if err := q.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := q.List()
+	if err != nil || len(list) != 2 || list[0].Key != older.Key {
+		t.Fatalf("expected both reviews, oldest first; got %+v, %v", list, err)
+	}
+
+	// Progress is kept, and the rest is what remains to send
+	older.Sent = 2
+	older.Attempts = 1
+	if err := q.Add(older); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := q.Get(older.Key)
+	if err != nil || !ok || got.Sent != 2 || len(got.Remaining()) != 1 || string(got.Remaining()[0]) != `"three"` {
+		t.Errorf("expected the third part to remain, got %+v, %v, %v", got, ok, err)
+	}
+
+	if q.Sent(newer.Key) {
+		t.Error("not published yet")
+	}
+	if err := q.Done(newer.Key); err != nil {
+		t.Fatal(err)
+	}
+	if !q.Sent(newer.Key) {
+		t.Error("expected the review to be recorded as published")
+	}
+	if list, _ := q.List(); len(list) != 1 {
+		t.Errorf("expected the published review to leave the queue, got %+v", list)
+	}
+
+	if err := q.Remove(older.Key); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Remove(older.Key); err == nil {
+		t.Error("expected an error removing a review that isn't queued")
+	}
+}
@@ -0,0 +1,232 @@
+// Package record captures a review session as an asciinema v2 cast: the
+// screen each time it's drawn, plus a marker for each file opened and
+// comment saved. Casts replay in the terminal, or in any asciinema player.
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event kinds, as asciinema names them
+const (
+	KindOutput = "o" // Data is the screen drawn
+	KindMarker = "m" // Data labels a point in the session
+	KindResize = "r" // Data is the new size, as "80x24"
+)
+
+// IdleLimit caps the pauses between events on replay, as asciinema's
+// idle_time_limit does
+const IdleLimit = 2 * time.Second
+
+// Header is the first line of a cast
+type Header struct {
+	Version   int     `json:"version"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Timestamp int64   `json:"timestamp,omitempty"`
+	IdleLimit float64 `json:"idle_time_limit,omitempty"`
+	Title     string  `json:"title,omitempty"`
+}
+
+// Event is one line of a cast after the header
+type Event struct {
+	Time float64 // Seconds since the recording started
+	Kind string
+	Data string
+}
+
+// Recorder writes a cast as the session goes
+type Recorder struct {
+	mu    sync.Mutex
+	w     *bufio.Writer
+	file  *os.File // Closed with the recorder, if it opened one
+	start time.Time
+	last  string // The screen last written, to skip redraws of the same
+	err   error  // The first write error; later writes are dropped
+}
+
+// Create starts a cast at path for a screen of width by height
+func Create(path string, width, height int, title string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	r, err := New(f, width, height, title)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.file = f
+	return r, nil
+}
+
+// New starts a cast written to w for a screen of width by height
+func New(w io.Writer, width, height int, title string) (*Recorder, error) {
+	r := &Recorder{w: bufio.NewWriter(w), start: time.Now()}
+	header, err := json.Marshal(Header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		IdleLimit: IdleLimit.Seconds(),
+		Title:     title,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.w.Write(append(header, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write recording: %w", err)
+	}
+	return r, nil
+}
+
+// Frame records the screen as drawn, unless it's unchanged. Each frame
+// redraws the whole screen from the top left.
+func (r *Recorder) Frame(screen string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if screen == r.last {
+		return
+	}
+	r.last = screen
+	r.write(KindOutput, "\x1b[H\x1b[2J"+strings.ReplaceAll(screen, "\n", "\r\n"))
+}
+
+// Mark records a marker labelling this point in the session
+func (r *Recorder) Mark(label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.write(KindMarker, label)
+}
+
+// Resize records the screen changing size
+func (r *Recorder) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.write(KindResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+// write appends an event, keeping the first error for Close
+func (r *Recorder) write(kind, data string) {
+	if r.err != nil {
+		return
+	}
+	line, err := json.Marshal([]any{roundTime(time.Since(r.start)), kind, data})
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	r.err = err
+}
+
+// roundTime gives d in seconds to the microsecond, as asciinema writes it
+func roundTime(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1e6
+}
+
+// Close flushes the cast, closing its file if Create opened it, and
+// reports the first error writing it
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.err
+	if ferr := r.w.Flush(); err == nil {
+		err = ferr
+	}
+	if r.file != nil {
+		if cerr := r.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// Read parses a cast
+func Read(r io.Reader) (Header, []Event, error) {
+	var header Header
+	scanner := bufio.NewScanner(r)
+	// A frame holds a whole screen
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return header, nil, errors.New("empty recording")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version != 2 {
+		return header, nil, errors.New("not an asciinema v2 recording")
+	}
+
+	var events []Event
+	for n := 2; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var fields []any
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil || len(fields) != 3 {
+			return header, nil, fmt.Errorf("line %d: malformed event", n)
+		}
+		t, tok := fields[0].(float64)
+		kind, kok := fields[1].(string)
+		data, dok := fields[2].(string)
+		if !tok || !kok || !dok {
+			return header, nil, fmt.Errorf("line %d: malformed event", n)
+		}
+		events = append(events, Event{Time: t, Kind: kind, Data: data})
+	}
+	if err := scanner.Err(); err != nil {
+		return header, nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return header, events, nil
+}
+
+// Play writes the output events to w in time, speed times faster, with
+// pauses capped at IdleLimit. sleep waits between events; nil uses
+// time.Sleep.
+func Play(w io.Writer, events []Event, speed float64, sleep func(time.Duration)) error {
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	if speed <= 0 {
+		speed = 1
+	}
+	prev := 0.0
+	for _, e := range events {
+		if e.Kind != KindOutput {
+			continue
+		}
+		pause := min(time.Duration((e.Time-prev)*float64(time.Second)), IdleLimit)
+		prev = e.Time
+		if pause > 0 {
+			sleep(time.Duration(float64(pause) / speed))
+		}
+		if _, err := io.WriteString(w, e.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Markers returns the markers in order, each with its time
+func Markers(events []Event) []Event {
+	var markers []Event
+	for _, e := range events {
+		if e.Kind == KindMarker {
+			markers = append(markers, e)
+		}
+	}
+	return markers
+}
+
+// FormatTime shows seconds into a session as m:ss
+func FormatTime(seconds float64) string {
+	s := int(seconds)
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
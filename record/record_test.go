@@ -0,0 +1,84 @@
+package record
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(&buf, 80, 24, "tcr review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Frame("Files\nDiff")
+	r.Frame("Files\nDiff") // Unchanged, so skipped
+	r.Mark("open a.go")
+	r.Resize(100, 30)
+	r.Frame("wider")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	header, events, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Title != "tcr review" {
+		t.Errorf("unexpected header %+v", header)
+	}
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != "o,m,r,o" {
+		t.Fatalf("expected output, marker, resize, output; got %s", got)
+	}
+	if events[0].Data != "\x1b[H\x1b[2JFiles\r\nDiff" {
+		t.Errorf("expected a full redraw with CRLF line ends, got %q", events[0].Data)
+	}
+	if events[2].Data != "100x30" {
+		t.Errorf("resize = %q", events[2].Data)
+	}
+	if m := Markers(events); len(m) != 1 || m[0].Data != "open a.go" {
+		t.Errorf("Markers = %+v", m)
+	}
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+	if _, _, err := Read(strings.NewReader("# notes\n")); err == nil {
+		t.Error("expected an error for a file that isn't a cast")
+	}
+	if _, _, err := Read(strings.NewReader(`{"version":2,"width":80,"height":24}` + "\n[1, \"o\"]\n")); err == nil {
+		t.Error("expected an error for a malformed event")
+	}
+}
+
+func TestPlay(t *testing.T) {
+	events := []Event{
+		{Time: 0.5, Kind: KindOutput, Data: "a"},
+		{Time: 0.7, Kind: KindMarker, Data: "open a.go"},
+		{Time: 10, Kind: KindOutput, Data: "b"},
+	}
+	var out bytes.Buffer
+	var pauses []time.Duration
+	if err := Play(&out, events, 2, func(d time.Duration) { pauses = append(pauses, d) }); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "ab" {
+		t.Errorf("expected the output events only, got %q", out.String())
+	}
+	// Halved by the speed, and the long pause capped at the idle limit
+	want := []time.Duration{250 * time.Millisecond, IdleLimit / 2}
+	if len(pauses) != 2 || pauses[0] != want[0] || pauses[1] != want[1] {
+		t.Errorf("pauses = %v, want %v", pauses, want)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	if got := FormatTime(75.9); got != "1:15" {
+		t.Errorf("FormatTime = %q", got)
+	}
+}
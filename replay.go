@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/gerunddev/tcr/record"
+)
+
+const replayUsage = `Usage: tcr replay <session.cast> [--speed N] [--markers]
+
+Plays back a review recorded with tcr --record, N times as fast. With
+--markers, lists the files opened and comments saved, with their times,
+instead. The recording is an asciinema cast, so asciinema play works too.
+`
+
+// startRecording creates a cast at path sized to the terminal
+func startRecording(path string) (*record.Recorder, error) {
+	width, height := 80, 24
+	if isTerminal(os.Stdout) {
+		if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil {
+			width, height = w, h
+		}
+	}
+	return record.Create(path, width, height, "tcr review")
+}
+
+// runReplay implements "tcr replay": it plays a recorded session, or lists
+// its markers
+func runReplay(args []string) int {
+	markers, args := boolFlag(args, "--markers")
+	speedFlag, args, err := valueFlag(args, "--speed")
+	if err != nil || len(args) != 1 {
+		fmt.Fprint(os.Stderr, replayUsage)
+		return 1
+	}
+	speed := 1.0
+	if speedFlag != "" {
+		if speed, err = strconv.ParseFloat(speedFlag, 64); err != nil || speed <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: --speed wants a positive number, not %q\n", speedFlag)
+			return 1
+		}
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	header, events, err := record.Read(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", args[0], err)
+		return 1
+	}
+
+	if markers {
+		for _, m := range record.Markers(events) {
+			fmt.Printf("%6s  %s\n", record.FormatTime(m.Time), m.Data)
+		}
+		return 0
+	}
+	if !isTerminal(os.Stdout) {
+		fmt.Fprintln(os.Stderr, "Error: replaying needs a terminal; use --markers to list what happened")
+		return 1
+	}
+	if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 && (w < header.Width || h < header.Height) {
+		fmt.Fprintf(os.Stderr, "Warning: recorded at %dx%d, larger than this %dx%d terminal\n", header.Width, header.Height, w, h)
+	}
+
+	// Hide the cursor while playing, and bring it back if interrupted
+	fmt.Print("\x1b[?25l")
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		fmt.Print("\x1b[?25h\r\n")
+		os.Exit(130)
+	}()
+	err = record.Play(os.Stdout, events, speed, nil)
+	signal.Stop(interrupt)
+	fmt.Print("\x1b[?25h\r\n")
+	if err != nil && !errors.Is(err, os.ErrClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,130 @@
+// Package risk rates how likely a change to each file is to hide a defect,
+// so reviewers can start where their attention pays off most
+package risk
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// untestedFactor weighs a file changed without any of its tests
+const untestedFactor = 2
+
+// Score is a file's risk and what it's made of: the lines the change
+// touches, times one more than the recent commits that touched the file,
+// times untestedFactor when no test for it changed too
+type Score struct {
+	Lines   int
+	Commits int
+	Tested  bool
+	Value   int
+}
+
+// String explains the score, as "Risk 840: 42 lines × 10 (9 recent commits) × 2 (no test changed)"
+func (s Score) String() string {
+	text := fmt.Sprintf("Risk %d: %d lines × %d (%d recent commits)", s.Value, s.Lines, s.Commits+1, s.Commits)
+	if !s.Tested {
+		text += fmt.Sprintf(" × %d (no test changed)", untestedFactor)
+	}
+	return text
+}
+
+// Scores rates each changed file from its line counts, and churn: how
+// many recent commits touched it. A renamed file's churn is its old path's.
+func Scores(files []vcs.FileChange, stats map[string]vcs.Stat, churn map[string]int) map[string]Score {
+	// Tests in test directories, which rarely mirror the source tree, are
+	// matched by name alone
+	subjects, names := make(map[string]bool), make(map[string]bool)
+	for _, f := range files {
+		if !IsTest(f.Path) {
+			continue
+		}
+		covered, _ := subject(f.Path)
+		subjects[covered] = true
+		if inTestDir(f.Path) {
+			names[path.Base(covered)] = true
+		}
+	}
+
+	scores := make(map[string]Score, len(files))
+	for _, f := range files {
+		s := Score{
+			Lines:   stats[f.Path].Added + stats[f.Path].Removed,
+			Commits: max(churn[f.Path], churn[f.OldPath]),
+			Tested:  IsTest(f.Path) || subjects[stem(f.Path)] || names[path.Base(stem(f.Path))],
+		}
+		s.Value = s.Lines * (s.Commits + 1)
+		if !s.Tested {
+			s.Value *= untestedFactor
+		}
+		scores[f.Path] = s
+	}
+	return scores
+}
+
+// testDirs are directories that hold tests
+var testDirs = []string{"test", "tests", "__tests__", "spec"}
+
+// IsTest reports whether a path looks like a test by the usual naming of
+// Go, Python, JavaScript, Java and Ruby projects, or lives in a test
+// directory
+func IsTest(name string) bool {
+	_, named := subject(name)
+	return named || inTestDir(name)
+}
+
+// inTestDir reports whether a path is inside a test directory
+func inTestDir(name string) bool {
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		if slices.Contains(testDirs, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// stem is a path without its extensions, as src/ui/app for src/ui/app.tsx
+func stem(name string) string {
+	base := path.Base(name)
+	if i := strings.Index(base, "."); i > 0 {
+		base = base[:i]
+	}
+	return path.Join(path.Dir(name), base)
+}
+
+// subject is the file a test covers, as its stem without the test
+// markers: src/app for src/app_test.go, src/app.test.ts or src/test_app.py.
+// It reports false, for files named like no test, with their own stem.
+func subject(name string) (string, bool) {
+	base := path.Base(name)
+	for _, marker := range []string{".test.", ".spec."} {
+		if i := strings.Index(base, marker); i > 0 {
+			return path.Join(path.Dir(name), base[:i]), true
+		}
+	}
+	s := path.Base(stem(name))
+	for _, suffix := range []string{"_test", "_spec", "Test", "Tests"} {
+		if t, ok := strings.CutSuffix(s, suffix); ok && t != "" {
+			return path.Join(path.Dir(name), t), true
+		}
+	}
+	if t, ok := strings.CutPrefix(s, "test_"); ok && t != "" {
+		return path.Join(path.Dir(name), t), true
+	}
+	return stem(name), false
+}
+
+// Sort returns files riskiest first, keeping the given order among equal
+// scores. The input is left untouched.
+func Sort(files []vcs.FileChange, scores map[string]Score) []vcs.FileChange {
+	sorted := append([]vcs.FileChange(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return scores[sorted[i].Path].Value > scores[sorted[j].Path].Value
+	})
+	return sorted
+}
@@ -0,0 +1,76 @@
+package risk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gerunddev/tcr/vcs"
+)
+
+func TestScores(t *testing.T) {
+	files := []vcs.FileChange{
+		{Path: "ui/app.go", Status: vcs.StatusModified},
+		{Path: "ui/app_test.go", Status: vcs.StatusModified},
+		{Path: "vcs/git.go", Status: vcs.StatusModified},
+		{Path: "src/parser.py", Status: vcs.StatusModified},
+		{Path: "tests/test_parser.py", Status: vcs.StatusModified},
+		{Path: "lib/new.go", Status: vcs.StatusRenamed, OldPath: "lib/old.go", NewPath: "lib/new.go"},
+	}
+	stats := map[string]vcs.Stat{
+		"ui/app.go":            {Added: 30, Removed: 12},
+		"ui/app_test.go":       {Added: 20},
+		"vcs/git.go":           {Added: 5, Removed: 5},
+		"src/parser.py":        {Added: 3},
+		"tests/test_parser.py": {Added: 4},
+		"lib/new.go":           {Added: 1},
+	}
+	churn := map[string]int{"ui/app.go": 9, "vcs/git.go": 3, "lib/old.go": 4}
+
+	scores := Scores(files, stats, churn)
+	want := map[string]Score{
+		"ui/app.go":            {Lines: 42, Commits: 9, Tested: true, Value: 420},
+		"ui/app_test.go":       {Lines: 20, Tested: true, Value: 20},
+		"vcs/git.go":           {Lines: 10, Commits: 3, Value: 80},
+		"src/parser.py":        {Lines: 3, Tested: true, Value: 3},
+		"tests/test_parser.py": {Lines: 4, Tested: true, Value: 4},
+		"lib/new.go":           {Lines: 1, Commits: 4, Value: 10},
+	}
+	if !reflect.DeepEqual(scores, want) {
+		t.Errorf("Scores = %+v, want %+v", scores, want)
+	}
+
+	var order []string
+	for _, f := range Sort(files, scores) {
+		order = append(order, f.Path)
+	}
+	if want := []string{"ui/app.go", "vcs/git.go", "ui/app_test.go", "lib/new.go", "tests/test_parser.py", "src/parser.py"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Sort = %v, want %v", order, want)
+	}
+
+	if got, want := scores["vcs/git.go"].String(), "Risk 80: 10 lines × 4 (3 recent commits) × 2 (no test changed)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestIsTest(t *testing.T) {
+	tests := map[string]bool{
+		"ui/app_test.go":             true,
+		"web/button.test.tsx":        true,
+		"web/button.spec.ts":         true,
+		"pkg/test_parser.py":         true,
+		"src/FooTest.java":           true,
+		"spec/models/user_spec.rb":   true,
+		"tests/fixtures/data.json":   true,
+		"web/__tests__/button.tsx":   true,
+		"ui/app.go":                  false,
+		"cmd/latest.go":              false,
+		"docs/testing.md":            false,
+		"web/button.tsx":             false,
+		"internal/contest/runner.go": false,
+	}
+	for name, want := range tests {
+		if got := IsTest(name); got != want {
+			t.Errorf("IsTest(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
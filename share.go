@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gerunddev/tcr/follow"
+)
+
+// socketFlag extracts a --share or --follow flag, bare or as
+// --flag=socket, from args. It returns whether the flag was given, the
+// socket ("" for the default) and the remaining args.
+func socketFlag(args []string, flag string) (bool, string, []string) {
+	set, socket := false, ""
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case arg == flag:
+			set = true
+		case strings.HasPrefix(arg, flag+"="):
+			set, socket = true, strings.TrimPrefix(arg, flag+"=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return set, socket, rest
+}
+
+// sharedSocket returns the socket to share on or follow: the one given, or
+// the default for the current directory
+func sharedSocket(socket string) (string, error) {
+	if socket != "" {
+		return socket, nil
+	}
+	return follow.DefaultSocket(".")
+}
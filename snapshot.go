@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// runSnapshot implements "tcr snapshot [dir]": it records dir (default the
+// current directory) as the baseline tcr diffs against when dir isn't under
+// version control
+func runSnapshot(args []string) int {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "Usage: tcr snapshot [dir]\n")
+		return 1
+	}
+
+	m, err := vcs.TakeSnapshot(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Snapshot of %d files in %s saved.\n", len(m.Files), m.Root)
+
+	if v, err := vcs.Detect(m.Root); err == nil && v.Name() != "snapshot" {
+		fmt.Printf("Note: %s is under %s, so tcr will review its %s changes instead.\n", m.Root, v.Name(), v.Name())
+	} else {
+		fmt.Println("Run tcr there to review changes made since.")
+	}
+	return 0
+}
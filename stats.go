@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/stats"
+)
+
+// recordStats appends a finished session to the stats file
+func recordStats(sess stats.Session) error {
+	path, err := stats.Path()
+	if err != nil {
+		return err
+	}
+	return stats.Record(path, sess)
+}
+
+// runStats prints the local usage stats
+func runStats() int {
+	path, err := stats.Path()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	s, err := stats.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	cfg, _ := config.Load()
+	if !cfg.Stats {
+		fmt.Println("Stats collection is off. Set `stats = true` in the config or toggle it in preferences (,).")
+		if s.Reviews == 0 {
+			return 0
+		}
+		fmt.Println()
+	}
+	if s.Reviews == 0 {
+		fmt.Println("No reviews recorded yet.")
+		return 0
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	fmt.Printf("Stats since %s (%s)\n\n", s.First.Format("2006-01-02"), path)
+	fmt.Printf("  %-12s %8s %9s %10s\n", "", "reviews", "comments", "time")
+	printTotals("today", s.Since(today))
+	printTotals("last 7 days", s.Since(today.AddDate(0, 0, -6)))
+	printTotals("last 30 days", s.Since(today.AddDate(0, 0, -29)))
+	printTotals("all time", s.Totals)
+
+	avg := s.Duration() / time.Duration(s.Reviews)
+	fmt.Printf("\n  %.1f comments and %s per review on average\n",
+		float64(s.Comments)/float64(s.Reviews), formatDuration(avg))
+
+	fmt.Println("\nRecent days:")
+	for _, day := range s.RecentDays(7) {
+		d := s.Days[day]
+		fmt.Printf("  %-12s %8d %9d %10s\n", day, d.Reviews, d.Comments, formatDuration(d.Duration()))
+	}
+	return 0
+}
+
+func printTotals(label string, t stats.Totals) {
+	fmt.Printf("  %-12s %8d %9d %10s\n", label, t.Reviews, t.Comments, formatDuration(t.Duration()))
+}
+
+// formatDuration renders a duration as e.g. "1h05m" or "42s"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
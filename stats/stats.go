@@ -0,0 +1,153 @@
+// Package stats keeps opt-in, local-only usage counters. Nothing here
+// touches the network.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// dayFormat keys the per-day breakdown
+const dayFormat = "2006-01-02"
+
+// Totals are counters for a period
+type Totals struct {
+	Reviews  int `json:"reviews"`
+	Comments int `json:"comments"`
+	Seconds  int `json:"seconds"`
+}
+
+// Duration returns the time spent as a duration
+func (t Totals) Duration() time.Duration {
+	return time.Duration(t.Seconds) * time.Second
+}
+
+func (t *Totals) add(o Totals) {
+	t.Reviews += o.Reviews
+	t.Comments += o.Comments
+	t.Seconds += o.Seconds
+}
+
+// Stats is the on-disk stats file
+type Stats struct {
+	Totals
+	First time.Time         `json:"first"`
+	Last  time.Time         `json:"last"`
+	Days  map[string]Totals `json:"days"`
+}
+
+// Session is one completed review
+type Session struct {
+	Start    time.Time
+	End      time.Time
+	Comments int
+}
+
+// Path returns the stats file location: $TCR_STATS if set, otherwise
+// tcr/stats.json under the user config directory
+func Path() (string, error) {
+	if p := os.Getenv("TCR_STATS"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "tcr", "stats.json"), nil
+}
+
+// Load reads the stats file, returning empty stats if it doesn't exist
+func Load(path string) (Stats, error) {
+	s := Stats{Days: map[string]Totals{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return s, fmt.Errorf("failed to read stats: %w", err)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Stats{Days: map[string]Totals{}}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if s.Days == nil {
+		s.Days = map[string]Totals{}
+	}
+	return s, nil
+}
+
+// Save writes the stats file, creating parent directories
+func (s Stats) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create stats directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	return nil
+}
+
+// Add counts a completed session, attributed to the day it started
+func (s *Stats) Add(sess Session) {
+	t := Totals{
+		Reviews:  1,
+		Comments: sess.Comments,
+		Seconds:  int(sess.End.Sub(sess.Start).Round(time.Second) / time.Second),
+	}
+	s.Totals.add(t)
+
+	day := sess.Start.Format(dayFormat)
+	d := s.Days[day]
+	d.add(t)
+	s.Days[day] = d
+
+	if s.First.IsZero() || sess.Start.Before(s.First) {
+		s.First = sess.Start
+	}
+	if sess.End.After(s.Last) {
+		s.Last = sess.End
+	}
+}
+
+// Since sums the days on or after the given date
+func (s Stats) Since(from time.Time) Totals {
+	var t Totals
+	cutoff := from.Format(dayFormat)
+	for day, d := range s.Days {
+		if day >= cutoff {
+			t.add(d)
+		}
+	}
+	return t
+}
+
+// RecentDays returns the last n days with activity, newest first
+func (s Stats) RecentDays(n int) []string {
+	days := make([]string, 0, len(s.Days))
+	for day := range s.Days {
+		days = append(days, day)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(days)))
+	if len(days) > n {
+		days = days[:n]
+	}
+	return days
+}
+
+// Record adds a session to the stats file at path
+func Record(path string, sess Session) error {
+	s, err := Load(path)
+	if err != nil {
+		return err
+	}
+	s.Add(sess)
+	return s.Save(path)
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of missing file failed: %v", err)
+	}
+	if s.Reviews != 0 || len(s.Days) != 0 {
+		t.Errorf("expected empty stats, got %+v", s)
+	}
+
+	day1 := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	day2 := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{Start: day1, End: day1.Add(10 * time.Minute), Comments: 3},
+		{Start: day1.Add(time.Hour), End: day1.Add(time.Hour + 5*time.Minute), Comments: 1},
+		{Start: day2, End: day2.Add(90 * time.Second), Comments: 0},
+	}
+	for _, sess := range sessions {
+		if err := Record(path, sess); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := Totals{Reviews: 3, Comments: 4, Seconds: 600 + 300 + 90}
+	if s.Totals != want {
+		t.Errorf("totals = %+v, want %+v", s.Totals, want)
+	}
+	if got := s.Days["2024-03-01"]; got != (Totals{Reviews: 2, Comments: 4, Seconds: 900}) {
+		t.Errorf("unexpected day totals: %+v", got)
+	}
+	if !s.First.Equal(day1) || !s.Last.Equal(day2.Add(90*time.Second)) {
+		t.Errorf("unexpected first/last: %v %v", s.First, s.Last)
+	}
+
+	if got := s.Since(day2); got != (Totals{Reviews: 1, Seconds: 90}) {
+		t.Errorf("Since = %+v", got)
+	}
+	if got := s.RecentDays(1); !reflect.DeepEqual(got, []string{"2024-03-02"}) {
+		t.Errorf("RecentDays = %v", got)
+	}
+}
+
+func TestPathHonorsEnv(t *testing.T) {
+	t.Setenv("TCR_STATS", "/custom/stats.json")
+	path, err := Path()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/custom/stats.json" {
+		t.Errorf("expected TCR_STATS path, got %q", path)
+	}
+}
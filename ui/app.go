@@ -1,44 +1,178 @@
 package ui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/crash"
+	"github.com/gerunddev/tcr/describe"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/follow"
+	"github.com/gerunddev/tcr/goapi"
+	"github.com/gerunddev/tcr/highlight"
+	"github.com/gerunddev/tcr/lockfile"
+	"github.com/gerunddev/tcr/notebook"
 	"github.com/gerunddev/tcr/output"
+	"github.com/gerunddev/tcr/plugin"
+	"github.com/gerunddev/tcr/record"
+	"github.com/gerunddev/tcr/risk"
+	"github.com/gerunddev/tcr/ui/cache"
 	"github.com/gerunddev/tcr/ui/floating"
+	"github.com/gerunddev/tcr/ui/keys"
 	"github.com/gerunddev/tcr/ui/panels"
 	"github.com/gerunddev/tcr/ui/search"
 	"github.com/gerunddev/tcr/ui/theme"
 	"github.com/gerunddev/tcr/vcs"
+	"github.com/gerunddev/tcr/workpool"
 )
 
 // App is the main application model
 type App struct {
 	vcs        vcs.VCS
 	outputPath string
+	cfg        config.Config
 	width      int
 	height     int
 	ready      bool
 
+	// Resize debouncing: the latest size waits here until resizing settles
+	pendingSize *tea.WindowSizeMsg
+	resizeSeq   int
+
+	// Key routing
+	router *keys.Router
+
 	// Panels
 	filesPanel *panels.FilesPanel
 	diffPanel  *panels.DiffPanel
+	descPanel  *panels.DescriptionPanel // Commit message over the panels, when there is one
+	files      []vcs.FileChange         // As reported by the VCS, before cfg.FileOrder
+	stats      map[string]vcs.Stat      // Changed lines per file, once counted
+	churn      map[string]int           // Recent commits touching each file, for the risk order
+	scores     map[string]risk.Score    // Each file's risk, in the risk order
+	todos      findings.Markers         // TODO and FIXME markers the whole change adds and removes, counted with stats
+
+	// Background diff loading. Cancelling ctx, when the app closes, stops
+	// every VCS command; diffCtx is replaced along with diffGen, stopping
+	// the loads of stale diffs.
+	pool       *workpool.Pool
+	ctx        context.Context
+	cancel     context.CancelFunc
+	diffCtx    context.Context
+	diffCancel context.CancelFunc
+	loadCancel context.CancelFunc // Stops loading the file last selected
 
 	// Search
 	searchCtrl *search.Controller
-	diffCache  map[string]string // Cache of loaded diffs by file path
+	diffCache  *cache.Cache    // Loaded diffs by file path, LRU under cfg.CacheMB
+	diffGen    int             // Bumped when cached diffs go stale; older loads are dropped
+	pending    map[string]bool // Diffs being preloaded, not searched yet
+
+	// Where the cursor was in each file viewed, to return to it
+	positions map[string]diffPosition
 
 	// Modal
 	feedbackModal *floating.FeedbackModal
 	modalOpen     bool
+	editing       int // Index in saved of the comment being revised, or -1
+	chooser       *floating.CommentChooser
+	chooserSaved  []int                       // Index in saved of each comment in the chooser
+	lastComment   string                      // Text of the last comment written, for the repeat key
+	bulkTargets   []floating.FeedbackSavedMsg // Where the comment being written goes, for one on every search match
+	earlierList   *floating.EarlierList
+	earlierOrder  []int // Index in previous of each comment in the list
+	historyList   *floating.HistoryList
+	history       []vcs.LogEntry // The revisions in historyList, after the working copy
+	prefsModal    *floating.PreferencesModal
+	commitModal   *floating.CommitModal
+	notesModal    *floating.NotesModal
+	detailsModal  *floating.DetailsModal
+	baseModal     *floating.BaseModal
+	noBase        *vcs.BaseError // The base that didn't resolve, shown on noBaseScreen
+	noBaseScreen  *floating.NoBaseScreen
+	pluginMenu    *floating.PluginMenu
+	pluginItems   []plugin.Item // The actions and panels in pluginMenu
+	hunkMenu      *floating.HunkMenu
+	hunkActions   []hunkAction    // The actions in hunkMenu
+	hunkHeader    string          // Header of the hunk hunkMenu is for
+	clipboard     string          // OSC 52 escape drawn ahead of the frame, see View
+	clipboardSeq  int             // Counts copies, so only the last one's clearClipboardMsg applies
+	compared      *vcs.Comparison // What the review diffs against, when the VCS can say
+
+	// Headers of the hunks folded from the hunk menu, by file
+	folded map[string]map[string]bool
+
+	// When each line of a file was last changed, by line number before the
+	// change, once blamed for line_age
+	blames map[string]map[int]time.Time
+
+	// Plugins, and the lines they flag in each file's diff once asked
+	plugins        *plugin.Host
+	pluginFindings map[string][]plugin.Finder
+
+	// Free-form review notes, kept between openings of the notes editor
+	notes string
+
+	// Show lockfiles as their raw diff instead of a package summary
+	rawLockfiles bool
+
+	// Generated files in the change -> the changed .proto each came from
+	generated map[string]string
+
+	// Expand notebook cell outputs instead of counting the changed lines
+	showOutputs bool
 
 	// Messages
 	statusMsg string
+
+	// Comments saved this session, for local stats and the commit summary
+	saved []floating.FeedbackSavedMsg
+
+	// Re-review: an earlier review's comments, the hunk that resolved
+	// each one marked resolved, and those whose lines have changed since,
+	// by index in previous
+	previous  []output.Feedback
+	resolved  map[int]string
+	addressed map[int]bool
+
+	// Earlier comment to put the cursor on once its diff loads
+	jumpTo *output.Feedback
+
+	// Marks each comment saved in a --record session, if recording
+	rec *record.Recorder
+
+	// Shared review: the position is broadcast to followers, or this is
+	// a read-only follower moving where the sharer does
+	sharing   *follow.Broadcaster
+	following *follow.Follower
+	followPos *follow.Position // Position to take once its diff loads
+
+	// Startup: the shell renders while the file list loads
+	loading     bool
+	loadSpinner spinner.Model
+	loadStart   time.Time
+
+	// Position to restore once files load, after a crash
+	resume *crash.State
+
+	// Quick mode: one pane with every file's diff in a row
+	quick         bool
+	quickContent  string
+	quickSections []quickSection
 }
 
 // NewApp creates a new application
-func NewApp(v vcs.VCS, outputPath string) *App {
+func NewApp(v vcs.VCS, outputPath string, cfg config.Config) *App {
 	filesPanel := panels.NewFilesPanel()
 	diffPanel := panels.NewDiffPanel()
 
@@ -46,176 +180,1726 @@ func NewApp(v vcs.VCS, outputPath string) *App {
 	filesPanel.SetFocused(true)
 	diffPanel.SetFocused(true)
 
-	return &App{
+	theme.Apply(cfg.Theme)
+	diffPanel.SetWrap(cfg.Wrap)
+	diffPanel.SetWordDiff(cfg.WordDiff)
+	diffPanel.SetHighlights(highlightRules(cfg))
+
+	filesPanel.SetLoading("Loading changes...")
+
+	diffCache := cache.New(cacheBudget(cfg))
+	diffCache.SetCompression(cfg.Compress)
+
+	a := &App{
 		vcs:        v,
 		outputPath: outputPath,
+		cfg:        cfg,
+		router:     keys.NewRouter(keys.ProfileKeymap(cfg.Keymap)),
 		filesPanel: filesPanel,
 		diffPanel:  diffPanel,
+		descPanel:  panels.NewDescriptionPanel(),
+		plugins:    plugin.NewHost(cfg.Plugins),
+		pool:       workpool.New(2),
 		searchCtrl: search.NewController(),
-		diffCache:  make(map[string]string),
+		diffCache:  diffCache,
+		editing:    -1,
+		loading:    true,
+		loadSpinner: spinner.New(spinner.WithSpinner(spinner.Dot),
+			spinner.WithStyle(theme.DimmedStyle)),
+	}
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	a.diffCtx, a.diffCancel = context.WithCancel(a.ctx)
+	a.setFilesTitle()
+	return a
+}
+
+// Close stops the VCS commands still running, once the app has quit, and
+// ends the plugins' session
+func (a *App) Close() {
+	a.cancel()
+	a.plugins.Close(plugin.Finish{Output: a.outputPath, Comments: len(a.saved)})
+}
+
+func (a *App) Init() tea.Cmd {
+	a.loadStart = time.Now()
+	if a.following != nil {
+		return tea.Batch(a.loadFiles, a.loadSpinner.Tick, a.loadDescription(), a.nextPosition)
+	}
+	return tea.Batch(a.loadFiles, a.loadSpinner.Tick, a.loadDescription(), a.startPlugins())
+}
+
+// loadDescription reads the commit message or change description of what's
+// reviewed in the background, for backends that have one
+func (a *App) loadDescription() tea.Cmd {
+	d, ok := a.vcs.(vcs.Describer)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		text, err := d.Description(a.ctx)
+		return descriptionLoadedMsg{text: text, err: err}
+	}
+}
+
+// descriptionLoadedMsg carries the description of the reviewed revisions
+type descriptionLoadedMsg struct {
+	text string
+	err  error
+}
+
+func (a *App) loadFiles() tea.Msg {
+	files, err := a.vcs.ChangedFiles(a.ctx)
+	if err != nil {
+		return errMsg{err}
+	}
+	msg := filesLoadedMsg{files: files}
+	if e, ok := a.vcs.(vcs.Explainer); ok {
+		compared := e.Explain(a.ctx)
+		msg.compared = &compared
+	}
+	return msg
+}
+
+type filesLoadedMsg struct {
+	files    []vcs.FileChange
+	compared *vcs.Comparison // What was diffed, when the VCS can say
+}
+
+// emptyMessage spells out why a review found no changes and what to try
+func emptyMessage(files []vcs.FileChange, state *vcs.Comparison) string {
+	if len(files) > 0 || state == nil {
+		return ""
+	}
+	lines := []string{"No changes comparing " + state.Compared + "."}
+	if len(state.Hints) > 0 {
+		lines = append(lines, "")
+	}
+	for _, hint := range state.Hints {
+		lines = append(lines, "• "+hint)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// loadStats counts each file's changed lines and the change's TODO
+// markers in the background, and in the risk order how often the history
+// touched each file. Quick mode has no files panel to show them.
+func (a *App) loadStats() tea.Cmd {
+	if a.quick {
+		return nil
+	}
+	ctx, gen, byRisk := a.diffCtx, a.diffGen, a.cfg.FileOrder == "risk"
+	return func() tea.Msg {
+		stats, err := vcs.DiffStat(ctx, a.vcs)
+		if err != nil {
+			// The counts are only a guide; the list works without them
+			return nil
+		}
+		var todos findings.Markers
+		if all, err := a.vcs.DiffAll(ctx); err == nil {
+			todos = findings.TODOs(all)
+		}
+		var churn map[string]int
+		if byRisk {
+			// Without history, risk goes by the change alone
+			churn, _ = vcs.Churn(ctx, a.vcs)
+		}
+		return statsLoadedMsg{stats: stats, churn: churn, todos: todos, gen: gen}
+	}
+}
+
+// statsLoadedMsg carries the changed line counts of every file, the
+// change's TODO markers, and each file's churn when it was counted
+type statsLoadedMsg struct {
+	stats map[string]vcs.Stat
+	churn map[string]int
+	todos findings.Markers
+	gen   int
+}
+
+type errMsg struct {
+	err error
+}
+
+// resizeDebounce is how long resizing must pause before the layout is redone
+const resizeDebounce = 50 * time.Millisecond
+
+// resizeSettledMsg fires resizeDebounce after a WindowSizeMsg
+type resizeSettledMsg struct {
+	seq int
+}
+
+// resize applies a new terminal size to the panels and any open modal
+func (a *App) resize(width, height int) {
+	a.width = width
+	a.height = height
+	a.updatePanelSizes()
+
+	if a.feedbackModal != nil {
+		a.feedbackModal.SetSize(a.width, a.height)
+	}
+	if a.chooser != nil {
+		a.chooser.SetSize(a.width, a.height)
+	}
+	if a.earlierList != nil {
+		a.earlierList.SetSize(a.width, a.height)
+	}
+	if a.historyList != nil {
+		a.historyList.SetSize(a.width, a.height)
+	}
+	if a.pluginMenu != nil {
+		a.pluginMenu.SetSize(a.width, a.height)
+	}
+	if a.hunkMenu != nil {
+		a.hunkMenu.SetSize(a.width, a.height)
+	}
+	if a.prefsModal != nil {
+		a.prefsModal.SetSize(a.width, a.height)
+	}
+	if a.commitModal != nil {
+		a.commitModal.SetSize(a.width, a.height)
+	}
+	if a.notesModal != nil {
+		a.notesModal.SetSize(a.width, a.height)
+	}
+	if a.detailsModal != nil {
+		a.detailsModal.SetSize(a.width, a.height)
+	}
+	if a.baseModal != nil {
+		a.baseModal.SetSize(a.width, a.height)
+	}
+	if a.noBaseScreen != nil {
+		a.noBaseScreen.SetSize(a.width, a.height)
+	}
+}
+
+func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := a.update(msg)
+	if a.quick {
+		a.syncQuickFile()
+	}
+	a.broadcast()
+	return m, cmd
+}
+
+func (a *App) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// The first size lays out immediately; later ones are coalesced
+		// until resizing pauses so each step doesn't re-render everything
+		if !a.ready {
+			a.ready = true
+			a.resize(msg.Width, msg.Height)
+			return a, nil
+		}
+		a.pendingSize = &msg
+		a.resizeSeq++
+		seq := a.resizeSeq
+		return a, tea.Tick(resizeDebounce, func(time.Time) tea.Msg {
+			return resizeSettledMsg{seq: seq}
+		})
+
+	case resizeSettledMsg:
+		// Only the last resize in a burst applies
+		if msg.seq == a.resizeSeq && a.pendingSize != nil {
+			a.resize(a.pendingSize.Width, a.pendingSize.Height)
+			a.pendingSize = nil
+		}
+		return a, nil
+
+	case spinner.TickMsg:
+		// Animate until the file list arrives, then let the ticks stop
+		if !a.loading {
+			return a, nil
+		}
+		var cmd tea.Cmd
+		a.loadSpinner, cmd = a.loadSpinner.Update(msg)
+		label := a.loadSpinner.View() + "Loading changes..."
+		if elapsed := time.Since(a.loadStart); elapsed >= time.Second {
+			label += " " + elapsed.Truncate(time.Second).String()
+		}
+		a.filesPanel.SetLoading(label)
+		return a, cmd
+
+	case filesLoadedMsg:
+		a.loading = false
+		a.noBase, a.noBaseScreen = nil, nil
+		a.files = msg.files
+		a.diffPanel.SetEmptyMessage(emptyMessage(msg.files, msg.compared))
+		a.showCompared(msg.compared)
+		prev := a.diffPanel.FilePath()
+		files := a.arrangeFiles()
+		a.filesPanel.SetFiles(files)
+		if a.quick {
+			a.resume = nil
+			return a, a.loadQuick(files)
+		}
+		stats := a.loadStats()
+		if n := a.filesPanel.ConflictCount(); n > 0 && !a.filesPanel.ConflictsOnly() {
+			a.statusMsg = fmt.Sprintf("Files with merge conflicts: %d · x shows only them", n)
+		} else if n == 0 && a.filesPanel.ConflictsOnly() {
+			a.filesPanel.SetConflictsOnly(false)
+			a.setFilesTitle()
+			a.statusMsg = "No merge conflicts left · showing all files"
+		}
+		// Open the file a shared review moved to while loading
+		if a.followPos != nil && a.filesPanel.SelectPath(a.followPos.File) {
+			return a, tea.Batch(a.loadDiff(a.followPos.File), stats)
+		}
+		// Reopen the file a crashed session was on
+		if a.resume != nil && a.filesPanel.SelectPath(a.resume.File) {
+			return a, tea.Batch(a.loadDiff(a.resume.File), stats)
+		}
+		a.resume = nil
+		// Stay on the file that was open when the list is reloaded
+		if prev != "" {
+			a.filesPanel.SelectPath(prev)
+		}
+		// Load diff for the selected (first visible) file if any
+		if sel := a.filesPanel.SelectedFile(); sel != nil {
+			return a, tea.Batch(a.loadDiff(sel.Path), stats)
+		}
+		a.diffPanel.ClearDiff()
+		return a, stats
+
+	case descriptionLoadedMsg:
+		if msg.err != nil {
+			a.statusMsg = "Couldn't read the change description: " + msg.err.Error()
+			return a, nil
+		}
+		a.descPanel.SetDescription(msg.text)
+		a.updatePanelSizes()
+		return a, nil
+
+	case statsLoadedMsg:
+		if msg.gen != a.diffGen {
+			return a, nil
+		}
+		a.stats, a.churn, a.todos = msg.stats, msg.churn, msg.todos
+		a.filesPanel.SetStats(msg.stats)
+		a.setFilesTitle()
+		if a.cfg.FileOrder == "risk" {
+			return a, a.rearrangeFiles()
+		}
+		return a, nil
+
+	case followMsg:
+		return a, a.followTo(msg)
+
+	case quickLoadedMsg:
+		if msg.gen != a.diffGen {
+			return a, nil
+		}
+		a.quickContent, a.quickSections = msg.content, msg.sections
+		a.showQuick()
+		if a.followPos != nil {
+			a.quickGoto(a.followPos.File, a.followPos.Line)
+			a.followPos = nil
+		}
+		return a, nil
+
+	case panels.FileSelectedMsg:
+		// Quick mode shows every file at once
+		if a.quick {
+			return a, nil
+		}
+		if status := a.generatedStatus(msg.Path); status != "" {
+			a.statusMsg = status
+		} else if score, ok := a.scores[msg.Path]; ok {
+			a.statusMsg = score.String()
+		}
+		// Prefetched diffs show without waiting on the VCS
+		if content, ok := a.diffCache.Get(msg.Path); ok {
+			a.cancelLoad()
+			a.showDiff(msg.Path, content)
+			return a, tea.Batch(a.prefetchNeighbors(), a.findPluginLines(msg.Path, content), a.blameLines(msg.Path))
+		}
+		return a, a.loadDiff(msg.Path)
+
+	case diffLoadedMsg:
+		if msg.gen != a.diffGen {
+			return a, nil
+		}
+		// Cache the diff
+		a.diffCache.Put(msg.path, msg.content)
+
+		// The selection may have moved on while this was loading
+		if sel := a.filesPanel.SelectedFile(); sel != nil && sel.Path != msg.path {
+			return a, nil
+		}
+		a.showDiff(msg.path, msg.content)
+		return a, tea.Batch(a.prefetchNeighbors(), a.findPluginLines(msg.path, msg.content), a.blameLines(msg.path))
+
+	case diffPrefetchedMsg:
+		if msg.gen == a.diffGen {
+			a.diffCache.Put(msg.path, msg.content)
+		}
+		return a, nil
+
+	case floating.FeedbackSavedMsg:
+		if msg.Comment != "" {
+			a.lastComment = msg.Comment
+		}
+		if a.editing >= 0 {
+			a.reviseComment(a.editing, msg.Comment)
+			a.closeModal()
+			return a, nil
+		}
+		if a.bulkTargets != nil {
+			cmd := a.addBulkComment(msg.Comment)
+			a.closeModal()
+			return a, cmd
+		}
+		cmd := a.addComment(msg, "Feedback saved")
+		a.closeModal()
+		return a, cmd
+
+	case floating.FeedbackCancelledMsg:
+		a.closeModal()
+		return a, nil
+
+	case floating.CommentChosenMsg:
+		saved := a.chooserSaved
+		a.closeModal()
+		if msg.Index < 0 {
+			a.newFeedbackModal()
+		} else {
+			a.editComment(saved[msg.Index])
+		}
+		return a, nil
+
+	case historyLoadedMsg:
+		a.showHistory(msg)
+		return a, nil
+
+	case floating.RevisionChosenMsg:
+		a.closeModal()
+		return a, a.reviewRevision(msg.Index)
+
+	case floating.PluginChosenMsg:
+		item := a.pluginItems[msg.Index]
+		a.closeModal()
+		return a, a.runPlugin(item)
+
+	case floating.HunkChosenMsg:
+		action, header := a.hunkActions[msg.Index], a.hunkHeader
+		a.closeModal()
+		return a, a.runHunkAction(action, header)
+
+	case hunkStagedMsg:
+		return a, a.hunkStaged(msg)
+
+	case clipboardMsg:
+		return a, a.copied(msg)
+
+	case clipboardSentMsg:
+		if msg.seq == a.clipboardSeq {
+			a.clipboard = ""
+		}
+		return a, nil
+
+	case pluginsStartedMsg:
+		return a, a.pluginsStarted(msg)
+
+	case pluginFindingsMsg:
+		a.pluginsFound(msg)
+		return a, nil
+
+	case blamedMsg:
+		a.blamed(msg)
+		return a, nil
+
+	case pluginRanMsg:
+		return a, a.pluginRan(msg)
+
+	case anchorMsg:
+		// Best effort, like the rest of anchoring
+		_ = output.SaveAnchor(a.outputPath, msg.path, msg.line, msg.text)
+		return a, nil
+
+	case floating.EarlierChosenMsg:
+		i := a.earlierOrder[msg.Index]
+		a.closeModal()
+		return a, a.gotoEarlier(i)
+
+	case floating.CommentDeletedMsg:
+		saved := a.chooserSaved
+		a.closeModal()
+		a.reviseComment(saved[msg.Index], "")
+		return a, nil
+
+	case floating.PreferencesSavedMsg:
+		a.prefsModal = nil
+		cmd := a.applyConfig(msg.Config)
+		if path, err := a.cfg.Save(); err != nil {
+			a.statusMsg = "Error: " + err.Error()
+		} else {
+			a.statusMsg = "Preferences saved to " + path
+		}
+		return a, cmd
+
+	case floating.PreferencesCancelledMsg:
+		a.prefsModal = nil
+		return a, nil
+
+	case floating.CommitConfirmedMsg:
+		a.commitModal = nil
+		a.statusMsg = "Committing..."
+		return a, a.commit(msg.Message)
+
+	case floating.CommitCancelledMsg:
+		a.commitModal = nil
+		return a, nil
+
+	case floating.NotesClosedMsg:
+		a.notes = msg.Notes
+		a.notesModal = nil
+		return a, nil
+
+	case floating.NotesExportedMsg:
+		a.notes = msg.Notes
+		a.notesModal = nil
+		if err := output.AppendNotes(a.outputPath, msg.Notes); err != nil {
+			a.statusMsg = "Error: " + err.Error()
+		} else {
+			a.statusMsg = "Notes exported to " + a.outputPath
+		}
+		return a, nil
+
+	case floating.DetailsClosedMsg:
+		a.detailsModal = nil
+		return a, nil
+
+	case floating.BaseChosenMsg:
+		a.baseModal = nil
+		return a, a.changeBase(msg.Rev)
+
+	case floating.BaseCancelledMsg:
+		a.baseModal = nil
+		return a, nil
+
+	case baseCandidatesMsg:
+		return a, a.showBase(msg)
+
+	case baseFailedMsg:
+		return a, a.restoreBase(msg)
+
+	case floating.NoBaseChosenMsg:
+		return a, a.leaveNoBase(msg.Choice)
+
+	case apiSummaryMsg:
+		if msg.err != nil {
+			a.statusMsg = "Error: " + msg.err.Error()
+			return a, nil
+		}
+		a.statusMsg = ""
+		a.detailsModal = floating.NewDetailsModal("API: "+msg.path, msg.text)
+		a.detailsModal.SetSize(a.width, a.height)
+		return a, nil
+
+	case descriptionDraftedMsg:
+		if msg.err != nil {
+			a.statusMsg = "Error: " + msg.err.Error() + " (used the template instead)"
+		} else {
+			a.statusMsg = ""
+		}
+		if strings.TrimSpace(a.notes) != "" {
+			a.notes = strings.TrimRight(a.notes, "\n") + "\n\n" + msg.text
+		} else {
+			a.notes = msg.text
+		}
+		a.openNotes()
+		return a, nil
+
+	case commitDoneMsg:
+		if msg.err != nil {
+			a.statusMsg = "Error: " + msg.err.Error()
+			return a, nil
+		}
+		a.statusMsg = "Committed the reviewed changes"
+		if a.vcs.Name() == "jj" {
+			a.statusMsg = "Described the change and started a new one"
+		}
+		a.invalidateDiffs()
+		return a, a.loadFiles
+
+	case errMsg:
+		if a.loading && a.showNoBase(msg.err) {
+			a.loading = false
+			return a, nil
+		}
+		a.statusMsg = "Error: " + msg.err.Error()
+		if a.loading {
+			a.loading = false
+			a.filesPanel.SetLoading("Failed to load changes")
+		}
+		return a, nil
+
+	case diffPreloadedMsg:
+		// Stale loads are drained so the loaders finish
+		if msg.gen == a.diffGen {
+			a.preloaded(msg)
+		}
+		return a, waitForPreload(msg.next, msg.gen, msg.paths)
+
+	case diffsPreloadedMsg:
+		if msg.gen != a.diffGen {
+			return a, nil
+		}
+		// Whatever didn't fit the cache is loaded as search reaches it
+		for _, path := range msg.paths {
+			delete(a.pending, path)
+		}
+		a.filesPanel.SetPending(a.pending)
+		if a.searchCtrl.IsActive() && len(a.searchCtrl.Pending()) > 0 {
+			return a, a.runSearch()
+		}
+		return a, nil
+
+	case tea.KeyMsg:
+		// Clear status message on any key press
+		a.statusMsg = ""
+
+		if a.following != nil {
+			return a, a.followerKey(msg)
+		}
+
+		// Handle modal input first if open
+		if a.chooser != nil {
+			var cmd tea.Cmd
+			_, cmd = a.chooser.Update(msg)
+			return a, cmd
+		}
+		if a.earlierList != nil {
+			var cmd tea.Cmd
+			_, cmd = a.earlierList.Update(msg)
+			return a, cmd
+		}
+		if a.historyList != nil {
+			var cmd tea.Cmd
+			_, cmd = a.historyList.Update(msg)
+			return a, cmd
+		}
+		if a.pluginMenu != nil {
+			var cmd tea.Cmd
+			_, cmd = a.pluginMenu.Update(msg)
+			return a, cmd
+		}
+		if a.hunkMenu != nil {
+			var cmd tea.Cmd
+			_, cmd = a.hunkMenu.Update(msg)
+			return a, cmd
+		}
+		if a.modalOpen && a.feedbackModal != nil {
+			var cmd tea.Cmd
+			_, cmd = a.feedbackModal.Update(msg)
+			return a, cmd
+		}
+		if a.prefsModal != nil {
+			var cmd tea.Cmd
+			_, cmd = a.prefsModal.Update(msg)
+			return a, cmd
+		}
+		if a.commitModal != nil {
+			var cmd tea.Cmd
+			_, cmd = a.commitModal.Update(msg)
+			return a, cmd
+		}
+		if a.notesModal != nil {
+			var cmd tea.Cmd
+			_, cmd = a.notesModal.Update(msg)
+			return a, cmd
+		}
+		if a.detailsModal != nil {
+			var cmd tea.Cmd
+			_, cmd = a.detailsModal.Update(msg)
+			return a, cmd
+		}
+		if a.baseModal != nil {
+			var cmd tea.Cmd
+			_, cmd = a.baseModal.Update(msg)
+			return a, cmd
+		}
+		if a.noBaseScreen != nil {
+			var cmd tea.Cmd
+			_, cmd = a.noBaseScreen.Update(msg)
+			return a, cmd
+		}
+
+		// Handle unified search mode at app level
+		if a.searchCtrl.IsActive() {
+			return a.handleSearchInput(msg)
+		}
+
+		// Files panel name filter captures typing while focused
+		if a.filesPanel.IsNameFiltering() {
+			return a, a.filesPanel.UpdateNameFilter(msg)
+		}
+
+		// esc drops an applied name filter (unless cancelling a count)
+		if msg.String() == "esc" && a.router.Pending() == 0 && a.filesPanel.HasNameFilter() {
+			a.filesPanel.ClearNameFilter()
+			return a, nil
+		}
+
+		// Global key handling
+		action, count := a.router.Route(msg)
+		return a, a.handleAction(action, count)
+	}
+
+	return a, tea.Batch(cmds...)
+}
+
+// handleAction performs a routed action. count is the numeric prefix typed
+// before the key, or 0 if none was given.
+func (a *App) handleAction(action keys.Action, count int) tea.Cmd {
+	n := count
+	if n < 1 {
+		n = 1
+	}
+
+	switch action {
+	case keys.Quit:
+		return tea.Quit
+
+	case keys.Search:
+		// Activate unified search
+		_, cmd := a.activateSearch()
+		return cmd
+
+	case keys.FilterFiles:
+		if a.quick {
+			a.statusMsg = "Quick mode has no files panel to filter"
+			return nil
+		}
+		// Filter the files panel by name, separate from unified search
+		return a.filesPanel.ActivateNameFilter()
+
+	case keys.Preferences:
+		a.prefsModal = floating.NewPreferencesModal(a.cfg)
+		a.prefsModal.SetSize(a.width, a.height)
+
+	case keys.Commit:
+		a.openCommitModal()
+
+	case keys.Notes:
+		a.openNotes()
+
+	case keys.Describe:
+		a.statusMsg = "Drafting description..."
+		return a.draftDescription()
+
+	case keys.SwitchScope:
+		return a.switchScope()
+
+	case keys.ToggleInvisibles:
+		a.diffPanel.SetShowInvisibles(!a.diffPanel.ShowInvisibles())
+
+	case keys.ToggleWhitespace:
+		return a.toggleWhitespace()
+
+	case keys.ToggleIgnored:
+		return a.toggleIgnored()
+
+	case keys.APISummary:
+		return a.summarizeAPI()
+
+	case keys.ToggleLockfile:
+		a.rawLockfiles = !a.rawLockfiles
+		if a.rawLockfiles {
+			a.statusMsg = "Lockfiles: raw diff"
+		} else {
+			a.statusMsg = "Lockfiles: package summary"
+		}
+		if lockfile.Recognized(a.diffPanel.FilePath()) {
+			a.redisplay()
+		}
+
+	case keys.ToggleOutputs:
+		a.showOutputs = !a.showOutputs
+		if a.showOutputs {
+			a.statusMsg = "Notebook outputs: expanded"
+		} else {
+			a.statusMsg = "Notebook outputs: collapsed"
+		}
+		if notebook.Recognized(a.diffPanel.FilePath()) {
+			a.redisplay()
+		}
+
+	case keys.Feedback:
+		// Enter on diff panel opens feedback modal
+		a.openFeedbackModal()
+
+	case keys.Praise:
+		return a.praise()
+
+	case keys.Resolve:
+		// r reloads like ctrl+r, except in a re-review, where it resolves
+		if a.previous == nil {
+			return a.reload()
+		}
+		a.toggleResolved()
+
+	case keys.EarlierComments:
+		a.openEarlierList()
+
+	case keys.ConflictsOnly:
+		return a.toggleConflictsOnly()
+
+	case keys.ToggleDescription:
+		a.toggleDescription()
+
+	case keys.RepeatComment:
+		a.repeatComment()
+
+	case keys.History:
+		return a.openHistory()
+
+	case keys.Reload:
+		return a.reload()
+
+	case keys.Base:
+		return a.openBase()
+	case keys.Plugins:
+		a.openPluginMenu()
+
+	case keys.HunkMenu:
+		a.openHunkMenu()
+
+	case keys.ToggleFold:
+		a.foldCursorHunk()
+
+	// File navigation goes to the files panel, or between the files of
+	// the combined diff in quick mode
+	case keys.FileUp:
+		if a.quick {
+			a.quickJump(-n)
+			return nil
+		}
+		return a.filesPanel.MoveCursor(-n)
+	case keys.FileDown:
+		if a.quick {
+			a.quickJump(n)
+			return nil
+		}
+		return a.filesPanel.MoveCursor(n)
+
+	// Line navigation goes to the diff panel (always)
+	case keys.LineUp:
+		a.diffPanel.MoveCursor(-n)
+	case keys.LineDown:
+		a.diffPanel.MoveCursor(n)
+	case keys.PageUp:
+		a.diffPanel.MovePage(-n)
+	case keys.PageDown:
+		a.diffPanel.MovePage(n)
+	case keys.HalfPageUp:
+		a.diffPanel.MoveHalfPage(-n)
+	case keys.HalfPageDown:
+		a.diffPanel.MoveHalfPage(n)
+	case keys.Percent:
+		// Bare % jumps to the middle of the diff
+		if count == 0 {
+			count = 50
+		}
+		a.diffPanel.GotoPercent(count)
+	case keys.Top:
+		if count > 0 {
+			a.diffPanel.GotoLine(count - 1)
+		} else {
+			a.diffPanel.GotoTop()
+		}
+	case keys.Bottom:
+		if count > 0 {
+			a.diffPanel.GotoLine(count - 1)
+		} else {
+			a.diffPanel.GotoBottom()
+		}
+	}
+
+	a.diffPanel.Refresh()
+	return nil
+}
+
+// applyConfig switches the running app to new preferences. Changing the
+// context lines drops cached diffs and reloads the current one.
+func (a *App) applyConfig(cfg config.Config) tea.Cmd {
+	prev := a.cfg
+	a.cfg = cfg
+
+	theme.Apply(cfg.Theme)
+	a.router.SetKeymap(keys.ProfileKeymap(cfg.Keymap))
+	a.diffPanel.SetWrap(cfg.Wrap)
+	a.diffPanel.SetWordDiff(cfg.WordDiff)
+	a.diffPanel.SetHighlights(highlightRules(cfg))
+	a.updatePanelSizes()
+	a.diffCache.SetBudget(cacheBudget(cfg))
+	a.diffCache.SetCompression(cfg.Compress)
+	a.setFilesTitle()
+
+	var load tea.Cmd
+	if (cfg.FileOrder != prev.FileOrder || cfg.Generated != prev.Generated) && a.files != nil {
+		load = a.rearrangeFiles()
+		if cfg.FileOrder == "risk" && prev.FileOrder != "risk" {
+			// Count the churn the risk order needs
+			load = tea.Batch(load, a.loadStats())
+		}
+	}
+
+	if cfg.FormatNoise != prev.FormatNoise {
+		a.redisplay()
+	}
+	if cfg.LineAge != prev.LineAge {
+		load = tea.Batch(load, a.showLineAges())
+	}
+
+	if cfg.ContextLines == prev.ContextLines {
+		return load
+	}
+	c, ok := a.vcs.(vcs.Configurable)
+	if !ok {
+		return load
+	}
+	opts := c.Options()
+	opts.ContextLines = cfg.ContextLines
+	c.SetOptions(opts)
+	a.invalidateDiffs()
+
+	if path := a.diffPanel.FilePath(); path != "" {
+		return tea.Batch(load, a.loadDiff(path))
+	}
+	return load
+}
+
+// invalidateDiffs drops cached diffs after the backend's options change,
+// along with any loads still in flight under the old options
+func (a *App) invalidateDiffs() {
+	a.diffCache.Clear()
+	a.diffGen++
+	a.diffCancel()
+	a.diffCtx, a.diffCancel = context.WithCancel(a.ctx)
+	a.pending = nil
+	a.filesPanel.SetPending(nil)
+	a.pluginFindings = nil
+	a.blames = nil
+}
+
+// reload re-scans the changed files and drops every cached diff, picking
+// up edits made to the working copy since the review started
+func (a *App) reload() tea.Cmd {
+	if a.loading {
+		return nil
+	}
+	a.invalidateDiffs()
+	a.stats = nil
+	a.filesPanel.SetStats(nil)
+	a.statusMsg = "Reloaded the changes"
+	return tea.Batch(a.loadFiles, a.loadDescription())
+}
+
+// switchScope cycles git between all, staged and unstaged changes and
+// reloads the file list
+func (a *App) switchScope() tea.Cmd {
+	s, ok := a.vcs.(vcs.Scoped)
+	if !ok {
+		a.statusMsg = a.vcs.Name() + " has no staging area to switch"
+		return nil
+	}
+
+	opts := s.Options()
+	scopes := s.Scopes()
+	if len(scopes) < 2 {
+		a.statusMsg = "A range of revisions has no staged or unstaged changes to switch"
+		return nil
+	}
+	next := scopes[0]
+	for i, scope := range scopes {
+		if scope == opts.Scope {
+			next = scopes[(i+1)%len(scopes)]
+		}
+	}
+	opts.Scope = next
+	s.SetOptions(opts)
+	a.invalidateDiffs()
+	a.stats = nil
+	a.positions = nil
+	a.filesPanel.SetStats(nil)
+
+	a.setFilesTitle()
+	a.statusMsg = "Showing " + next.String() + " changes"
+	return a.loadFiles
+}
+
+// toggleWhitespace reloads the diffs with changes that only touch
+// whitespace left out, or shown again
+func (a *App) toggleWhitespace() tea.Cmd {
+	c, ok := a.vcs.(vcs.Configurable)
+	if !ok {
+		a.statusMsg = a.vcs.Name() + " diffs can't leave out whitespace changes"
+		return nil
+	}
+	opts := c.Options()
+	opts.IgnoreWhitespace = !opts.IgnoreWhitespace
+	c.SetOptions(opts)
+	a.invalidateDiffs()
+
+	a.setFilesTitle()
+	if opts.IgnoreWhitespace {
+		a.statusMsg = "Whitespace changes: hidden"
+	} else {
+		a.statusMsg = "Whitespace changes: shown"
+	}
+	if path := a.diffPanel.FilePath(); path != "" {
+		return a.loadDiff(path)
+	}
+	return nil
+}
+
+// toggleIgnored shows the files .tcrignore leaves out of the review, or
+// hides them again, and reloads the file list
+func (a *App) toggleIgnored() tea.Cmd {
+	c, ok := a.vcs.(vcs.Configurable)
+	if !ok || c.Options().Ignore == nil {
+		a.statusMsg = "No " + vcs.IgnoreFile + " leaves files out of this review"
+		return nil
+	}
+	opts := c.Options()
+	opts.ShowIgnored = !opts.ShowIgnored
+	c.SetOptions(opts)
+	a.invalidateDiffs()
+	a.stats = nil
+	a.filesPanel.SetStats(nil)
+
+	a.setFilesTitle()
+	if opts.ShowIgnored {
+		a.statusMsg = "Files in " + vcs.IgnoreFile + ": shown"
+	} else {
+		a.statusMsg = "Files in " + vcs.IgnoreFile + ": hidden"
+	}
+	return a.loadFiles
+}
+
+// openCommitModal offers to commit the reviewed changes with a message
+// pre-filled from the review
+func (a *App) openCommitModal() {
+	if _, ok := a.vcs.(vcs.Committer); !ok {
+		a.statusMsg = a.vcs.Name() + " doesn't support committing from tcr"
+		return
+	}
+
+	title := "Commit"
+	if a.vcs.Name() == "jj" {
+		title = "Describe & new change"
+	}
+	a.commitModal = floating.NewCommitModal(title, a.reviewSummary())
+	a.commitModal.SetSize(a.width, a.height)
+}
+
+// reviewSummary describes the session's review for a commit message: what
+// was looked at and the first line of each comment
+func (a *App) reviewSummary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reviewed %d file(s)", len(a.files))
+	if len(a.saved) == 0 {
+		b.WriteString(", no comments")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, ", %d comment(s):\n", len(a.saved))
+	for _, c := range a.saved {
+		comment, _, _ := strings.Cut(c.Comment, "\n")
+		fmt.Fprintf(&b, "\n- %s:%d %s", c.FilePath, c.LineNumber, comment)
+	}
+	return b.String()
+}
+
+// openNotes opens the review notes editor
+func (a *App) openNotes() {
+	a.notesModal = floating.NewNotesModal(a.notes)
+	a.notesModal.SetSize(a.width, a.height)
+}
+
+// describeTimeout bounds a configured describe_command
+const describeTimeout = 2 * time.Minute
+
+// draftDescription drafts a change description from every changed file's
+// diff in the background, through describe_command if one is configured
+func (a *App) draftDescription() tea.Cmd {
+	files := make([]describe.File, len(a.files))
+	for i, f := range a.files {
+		files[i] = describe.File{Path: f.Path, Status: f.Status}
+		files[i].Diff, _ = a.diffCache.Peek(f.Path)
+	}
+	command := a.cfg.DescribeCmd
+
+	return func() tea.Msg {
+		for i := range files {
+			if files[i].Diff == "" {
+				files[i].Diff, _ = a.vcs.Diff(a.ctx, files[i].Path)
+			}
+		}
+		draft := describe.Draft(files)
+		if command == "" {
+			return descriptionDraftedMsg{text: draft}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+		defer cancel()
+		text, err := describe.External(ctx, command, draft, files)
+		if err != nil {
+			return descriptionDraftedMsg{text: draft, err: err}
+		}
+		return descriptionDraftedMsg{text: text}
+	}
+}
+
+// summarizeAPI compares the exported API of the selected Go file before and
+// after the changes, in the background
+func (a *App) summarizeAPI() tea.Cmd {
+	sel := a.filesPanel.SelectedFile()
+	if sel == nil {
+		return nil
+	}
+	if !strings.HasSuffix(sel.Path, ".go") {
+		a.statusMsg = "API summaries are only available for Go files"
+		return nil
+	}
+	path := sel.Path
+	a.statusMsg = "Comparing API of " + path + "..."
+	return func() tea.Msg {
+		var sources [2]string
+		for i, rev := range []vcs.Rev{vcs.RevBase, vcs.RevHead} {
+			src, err := a.vcs.FileContents(a.ctx, path, rev)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return apiSummaryMsg{path: path, err: err}
+			}
+			sources[i] = src
+		}
+		changes, err := goapi.Diff(sources[0], sources[1])
+		if err != nil {
+			return apiSummaryMsg{path: path, err: fmt.Errorf("%s: %w", path, err)}
+		}
+		return apiSummaryMsg{path: path, text: goapi.Format(changes)}
+	}
+}
+
+// apiSummaryMsg carries the exported API changes of one file
+type apiSummaryMsg struct {
+	path string
+	text string
+	err  error
+}
+
+// descriptionDraftedMsg carries a drafted description for the notes
+type descriptionDraftedMsg struct {
+	text string
+	err  error // describe_command failed and text is the template draft
+}
+
+// commit runs the backend's commit with message in the background
+func (a *App) commit(message string) tea.Cmd {
+	c := a.vcs.(vcs.Committer)
+	return func() tea.Msg {
+		return commitDoneMsg{err: c.Commit(a.ctx, message)}
+	}
+}
+
+// commitDoneMsg reports the result of a commit
+type commitDoneMsg struct {
+	err error
+}
+
+// toggleDescription shows the whole commit message over the panels, or
+// only its first line
+func (a *App) toggleDescription() {
+	if a.descPanel.Empty() {
+		a.statusMsg = "No commit message or change description to show"
+		return
+	}
+	a.descPanel.SetExpanded(!a.descPanel.Expanded())
+	a.updatePanelSizes()
+}
+
+// toggleConflictsOnly narrows the files panel to files with merge
+// conflicts, or shows every file again
+func (a *App) toggleConflictsOnly() tea.Cmd {
+	if a.quick {
+		a.statusMsg = "Quick mode has no files panel to filter"
+		return nil
+	}
+	if a.filesPanel.ConflictsOnly() {
+		cmd := a.filesPanel.SetConflictsOnly(false)
+		a.setFilesTitle()
+		a.statusMsg = "Showing all files"
+		return cmd
+	}
+	n := a.filesPanel.ConflictCount()
+	if n == 0 {
+		a.statusMsg = "No files have merge conflicts"
+		return nil
+	}
+	cmd := a.filesPanel.SetConflictsOnly(true)
+	a.setFilesTitle()
+	a.statusMsg = fmt.Sprintf("Showing only files with merge conflicts (%d) · x shows all", n)
+	return cmd
+}
+
+// setFilesTitle names the git scope in the files panel title when it isn't
+// showing all changes, or the range of revisions being reviewed
+func (a *App) setFilesTitle() {
+	title := "Files"
+	if c, ok := a.vcs.(vcs.Configurable); ok {
+		opts := c.Options()
+		switch {
+		case opts.HasRange():
+			title += " (" + rangeLabel(opts) + ")"
+		case opts.Scope != vcs.ScopeAll:
+			title += " (" + opts.Scope.String() + ")"
+		}
+	}
+	// The whole change's line counts, once counted
+	if total := vcs.Total(a.stats); total.Added > 0 || total.Removed > 0 {
+		title += fmt.Sprintf(" +%d −%d", total.Added, total.Removed)
+	}
+	// The TODO markers added and removed, counted along with the lines
+	if todos := a.todos.String(); todos != "" && a.stats != nil {
+		title += " · " + todos
+	}
+	// The comment count against the review budget, once there are comments
+	if budget := a.cfg.ReviewMaxComments; budget > 0 && len(a.saved) > 0 {
+		title += fmt.Sprintf(" · %d/%d comments", len(a.saved), budget)
+	}
+	if c, ok := a.vcs.(vcs.Configurable); ok && c.Options().IgnoreWhitespace {
+		title += " · ignoring whitespace"
+	}
+	if c, ok := a.vcs.(vcs.Configurable); ok && c.Options().ShowIgnored {
+		title += " · +ignored"
+	}
+	a.filesPanel.SetTitle(title)
+}
+
+// rangeLabel describes a range of revisions as from..to, leaving out an
+// unset end
+func rangeLabel(opts vcs.Options) string {
+	switch {
+	case opts.Interdiff:
+		return "interdiff " + opts.From + " → " + opts.To
+	case opts.From == "":
+		return opts.To
+	case opts.To == "":
+		return opts.From + ".."
+	}
+	return opts.From + ".." + opts.To
+}
+
+// Config returns the preferences currently in effect
+func (a *App) Config() config.Config {
+	return a.cfg
+}
+
+// arrangeFiles orders the changed files for the files panel and marks the
+// code generated from changed .proto files, per the config
+func (a *App) arrangeFiles() []vcs.FileChange {
+	files := vcs.SortChanges(a.files, vcs.Order(a.cfg.FileOrder))
+	a.scores = nil
+	if a.cfg.FileOrder == "risk" {
+		a.scores = risk.Scores(a.files, a.stats, a.churn)
+		files = risk.Sort(files, a.scores)
+	}
+	a.generated = nil
+	if a.cfg.Generated != "show" {
+		a.generated = vcs.GeneratedSources(a.files)
+		files = vcs.GroupGenerated(files, a.generated)
+	}
+	a.filesPanel.SetGenerated(a.generated, a.cfg.Generated == "collapse")
+	return files
+}
+
+// rearrangeFiles redoes the files panel's order, keeping the selected file
+func (a *App) rearrangeFiles() tea.Cmd {
+	sel := a.filesPanel.SelectedFile()
+	a.filesPanel.SetFiles(a.arrangeFiles())
+	var cmd tea.Cmd
+	if a.searchCtrl.IsActive() {
+		// Search results are file indices, so they follow the new order
+		cmd = a.runSearch()
+	}
+	if sel != nil {
+		a.filesPanel.SelectPath(sel.Path)
+	}
+	return cmd
+}
+
+// generatedStatus tells which .proto a generated file comes from, or which
+// generated files changed along with a .proto
+func (a *App) generatedStatus(path string) string {
+	if proto, ok := a.generated[path]; ok {
+		return "Generated from " + proto
+	}
+	var outputs []string
+	for _, f := range a.files {
+		if a.generated[f.Path] == path {
+			outputs = append(outputs, f.Path)
+		}
+	}
+	if len(outputs) == 0 {
+		return ""
+	}
+	return "Regenerated: " + strings.Join(outputs, ", ")
+}
+
+// Severity returns the most severe label among this session's comments
+func (a *App) Severity() output.Severity {
+	worst := output.SeverityNone
+	for _, c := range a.saved {
+		worst = max(worst, output.Classify(c.Comment))
+	}
+	return worst
+}
+
+// CommentCount returns how many comments were saved this session
+func (a *App) CommentCount() int {
+	return len(a.saved)
+}
+
+// showDiff displays a loaded diff in the diff panel
+func (a *App) showDiff(path, content string) {
+	todos := findings.TODOs(content)
+	folds := foldedHunks(content, a.folded[path])
+	content, noise := prepareDiff(path, content, a.cfg, a.rawLockfiles, a.showOutputs, a.folded[path])
+
+	a.rememberPosition()
+	a.diffPanel.SetDiff(path, content)
+	a.diffPanel.SetTODOs(todos)
+	a.diffPanel.SetFoldedHunks(folds)
+	a.diffPanel.SetLineAges(a.lineAges(path, content))
+	a.diffPanel.SetFindings(a.lineFindings(path, content))
+	a.diffPanel.SetFormatNoise(noise)
+	a.annotate(path, content)
+	a.markComments()
+	if pos, ok := a.positions[path]; ok {
+		a.diffPanel.Restore(pos.cursor, pos.offset)
+	}
+
+	if a.resume != nil && a.resume.File == path {
+		a.diffPanel.GotoLine(a.resume.Line)
+		a.diffPanel.Refresh()
+		a.statusMsg = "Resumed review at " + path
+		a.resume = nil
+	}
+	if a.jumpTo != nil && a.jumpTo.FilePath == path {
+		a.gotoCommentLine(a.jumpTo.Line)
+		a.jumpTo = nil
+	}
+	if a.followPos != nil && a.followPos.File == path {
+		a.gotoPosition(*a.followPos)
+		a.followPos = nil
+	}
+
+	// If search is active, apply search to the new diff
+	if a.searchCtrl.IsActive() {
+		a.diffPanel.SetSearchQuery(a.searchCtrl.Query())
+		a.updateDiffSearchMatches(a.searchCtrl.Query())
+		a.diffPanel.SetSearchInputView(a.searchCtrl.InputView())
+	}
+}
+
+// diffPosition is where the cursor and scroll were in a file's diff
+type diffPosition struct {
+	cursor int // Diff line index
+	offset int // First display row in view
+}
+
+// rememberPosition notes where the cursor is in the diff shown, so
+// returning to the file later puts it back there
+func (a *App) rememberPosition() {
+	path := a.diffPanel.FilePath()
+	if path == "" || a.quick {
+		return
+	}
+	if a.positions == nil {
+		a.positions = make(map[string]diffPosition)
+	}
+	a.positions[path] = diffPosition{cursor: a.diffPanel.CursorLine(), offset: a.diffPanel.ScrollOffset()}
+}
+
+// prepareDiff turns a file's diff into what's displayed: mode changes
+// spelled out, lockfiles summarized unless raw, notebook outputs collapsed unless shown,
+// the hunks with headers in folded folded, and formatting-only hunks found
+// (and folded) per the config. noise holds the lines of those hunks.
+func prepareDiff(path, content string, cfg config.Config, rawLockfiles, showOutputs bool, folded map[string]bool) (string, []findings.Range) {
+	content = vcs.ReadableModes(content)
+
+	// Lockfile diffs run to thousands of lines; list the packages instead
+	summarized := false
+	if !rawLockfiles {
+		if changes, ok := lockfile.Summarize(path, content); ok {
+			content = lockfile.Render(path, changes)
+			summarized = true
+		}
+	}
+
+	// Notebook outputs are mostly noise next to the source changes
+	if !showOutputs && notebook.Recognized(path) {
+		content = notebook.CollapseOutputs(content)
+	}
+
+	if !summarized {
+		content = vcs.FoldHunks(content, folded)
+	}
+
+	var noise []findings.Range
+	if !summarized && cfg.FormatNoise != "show" {
+		noise = findings.FormatOnly(content)
+		if cfg.FormatNoise == "collapse" {
+			content, noise = findings.CollapseRanges(content, noise)
+		}
+	}
+	return content, noise
+}
+
+// annotate shows comments made elsewhere on the diff: those the VCS knows
+// of, such as on a review server, and an earlier review's
+func (a *App) annotate(path, content string) {
+	var annotations []vcs.Annotation
+	if an, ok := a.vcs.(vcs.Annotator); ok {
+		annotations = an.Annotations(path)
+	}
+	for i, c := range a.previous {
+		if c.FilePath != path {
+			continue
+		}
+		author := "earlier"
+		if _, ok := a.resolved[i]; ok {
+			author = "✓ resolved"
+		} else if a.addressed[i] {
+			author = "possibly addressed"
+		}
+		annotations = append(annotations, vcs.Annotation{Line: c.Line, Side: vcs.RevHead, Author: author, Message: c.Comment})
+	}
+	a.diffPanel.SetAnnotations(annotationLines(content, annotations))
+}
+
+// annotationLines places review comments on the diff lines they refer to.
+// Comments on the whole file, or on lines the diff doesn't show, go on the
+// first line.
+func annotationLines(diff string, annotations []vcs.Annotation) map[int]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	oldLines, newLines := findings.LineIndexes(diff)
+	lines := make(map[int]string)
+	for _, an := range annotations {
+		index, ok := newLines[an.Line]
+		if an.Side == vcs.RevBase {
+			index, ok = oldLines[an.Line]
+		}
+		if !ok {
+			index = 0
+		}
+		text := an.Author + ": " + strings.Join(strings.Fields(an.Message), " ")
+		if prev, ok := lines[index]; ok {
+			text = prev + " · " + text
+		}
+		lines[index] = text
+	}
+	return lines
+}
+
+// redisplay shows the current diff again after a display setting changes
+func (a *App) redisplay() {
+	if a.quick {
+		a.showQuick()
+		return
+	}
+	path := a.diffPanel.FilePath()
+	if path == "" {
+		return
+	}
+	if content, ok := a.diffCache.Peek(path); ok {
+		a.showDiff(path, content)
 	}
 }
 
-func (a *App) Init() tea.Cmd {
-	return a.loadFiles
-}
-
-func (a *App) loadFiles() tea.Msg {
-	files, err := a.vcs.ChangedFiles()
-	if err != nil {
-		return errMsg{err}
+// prefetchNeighbors loads the diffs next to the selection in the background
+// so moving through the file list doesn't wait on the VCS
+func (a *App) prefetchNeighbors() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, path := range a.filesPanel.NeighborPaths(prefetchDistance) {
+		if a.diffCache.Contains(path) || a.pool.InFlight(path) {
+			continue
+		}
+		ctx, gen := a.diffCtx, a.diffGen
+		cmds = append(cmds, func() tea.Msg {
+			var msg tea.Msg
+			a.pool.Do(path, func() {
+				if content, err := a.vcs.Diff(ctx, path); err == nil {
+					msg = diffPrefetchedMsg{path: path, content: content, gen: gen}
+				}
+			})
+			return msg
+		})
 	}
-	return filesLoadedMsg{files}
+	return tea.Batch(cmds...)
 }
 
-type filesLoadedMsg struct {
-	files []vcs.FileChange
+// SessionState snapshots the review position for crash reports
+func (a *App) SessionState() crash.State {
+	s := crash.State{
+		OutputPath:  a.outputPath,
+		File:        a.diffPanel.FilePath(),
+		Line:        a.diffPanel.CursorLine(),
+		FilterQuery: a.filesPanel.NameFilterQuery(),
+		FileCount:   a.filesPanel.TotalCount(),
+	}
+	if a.vcs != nil {
+		s.VCS = a.vcs.Name()
+	}
+	if a.searchCtrl.IsActive() {
+		s.SearchQuery = a.searchCtrl.Query()
+	}
+	return s
 }
 
-type errMsg struct {
-	err error
+// SetPrevious loads an earlier review's comments, from the review file at
+// reviewPath, to re-review the change against: they're shown on the diff,
+// those whose lines changed are flagged as possibly addressed, and r marks
+// them resolved
+func (a *App) SetPrevious(reviewPath string, comments []output.Feedback) {
+	a.previous = comments
+	a.resolved = make(map[int]string)
+	a.addressed = possiblyAddressed(a.ctx, a.vcs, reviewPath, comments)
+	if len(a.addressed) > 0 {
+		a.statusMsg = fmt.Sprintf("%d of %d earlier comments possibly addressed · R lists them", len(a.addressed), len(comments))
+	}
 }
 
-func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
-
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		a.width = msg.Width
-		a.height = msg.Height
-		a.ready = true
-		a.updatePanelSizes()
-
-		if a.feedbackModal != nil {
-			a.feedbackModal.SetSize(a.width, a.height)
-		}
-
-		return a, nil
+// possiblyAddressed finds the earlier comments whose lines have changed
+// since they were written, by the line text saved with each. Comments
+// saved without it, or on files the VCS has only part of, can't be told.
+func possiblyAddressed(ctx context.Context, v vcs.VCS, reviewPath string, comments []output.Feedback) map[int]bool {
+	anchors, err := output.LoadAnchors(reviewPath)
+	if err != nil || len(anchors) == 0 {
+		return nil
+	}
 
-	case filesLoadedMsg:
-		a.filesPanel.SetFiles(msg.files)
-		// Load diff for first file if any
-		if len(msg.files) > 0 {
-			return a, a.loadDiff(msg.files[0].Path)
+	files := make(map[string][]string)
+	addressed := make(map[int]bool)
+	for i, c := range comments {
+		anchor, ok := anchors.Anchor(c.FilePath, c.Line)
+		if !ok {
+			continue
 		}
-		return a, nil
-
-	case panels.FileSelectedMsg:
-		return a, a.loadDiff(msg.Path)
-
-	case diffLoadedMsg:
-		// Cache the diff
-		a.diffCache[msg.path] = msg.content
-
-		// Set the diff content
-		a.diffPanel.SetDiff(msg.path, msg.content)
-
-		// If search is active, apply search to the new diff
-		if a.searchCtrl.IsActive() {
-			a.diffPanel.SetSearchQuery(a.searchCtrl.Query())
-			a.updateDiffSearchMatches(a.searchCtrl.Query())
-			a.diffPanel.SetSearchInputView(a.searchCtrl.InputView())
+		lines, ok := files[c.FilePath]
+		if !ok {
+			// A deleted file leaves no lines, so its comments changed
+			content, err := v.FileContents(ctx, c.FilePath, vcs.RevHead)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			lines = strings.Split(content, "\n")
+			files[c.FilePath] = lines
 		}
-		return a, nil
-
-	case floating.FeedbackSavedMsg:
-		// Save feedback to file
-		err := output.AppendFeedback(a.outputPath, msg.FilePath, msg.LineNumber, msg.Comment)
-		if err != nil {
-			a.statusMsg = "Error: " + err.Error()
-		} else {
-			a.statusMsg = "Feedback saved"
+		if output.LineChanged(anchor, lines, c.Line) {
+			addressed[i] = true
 		}
-		a.closeModal()
-		return a, nil
-
-	case floating.FeedbackCancelledMsg:
-		a.closeModal()
-		return a, nil
+	}
+	return addressed
+}
 
-	case errMsg:
-		a.statusMsg = "Error: " + msg.err.Error()
-		return a, nil
+// openEarlierList lists the earlier review's comments to verify: those
+// possibly addressed first, then the rest still open, then the resolved
+func (a *App) openEarlierList() {
+	if a.previous == nil {
+		a.statusMsg = "No earlier review to list (start tcr with --previous FILE)"
+		return
+	}
 
-	case diffsPreloadedBatchMsg:
-		// Add preloaded diffs to cache
-		for _, result := range msg.results {
-			a.diffCache[result.path] = result.content
+	var order []int
+	rank := func(i int) int {
+		if _, ok := a.resolved[i]; ok {
+			return 2
 		}
-		// Re-run search if active to include newly cached diffs
-		if a.searchCtrl.IsActive() && a.searchCtrl.Query() != "" {
-			a.runSearch()
+		if a.addressed[i] {
+			return 0
 		}
-		return a, nil
-
-	case tea.KeyMsg:
-		// Clear status message on any key press
-		a.statusMsg = ""
+		return 1
+	}
+	for r := 0; r <= 2; r++ {
+		for i := range a.previous {
+			if rank(i) == r {
+				order = append(order, i)
+			}
+		}
+	}
 
-		// Handle modal input first if open
-		if a.modalOpen && a.feedbackModal != nil {
-			var cmd tea.Cmd
-			_, cmd = a.feedbackModal.Update(msg)
-			return a, cmd
+	statuses := []string{"possibly addressed", "open", "resolved"}
+	comments := make([]floating.EarlierComment, len(order))
+	for n, i := range order {
+		c := a.previous[i]
+		where := c.FilePath
+		if c.Line > 0 {
+			where = fmt.Sprintf("%s:%d", c.FilePath, c.Line)
+		}
+		comments[n] = floating.EarlierComment{
+			Location: where,
+			Comment:  c.Comment,
+			Status:   statuses[rank(i)],
 		}
+	}
+	a.earlierList = floating.NewEarlierList(comments)
+	a.earlierList.SetSize(a.width, a.height)
+	a.earlierOrder = order
+}
 
-		// Handle unified search mode at app level
-		if a.searchCtrl.IsActive() {
-			return a.handleSearchInput(msg)
+// gotoEarlier shows the diff an earlier comment is on, with the cursor on
+// its line
+func (a *App) gotoEarlier(i int) tea.Cmd {
+	c := a.previous[i]
+	if a.quick {
+		if !a.quickGoto(c.FilePath, c.Line) {
+			a.statusMsg = c.FilePath + " isn't among the changed files"
 		}
+		return nil
+	}
+	if a.diffPanel.FilePath() == c.FilePath {
+		a.gotoCommentLine(c.Line)
+		return nil
+	}
+	if !a.filesPanel.SelectPath(c.FilePath) {
+		a.statusMsg = c.FilePath + " isn't among the changed files"
+		return nil
+	}
+	a.jumpTo = &c
+	return a.loadDiff(c.FilePath)
+}
 
-		// Global key handling
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return a, tea.Quit
+// gotoCommentLine puts the diff cursor where annotationLines shows a
+// comment on a line: the line itself, or the first line
+func (a *App) gotoCommentLine(line int) {
+	_, newLines := findings.LineIndexes(a.diffPanel.DiffContent())
+	a.diffPanel.GotoLine(newLines[line])
+	a.diffPanel.Refresh()
+}
 
-		case "/":
-			// Activate unified search
-			return a.activateSearch()
+// Rereview returns the earlier review's comments and the hunk resolving
+// each one resolved, for the re-review report; nil if there was none
+func (a *App) Rereview() ([]output.Feedback, map[int]string) {
+	return a.previous, a.resolved
+}
 
-		case "enter":
-			// Enter on diff panel opens feedback modal
-			a.openFeedbackModal()
-			return a, nil
+// toggleResolved marks the earlier comments on the cursor line resolved,
+// recording the hunk they were resolved in, or opens them again if all
+// already are
+func (a *App) toggleResolved() {
+	if a.previous == nil {
+		a.statusMsg = "No earlier review to resolve (start tcr with --previous FILE)"
+		return
+	}
+	path, content := a.diffPanel.FilePath(), a.diffPanel.DiffContent()
+	cursor := a.diffPanel.CursorLine()
+	_, newLines := findings.LineIndexes(content)
+
+	var here []int
+	allResolved := true
+	for i, c := range a.previous {
+		if c.FilePath != path {
+			continue
 		}
+		// Where annotationLines puts it: its line, or the first line
+		index, ok := newLines[c.Line]
+		if !ok {
+			index = 0
+		}
+		if index == cursor {
+			here = append(here, i)
+			_, resolved := a.resolved[i]
+			allResolved = allResolved && resolved
+		}
+	}
+	if len(here) == 0 {
+		a.statusMsg = "No earlier comment on this line"
+		return
+	}
 
-		// Route arrow keys to files panel (always)
-		switch msg.String() {
-		case "up", "down":
-			var cmd tea.Cmd
-			_, cmd = a.filesPanel.Update(msg)
-			if cmd != nil {
-				cmds = append(cmds, cmd)
-			}
+	hunk := hunkAt(a.diffPanel.Lines(), cursor)
+	for _, i := range here {
+		if allResolved {
+			delete(a.resolved, i)
+		} else if _, ok := a.resolved[i]; !ok {
+			a.resolved[i] = hunk
 		}
+	}
+	if allResolved {
+		a.statusMsg = fmt.Sprintf("Reopened %d earlier comment(s)", len(here))
+	} else {
+		a.statusMsg = fmt.Sprintf("Resolved in %s · %d of %d earlier comments resolved", hunk, len(a.resolved), len(a.previous))
+	}
+	a.annotate(path, content)
+}
 
-		// Route emacs bindings to diff panel (always)
-		switch msg.String() {
-		case "ctrl+n", "ctrl+p", "ctrl+v", "alt+v", "alt+<", "alt+>":
-			var cmd tea.Cmd
-			_, cmd = a.diffPanel.Update(msg)
-			if cmd != nil {
-				cmds = append(cmds, cmd)
-			}
+// hunkAt returns the header of the hunk containing diff line i, such as
+// "@@ -10,4 +10,6 @@", or the line itself for diffs without headers
+func hunkAt(lines []string, i int) string {
+	for j := min(i, len(lines)-1); j >= 0; j-- {
+		if h, ok := vcs.HunkHeader(strings.TrimSpace(ansi.Strip(lines[j]))); ok {
+			return h
 		}
 	}
+	if i >= 0 && i < len(lines) {
+		return strings.TrimSpace(ansi.Strip(lines[i]))
+	}
+	return ""
+}
 
-	return a, tea.Batch(cmds...)
+// Resume restores the file and cursor line from a crashed session once
+// the file list has loaded
+func (a *App) Resume(s crash.State) {
+	a.resume = &s
 }
 
 func (a *App) loadDiff(path string) tea.Cmd {
+	a.cancelLoad()
+	ctx, cancel := context.WithCancel(a.diffCtx)
+	a.loadCancel = cancel
+	gen := a.diffGen
 	return func() tea.Msg {
-		content, err := a.vcs.Diff(path)
+		defer cancel()
+		content, err := a.vcs.Diff(ctx, path)
+		if ctx.Err() != nil {
+			// Another file was selected, or the diffs went stale
+			return nil
+		}
 		if err != nil {
 			return errMsg{err}
 		}
-		return diffLoadedMsg{path: path, content: content}
+		return diffLoadedMsg{path: path, content: content, gen: gen}
+	}
+}
+
+// cancelLoad stops loading the file last selected, if it's still loading
+func (a *App) cancelLoad() {
+	if a.loadCancel != nil {
+		a.loadCancel()
+		a.loadCancel = nil
 	}
 }
 
 type diffLoadedMsg struct {
 	path    string
 	content string
+	gen     int // diffGen when the load started
+}
+
+// prefetchDistance is how many files either side of the selection to prefetch
+const prefetchDistance = 1
+
+// diffPrefetchedMsg carries a neighbor's diff loaded ahead of selection
+type diffPrefetchedMsg struct {
+	path    string
+	content string
+	gen     int
 }
 
 // activateSearch starts unified search mode
@@ -234,50 +1918,172 @@ func (a *App) activateSearch() (tea.Model, tea.Cmd) {
 	// Sync input view for proper cursor rendering
 	a.diffPanel.SetSearchInputView(a.searchCtrl.InputView())
 
+	// Quick mode searches only the diff on screen, so loads nothing more,
+	// and preload_workers = 0 leaves search to load each diff as it goes
+	if a.quick || a.cfg.Preload == 0 {
+		return a, cmd
+	}
+
 	// Start preloading uncached diffs in background
 	preloadCmd := a.preloadDiffsAsync()
 
 	return a, tea.Batch(cmd, preloadCmd)
 }
 
-// diffPreloadedMsg is sent when a diff is preloaded into cache
+// preloadBatchSize is how many files a backend that diffs several at once
+// is given per command when preloading
+const preloadBatchSize = 50
+
+// diffPreloadedMsg is sent as each diff is preloaded into the cache
 type diffPreloadedMsg struct {
 	path    string
 	content string
+	err     error
+	gen     int
+	next    chan diffPreloadedMsg // Where the rest arrive
+	paths   []string              // All the preload was given
+}
+
+// diffsPreloadedMsg is sent when a preload is done, with the paths it
+// was given, loaded or not
+type diffsPreloadedMsg struct {
+	gen   int
+	paths []string
 }
 
-// preloadDiffsAsync returns a command that loads uncached diffs in background
+// preloadDiffsAsync returns a command that loads uncached diffs in the
+// background, each arriving as a diffPreloadedMsg so search can take it in
+// as soon as it's there
 func (a *App) preloadDiffsAsync() tea.Cmd {
+	if len(a.pending) > 0 {
+		// Still preloading from the last search
+		a.filesPanel.SetPending(a.pending)
+		return nil
+	}
 	paths := a.filesPanel.FilePaths()
 
 	// Collect paths that need loading
 	var uncachedPaths []string
 	for _, path := range paths {
-		if _, ok := a.diffCache[path]; !ok {
+		if !a.diffCache.Contains(path) {
 			uncachedPaths = append(uncachedPaths, path)
 		}
 	}
 
-	if len(uncachedPaths) == 0 {
+	// Don't preload past the memory budget; search reloads the rest on demand
+	room := a.diffCache.Remaining()
+	if len(uncachedPaths) == 0 || room == 0 {
 		return nil
 	}
 
-	// Load all uncached diffs concurrently
+	return a.loadDiffsAsync(uncachedPaths, room)
+}
+
+// loadDiffsAsync marks paths pending and loads their diffs in the
+// background preload_workers at a time, stopping once they pass room
+// bytes unless room is negative
+func (a *App) loadDiffsAsync(uncachedPaths []string, room int64) tea.Cmd {
+	if a.pending == nil {
+		a.pending = make(map[string]bool, len(uncachedPaths))
+	}
+	for _, path := range uncachedPaths {
+		a.pending[path] = true
+	}
+	a.filesPanel.SetPending(a.pending)
+
+	// Load uncached diffs preload_workers at a time
+	ctx, gen := a.diffCtx, a.diffGen
+	pool := workpool.New(a.cfg.Preload)
+	results := make(chan diffPreloadedMsg)
+	v := a.vcs
+	go func() {
+		var (
+			mu     sync.Mutex
+			wg     sync.WaitGroup
+			loaded int64
+		)
+		// Backends that diff several files with one command get them a
+		// batch at a time, the rest a file at a time
+		size := 1
+		if _, ok := v.(vcs.BatchDiffer); ok {
+			size = preloadBatchSize
+		}
+		for start := 0; start < len(uncachedPaths); start += size {
+			batch := uncachedPaths[start:min(start+size, len(uncachedPaths))]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pool.Do(batch[0], func() {
+					mu.Lock()
+					full := room > 0 && loaded > room
+					mu.Unlock()
+					if full || ctx.Err() != nil {
+						return
+					}
+					diffs, err := vcs.DiffFiles(ctx, v, batch)
+					if ctx.Err() != nil {
+						// The diffs went stale; the search that wanted them is gone
+						return
+					}
+					for _, path := range batch {
+						content := diffs[path]
+						mu.Lock()
+						loaded += int64(len(content))
+						full = room > 0 && loaded > room
+						mu.Unlock()
+						if full {
+							return
+						}
+						results <- diffPreloadedMsg{path: path, content: content, err: err, gen: gen, next: results}
+					}
+				})
+			}()
+		}
+		wg.Wait()
+		close(results)
+	}()
+	return waitForPreload(results, gen, uncachedPaths)
+}
+
+// waitForPreload returns a command that waits for the next preloaded diff
+// of paths
+func waitForPreload(results chan diffPreloadedMsg, gen int, paths []string) tea.Cmd {
 	return func() tea.Msg {
-		var results []diffPreloadedMsg
-		for _, path := range uncachedPaths {
-			content, err := a.vcs.Diff(path)
-			if err == nil {
-				results = append(results, diffPreloadedMsg{path: path, content: content})
-			}
+		if msg, ok := <-results; ok {
+			msg.paths = paths
+			return msg
 		}
-		return diffsPreloadedBatchMsg{results: results}
+		return diffsPreloadedMsg{gen: gen, paths: paths}
 	}
 }
 
-// diffsPreloadedBatchMsg is sent when all background diffs are loaded
-type diffsPreloadedBatchMsg struct {
-	results []diffPreloadedMsg
+// preloaded caches a preloaded diff and searches it if search is waiting
+// on it
+func (a *App) preloaded(msg diffPreloadedMsg) {
+	// Diffs are cached only while there's room, so a search over a huge
+	// change set doesn't churn the cache
+	if room := a.diffCache.Remaining(); msg.err == nil && (room < 0 || int64(len(msg.content)) <= room) {
+		a.diffCache.Put(msg.path, msg.content)
+	}
+	delete(a.pending, msg.path)
+	if !a.searchCtrl.IsActive() {
+		return
+	}
+	a.filesPanel.SetPending(a.pending)
+	if a.searchCtrl.Query() == "" {
+		return
+	}
+	for i, path := range a.filesPanel.FilePaths() {
+		if path == msg.path {
+			a.searchCtrl.Resolve(i, msg.content)
+			break
+		}
+	}
+	if filtered := a.searchCtrl.FilteredIndices(); filtered != nil {
+		a.filesPanel.SetFilteredIndices(filtered)
+	} else {
+		a.filesPanel.ClearFilter()
+	}
 }
 
 // handleSearchInput processes keys during search mode
@@ -293,6 +2099,11 @@ func (a *App) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.diffPanel.CycleNextMatch()
 		return a, nil
 
+	case "alt+enter", "alt+f":
+		// One comment on every match, or every matched file
+		a.openBulkComment(msg.String() == "alt+f")
+		return a, nil
+
 	case "up":
 		// Navigate to previous file in filtered list
 		var cmd tea.Cmd
@@ -312,7 +2123,7 @@ func (a *App) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		// Re-run search if query changed
 		if a.searchCtrl.Query() != oldQuery {
-			a.runSearch()
+			cmd = tea.Batch(cmd, a.runSearch())
 		}
 
 		// Always sync the input view (for cursor position)
@@ -322,15 +2133,22 @@ func (a *App) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
-// runSearch executes search across all files and updates panels
-func (a *App) runSearch() {
+// runSearch executes search across all files and updates panels,
+// returning a command that loads the diffs it's still waiting on
+func (a *App) runSearch() tea.Cmd {
 	query := a.searchCtrl.Query()
+	if a.quick {
+		a.diffPanel.SetSearchQuery(query)
+		a.updateDiffSearchMatches(query)
+		return nil
+	}
 
 	// Get file paths
 	paths := a.filesPanel.FilePaths()
 
 	// Run search across all cached diffs
-	a.searchCtrl.SearchAllFiles(query, paths, a.diffCache)
+	a.searchCtrl.SearchFiles(query, paths, a.lookupDiff)
+	a.filesPanel.SetSearchQuery(query)
 
 	// Update files panel with filtered indices
 	filteredIdxs := a.searchCtrl.FilteredIndices()
@@ -343,6 +2161,42 @@ func (a *App) runSearch() {
 	// Update diff panel with current search query and matches
 	a.diffPanel.SetSearchQuery(query)
 	a.updateDiffSearchMatches(query)
+
+	return a.loadSearchDiffs(paths)
+}
+
+// lookupDiff serves a diff for search from the cache, or reports it isn't
+// loaded, leaving the file pending in the search
+func (a *App) lookupDiff(path string) (string, bool) {
+	return a.diffCache.Peek(path)
+}
+
+// loadSearchDiffs loads in the background the diffs search is waiting on
+// that nothing is loading yet: evicted, past the cache budget or, with
+// preload_workers = 0, never preloaded. Each is searched as it arrives.
+func (a *App) loadSearchDiffs(paths []string) tea.Cmd {
+	var missing []string
+	for _, i := range a.searchCtrl.Pending() {
+		if i < len(paths) && !a.pending[paths[i]] {
+			missing = append(missing, paths[i])
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return a.loadDiffsAsync(missing, -1)
+}
+
+// cacheBudget converts the configured cache size to bytes
+func cacheBudget(cfg config.Config) int64 {
+	return int64(cfg.CacheMB) << 20
+}
+
+// highlightRules compiles the configured highlight rules. The config was
+// validated when loaded, so a rule that doesn't compile drops them all.
+func highlightRules(cfg config.Config) []highlight.Rule {
+	rules, _ := highlight.Compile(cfg.Highlights)
+	return rules
 }
 
 // updateDiffSearchMatches runs search on current diff and updates matches
@@ -360,30 +2214,243 @@ func (a *App) updateDiffSearchMatches(query string) {
 func (a *App) deactivateSearch() {
 	a.searchCtrl.Deactivate()
 	a.filesPanel.ClearFilter()
+	a.filesPanel.SetPending(nil)
 	a.diffPanel.DeactivateSearch()
 }
 
+// openFeedbackModal starts a comment on the cursor line, first offering
+// the line's existing comments to edit or delete if it has any
 func (a *App) openFeedbackModal() {
 	filePath := a.diffPanel.FilePath()
-	cursorLine := a.diffPanel.CursorLine()
-	lineContent := a.diffPanel.CurrentLineContent()
-	diffContent := a.diffPanel.DiffContent()
-
 	if filePath == "" {
 		return
 	}
 
+	line := a.cursorSourceLine()
+	var indexes []int
+	var comments []string
+	for i, c := range a.saved {
+		if c.FilePath == filePath && c.LineNumber == line {
+			indexes = append(indexes, i)
+			comments = append(comments, c.Comment)
+		}
+	}
+	if len(indexes) == 0 {
+		a.newFeedbackModal()
+		return
+	}
+
+	a.chooser = floating.NewCommentChooser(fmt.Sprintf("%s:%d", filePath, line), comments)
+	a.chooser.SetSize(a.width, a.height)
+	a.chooserSaved = indexes
+}
+
+// addComment saves a new comment to the output file, reporting status
+// (and whether the review is over its comment budget) on success. The
+// command it returns anchors the comment.
+func (a *App) addComment(c floating.FeedbackSavedMsg, status string) tea.Cmd {
+	if err := output.AppendFeedback(a.outputPath, c.FilePath, c.LineNumber, c.Comment); err != nil {
+		a.statusMsg = "Error: " + err.Error()
+		return nil
+	}
+	a.statusMsg = status
+	a.saved = append(a.saved, c)
+	go a.plugins.Commented(plugin.Comment{Path: c.FilePath, Line: c.LineNumber, Comment: c.Comment})
+	if a.rec != nil {
+		a.rec.Mark(commentMarker(c))
+	}
+	if budget := a.cfg.ReviewMaxComments; budget > 0 && len(a.saved) > budget {
+		a.statusMsg += fmt.Sprintf(" · %d comments, over the review budget of %d", len(a.saved), budget)
+	}
+	a.markComments()
+	return a.saveAnchor(c)
+}
+
+// SetRecorder marks each comment saved in the session recording, which
+// Recorded draws the frames of
+func (a *App) SetRecorder(rec *record.Recorder) {
+	a.rec = rec
+}
+
+// commentMarker labels a comment in a recording by where it is and its
+// first line
+func commentMarker(c floating.FeedbackSavedMsg) string {
+	where := c.FilePath
+	if c.LineNumber > 0 {
+		where = fmt.Sprintf("%s:%d", c.FilePath, c.LineNumber)
+	}
+	first, _, _ := strings.Cut(c.Comment, "\n")
+	return "comment " + where + ": " + first
+}
+
+// saveAnchor returns a command that reads the line a new comment is on,
+// recorded when it arrives as an anchorMsg so a later re-review can tell
+// whether the line changed. It's best effort: without it the comment is
+// just never flagged as possibly addressed.
+func (a *App) saveAnchor(c floating.FeedbackSavedMsg) tea.Cmd {
+	if c.LineNumber <= 0 {
+		return nil
+	}
+	ctx, v := a.ctx, a.vcs
+	return func() tea.Msg {
+		content, err := v.FileContents(ctx, c.FilePath, vcs.RevHead)
+		if err != nil {
+			return nil
+		}
+		lines := strings.Split(content, "\n")
+		if c.LineNumber > len(lines) {
+			return nil
+		}
+		return anchorMsg{path: c.FilePath, line: c.LineNumber, text: lines[c.LineNumber-1]}
+	}
+}
+
+// anchorMsg carries the text of a commented line, to record as its anchor
+type anchorMsg struct {
+	path string
+	line int
+	text string
+}
+
+// praiseText is the comment the praise key leaves
+const praiseText = "Nice work here."
+
+// praise leaves a short positive comment on the cursor line, without
+// opening the feedback modal, unless the line already has one
+func (a *App) praise() tea.Cmd {
+	filePath := a.diffPanel.FilePath()
+	if filePath == "" {
+		return nil
+	}
+	c := floating.FeedbackSavedMsg{
+		FilePath:   filePath,
+		LineNumber: a.cursorSourceLine(),
+		Comment:    output.WithLabel(praiseText, "praise", "", a.cfg.CommentLabels == "emoji"),
+	}
+	for _, s := range a.saved {
+		if s == c {
+			a.statusMsg = "Already praised this line"
+			return nil
+		}
+	}
+	return a.addComment(c, fmt.Sprintf("Praised %s:%d", c.FilePath, c.LineNumber))
+}
+
+// cursorSourceLine returns the file line number under the diff cursor
+func (a *App) cursorSourceLine() int {
+	if a.quick {
+		return a.quickSourceLine()
+	}
 	// Calculate actual source line number from diff hunk headers
-	actualLineNumber := floating.CalculateLineNumber(diffContent, cursorLine)
+	return floating.CalculateLineNumber(a.diffPanel.DiffContent(), a.diffPanel.CursorLine())
+}
+
+// newFeedbackModal opens the feedback editor for a new comment on the
+// cursor line
+func (a *App) newFeedbackModal() {
+	filePath := a.diffPanel.FilePath()
+	lineContent := panels.EscapeControl(a.diffPanel.CurrentLineContent())
 
-	a.feedbackModal = floating.NewFeedbackModal(filePath, actualLineNumber, lineContent)
+	a.feedbackModal = floating.NewFeedbackModal(filePath, a.cursorSourceLine(), lineContent)
+	a.feedbackModal.SetTemplates(a.cfg.TemplatesFor(filePath))
+	a.feedbackModal.SetLineBudget(a.cfg.CommentMaxLines)
+	a.feedbackModal.SetLabelStyle(a.cfg.CommentLabels)
 	a.feedbackModal.SetSize(a.width, a.height)
 	a.modalOpen = true
 }
 
+// repeatComment opens the feedback editor on the cursor line filled in with
+// the last comment written, for a nit that recurs
+func (a *App) repeatComment() {
+	if a.diffPanel.FilePath() == "" {
+		return
+	}
+	if a.lastComment == "" {
+		a.statusMsg = "No comment written yet to repeat"
+		return
+	}
+	a.newFeedbackModal()
+	a.feedbackModal.SetDraft(a.lastComment)
+}
+
+// editComment opens the feedback editor on a saved comment
+func (a *App) editComment(i int) {
+	a.newFeedbackModal()
+	a.feedbackModal.SetComment(a.saved[i].Comment)
+	a.editing = i
+}
+
+// reviseComment rewrites saved comment i in the output file, or deletes
+// it if comment is empty
+func (a *App) reviseComment(i int, comment string) {
+	c := a.saved[i]
+	if err := output.ReplaceFeedback(a.outputPath, c.FilePath, c.LineNumber, c.Comment, comment); err != nil {
+		a.statusMsg = "Error: " + err.Error()
+		return
+	}
+	if comment == "" {
+		a.saved = append(a.saved[:i], a.saved[i+1:]...)
+		a.statusMsg = "Comment deleted"
+	} else {
+		a.saved[i].Comment = comment
+		a.statusMsg = "Feedback updated"
+	}
+	a.markComments()
+}
+
+// markComments shows the current file's comments from this review in the
+// diff gutter, and the review's comment count in the files title
+func (a *App) markComments() {
+	a.setFilesTitle()
+	if a.quick {
+		a.diffPanel.SetComments(a.quickComments())
+		return
+	}
+	a.diffPanel.SetComments(commentLines(a.diffPanel.DiffContent(), a.diffPanel.FilePath(), a.saved))
+}
+
+// commentLines counts the comments on each diff line of path. Lines are
+// numbered the way openFeedbackModal numbers them, so each comment lands on
+// the line it was made on.
+func commentLines(diff, path string, saved []floating.FeedbackSavedMsg) map[int]int {
+	perLine := make(map[int]int)
+	for _, c := range saved {
+		if c.FilePath == path {
+			perLine[c.LineNumber]++
+		}
+	}
+	if len(perLine) == 0 {
+		return nil
+	}
+
+	lines := make(map[int]int)
+	for i, line := range strings.Split(diff, "\n") {
+		n := floating.ExtractLineNumberFromDiffLine(line)
+		if n == 0 {
+			n = i + 1
+		}
+		if count, ok := perLine[n]; ok {
+			lines[i] = count
+			delete(perLine, n) // Mark only the first line with the number
+		}
+	}
+	return lines
+}
+
 func (a *App) closeModal() {
 	a.feedbackModal = nil
 	a.modalOpen = false
+	a.editing = -1
+	a.chooser = nil
+	a.chooserSaved = nil
+	a.bulkTargets = nil
+	a.earlierList = nil
+	a.historyList = nil
+	a.earlierOrder = nil
+	a.pluginMenu = nil
+	a.hunkMenu = nil
+	a.hunkActions = nil
+	a.pluginItems = nil
 }
 
 func (a *App) updatePanelSizes() {
@@ -391,8 +2458,28 @@ func (a *App) updatePanelSizes() {
 		return
 	}
 
-	// Reserve 1 line for help bar
+	// Reserve 1 line for help bar, and the description's rows above the
+	// panels, up to a third of the screen
 	availableHeight := a.height - 1
+	descHeight := a.descPanel.Rows(max(availableHeight/3, 3))
+	a.descPanel.SetSize(a.width, descHeight)
+	availableHeight -= descHeight
+
+	if a.quick {
+		a.diffPanel.SetSize(a.width, availableHeight)
+		return
+	}
+
+	if a.cfg.Layout == "stacked" {
+		// Files panel on top, diff below, both full width
+		filesHeight := availableHeight / 3
+		if filesHeight < 5 {
+			filesHeight = 5
+		}
+		a.filesPanel.SetSize(a.width, filesHeight)
+		a.diffPanel.SetSize(a.width, availableHeight-filesHeight)
+		return
+	}
 
 	// Files panel: fixed width on left
 	filesWidth := theme.SidebarWidth
@@ -407,7 +2494,13 @@ func (a *App) updatePanelSizes() {
 	a.diffPanel.SetSize(diffWidth, availableHeight)
 }
 
+// View draws the app, led by the escape for any copy to the clipboard
+// so the renderer writes it with the frame rather than alongside it
 func (a *App) View() string {
+	return a.clipboard + a.view()
+}
+
+func (a *App) view() string {
 	if !a.ready {
 		return "Loading..."
 	}
@@ -416,13 +2509,27 @@ func (a *App) View() string {
 	filesView := a.filesPanel.View()
 	diffView := a.diffPanel.View()
 
-	// Join panels horizontally
-	mainView := lipgloss.JoinHorizontal(lipgloss.Top, filesView, diffView)
+	// Join panels horizontally (or vertically in the stacked layout)
+	var mainView string
+	if a.quick {
+		mainView = diffView
+	} else if a.cfg.Layout == "stacked" {
+		mainView = lipgloss.JoinVertical(lipgloss.Left, filesView, diffView)
+	} else {
+		mainView = lipgloss.JoinHorizontal(lipgloss.Top, filesView, diffView)
+	}
+	if !a.descPanel.Empty() {
+		mainView = lipgloss.JoinVertical(lipgloss.Left, a.descPanel.View(), mainView)
+	}
 
 	// Add help bar
 	helpCtx := HelpBarContext{
-		ModalOpen:    a.modalOpen,
+		ModalOpen:    a.modalOpen || a.chooser != nil || a.earlierList != nil || a.historyList != nil || a.pluginMenu != nil || a.hunkMenu != nil || a.prefsModal != nil || a.commitModal != nil || a.notesModal != nil || a.detailsModal != nil || a.baseModal != nil || a.noBaseScreen != nil,
 		SearchActive: a.searchCtrl.IsActive(),
+		FilterActive: a.filesPanel.IsNameFiltering(),
+		PendingCount: a.router.Pending(),
+		Quick:        a.quick,
+		Following:    a.following != nil,
 	}
 	helpBar := RenderHelpBar(helpCtx, a.width)
 
@@ -430,9 +2537,44 @@ func (a *App) View() string {
 	fullView := lipgloss.JoinVertical(lipgloss.Left, mainView, helpBar)
 
 	// Overlay modal if open
+	if a.chooser != nil {
+		return floating.RenderSimpleOverlay(fullView, a.chooser.View(), a.width, a.height)
+	}
+	if a.earlierList != nil {
+		return floating.RenderSimpleOverlay(fullView, a.earlierList.View(), a.width, a.height)
+	}
+	if a.historyList != nil {
+		return floating.RenderSimpleOverlay(fullView, a.historyList.View(), a.width, a.height)
+	}
+	if a.pluginMenu != nil {
+		return floating.RenderSimpleOverlay(fullView, a.pluginMenu.View(), a.width, a.height)
+	}
+	if a.hunkMenu != nil {
+		return floating.RenderSimpleOverlay(fullView, a.hunkMenu.View(), a.width, a.height)
+	}
 	if a.modalOpen && a.feedbackModal != nil {
 		return floating.RenderSimpleOverlay(fullView, a.feedbackModal.View(), a.width, a.height)
 	}
+	if a.prefsModal != nil {
+		return floating.RenderSimpleOverlay(fullView, a.prefsModal.View(), a.width, a.height)
+	}
+	if a.commitModal != nil {
+		return floating.RenderSimpleOverlay(fullView, a.commitModal.View(), a.width, a.height)
+	}
+	if a.notesModal != nil {
+		return floating.RenderSimpleOverlay(fullView, a.notesModal.View(), a.width, a.height)
+	}
+	if a.detailsModal != nil {
+		return floating.RenderSimpleOverlay(fullView, a.detailsModal.View(), a.width, a.height)
+	}
+	if a.baseModal != nil {
+		return floating.RenderSimpleOverlay(fullView, a.baseModal.View(), a.width, a.height)
+	}
+	// The panels have nothing to show without a base, nor the status
+	// line: the screen explains
+	if a.noBaseScreen != nil {
+		return a.noBaseScreen.View()
+	}
 
 	// Add status message if any (replaces help bar temporarily)
 	if a.statusMsg != "" {
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/ui/floating"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// baseFailedMsg reports a base revision that couldn't be diffed against,
+// with the options to go back to
+type baseFailedMsg struct {
+	opts vcs.Options
+	err  error
+}
+
+// showCompared remembers what the review diffs against and says so when
+// that changes, as on startup or after picking another base
+func (a *App) showCompared(compared *vcs.Comparison) {
+	prev := a.compared
+	a.compared = compared
+	if compared == nil || a.statusMsg != "" || (prev != nil && prev.Compared == compared.Compared) {
+		return
+	}
+	a.statusMsg = "Comparing " + compared.Compared + " · b changes the base"
+}
+
+// baseCandidatesLimit is how many recent commits the base picker lists,
+// after the branches
+const baseCandidatesLimit = 50
+
+// baseCandidatesMsg carries the branches and recent revisions to pick a
+// base from
+type baseCandidatesMsg struct {
+	candidates []floating.BaseCandidate
+}
+
+// openBase loads the branches, or bookmarks, and recent revisions in the
+// background, to pick another base from. Only backends with a history of
+// revisions have one to pick.
+func (a *App) openBase() tea.Cmd {
+	logger, ok := a.vcs.(vcs.Logger)
+	c, configurable := a.vcs.(vcs.Configurable)
+	if !ok || !configurable {
+		a.statusMsg = a.vcs.Name() + " has no base revision to change"
+		return nil
+	}
+	if c.Options().Interdiff {
+		a.statusMsg = "An interdiff compares two versions of a change; quit to review against another base"
+		return nil
+	}
+	a.statusMsg = "Loading revisions..."
+	return func() tea.Msg {
+		// Either list failing leaves the other, and typing a revision
+		var candidates []floating.BaseCandidate
+		if lister, ok := a.vcs.(vcs.RefLister); ok {
+			refs, _ := lister.Refs(a.ctx)
+			for _, r := range refs {
+				candidates = append(candidates, floating.BaseCandidate{Rev: r.Name, Label: strings.TrimSpace(r.Name + "  " + r.ID)})
+			}
+		}
+		entries, _ := logger.Log(a.ctx, baseCandidatesLimit)
+		for _, e := range entries {
+			candidates = append(candidates, floating.BaseCandidate{Rev: e.ID, Label: e.ID + "  " + e.Date + "  " + e.Subject})
+		}
+		return baseCandidatesMsg{candidates: candidates}
+	}
+}
+
+// showBase shows what the review diffs against, over the revisions to
+// pick another base from
+func (a *App) showBase(msg baseCandidatesMsg) tea.Cmd {
+	a.statusMsg = ""
+	var compared string
+	if a.compared != nil {
+		compared = a.compared.Compared
+	}
+	a.baseModal = floating.NewBaseModal(compared, a.vcs.(vcs.Configurable).Options().From, msg.candidates)
+	a.baseModal.SetSize(a.width, a.height)
+	return a.baseModal.Init()
+}
+
+// changeBase diffs against rev instead, or the default base when rev is
+// empty, and reloads the files. A revision that doesn't resolve leaves
+// the review as it was.
+func (a *App) changeBase(rev string) tea.Cmd {
+	c := a.vcs.(vcs.Configurable)
+	prev := c.Options()
+	if rev == prev.From {
+		return nil
+	}
+	opts := prev
+	opts.From = rev
+	if rev != "" {
+		// A range has no staged or unstaged changes
+		opts.Scope = vcs.ScopeAll
+	}
+	c.SetOptions(opts)
+	a.invalidateDiffs()
+	a.stats = nil
+	a.positions = nil
+	a.filesPanel.SetStats(nil)
+
+	a.setFilesTitle()
+	a.statusMsg = ""
+	return func() tea.Msg {
+		msg := a.loadFiles()
+		if failed, ok := msg.(errMsg); ok {
+			return baseFailedMsg{opts: prev, err: failed.err}
+		}
+		return msg
+	}
+}
+
+// restoreBase goes back to the base in effect before a failed change,
+// counting the files' lines again
+func (a *App) restoreBase(msg baseFailedMsg) tea.Cmd {
+	a.vcs.(vcs.Configurable).SetOptions(msg.opts)
+	a.invalidateDiffs()
+	a.setFilesTitle()
+	a.statusMsg = "Error: " + msg.err.Error()
+	if a.noBaseScreen != nil {
+		// Nothing loaded yet to count the lines of
+		if !a.showNoBase(msg.err) {
+			a.noBaseScreen.SetFailed(a.statusMsg)
+		}
+		return nil
+	}
+	return a.loadStats()
+}
+
+// showNoBase takes over the screen when err is a base that doesn't
+// resolve, to pick another or review the working copy alone, and reports
+// whether it is
+func (a *App) showNoBase(err error) bool {
+	var baseErr *vcs.BaseError
+	if !errors.As(err, &baseErr) {
+		return false
+	}
+	if _, ok := a.vcs.(vcs.Configurable); !ok {
+		return false
+	}
+	a.noBase = baseErr
+	a.noBaseScreen = floating.NewNoBaseScreen(baseErr.Base, baseErr.Shallow, baseErr.Err.Error(), baseErr.Hints)
+	a.noBaseScreen.SetSize(a.width, a.height)
+	a.filesPanel.SetLoading("No base to compare against")
+	return true
+}
+
+// leaveNoBase goes on without the base that didn't resolve: reviewing the
+// working copy alone, or picking another base over the screen, which stays
+// until one loads
+func (a *App) leaveNoBase(choice floating.NoBaseChoice) tea.Cmd {
+	if choice == floating.PickBase {
+		return a.openBase()
+	}
+	a.vcs.(vcs.Configurable).SetOptions(a.noBase.Fallback)
+	a.invalidateDiffs()
+	a.setFilesTitle()
+	a.statusMsg = "Reviewing the working copy alone, without the base " + a.noBase.Base
+	a.noBase, a.noBaseScreen = nil, nil
+	a.loading = true
+	a.filesPanel.SetLoading("Loading changes...")
+	// What's described changes with the base too
+	return tea.Batch(a.loadFiles, a.loadDescription())
+}
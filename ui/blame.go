@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// blameLines finds out in the background when each line of a file was
+// last changed, for line_age to tint the context lines of its diff
+func (a *App) blameLines(path string) tea.Cmd {
+	b, ok := a.vcs.(vcs.Blamer)
+	if !ok || !a.cfg.LineAge || a.quick || path == "" {
+		return nil
+	}
+	if _, ok := a.blames[path]; ok {
+		return nil
+	}
+	if a.blames == nil {
+		a.blames = make(map[string]map[int]time.Time)
+	}
+	a.blames[path] = nil
+	ctx, gen := a.diffCtx, a.diffGen
+	return func() tea.Msg {
+		// Added files have nothing to blame, and the tint is only a guide
+		times, _ := b.Blame(ctx, path)
+		return blamedMsg{gen: gen, path: path, times: times}
+	}
+}
+
+// blamedMsg carries when each line of a file was last changed, by its
+// line number before the change
+type blamedMsg struct {
+	gen   int
+	path  string
+	times map[int]time.Time
+}
+
+// blamed keeps when a file's lines were last changed, and tints them if
+// the file is shown
+func (a *App) blamed(msg blamedMsg) {
+	if msg.gen != a.diffGen {
+		return
+	}
+	a.blames[msg.path] = msg.times
+	if path := a.diffPanel.FilePath(); path == msg.path {
+		a.diffPanel.SetLineAges(a.lineAges(path, a.diffPanel.DiffContent()))
+	}
+}
+
+// lineAges says how long ago each context line of a file's diff was last
+// changed, by diff line, once the file is blamed and if line_age is on
+func (a *App) lineAges(path, content string) map[int]time.Duration {
+	times := a.blames[path]
+	if !a.cfg.LineAge || times == nil {
+		return nil
+	}
+	// Context lines are the ones on both sides of the change
+	oldLines, newLines := findings.LineIndexes(content)
+	context := make(map[int]bool, len(newLines))
+	for _, i := range newLines {
+		context[i] = true
+	}
+	now := time.Now()
+	ages := make(map[int]time.Duration)
+	for n, i := range oldLines {
+		if t, ok := times[n]; ok && context[i] {
+			ages[i] = now.Sub(t)
+		}
+	}
+	return ages
+}
+
+// showLineAges tints the shown diff's context lines, or drops the tint,
+// after line_age changes
+func (a *App) showLineAges() tea.Cmd {
+	path := a.diffPanel.FilePath()
+	a.diffPanel.SetLineAges(a.lineAges(path, a.diffPanel.DiffContent()))
+	return a.blameLines(path)
+}
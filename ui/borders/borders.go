@@ -79,7 +79,7 @@ func buildTopBorder(title string, width int, borderStyle, titleStyle lipgloss.St
 	// Calculate available space for horizontal lines
 	// Format: ╭─ Title ─...─╮
 	titleLen := lipgloss.Width(title)
-	minPadding := 4 // "╭─ " before title and " ─╮" after (minimum)
+	minPadding := 6 // "╭─ " before title and " ─╮" after (minimum)
 
 	var topLine string
 	if titleLen+minPadding > width {
@@ -71,6 +71,17 @@ func TestRenderTitledBorder(t *testing.T) {
 	}
 }
 
+func TestRenderTitledBorderLongTitle(t *testing.T) {
+	result := RenderTitledBorder("x", "Files · ignoring whitespace +3 −3", 20, 3, false)
+	top := strings.Split(result, "\n")[0]
+	if w := lipgloss.Width(top); w != 20 {
+		t.Errorf("expected a long title to be cut to the border's width 20, got %d: %q", w, top)
+	}
+	if !strings.HasSuffix(top, TopRight) {
+		t.Errorf("expected the top border to keep its corner, got %q", top)
+	}
+}
+
 func TestRenderTitledBorderMinimumSize(t *testing.T) {
 	// Too small dimensions should return content as-is
 	result := RenderTitledBorder("test", "title", 2, 1, false)
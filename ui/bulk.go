@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/ui/floating"
+)
+
+// searchTargets returns where the search matches, to comment on all at
+// once: each matching line of every matched file, or each matched file as
+// a whole with wholeFiles set. Quick mode has only the diff on screen.
+func (a *App) searchTargets(wholeFiles bool) []floating.FeedbackSavedMsg {
+	query := a.searchCtrl.Query()
+	if query == "" {
+		return nil
+	}
+	var targets []floating.FeedbackSavedMsg
+	seen := make(map[floating.FeedbackSavedMsg]bool)
+	add := func(path string, line int) {
+		if wholeFiles {
+			line = 0
+		}
+		t := floating.FeedbackSavedMsg{FilePath: path, LineNumber: line}
+		if !seen[t] {
+			seen[t] = true
+			targets = append(targets, t)
+		}
+	}
+
+	if a.quick {
+		matches, _ := a.searchCtrl.SearchInDiff(query, a.diffPanel.Lines())
+		for _, i := range matches {
+			if n := a.quickSectionAt(i); n >= 0 && i > a.quickSections[n].start {
+				add(a.quickSections[n].path, floating.CalculateLineNumber(a.quickSectionDiff(n), i-a.quickSections[n].start-1))
+			}
+		}
+		return targets
+	}
+
+	paths := a.filesPanel.FilePaths()
+	for _, idx := range a.searchCtrl.FilteredIndices() {
+		if idx >= len(paths) {
+			continue
+		}
+		diff, ok := a.lookupDiff(paths[idx])
+		if !ok {
+			continue
+		}
+		matches, _ := a.searchCtrl.SearchInDiff(query, strings.Split(diff, "\n"))
+		for _, i := range matches {
+			add(paths[idx], floating.CalculateLineNumber(diff, i))
+		}
+	}
+	return targets
+}
+
+// openBulkComment opens the feedback editor for one comment to save on
+// every search match, or every matched file
+func (a *App) openBulkComment(wholeFiles bool) {
+	targets := a.searchTargets(wholeFiles)
+	if len(targets) == 0 {
+		a.statusMsg = "Nothing matches the search to comment on"
+		return
+	}
+	a.newFeedbackModal()
+	a.feedbackModal.SetContext(fmt.Sprintf("%s matching %q", targetSummary(targets, wholeFiles), a.searchCtrl.Query()))
+	a.bulkTargets = targets
+}
+
+// addBulkComment saves comment on every target of the bulk comment
+func (a *App) addBulkComment(comment string) tea.Cmd {
+	targets := a.bulkTargets
+	a.bulkTargets = nil
+	saved := 0
+	var anchors []tea.Cmd
+	for _, t := range targets {
+		t.Comment = comment
+		before := len(a.saved)
+		anchors = append(anchors, a.addComment(t, ""))
+		if len(a.saved) == before {
+			// addComment reported the error
+			return tea.Batch(anchors...)
+		}
+		saved++
+	}
+	a.statusMsg = "Feedback saved on " + targetSummary(targets[:saved], targets[0].LineNumber == 0)
+	return tea.Batch(anchors...)
+}
+
+// targetSummary counts the lines and files of a bulk comment
+func targetSummary(targets []floating.FeedbackSavedMsg, wholeFiles bool) string {
+	files := make(map[string]bool)
+	for _, t := range targets {
+		files[t.FilePath] = true
+	}
+	if wholeFiles {
+		return plural(len(files), "file")
+	}
+	return plural(len(targets), "line") + " in " + plural(len(files), "file")
+}
+
+// plural formats a count of things, as "1 line" or "3 lines"
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
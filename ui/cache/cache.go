@@ -0,0 +1,161 @@
+// Package cache holds loaded diffs in memory under a byte budget, evicting
+// the least recently used entries first. Entries can be stored s2
+// compressed, which typically shrinks diff text several times over.
+package cache
+
+import (
+	"container/list"
+
+	"github.com/klauspost/compress/s2"
+)
+
+// DefaultBudget is used when no budget is configured (256 MiB)
+const DefaultBudget = 256 << 20
+
+// entry is one cached diff, held either as text or s2 compressed
+type entry struct {
+	path    string
+	content string
+	packed  []byte // Compressed content, nil when stored as text
+}
+
+// size returns the bytes the entry counts against the budget
+func (e *entry) size() int64 {
+	if e.packed != nil {
+		return int64(len(e.packed))
+	}
+	return int64(len(e.content))
+}
+
+// text returns the diff, decompressing if needed
+func (e *entry) text() (string, bool) {
+	if e.packed == nil {
+		return e.content, true
+	}
+	b, err := s2.Decode(nil, e.packed)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// Cache is an LRU cache of diff text keyed by file path. It is not safe
+// for concurrent use; the app only touches it from Update.
+type Cache struct {
+	budget   int64 // Maximum total bytes, 0 for unlimited
+	size     int64 // Stored bytes (compressed size for packed entries)
+	compress bool
+	order    *list.List // Front is most recently used
+	items    map[string]*list.Element
+}
+
+// New creates a cache holding at most budget bytes of diff text.
+// A budget of 0 disables eviction.
+func New(budget int64) *Cache {
+	return &Cache{
+		budget: budget,
+		order:  list.New(),
+		items:  make(map[string]*list.Element),
+	}
+}
+
+// SetCompression toggles storing new entries compressed. Existing entries
+// keep the form they were stored in.
+func (c *Cache) SetCompression(on bool) {
+	c.compress = on
+}
+
+// Get returns a cached diff and marks it most recently used
+func (c *Cache) Get(path string) (string, bool) {
+	el, ok := c.items[path]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*entry).text()
+}
+
+// Peek returns a cached diff without affecting eviction order
+func (c *Cache) Peek(path string) (string, bool) {
+	el, ok := c.items[path]
+	if !ok {
+		return "", false
+	}
+	return el.Value.(*entry).text()
+}
+
+// Contains reports whether path is cached
+func (c *Cache) Contains(path string) bool {
+	_, ok := c.items[path]
+	return ok
+}
+
+// Put stores a diff and evicts older entries until the cache fits its
+// budget. The newest entry is always kept, even if it alone exceeds it.
+func (c *Cache) Put(path, content string) {
+	e := &entry{path: path}
+	if c.compress {
+		e.packed = s2.Encode(nil, []byte(content))
+	} else {
+		e.content = content
+	}
+
+	if el, ok := c.items[path]; ok {
+		c.size -= el.Value.(*entry).size()
+		el.Value = e
+		c.order.MoveToFront(el)
+	} else {
+		c.items[path] = c.order.PushFront(e)
+	}
+	c.size += e.size()
+	c.evict()
+}
+
+// Remaining returns how many bytes can be added before eviction starts,
+// or -1 if the cache is unlimited
+func (c *Cache) Remaining() int64 {
+	if c.budget <= 0 {
+		return -1
+	}
+	if c.size >= c.budget {
+		return 0
+	}
+	return c.budget - c.size
+}
+
+// SetBudget changes the byte budget, evicting if it shrank
+func (c *Cache) SetBudget(budget int64) {
+	c.budget = budget
+	c.evict()
+}
+
+// Clear drops every entry
+func (c *Cache) Clear() {
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	c.size = 0
+}
+
+// Len returns the number of cached diffs
+func (c *Cache) Len() int {
+	return len(c.items)
+}
+
+// Size returns the total bytes stored, after compression
+func (c *Cache) Size() int64 {
+	return c.size
+}
+
+// evict drops least recently used entries until within budget
+func (c *Cache) evict() {
+	if c.budget <= 0 {
+		return
+	}
+	for c.size > c.budget && c.order.Len() > 1 {
+		el := c.order.Back()
+		e := el.Value.(*entry)
+		c.order.Remove(el)
+		delete(c.items, e.path)
+		c.size -= e.size()
+	}
+}
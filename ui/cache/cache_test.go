@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(10)
+	c.Put("a", "aaaa")
+	c.Put("b", "bbbb")
+
+	// Touch a so b becomes the eviction candidate
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+
+	c.Put("c", "cccc")
+	if c.Contains("b") {
+		t.Error("expected b to be evicted")
+	}
+	if !c.Contains("a") || !c.Contains("c") {
+		t.Error("expected a and c to remain")
+	}
+	if c.Size() != 8 || c.Len() != 2 {
+		t.Errorf("unexpected size %d / len %d", c.Size(), c.Len())
+	}
+}
+
+func TestCache_PeekDoesNotRefresh(t *testing.T) {
+	c := New(8)
+	c.Put("a", "aaaa")
+	c.Put("b", "bbbb")
+
+	if got, _ := c.Peek("a"); got != "aaaa" {
+		t.Errorf("unexpected peek %q", got)
+	}
+	c.Put("c", "cccc")
+	if c.Contains("a") {
+		t.Error("Peek should not have protected a from eviction")
+	}
+}
+
+func TestCache_KeepsOversizedNewest(t *testing.T) {
+	c := New(4)
+	c.Put("a", "aa")
+	c.Put("big", strings.Repeat("x", 10))
+
+	if !c.Contains("big") || c.Contains("a") {
+		t.Error("expected only the oversized newest entry to remain")
+	}
+	if c.Remaining() != 0 {
+		t.Errorf("expected no room left, got %d", c.Remaining())
+	}
+}
+
+func TestCache_ReplaceAndBudget(t *testing.T) {
+	c := New(0)
+	c.Put("a", "aaaa")
+	c.Put("a", "aa")
+	if c.Size() != 2 || c.Len() != 1 {
+		t.Errorf("replace: unexpected size %d / len %d", c.Size(), c.Len())
+	}
+	if c.Remaining() != -1 {
+		t.Errorf("expected unlimited cache, got %d", c.Remaining())
+	}
+
+	c.Put("b", "bbbb")
+	c.SetBudget(4)
+	if c.Contains("a") || !c.Contains("b") {
+		t.Error("shrinking the budget should evict the oldest entry")
+	}
+
+	c.Clear()
+	if c.Len() != 0 || c.Size() != 0 {
+		t.Error("expected empty cache after Clear")
+	}
+}
+
+func TestCache_Compression(t *testing.T) {
+	diff := strings.Repeat("+\tif err != nil {\n+\t\treturn err\n+\t}\n", 200)
+
+	c := New(0)
+	c.SetCompression(true)
+	c.Put("a", diff)
+
+	if c.Size() >= int64(len(diff))/4 {
+		t.Errorf("expected compressed size well under %d, got %d", len(diff), c.Size())
+	}
+	got, ok := c.Get("a")
+	if !ok || got != diff {
+		t.Error("compressed entry did not round trip")
+	}
+
+	// Entries stored before the toggle keep their form
+	c.SetCompression(false)
+	c.Put("b", "plain")
+	if got, _ := c.Peek("a"); got != diff {
+		t.Error("compressed entry unreadable after disabling compression")
+	}
+	if c.Size() >= int64(len(diff))/4+5 {
+		t.Errorf("unexpected size after mixed puts: %d", c.Size())
+	}
+}
+
+// benchDiff is repetitive diff text similar to a generated-code change
+var benchDiff = strings.Repeat("@@ -10,6 +10,8 @@ func handler() {\n \tctx := context.Background()\n-\tresult, err := fetch(ctx)\n+\tresult, err := fetchWithRetry(ctx, 3)\n+\tif err != nil {\n+\t\treturn err\n+\t}\n", 2000)
+
+func benchmarkPutGet(b *testing.B, compress bool) {
+	c := New(0)
+	c.SetCompression(compress)
+	b.SetBytes(int64(len(benchDiff)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Put("file.go", benchDiff)
+		if _, ok := c.Get("file.go"); !ok {
+			b.Fatal("missing entry")
+		}
+	}
+	b.ReportMetric(float64(c.Size()), "stored-bytes")
+}
+
+func BenchmarkCache_Plain(b *testing.B)      { benchmarkPutGet(b, false) }
+func BenchmarkCache_Compressed(b *testing.B) { benchmarkPutGet(b, true) }
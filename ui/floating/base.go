@@ -0,0 +1,166 @@
+package floating
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// BaseChosenMsg is sent when the user picks a revision to diff against;
+// an empty Rev goes back to the default base
+type BaseChosenMsg struct {
+	Rev string
+}
+
+// BaseCancelledMsg is sent when the base popup is dismissed
+type BaseCancelledMsg struct{}
+
+// BaseCandidate is a revision offered as the base: a branch, bookmark or
+// recent commit
+type BaseCandidate struct {
+	Rev   string // What to diff against
+	Label string // How it's listed, and what typing filters on
+}
+
+// BaseModal shows what the review diffs against and picks another base:
+// one of the candidates, narrowed by typing, or any revision typed in full
+type BaseModal struct {
+	compared   string
+	input      textinput.Model
+	candidates []BaseCandidate
+	matches    []int // Indexes of the candidates the input matches
+	choice     int   // Index in matches, -1 for the input itself
+	offset     int   // First match shown when the list doesn't fit
+	width      int
+	height     int
+	ready      bool
+}
+
+// NewBaseModal creates a popup describing the comparison, with the input
+// pre-filled with the base given so far and candidates listed under it
+func NewBaseModal(compared, base string, candidates []BaseCandidate) *BaseModal {
+	ti := textinput.New()
+	ti.Placeholder = "default base"
+	ti.Prompt = "Base: "
+	ti.CharLimit = 200
+	ti.SetValue(base)
+	ti.Focus()
+
+	m := &BaseModal{compared: compared, input: ti, candidates: candidates}
+	m.filter()
+	return m
+}
+
+func (m *BaseModal) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *BaseModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "enter":
+			rev := strings.TrimSpace(m.input.Value())
+			if m.choice >= 0 {
+				rev = m.candidates[m.matches[m.choice]].Rev
+			}
+			return m, func() tea.Msg {
+				return BaseChosenMsg{Rev: rev}
+			}
+		case "esc":
+			return m, func() tea.Msg {
+				return BaseCancelledMsg{}
+			}
+		case "up", "ctrl+p":
+			m.choice = max(m.choice-1, -1)
+			return m, nil
+		case "down", "ctrl+n":
+			m.choice = min(m.choice+1, len(m.matches)-1)
+			return m, nil
+		}
+	}
+
+	prev := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != prev {
+		m.filter()
+	}
+	return m, cmd
+}
+
+// filter lists the candidates whose label contains the input, ignoring
+// case, and picks the first. With nothing typed, enter takes the input:
+// the default base.
+func (m *BaseModal) filter() {
+	query := strings.ToLower(strings.TrimSpace(m.input.Value()))
+	m.matches = m.matches[:0]
+	for i, c := range m.candidates {
+		if strings.Contains(strings.ToLower(c.Label), query) {
+			m.matches = append(m.matches, i)
+		}
+	}
+	m.choice, m.offset = -1, 0
+	if query != "" && len(m.matches) > 0 {
+		m.choice = 0
+	}
+}
+
+// SetSize sets the available screen size
+func (m *BaseModal) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *BaseModal) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*60/100, 40)
+	contentWidth := windowWidth - 4
+	m.input.Width = contentWidth - len(m.input.Prompt) - 1
+
+	compared := "Comparing " + m.compared
+	if m.compared == "" {
+		compared = "This review has no base to change"
+	}
+	lines := []string{
+		lipgloss.NewStyle().Width(contentWidth).Render(compared),
+		"",
+		m.input.View(),
+		"",
+	}
+
+	// Keep the choice in view, in what's left of three quarters of the
+	// screen after the text, help and border
+	rows := max(m.height*75/100-lipgloss.Height(strings.Join(lines, "\n"))-4, 1)
+	if m.choice < m.offset {
+		m.offset = max(m.choice, 0)
+	} else if m.choice >= m.offset+rows {
+		m.offset = m.choice - rows + 1
+	}
+	for i := m.offset; i < len(m.matches) && i < m.offset+rows; i++ {
+		item := ansi.Truncate(m.candidates[m.matches[i]].Label, contentWidth-2, "…")
+		if i == m.choice {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+item))
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+item))
+		}
+	}
+	if len(m.matches) > 0 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, theme.HelpDescStyle.Render("type to filter  ↑/↓ pick  enter diff against it  esc cancel"))
+	content := strings.Join(lines, "\n")
+	windowHeight := lipgloss.Height(content) + 2
+
+	windowContent := borders.RenderFloatingBorder(content, "Base", windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
@@ -0,0 +1,78 @@
+package floating
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestBaseModal(t *testing.T) {
+	m := NewBaseModal("the working tree against HEAD (1a2b3c4)", "", nil)
+	m.SetSize(100, 24)
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Base", "Comparing the working tree against HEAD (1a2b3c4)", "Base: "} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("main")})
+	_, cmd := m.Update(key("enter"))
+	if msg, ok := cmd().(BaseChosenMsg); !ok || msg.Rev != "main" {
+		t.Errorf("expected main, got %#v", cmd())
+	}
+
+	_, cmd = m.Update(key("esc"))
+	if _, ok := cmd().(BaseCancelledMsg); !ok {
+		t.Errorf("expected esc to cancel, got %#v", cmd())
+	}
+}
+
+func TestBaseModal_Candidates(t *testing.T) {
+	m := NewBaseModal("the working tree against HEAD (1a2b3c4)", "", []BaseCandidate{
+		{Rev: "main", Label: "main  1a2b3c4"},
+		{Rev: "origin/feature", Label: "origin/feature  5e6f7a8"},
+		{Rev: "9b0c1d2", Label: "9b0c1d2  Fix the parser"},
+	})
+	m.SetSize(100, 24)
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"  main  1a2b3c4", "  origin/feature", "  9b0c1d2  Fix the parser"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Nothing typed or picked goes back to the default base
+	_, cmd := m.Update(key("enter"))
+	if msg := cmd().(BaseChosenMsg); msg.Rev != "" {
+		t.Errorf("expected the default base, got %q", msg.Rev)
+	}
+
+	m.Update(key("down"))
+	m.Update(key("down"))
+	_, cmd = m.Update(key("enter"))
+	if msg := cmd().(BaseChosenMsg); msg.Rev != "origin/feature" {
+		t.Errorf("expected the second candidate, got %q", msg.Rev)
+	}
+
+	// Typing narrows the list and picks the first match
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("PARSER")})
+	if view := ansi.Strip(m.View()); strings.Contains(view, "main  1a2b3c4") || !strings.Contains(view, "> 9b0c1d2") {
+		t.Errorf("expected only the matching commit, picked:\n%s", view)
+	}
+	_, cmd = m.Update(key("enter"))
+	if msg := cmd().(BaseChosenMsg); msg.Rev != "9b0c1d2" {
+		t.Errorf("expected the matching commit, got %q", msg.Rev)
+	}
+
+	// A revision nothing matches is taken as typed
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("~2")})
+	_, cmd = m.Update(key("enter"))
+	if msg := cmd().(BaseChosenMsg); msg.Rev != "PARSER~2" {
+		t.Errorf("expected the typed revision, got %q", msg.Rev)
+	}
+}
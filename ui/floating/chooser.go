@@ -0,0 +1,138 @@
+package floating
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// CommentChosenMsg is sent when a comment is picked from the chooser.
+// Index is the comment's position in the list, or -1 to add a new one.
+type CommentChosenMsg struct {
+	Index int
+}
+
+// CommentDeletedMsg is sent when a comment is deleted from the chooser
+type CommentDeletedMsg struct {
+	Index int
+}
+
+// CommentChooser lists the comments already on a line, to pick one to
+// edit or delete, or to add another
+type CommentChooser struct {
+	location string // path:line the comments are on
+	comments []string
+	choice   int // len(comments) is "new comment"
+	confirm  bool
+	width    int
+	height   int
+	ready    bool
+}
+
+// NewCommentChooser creates a chooser over the comments at location
+func NewCommentChooser(location string, comments []string) *CommentChooser {
+	// Start on "new comment", the usual reason to comment on a line again
+	return &CommentChooser{location: location, comments: comments, choice: len(comments)}
+}
+
+func (m *CommentChooser) Init() tea.Cmd {
+	return nil
+}
+
+func (m *CommentChooser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	// d asks before deleting; any key but a second d keeps the comment
+	if m.confirm {
+		m.confirm = false
+		if keyMsg.String() == "d" {
+			index := m.choice
+			return m, func() tea.Msg {
+				return CommentDeletedMsg{Index: index}
+			}
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return FeedbackCancelledMsg{}
+		}
+	case "enter":
+		index := m.choice
+		if index == len(m.comments) {
+			index = -1
+		}
+		return m, func() tea.Msg {
+			return CommentChosenMsg{Index: index}
+		}
+	case "a", "n":
+		return m, func() tea.Msg {
+			return CommentChosenMsg{Index: -1}
+		}
+	case "d", "x":
+		m.confirm = m.choice < len(m.comments)
+	case "up", "k", "ctrl+p":
+		m.choice = max(m.choice-1, 0)
+	case "down", "j", "ctrl+n":
+		m.choice = min(m.choice+1, len(m.comments))
+	}
+	return m, nil
+}
+
+// SetSize sets the available screen size
+func (m *CommentChooser) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *CommentChooser) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*75/100, 40)
+	contentWidth := windowWidth - 4
+
+	lines := []string{theme.DimmedStyle.Render("@" + m.location), ""}
+	items := make([]string, 0, len(m.comments)+1)
+	for i, c := range m.comments {
+		first, _, more := strings.Cut(c, "\n")
+		if more {
+			first += " …"
+		}
+		items = append(items, fmt.Sprintf("%d. %s", i+1, first))
+	}
+	items = append(items, "+ New comment")
+	for i, item := range items {
+		item = ansi.Truncate(item, contentWidth-2, "…")
+		if i == m.choice {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+item))
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+item))
+		}
+	}
+
+	help := "enter edit  a add  d delete  esc cancel"
+	if m.confirm {
+		help = "press d again to delete this comment"
+	}
+	lines = append(lines, "", theme.HelpDescStyle.Render(help))
+
+	windowHeight := len(lines) + 2
+	title := fmt.Sprintf("%d comments", len(m.comments))
+	if len(m.comments) == 1 {
+		title = "1 comment"
+	}
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), title, windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
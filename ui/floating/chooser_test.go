@@ -0,0 +1,68 @@
+package floating
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestCommentChooser(t *testing.T) {
+	m := NewCommentChooser("main.go:3", []string{"nit: rename\nto count", "issue: off by one"})
+	m.SetSize(80, 24)
+
+	view := m.View()
+	for _, want := range []string{"2 comments", "@main.go:3", "1. nit: rename …", "2. issue: off by one", "> + New comment"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Enter starts on "new comment"
+	_, cmd := m.Update(key("enter"))
+	if msg, ok := cmd().(CommentChosenMsg); !ok || msg.Index != -1 {
+		t.Errorf("expected a new comment, got %#v", cmd())
+	}
+
+	m.Update(key("k"))
+	m.Update(key("k"))
+	m.Update(key("k"))
+	_, cmd = m.Update(key("enter"))
+	if msg, ok := cmd().(CommentChosenMsg); !ok || msg.Index != 0 {
+		t.Errorf("expected the first comment, got %#v", cmd())
+	}
+
+	// Deleting asks first
+	m.Update(key("j"))
+	if _, cmd = m.Update(key("d")); cmd != nil {
+		t.Fatal("first d should only ask")
+	}
+	if !strings.Contains(m.View(), "press d again") {
+		t.Error("expected a delete confirmation")
+	}
+	_, cmd = m.Update(key("d"))
+	if msg, ok := cmd().(CommentDeletedMsg); !ok || msg.Index != 1 {
+		t.Errorf("expected the second comment deleted, got %#v", cmd())
+	}
+
+	// Any other key keeps it
+	m.Update(key("d"))
+	if _, cmd = m.Update(key("j")); cmd != nil {
+		t.Error("j after d should cancel the delete")
+	}
+
+	_, cmd = m.Update(key("esc"))
+	if _, ok := cmd().(FeedbackCancelledMsg); !ok {
+		t.Error("esc should cancel")
+	}
+}
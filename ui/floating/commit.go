@@ -0,0 +1,105 @@
+package floating
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// CommitConfirmedMsg is sent when the user confirms the commit message
+type CommitConfirmedMsg struct {
+	Message string
+}
+
+// CommitCancelledMsg is sent when the commit modal is dismissed
+type CommitCancelledMsg struct{}
+
+// CommitModal edits the message for committing the reviewed changes
+type CommitModal struct {
+	textarea textarea.Model
+	title    string
+	width    int
+	height   int
+	ready    bool
+}
+
+// NewCommitModal creates a commit message editor titled title and
+// pre-filled with message
+func NewCommitModal(title, message string) *CommitModal {
+	ta := textarea.New()
+	ta.Placeholder = "Commit message..."
+	ta.CharLimit = 0 // No limit
+	ta.ShowLineNumbers = false
+	ta.SetValue(message)
+	ta.Focus()
+
+	return &CommitModal{textarea: ta, title: title}
+}
+
+func (m *CommitModal) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m *CommitModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "enter":
+			message := strings.TrimSpace(m.textarea.Value())
+			if message == "" {
+				return m, nil
+			}
+			return m, func() tea.Msg {
+				return CommitConfirmedMsg{Message: message}
+			}
+		case "ctrl+j":
+			m.textarea.InsertString("\n")
+			return m, nil
+		case "esc":
+			return m, func() tea.Msg {
+				return CommitCancelledMsg{}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+// SetSize sets the available screen size
+func (m *CommitModal) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+// Value returns the current message
+func (m *CommitModal) Value() string {
+	return m.textarea.Value()
+}
+
+func (m *CommitModal) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*75/100, 40)
+	windowHeight := max(m.height*75/100, 10)
+	contentWidth := windowWidth - 4
+	contentHeight := windowHeight - 4
+
+	m.textarea.SetWidth(contentWidth)
+	m.textarea.SetHeight(contentHeight - 2)
+
+	lines := []string{
+		m.textarea.View(),
+		"",
+		theme.HelpDescStyle.Render("enter commit  C-j newline  esc cancel"),
+	}
+
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), m.title, windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
@@ -0,0 +1,40 @@
+package floating
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCommitModal_ConfirmAndCancel(t *testing.T) {
+	m := NewCommitModal("Commit", "Reviewed 2 files, no comments")
+	m.SetSize(80, 24)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected confirm command")
+	}
+	msg, ok := cmd().(CommitConfirmedMsg)
+	if !ok {
+		t.Fatal("expected CommitConfirmedMsg on enter")
+	}
+	if msg.Message != "Reviewed 2 files, no comments" {
+		t.Errorf("expected pre-filled message, got %q", msg.Message)
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("expected cancel command")
+	}
+	if _, ok := cmd().(CommitCancelledMsg); !ok {
+		t.Error("expected CommitCancelledMsg on esc")
+	}
+}
+
+func TestCommitModal_EmptyMessageIgnored(t *testing.T) {
+	m := NewCommitModal("Commit", "  \n")
+
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil {
+		t.Error("expected enter with an empty message to do nothing")
+	}
+}
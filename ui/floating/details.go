@@ -0,0 +1,111 @@
+package floating
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// DetailsClosedMsg is sent when the details popup is dismissed
+type DetailsClosedMsg struct{}
+
+// DetailsModal shows read-only text about a file, scrolled with the
+// movement keys
+type DetailsModal struct {
+	title  string
+	lines  []string
+	offset int
+	width  int
+	height int
+	ready  bool
+}
+
+// NewDetailsModal creates a popup titled title showing text
+func NewDetailsModal(title, text string) *DetailsModal {
+	return &DetailsModal{title: title, lines: strings.Split(text, "\n")}
+}
+
+func (m *DetailsModal) Init() tea.Cmd {
+	return nil
+}
+
+func (m *DetailsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q", "enter":
+		return m, func() tea.Msg {
+			return DetailsClosedMsg{}
+		}
+	case "up", "k", "ctrl+p":
+		m.scroll(-1)
+	case "down", "j", "ctrl+n":
+		m.scroll(1)
+	case "pgup", "alt+v", "ctrl+b":
+		m.scroll(-m.pageSize())
+	case "pgdown", "ctrl+v", "ctrl+f", " ":
+		m.scroll(m.pageSize())
+	case "g", "home":
+		m.offset = 0
+	case "G", "end":
+		m.scroll(len(m.lines))
+	}
+	return m, nil
+}
+
+// scroll moves the view by delta lines, staying within the text
+func (m *DetailsModal) scroll(delta int) {
+	m.offset = max(0, min(m.offset+delta, len(m.lines)-m.pageSize()))
+}
+
+// SetSize sets the available screen size
+func (m *DetailsModal) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+	m.scroll(0)
+}
+
+// windowSize returns the popup's outer width and height
+func (m *DetailsModal) windowSize() (int, int) {
+	return max(m.width*75/100, 40), max(m.height*75/100, 10)
+}
+
+// pageSize is how many text lines fit in the popup
+func (m *DetailsModal) pageSize() int {
+	_, windowHeight := m.windowSize()
+	return max(windowHeight-6, 1)
+}
+
+func (m *DetailsModal) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth, windowHeight := m.windowSize()
+	contentWidth := windowWidth - 4
+
+	end := min(m.offset+m.pageSize(), len(m.lines))
+	var lines []string
+	for _, line := range m.lines[m.offset:end] {
+		lines = append(lines, ansi.Truncate(line, contentWidth, "…"))
+	}
+	for len(lines) < m.pageSize() {
+		lines = append(lines, "")
+	}
+
+	help := "esc close"
+	if len(m.lines) > m.pageSize() {
+		help = "j/k scroll  " + help
+	}
+	lines = append(lines, "", theme.HelpDescStyle.Render(help))
+
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), m.title, windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
@@ -0,0 +1,57 @@
+package floating
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDetailsModal_ScrollAndClose(t *testing.T) {
+	var text []string
+	for i := 0; i < 50; i++ {
+		text = append(text, fmt.Sprintf("line %02d", i))
+	}
+	m := NewDetailsModal("API: lib.go", strings.Join(text, "\n"))
+	m.SetSize(80, 24)
+
+	if view := m.View(); !strings.Contains(view, "line 00") || !strings.Contains(view, "API: lib.go") {
+		t.Fatalf("expected the first lines and the title:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if view := m.View(); strings.Contains(view, "line 00") || !strings.Contains(view, "line 01") {
+		t.Errorf("j should scroll down one line:\n%s", view)
+	}
+
+	// Scrolling stops with the last line at the bottom
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if view := m.View(); !strings.Contains(view, "line 49") {
+		t.Errorf("G should show the last line:\n%s", view)
+	}
+	if m.offset != len(text)-m.pageSize() {
+		t.Errorf("offset %d past the end", m.offset)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("expected close command")
+	}
+	if _, ok := cmd().(DetailsClosedMsg); !ok {
+		t.Error("expected DetailsClosedMsg")
+	}
+}
+
+func TestDetailsModal_ShortText(t *testing.T) {
+	m := NewDetailsModal("API", "No exported API changes")
+	m.SetSize(80, 24)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if m.offset != 0 {
+		t.Errorf("short text should not scroll, offset %d", m.offset)
+	}
+	if strings.Contains(m.View(), "scroll") {
+		t.Error("no scroll hint for text that fits")
+	}
+}
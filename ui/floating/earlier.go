@@ -0,0 +1,120 @@
+package floating
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// EarlierComment is one of an earlier review's comments as listed for a
+// re-review
+type EarlierComment struct {
+	Location string // path:line
+	Comment  string
+	Status   string // Such as "possibly addressed", "open" or "resolved"
+}
+
+// EarlierChosenMsg is sent when an earlier comment is picked, to go to it.
+// Index is its position in the list.
+type EarlierChosenMsg struct {
+	Index int
+}
+
+// EarlierList lists an earlier review's comments during a re-review, to
+// go to each one and verify it
+type EarlierList struct {
+	comments []EarlierComment
+	choice   int
+	offset   int // First comment shown when the list doesn't fit
+	width    int
+	height   int
+	ready    bool
+}
+
+// NewEarlierList creates a list over comments, in the order given
+func NewEarlierList(comments []EarlierComment) *EarlierList {
+	return &EarlierList{comments: comments}
+}
+
+func (m *EarlierList) Init() tea.Cmd {
+	return nil
+}
+
+func (m *EarlierList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return FeedbackCancelledMsg{}
+		}
+	case "enter":
+		if len(m.comments) == 0 {
+			return m, nil
+		}
+		index := m.choice
+		return m, func() tea.Msg {
+			return EarlierChosenMsg{Index: index}
+		}
+	case "up", "k", "ctrl+p":
+		m.choice = max(m.choice-1, 0)
+	case "down", "j", "ctrl+n":
+		m.choice = max(min(m.choice+1, len(m.comments)-1), 0)
+	}
+	return m, nil
+}
+
+// SetSize sets the available screen size
+func (m *EarlierList) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *EarlierList) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*75/100, 40)
+	contentWidth := windowWidth - 4
+
+	// Keep the choice in view: border, blank line and help take 4 rows
+	rows := max(m.height*75/100-4, 1)
+	if m.choice < m.offset {
+		m.offset = m.choice
+	} else if m.choice >= m.offset+rows {
+		m.offset = m.choice - rows + 1
+	}
+
+	var lines []string
+	if len(m.comments) == 0 {
+		lines = append(lines, theme.DimmedStyle.Render("The earlier review has no comments"))
+	}
+	for i := m.offset; i < len(m.comments) && i < m.offset+rows; i++ {
+		c := m.comments[i]
+		first, _, more := strings.Cut(strings.TrimSpace(c.Comment), "\n")
+		if more {
+			first += " …"
+		}
+		item := ansi.Truncate(fmt.Sprintf("[%s] %s %s", c.Status, c.Location, first), contentWidth-2, "…")
+		if i == m.choice {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+item))
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+item))
+		}
+	}
+	lines = append(lines, "", theme.HelpDescStyle.Render("enter go to comment  esc close"))
+
+	windowHeight := len(lines) + 2
+	title := fmt.Sprintf("Earlier comments (%d)", len(m.comments))
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), title, windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
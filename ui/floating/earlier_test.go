@@ -0,0 +1,44 @@
+package floating
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEarlierList(t *testing.T) {
+	m := NewEarlierList([]EarlierComment{
+		{Location: "a.go:3", Comment: "issue: off by one\nsee the loop", Status: "possibly addressed"},
+		{Location: "b.go", Comment: "Needs a test", Status: "open"},
+	})
+	m.SetSize(80, 24)
+
+	view := m.View()
+	for _, want := range []string{"Earlier comments (2)", "> [possibly addressed] a.go:3 issue: off by one …", "[open] b.go Needs a test"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	m.Update(key("j"))
+	m.Update(key("j"))
+	_, cmd := m.Update(key("enter"))
+	if msg, ok := cmd().(EarlierChosenMsg); !ok || msg.Index != 1 {
+		t.Errorf("expected the second comment, got %#v", cmd())
+	}
+
+	_, cmd = m.Update(key("esc"))
+	if _, ok := cmd().(FeedbackCancelledMsg); !ok {
+		t.Errorf("expected esc to close, got %#v", cmd())
+	}
+}
+
+func TestEarlierListEmpty(t *testing.T) {
+	m := NewEarlierList(nil)
+	m.SetSize(80, 24)
+	if !strings.Contains(m.View(), "no comments") {
+		t.Errorf("expected an empty message:\n%s", m.View())
+	}
+	if _, cmd := m.Update(key("enter")); cmd != nil {
+		t.Error("enter on an empty list should do nothing")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/output"
 	"github.com/gerunddev/tcr/ui/borders"
 	"github.com/gerunddev/tcr/ui/theme"
 )
@@ -29,6 +31,13 @@ type FeedbackModal struct {
 	filePath    string
 	lineNumber  int
 	lineContent string
+	context     string   // Replaces the @path:line header, as for a comment on many lines
+	templates   []string // Prompts configured for this file type
+	editing     bool     // Revising an existing comment
+	lineBudget  int      // Soft limit on comment lines, 0 for none
+	labelStyle  string   // comment_labels: off, conventional or emoji
+	label       int      // Index in output.Labels, or -1 for none
+	decorations string   // Kept from an edited comment, e.g. "blocking"
 	width       int
 	height      int
 	ready       bool
@@ -47,9 +56,118 @@ func NewFeedbackModal(filePath string, lineNumber int, lineContent string) *Feed
 		filePath:    filePath,
 		lineNumber:  lineNumber,
 		lineContent: lineContent,
+		label:       -1,
 	}
 }
 
+// SetTemplates lists prompts for the file being commented on; alt+N
+// inserts the Nth into the comment
+func (m *FeedbackModal) SetTemplates(templates []string) {
+	m.templates = templates
+}
+
+// SetContext describes where the comment goes in place of the file and
+// line, as when it's saved on many lines at once
+func (m *FeedbackModal) SetContext(context string) {
+	m.context = context
+	m.lineContent = ""
+}
+
+// SetComment fills in an existing comment to revise. With labels on, a
+// leading label is taken off the text and selected instead.
+func (m *FeedbackModal) SetComment(comment string) {
+	m.SetDraft(comment)
+	m.editing = true
+}
+
+// SetDraft starts a new comment from text, such as the last one written,
+// its label selected as SetComment does
+func (m *FeedbackModal) SetDraft(comment string) {
+	if m.labelsOn() {
+		label, decorations, body := output.SplitLabel(comment)
+		if label != "" {
+			m.label = labelIndex(label)
+			m.decorations = decorations
+			comment = body
+		}
+	}
+	m.textarea.SetValue(comment)
+}
+
+// SetLabelStyle turns on choosing a Conventional Comments label with tab,
+// written as a word ("conventional") or with its emoji ("emoji")
+func (m *FeedbackModal) SetLabelStyle(style string) {
+	m.labelStyle = style
+}
+
+// labelsOn reports whether labels can be chosen
+func (m *FeedbackModal) labelsOn() bool {
+	return m.labelStyle == "conventional" || m.labelStyle == "emoji"
+}
+
+// labelIndex returns a label's position in output.Labels, or -1
+func labelIndex(name string) int {
+	for i, l := range output.Labels {
+		if l.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// cycleLabel moves through the labels and "no label" by delta
+func (m *FeedbackModal) cycleLabel(delta int) {
+	n := len(output.Labels) + 1 // Position 0 is "no label"
+	m.label = (m.label+1+delta+n)%n - 1
+}
+
+// comment returns the text to save, with the chosen label
+func (m *FeedbackModal) comment() string {
+	comment := strings.TrimSpace(m.textarea.Value())
+	if comment == "" || m.label < 0 {
+		return comment
+	}
+	return output.WithLabel(comment, output.Labels[m.label].Name, m.decorations, m.labelStyle == "emoji")
+}
+
+// SetLineBudget sets the comment length, in lines, past which the modal
+// suggests trimming; 0 turns the hint off
+func (m *FeedbackModal) SetLineBudget(lines int) {
+	m.lineBudget = lines
+}
+
+// budgetHint returns the line count against the budget, or "" if there's
+// no budget or nothing written yet
+func (m *FeedbackModal) budgetHint() string {
+	value := strings.TrimSpace(m.textarea.Value())
+	if m.lineBudget == 0 || value == "" {
+		return ""
+	}
+	n := strings.Count(value, "\n") + 1
+	if n <= m.lineBudget {
+		return theme.DimmedStyle.Render(fmt.Sprintf("%d/%d lines", n, m.lineBudget))
+	}
+	return theme.BudgetStyle.Render(fmt.Sprintf("%d/%d lines, consider trimming or splitting", n, m.lineBudget))
+}
+
+// insertTemplate adds a prompt on its own line, returning false if there's
+// no template key for it
+func (m *FeedbackModal) insertTemplate(key string) bool {
+	digit, ok := strings.CutPrefix(key, "alt+")
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(digit)
+	if err != nil || n < 1 || n > len(m.templates) {
+		return false
+	}
+	if value := m.textarea.Value(); value != "" && !strings.HasSuffix(value, "\n") {
+		m.textarea.InsertString("\n")
+	}
+	m.textarea.InsertString(m.templates[n-1])
+	return true
+}
+
 func (m *FeedbackModal) Init() tea.Cmd {
 	return textarea.Blink
 }
@@ -60,7 +178,7 @@ func (m *FeedbackModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "enter":
 			// Enter saves feedback
-			comment := strings.TrimSpace(m.textarea.Value())
+			comment := m.comment()
 			if comment != "" {
 				return m, func() tea.Msg {
 					return FeedbackSavedMsg{
@@ -78,12 +196,24 @@ func (m *FeedbackModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Ctrl+J inserts newline
 			m.textarea.InsertString("\n")
 			return m, nil
+		case "tab", "shift+tab":
+			if m.labelsOn() {
+				if msg.String() == "tab" {
+					m.cycleLabel(1)
+				} else {
+					m.cycleLabel(-1)
+				}
+				return m, nil
+			}
 		case "esc":
 			// Escape cancels
 			return m, func() tea.Msg {
 				return FeedbackCancelledMsg{}
 			}
 		}
+		if m.insertTemplate(msg.String()) {
+			return m, nil
+		}
 	}
 
 	var cmd tea.Cmd
@@ -117,7 +247,9 @@ func (m *FeedbackModal) View() string {
 
 	// Show context: file path and line number
 	var context string
-	if m.lineNumber > 0 {
+	if m.context != "" {
+		context = theme.DimmedStyle.Render(m.context)
+	} else if m.lineNumber > 0 {
 		context = theme.DimmedStyle.Render(fmt.Sprintf("@%s:%d", m.filePath, m.lineNumber))
 	} else {
 		context = theme.DimmedStyle.Render(fmt.Sprintf("@%s", m.filePath))
@@ -135,6 +267,26 @@ func (m *FeedbackModal) View() string {
 		lines = append(lines, "")
 	}
 
+	// Prompts configured for this file type, up to alt+9
+	for i, prompt := range m.templates {
+		if i == 9 {
+			break
+		}
+		line := fmt.Sprintf("alt+%d %s", i+1, prompt)
+		if lipgloss.Width(line) > contentWidth {
+			line = ansi.Truncate(line, contentWidth-3, "...")
+		}
+		lines = append(lines, theme.DimmedStyle.Render(line))
+	}
+	if len(m.templates) > 0 {
+		lines = append(lines, "")
+	}
+
+	// Labels, with the chosen one highlighted
+	if m.labelsOn() {
+		lines = append(lines, m.labelRow(contentWidth), "")
+	}
+
 	// Textarea
 	m.textarea.SetWidth(contentWidth)
 	m.textarea.SetHeight(contentHeight - len(lines) - 3)
@@ -142,12 +294,20 @@ func (m *FeedbackModal) View() string {
 
 	// Help text at bottom
 	lines = append(lines, "")
-	lines = append(lines, theme.HelpDescStyle.Render("enter save  C-j newline  esc cancel"))
+	help := theme.HelpDescStyle.Render("enter save  C-j newline  esc cancel")
+	if hint := m.budgetHint(); hint != "" {
+		help += "  " + hint
+	}
+	lines = append(lines, help)
 
 	content := strings.Join(lines, "\n")
 
 	// Render floating window
-	windowContent := borders.RenderFloatingBorder(content, "Feedback", windowWidth, windowHeight)
+	title := "Feedback"
+	if m.editing {
+		title = "Edit feedback"
+	}
+	windowContent := borders.RenderFloatingBorder(content, title, windowWidth, windowHeight)
 
 	// Center the window
 	x := (m.width - windowWidth) / 2
@@ -163,6 +323,36 @@ func (m *FeedbackModal) View() string {
 	return paddingTop + strings.Join(windowLines, "\n")
 }
 
+// labelRow lists the labels tab cycles through, highlighting the chosen one
+func (m *FeedbackModal) labelRow(width int) string {
+	parts := []string{theme.DimmedStyle.Render("tab label:")}
+	for i, l := range output.Labels {
+		name := l.Name
+		if m.labelStyle == "emoji" {
+			name = l.Emoji + " " + name
+		}
+		if i == m.label {
+			parts = append(parts, theme.SelectedItemStyle.Render("["+name+"]"))
+		} else {
+			parts = append(parts, theme.DimmedStyle.Render(name))
+		}
+	}
+	row := strings.Join(parts, " ")
+	if ansi.StringWidth(row) <= width {
+		return row
+	}
+
+	// Too narrow for every label: show just the chosen one
+	chosen := "none"
+	if m.label >= 0 {
+		chosen = output.Labels[m.label].Name
+		if m.labelStyle == "emoji" {
+			chosen = output.Labels[m.label].Emoji + " " + chosen
+		}
+	}
+	return ansi.Truncate(parts[0]+" "+theme.SelectedItemStyle.Render("["+chosen+"]"), width, "…")
+}
+
 // SetSize sets the available screen size
 func (m *FeedbackModal) SetSize(width, height int) {
 	m.width = width
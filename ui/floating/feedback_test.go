@@ -1,6 +1,11 @@
 package floating
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
 
 func TestCalculateLineNumber(t *testing.T) {
 	tests := []struct {
@@ -57,3 +62,104 @@ func TestCalculateLineNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestFeedbackModal_Templates(t *testing.T) {
+	m := NewFeedbackModal("db/001.sql", 3, "ALTER TABLE users DROP COLUMN age;")
+	m.SetTemplates([]string{"Is this backwards compatible?", "Does it need an index?"})
+	m.SetSize(100, 30)
+
+	if view := m.View(); !strings.Contains(view, "alt+2 Does it need an index?") {
+		t.Errorf("expected the prompts to be listed:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("why?")})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1"), Alt: true})
+	if got := m.Value(); got != "why?\nIs this backwards compatible?" {
+		t.Errorf("alt+1 should insert the first prompt on its own line, got %q", got)
+	}
+
+	// Without a prompt for it, alt+3 does nothing
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3"), Alt: true})
+	if strings.Count(m.Value(), "\n") != 1 {
+		t.Errorf("unexpected value %q", m.Value())
+	}
+}
+
+func TestFeedbackModal_LineBudget(t *testing.T) {
+	m := NewFeedbackModal("main.go", 3, "")
+	m.SetSize(100, 40)
+	m.SetLineBudget(2)
+
+	if strings.Contains(m.View(), "lines") {
+		t.Error("no budget hint before anything is written")
+	}
+	m.textarea.SetValue("one\ntwo")
+	if view := m.View(); !strings.Contains(view, "2/2 lines") || strings.Contains(view, "consider") {
+		t.Errorf("expected the line count within budget:\n%s", view)
+	}
+	m.textarea.SetValue("one\ntwo\nthree")
+	if !strings.Contains(m.View(), "3/2 lines, consider trimming") {
+		t.Errorf("expected an over-budget hint:\n%s", m.View())
+	}
+
+	m.SetLineBudget(0)
+	if strings.Contains(m.View(), "3/2") {
+		t.Error("a zero budget turns the hint off")
+	}
+}
+
+func TestFeedbackModal_Labels(t *testing.T) {
+	m := NewFeedbackModal("main.go", 3, "")
+	m.SetSize(160, 40)
+	m.SetLabelStyle("conventional")
+	m.textarea.SetValue("use a map")
+
+	if !strings.Contains(m.View(), "tab label:") {
+		t.Fatalf("expected the label row:\n%s", m.View())
+	}
+	if got := m.comment(); got != "use a map" {
+		t.Errorf("no label chosen yet, got %q", got)
+	}
+
+	// tab moves to the first label, shift+tab back past "no label" to the last
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got := m.comment(); got != "praise: use a map" {
+		t.Errorf("got %q", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if got := m.comment(); got != "note: use a map" {
+		t.Errorf("got %q", got)
+	}
+
+	// Editing takes the label off the text, keeping its decorations
+	m = NewFeedbackModal("main.go", 3, "")
+	m.SetLabelStyle("emoji")
+	m.SetComment("issue (blocking): leaks")
+	if m.Value() != "leaks" {
+		t.Errorf("expected the label split off, got %q", m.Value())
+	}
+	if got := m.comment(); got != "🐛 issue (blocking): leaks" {
+		t.Errorf("got %q", got)
+	}
+
+	// A draft is a new comment, not an edit
+	m = NewFeedbackModal("main.go", 3, "")
+	m.SetSize(160, 40)
+	m.SetLabelStyle("conventional")
+	m.SetDraft("nitpick: drop the blank line")
+	if m.Value() != "drop the blank line" || m.comment() != "nitpick: drop the blank line" {
+		t.Errorf("expected the draft's label selected, got %q", m.comment())
+	}
+	if strings.Contains(m.View(), "Edit feedback") {
+		t.Error("expected a draft to open as new feedback")
+	}
+
+	// With labels off, tab is left to the textarea and text is kept as is
+	m = NewFeedbackModal("main.go", 3, "")
+	m.SetSize(160, 40)
+	m.SetComment("issue: leaks")
+	if m.Value() != "issue: leaks" || strings.Contains(m.View(), "tab label:") {
+		t.Error("labels should be off by default")
+	}
+}
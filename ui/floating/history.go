@@ -0,0 +1,126 @@
+package floating
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// Revision is a commit or change listed in the history
+type Revision struct {
+	ID      string // Empty for the working copy's changes, the usual review
+	Author  string
+	Date    string
+	Subject string
+}
+
+// RevisionChosenMsg is sent when a revision is picked, to review it.
+// Index is its position in the list.
+type RevisionChosenMsg struct {
+	Index int
+}
+
+// HistoryList lists recent revisions, to review one instead of the
+// working copy
+type HistoryList struct {
+	revisions []Revision
+	current   int // The revision being reviewed
+	choice    int
+	offset    int // First revision shown when the list doesn't fit
+	width     int
+	height    int
+	ready     bool
+}
+
+// NewHistoryList creates a list over revisions, newest first, marking and
+// starting on the one at current
+func NewHistoryList(revisions []Revision, current int) *HistoryList {
+	return &HistoryList{revisions: revisions, current: current, choice: max(current, 0)}
+}
+
+func (m *HistoryList) Init() tea.Cmd {
+	return nil
+}
+
+func (m *HistoryList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return FeedbackCancelledMsg{}
+		}
+	case "enter":
+		if len(m.revisions) == 0 {
+			return m, nil
+		}
+		index := m.choice
+		return m, func() tea.Msg {
+			return RevisionChosenMsg{Index: index}
+		}
+	case "up", "k", "ctrl+p":
+		m.choice = max(m.choice-1, 0)
+	case "down", "j", "ctrl+n":
+		m.choice = max(min(m.choice+1, len(m.revisions)-1), 0)
+	case "g", "home":
+		m.choice = 0
+	case "G", "end":
+		m.choice = max(len(m.revisions)-1, 0)
+	}
+	return m, nil
+}
+
+// SetSize sets the available screen size
+func (m *HistoryList) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *HistoryList) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*75/100, 40)
+	contentWidth := windowWidth - 4
+
+	// Keep the choice in view: border, blank line and help take 4 rows
+	rows := max(m.height*75/100-4, 1)
+	if m.choice < m.offset {
+		m.offset = m.choice
+	} else if m.choice >= m.offset+rows {
+		m.offset = m.choice - rows + 1
+	}
+
+	var lines []string
+	for i := m.offset; i < len(m.revisions) && i < m.offset+rows; i++ {
+		r := m.revisions[i]
+		item := "Working copy"
+		if r.ID != "" {
+			item = fmt.Sprintf("%s  %s  %s  %s", r.ID, r.Date, r.Author, r.Subject)
+		}
+		if i == m.current {
+			item += theme.DimmedStyle.Render("  (reviewing)")
+		}
+		item = ansi.Truncate(item, contentWidth-2, "…")
+		if i == m.choice {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+item))
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+item))
+		}
+	}
+	lines = append(lines, "", theme.HelpDescStyle.Render("enter review  esc close"))
+
+	windowHeight := len(lines) + 2
+	title := "History"
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), title, windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
@@ -0,0 +1,40 @@
+package floating
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHistoryList(t *testing.T) {
+	m := NewHistoryList([]Revision{
+		{},
+		{ID: "abc1234", Author: "Ann", Date: "2024-10-01", Subject: "Fix the parser"},
+		{ID: "def5678", Author: "Bob", Date: "2024-09-30", Subject: "Add the parser"},
+	}, 1)
+	m.SetSize(100, 24)
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"History", "  Working copy", "> abc1234  2024-10-01  Ann  Fix the parser  (reviewing)", "  def5678"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	m.Update(key("j"))
+	_, cmd := m.Update(key("enter"))
+	if msg, ok := cmd().(RevisionChosenMsg); !ok || msg.Index != 2 {
+		t.Errorf("expected the oldest revision, got %#v", cmd())
+	}
+	m.Update(key("g"))
+	_, cmd = m.Update(key("enter"))
+	if msg, ok := cmd().(RevisionChosenMsg); !ok || msg.Index != 0 {
+		t.Errorf("expected the working copy, got %#v", cmd())
+	}
+
+	_, cmd = m.Update(key("esc"))
+	if _, ok := cmd().(FeedbackCancelledMsg); !ok {
+		t.Errorf("expected esc to close, got %#v", cmd())
+	}
+}
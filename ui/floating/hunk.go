@@ -0,0 +1,105 @@
+package floating
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// HunkChosenMsg is sent when an action is picked from the hunk menu. Index
+// is its position in the menu.
+type HunkChosenMsg struct {
+	Index int
+}
+
+// HunkMenu lists what can be done with the hunk under the cursor. Actions
+// are picked with the arrows and enter, or by their number.
+type HunkMenu struct {
+	header  string // The hunk's "@@ ... @@" line
+	actions []string
+	choice  int
+	width   int
+	height  int
+	ready   bool
+}
+
+// NewHunkMenu creates a menu of actions on the hunk with the given header
+func NewHunkMenu(header string, actions []string) *HunkMenu {
+	return &HunkMenu{header: header, actions: actions}
+}
+
+func (m *HunkMenu) Init() tea.Cmd {
+	return nil
+}
+
+func (m *HunkMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	key := keyMsg.String()
+	if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+		if i := int(key[0] - '1'); i < len(m.actions) {
+			return m, func() tea.Msg {
+				return HunkChosenMsg{Index: i}
+			}
+		}
+		return m, nil
+	}
+
+	switch key {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return FeedbackCancelledMsg{}
+		}
+	case "enter":
+		if len(m.actions) == 0 {
+			return m, nil
+		}
+		index := m.choice
+		return m, func() tea.Msg {
+			return HunkChosenMsg{Index: index}
+		}
+	case "up", "k", "ctrl+p":
+		m.choice = max(m.choice-1, 0)
+	case "down", "j", "ctrl+n":
+		m.choice = max(min(m.choice+1, len(m.actions)-1), 0)
+	}
+	return m, nil
+}
+
+// SetSize sets the available screen size
+func (m *HunkMenu) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *HunkMenu) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*50/100, 40)
+	contentWidth := windowWidth - 4
+
+	lines := []string{theme.DimmedStyle.Render(ansi.Truncate(m.header, contentWidth, "…")), ""}
+	for i, action := range m.actions {
+		item := ansi.Truncate(fmt.Sprintf("%d. %s", i+1, action), contentWidth-2, "…")
+		if i == m.choice {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+item))
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+item))
+		}
+	}
+	lines = append(lines, "", theme.HelpDescStyle.Render("enter or 1-9 run  esc close"))
+
+	windowHeight := len(lines) + 2
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), "Hunk", windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
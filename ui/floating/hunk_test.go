@@ -0,0 +1,39 @@
+package floating
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHunkMenu(t *testing.T) {
+	m := NewHunkMenu("@@ -10,2 +10,3 @@", []string{"Comment on the hunk", "Copy the hunk", "Fold the hunk"})
+	m.SetSize(100, 24)
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Hunk", "@@ -10,2 +10,3 @@", "> 1. Comment on the hunk", "  3. Fold the hunk", "esc close"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	m.Update(key("down"))
+	_, cmd := m.Update(key("enter"))
+	if msg, ok := cmd().(HunkChosenMsg); !ok || msg.Index != 1 {
+		t.Errorf("expected the copy action, got %#v", cmd())
+	}
+
+	_, cmd = m.Update(key("3"))
+	if msg, ok := cmd().(HunkChosenMsg); !ok || msg.Index != 2 {
+		t.Errorf("expected 3 to pick the fold action, got %#v", cmd())
+	}
+	if _, cmd := m.Update(key("4")); cmd != nil {
+		t.Error("expected a number past the actions to do nothing")
+	}
+
+	_, cmd = m.Update(key("esc"))
+	if _, ok := cmd().(FeedbackCancelledMsg); !ok {
+		t.Errorf("expected esc to close, got %#v", cmd())
+	}
+}
@@ -0,0 +1,147 @@
+package floating
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// NoBaseChoice is what to do about a base that doesn't resolve
+type NoBaseChoice int
+
+const (
+	// ReviewWorkingCopy reviews the working copy alone, which needs no base
+	ReviewWorkingCopy NoBaseChoice = iota
+	// PickBase picks another base
+	PickBase
+)
+
+// NoBaseChosenMsg is sent when a way past the missing base is picked
+type NoBaseChosenMsg struct {
+	Choice NoBaseChoice
+}
+
+// NoBaseScreen takes the whole screen when the review's base revision
+// doesn't resolve, explaining why and how to fix it, with the ways to go
+// on without it
+type NoBaseScreen struct {
+	base    string
+	shallow bool
+	reason  string
+	hints   []string
+	failed  string
+	width   int
+	height  int
+	ready   bool
+}
+
+// NewNoBaseScreen creates the screen for base, which failed to resolve
+// for reason, with hints on what would make it resolve
+func NewNoBaseScreen(base string, shallow bool, reason string, hints []string) *NoBaseScreen {
+	return &NoBaseScreen{base: base, shallow: shallow, reason: reason, hints: hints}
+}
+
+func (m *NoBaseScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (m *NoBaseScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "w", "enter":
+		return m, func() tea.Msg {
+			return NoBaseChosenMsg{Choice: ReviewWorkingCopy}
+		}
+	case "b":
+		return m, func() tea.Msg {
+			return NoBaseChosenMsg{Choice: PickBase}
+		}
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// SetFailed reports another base picked that didn't work either
+func (m *NoBaseScreen) SetFailed(text string) {
+	m.failed = text
+}
+
+// SetSize sets the available screen size
+func (m *NoBaseScreen) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *NoBaseScreen) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := min(max(m.width*75/100, 40), m.width)
+	contentWidth := windowWidth - 4
+
+	var lines []string
+	add := func(text string) {
+		lines = append(lines, wrapWords(text, contentWidth)...)
+	}
+	if m.shallow {
+		add("The base " + m.base + " isn't in this shallow clone.")
+	} else {
+		add("The base " + m.base + " doesn't resolve.")
+	}
+	lines = append(lines, "")
+	add(theme.HelpDescStyle.Render(m.reason))
+	if len(m.hints) > 0 {
+		lines = append(lines, "", "To fix it:")
+		for _, hint := range m.hints {
+			add("• " + hint)
+		}
+	}
+	if m.failed != "" {
+		lines = append(lines, "")
+		add(theme.ConflictStyle.Render(m.failed))
+	}
+	lines = append(lines, "",
+		theme.HelpKeyStyle.Render("w")+" "+theme.HelpDescStyle.Render("review the working copy alone"),
+		theme.HelpKeyStyle.Render("b")+" "+theme.HelpDescStyle.Render("pick another base"),
+		theme.HelpKeyStyle.Render("q")+" "+theme.HelpDescStyle.Render("quit"))
+
+	windowHeight := max(min(len(lines)+2, m.height), 3)
+	lines = lines[:min(len(lines), windowHeight-2)]
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), "No base", windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
+
+// wrapWords breaks text into lines of at most width cells at its spaces
+// alone, keeping commands like "git fetch --unshallow" whole, and cuts
+// words longer than a line
+func wrapWords(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case ansi.StringWidth(line)+1+ansi.StringWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+		if ansi.StringWidth(line) > width {
+			cut := strings.Split(ansi.Hardwrap(line, width, true), "\n")
+			lines = append(lines, cut[:len(cut)-1]...)
+			line = cut[len(cut)-1]
+		}
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,54 @@
+package floating
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNoBaseScreen(t *testing.T) {
+	m := NewNoBaseScreen("origin/main", true, "bad revision 'origin/main'", []string{"git fetch --unshallow"})
+	m.SetSize(100, 30)
+
+	view := m.View()
+	for _, want := range []string{"isn't in this shallow clone", "bad revision", "git fetch --unshallow", "review the working copy alone", "pick another base"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the view:\n%s", want, view)
+		}
+	}
+
+	for key, want := range map[string]NoBaseChoice{"w": ReviewWorkingCopy, "b": PickBase} {
+		_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		if cmd == nil {
+			t.Fatalf("%s: expected a command", key)
+		}
+		if msg, ok := cmd().(NoBaseChosenMsg); !ok || msg.Choice != want {
+			t.Errorf("%s: got %+v, want choice %d", key, msg, want)
+		}
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("q should quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("q should quit")
+	}
+}
+
+func TestNoBaseScreen_Tiny(t *testing.T) {
+	m := NewNoBaseScreen("trunk()", false, "no base revision found", []string{"Create a bookmark"})
+	m.SetSize(20, 2)
+	if view := m.View(); !strings.Contains(view, "No base") {
+		t.Errorf("expected the title even when cramped:\n%s", view)
+	}
+}
+
+func TestWrapWords(t *testing.T) {
+	got := wrapWords("run git fetch --unshallow now, or abcdefghijkl", 12)
+	want := []string{"run git", "fetch", "--unshallow", "now, or", "abcdefghijkl"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("wrapWords = %q, want %q", got, want)
+	}
+	if got := wrapWords("abcdefghijklmnopqrstuvwxyz", 10); len(got) != 3 || got[2] != "uvwxyz" {
+		t.Errorf("expected a long word cut into lines, got %q", got)
+	}
+}
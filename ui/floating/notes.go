@@ -0,0 +1,105 @@
+package floating
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// NotesExportedMsg is sent when the user exports the notes to the output file
+type NotesExportedMsg struct {
+	Notes string
+}
+
+// NotesClosedMsg is sent when the notes editor is closed, carrying the
+// edited notes so they survive until it's reopened
+type NotesClosedMsg struct {
+	Notes string
+}
+
+// NotesModal edits free-form review notes, such as a drafted change
+// description
+type NotesModal struct {
+	textarea textarea.Model
+	width    int
+	height   int
+	ready    bool
+}
+
+// NewNotesModal creates a notes editor holding notes
+func NewNotesModal(notes string) *NotesModal {
+	ta := textarea.New()
+	ta.Placeholder = "Review notes..."
+	ta.CharLimit = 0 // No limit
+	ta.ShowLineNumbers = false
+	ta.SetValue(notes)
+	ta.Focus()
+
+	return &NotesModal{textarea: ta}
+}
+
+func (m *NotesModal) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m *NotesModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		notes := m.textarea.Value()
+		switch keyMsg.String() {
+		case "ctrl+s":
+			if strings.TrimSpace(notes) == "" {
+				return m, nil
+			}
+			return m, func() tea.Msg {
+				return NotesExportedMsg{Notes: notes}
+			}
+		case "esc":
+			return m, func() tea.Msg {
+				return NotesClosedMsg{Notes: notes}
+			}
+		}
+	}
+
+	// Enter inserts a newline: notes are usually several paragraphs
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+// SetSize sets the available screen size
+func (m *NotesModal) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+// Value returns the current notes
+func (m *NotesModal) Value() string {
+	return m.textarea.Value()
+}
+
+func (m *NotesModal) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*75/100, 40)
+	windowHeight := max(m.height*75/100, 10)
+	contentWidth := windowWidth - 4
+	contentHeight := windowHeight - 4
+
+	m.textarea.SetWidth(contentWidth)
+	m.textarea.SetHeight(contentHeight - 2)
+
+	lines := []string{
+		m.textarea.View(),
+		"",
+		theme.HelpDescStyle.Render("C-s export to output file  esc close (notes are kept)"),
+	}
+
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), "Notes", windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
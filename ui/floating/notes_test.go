@@ -0,0 +1,39 @@
+package floating
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNotesModal_EditExportAndClose(t *testing.T) {
+	m := NewNotesModal("Draft")
+	m.SetSize(80, 24)
+
+	// Enter is a newline, not a submit
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil {
+		if _, ok := cmd().(NotesExportedMsg); ok {
+			t.Fatal("enter should not export")
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("more")})
+	if got := m.Value(); got != "Draft\nmore" {
+		t.Errorf("expected edited notes, got %q", got)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd == nil {
+		t.Fatal("expected export command")
+	}
+	if msg, ok := cmd().(NotesExportedMsg); !ok || msg.Notes != "Draft\nmore" {
+		t.Errorf("expected NotesExportedMsg with the notes, got %#v", msg)
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("expected close command")
+	}
+	if msg, ok := cmd().(NotesClosedMsg); !ok || msg.Notes != "Draft\nmore" {
+		t.Errorf("expected NotesClosedMsg keeping the notes, got %#v", msg)
+	}
+}
@@ -0,0 +1,121 @@
+package floating
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// PluginItem is an action or panel offered by a plugin
+type PluginItem struct {
+	Plugin string
+	Title  string
+	Panel  bool
+}
+
+// PluginChosenMsg is sent when a plugin item is picked. Index is its
+// position in the menu.
+type PluginChosenMsg struct {
+	Index int
+}
+
+// PluginMenu lists the actions and panels plugins add, to run one on the
+// cursor's line
+type PluginMenu struct {
+	items  []PluginItem
+	choice int
+	offset int // First item shown when the menu doesn't fit
+	width  int
+	height int
+	ready  bool
+}
+
+// NewPluginMenu creates a menu over items
+func NewPluginMenu(items []PluginItem) *PluginMenu {
+	return &PluginMenu{items: items}
+}
+
+func (m *PluginMenu) Init() tea.Cmd {
+	return nil
+}
+
+func (m *PluginMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return FeedbackCancelledMsg{}
+		}
+	case "enter":
+		if len(m.items) == 0 {
+			return m, nil
+		}
+		index := m.choice
+		return m, func() tea.Msg {
+			return PluginChosenMsg{Index: index}
+		}
+	case "up", "k", "ctrl+p":
+		m.choice = max(m.choice-1, 0)
+	case "down", "j", "ctrl+n":
+		m.choice = max(min(m.choice+1, len(m.items)-1), 0)
+	case "g", "home":
+		m.choice = 0
+	case "G", "end":
+		m.choice = max(len(m.items)-1, 0)
+	}
+	return m, nil
+}
+
+// SetSize sets the available screen size
+func (m *PluginMenu) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *PluginMenu) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := max(m.width*60/100, 40)
+	contentWidth := windowWidth - 4
+
+	// Keep the choice in view: border, blank line and help take 4 rows
+	rows := max(m.height*75/100-4, 1)
+	if m.choice < m.offset {
+		m.offset = m.choice
+	} else if m.choice >= m.offset+rows {
+		m.offset = m.choice - rows + 1
+	}
+
+	var lines []string
+	if len(m.items) == 0 {
+		lines = append(lines, theme.DimmedStyle.Render("No plugin actions or panels"))
+	}
+	for i := m.offset; i < len(m.items) && i < m.offset+rows; i++ {
+		it := m.items[i]
+		item := it.Title + theme.DimmedStyle.Render("  "+it.Plugin)
+		if it.Panel {
+			item += theme.DimmedStyle.Render(" panel")
+		}
+		item = ansi.Truncate(item, contentWidth-2, "…")
+		if i == m.choice {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+item))
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+item))
+		}
+	}
+	lines = append(lines, "", theme.HelpDescStyle.Render("enter run  esc close"))
+
+	windowHeight := len(lines) + 2
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), "Plugins", windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
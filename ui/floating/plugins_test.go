@@ -0,0 +1,43 @@
+package floating
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestPluginMenu(t *testing.T) {
+	m := NewPluginMenu([]PluginItem{
+		{Plugin: "jira", Title: "File a ticket"},
+		{Plugin: "owners", Title: "Code owners", Panel: true},
+	})
+	m.SetSize(100, 24)
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Plugins", "> File a ticket  jira", "  Code owners  owners panel", "enter run"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	m.Update(key("j"))
+	m.Update(key("j"))
+	_, cmd := m.Update(key("enter"))
+	if msg, ok := cmd().(PluginChosenMsg); !ok || msg.Index != 1 {
+		t.Errorf("expected the panel, got %#v", cmd())
+	}
+	_, cmd = m.Update(key("esc"))
+	if _, ok := cmd().(FeedbackCancelledMsg); !ok {
+		t.Errorf("expected esc to close, got %#v", cmd())
+	}
+
+	empty := NewPluginMenu(nil)
+	empty.SetSize(100, 24)
+	if view := ansi.Strip(empty.View()); !strings.Contains(view, "No plugin actions or panels") {
+		t.Errorf("expected an empty menu to say so:\n%s", view)
+	}
+	if _, cmd := empty.Update(key("enter")); cmd != nil {
+		t.Error("expected enter to do nothing in an empty menu")
+	}
+}
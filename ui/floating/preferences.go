@@ -0,0 +1,174 @@
+package floating
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// PreferencesSavedMsg is sent when the user confirms new preferences
+type PreferencesSavedMsg struct {
+	Config config.Config
+}
+
+// PreferencesCancelledMsg is sent when the preferences modal is dismissed
+type PreferencesCancelledMsg struct{}
+
+// prefRow is one editable setting in the preferences modal
+type prefRow struct {
+	field  config.Field
+	choice int // Index into field.Choices
+}
+
+// PreferencesModal lets the user cycle through the values of each setting
+type PreferencesModal struct {
+	cfg    config.Config
+	rows   []prefRow
+	cursor int
+	width  int
+	height int
+	ready  bool
+}
+
+// NewPreferencesModal creates a preferences modal showing cfg's values
+func NewPreferencesModal(cfg config.Config) *PreferencesModal {
+	var rows []prefRow
+	for _, f := range config.Fields() {
+		if len(f.Choices) == 0 {
+			continue
+		}
+		rows = append(rows, prefRow{field: f, choice: choiceIndex(f.Choices, cfg.Get(f.Key))})
+	}
+	return &PreferencesModal{cfg: cfg, rows: rows}
+}
+
+// choiceIndex finds value in choices, defaulting to the first choice
+func choiceIndex(choices []string, value string) int {
+	for i, c := range choices {
+		if c == value {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m *PreferencesModal) Init() tea.Cmd {
+	return nil
+}
+
+func (m *PreferencesModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "ctrl+p", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "ctrl+n", "j":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "left", "h":
+		m.cycle(-1)
+	case "right", "l", " ":
+		m.cycle(1)
+	case "enter":
+		cfg := m.Config()
+		return m, func() tea.Msg {
+			return PreferencesSavedMsg{Config: cfg}
+		}
+	case "esc", "q":
+		return m, func() tea.Msg {
+			return PreferencesCancelledMsg{}
+		}
+	}
+	return m, nil
+}
+
+// cycle moves the selected row's value forward or backward, wrapping
+func (m *PreferencesModal) cycle(delta int) {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return
+	}
+	row := &m.rows[m.cursor]
+	n := len(row.field.Choices)
+	row.choice = ((row.choice+delta)%n + n) % n
+}
+
+// Config returns the configuration with the currently selected values
+func (m *PreferencesModal) Config() config.Config {
+	cfg := m.cfg
+	for _, row := range m.rows {
+		// Choices come from the schema, so Set cannot fail here
+		_ = cfg.Set(row.field.Key, row.field.Choices[row.choice])
+	}
+	return cfg
+}
+
+// SetSize sets the available screen size
+func (m *PreferencesModal) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+}
+
+func (m *PreferencesModal) View() string {
+	if !m.ready {
+		return ""
+	}
+
+	windowWidth := m.width * 60 / 100
+	if windowWidth < 50 {
+		windowWidth = 50
+	}
+	windowHeight := len(m.rows) + 6
+
+	keyWidth := 0
+	for _, row := range m.rows {
+		if len(row.field.Key) > keyWidth {
+			keyWidth = len(row.field.Key)
+		}
+	}
+
+	var lines []string
+	for i, row := range m.rows {
+		value := row.field.Choices[row.choice]
+		label := fmt.Sprintf("%-*s  ", keyWidth, row.field.Key)
+		desc := theme.DimmedStyle.Render("  " + row.field.Description)
+		if i == m.cursor {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+label+"< "+value+" >")+desc)
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+label+"  "+value+"  ")+desc)
+		}
+	}
+	lines = append(lines, "")
+	lines = append(lines, theme.HelpDescStyle.Render("up/dn select  left/right change  enter save  esc cancel"))
+
+	windowContent := borders.RenderFloatingBorder(strings.Join(lines, "\n"), "Preferences", windowWidth, windowHeight)
+	return centerWindow(windowContent, windowWidth, windowHeight, m.width, m.height)
+}
+
+// centerWindow pads a rendered window so it sits in the middle of the screen
+func centerWindow(window string, windowWidth, windowHeight, width, height int) string {
+	x := (width - windowWidth) / 2
+	y := (height - windowHeight) / 2
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	windowLines := strings.Split(window, "\n")
+	for i := range windowLines {
+		windowLines[i] = strings.Repeat(" ", x) + windowLines[i]
+	}
+	return strings.Repeat("\n", y) + strings.Join(windowLines, "\n")
+}
@@ -0,0 +1,65 @@
+package floating
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/config"
+)
+
+func TestPreferencesModal_CycleValues(t *testing.T) {
+	m := NewPreferencesModal(config.Default())
+
+	// First row is theme: cycle forward to the next theme
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got := m.Config().Theme; got != "light" {
+		t.Errorf("expected theme 'light', got %q", got)
+	}
+
+	// Cycling backward twice wraps around
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if got := m.Config().Theme; got != "light" {
+		t.Errorf("expected wrap-around to 'light', got %q", got)
+	}
+
+	// Move to the wrap row and toggle it
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if !m.Config().Wrap {
+		t.Error("expected wrap to be enabled")
+	}
+}
+
+func TestPreferencesModal_SaveAndCancel(t *testing.T) {
+	m := NewPreferencesModal(config.Default())
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected save command")
+	}
+	if _, ok := cmd().(PreferencesSavedMsg); !ok {
+		t.Error("expected PreferencesSavedMsg on enter")
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Fatal("expected cancel command")
+	}
+	if _, ok := cmd().(PreferencesCancelledMsg); !ok {
+		t.Error("expected PreferencesCancelledMsg on esc")
+	}
+}
+
+func TestPreferencesModal_KeepsCurrentValues(t *testing.T) {
+	cfg := config.Default()
+	cfg.Keymap = "vim"
+	cfg.ContextLines = 10
+
+	m := NewPreferencesModal(cfg)
+	if got := m.Config(); !reflect.DeepEqual(got, cfg) {
+		t.Errorf("expected unchanged config, got %+v", got)
+	}
+}
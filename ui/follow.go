@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/follow"
+	"github.com/gerunddev/tcr/ui/keys"
+)
+
+// followMsg carries the shared review's next position, or why it stopped
+type followMsg struct {
+	pos follow.Position
+	err error
+}
+
+// SetShare broadcasts where the cursor is to anyone following on b
+func (a *App) SetShare(b *follow.Broadcaster) {
+	a.sharing = b
+	a.statusMsg = "Sharing this review · tcr --follow " + b.Path() + " joins"
+}
+
+// SetFollow makes the app a read-only view that follows the review shared
+// on f: it opens the files and moves to the lines the sharer does, and
+// every key but quit is ignored
+func (a *App) SetFollow(f *follow.Follower) {
+	a.following = f
+	a.statusMsg = "Following a shared review · read-only · q quits"
+}
+
+// broadcast shares the cursor position, when sharing
+func (a *App) broadcast() {
+	if a.sharing == nil || a.diffPanel.FilePath() == "" {
+		return
+	}
+	a.sharing.Send(follow.Position{
+		File:   a.diffPanel.FilePath(),
+		Line:   a.cursorSourceLine(),
+		Cursor: a.diffPanel.CursorLine(),
+	})
+}
+
+// nextPosition waits for the shared review to move
+func (a *App) nextPosition() tea.Msg {
+	pos, err := a.following.Next()
+	return followMsg{pos: pos, err: err}
+}
+
+// followTo moves to the shared review's position, opening its file first
+// if needed, and waits for the next one
+func (a *App) followTo(msg followMsg) tea.Cmd {
+	if errors.Is(msg.err, follow.ErrEnded) {
+		a.statusMsg = "The shared review ended · q quits"
+		return nil
+	}
+	if msg.err != nil {
+		a.statusMsg = "Error: " + msg.err.Error()
+		return nil
+	}
+	pos := msg.pos
+	switch {
+	case a.loading:
+		// filesLoadedMsg opens it
+		a.followPos = &pos
+	case a.quick:
+		if !a.quickGoto(pos.File, pos.Line) {
+			a.statusMsg = pos.File + " isn't among the changed files here"
+		}
+	case a.diffPanel.FilePath() == pos.File:
+		a.gotoPosition(pos)
+	case a.filesPanel.SelectPath(pos.File):
+		a.followPos = &pos
+		if content, ok := a.diffCache.Get(pos.File); ok {
+			a.showDiff(pos.File, content)
+		} else {
+			return tea.Batch(a.loadDiff(pos.File), a.nextPosition)
+		}
+	default:
+		a.statusMsg = pos.File + " isn't among the changed files here"
+	}
+	return a.nextPosition
+}
+
+// gotoPosition puts the cursor on the shared review's line, or on its
+// diff line when the file line isn't in the diff
+func (a *App) gotoPosition(pos follow.Position) {
+	_, newLines := findings.LineIndexes(a.diffPanel.DiffContent())
+	if i, ok := newLines[pos.Line]; ok && pos.Line > 0 {
+		a.diffPanel.GotoLine(i)
+	} else {
+		a.diffPanel.GotoLine(pos.Cursor)
+	}
+	a.diffPanel.Refresh()
+}
+
+// followerKey handles a key while following: quit, and nothing else
+func (a *App) followerKey(msg tea.KeyMsg) tea.Cmd {
+	if action, _ := a.router.Route(msg); action == keys.Quit {
+		return tea.Quit
+	}
+	a.statusMsg = "Following a shared review · read-only · q quits"
+	return nil
+}
@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/crash"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// recentMessages is how many messages are kept for crash reports
+const recentMessages = 50
+
+// Guard wraps the app so a panic produces a crash report and a clean exit
+// instead of a broken terminal and a lost review
+type Guard struct {
+	app      *App
+	version  string
+	recorder *crash.Recorder
+	report   *crash.Report
+	writeErr error
+}
+
+// NewGuard wraps app; version is recorded in crash reports
+func NewGuard(app *App, version string) *Guard {
+	return &Guard{
+		app:      app,
+		version:  version,
+		recorder: crash.NewRecorder(recentMessages),
+	}
+}
+
+// crashedMsg carries a panic recovered inside a command goroutine
+type crashedMsg struct {
+	value any
+	stack []byte
+}
+
+// Crash returns the crash report, if the session crashed, and any error
+// from writing it to disk
+func (g *Guard) Crash() (*crash.Report, error) {
+	return g.report, g.writeErr
+}
+
+func (g *Guard) Init() (cmd tea.Cmd) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash(r, debug.Stack())
+			cmd = nil
+		}
+	}()
+	return g.wrap(g.app.Init())
+}
+
+func (g *Guard) Update(msg tea.Msg) (m tea.Model, cmd tea.Cmd) {
+	if c, ok := msg.(crashedMsg); ok {
+		g.crash(c.value, c.stack)
+		return g, tea.Quit
+	}
+
+	// After a crash only wait for a key to exit
+	if g.report != nil {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return g, tea.Quit
+		}
+		return g, nil
+	}
+
+	g.recorder.Add(describeMsg(msg))
+
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash(r, debug.Stack())
+			m, cmd = g, tea.Quit
+		}
+	}()
+
+	_, cmd = g.app.Update(msg)
+	return g, g.wrap(cmd)
+}
+
+func (g *Guard) View() (view string) {
+	if g.report != nil {
+		return g.crashView()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			g.crash(r, debug.Stack())
+			view = g.crashView()
+		}
+	}()
+	return g.app.View()
+}
+
+// wrap recovers panics inside cmd and turns them into a crashedMsg
+func (g *Guard) wrap(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() (msg tea.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = crashedMsg{value: r, stack: debug.Stack()}
+			}
+		}()
+		return cmd()
+	}
+}
+
+// crash builds and writes the report for a recovered panic
+func (g *Guard) crash(value any, stack []byte) {
+	if g.report != nil {
+		return
+	}
+	g.report = &crash.Report{
+		Time:     time.Now(),
+		Version:  g.version,
+		Panic:    fmt.Sprint(value),
+		Stack:    string(stack),
+		Messages: g.recorder.Recent(),
+		State:    g.sessionState(),
+	}
+
+	dir, err := crash.Dir()
+	if err == nil {
+		_, err = crash.Write(dir, g.report)
+	}
+	g.writeErr = err
+}
+
+// sessionState snapshots the app, tolerating a model left inconsistent by
+// the panic
+func (g *Guard) sessionState() (s crash.State) {
+	defer func() { _ = recover() }()
+	return g.app.SessionState()
+}
+
+// crashView is shown in place of the app after a crash
+func (g *Guard) crashView() string {
+	where := g.report.Path
+	if g.writeErr != nil {
+		where = "(not written: " + g.writeErr.Error() + ")"
+	}
+	return theme.HelpDescStyle.Render(fmt.Sprintf(
+		"tcr crashed: %s\n\nCrash report: %s\n\nPress any key to exit.", g.report.Panic, where))
+}
+
+// describeMsg summarizes a message for the crash log without recording
+// diff or comment contents
+func describeMsg(msg tea.Msg) string {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		return "key " + msg.String()
+	case tea.WindowSizeMsg:
+		return fmt.Sprintf("resize %dx%d", msg.Width, msg.Height)
+	default:
+		return fmt.Sprintf("%T", msg)
+	}
+}
@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/gerunddev/tcr/ui/theme"
 )
 
@@ -22,6 +24,10 @@ func (h HelpHint) Format() string {
 type HelpBarContext struct {
 	ModalOpen    bool // True if feedback modal is open
 	SearchActive bool // True if search mode is active
+	FilterActive bool // True if the files panel name filter has focus
+	PendingCount int  // Count prefix typed so far, 0 if none
+	Quick        bool // True in quick mode, which has no files panel
+	Following    bool // True while following a shared review, read-only
 }
 
 // getHints returns context-specific hints
@@ -33,20 +39,60 @@ func getHints(ctx HelpBarContext) []HelpHint {
 		}
 	}
 
+	if ctx.Following {
+		return []HelpHint{
+			{Key: "following", Desc: "read-only"},
+			{Key: "q", Desc: "quit"},
+		}
+	}
+
 	if ctx.SearchActive {
 		return []HelpHint{
 			{Key: "up/dn", Desc: "file nav"},
 			{Key: "enter", Desc: "cycle match"},
+			{Key: "M-enter", Desc: "comment on all"},
 			{Key: "esc", Desc: "close"},
 		}
 	}
 
+	if ctx.FilterActive {
+		return []HelpHint{
+			{Key: "up/dn", Desc: "file nav"},
+			{Key: "enter", Desc: "keep filter"},
+			{Key: "esc", Desc: "clear"},
+		}
+	}
+
+	// A count prefix is being typed: show what it applies to
+	if ctx.PendingCount > 0 {
+		return []HelpHint{
+			{Key: strconv.Itoa(ctx.PendingCount), Desc: "count"},
+			{Key: "up/dn", Desc: "files"},
+			{Key: "C-n/C-p j/k", Desc: "lines"},
+			{Key: "g/G", Desc: "goto line"},
+			{Key: "%", Desc: "goto percent"},
+			{Key: "esc", Desc: "cancel"},
+		}
+	}
+
+	if ctx.Quick {
+		return []HelpHint{
+			{Key: "up/dn", Desc: "files"},
+			{Key: "C-n/C-p", Desc: "diff nav"},
+			{Key: "/", Desc: "search"},
+			{Key: "enter", Desc: "feedback"},
+			{Key: "q", Desc: "quit"},
+		}
+	}
+
 	// Both panels always active with their own keys
 	return []HelpHint{
 		{Key: "up/dn", Desc: "file nav"},
 		{Key: "C-n/C-p", Desc: "diff nav"},
 		{Key: "/", Desc: "search"},
+		{Key: "f", Desc: "filter files"},
 		{Key: "enter", Desc: "feedback"},
+		{Key: ",", Desc: "prefs"},
 		{Key: "q", Desc: "quit"},
 	}
 }
@@ -69,10 +115,16 @@ func RenderHelpBar(ctx HelpBarContext, width int) string {
 	hints := getHints(ctx)
 	content := formatHints(hints)
 
+	// Keep to one line: drop hints before the last one until it fits
+	for len(hints) > 1 && lipgloss.Width(content) > width {
+		hints = append(hints[:len(hints)-2:len(hints)-2], hints[len(hints)-1])
+		content = formatHints(hints)
+	}
+
 	// Center the content
 	contentWidth := lipgloss.Width(content)
 	if contentWidth >= width {
-		return theme.HelpBarStyle.Width(width).Render(content)
+		return theme.HelpBarStyle.Render(ansi.Truncate(content, width, ""))
 	}
 
 	padding := (width - contentWidth) / 2
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/ui/floating"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// historyLimit is how many revisions the history lists
+const historyLimit = 200
+
+// historyLoadedMsg carries the recent revisions to pick one to review
+type historyLoadedMsg struct {
+	entries []vcs.LogEntry
+	err     error
+}
+
+// openHistory loads the recent commits or changes in the background, to
+// list them
+func (a *App) openHistory() tea.Cmd {
+	logger, ok := a.vcs.(vcs.Logger)
+	c, configurable := a.vcs.(vcs.Configurable)
+	if !ok || !configurable {
+		a.statusMsg = a.vcs.Name() + " has no history to browse"
+		return nil
+	}
+	if c.Options().Interdiff {
+		a.statusMsg = "An interdiff compares two versions of a change; quit to review another revision"
+		return nil
+	}
+	a.statusMsg = "Loading history..."
+	return func() tea.Msg {
+		entries, err := logger.Log(a.ctx, historyLimit)
+		return historyLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// showHistory lists the working copy and then the recent revisions,
+// marking the one under review
+func (a *App) showHistory(msg historyLoadedMsg) {
+	if msg.err != nil {
+		a.statusMsg = "Error: " + msg.err.Error()
+		return
+	}
+	a.statusMsg = ""
+	a.history = msg.entries
+
+	opts := a.vcs.(vcs.Configurable).Options()
+	current := -1
+	if !opts.HasRange() {
+		current = 0
+	}
+	revisions := []floating.Revision{{}}
+	for i, e := range msg.entries {
+		revisions = append(revisions, floating.Revision{ID: e.ID, Author: e.Author, Date: e.Date, Subject: e.Subject})
+		if opts.From == "" && opts.To == e.ID {
+			current = i + 1
+		}
+	}
+	a.historyList = floating.NewHistoryList(revisions, current)
+	a.historyList.SetSize(a.width, a.height)
+}
+
+// reviewRevision switches the review to a revision picked from the
+// history, index 0 being the working copy's changes, and reloads the files
+func (a *App) reviewRevision(index int) tea.Cmd {
+	c := a.vcs.(vcs.Configurable)
+	opts := c.Options()
+	opts.From, opts.To, opts.Scope = "", "", vcs.ScopeAll
+	label := "the working copy's changes"
+	if index > 0 && index <= len(a.history) {
+		e := a.history[index-1]
+		opts.To = e.ID
+		label = e.ID + " " + e.Subject
+	}
+	c.SetOptions(opts)
+	a.invalidateDiffs()
+	a.stats = nil
+	a.positions = nil
+	a.filesPanel.SetStats(nil)
+	a.descPanel.SetDescription("")
+	a.updatePanelSizes()
+
+	a.setFilesTitle()
+	a.statusMsg = "Reviewing " + label
+	return tea.Batch(a.loadFiles, a.loadDescription())
+}
@@ -0,0 +1,283 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/output"
+	"github.com/gerunddev/tcr/ui/floating"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// hunkAction is something the hunk menu does with the hunk under the cursor
+type hunkAction int
+
+const (
+	commentHunk hunkAction = iota
+	copyHunk
+	stageHunk
+	foldHunk
+	expandContext
+)
+
+// contextStep is how many more unchanged lines each expansion of the
+// context shows
+const contextStep = 10
+
+// openHunkMenu lists the actions that apply to the hunk under the cursor
+func (a *App) openHunkMenu() {
+	path := a.diffPanel.FilePath()
+	if path == "" || a.quick {
+		return
+	}
+	header, ok := vcs.HunkHeader(hunkAt(a.diffPanel.Lines(), a.diffPanel.CursorLine()))
+	if !ok {
+		a.statusMsg = "Not on a hunk"
+		return
+	}
+
+	a.hunkActions = []hunkAction{commentHunk}
+	titles := []string{"Comment on the hunk"}
+	raw, _ := a.diffCache.Peek(path)
+	if _, _, ok := vcs.Hunk(raw, header); ok {
+		a.hunkActions = append(a.hunkActions, copyHunk)
+		titles = append(titles, "Copy the hunk")
+		if s, ok := a.vcs.(vcs.HunkStager); ok && s.CanStage() {
+			a.hunkActions = append(a.hunkActions, stageHunk)
+			titles = append(titles, "Stage the hunk")
+		}
+	}
+	a.hunkActions = append(a.hunkActions, foldHunk)
+	if a.folded[path][header] {
+		titles = append(titles, "Unfold the hunk")
+	} else {
+		titles = append(titles, "Fold the hunk")
+	}
+	if c, ok := a.vcs.(vcs.Configurable); ok {
+		a.hunkActions = append(a.hunkActions, expandContext)
+		titles = append(titles, fmt.Sprintf("Expand context to %d lines", contextLines(c.Options())+contextStep))
+	}
+
+	a.hunkHeader = header
+	a.hunkMenu = floating.NewHunkMenu(header, titles)
+	a.hunkMenu.SetSize(a.width, a.height)
+}
+
+// contextLines is how many unchanged lines the diffs show around each
+// change, counting the VCS default of 3 for none set
+func contextLines(opts vcs.Options) int {
+	if opts.ContextLines > 0 {
+		return opts.ContextLines
+	}
+	return 3
+}
+
+// runHunkAction does what was picked from the hunk menu to the hunk with
+// the given header in the diff shown
+func (a *App) runHunkAction(action hunkAction, header string) tea.Cmd {
+	path := a.diffPanel.FilePath()
+	switch action {
+	case commentHunk:
+		a.gotoHunkChange(header)
+		a.openFeedbackModal()
+	case copyHunk:
+		raw, _ := a.diffCache.Peek(path)
+		_, hunk, ok := vcs.Hunk(raw, header)
+		if !ok {
+			a.statusMsg = "The hunk is no longer in the diff"
+			return nil
+		}
+		a.statusMsg = fmt.Sprintf("Copied %s of %s", header, path)
+		return copyToClipboard(hunk)
+	case stageHunk:
+		return a.stageHunk(path, header)
+	case foldHunk:
+		a.toggleFold(path, header)
+	case expandContext:
+		return a.expandContext()
+	}
+	return nil
+}
+
+// gotoHunkChange puts the cursor on the first added or removed line of the
+// hunk with the given header, where a comment on the hunk goes
+func (a *App) gotoHunkChange(header string) {
+	lines := a.diffPanel.Lines()
+	start := -1
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		if h, ok := vcs.HunkHeader(plain); ok {
+			if start >= 0 {
+				return
+			}
+			if h == header {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && (strings.HasPrefix(plain, "+") || strings.HasPrefix(plain, "-")) {
+			a.diffPanel.GotoLine(i)
+			a.diffPanel.Refresh()
+			return
+		}
+	}
+}
+
+// copyToClipboard puts text on the system clipboard with an OSC 52
+// escape, which terminals pass on even over ssh
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		return clipboardMsg{text: text}
+	}
+}
+
+// clipboardMsg asks for text to be put on the system clipboard
+type clipboardMsg struct {
+	text string
+}
+
+// clipboardSentMsg ends a copy once its escape has been drawn
+type clipboardSentMsg struct {
+	seq int
+}
+
+// clipboardHold is how long a copy's escape leads the frame: a few frames,
+// so one is sure to be drawn. The renderer skips lines that haven't
+// changed, so the escape goes out once.
+const clipboardHold = 100 * time.Millisecond
+
+// copied leads the frame with the escape for a copy, through the renderer
+// so it can't land in the middle of a frame being drawn
+func (a *App) copied(msg clipboardMsg) tea.Cmd {
+	a.clipboard = ansi.SetSystemClipboard(msg.text)
+	a.clipboardSeq++
+	seq := a.clipboardSeq
+	return tea.Tick(clipboardHold, func(time.Time) tea.Msg {
+		return clipboardSentMsg{seq: seq}
+	})
+}
+
+// stageHunk adds one hunk of a file's changes to the index in the
+// background
+func (a *App) stageHunk(path, header string) tea.Cmd {
+	s, ok := a.vcs.(vcs.HunkStager)
+	raw, _ := a.diffCache.Peek(path)
+	fileHeader, hunk, found := vcs.Hunk(raw, header)
+	if !ok || !found {
+		a.statusMsg = "The hunk is no longer in the diff"
+		return nil
+	}
+	a.statusMsg = "Staging " + header + "..."
+	return func() tea.Msg {
+		return hunkStagedMsg{path: path, header: header, err: s.StageHunk(a.ctx, fileHeader+hunk)}
+	}
+}
+
+// hunkStagedMsg reports a hunk staged from the hunk menu
+type hunkStagedMsg struct {
+	path   string
+	header string
+	err    error
+}
+
+// hunkStaged reloads the changes once a hunk is staged, as it may have
+// been the file's last unstaged one
+func (a *App) hunkStaged(msg hunkStagedMsg) tea.Cmd {
+	if msg.err != nil {
+		a.statusMsg = "Error: " + msg.err.Error()
+		return nil
+	}
+	cmd := a.reload()
+	a.statusMsg = fmt.Sprintf("Staged %s of %s", msg.header, msg.path)
+	return cmd
+}
+
+// foldCursorHunk folds the hunk under the cursor, or unfolds it
+func (a *App) foldCursorHunk() {
+	path := a.diffPanel.FilePath()
+	if path == "" || a.quick {
+		return
+	}
+	header, ok := vcs.HunkHeader(hunkAt(a.diffPanel.Lines(), a.diffPanel.CursorLine()))
+	if !ok {
+		a.statusMsg = "Not on a hunk"
+		return
+	}
+	a.toggleFold(path, header)
+}
+
+// foldedHunks counts the hunks of a diff that are folded
+func foldedHunks(diff string, folded map[string]bool) int {
+	if len(folded) == 0 {
+		return 0
+	}
+	n := 0
+	for _, line := range strings.Split(diff, "\n") {
+		if h, ok := vcs.HunkHeader(line); ok && folded[h] {
+			n++
+		}
+	}
+	return n
+}
+
+// toggleFold folds the hunk with the given header down to its header, or
+// unfolds it, keeping the cursor on the header
+func (a *App) toggleFold(path, header string) {
+	if a.folded == nil {
+		a.folded = make(map[string]map[string]bool)
+	}
+	if a.folded[path][header] {
+		delete(a.folded[path], header)
+		a.statusMsg = "Unfolded " + header
+	} else {
+		if a.folded[path] == nil {
+			a.folded[path] = make(map[string]bool)
+		}
+		a.folded[path][header] = true
+		a.statusMsg = "Folded " + header
+	}
+	a.redisplay()
+	for i, line := range a.diffPanel.Lines() {
+		if h, ok := vcs.HunkHeader(ansi.Strip(line)); ok && h == header {
+			a.diffPanel.GotoLine(i)
+			a.diffPanel.Refresh()
+			break
+		}
+	}
+}
+
+// expandContext reloads the diffs with more unchanged lines around each
+// change, for this session only, keeping the cursor on its line
+func (a *App) expandContext() tea.Cmd {
+	c, ok := a.vcs.(vcs.Configurable)
+	if !ok {
+		return nil
+	}
+	opts := c.Options()
+	opts.ContextLines = contextLines(opts) + contextStep
+	c.SetOptions(opts)
+	a.invalidateDiffs()
+	a.statusMsg = fmt.Sprintf("Showing %d lines of context", opts.ContextLines)
+
+	path := a.diffPanel.FilePath()
+	if path == "" {
+		return nil
+	}
+	// Hunk headers and removed lines have no line of their own to return
+	// to, so go back to the next line that does
+	_, newLines := findings.LineIndexes(a.diffPanel.DiffContent())
+	cursor, next := a.diffPanel.CursorLine(), -1
+	for line, i := range newLines {
+		if i >= cursor && (next < 0 || i < newLines[next]) {
+			next = line
+		}
+	}
+	if next > 0 {
+		a.jumpTo = &output.Feedback{FilePath: path, Line: next}
+	}
+	return a.loadDiff(path)
+}
@@ -0,0 +1,301 @@
+package keys
+
+import (
+	"sort"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action identifies something the user can ask the app to do
+type Action int
+
+const (
+	None Action = iota
+	Quit
+	Search
+	Feedback
+	FileUp
+	FileDown
+	LineUp
+	LineDown
+	PageUp
+	PageDown
+	HalfPageUp
+	HalfPageDown
+	Top
+	Bottom
+	Percent
+	FilterFiles
+	Preferences
+	ToggleInvisibles
+	SwitchScope
+	Commit
+	Notes
+	Describe
+	ToggleLockfile
+	APISummary
+	ToggleOutputs
+	Praise
+	Resolve
+	EarlierComments
+	ConflictsOnly
+	ToggleDescription
+	RepeatComment
+	History
+	Reload
+	Base
+	Plugins
+	ToggleWhitespace
+	ToggleIgnored
+	HunkMenu
+	ToggleFold
+
+	actionCount // Keep last: number of actions
+)
+
+// descriptions documents each action for generated help
+var descriptions = map[Action]string{
+	Quit:              "Quit",
+	Search:            "Search across all diffs",
+	Feedback:          "Add feedback on the current line",
+	FileUp:            "Previous file",
+	FileDown:          "Next file",
+	LineUp:            "Previous diff line",
+	LineDown:          "Next diff line",
+	PageUp:            "Page up",
+	PageDown:          "Page down",
+	HalfPageUp:        "Half page up",
+	HalfPageDown:      "Half page down",
+	Top:               "Top of diff (with count: go to line N)",
+	Bottom:            "Bottom of diff (with count: go to line N)",
+	Percent:           "Jump to N% of the diff (default 50%)",
+	FilterFiles:       "Filter files by name",
+	Preferences:       "Open preferences",
+	ToggleInvisibles:  "Show/hide tabs, trailing and zero-width characters",
+	SwitchScope:       "Switch git changes: all, staged, unstaged",
+	Commit:            "Commit the reviewed changes (jj: describe and start a new change)",
+	Notes:             "Edit review notes",
+	Describe:          "Draft a change description into the notes",
+	ToggleLockfile:    "Toggle lockfiles between a package summary and the raw diff",
+	APISummary:        "Show the exported API changes in a Go file",
+	ToggleOutputs:     "Expand/collapse notebook cell outputs",
+	Praise:            "Praise the current line without opening the feedback modal",
+	Resolve:           "Reload the changed files; in a re-review, mark the earlier comment on this line resolved, or open it again",
+	EarlierComments:   "Re-review: list the earlier comments, possibly addressed first",
+	ConflictsOnly:     "Show only files with merge conflicts, or all files again",
+	ToggleDescription: "Expand/collapse the commit message or change description",
+	RepeatComment:     "Comment on the current line with the last comment written, to adjust and save",
+	History:           "Browse recent commits (jj: changes) and review one",
+	Reload:            "Reload the changed files, picking up edits made since tcr started",
+	Base:              "Show what the review diffs against, and pick another base from the branches and recent commits",
+	Plugins:           "Run a plugin action or open a plugin panel on the current line",
+	ToggleWhitespace:  "Hide/show changes that only touch whitespace, reloading the diffs",
+	ToggleIgnored:     "Show/hide the files .tcrignore leaves out of the review",
+	HunkMenu:          "Act on the hunk under the cursor: comment, copy, stage, fold, expand context",
+	ToggleFold:        "Fold the hunk under the cursor down to its header, or unfold it",
+}
+
+// Describe returns the help text for an action
+func (a Action) Describe() string {
+	return descriptions[a]
+}
+
+// Binding lists every key bound to one action
+type Binding struct {
+	Action Action
+	Keys   []string
+}
+
+// Bindings groups the keymap by action, in action order, with keys sorted
+func (km Keymap) Bindings() []Binding {
+	byAction := make(map[Action][]string)
+	for k, a := range km {
+		if a != None {
+			byAction[a] = append(byAction[a], k)
+		}
+	}
+
+	var result []Binding
+	for a := None + 1; a < actionCount; a++ {
+		if ks, ok := byAction[a]; ok {
+			sort.Strings(ks)
+			result = append(result, Binding{Action: a, Keys: ks})
+		}
+	}
+	return result
+}
+
+// ModeBinding documents a key that only applies in a specific mode
+type ModeBinding struct {
+	Mode string
+	Key  string
+	Desc string
+}
+
+// ModeBindings documents keys handled by modes outside the keymap
+// (search input, name filter, modals). Keep in sync with those handlers.
+var ModeBindings = []ModeBinding{
+	{Mode: "search", Key: "enter", Desc: "Next match in current diff"},
+	{Mode: "search", Key: "up/down", Desc: "Navigate matching files"},
+	{Mode: "search", Key: "alt+enter", Desc: "Comment on every matching line, in every matching file"},
+	{Mode: "search", Key: "alt+f", Desc: "Comment on every matching file as a whole"},
+	{Mode: "search", Key: "esc", Desc: "Close search"},
+	{Mode: "filter", Key: "up/down", Desc: "Navigate filtered files"},
+	{Mode: "filter", Key: "enter", Desc: "Keep filter and return to navigation"},
+	{Mode: "filter", Key: "esc", Desc: "Clear filter"},
+	{Mode: "feedback", Key: "enter", Desc: "Save feedback"},
+	{Mode: "feedback", Key: "ctrl+j", Desc: "Insert newline"},
+	{Mode: "feedback", Key: "tab/shift+tab", Desc: "Cycle the comment label (with comment_labels on)"},
+	{Mode: "feedback", Key: "esc", Desc: "Cancel"},
+	{Mode: "comments", Key: "enter", Desc: "Edit the selected comment, or add one"},
+	{Mode: "comments", Key: "a", Desc: "Add another comment on the line"},
+	{Mode: "comments", Key: "d d", Desc: "Delete the selected comment"},
+	{Mode: "comments", Key: "esc", Desc: "Cancel"},
+	{Mode: "earlier", Key: "enter", Desc: "Go to the selected earlier comment"},
+	{Mode: "earlier", Key: "esc", Desc: "Close"},
+	{Mode: "plugins", Key: "enter", Desc: "Run the selected action or open the panel"},
+	{Mode: "plugins", Key: "esc", Desc: "Close"},
+	{Mode: "hunk", Key: "enter/1-9", Desc: "Run the selected or numbered action"},
+	{Mode: "hunk", Key: "esc", Desc: "Close"},
+	{Mode: "preferences", Key: "up/down", Desc: "Select setting"},
+	{Mode: "preferences", Key: "left/right", Desc: "Change value"},
+	{Mode: "preferences", Key: "enter", Desc: "Save to config file"},
+	{Mode: "preferences", Key: "esc", Desc: "Cancel"},
+}
+
+// Keymap maps key strings (as reported by tea.KeyMsg.String) to actions
+type Keymap map[string]Action
+
+// DefaultKeymap returns the built-in bindings: arrows for files,
+// emacs-style keys for the diff, plus vim-style j/k/g/G aliases
+func DefaultKeymap() Keymap {
+	return Keymap{
+		"q":      Quit,
+		"ctrl+c": Quit,
+		"/":      Search,
+		"enter":  Feedback,
+		"up":     FileUp,
+		"down":   FileDown,
+		"ctrl+p": LineUp,
+		"ctrl+n": LineDown,
+		"k":      LineUp,
+		"j":      LineDown,
+		"alt+v":  PageUp,
+		"ctrl+v": PageDown,
+		"ctrl+u": HalfPageUp,
+		"ctrl+d": HalfPageDown,
+		"alt+<":  Top,
+		"g":      Top,
+		"alt+>":  Bottom,
+		"G":      Bottom,
+		"%":      Percent,
+		"f":      FilterFiles,
+		",":      Preferences,
+		"i":      ToggleInvisibles,
+		"s":      SwitchScope,
+		"c":      Commit,
+		"n":      Notes,
+		"D":      Describe,
+		"L":      ToggleLockfile,
+		"a":      APISummary,
+		"O":      ToggleOutputs,
+		"p":      Praise,
+		"r":      Resolve,
+		"R":      EarlierComments,
+		"x":      ConflictsOnly,
+		"m":      ToggleDescription,
+		"C":      RepeatComment,
+		"l":      History,
+		"ctrl+r": Reload,
+		"b":      Base,
+		"P":      Plugins,
+		"w":      ToggleWhitespace,
+		"I":      ToggleIgnored,
+		".":      HunkMenu,
+		"z":      ToggleFold,
+	}
+}
+
+// VimKeymap returns the default bindings plus vim-flavored extras:
+// J/K for files and ctrl+f/ctrl+b for full pages
+func VimKeymap() Keymap {
+	km := DefaultKeymap()
+	km["J"] = FileDown
+	km["K"] = FileUp
+	km["ctrl+f"] = PageDown
+	km["ctrl+b"] = PageUp
+	return km
+}
+
+// ProfileKeymap returns the keymap for a named profile ("default" or "vim").
+// Unknown names get the default keymap.
+func ProfileKeymap(name string) Keymap {
+	if name == "vim" {
+		return VimKeymap()
+	}
+	return DefaultKeymap()
+}
+
+// maxCount bounds the count prefix so a stuck key can't overflow it
+const maxCount = 99999
+
+// Router resolves key presses to actions and accumulates numeric
+// count prefixes (e.g. "15" followed by ctrl+n moves 15 lines)
+type Router struct {
+	keymap Keymap
+	count  int // Pending count prefix, 0 if none
+}
+
+// NewRouter creates a router for the given keymap
+func NewRouter(km Keymap) *Router {
+	return &Router{keymap: km}
+}
+
+// Route resolves a key press. Digits are consumed as a count prefix and
+// return None. For any other key the pending count is returned alongside
+// the action (0 if no prefix was typed) and then cleared.
+func (r *Router) Route(msg tea.KeyMsg) (Action, int) {
+	key := msg.String()
+
+	if d, ok := digit(key); ok && (d != 0 || r.count > 0) {
+		r.count = r.count*10 + d
+		if r.count > maxCount {
+			r.count = maxCount
+		}
+		return None, 0
+	}
+
+	count := r.count
+	r.count = 0
+
+	if key == "esc" {
+		return None, 0
+	}
+	return r.keymap[key], count
+}
+
+// SetKeymap replaces the active bindings
+func (r *Router) SetKeymap(km Keymap) {
+	r.keymap = km
+	r.count = 0
+}
+
+// Pending returns the count prefix typed so far, 0 if none
+func (r *Router) Pending() int {
+	return r.count
+}
+
+// Reset discards any pending count prefix
+func (r *Router) Reset() {
+	r.count = 0
+}
+
+// digit reports whether key is a single decimal digit
+func digit(key string) (int, bool) {
+	if len(key) != 1 || key[0] < '0' || key[0] > '9' {
+		return 0, false
+	}
+	d, err := strconv.Atoi(key)
+	return d, err == nil
+}
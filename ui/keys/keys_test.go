@@ -0,0 +1,155 @@
+package keys
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "ctrl+n":
+		return tea.KeyMsg{Type: tea.KeyCtrlN}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestRouter_NoCount(t *testing.T) {
+	r := NewRouter(DefaultKeymap())
+
+	action, count := r.Route(key("ctrl+n"))
+	if action != LineDown {
+		t.Errorf("expected LineDown, got %v", action)
+	}
+	if count != 0 {
+		t.Errorf("expected no count, got %d", count)
+	}
+}
+
+func TestRouter_CountPrefix(t *testing.T) {
+	r := NewRouter(DefaultKeymap())
+
+	for _, k := range []string{"1", "5"} {
+		if action, _ := r.Route(key(k)); action != None {
+			t.Fatalf("digit %q should be consumed, got %v", k, action)
+		}
+	}
+	if r.Pending() != 15 {
+		t.Errorf("expected pending 15, got %d", r.Pending())
+	}
+
+	action, count := r.Route(key("j"))
+	if action != LineDown || count != 15 {
+		t.Errorf("expected LineDown x15, got %v x%d", action, count)
+	}
+	if r.Pending() != 0 {
+		t.Errorf("count should be cleared after use, got %d", r.Pending())
+	}
+}
+
+func TestRouter_ZeroContinuesCount(t *testing.T) {
+	r := NewRouter(DefaultKeymap())
+
+	// A leading zero is not a count
+	r.Route(key("0"))
+	if r.Pending() != 0 {
+		t.Errorf("leading 0 should not start a count, got %d", r.Pending())
+	}
+
+	r.Route(key("1"))
+	r.Route(key("0"))
+	action, count := r.Route(key("down"))
+	if action != FileDown || count != 10 {
+		t.Errorf("expected FileDown x10, got %v x%d", action, count)
+	}
+}
+
+func TestRouter_EscCancelsCount(t *testing.T) {
+	r := NewRouter(DefaultKeymap())
+
+	r.Route(key("4"))
+	if action, _ := r.Route(key("esc")); action != None {
+		t.Errorf("esc should not trigger an action, got %v", action)
+	}
+
+	_, count := r.Route(key("j"))
+	if count != 0 {
+		t.Errorf("count should be discarded after esc, got %d", count)
+	}
+}
+
+func TestRouter_CountIsBounded(t *testing.T) {
+	r := NewRouter(DefaultKeymap())
+
+	for i := 0; i < 10; i++ {
+		r.Route(key("9"))
+	}
+	if r.Pending() != maxCount {
+		t.Errorf("expected count capped at %d, got %d", maxCount, r.Pending())
+	}
+}
+
+func TestRouter_UnboundKeyClearsCount(t *testing.T) {
+	r := NewRouter(DefaultKeymap())
+
+	r.Route(key("3"))
+	if action, _ := r.Route(key("y")); action != None {
+		t.Errorf("unbound key should map to None, got %v", action)
+	}
+	if r.Pending() != 0 {
+		t.Errorf("unbound key should clear count, got %d", r.Pending())
+	}
+}
+
+func TestRouter_PercentWithCount(t *testing.T) {
+	r := NewRouter(DefaultKeymap())
+
+	r.Route(key("5"))
+	r.Route(key("0"))
+	action, count := r.Route(key("%"))
+	if action != Percent || count != 50 {
+		t.Errorf("expected Percent x50, got %v x%d", action, count)
+	}
+}
+
+func TestProfileKeymap(t *testing.T) {
+	vim := ProfileKeymap("vim")
+	if vim["J"] != FileDown || vim["K"] != FileUp {
+		t.Error("vim profile should bind J/K to file navigation")
+	}
+	if vim["ctrl+n"] != LineDown {
+		t.Error("vim profile should keep default bindings")
+	}
+
+	def := ProfileKeymap("unknown")
+	if _, ok := def["J"]; ok {
+		t.Error("unknown profile should fall back to the default keymap")
+	}
+}
+
+func TestEveryActionIsDescribed(t *testing.T) {
+	for a := None + 1; a < actionCount; a++ {
+		if a.Describe() == "" {
+			t.Errorf("action %d has no description", a)
+		}
+	}
+}
+
+func TestBindingsGroupsKeys(t *testing.T) {
+	km := Keymap{"j": LineDown, "ctrl+n": LineDown, "q": Quit}
+
+	bindings := km.Bindings()
+	if len(bindings) != 2 {
+		t.Fatalf("expected 2 bindings, got %d", len(bindings))
+	}
+	if bindings[0].Action != Quit {
+		t.Errorf("expected bindings in action order, got %v first", bindings[0].Action)
+	}
+	if got := bindings[1].Keys; len(got) != 2 || got[0] != "ctrl+n" || got[1] != "j" {
+		t.Errorf("expected sorted keys [ctrl+n j], got %v", got)
+	}
+}
@@ -0,0 +1,210 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// onboardingStep is one question in the first-run wizard
+type onboardingStep struct {
+	key      string   // Config key the answer is stored under
+	provider string   // Forge whose token the answer is, kept out of the config
+	prompt   string   // Question shown to the user
+	hint     string   // Extra explanation under the question
+	choices  []string // Allowed answers, nil for free text
+	secret   bool     // Mask free-text input
+}
+
+// Onboarding is a short first-run wizard that builds the initial config
+type Onboarding struct {
+	cfg     config.Config
+	tokens  map[string]string // Tokens entered, by provider
+	steps   []onboardingStep
+	step    int
+	choice  int
+	input   textinput.Model
+	width   int
+	height  int
+	done    bool
+	skipped bool
+}
+
+// NewOnboarding creates the wizard, starting from the default config
+func NewOnboarding() *Onboarding {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.CharLimit = 256
+
+	o := &Onboarding{
+		cfg:    config.Default(),
+		tokens: map[string]string{},
+		steps: []onboardingStep{
+			{key: "keymap", prompt: "Which keybindings do you prefer?", hint: "default: arrows + emacs keys, vim: adds J/K and ctrl+f/b", choices: config.Keymaps},
+			{key: "theme", prompt: "Pick a color theme", hint: "Use light on terminals with a light background", choices: config.Themes},
+			{key: "output_dir", prompt: "Where should feedback files go when no path is given?", hint: "Leave as-is to keep the default"},
+			{provider: "github", prompt: "GitHub token (optional)", hint: "Press enter to skip. Saved like tcr auth login, not in the config", secret: true},
+			{provider: "gitlab", prompt: "GitLab token (optional)", hint: "Press enter to skip. Saved like tcr auth login, not in the config", secret: true},
+		},
+		input: ti,
+	}
+	o.enterStep()
+	return o
+}
+
+// Config returns the answers collected so far
+func (o *Onboarding) Config() config.Config {
+	return o.cfg
+}
+
+// Tokens returns the tokens entered, by provider, for the credential
+// store rather than the config
+func (o *Onboarding) Tokens() map[string]string {
+	return o.tokens
+}
+
+// Skipped reports whether the user dismissed the wizard with esc
+func (o *Onboarding) Skipped() bool {
+	return o.skipped
+}
+
+// enterStep prepares the input state for the current step
+func (o *Onboarding) enterStep() {
+	st := o.steps[o.step]
+	o.choice = 0
+	o.input.Blur()
+	if st.choices != nil {
+		for i, c := range st.choices {
+			if c == o.cfg.Get(st.key) {
+				o.choice = i
+			}
+		}
+		return
+	}
+	if st.provider != "" {
+		o.input.SetValue(o.tokens[st.provider])
+	} else {
+		o.input.SetValue(o.cfg.Get(st.key))
+	}
+	o.input.CursorEnd()
+	if st.secret {
+		o.input.EchoMode = textinput.EchoPassword
+	} else {
+		o.input.EchoMode = textinput.EchoNormal
+	}
+	o.input.Focus()
+}
+
+func (o *Onboarding) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (o *Onboarding) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		o.width = msg.Width
+		o.height = msg.Height
+		return o, nil
+
+	case tea.KeyMsg:
+		st := o.steps[o.step]
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			o.skipped = true
+			return o, tea.Quit
+
+		case "enter":
+			if st.choices != nil {
+				_ = o.cfg.Set(st.key, st.choices[o.choice])
+				if st.key == "theme" {
+					theme.Apply(o.cfg.Theme)
+				}
+			} else if value := strings.TrimSpace(o.input.Value()); st.provider != "" {
+				if value != "" {
+					o.tokens[st.provider] = value
+				} else {
+					delete(o.tokens, st.provider)
+				}
+			} else if value != "" {
+				_ = o.cfg.Set(st.key, value)
+			}
+			if o.step == len(o.steps)-1 {
+				o.done = true
+				return o, tea.Quit
+			}
+			o.step++
+			o.enterStep()
+			return o, nil
+		}
+
+		if st.choices != nil {
+			switch msg.String() {
+			case "up", "left", "k":
+				o.choice = (o.choice - 1 + len(st.choices)) % len(st.choices)
+			case "down", "right", "j", "tab":
+				o.choice = (o.choice + 1) % len(st.choices)
+			}
+			return o, nil
+		}
+
+		var cmd tea.Cmd
+		o.input, cmd = o.input.Update(msg)
+		return o, cmd
+	}
+	return o, nil
+}
+
+func (o *Onboarding) View() string {
+	if o.done || o.skipped {
+		return ""
+	}
+
+	st := o.steps[o.step]
+	var lines []string
+	lines = append(lines, theme.DimmedStyle.Render(fmt.Sprintf("Welcome to tcr! Step %d of %d", o.step+1, len(o.steps))))
+	lines = append(lines, "")
+	lines = append(lines, theme.NormalItemStyle.Bold(true).Render(st.prompt))
+	lines = append(lines, theme.DimmedStyle.Render(st.hint))
+	lines = append(lines, "")
+
+	if st.choices != nil {
+		for i, c := range st.choices {
+			if i == o.choice {
+				lines = append(lines, theme.SelectedItemStyle.Render("> "+c))
+			} else {
+				lines = append(lines, theme.NormalItemStyle.Render("  "+c))
+			}
+		}
+	} else {
+		lines = append(lines, o.input.View())
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, theme.HelpDescStyle.Render("enter next  esc skip setup"))
+
+	width := 64
+	if o.width > 0 && o.width < width {
+		width = o.width
+	}
+	height := len(lines) + 2
+	return borders.RenderFloatingBorder(strings.Join(lines, "\n"), "Setup", width, height)
+}
+
+// RunOnboarding shows the first-run wizard and returns the resulting config
+// and the tokens entered, by provider, to store with the other credentials.
+// If the user skips it, defaults are returned so the wizard isn't shown again.
+func RunOnboarding() (config.Config, map[string]string, error) {
+	o := NewOnboarding()
+	if _, err := tea.NewProgram(o).Run(); err != nil {
+		return config.Default(), nil, err
+	}
+	if o.Skipped() {
+		return config.Default(), nil, nil
+	}
+	return o.Config(), o.Tokens(), nil
+}
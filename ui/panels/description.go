@@ -0,0 +1,84 @@
+package panels
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// DescriptionPanel shows the commit message or change description of the
+// revisions reviewed, above the other panels: the first line when
+// collapsed, as much as fits when expanded
+type DescriptionPanel struct {
+	BasePanel
+	lines    []string
+	expanded bool
+}
+
+// NewDescriptionPanel creates an empty, collapsed description panel
+func NewDescriptionPanel() *DescriptionPanel {
+	return &DescriptionPanel{BasePanel: NewBasePanel("Description", "change description")}
+}
+
+// SetDescription sets the text shown, "" for none
+func (p *DescriptionPanel) SetDescription(text string) {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\t", "    ")
+	p.lines = nil
+	if text != "" {
+		p.lines = strings.Split(text, "\n")
+	}
+}
+
+// Empty reports whether there's no description to show
+func (p *DescriptionPanel) Empty() bool {
+	return len(p.lines) == 0
+}
+
+// Expanded reports whether the whole description is shown
+func (p *DescriptionPanel) Expanded() bool {
+	return p.expanded
+}
+
+// SetExpanded shows the whole description, or only its first line
+func (p *DescriptionPanel) SetExpanded(expanded bool) {
+	p.expanded = expanded
+}
+
+// Rows returns the height the panel needs, borders included, up to max:
+// none when empty, three rows collapsed
+func (p *DescriptionPanel) Rows(max int) int {
+	switch {
+	case p.Empty():
+		return 0
+	case !p.expanded:
+		return 3
+	case len(p.lines)+2 > max:
+		return max
+	}
+	return len(p.lines) + 2
+}
+
+func (p *DescriptionPanel) View() string {
+	width := p.ContentWidth()
+	if !p.expanded {
+		line := p.lines[0]
+		if more := len(p.lines) - 1; more > 0 {
+			hint := theme.DimmedStyle.Render(fmt.Sprintf(" (+%d lines · m shows all)", more))
+			return p.RenderFrame(ansi.Truncate(line, width-ansi.StringWidth(hint), "…") + hint)
+		}
+		return p.RenderFrame(ansi.Truncate(line, width, "…"))
+	}
+
+	lines := p.lines
+	if height := p.ContentHeight(); len(lines) > height && height > 0 {
+		more := len(lines) - height + 1
+		lines = append(lines[:height-1:height-1], theme.DimmedStyle.Render(fmt.Sprintf("… %d more lines", more)))
+	}
+	rows := make([]string, len(lines))
+	for i, line := range lines {
+		rows[i] = ansi.Truncate(line, width, "…")
+	}
+	return p.RenderFrame(strings.Join(rows, "\n"))
+}
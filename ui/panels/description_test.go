@@ -0,0 +1,38 @@
+package panels
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestDescriptionPanel(t *testing.T) {
+	p := NewDescriptionPanel()
+	if !p.Empty() || p.Rows(10) != 0 {
+		t.Fatal("expected an empty panel to take no rows")
+	}
+
+	p.SetDescription("Fix the parser\n\nLong lines broke it.\nNow they don't.\n")
+	p.SetSize(60, p.Rows(10))
+	view := ansi.Strip(p.View())
+	if p.Rows(10) != 3 || !strings.Contains(view, "Fix the parser (+3 lines · m shows all)") || strings.Contains(view, "Long lines") {
+		t.Errorf("expected only the first line collapsed, got:\n%s", view)
+	}
+
+	p.SetExpanded(true)
+	if p.Rows(10) != 6 {
+		t.Errorf("expected the whole description's rows, got %d", p.Rows(10))
+	}
+	p.SetSize(60, p.Rows(10))
+	if view := ansi.Strip(p.View()); !strings.Contains(view, "Now they don't.") {
+		t.Errorf("expected the whole description expanded, got:\n%s", view)
+	}
+
+	// Too long for the space given: the rest is counted
+	p.SetSize(60, p.Rows(4))
+	view = ansi.Strip(p.View())
+	if !strings.Contains(view, "Fix the parser") || !strings.Contains(view, "… 3 more lines") {
+		t.Errorf("expected the overflow counted, got:\n%s", view)
+	}
+}
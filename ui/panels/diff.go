@@ -3,28 +3,38 @@ package panels
 import (
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/textinput"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/highlight"
 	"github.com/gerunddev/tcr/ui/theme"
+	"github.com/gerunddev/tcr/worddiff"
 	"github.com/mattn/go-runewidth"
 )
 
 // ansiRegex matches ANSI escape sequences
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
+// sgrPrefix matches an SGR (color/style) sequence at the start of a string
+var sgrPrefix = regexp.MustCompile(`^\x1b\[[0-9;]*m`)
+
 // SearchState holds the state for diff search
 type SearchState struct {
-	active          bool         // Whether search mode is active
-	matches         []int        // Line indices that match (0-indexed)
-	matchSet        map[int]bool // O(1) lookup for matched lines
-	currentMatch    int          // Index into matches slice (-1 if no matches)
-	input           textinput.Model
-	externalInputView string     // When set, use this for rendering instead of local input
-	fzfError        string       // Error message if fzf unavailable
+	active            bool         // Whether search mode is active
+	matches           []int        // Line indices that match (0-indexed)
+	matchSet          map[int]bool // O(1) lookup for matched lines
+	currentMatch      int          // Index into matches slice (-1 if no matches)
+	input             textinput.Model
+	externalInputView string // When set, use this for rendering instead of local input
+	fzfError          string // Error message if fzf unavailable
 }
 
 // NewSearchState creates a new search state
@@ -159,12 +169,33 @@ func (s *SearchState) SetExternalInputView(view string) {
 // DiffPanel shows diff content with a cursor for line selection
 type DiffPanel struct {
 	BasePanel
-	viewport    viewport.Model
-	lines       []string     // Raw diff lines
-	cursorLine  int          // Current cursor position (0-indexed)
-	filePath    string       // Currently displayed file
-	ready       bool
-	searchState *SearchState // Search state
+	viewport      virtualViewport
+	lines         []string // Raw diff lines
+	cursorLine    int      // Current cursor position (0-indexed)
+	filePath      string   // Currently displayed file
+	empty         string   // Shown when there's no diff at all, instead of a bare notice
+	ready         bool
+	searchState   *SearchState   // Search state
+	wrap          bool           // Wrap long lines instead of truncating
+	invisibles    bool           // Draw glyphs for tabs, trailing and odd spaces
+	findings      map[int]string // Finding message per flagged line
+	noise         map[int]bool   // Lines in formatting-only hunks
+	annotations   map[int]string // Existing review comments per line
+	comments      map[int]int    // Comments made in this review per line, marked in the gutter
+	noiseHunks    int
+	foldedHunks   int                     // Hunks folded down to their header
+	todos         findings.Markers        // TODO and FIXME markers the file's change adds and removes
+	ages          map[int]time.Duration   // How long ago each context line was last changed, if blamed
+	wordDiff      bool                    // Highlight the words changed in paired lines
+	pairs         map[int]int             // Partner of each paired removed or added line, once needed
+	words         map[int][]worddiff.Span // Changed words per paired line, in its display text
+	highlights    []highlight.Rule        // Patterns styled wherever they match
+	rowStarts     []int                   // First display row of each line when wrapping, nil otherwise
+	totalRowCount int                     // Display rows across all lines
+
+	// Styled rows per line near the viewport, reused while the key matches
+	renderCache map[int]renderedLine
+	renderKey   renderKey
 }
 
 // NewDiffPanel creates a new diff panel
@@ -180,9 +211,15 @@ func (p *DiffPanel) SetDiff(filePath, content string) {
 	p.filePath = filePath
 	p.lines = strings.Split(content, "\n")
 	p.cursorLine = 0
-
-	// Update title to show file path
-	p.SetTitle("Diff: " + filePath)
+	p.renderCache = nil
+	p.findings = nil
+	p.noise, p.noiseHunks = nil, 0
+	p.foldedHunks = 0
+	p.todos = findings.Markers{}
+	p.ages = nil
+	p.annotations = nil
+	p.comments = nil
+	p.pairs, p.words = nil, nil
 
 	// Clear search matches (app will re-apply if needed)
 	if p.searchState.active {
@@ -191,24 +228,36 @@ func (p *DiffPanel) SetDiff(filePath, content string) {
 		p.searchState.currentMatch = -1
 	}
 
-	if p.ready {
-		p.viewport.SetContent(p.renderContent())
-		p.viewport.GotoTop()
-	}
+	p.layout()
+	p.viewport.GotoTop()
+}
+
+// SetFilePath changes the file the diff is attributed to without reloading
+// it, for a diff that spans several files
+func (p *DiffPanel) SetFilePath(filePath string) {
+	p.filePath = filePath
 }
 
 // ClearDiff clears the diff content
+// SetEmptyMessage sets what's shown when there's no file to diff, such as
+// why nothing changed; "" keeps the plain notice
+func (p *DiffPanel) SetEmptyMessage(text string) {
+	p.empty = text
+}
+
 func (p *DiffPanel) ClearDiff() {
 	p.filePath = ""
 	p.lines = nil
 	p.cursorLine = 0
+	p.renderCache = nil
+	p.findings = nil
+	p.noise, p.noiseHunks = nil, 0
+	p.annotations = nil
+	p.comments = nil
 	p.searchState.Reset()
-	p.SetTitle("Diff")
 
-	if p.ready {
-		p.viewport.SetContent("")
-		p.viewport.GotoTop()
-	}
+	p.layout()
+	p.viewport.GotoTop()
 }
 
 func (p *DiffPanel) Init() tea.Cmd {
@@ -234,23 +283,21 @@ func (p *DiffPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Emacs-style navigation
 		case "ctrl+n":
-			p.cursorDown()
+			p.MoveCursor(1)
 		case "ctrl+p":
-			p.cursorUp()
+			p.MoveCursor(-1)
 		case "ctrl+v":
-			p.pageDown()
+			p.MovePage(1)
 		case "alt+v":
-			p.pageUp()
+			p.MovePage(-1)
 		case "alt+<":
-			p.gotoTop()
+			p.GotoTop()
 		case "alt+>":
-			p.gotoBottom()
+			p.GotoBottom()
 		}
 
 		// Update viewport content after cursor moves
-		if p.ready {
-			p.viewport.SetContent(p.renderContent())
-		}
+		p.Refresh()
 	}
 
 	return p, nil
@@ -270,7 +317,6 @@ func (p *DiffPanel) handleSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			p.searchState.NextMatch()
 			p.cursorLine = p.searchState.CurrentMatchLine()
 			p.ensureCursorVisible()
-			p.viewport.SetContent(p.renderContent())
 		}
 		return p, nil
 
@@ -298,9 +344,6 @@ func (p *DiffPanel) ActivateSearch() {
 func (p *DiffPanel) DeactivateSearch() {
 	p.searchState.Reset()
 	p.updateViewportSize()
-	if p.ready {
-		p.viewport.SetContent(p.renderContent())
-	}
 }
 
 // SetSearchQuery updates the search query (called by App)
@@ -327,10 +370,6 @@ func (p *DiffPanel) SetSearchMatches(matches []int) {
 	} else {
 		p.searchState.currentMatch = -1
 	}
-
-	if p.ready {
-		p.viewport.SetContent(p.renderContent())
-	}
 }
 
 // SetSearchInputView sets the external input view for proper cursor rendering
@@ -346,9 +385,6 @@ func (p *DiffPanel) CycleNextMatch() bool {
 	p.searchState.NextMatch()
 	p.cursorLine = p.searchState.CurrentMatchLine()
 	p.ensureCursorVisible()
-	if p.ready {
-		p.viewport.SetContent(p.renderContent())
-	}
 	return true
 }
 
@@ -365,70 +401,261 @@ func (p *DiffPanel) CurrentMatchIndex() int {
 	return p.searchState.currentMatch + 1
 }
 
-func (p *DiffPanel) cursorUp() {
-	if p.cursorLine > 0 {
-		p.cursorLine--
-		p.ensureCursorVisible()
-	}
+// MoveCursor moves the cursor by delta lines, clamped to the diff bounds
+func (p *DiffPanel) MoveCursor(delta int) {
+	p.setCursor(p.cursorLine + delta)
 }
 
-func (p *DiffPanel) cursorDown() {
-	if p.cursorLine < len(p.lines)-1 {
-		p.cursorLine++
-		p.ensureCursorVisible()
-	}
+// MovePage moves the cursor by delta pages, clamped to the diff bounds
+func (p *DiffPanel) MovePage(delta int) {
+	p.setCursor(p.cursorLine + delta*p.ContentHeight())
 }
 
-func (p *DiffPanel) pageUp() {
-	pageSize := p.ContentHeight()
-	p.cursorLine -= pageSize
-	if p.cursorLine < 0 {
-		p.cursorLine = 0
+// MoveHalfPage moves the cursor by delta half-pages, clamped to the diff bounds
+func (p *DiffPanel) MoveHalfPage(delta int) {
+	half := p.ContentHeight() / 2
+	if half < 1 {
+		half = 1
 	}
-	p.ensureCursorVisible()
+	p.setCursor(p.cursorLine + delta*half)
 }
 
-func (p *DiffPanel) pageDown() {
-	pageSize := p.ContentHeight()
-	p.cursorLine += pageSize
-	if p.cursorLine >= len(p.lines) {
-		p.cursorLine = len(p.lines) - 1
+// GotoPercent moves the cursor to pct percent of the way through the diff,
+// rounding up like vim's N% so that 100 always lands on the last line
+func (p *DiffPanel) GotoPercent(pct int) {
+	if pct < 0 {
+		pct = 0
 	}
-	if p.cursorLine < 0 {
-		p.cursorLine = 0
+	if pct > 100 {
+		pct = 100
 	}
-	p.ensureCursorVisible()
+	p.setCursor((pct*len(p.lines)+99)/100 - 1)
 }
 
-func (p *DiffPanel) gotoTop() {
+// GotoLine moves the cursor to the given diff line (0-indexed), clamped
+func (p *DiffPanel) GotoLine(line int) {
+	p.setCursor(line)
+}
+
+// ScrollOffset returns the first display row in view
+func (p *DiffPanel) ScrollOffset() int {
+	return p.viewport.YOffset
+}
+
+// Restore puts the cursor and scroll back where CursorLine and
+// ScrollOffset reported them, clamped to the diff now shown
+func (p *DiffPanel) Restore(cursor, offset int) {
+	p.viewport.SetYOffset(offset)
+	p.setCursor(cursor)
+}
+
+// GotoTop moves the cursor to the first line
+func (p *DiffPanel) GotoTop() {
 	p.cursorLine = 0
 	p.viewport.GotoTop()
 }
 
-func (p *DiffPanel) gotoBottom() {
+// GotoBottom moves the cursor to the last line
+func (p *DiffPanel) GotoBottom() {
 	if len(p.lines) > 0 {
 		p.cursorLine = len(p.lines) - 1
 	}
 	p.viewport.GotoBottom()
 }
 
+// setCursor clamps line to the diff bounds and scrolls it into view
+func (p *DiffPanel) setCursor(line int) {
+	if line >= len(p.lines) {
+		line = len(p.lines) - 1
+	}
+	if line < 0 {
+		line = 0
+	}
+	p.cursorLine = line
+	p.ensureCursorVisible()
+}
+
+// Refresh re-clamps the viewport after external changes. Rows are styled
+// lazily in View, so there is nothing to re-render here.
+func (p *DiffPanel) Refresh() {
+	p.viewport.SetTotal(p.totalRows())
+}
+
 func (p *DiffPanel) ensureCursorVisible() {
-	if p.cursorLine < p.viewport.YOffset {
-		p.viewport.SetYOffset(p.cursorLine)
-	} else if p.cursorLine >= p.viewport.YOffset+p.viewport.Height {
-		p.viewport.SetYOffset(p.cursorLine - p.viewport.Height + 1)
+	first, last := p.cursorRows()
+	if first < p.viewport.YOffset {
+		p.viewport.SetYOffset(first)
+	} else if last >= p.viewport.YOffset+p.viewport.Height {
+		p.viewport.SetYOffset(last - p.viewport.Height + 1)
+	}
+}
+
+// SetFindings flags diff lines with problems found in them. Flagged lines
+// are highlighted and the cursor line's messages are shown in the title.
+func (p *DiffPanel) SetFindings(fs []findings.Finding) {
+	p.findings = nil
+	if len(fs) > 0 {
+		p.findings = make(map[int]string, len(fs))
+		for _, f := range fs {
+			if prev, ok := p.findings[f.Line]; ok {
+				p.findings[f.Line] = prev + " · " + f.Message
+			} else {
+				p.findings[f.Line] = f.Message
+			}
+		}
+	}
+	p.renderCache = nil
+}
+
+// SetFormatNoise marks hunks that only change formatting, which are drawn
+// dimmed so substantive changes stand out
+func (p *DiffPanel) SetFormatNoise(ranges []findings.Range) {
+	p.noise = nil
+	p.noiseHunks = len(ranges)
+	if len(ranges) > 0 {
+		p.noise = make(map[int]bool)
+		for _, r := range ranges {
+			for i := r.Start; i < r.End; i++ {
+				p.noise[i] = true
+			}
+		}
+	}
+	p.renderCache = nil
+}
+
+// SetFoldedHunks sets how many hunks are folded down to their header,
+// shown in the title
+func (p *DiffPanel) SetFoldedHunks(n int) {
+	p.foldedHunks = n
+}
+
+// SetTODOs sets the TODO and FIXME markers counted in the file's change,
+// shown in the title
+func (p *DiffPanel) SetTODOs(m findings.Markers) {
+	p.todos = m
+}
+
+// Context lines changed within recentAge are drawn brighter, and those
+// left alone for oldAge faded
+const (
+	recentAge = 30 * 24 * time.Hour
+	oldAge    = 365 * 24 * time.Hour
+)
+
+// SetLineAges tints context lines by how long ago they were last changed,
+// by line index; nil drops the tint
+func (p *DiffPanel) SetLineAges(ages map[int]time.Duration) {
+	p.ages = ages
+	p.renderCache = nil
+}
+
+// SetAnnotations attaches review comments made elsewhere to diff lines.
+// Annotated lines are highlighted and the cursor line's comments are shown
+// in the title.
+func (p *DiffPanel) SetAnnotations(annotations map[int]string) {
+	p.annotations = annotations
+	p.renderCache = nil
+}
+
+// SetComments marks lines with comments made in this review. The gutter
+// shows one mark per line, or a count when comments are stacked.
+func (p *DiffPanel) SetComments(comments map[int]int) {
+	gutterChanged := (len(comments) > 0) != (len(p.comments) > 0)
+	p.comments = comments
+	p.renderCache = nil
+	if gutterChanged {
+		p.layout()
+		p.ensureCursorVisible()
+	}
+}
+
+// commentGutterWidth is the width of the comment marks column
+const commentGutterWidth = 2
+
+// textWidth returns the width left for diff text: the content width less
+// the comment gutter, which is only drawn while there are comments
+func (p *DiffPanel) textWidth() int {
+	if len(p.comments) == 0 {
+		return p.ContentWidth()
+	}
+	return max(p.ContentWidth()-commentGutterWidth, 0)
+}
+
+// commentMark returns the gutter cell for line i's comments
+func (p *DiffPanel) commentMark(i int) string {
+	n := p.comments[i]
+	switch {
+	case n == 0:
+		return strings.Repeat(" ", commentGutterWidth)
+	case n == 1:
+		return theme.CommentMarkStyle.Render("●") + " "
+	case n < 10:
+		return theme.CommentMarkStyle.Render(fmt.Sprint(n)) + " "
+	}
+	return theme.CommentMarkStyle.Render("+") + " "
+}
+
+// FindingCount returns the number of flagged lines in the current diff
+func (p *DiffPanel) FindingCount() int {
+	return len(p.findings)
+}
+
+// updateTitle shows the file path plus the finding under the cursor, or
+// how many findings the diff has
+func (p *DiffPanel) updateTitle() {
+	if p.filePath == "" {
+		p.SetTitle("Diff")
+		return
+	}
+
+	title := "Diff: " + p.filePath
+	if msg, ok := p.findings[p.cursorLine]; ok {
+		title += " ⚠ " + msg
+	} else if n := len(p.findings); n == 1 {
+		title += " ⚠ 1 finding"
+	} else if n > 1 {
+		title += fmt.Sprintf(" ⚠ %d findings", n)
+	}
+	if msg, ok := p.annotations[p.cursorLine]; ok {
+		title += " 💬 " + msg
+	} else if n := len(p.annotations); n == 1 {
+		title += " · 💬 1 commented line"
+	} else if n > 1 {
+		title += fmt.Sprintf(" · 💬 %d commented lines", n)
 	}
+	if p.noiseHunks == 1 {
+		title += " · 1 formatting-only hunk"
+	} else if p.noiseHunks > 1 {
+		title += fmt.Sprintf(" · %d formatting-only hunks", p.noiseHunks)
+	}
+	if p.foldedHunks == 1 {
+		title += " · 1 hunk folded"
+	} else if p.foldedHunks > 1 {
+		title += fmt.Sprintf(" · %d hunks folded", p.foldedHunks)
+	}
+	if todos := p.todos.String(); todos != "" {
+		title += " · " + todos
+	}
+	p.SetTitle(title)
 }
 
 func (p *DiffPanel) View() string {
+	p.updateTitle()
 	if !p.ready {
 		return p.RenderFrame("Loading...")
 	}
 	if len(p.lines) == 0 || (len(p.lines) == 1 && p.lines[0] == "") {
-		return p.RenderFrame(theme.DimmedStyle.Render("No diff to show"))
+		msg := "No diff to show"
+		switch {
+		case p.filePath != "":
+			msg = "No changes to show in " + p.filePath
+		case p.empty != "":
+			msg = p.empty
+		}
+		return p.RenderFrame(theme.DimmedStyle.Width(p.ContentWidth()).Render(msg))
 	}
 
-	content := p.viewport.View()
+	content := p.renderWindow()
 
 	// Add search bar if active
 	if p.searchState.active {
@@ -492,7 +719,8 @@ func (p *DiffPanel) updateViewportSize() {
 		contentHeight-- // Reserve one line for search bar
 	}
 	p.viewport.Height = contentHeight
-	p.viewport.SetContent(p.renderContent())
+	p.viewport.SetTotal(p.totalRows())
+	p.ensureCursorVisible()
 }
 
 // SetSize initializes or resizes the viewport
@@ -507,64 +735,494 @@ func (p *DiffPanel) SetSize(width, height int) {
 		contentHeight--
 	}
 
-	if !p.ready {
-		p.viewport = viewport.New(contentWidth, contentHeight)
-		p.viewport.SetContent(p.renderContent())
-		p.ready = true
-	} else {
-		p.viewport.Width = contentWidth
-		p.viewport.Height = contentHeight
-		p.viewport.SetContent(p.renderContent())
-	}
+	p.viewport.Width = contentWidth
+	p.viewport.Height = contentHeight
+	p.ready = true
+	p.layout()
 
 	// Update search input width
 	p.searchState.SetWidth(contentWidth)
 }
 
-func (p *DiffPanel) renderContent() string {
-	if len(p.lines) == 0 {
+// lineState is the per-line input to styling besides the text itself
+type lineState uint8
+
+const (
+	stateCursor lineState = 1 << iota
+	stateCurrentMatch
+	stateOtherMatch
+)
+
+// renderedLine caches the styled rows of one diff line
+type renderedLine struct {
+	state lineState
+	rows  []string
+}
+
+// renderKey identifies what a render cache is valid for
+type renderKey struct {
+	width      int
+	wrap       bool
+	invisibles bool
+	theme      int
+}
+
+// overscan is how many lines beyond the viewport are styled ahead of time
+const overscan = 20
+
+// layout recomputes the display row positions after the content, width or
+// wrapping changes. Without wrapping every line is one row and rowStarts
+// stays nil.
+func (p *DiffPanel) layout() {
+	p.rowStarts = nil
+	p.totalRowCount = len(p.lines)
+
+	if p.wrap && len(p.lines) > 0 {
+		width := p.textWidth()
+		p.rowStarts = make([]int, len(p.lines))
+		rows := 0
+		for i, line := range p.lines {
+			p.rowStarts[i] = rows
+			rows += p.rowCount(line, width)
+		}
+		p.totalRowCount = rows
+	}
+
+	p.viewport.SetTotal(p.totalRowCount)
+}
+
+// rowCount returns how many display rows a line occupies
+func (p *DiffPanel) rowCount(line string, width int) int {
+	if !p.wrap || width <= 0 {
+		return 1
+	}
+	return len(p.splitRows(stripANSI(p.displayText(line)), width))
+}
+
+// totalRows returns the number of display rows in the diff
+func (p *DiffPanel) totalRows() int {
+	return p.totalRowCount
+}
+
+// rowOf returns the first display row of line i
+func (p *DiffPanel) rowOf(i int) int {
+	if p.rowStarts == nil {
+		return i
+	}
+	return p.rowStarts[i]
+}
+
+// lineAtRow returns the line containing display row r
+func (p *DiffPanel) lineAtRow(r int) int {
+	if p.rowStarts == nil {
+		return r
+	}
+	return sort.Search(len(p.rowStarts), func(i int) bool { return p.rowStarts[i] > r }) - 1
+}
+
+// renderWindow styles only the lines visible in the viewport and returns
+// exactly viewport.Height rows
+func (p *DiffPanel) renderWindow() string {
+	height := p.viewport.Height
+	if len(p.lines) == 0 || height <= 0 {
 		return ""
 	}
 
-	contentWidth := p.ContentWidth()
-	var rendered []string
-
-	for i, line := range p.lines {
-		// Determine cursor/search state for this line
-		isCursorLine := (i == p.cursorLine)
-		isSearchActive := p.searchState.active && p.searchState.HasMatches()
-		isCurrentMatch := isSearchActive && p.searchState.IsCurrentMatch(i)
-		isOtherMatch := isSearchActive && p.searchState.IsLineMatched(i) && !isCurrentMatch
-
-		// Only strip ANSI for lines that need our styling (cursor/search lines)
-		// Other lines keep their original colors
-		needsOurStyling := isCursorLine || isCurrentMatch || isOtherMatch
-
-		var styledLine string
-		if needsOurStyling {
-			// Strip ANSI so our Reverse style takes effect
-			cleanLine := stripANSI(line)
-			truncated := p.truncateLine(cleanLine, contentWidth)
-			padded := padToWidth(truncated, contentWidth)
-			style := p.getLineStyle(cleanLine, isCursorLine, isCurrentMatch, isOtherMatch)
-			styledLine = style.Width(contentWidth).Render(padded)
+	contentWidth := p.textWidth()
+	key := renderKey{width: contentWidth, wrap: p.wrap, invisibles: p.invisibles, theme: theme.Generation()}
+	if key != p.renderKey || p.renderCache == nil {
+		p.renderKey = key
+		p.renderCache = make(map[int]renderedLine)
+	}
+
+	first := p.lineAtRow(p.viewport.YOffset)
+	if first < 0 {
+		first = 0
+	}
+	skip := p.viewport.YOffset - p.rowOf(first)
+
+	rows := make([]string, 0, height+skip)
+	last := first
+	for i := first; i < len(p.lines) && len(rows) < height+skip; i++ {
+		rows = append(rows, p.renderLine(i, contentWidth)...)
+		last = i
+	}
+	rows = rows[skip:]
+	if len(rows) > height {
+		rows = rows[:height]
+	}
+
+	// Style a few lines either side so scrolling reuses cached rows
+	lo, hi := first-overscan, last+overscan
+	for i := max(lo, 0); i < first; i++ {
+		p.renderLine(i, contentWidth)
+	}
+	for i := last + 1; i <= hi && i < len(p.lines); i++ {
+		p.renderLine(i, contentWidth)
+	}
+	p.evictRenderCache(lo, hi)
+
+	var b strings.Builder
+	b.Grow(height * (contentWidth + 16))
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(row)
+	}
+	return b.String()
+}
+
+// evictRenderCache drops cached lines outside [lo, hi] once the cache has
+// grown well beyond the window, keeping memory flat on huge diffs
+func (p *DiffPanel) evictRenderCache(lo, hi int) {
+	if len(p.renderCache) <= 4*(hi-lo+1) {
+		return
+	}
+	for i := range p.renderCache {
+		if i < lo || i > hi {
+			delete(p.renderCache, i)
+		}
+	}
+}
+
+// renderLine returns the styled display rows for line i, reusing the cached
+// rows when the line's cursor/search state hasn't changed
+func (p *DiffPanel) renderLine(i, contentWidth int) []string {
+	var state lineState
+	if i == p.cursorLine {
+		state |= stateCursor
+	}
+	if p.searchState.active && p.searchState.HasMatches() {
+		if p.searchState.IsCurrentMatch(i) {
+			state |= stateCurrentMatch
+		} else if p.searchState.IsLineMatched(i) {
+			state |= stateOtherMatch
+		}
+	}
+
+	if cached, ok := p.renderCache[i]; ok && cached.state == state {
+		return cached.rows
+	}
+
+	line, style, marks := p.styledLine(i, state)
+	rowStyle := style
+	if state != 0 {
+		rowStyle = style.Width(contentWidth)
+	}
+
+	// Row count must agree with layout, which measures the plain text
+	split := p.splitRows(line, contentWidth)
+	if n := p.rowCount(p.lines[i], contentWidth); len(split) != n {
+		split = append(split[:min(len(split), n)], make([]string, max(n-len(split), 0))...)
+	}
+
+	var rows []string
+	offset := 0
+	for r, row := range split {
+		if marks != nil {
+			// Styled piece by piece, so the padding takes the line's style
+			pad := strings.Repeat(" ", max(contentWidth-runewidth.StringWidth(row), 0))
+			styled := markRow(row, offset, marks, style)
+			offset += len(row)
+			row = styled + style.Render(pad)
 		} else {
-			// Keep original line with its colors, just pad for consistent width
-			truncated := p.truncateLine(line, contentWidth)
-			padded := padToWidth(truncated, contentWidth)
-			style := p.getLineStyle(line, false, false, false)
-			styledLine = style.Render(padded)
+			row = rowStyle.Render(padToWidth(row, contentWidth))
+		}
+		if len(p.comments) > 0 {
+			mark := strings.Repeat(" ", commentGutterWidth)
+			if r == 0 {
+				mark = p.commentMark(i)
+			}
+			row = mark + row
+		}
+		rows = append(rows, row)
+	}
+
+	p.renderCache[i] = renderedLine{state: state, rows: rows}
+	return rows
+}
+
+// styledLine returns the text line i is drawn as in a state, its style, and
+// the runs of it styled over that, if any
+func (p *DiffPanel) styledLine(i int, state lineState) (string, lipgloss.Style, []highlight.Match) {
+	line := p.displayText(p.lines[i])
+	plain := stripANSI(line)
+	style := p.getLineStyle(plain, state&stateCursor != 0, state&stateCurrentMatch != 0, state&stateOtherMatch != 0)
+	_, flagged := p.findings[i]
+	_, annotated := p.annotations[i]
+	if flagged {
+		style = style.Foreground(theme.ColorYellow).Bold(true)
+	} else if annotated {
+		style = style.Foreground(theme.ColorBlue).Underline(true)
+	} else if p.noise[i] {
+		style = style.Faint(true)
+	}
+	age, aged := p.ages[i]
+	if aged && !flagged && !annotated && !p.noise[i] && state&(stateCurrentMatch|stateOtherMatch) == 0 {
+		switch {
+		case age < recentAge:
+			style = style.Foreground(theme.ColorWhite)
+		case age >= oldAge:
+			style = style.Faint(true)
+		default:
+			aged = false
+		}
+	}
+
+	// Findings, annotations and noise outrank changed words and highlights
+	var marks []highlight.Match
+	if !flagged && !annotated && !p.noise[i] {
+		marks = p.marks(i, plain, style)
+	}
+
+	// Lines that need our styling (cursor, search, findings, annotations,
+	// noise, marks) drop the VCS colors so it takes effect; other lines
+	// keep them
+	if state != 0 || flagged || annotated || p.noise[i] || aged || marks != nil {
+		line = plain
+	}
+	return line, style, marks
+}
+
+// Print renders the whole diff styled as the panel draws it, for output
+// outside the panel such as to a pager: no border, cursor or scrolling.
+// A positive width wraps or cuts lines to it, per the wrap setting.
+func (p *DiffPanel) Print(width int) string {
+	var b strings.Builder
+	for i := range p.lines {
+		line, style, marks := p.styledLine(i, 0)
+		rows := []string{line}
+		if width > 0 {
+			rows = p.splitRows(line, width)
+		}
+		offset := 0
+		for _, row := range rows {
+			if marks != nil {
+				b.WriteString(markRow(row, offset, marks, style))
+				offset += len(row)
+			} else {
+				b.WriteString(style.Render(row))
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// splitRows breaks a line into the display rows it occupies: one truncated
+// row normally, or several rows when wrapping is enabled
+func (p *DiffPanel) splitRows(line string, width int) []string {
+	hasANSI := strings.Contains(line, "\x1b")
+	if !p.wrap || width <= 0 {
+		if hasANSI {
+			return []string{ansi.Truncate(line, width, "")}
+		}
+		return []string{p.truncateLine(line, width)}
+	}
+	if hasANSI {
+		return strings.Split(ansi.Hardwrap(line, width, true), "\n")
+	}
+
+	var rows []string
+	var row strings.Builder
+	rowWidth := 0
+	for _, r := range line {
+		rw := runewidth.RuneWidth(r)
+		if rowWidth+rw > width && rowWidth > 0 {
+			rows = append(rows, row.String())
+			row.Reset()
+			rowWidth = 0
+		}
+		row.WriteRune(r)
+		rowWidth += rw
+	}
+	return append(rows, row.String())
+}
+
+// displayText returns the text drawn for a raw diff line. Control
+// characters are always escaped so they can't move the terminal cursor or
+// restyle the rest of the panel.
+func (p *DiffPanel) displayText(line string) string {
+	line = EscapeControl(line)
+	if p.invisibles {
+		line = MarkInvisibles(line)
+	}
+	return line
+}
+
+// SetShowInvisibles toggles drawing visible glyphs for whitespace and
+// zero-width characters
+func (p *DiffPanel) SetShowInvisibles(show bool) {
+	p.invisibles = show
+	p.words = nil
+	p.layout()
+	p.ensureCursorVisible()
+}
+
+// ShowInvisibles reports whether invisible characters are being drawn
+func (p *DiffPanel) ShowInvisibles() bool {
+	return p.invisibles
+}
+
+// SetWordDiff toggles highlighting the words changed between each removed
+// line and the added line that replaced it
+func (p *DiffPanel) SetWordDiff(on bool) {
+	p.wordDiff = on
+	p.renderCache = nil
+}
+
+// SetHighlights sets the rules that style the text matching them
+func (p *DiffPanel) SetHighlights(rules []highlight.Rule) {
+	p.highlights = rules
+	p.renderCache = nil
+}
+
+// wordSpans returns the changed words of line i, as offsets into its plain
+// display text, or nil if it isn't paired with another line or the two
+// have too little in common
+func (p *DiffPanel) wordSpans(i int) []worddiff.Span {
+	if !p.wordDiff {
+		return nil
+	}
+	if p.pairs == nil {
+		// Only VCS colors need stripping, which most diffs don't have
+		plain := p.lines
+		if slices.ContainsFunc(p.lines, func(line string) bool { return strings.Contains(line, "\x1b") }) {
+			plain = make([]string, len(p.lines))
+			for j, line := range p.lines {
+				plain[j] = stripANSI(line)
+			}
+		}
+		p.pairs = worddiff.Pairs(plain)
+	}
+	partner, ok := p.pairs[i]
+	if !ok {
+		return nil
+	}
+	if spans, ok := p.words[i]; ok {
+		return spans
+	}
+
+	// Spans skip the +/- prefix, which always differs
+	body := func(j int) string { return stripANSI(p.displayText(p.lines[j]))[1:] }
+	old, new := min(i, partner), max(i, partner)
+	oldSpans, newSpans := worddiff.Changes(body(old), body(new))
+	if p.words == nil {
+		p.words = make(map[int][]worddiff.Span)
+	}
+	p.words[old], p.words[new] = shiftSpans(oldSpans, 1), shiftSpans(newSpans, 1)
+	return p.words[i]
+}
+
+// shiftSpans moves spans n bytes along
+func shiftSpans(spans []worddiff.Span, n int) []worddiff.Span {
+	for k := range spans {
+		spans[k].Start += n
+		spans[k].End += n
+	}
+	return spans
+}
+
+// marks returns the runs of line i to style over the line's style: its
+// changed words, then text matching the highlight rules
+func (p *DiffPanel) marks(i int, plain string, style lipgloss.Style) []highlight.Match {
+	var marks []highlight.Match
+	if spans := p.wordSpans(i); spans != nil {
+		emphasis := wordStyle(style, strings.HasPrefix(plain, "+"))
+		for _, span := range spans {
+			marks = append(marks, highlight.Match{Start: span.Start, End: span.End, Style: emphasis})
+		}
+	}
+	if len(p.highlights) > 0 && isContentLine(plain) {
+		// Rules match the code, after the +/- prefix, so ^ anchors work
+		for _, m := range highlight.Find(p.highlights, plain[1:]) {
+			m.Start, m.End = m.Start+1, m.End+1
+			marks = append(marks, m)
 		}
+	}
+	return marks
+}
 
-		rendered = append(rendered, styledLine)
+// isContentLine reports whether a diff line is an added, removed or
+// context line rather than a header
+func isContentLine(line string) bool {
+	if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+		return false
 	}
+	return strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") || strings.HasPrefix(line, " ")
+}
 
-	return strings.Join(rendered, "\n")
+// wordStyle is drawn over changed words: a background, or on lines that
+// already have one, bold and underline
+func wordStyle(style lipgloss.Style, added bool) lipgloss.Style {
+	if _, plain := style.GetBackground().(lipgloss.NoColor); !plain || style.GetReverse() {
+		return lipgloss.NewStyle().Bold(true).Underline(true)
+	}
+	if added {
+		return theme.DiffAddWord
+	}
+	return theme.DiffRemoveWord
 }
 
-// padToWidth pads a string with spaces to reach the target width (plain text, no ANSI)
+// markRow draws a row of a line in style, with each mark's style drawn
+// over it, later marks over earlier ones. The row starts offset bytes into
+// the line's plain text.
+func markRow(row string, offset int, marks []highlight.Match, style lipgloss.Style) string {
+	cuts := []int{0, len(row)}
+	for _, m := range marks {
+		for _, cut := range []int{m.Start - offset, m.End - offset} {
+			if cut > 0 && cut < len(row) {
+				cuts = append(cuts, cut)
+			}
+		}
+	}
+	slices.Sort(cuts)
+	cuts = slices.Compact(cuts)
+
+	var b strings.Builder
+	for k := 0; k+1 < len(cuts); k++ {
+		start, end := cuts[k], cuts[k+1]
+		piece := style
+		for _, m := range marks {
+			if m.Start-offset <= start && end <= m.End-offset {
+				piece = m.Style.Inherit(piece)
+			}
+		}
+		b.WriteString(piece.Render(row[start:end]))
+	}
+	return b.String()
+}
+
+// SetWrap toggles wrapping of long lines
+func (p *DiffPanel) SetWrap(wrap bool) {
+	p.wrap = wrap
+	p.layout()
+	p.ensureCursorVisible()
+}
+
+// cursorRows returns the first and last display rows of the cursor line
+func (p *DiffPanel) cursorRows() (int, int) {
+	if p.cursorLine < 0 || p.cursorLine >= len(p.rowStarts) {
+		return p.cursorLine, p.cursorLine
+	}
+	first := p.rowStarts[p.cursorLine]
+	last := p.totalRowCount - 1
+	if p.cursorLine+1 < len(p.rowStarts) {
+		last = p.rowStarts[p.cursorLine+1] - 1
+	}
+	if last < first {
+		last = first
+	}
+	return first, last
+}
+
+// padToWidth pads a string with spaces to reach the target width
 func padToWidth(s string, width int) string {
 	currentWidth := runewidth.StringWidth(s)
+	if strings.Contains(s, "\x1b") {
+		currentWidth = ansi.StringWidth(s)
+	}
 	if currentWidth >= width {
 		return s
 	}
@@ -591,18 +1249,111 @@ func (p *DiffPanel) truncateLine(line string, maxWidth int) string {
 	return result.String()
 }
 
+// EscapeControl replaces control characters with caret notation (ESC as
+// ^[, CR as ^M, DEL as ^?, C1 controls as M-^X like cat -v) so they can't
+// move the terminal cursor or clear the screen. SGR color sequences, as
+// emitted by a VCS with color on, are kept. Tabs are kept since lipgloss
+// expands them.
+func EscapeControl(s string) string {
+	i := strings.IndexFunc(s, isEscapedControl)
+	if i < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	b.WriteString(s[:i])
+	for i < len(s) {
+		if s[i] == 0x1b {
+			if loc := sgrPrefix.FindStringIndex(s[i:]); loc != nil {
+				b.WriteString(s[i : i+loc[1]])
+				i += loc[1]
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case !isEscapedControl(r):
+			b.WriteString(s[i : i+size])
+		case r >= 0x80:
+			b.WriteString("M-^")
+			b.WriteByte(byte(r-0x80) ^ 0x40)
+		default:
+			b.WriteByte('^')
+			b.WriteByte(byte(r) ^ 0x40)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// Glyphs drawn by MarkInvisibles
+const (
+	tabGlyph      = "→   " // Same width as lipgloss's tab expansion
+	trailingGlyph = "·"
+	nbspGlyph     = "␣"
+)
+
+// MarkInvisibles replaces tabs, trailing spaces, non-breaking spaces and
+// zero-width characters with visible glyphs. The leading +/-/space of a
+// diff body line is left alone.
+func MarkInvisibles(line string) string {
+	prefix := ""
+	if line != "" && strings.ContainsRune("+- ", rune(line[0])) {
+		prefix, line = line[:1], line[1:]
+	}
+	body := strings.TrimRight(line, " ")
+	trailing := len(line) - len(body)
+
+	var b strings.Builder
+	b.Grow(len(line) + 8)
+	b.WriteString(prefix)
+	for _, r := range body {
+		switch {
+		case r == '\t':
+			b.WriteString(tabGlyph)
+		case r == '\u00a0' || r == '\u2007' || r == '\u202f':
+			b.WriteString(nbspGlyph)
+		case isZeroWidth(r):
+			fmt.Fprintf(&b, "<U+%04X>", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(strings.Repeat(trailingGlyph, trailing))
+	return b.String()
+}
+
+// isZeroWidth reports whether r is a zero-width or invisible formatting
+// character that can hide in source text
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u00ad', '\u180e', '\u200b', '\u200c', '\u200d', '\u2060', '\ufeff':
+		return true
+	}
+	return false
+}
+
 // stripANSI removes ANSI escape sequences from a string
 func stripANSI(s string) string {
 	return ansiRegex.ReplaceAllString(s, "")
 }
 
+// isEscapedControl reports whether r is a control character EscapeControl
+// rewrites
+func isEscapedControl(r rune) bool {
+	return r != '\t' && (r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0))
+}
+
 // getLineStyle returns the appropriate style based on line type and cursor/search state
 func (p *DiffPanel) getLineStyle(line string, isCursor, isCurrentMatch, isOtherMatch bool) lipgloss.Style {
 	// Determine line type
 	isAdd := strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++")
 	isRemove := strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---")
 	isHunk := strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff ") ||
-		strings.HasPrefix(line, "index ") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++")
+		strings.HasPrefix(line, "index ") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++") ||
+		strings.HasPrefix(line, "Mode changed: ")
 
 	// Return combined style based on state
 	if isCurrentMatch {
@@ -648,7 +1399,6 @@ func (p *DiffPanel) getLineStyle(line string, isCursor, isCurrentMatch, isOtherM
 	return theme.DiffContextLine
 }
 
-
 // CursorLine returns the current cursor line number (0-indexed)
 func (p *DiffPanel) CursorLine() int {
 	return p.cursorLine
@@ -0,0 +1,660 @@
+package panels
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/highlight"
+	"github.com/gerunddev/tcr/ui/theme"
+	"github.com/gerunddev/tcr/worddiff"
+)
+
+func TestDiffPanel_MoveCursor(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 12)
+	p.SetDiff("test.go", "line 0\nline 1\nline 2\nline 3\nline 4")
+
+	p.MoveCursor(3)
+	if p.CursorLine() != 3 {
+		t.Errorf("expected cursor at 3, got %d", p.CursorLine())
+	}
+
+	// Clamp at the end
+	p.MoveCursor(15)
+	if p.CursorLine() != 4 {
+		t.Errorf("expected cursor clamped at 4, got %d", p.CursorLine())
+	}
+
+	// Clamp at the start
+	p.MoveCursor(-15)
+	if p.CursorLine() != 0 {
+		t.Errorf("expected cursor clamped at 0, got %d", p.CursorLine())
+	}
+}
+
+func TestDiffPanel_GotoLine(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 12)
+	p.SetDiff("test.go", "a\nb\nc")
+
+	p.GotoLine(1)
+	if p.CursorLine() != 1 {
+		t.Errorf("expected cursor at 1, got %d", p.CursorLine())
+	}
+
+	p.GotoLine(100)
+	if p.CursorLine() != 2 {
+		t.Errorf("expected cursor clamped at 2, got %d", p.CursorLine())
+	}
+}
+
+func TestDiffPanel_MovePage(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 7) // 5 content lines
+
+	content := ""
+	for i := 0; i < 30; i++ {
+		content += "line\n"
+	}
+	p.SetDiff("test.go", content)
+
+	p.MovePage(2)
+	if p.CursorLine() != 10 {
+		t.Errorf("expected cursor at 10 after two pages, got %d", p.CursorLine())
+	}
+
+	p.MovePage(-1)
+	if p.CursorLine() != 5 {
+		t.Errorf("expected cursor at 5, got %d", p.CursorLine())
+	}
+}
+
+func TestDiffPanel_Restore(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 7) // 5 content lines
+	content := strings.Repeat("line\n", 30)
+	p.SetDiff("test.go", content)
+
+	p.GotoLine(20)
+	p.MoveCursor(-3)
+	cursor, offset := p.CursorLine(), p.ScrollOffset()
+
+	p.SetDiff("other.go", "a\nb")
+	p.SetDiff("test.go", content)
+	p.Restore(cursor, offset)
+	if p.CursorLine() != cursor || p.ScrollOffset() != offset {
+		t.Errorf("expected cursor %d at offset %d, got %d at %d", cursor, offset, p.CursorLine(), p.ScrollOffset())
+	}
+
+	// A diff that shrank since keeps the cursor on it
+	p.SetDiff("test.go", "a\nb\nc")
+	p.Restore(cursor, offset)
+	if p.CursorLine() != 2 || p.ScrollOffset() != 0 {
+		t.Errorf("expected the cursor clamped to 2, got %d at offset %d", p.CursorLine(), p.ScrollOffset())
+	}
+}
+
+func TestDiffPanel_MoveCursorEmpty(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 12)
+
+	p.MoveCursor(5) // Should not panic
+	if p.CursorLine() != 0 {
+		t.Errorf("expected cursor at 0, got %d", p.CursorLine())
+	}
+}
+
+func TestDiffPanel_EmptyMessage(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 12)
+	p.SetDiff("", "")
+
+	if view := p.View(); !strings.Contains(view, "No diff to show") {
+		t.Errorf("expected the default empty message:\n%s", view)
+	}
+	p.SetEmptyMessage("No changes comparing the working tree.")
+	if view := p.View(); !strings.Contains(view, "No changes comparing the working tree.") {
+		t.Errorf("expected the empty message:\n%s", view)
+	}
+	p.SetDiff("a.go", "")
+	if view := p.View(); !strings.Contains(view, "No changes to show in a.go") {
+		t.Errorf("expected the file's empty message:\n%s", view)
+	}
+}
+
+func TestDiffPanel_GotoPercent(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 12)
+
+	content := "line"
+	for i := 1; i < 200; i++ {
+		content += "\nline"
+	}
+	p.SetDiff("test.go", content)
+
+	tests := []struct {
+		pct  int
+		want int
+	}{
+		{0, 0},
+		{1, 1},
+		{50, 99},
+		{100, 199},
+		{150, 199},
+	}
+
+	for _, tt := range tests {
+		p.GotoPercent(tt.pct)
+		if p.CursorLine() != tt.want {
+			t.Errorf("GotoPercent(%d): expected line %d, got %d", tt.pct, tt.want, p.CursorLine())
+		}
+	}
+}
+
+func TestDiffPanel_MoveHalfPage(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 12) // 10 content lines
+
+	content := "line"
+	for i := 1; i < 40; i++ {
+		content += "\nline"
+	}
+	p.SetDiff("test.go", content)
+
+	p.MoveHalfPage(1)
+	if p.CursorLine() != 5 {
+		t.Errorf("expected cursor at 5, got %d", p.CursorLine())
+	}
+
+	p.MoveHalfPage(3)
+	if p.CursorLine() != 20 {
+		t.Errorf("expected cursor at 20, got %d", p.CursorLine())
+	}
+
+	p.MoveHalfPage(-10)
+	if p.CursorLine() != 0 {
+		t.Errorf("expected cursor clamped at 0, got %d", p.CursorLine())
+	}
+}
+
+func TestDiffPanel_WrapKeepsCursorVisible(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(12, 6) // 10 columns, 4 rows
+	p.SetWrap(true)
+
+	long := "+" + strings.Repeat("x", 29) // Wraps to 3 rows
+	p.SetDiff("test.go", long+"\n"+long+"\nshort")
+
+	p.MoveCursor(2)
+	first, last := p.cursorRows()
+	if first != 6 || last != 6 {
+		t.Errorf("expected cursor on row 6, got rows %d-%d", first, last)
+	}
+	if p.viewport.YOffset+p.viewport.Height <= last {
+		t.Errorf("cursor row %d not visible (offset %d, height %d)", last, p.viewport.YOffset, p.viewport.Height)
+	}
+}
+
+func TestDiffPanel_NoWrapTruncates(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(12, 6)
+	p.SetDiff("test.go", strings.Repeat("x", 30)+"\nnext")
+
+	if p.viewport.TotalLineCount() != 2 {
+		t.Errorf("expected one row per line without wrap, got %d", p.viewport.TotalLineCount())
+	}
+}
+
+func TestDiffPanel_Print(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetDiff("test.go", "@@ -1 +1 @@\n-old\n+"+strings.Repeat("x", 30))
+
+	lines := strings.Split(strings.TrimSuffix(p.Print(0), "\n"), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], strings.Repeat("x", 30)) {
+		t.Errorf("expected every line in full without a width, got %q", lines)
+	}
+
+	p.SetWrap(true)
+	if rows := strings.Count(p.Print(12), "\n"); rows != 5 {
+		t.Errorf("expected the long line wrapped to 12 columns, got %d rows", rows)
+	}
+}
+
+func TestDiffPanel_RenderCacheMatchesFreshRender(t *testing.T) {
+	content := "@@ -1,3 +1,3 @@\n context\n-old\n+new\n tail"
+
+	p := NewDiffPanel()
+	p.SetSize(40, 10)
+	p.SetDiff("test.go", content)
+	p.MoveCursor(2)
+	p.MoveCursor(1)
+	cached := p.renderWindow()
+
+	fresh := NewDiffPanel()
+	fresh.SetSize(40, 10)
+	fresh.SetDiff("test.go", content)
+	fresh.GotoLine(3)
+	if got := fresh.renderWindow(); got != cached {
+		t.Errorf("cached render differs from fresh render:\n%q\n%q", cached, got)
+	}
+
+	// Resizing invalidates the cache
+	p.SetSize(30, 10)
+	for _, row := range strings.Split(p.renderWindow(), "\n") {
+		if w := lipgloss.Width(row); w != p.ContentWidth() {
+			t.Fatalf("expected rows of width %d after resize, got %d", p.ContentWidth(), w)
+		}
+	}
+}
+
+func BenchmarkDiffPanel_CursorMove(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 5000; i++ {
+		sb.WriteString("+added line with some content to style\n")
+	}
+
+	p := NewDiffPanel()
+	p.SetSize(120, 40)
+	p.SetDiff("bench.go", sb.String())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.MoveCursor(1)
+		p.Refresh()
+		_ = p.View()
+	}
+}
+
+func TestDiffPanel_RendersOnlyVisibleLines(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 100000; i++ {
+		sb.WriteString("+line\n")
+	}
+
+	p := NewDiffPanel()
+	p.SetSize(40, 12) // 10 visible rows
+	p.SetDiff("big.go", sb.String())
+
+	view := p.renderWindow()
+	if rows := strings.Count(view, "\n") + 1; rows != p.viewport.Height {
+		t.Errorf("expected %d rows, got %d", p.viewport.Height, rows)
+	}
+	if len(p.renderCache) > p.viewport.Height+2*overscan {
+		t.Errorf("expected only the window to be styled, cache holds %d lines", len(p.renderCache))
+	}
+
+	p.GotoBottom()
+	_ = p.renderWindow()
+	p.GotoPercent(50)
+	_ = p.renderWindow()
+	if len(p.renderCache) > 4*(p.viewport.Height+2*overscan) {
+		t.Errorf("render cache not evicted after jumping, holds %d lines", len(p.renderCache))
+	}
+	if _, ok := p.renderCache[p.CursorLine()]; !ok {
+		t.Error("expected cursor line to be rendered after jump")
+	}
+}
+
+func TestDiffPanel_WrappedWindowStartsMidLine(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(12, 4) // 10 columns, 2 rows
+	p.SetWrap(true)
+	p.SetDiff("test.go", "+"+strings.Repeat("x", 29)+"\nshort")
+
+	// Scroll so the window starts on the second row of the wrapped line
+	p.viewport.SetYOffset(1)
+	rows := strings.Split(p.renderWindow(), "\n")
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if !strings.Contains(rows[0], "xxxxxxxxxx") || strings.Contains(rows[0], "+") {
+		t.Errorf("expected first visible row to be a continuation, got %q", rows[0])
+	}
+}
+
+func TestEscapeControl(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"crlf\r", "crlf^M"},
+		{"\x1b[31mred\x1b[0m", "\x1b[31mred\x1b[0m"}, // Colors are kept
+		{"\x1b[2Jclear\x1b]0;title\x07", "^[[2Jclear^[]0;title^G"},
+		{"a\tb", "a\tb"},
+		{"nul\x00 del\x7f", "nul^@ del^?"},
+		{"next\u0085line", "nextM-^Eline"},
+		{"héllo\r", "héllo^M"},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeControl(tt.in); got != tt.want {
+			t.Errorf("EscapeControl(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDiffPanel_ControlCharactersKeepLayout(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(22, 6) // 20 columns, 4 rows
+	p.SetDiff("test.go", "+\x1b[2J\x1b[31mred\r\n context\r\n-gone\x1b[0m")
+
+	rows := strings.Split(p.renderWindow(), "\n")
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	for i, row := range rows {
+		if w := lipgloss.Width(row); w != p.ContentWidth() {
+			t.Errorf("row %d: expected width %d, got %d (%q)", i, p.ContentWidth(), w, row)
+		}
+		if strings.Contains(row, "\r") || strings.Contains(row, "\x1b[2J") {
+			t.Errorf("row %d: raw control character reached the terminal: %q", i, row)
+		}
+	}
+	if !strings.Contains(rows[0], "^[[2Jred^M") {
+		t.Errorf("expected escapes shown in caret notation, got %q", rows[0])
+	}
+	if !strings.Contains(rows[2], "-gone\x1b[0m") {
+		t.Errorf("expected color sequence kept off the cursor line, got %q", rows[2])
+	}
+
+	// Wrapping counts the escaped width
+	p.SetSize(12, 6)
+	p.SetWrap(true)
+	if got := p.totalRows(); got != 4 {
+		t.Errorf("expected 4 wrapped rows, got %d", got)
+	}
+}
+
+func TestMarkInvisibles(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"+plain", "+plain"},
+		{"+\tindent", "+→   indent"},
+		{"+trailing  ", "+trailing··"},
+		{" ", " "},
+		{"  ", " ·"},
+		{"-a\u00a0b", "-a␣b"},
+		{"+pass\u200bword", "+pass<U+200B>word"},
+		{"@@ -1 +1 @@", "@@ -1 +1 @@"},
+	}
+
+	for _, tt := range tests {
+		if got := MarkInvisibles(tt.in); got != tt.want {
+			t.Errorf("MarkInvisibles(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDiffPanel_ShowInvisiblesInvalidatesRender(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(40, 6)
+	p.SetDiff("test.go", "+x \n context")
+
+	if strings.Contains(p.renderWindow(), "·") {
+		t.Fatal("trailing space marked before enabling invisibles")
+	}
+	p.SetShowInvisibles(true)
+	if !strings.Contains(p.renderWindow(), "+x·") {
+		t.Error("expected trailing space marked after enabling invisibles")
+	}
+}
+
+func TestDiffPanel_FindingsInTitle(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 8)
+	p.SetDiff("test.go", "@@ -1 +1,2 @@\n+ok\n+bad\n+worse")
+	p.SetFindings([]findings.Finding{
+		{Line: 2, Message: "bidi control U+202E"},
+		{Line: 3, Message: "invisible U+200B"},
+	})
+
+	p.View()
+	if got := p.Title(); got != "Diff: test.go ⚠ 2 findings" {
+		t.Errorf("unexpected title %q", got)
+	}
+
+	p.GotoLine(2)
+	p.View()
+	if got := p.Title(); got != "Diff: test.go ⚠ bidi control U+202E" {
+		t.Errorf("expected cursor finding in title, got %q", got)
+	}
+
+	// Findings on the same line share it
+	p.SetFindings([]findings.Finding{
+		{Line: 2, Message: "bidi control U+202E"},
+		{Line: 2, Message: "lint: shadowed variable"},
+	})
+	p.View()
+	if got := p.Title(); got != "Diff: test.go ⚠ bidi control U+202E · lint: shadowed variable" {
+		t.Errorf("expected both findings in title, got %q", got)
+	}
+
+	// A new diff drops the old findings
+	p.SetDiff("other.go", "+fine")
+	p.View()
+	if got := p.Title(); got != "Diff: other.go" {
+		t.Errorf("expected plain title, got %q", got)
+	}
+}
+
+func TestDiffPanel_FormatNoise(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 10)
+	p.SetDiff("main.go", "@@ -1 +1 @@\n-  x\n+\tx\n@@ -5 +5 @@\n-a\n+b")
+	p.SetFormatNoise([]findings.Range{{Start: 0, End: 3}})
+
+	p.View()
+	if got := p.Title(); got != "Diff: main.go · 1 formatting-only hunk" {
+		t.Errorf("unexpected title %q", got)
+	}
+	if !p.noise[1] || !p.noise[2] || p.noise[4] {
+		t.Errorf("noise lines = %v, want only the first hunk", p.noise)
+	}
+
+	// A new diff drops the old noise
+	p.SetDiff("other.go", "+fine")
+	p.View()
+	if got := p.Title(); got != "Diff: other.go" {
+		t.Errorf("expected plain title, got %q", got)
+	}
+}
+
+func TestDiffPanel_TODOs(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 10)
+	p.SetDiff("main.go", "@@ -1 +1 @@\n-// TODO: retry\n+retry()")
+	p.SetTODOs(findings.Markers{Removed: 1})
+
+	p.View()
+	if got := p.Title(); got != "Diff: main.go · TODO +0 −1" {
+		t.Errorf("unexpected title %q", got)
+	}
+
+	p.SetDiff("other.go", "+fine")
+	p.View()
+	if got := p.Title(); got != "Diff: other.go" {
+		t.Errorf("expected plain title, got %q", got)
+	}
+}
+
+func TestDiffPanel_FoldedHunks(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(100, 10)
+	p.SetDiff("main.go", "@@ -1,9 +1,9 @@\n ⋯ 10 lines folded\n@@ -20,3 +20,3 @@\n ⋯ 4 lines folded")
+	p.SetFoldedHunks(2)
+
+	p.View()
+	if got := p.Title(); got != "Diff: main.go · 2 hunks folded" {
+		t.Errorf("unexpected title %q", got)
+	}
+
+	p.SetDiff("main.go", "@@ -1 +1 @@\n-a\n+b")
+	p.View()
+	if got := p.Title(); got != "Diff: main.go" {
+		t.Errorf("expected folds dropped with the diff, got %q", got)
+	}
+}
+
+func TestDiffPanel_LineAges(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 10)
+	p.SetDiff("main.go", "@@ -1,4 +1,4 @@\n recent\n middling\n ancient\n-a\n+b")
+	p.SetLineAges(map[int]time.Duration{1: time.Hour, 2: 90 * 24 * time.Hour, 3: 3 * 365 * 24 * time.Hour})
+
+	if _, style, _ := p.styledLine(1, 0); style.GetForeground() != theme.ColorWhite {
+		t.Errorf("expected a recent line brighter, got %v", style.GetForeground())
+	}
+	if _, style, _ := p.styledLine(2, 0); style.GetForeground() != theme.ColorDimWhite || style.GetFaint() {
+		t.Error("expected a line from this year left as it was")
+	}
+	if _, style, _ := p.styledLine(3, 0); !style.GetFaint() {
+		t.Error("expected an old line faded")
+	}
+
+	p.SetDiff("other.go", " line")
+	if p.ages != nil {
+		t.Error("expected a new diff to drop the ages")
+	}
+}
+
+func TestDiffPanel_Annotations(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(80, 10)
+	p.SetDiff("main.go", "@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2")
+	p.SetAnnotations(map[int]string{3: "Bob: Why 2?", 2: "carol: Was 1"})
+
+	p.View()
+	if got := p.Title(); got != "Diff: main.go · 💬 2 commented lines" {
+		t.Errorf("unexpected title %q", got)
+	}
+	p.MoveCursor(3)
+	p.View()
+	if got := p.Title(); got != "Diff: main.go 💬 Bob: Why 2?" {
+		t.Errorf("expected the cursor line's comment in the title, got %q", got)
+	}
+
+	p.SetDiff("other.go", "+fine")
+	p.View()
+	if got := p.Title(); got != "Diff: other.go" {
+		t.Errorf("a new diff should drop the annotations, got %q", got)
+	}
+}
+
+func TestDiffPanel_CommentGutter(t *testing.T) {
+	p := NewDiffPanel()
+	p.SetSize(40, 10)
+	p.SetDiff("main.go", "@@ -1,2 +1,2 @@\n package main\n-var x = 1\n+var x = 2")
+	if strings.Contains(stripANSI(p.View()), "●") {
+		t.Fatal("no gutter marks without comments")
+	}
+
+	p.SetComments(map[int]int{1: 1, 3: 3})
+	rows := strings.Split(stripANSI(p.renderWindow()), "\n")
+	if !strings.HasPrefix(rows[1], "● ") || !strings.HasPrefix(rows[3], "3 ") {
+		t.Errorf("expected marks on lines 1 and 3:\n%s", strings.Join(rows, "\n"))
+	}
+	if !strings.HasPrefix(rows[0], "  @@") {
+		t.Errorf("uncommented lines keep a blank gutter, got %q", rows[0])
+	}
+	if w := lipgloss.Width(rows[0]); w != p.ContentWidth() {
+		t.Errorf("row width %d, want %d", w, p.ContentWidth())
+	}
+
+	p.SetDiff("other.go", "+fine")
+	if strings.Contains(stripANSI(p.View()), "●") {
+		t.Error("a new diff should drop the marks")
+	}
+}
+
+func TestDiffPanel_WordDiff(t *testing.T) {
+	content := "@@ -1,3 +1,3 @@\n-  x := compute(a)\n-gone\n+  x := compute(b)\n+here\n+another"
+
+	p := NewDiffPanel()
+	p.SetSize(40, 10)
+	p.SetDiff("test.go", content)
+	if spans := p.wordSpans(1); spans != nil {
+		t.Errorf("expected no words highlighted with word diffs off, got %v", spans)
+	}
+
+	p.SetWordDiff(true)
+	want := []worddiff.Span{{Start: 16, End: 17}}
+	if got := p.wordSpans(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("removed line spans = %v, want %v", got, want)
+	}
+	if got := p.wordSpans(3); !reflect.DeepEqual(got, want) {
+		t.Errorf("added line spans = %v, want %v", got, want)
+	}
+	// Rewritten lines, and those left over, aren't highlighted
+	for _, i := range []int{2, 4, 5} {
+		if spans := p.wordSpans(i); spans != nil {
+			t.Errorf("line %d: expected no spans, got %v", i, spans)
+		}
+	}
+
+	// Highlighted rows are as wide as the rest, cursor or not, wrapped or not
+	for _, wrap := range []bool{false, true} {
+		p.SetWrap(wrap)
+		p.SetSize(14, 12)
+		p.GotoLine(1)
+		for _, row := range strings.Split(p.renderWindow(), "\n") {
+			if w := lipgloss.Width(row); w != p.ContentWidth() {
+				t.Errorf("wrap %v: expected rows of width %d, got %d in %q", wrap, p.ContentWidth(), w, row)
+			}
+		}
+	}
+
+	// A finding on the line takes precedence
+	p.SetFindings([]findings.Finding{{Line: 3, Message: "lint"}})
+	if _, _, spans := p.styledLine(3, 0); spans != nil {
+		t.Errorf("expected a flagged line to drop word highlights, got %v", spans)
+	}
+}
+
+func TestDiffPanel_Highlights(t *testing.T) {
+	content := "--- a/unsafe.go\n+++ b/unsafe.go\n@@ -1 +1 @@ unsafe\n-p := unsafe.Pointer(x)\n+p := unsafe.Pointer(y)\n unsafe := true"
+	rules, err := highlight.Compile(map[string]string{`\bunsafe\b`: "bold red", `^unsafe`: "reverse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewDiffPanel()
+	p.SetSize(40, 10)
+	p.SetDiff("unsafe.go", content)
+	p.SetHighlights(rules)
+	p.SetWordDiff(true)
+
+	// Headers aren't highlighted
+	for i := 0; i < 3; i++ {
+		if _, _, marks := p.styledLine(i, 0); marks != nil {
+			t.Errorf("line %d: expected no highlights in a header, got %v", i, marks)
+		}
+	}
+
+	// Changed words come first, so highlights are drawn over them
+	_, _, marks := p.styledLine(4, 0)
+	var got []string
+	for _, m := range marks {
+		got = append(got, fmt.Sprintf("%d-%d", m.Start, m.End))
+	}
+	if want := "21-22 6-12"; strings.Join(got, " ") != want {
+		t.Errorf("added line marks = %v, want %s", got, want)
+	}
+	if !marks[1].Style.GetBold() {
+		t.Error("expected the highlight to carry its rule's style")
+	}
+
+	// ^ anchors at the start of the code, after the prefix
+	_, _, marks = p.styledLine(5, 0)
+	if len(marks) != 2 || marks[1].Start != 1 || !marks[1].Style.GetReverse() {
+		t.Errorf("expected ^unsafe to match the context line's code, got %v", marks)
+	}
+}
@@ -1,11 +1,13 @@
 package panels
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/gerunddev/tcr/ui/borders"
 	"github.com/gerunddev/tcr/ui/theme"
 	"github.com/gerunddev/tcr/vcs"
 )
@@ -20,22 +22,50 @@ type FilesPanel struct {
 	BasePanel
 	files        []vcs.FileChange
 	filteredIdxs []int // Indices into files slice, nil means show all
+	searchIdxs   []int // Indices matched by unified search, nil means no search filter
+	searchQuery  string
+	nameFilter   *NameFilter
+	filterStart  int // Cursor when the name filter was opened
 	viewport     viewport.Model
 	ready        bool
+	loading      string // Shown instead of the list until files arrive
+
+	generated         map[string]string // Generated file -> the .proto it came from
+	generatedCounts   map[string]int    // .proto -> how many of its generated files changed
+	collapseGenerated bool              // Hide generated files under their .proto
+
+	stats map[string]vcs.Stat // Changed lines by path, once counted
+	most  int                 // Changed lines of the most changed file
+
+	pending map[string]bool // Files search hasn't looked at yet, still loading
+
+	conflictsOnly bool // Show only files with merge conflicts
 }
 
 // NewFilesPanel creates a new files panel
 func NewFilesPanel() *FilesPanel {
 	return &FilesPanel{
-		BasePanel: NewBasePanel("Files", "changed files"),
+		BasePanel:  NewBasePanel("Files", "changed files"),
+		nameFilter: NewNameFilter(),
 	}
 }
 
+// SetLoading shows msg in place of the file list until SetFiles is called.
+// An empty msg clears the loading state.
+func (p *FilesPanel) SetLoading(msg string) {
+	p.loading = msg
+}
+
 // SetFiles updates the file list
 func (p *FilesPanel) SetFiles(files []vcs.FileChange) {
+	p.loading = ""
 	p.files = files
 	p.filteredIdxs = nil
+	p.searchIdxs = nil
 	p.cursor = 0
+	if p.nameFilter.Applied() || p.hidesGenerated() || p.conflictsOnly {
+		p.applyFilters()
+	}
 	if p.ready {
 		p.viewport.SetContent(p.renderContent())
 		p.viewport.GotoTop()
@@ -45,6 +75,158 @@ func (p *FilesPanel) SetFiles(files []vcs.FileChange) {
 // SetFilteredIndices sets which files to show (by index into full files list)
 // Pass nil to show all files
 func (p *FilesPanel) SetFilteredIndices(indices []int) {
+	p.searchIdxs = indices
+	p.applyFilters()
+}
+
+// SetSearchQuery names the search the files are filtered by, for the title
+func (p *FilesPanel) SetSearchQuery(query string) {
+	p.searchQuery = query
+}
+
+// ClearFilter removes the search filter (the name filter is kept)
+func (p *FilesPanel) ClearFilter() {
+	p.searchIdxs = nil
+	p.applyFilters()
+}
+
+// SetGenerated marks generated files with the .proto each came from, so
+// they're drawn as belonging to it. With collapse they're hidden and the
+// .proto shows how many there are.
+func (p *FilesPanel) SetGenerated(sources map[string]string, collapse bool) {
+	p.generated = sources
+	p.collapseGenerated = collapse
+	p.generatedCounts = make(map[string]int)
+	for _, proto := range sources {
+		p.generatedCounts[proto]++
+	}
+	if len(p.files) > 0 {
+		p.applyFilters()
+	}
+}
+
+// SetStats shows each file's added and removed line counts beside it
+func (p *FilesPanel) SetStats(stats map[string]vcs.Stat) {
+	p.stats = stats
+	p.most = 0
+	for _, s := range stats {
+		p.most = max(p.most, s.Added+s.Removed)
+	}
+	if p.ready {
+		p.viewport.SetContent(p.renderContent())
+	}
+}
+
+// SetPending greys out the files search is still waiting on, badged as
+// pending. nil clears them.
+func (p *FilesPanel) SetPending(paths map[string]bool) {
+	p.pending = paths
+	if p.ready {
+		p.viewport.SetContent(p.renderContent())
+	}
+}
+
+// statLabel renders a file's line counts as "+12 −4", leaving out a side
+// with none
+func statLabel(s vcs.Stat) string {
+	if s.Binary {
+		return theme.DimmedStyle.Render("bin")
+	}
+	var parts []string
+	if s.Added > 0 {
+		parts = append(parts, theme.AddedStyle.Render(fmt.Sprintf("+%d", s.Added)))
+	}
+	if s.Removed > 0 {
+		parts = append(parts, theme.DeletedStyle.Render(fmt.Sprintf("−%d", s.Removed)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// heatWidth is how many columns the change heat bar takes
+const heatWidth = 4
+
+// heatMinWidth is the narrowest list with room for heat bars
+const heatMinWidth = 24
+
+// heatBars are the partial blocks a heat bar ends in, by eighths
+var heatBars = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// heatBar draws lines changed as a bar as long, relative to most, as the
+// heat column is wide. Any change shows as at least a sliver.
+func heatBar(lines, most int) string {
+	eighths := 0
+	if lines > 0 && most > 0 {
+		eighths = max((lines*heatWidth*8+most-1)/most, 1)
+	}
+	bar := strings.Repeat("█", eighths/8) + heatBars[eighths%8]
+	pad := strings.Repeat(" ", heatWidth-lipgloss.Width(bar))
+	return theme.HeatStyle.Render(bar) + pad
+}
+
+// SetConflictsOnly narrows the list to files with merge conflicts, or
+// shows every file again, selecting the first conflict if the selection
+// was filtered out
+func (p *FilesPanel) SetConflictsOnly(on bool) tea.Cmd {
+	prev := p.cursor
+	p.conflictsOnly = on
+	p.applyFilters()
+	if p.cursor != prev {
+		return p.selectedCmd()
+	}
+	return nil
+}
+
+// ConflictsOnly reports whether the list is narrowed to conflicted files
+func (p *FilesPanel) ConflictsOnly() bool {
+	return p.conflictsOnly
+}
+
+// ConflictCount returns how many files have merge conflicts
+func (p *FilesPanel) ConflictCount() int {
+	n := 0
+	for _, f := range p.files {
+		if f.Status == vcs.StatusConflict {
+			n++
+		}
+	}
+	return n
+}
+
+// hidesGenerated reports whether collapsed generated files are filtered out
+func (p *FilesPanel) hidesGenerated() bool {
+	return p.collapseGenerated && len(p.generated) > 0
+}
+
+// applyFilters combines the search, name and conflict filters, and hides
+// collapsed generated files, into filteredIdxs
+func (p *FilesPanel) applyFilters() {
+	indices := p.searchIdxs
+	if p.nameFilter.Applied() || p.hidesGenerated() || p.conflictsOnly {
+		if indices == nil {
+			indices = make([]int, len(p.files))
+			for i := range p.files {
+				indices[i] = i
+			}
+		}
+		matched := []int{}
+		for _, idx := range indices {
+			if idx < 0 || idx >= len(p.files) {
+				continue
+			}
+			path := p.files[idx].Path
+			if p.nameFilter.Applied() && !p.nameFilter.Matches(path) {
+				continue
+			}
+			if _, ok := p.generated[path]; ok && p.collapseGenerated {
+				continue
+			}
+			if p.conflictsOnly && p.files[idx].Status != vcs.StatusConflict {
+				continue
+			}
+			matched = append(matched, idx)
+		}
+		indices = matched
+	}
 	p.filteredIdxs = indices
 
 	if len(indices) > 0 {
@@ -66,14 +248,7 @@ func (p *FilesPanel) SetFilteredIndices(indices []int) {
 	if p.ready {
 		p.viewport.SetContent(p.renderContent())
 		p.viewport.GotoTop()
-	}
-}
-
-// ClearFilter removes any active filtering
-func (p *FilesPanel) ClearFilter() {
-	p.filteredIdxs = nil
-	if p.ready {
-		p.viewport.SetContent(p.renderContent())
+		p.ensureCursorVisible()
 	}
 }
 
@@ -125,21 +300,33 @@ func (p *FilesPanel) Init() tea.Cmd {
 }
 
 func (p *FilesPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	prevCursor := p.cursor
-
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		// Arrow keys only for file navigation
 		case "up":
-			p.cursorUpFiltered()
-			p.ensureCursorVisible()
+			return p, p.MoveCursor(-1)
 		case "down":
-			p.cursorDownFiltered()
-			p.ensureCursorVisible()
+			return p, p.MoveCursor(1)
 		}
 	}
 
+	return p, nil
+}
+
+// MoveCursor moves the selection by delta files within the visible list
+// and returns a command emitting FileSelectedMsg if the selection changed
+func (p *FilesPanel) MoveCursor(delta int) tea.Cmd {
+	prevCursor := p.cursor
+
+	for ; delta < 0; delta++ {
+		p.cursorUpFiltered()
+	}
+	for ; delta > 0; delta-- {
+		p.cursorDownFiltered()
+	}
+	p.ensureCursorVisible()
+
 	// Update viewport content when cursor changes
 	if p.ready {
 		p.viewport.SetContent(p.renderContent())
@@ -147,14 +334,20 @@ func (p *FilesPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Emit selection message if cursor changed
 	if p.cursor != prevCursor {
-		if file := p.SelectedFile(); file != nil {
-			return p, func() tea.Msg {
-				return FileSelectedMsg{Path: file.Path}
-			}
-		}
+		return p.selectedCmd()
 	}
 
-	return p, nil
+	return nil
+}
+
+// selectedCmd returns a command emitting FileSelectedMsg for the selection
+func (p *FilesPanel) selectedCmd() tea.Cmd {
+	if file := p.SelectedFile(); file != nil {
+		return func() tea.Msg {
+			return FileSelectedMsg{Path: file.Path}
+		}
+	}
+	return nil
 }
 
 // cursorUpFiltered moves cursor up within filtered list (or all files if no filter)
@@ -205,10 +398,78 @@ func (p *FilesPanel) View() string {
 	if !p.ready {
 		return p.RenderFrame("Loading...")
 	}
+	if p.loading != "" {
+		return p.RenderFrame(theme.DimmedStyle.MaxWidth(p.ContentWidth()).Render(p.loading))
+	}
 	if len(p.files) == 0 {
 		return p.RenderFrame(theme.DimmedStyle.Render("No files changed"))
 	}
-	return p.RenderFrame(p.viewport.View())
+
+	content := p.viewport.View()
+	if p.Count() == 0 {
+		content = theme.DimmedStyle.Render("No matching files")
+	}
+	if p.nameFilter.Visible() {
+		content = p.renderWithFilterBar(content)
+	}
+	return p.renderFiltered(content)
+}
+
+// renderFiltered frames content, saying in the title how many files the
+// search, name filter or conflicts view leaves and why, as "Files (4/17 ·
+// filter: foo)"
+func (p *FilesPanel) renderFiltered(content string) string {
+	var reasons []string
+	if p.searchIdxs != nil {
+		reasons = append(reasons, "search: "+p.searchQuery)
+	}
+	if p.nameFilter.Applied() {
+		reasons = append(reasons, "filter: "+p.nameFilter.Query())
+	}
+	if p.conflictsOnly {
+		reasons = append(reasons, "conflicts")
+	}
+	if len(reasons) == 0 {
+		return p.RenderFrame(content)
+	}
+
+	name, rest, _ := strings.Cut(p.Title(), " ")
+	title := fmt.Sprintf("%s (%d/%d · %s)", name, p.Count(), p.TotalCount(), strings.Join(reasons, " · "))
+	if rest != "" {
+		title += " " + rest
+	}
+	return borders.RenderTitledBorder(content, title, p.width, p.height, p.focused)
+}
+
+// renderWithFilterBar pins the name filter bar to the bottom of the panel
+func (p *FilesPanel) renderWithFilterBar(content string) string {
+	contentWidth := p.ContentWidth()
+	contentHeight := p.ContentHeight() - 1
+
+	lines := strings.Split(content, "\n")
+	if len(lines) > contentHeight {
+		lines = lines[:contentHeight]
+	}
+	for len(lines) < contentHeight {
+		lines = append(lines, strings.Repeat(" ", contentWidth))
+	}
+	lines = append(lines, p.renderFilterBar(contentWidth))
+
+	return strings.Join(lines, "\n")
+}
+
+// updateViewportSize reserves a line for the filter bar when it is shown
+func (p *FilesPanel) updateViewportSize() {
+	if !p.ready {
+		return
+	}
+	contentHeight := p.ContentHeight()
+	if p.nameFilter.Visible() {
+		contentHeight--
+	}
+	p.viewport.Height = contentHeight
+	p.viewport.SetContent(p.renderContent())
+	p.ensureCursorVisible()
 }
 
 // SetSize initializes or resizes the viewport
@@ -218,6 +479,11 @@ func (p *FilesPanel) SetSize(width, height int) {
 	contentWidth := p.ContentWidth()
 	contentHeight := p.ContentHeight()
 
+	// Reserve space for the filter bar when shown
+	if p.nameFilter.Visible() {
+		contentHeight--
+	}
+
 	if !p.ready {
 		p.viewport = viewport.New(contentWidth, contentHeight)
 		p.viewport.SetContent(p.renderContent())
@@ -243,33 +509,73 @@ func (p *FilesPanel) renderContent() string {
 		switch file.Status {
 		case vcs.StatusModified:
 			statusStyle = theme.ModifiedStyle
-		case vcs.StatusAdded:
+		case vcs.StatusAdded, vcs.StatusUntracked:
 			statusStyle = theme.AddedStyle
 		case vcs.StatusDeleted:
 			statusStyle = theme.DeletedStyle
-		case vcs.StatusRenamed:
+		case vcs.StatusRenamed, vcs.StatusCopied:
 			statusStyle = theme.RenamedStyle
+		case vcs.StatusConflict:
+			statusStyle = theme.ConflictStyle
+		case vcs.StatusModeChanged:
+			statusStyle = theme.ModeStyle
 		default:
 			statusStyle = theme.NormalItemStyle
 		}
 
 		status := statusStyle.Render(string(file.Status))
 
+		// Generated files sit under their .proto, or are counted on it
+		prefix, suffix := "", ""
+		_, generated := p.generated[file.Path]
+		if generated {
+			prefix = "↳ "
+		} else if n := p.generatedCounts[file.Path]; n > 0 && p.collapseGenerated {
+			suffix = fmt.Sprintf(" +%d generated", n)
+		}
+
+		// Line counts go at the right edge
+		stat := ""
+		if s, ok := p.stats[file.Path]; ok {
+			stat = statLabel(s)
+			if contentWidth >= heatMinWidth {
+				stat += " " + heatBar(s.Added+s.Removed, p.most)
+			}
+		}
+		pending := p.pending[file.Path]
+		if pending {
+			stat = theme.DimmedStyle.Render("pending")
+		}
+		statWidth := lipgloss.Width(stat)
+		if statWidth > 0 {
+			statWidth++
+		}
+
 		// Truncate path if needed
-		maxPathLen := contentWidth - 3 // status + space
+		maxPathLen := contentWidth - 3 - lipgloss.Width(prefix) - lipgloss.Width(suffix) - statWidth // status + space
 		path := file.Path
-		if len(path) > maxPathLen && maxPathLen > 0 {
+		if file.Moved() {
+			path = file.OldPath + " → " + file.NewPath
+		}
+		if lipgloss.Width(path) > maxPathLen && maxPathLen > 0 {
 			path = truncate(path, maxPathLen)
 		}
 
-		if fileIdx == p.cursor {
+		switch {
+		case fileIdx == p.cursor:
 			// Show selected item in yellow
 			path = theme.SelectedItemStyle.Render(path)
-		} else {
+		case generated, pending:
+			path = theme.DimmedStyle.Render(path)
+		default:
 			path = theme.NormalItemStyle.Render(path)
 		}
 
-		line := status + " " + path
+		line := status + " " + theme.DimmedStyle.Render(prefix) + path + theme.DimmedStyle.Render(suffix)
+		if stat != "" {
+			gap := max(contentWidth-lipgloss.Width(line)-lipgloss.Width(stat), 1)
+			line += strings.Repeat(" ", gap) + stat
+		}
 		lines = append(lines, line)
 	}
 
@@ -284,6 +590,45 @@ func (p *FilesPanel) SelectedFile() *vcs.FileChange {
 	return nil
 }
 
+// SelectPath moves the cursor to the file with the given path, returning
+// false if it isn't in the visible list
+func (p *FilesPanel) SelectPath(path string) bool {
+	for i, f := range p.files {
+		if f.Path != path {
+			continue
+		}
+		if p.fileIndexToDisplayIndex(i) < 0 {
+			return false
+		}
+		p.cursor = i
+		p.ensureCursorVisible()
+		if p.ready {
+			p.viewport.SetContent(p.renderContent())
+		}
+		return true
+	}
+	return false
+}
+
+// NeighborPaths returns the paths of up to n visible files on each side of
+// the selection, nearest first and the file below before the one above
+func (p *FilesPanel) NeighborPaths(n int) []string {
+	display := p.fileIndexToDisplayIndex(p.cursor)
+	if display < 0 {
+		return nil
+	}
+	var paths []string
+	for d := 1; d <= n; d++ {
+		for _, idx := range []int{display + d, display - d} {
+			if idx < 0 || idx >= p.Count() {
+				continue
+			}
+			paths = append(paths, p.files[p.displayIndexToFileIndex(idx)].Path)
+		}
+	}
+	return paths
+}
+
 // Count returns the number of visible files (filtered or all)
 func (p *FilesPanel) Count() int {
 	if p.filteredIdxs != nil {
@@ -1,8 +1,11 @@
 package panels
 
 import (
+	"strings"
 	"testing"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/gerunddev/tcr/vcs"
 )
 
@@ -221,3 +224,336 @@ func TestFilesPanel_IndexConversion(t *testing.T) {
 		t.Errorf("expected -1 for file not in filter, got %d", p.fileIndexToDisplayIndex(1))
 	}
 }
+
+func TestFilesPanel_MoveCursorCount(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(30, 10)
+
+	files := []vcs.FileChange{
+		{Path: "a.go", Status: vcs.StatusModified},
+		{Path: "b.go", Status: vcs.StatusAdded},
+		{Path: "c.go", Status: vcs.StatusDeleted},
+		{Path: "d.go", Status: vcs.StatusModified},
+	}
+	p.SetFiles(files)
+
+	if cmd := p.MoveCursor(2); cmd == nil {
+		t.Error("expected a selection command when cursor moves")
+	}
+	if selected := p.SelectedFile(); selected == nil || selected.Path != "c.go" {
+		t.Errorf("expected c.go after moving 2, got %v", selected)
+	}
+
+	// Moving past the end clamps to the last file
+	p.MoveCursor(10)
+	if selected := p.SelectedFile(); selected == nil || selected.Path != "d.go" {
+		t.Errorf("expected d.go at end, got %v", selected)
+	}
+
+	// No movement, no selection message
+	if cmd := p.MoveCursor(1); cmd != nil {
+		t.Error("expected no command when cursor does not move")
+	}
+}
+
+func TestFilesPanel_NeighborPaths(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(30, 10)
+	p.SetFiles([]vcs.FileChange{
+		{Path: "a.go"}, {Path: "b.go"}, {Path: "c.go"}, {Path: "d.go"}, {Path: "e.go"},
+	})
+
+	p.MoveCursor(2)
+	got := strings.Join(p.NeighborPaths(2), " ")
+	if got != "d.go b.go e.go a.go" {
+		t.Errorf("unexpected neighbors: %q", got)
+	}
+
+	// Neighbors follow the visible (filtered) order
+	p.SetFilteredIndices([]int{0, 2, 4})
+	got = strings.Join(p.NeighborPaths(1), " ")
+	if got != "e.go a.go" {
+		t.Errorf("unexpected filtered neighbors: %q", got)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"", "src/main.go", true},
+		{"main", "src/main.go", true},
+		{"smg", "src/main.go", true},
+		{"SMG", "src/main.go", true},
+		{"gms", "src/main.go", false},
+		{"ui pan", "ui/panels/files.go", true},
+		{"xyz", "src/main.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFilesPanel_NameFilter(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(30, 10)
+
+	files := []vcs.FileChange{
+		{Path: "ui/app.go", Status: vcs.StatusModified},
+		{Path: "vcs/vcs.go", Status: vcs.StatusModified},
+		{Path: "ui/helpbar.go", Status: vcs.StatusAdded},
+	}
+	p.SetFiles(files)
+
+	p.ActivateNameFilter()
+	if !p.IsNameFiltering() {
+		t.Fatal("name filter should have focus after activation")
+	}
+
+	for _, r := range "ui" {
+		p.UpdateNameFilter(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if p.Count() != 2 {
+		t.Errorf("expected 2 files matching 'ui', got %d", p.Count())
+	}
+
+	// Enter keeps the filter but returns focus to navigation
+	p.UpdateNameFilter(tea.KeyMsg{Type: tea.KeyEnter})
+	if p.IsNameFiltering() {
+		t.Error("name filter should lose focus after enter")
+	}
+	if !p.HasNameFilter() || p.Count() != 2 {
+		t.Error("name filter should still apply after enter")
+	}
+
+	// Search filter combines with the name filter
+	p.SetFilteredIndices([]int{1, 2})
+	if p.Count() != 1 {
+		t.Errorf("expected 1 file matching both filters, got %d", p.Count())
+	}
+
+	// Clearing search keeps the name filter
+	p.ClearFilter()
+	if p.Count() != 2 {
+		t.Errorf("expected name filter to remain after clearing search, got %d", p.Count())
+	}
+
+	p.ClearNameFilter()
+	if p.IsFiltered() || p.Count() != 3 {
+		t.Errorf("expected all files after clearing name filter, got %d", p.Count())
+	}
+}
+
+func TestFilesPanel_FilterTitle(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(60, 10)
+	p.SetTitle("Files +3 −1")
+	p.SetFiles([]vcs.FileChange{
+		{Path: "ui/app.go", Status: vcs.StatusModified},
+		{Path: "vcs/vcs.go", Status: vcs.StatusModified},
+		{Path: "ui/helpbar.go", Status: vcs.StatusAdded},
+	})
+
+	title := func() string {
+		return strings.SplitN(ansi.Strip(p.View()), "\n", 2)[0]
+	}
+	if got := title(); !strings.Contains(got, "Files +3 −1") || strings.Contains(got, "/3") {
+		t.Errorf("unfiltered title: %q", got)
+	}
+
+	p.ActivateNameFilter()
+	for _, r := range "ui" {
+		p.UpdateNameFilter(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := title(); !strings.Contains(got, "Files (2/3 · filter: ui) +3 −1") {
+		t.Errorf("name filtered title: %q", got)
+	}
+
+	p.SetSearchQuery("help")
+	p.SetFilteredIndices([]int{2})
+	if got := title(); !strings.Contains(got, "Files (1/3 · search: help · filter: ui) +3 −1") {
+		t.Errorf("searched title: %q", got)
+	}
+
+	p.ClearFilter()
+	p.ClearNameFilter()
+	if got := title(); strings.Contains(got, "/3") {
+		t.Errorf("title should lose the count once unfiltered: %q", got)
+	}
+}
+
+func TestFilesPanel_NameFilterNoMatches(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(30, 10)
+	p.SetFiles([]vcs.FileChange{{Path: "a.go", Status: vcs.StatusModified}})
+
+	p.ActivateNameFilter()
+	p.UpdateNameFilter(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("zz")})
+
+	if p.Count() != 0 {
+		t.Errorf("expected no visible files, got %d", p.Count())
+	}
+	if !strings.Contains(p.View(), "No matching files") {
+		t.Error("expected empty filter state in view")
+	}
+}
+
+func TestFilesPanel_Generated(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(40, 10)
+
+	files := []vcs.FileChange{
+		{Path: "api/user.proto", Status: vcs.StatusModified},
+		{Path: "api/user.pb.go", Status: vcs.StatusModified},
+		{Path: "api/user_grpc.pb.go", Status: vcs.StatusModified},
+		{Path: "main.go", Status: vcs.StatusModified},
+	}
+	sources := map[string]string{
+		"api/user.pb.go":      "api/user.proto",
+		"api/user_grpc.pb.go": "api/user.proto",
+	}
+
+	p.SetGenerated(sources, false)
+	p.SetFiles(files)
+	if p.Count() != 4 {
+		t.Errorf("grouped files should all be listed, got %d", p.Count())
+	}
+	if view := ansi.Strip(p.View()); !strings.Contains(view, "M ↳ api/user.pb.go") {
+		t.Errorf("generated files should be marked:\n%s", view)
+	}
+
+	p.SetGenerated(sources, true)
+	if p.Count() != 2 {
+		t.Errorf("collapsed generated files should be hidden, got %d", p.Count())
+	}
+	if view := ansi.Strip(p.View()); !strings.Contains(view, "api/user.proto +2 generated") {
+		t.Errorf("the .proto should count its generated files:\n%s", view)
+	}
+	if p.SelectPath("api/user.pb.go") {
+		t.Error("a hidden generated file should not be selectable")
+	}
+
+	// Name filtering still applies on top
+	p.ActivateNameFilter()
+	p.UpdateNameFilter(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("main")})
+	if p.Count() != 1 {
+		t.Errorf("expected only main.go, got %d", p.Count())
+	}
+}
+
+func TestFilesPanel_Renamed(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(40, 10)
+	p.SetFiles([]vcs.FileChange{
+		{Path: "b.go", Status: vcs.StatusRenamed, OldPath: "a.go", NewPath: "b.go"},
+	})
+
+	if view := ansi.Strip(p.View()); !strings.Contains(view, "R a.go → b.go") {
+		t.Errorf("expected the rename shown as old → new:\n%s", view)
+	}
+	if sel := p.SelectedFile(); sel == nil || sel.Path != "b.go" {
+		t.Errorf("expected the new path selected, got %+v", sel)
+	}
+}
+
+func TestFilesPanel_Stats(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(40, 10)
+	p.SetFiles([]vcs.FileChange{
+		{Path: "main.go", Status: vcs.StatusModified},
+		{Path: "logo.png", Status: vcs.StatusAdded},
+		{Path: "old.go", Status: vcs.StatusDeleted},
+	})
+	p.SetStats(map[string]vcs.Stat{
+		"main.go":  {Added: 12, Removed: 4},
+		"logo.png": {Binary: true},
+		"old.go":   {Removed: 30},
+	})
+
+	// Heat bars follow the counts, scaled to the most changed file
+	lines := strings.Split(ansi.Strip(p.View()), "\n")
+	for i, want := range []string{"+12 −4 ██▎", "bin", "−30 ████"} {
+		line := strings.TrimRight(strings.TrimSuffix(lines[i+1], "│"), " ")
+		if !strings.HasSuffix(line, want) {
+			t.Errorf("line %d: expected %q at the right edge, got %q", i, want, lines[i+1])
+		}
+	}
+
+	// Narrow lists keep the room for paths
+	p.SetSize(22, 10)
+	if view := ansi.Strip(p.View()); strings.Contains(view, "█") || !strings.Contains(view, "+12 −4│") {
+		t.Errorf("expected no heat bars in a narrow list:\n%s", view)
+	}
+}
+
+func TestHeatBar(t *testing.T) {
+	for _, tt := range []struct {
+		lines, most int
+		want        string
+	}{
+		{0, 100, "    "},
+		{1, 1000, "▏   "},
+		{50, 100, "██  "},
+		{100, 100, "████"},
+	} {
+		if got := ansi.Strip(heatBar(tt.lines, tt.most)); got != tt.want {
+			t.Errorf("heatBar(%d, %d) = %q, want %q", tt.lines, tt.most, got, tt.want)
+		}
+	}
+}
+
+func TestFilesPanel_Pending(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(40, 10)
+	p.SetFiles([]vcs.FileChange{
+		{Path: "main.go", Status: vcs.StatusModified},
+		{Path: "util.go", Status: vcs.StatusModified},
+	})
+	p.SetStats(map[string]vcs.Stat{"main.go": {Added: 1}, "util.go": {Added: 2}})
+	p.SetPending(map[string]bool{"util.go": true})
+
+	lines := strings.Split(ansi.Strip(p.View()), "\n")
+	if strings.Contains(lines[1], "pending") || !strings.Contains(lines[2], "pending") {
+		t.Errorf("expected only util.go badged pending, got:\n%s", strings.Join(lines, "\n"))
+	}
+
+	p.SetPending(nil)
+	if strings.Contains(ansi.Strip(p.View()), "pending") {
+		t.Error("expected no pending badge once cleared")
+	}
+}
+
+func TestFilesPanel_ConflictsOnly(t *testing.T) {
+	p := NewFilesPanel()
+	p.SetSize(40, 10)
+	p.SetFiles([]vcs.FileChange{
+		{Path: "a.go", Status: vcs.StatusModified},
+		{Path: "b.go", Status: vcs.StatusConflict},
+		{Path: "c.go", Status: vcs.StatusModified},
+		{Path: "d.go", Status: vcs.StatusConflict},
+	})
+	if n := p.ConflictCount(); n != 2 {
+		t.Fatalf("expected 2 conflicts, got %d", n)
+	}
+
+	if cmd := p.SetConflictsOnly(true); cmd == nil {
+		t.Error("expected the first conflict to be selected")
+	}
+	view := ansi.Strip(p.View())
+	if p.Count() != 2 || strings.Contains(view, "a.go") || !strings.Contains(view, "U d.go") {
+		t.Errorf("expected only the conflicts:\n%s", view)
+	}
+	if sel := p.SelectedFile(); sel == nil || sel.Path != "b.go" {
+		t.Errorf("expected b.go selected, got %+v", sel)
+	}
+
+	p.SetConflictsOnly(false)
+	if p.Count() != 4 {
+		t.Errorf("expected every file again, got %d", p.Count())
+	}
+}
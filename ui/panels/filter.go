@@ -0,0 +1,146 @@
+package panels
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// NameFilter holds the state for filtering the files panel by path
+type NameFilter struct {
+	typing bool // Whether the filter input has focus
+	input  textinput.Model
+}
+
+// NewNameFilter creates a new, empty name filter
+func NewNameFilter() *NameFilter {
+	ti := textinput.New()
+	ti.Placeholder = ""
+	ti.Prompt = ""
+	ti.CharLimit = 100
+
+	return &NameFilter{input: ti}
+}
+
+// Query returns the current filter text
+func (f *NameFilter) Query() string {
+	return f.input.Value()
+}
+
+// Applied returns true if the filter narrows the list (non-empty query)
+func (f *NameFilter) Applied() bool {
+	return f.input.Value() != ""
+}
+
+// Visible returns true if the filter bar should be shown
+func (f *NameFilter) Visible() bool {
+	return f.typing || f.Applied()
+}
+
+// Matches reports whether path fuzzy-matches the current query
+func (f *NameFilter) Matches(path string) bool {
+	return fuzzyMatch(f.input.Value(), path)
+}
+
+// fuzzyMatch reports whether every rune of pattern appears in s in order
+// (case-insensitive). Spaces in the pattern are ignored.
+func fuzzyMatch(pattern, s string) bool {
+	target := []rune(strings.ToLower(s))
+	pos := 0
+	for _, r := range strings.ToLower(pattern) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		for pos < len(target) && target[pos] != r {
+			pos++
+		}
+		if pos == len(target) {
+			return false
+		}
+		pos++
+	}
+	return true
+}
+
+// ActivateNameFilter focuses the files panel filter input, keeping any
+// existing query so it can be refined
+func (p *FilesPanel) ActivateNameFilter() tea.Cmd {
+	p.nameFilter.typing = true
+	p.nameFilter.input.Focus()
+	p.filterStart = p.cursor
+	p.updateViewportSize()
+	return textinput.Blink
+}
+
+// IsNameFiltering returns true while the filter input has focus
+func (p *FilesPanel) IsNameFiltering() bool {
+	return p.nameFilter.typing
+}
+
+// HasNameFilter returns true if a name filter is narrowing the list
+func (p *FilesPanel) HasNameFilter() bool {
+	return p.nameFilter.Applied()
+}
+
+// NameFilterQuery returns the current name filter text
+func (p *FilesPanel) NameFilterQuery() string {
+	return p.nameFilter.Query()
+}
+
+// ClearNameFilter removes the name filter and closes its input
+func (p *FilesPanel) ClearNameFilter() {
+	p.nameFilter.typing = false
+	p.nameFilter.input.Blur()
+	p.nameFilter.input.SetValue("")
+	p.applyFilters()
+	p.updateViewportSize()
+}
+
+// UpdateNameFilter handles keys while the filter input has focus.
+// up/down move through the filtered list, enter keeps the filter and
+// returns to normal navigation, esc clears it.
+func (p *FilesPanel) UpdateNameFilter(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		p.ClearNameFilter()
+		return nil
+
+	case "enter":
+		p.nameFilter.typing = false
+		p.nameFilter.input.Blur()
+		p.updateViewportSize()
+		if p.cursor != p.filterStart {
+			return p.selectedCmd()
+		}
+		return nil
+
+	case "up":
+		return p.MoveCursor(-1)
+
+	case "down":
+		return p.MoveCursor(1)
+	}
+
+	oldQuery := p.nameFilter.Query()
+	var cmd tea.Cmd
+	p.nameFilter.input, cmd = p.nameFilter.input.Update(msg)
+	if p.nameFilter.Query() != oldQuery {
+		p.applyFilters()
+	}
+	return cmd
+}
+
+// renderFilterBar renders the "f>" input line shown under the file list
+func (p *FilesPanel) renderFilterBar(width int) string {
+	var query string
+	if p.nameFilter.typing {
+		query = p.nameFilter.input.View()
+	} else {
+		query = p.nameFilter.Query()
+	}
+	prompt := theme.SearchPromptStyle.Render("f> ")
+	return theme.SearchBarStyle.Width(width).Render(prompt + query)
+}
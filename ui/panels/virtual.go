@@ -0,0 +1,44 @@
+package panels
+
+// virtualViewport tracks the visible window over the diff's display rows.
+// Unlike viewport.Model it never holds the content, so only the rows on
+// screen need to be rendered.
+type virtualViewport struct {
+	Width   int
+	Height  int
+	YOffset int
+	total   int // Total display rows
+}
+
+// SetTotal updates the number of display rows and re-clamps the offset
+func (v *virtualViewport) SetTotal(n int) {
+	v.total = n
+	v.SetYOffset(v.YOffset)
+}
+
+// TotalLineCount returns the number of display rows
+func (v *virtualViewport) TotalLineCount() int {
+	return v.total
+}
+
+// SetYOffset scrolls to row n, clamped so the last page stays full
+func (v *virtualViewport) SetYOffset(n int) {
+	maxOffset := v.total - v.Height
+	if n > maxOffset {
+		n = maxOffset
+	}
+	if n < 0 {
+		n = 0
+	}
+	v.YOffset = n
+}
+
+// GotoTop scrolls to the first row
+func (v *virtualViewport) GotoTop() {
+	v.YOffset = 0
+}
+
+// GotoBottom scrolls so the last row is at the bottom
+func (v *virtualViewport) GotoBottom() {
+	v.SetYOffset(v.total)
+}
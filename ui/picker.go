@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/gerunddev/tcr/ui/borders"
+	"github.com/gerunddev/tcr/ui/theme"
+)
+
+// Picker is a full-screen list to choose one item from, such as the
+// review inbox
+type Picker struct {
+	title    string
+	items    []string
+	choice   int
+	offset   int // First item shown
+	width    int
+	height   int
+	chosen   bool
+	canceled bool
+}
+
+// NewPicker creates a picker over items
+func NewPicker(title string, items []string) *Picker {
+	return &Picker{title: title, items: items}
+}
+
+func (p *Picker) Init() tea.Cmd {
+	return nil
+}
+
+// visible is how many items fit between the border and the help line
+func (p *Picker) visible() int {
+	if p.height == 0 {
+		return len(p.items)
+	}
+	return max(1, p.height-3)
+}
+
+func (p *Picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			p.canceled = true
+			return p, tea.Quit
+		case "enter":
+			p.chosen = len(p.items) > 0
+			return p, tea.Quit
+		case "up", "k":
+			p.choice--
+		case "down", "j":
+			p.choice++
+		case "pgup", "ctrl+b":
+			p.choice -= p.visible()
+		case "pgdown", "ctrl+f":
+			p.choice += p.visible()
+		case "home", "g":
+			p.choice = 0
+		case "end", "G":
+			p.choice = len(p.items) - 1
+		}
+	}
+
+	p.choice = max(0, min(p.choice, len(p.items)-1))
+	if p.choice < p.offset {
+		p.offset = p.choice
+	}
+	if p.choice >= p.offset+p.visible() {
+		p.offset = p.choice - p.visible() + 1
+	}
+	return p, nil
+}
+
+func (p *Picker) View() string {
+	if p.chosen || p.canceled {
+		return ""
+	}
+
+	width := p.width
+	if width == 0 {
+		width = 100
+	}
+	var lines []string
+	end := min(len(p.items), p.offset+p.visible())
+	for i := p.offset; i < end; i++ {
+		item := ansi.Truncate(p.items[i], width-6, "…")
+		if i == p.choice {
+			lines = append(lines, theme.SelectedItemStyle.Render("> "+item))
+		} else {
+			lines = append(lines, theme.NormalItemStyle.Render("  "+item))
+		}
+	}
+	lines = append(lines, theme.HelpDescStyle.Render("enter open  ↑/↓ move  esc quit"))
+
+	return borders.RenderFloatingBorder(strings.Join(lines, "\n"), p.title, width, len(lines)+2)
+}
+
+// RunPicker shows items and returns the index chosen, or false if the
+// user quit without choosing
+func RunPicker(title string, items []string) (int, bool, error) {
+	p := NewPicker(title, items)
+	if _, err := tea.NewProgram(p, tea.WithAltScreen()).Run(); err != nil {
+		return 0, false, err
+	}
+	return p.choice, p.chosen, nil
+}
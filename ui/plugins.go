@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/plugin"
+	"github.com/gerunddev/tcr/ui/floating"
+)
+
+// startPlugins starts the configured plugins in the background
+func (a *App) startPlugins() tea.Cmd {
+	if !a.plugins.Configured() {
+		return nil
+	}
+	root, _ := os.Getwd()
+	info := plugin.Info{Version: plugin.Version, VCS: a.vcs.Name(), Root: root, Output: a.outputPath}
+	return func() tea.Msg {
+		return pluginsStartedMsg{err: a.plugins.Start(a.ctx, info)}
+	}
+}
+
+// pluginsStartedMsg reports plugins that failed to start
+type pluginsStartedMsg struct {
+	err error
+}
+
+// pluginsStarted reports plugins that failed to start, and asks those that
+// flag lines about the diff already shown
+func (a *App) pluginsStarted(msg pluginsStartedMsg) tea.Cmd {
+	if msg.err != nil {
+		a.statusMsg = "Error: " + pluginError(msg.err)
+	}
+	path := a.diffPanel.FilePath()
+	if a.quick || path == "" {
+		return nil
+	}
+	content, ok := a.diffCache.Peek(path)
+	if !ok {
+		return nil
+	}
+	return a.findPluginLines(path, content)
+}
+
+// pluginError puts the errors of several plugins on one line
+func pluginError(err error) string {
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
+// findPluginLines asks the plugins that flag lines about a file's diff, in
+// the background, unless they've been asked already
+func (a *App) findPluginLines(path, content string) tea.Cmd {
+	if !a.plugins.FindsLines() {
+		return nil
+	}
+	if _, ok := a.pluginFindings[path]; ok {
+		return nil
+	}
+	if a.pluginFindings == nil {
+		a.pluginFindings = make(map[string][]plugin.Finder)
+	}
+	a.pluginFindings[path] = nil
+	gen := a.diffGen
+	loc := plugin.Location{Path: path, Diff: content}
+	return func() tea.Msg {
+		fs, err := a.plugins.Findings(a.ctx, loc)
+		return pluginFindingsMsg{gen: gen, path: path, findings: fs, err: err}
+	}
+}
+
+// pluginFindingsMsg carries what plugins flagged in a file's diff
+type pluginFindingsMsg struct {
+	gen      int
+	path     string
+	findings []plugin.Finder
+	err      error
+}
+
+// pluginsFound keeps what plugins flagged in a file, and flags the lines
+// if the file is shown
+func (a *App) pluginsFound(msg pluginFindingsMsg) {
+	if msg.gen != a.diffGen {
+		return
+	}
+	if msg.err != nil {
+		a.statusMsg = "Error: " + pluginError(msg.err)
+	}
+	a.pluginFindings[msg.path] = msg.findings
+	if !a.quick && a.diffPanel.FilePath() == msg.path {
+		a.diffPanel.SetFindings(a.lineFindings(msg.path, a.diffPanel.DiffContent()))
+	}
+}
+
+// lineFindings gathers the problems flagged in a file's displayed diff:
+// suspicious Unicode, and whatever plugins flagged on the lines it shows
+func (a *App) lineFindings(path, content string) []findings.Finding {
+	fs := findings.Unicode(content)
+	if flagged := a.pluginFindings[path]; len(flagged) > 0 {
+		_, newLines := findings.LineIndexes(content)
+		for _, f := range flagged {
+			if i, ok := newLines[f.Line]; ok {
+				fs = append(fs, findings.Finding{Line: i, Rule: f.Rule, Message: f.Plugin + ": " + f.Message})
+			}
+		}
+	}
+	return fs
+}
+
+// openPluginMenu lists the actions and panels of the plugins running
+func (a *App) openPluginMenu() {
+	if !a.plugins.Configured() {
+		a.statusMsg = "No plugins configured · add them under [plugins] in config.toml"
+		return
+	}
+	a.pluginItems = a.plugins.Items()
+	items := make([]floating.PluginItem, len(a.pluginItems))
+	for i, it := range a.pluginItems {
+		items[i] = floating.PluginItem{Plugin: it.Plugin, Title: it.Title, Panel: it.Panel}
+	}
+	a.pluginMenu = floating.NewPluginMenu(items)
+	a.pluginMenu.SetSize(a.width, a.height)
+}
+
+// runPlugin runs a plugin's action, or renders its panel, on the cursor's
+// line in the background
+func (a *App) runPlugin(item plugin.Item) tea.Cmd {
+	loc := plugin.Location{Path: a.diffPanel.FilePath()}
+	if loc.Path != "" {
+		loc.Line = a.cursorSourceLine()
+		loc.Diff, _ = a.diffCache.Peek(loc.Path)
+	}
+	a.statusMsg = item.Title + "..."
+	return func() tea.Msg {
+		result, err := a.plugins.Run(a.ctx, item, loc)
+		return pluginRanMsg{item: item, loc: loc, result: result, err: err}
+	}
+}
+
+// pluginRanMsg carries the result of a plugin's action or panel
+type pluginRanMsg struct {
+	item   plugin.Item
+	loc    plugin.Location
+	result plugin.Result
+	err    error
+}
+
+// pluginRan shows what an action or panel returned: a status message, a
+// comment saved on the line it ran on, and text in a popup
+func (a *App) pluginRan(msg pluginRanMsg) tea.Cmd {
+	if msg.err != nil {
+		a.statusMsg = "Error: " + msg.err.Error()
+		return nil
+	}
+	a.statusMsg = msg.result.Message
+	var cmd tea.Cmd
+	if msg.result.Comment != "" && msg.loc.Path != "" {
+		status := msg.result.Message
+		if status == "" {
+			status = "Feedback saved by " + msg.item.Plugin
+		}
+		cmd = a.addComment(floating.FeedbackSavedMsg{FilePath: msg.loc.Path, LineNumber: msg.loc.Line, Comment: msg.result.Comment}, status)
+	}
+	if msg.item.Panel || msg.result.Text != "" {
+		a.detailsModal = floating.NewDetailsModal(msg.item.Title, msg.result.Text)
+		a.detailsModal.SetSize(a.width, a.height)
+	}
+	return cmd
+}
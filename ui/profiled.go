@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/profile"
+)
+
+// Profiled wraps a model and records how long each Update and View takes
+type Profiled struct {
+	model tea.Model
+	prof  *profile.Profiler
+}
+
+// NewProfiled wraps m, reporting timings to prof
+func NewProfiled(m tea.Model, prof *profile.Profiler) *Profiled {
+	return &Profiled{model: m, prof: prof}
+}
+
+func (p *Profiled) Init() tea.Cmd {
+	return p.model.Init()
+}
+
+func (p *Profiled) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	start := time.Now()
+	var cmd tea.Cmd
+	p.model, cmd = p.model.Update(msg)
+	p.prof.Observe(profile.KindUpdate, fmt.Sprintf("%T", msg), time.Since(start))
+	return p, cmd
+}
+
+func (p *Profiled) View() string {
+	start := time.Now()
+	view := p.model.View()
+	p.prof.Observe(profile.KindRender, "frame", time.Since(start))
+	return view
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/ui/floating"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// quickSection is one file's part of the combined quick-mode diff
+type quickSection struct {
+	path  string
+	start int // Index of the separator line that opens the section
+	end   int // Index just past the section's last line
+}
+
+// quickLoadedMsg carries the combined diff of every changed file
+type quickLoadedMsg struct {
+	content  string
+	sections []quickSection
+	gen      int
+}
+
+// SetQuick switches to quick mode: a single pane with every file's diff
+// one after another, for small changes and commit hooks. There's no files
+// panel, and search only looks through the diff on screen.
+func (a *App) SetQuick() {
+	a.quick = true
+}
+
+// quickSeparator is the line that opens a file's section
+func quickSeparator(f vcs.FileChange) string {
+	path := f.Path
+	if f.Moved() {
+		path = f.OldPath + " → " + f.NewPath
+	}
+	return fmt.Sprintf("━━━ %s %s ━━━", f.Status, path)
+}
+
+// loadQuick loads the diff of each file, in the background, and joins them
+// into one
+func (a *App) loadQuick(files []vcs.FileChange) tea.Cmd {
+	ctx, gen := a.diffCtx, a.diffGen
+	return func() tea.Msg {
+		var lines []string
+		var sections []quickSection
+		for _, f := range files {
+			content, err := a.vcs.Diff(ctx, f.Path)
+			if ctx.Err() != nil {
+				// Reloaded since; a newer load replaces this one
+				return nil
+			}
+			if err != nil {
+				return errMsg{fmt.Errorf("%s: %w", f.Path, err)}
+			}
+			s := quickSection{path: f.Path, start: len(lines)}
+			lines = append(lines, quickSeparator(f))
+			if content = strings.TrimRight(content, "\n"); content != "" {
+				lines = append(lines, strings.Split(content, "\n")...)
+			}
+			lines = append(lines, "")
+			s.end = len(lines)
+			sections = append(sections, s)
+		}
+		return quickLoadedMsg{content: strings.Join(lines, "\n"), sections: sections, gen: gen}
+	}
+}
+
+// showQuick displays the combined diff, keeping the cursor where it was
+func (a *App) showQuick() {
+	if len(a.quickSections) == 0 {
+		a.diffPanel.ClearDiff()
+		return
+	}
+	cursor := a.diffPanel.CursorLine()
+	a.diffPanel.SetDiff(a.quickSections[0].path, a.quickContent)
+	a.diffPanel.SetFindings(findings.Unicode(a.quickContent))
+	if cursor > 0 {
+		a.diffPanel.GotoLine(cursor)
+		a.diffPanel.Refresh()
+	}
+	a.syncQuickFile()
+	a.markComments()
+}
+
+// quickSectionAt returns the index of the section holding diff line i, or
+// -1 if there's none
+func (a *App) quickSectionAt(i int) int {
+	for n, s := range a.quickSections {
+		if i >= s.start && i < s.end {
+			return n
+		}
+	}
+	return -1
+}
+
+// quickSectionDiff returns the diff of section n, without its separator
+func (a *App) quickSectionDiff(n int) string {
+	s := a.quickSections[n]
+	lines := a.diffPanel.Lines()
+	return strings.Join(lines[min(s.start+1, len(lines)):min(s.end, len(lines))], "\n")
+}
+
+// syncQuickFile attributes the diff to the file under the cursor, so the
+// title names it and comments land on it
+func (a *App) syncQuickFile() {
+	if n := a.quickSectionAt(a.diffPanel.CursorLine()); n >= 0 && a.quickSections[n].path != a.diffPanel.FilePath() {
+		a.diffPanel.SetFilePath(a.quickSections[n].path)
+	}
+}
+
+// quickSourceLine returns the file line number under the cursor, counted
+// within the cursor's section the way the single-file view counts it. The
+// separator line comments on the whole file.
+func (a *App) quickSourceLine() int {
+	cursor := a.diffPanel.CursorLine()
+	n := a.quickSectionAt(cursor)
+	if n < 0 || cursor == a.quickSections[n].start {
+		return 0
+	}
+	return floating.CalculateLineNumber(a.quickSectionDiff(n), cursor-a.quickSections[n].start-1)
+}
+
+// quickComments counts the comments on each line of the combined diff
+func (a *App) quickComments() map[int]int {
+	lines := make(map[int]int)
+	for n, s := range a.quickSections {
+		for i, count := range commentLines(a.quickSectionDiff(n), s.path, a.saved) {
+			lines[s.start+1+i] = count
+		}
+	}
+	return lines
+}
+
+// quickJump moves the cursor to the start of the file delta files away
+func (a *App) quickJump(delta int) {
+	if len(a.quickSections) == 0 {
+		return
+	}
+	n := a.quickSectionAt(a.diffPanel.CursorLine())
+	n = max(min(n+delta, len(a.quickSections)-1), 0)
+	a.diffPanel.GotoLine(a.quickSections[n].start)
+	a.diffPanel.Refresh()
+}
+
+// quickGoto puts the cursor on a file's line in the combined diff, or on
+// the file's separator for a line the diff doesn't show
+func (a *App) quickGoto(path string, line int) bool {
+	for n, s := range a.quickSections {
+		if s.path != path {
+			continue
+		}
+		target := s.start
+		_, newLines := findings.LineIndexes(a.quickSectionDiff(n))
+		if i, ok := newLines[line]; ok {
+			target = s.start + 1 + i
+		}
+		a.diffPanel.GotoLine(target)
+		a.diffPanel.Refresh()
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gerunddev/tcr/record"
+	"github.com/gerunddev/tcr/ui/panels"
+)
+
+// Recorded wraps a model and records each frame it draws, and marks each
+// file opened, for --record. App.SetRecorder marks the comments.
+type Recorded struct {
+	model tea.Model
+	rec   *record.Recorder
+}
+
+// NewRecorded wraps m, writing the session to rec
+func NewRecorded(m tea.Model, rec *record.Recorder) *Recorded {
+	return &Recorded{model: m, rec: rec}
+}
+
+func (r *Recorded) Init() tea.Cmd {
+	return r.model.Init()
+}
+
+func (r *Recorded) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.rec.Resize(msg.Width, msg.Height)
+	case panels.FileSelectedMsg:
+		r.rec.Mark("open " + msg.Path)
+	}
+	var cmd tea.Cmd
+	r.model, cmd = r.model.Update(msg)
+	return r, cmd
+}
+
+func (r *Recorded) View() string {
+	view := r.model.View()
+	r.rec.Frame(view)
+	return view
+}
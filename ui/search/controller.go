@@ -23,6 +23,7 @@ type Controller struct {
 	input        textinput.Model   // Search input
 	query        string            // Current search query
 	filteredIdxs []int             // Indices of files that match (into original files list)
+	pending      []int             // Indices of files whose diffs weren't loaded to search yet
 	noMatches    bool              // True if search ran but found no matches
 	fzfError     string            // Error message if fzf unavailable
 	inputWidth   int               // Width for the input field
@@ -51,6 +52,7 @@ func (c *Controller) Activate() tea.Cmd {
 	c.active = true
 	c.query = ""
 	c.filteredIdxs = nil
+	c.pending = nil
 	c.noMatches = false
 	c.fzfError = ""
 	c.input.SetValue("")
@@ -63,6 +65,7 @@ func (c *Controller) Deactivate() {
 	c.active = false
 	c.query = ""
 	c.filteredIdxs = nil
+	c.pending = nil
 	c.noMatches = false
 	c.input.Blur()
 	c.input.SetValue("")
@@ -79,6 +82,12 @@ func (c *Controller) FilteredIndices() []int {
 	return c.filteredIdxs
 }
 
+// Pending returns the indices of files not searched yet because their
+// diffs are still loading. They're included in FilteredIndices.
+func (c *Controller) Pending() []int {
+	return c.pending
+}
+
 // HasNoMatches returns true if search was performed but found no matches
 func (c *Controller) HasNoMatches() bool {
 	return c.noMatches
@@ -114,21 +123,39 @@ func (c *Controller) Status() string {
 	if c.noMatches {
 		return "no matches"
 	}
-	if len(c.filteredIdxs) > 0 {
-		if len(c.filteredIdxs) == 1 {
-			return "1 file"
-		}
-		return fmt.Sprintf("%d files", len(c.filteredIdxs))
+	matched := len(c.filteredIdxs) - len(c.pending)
+	status := ""
+	switch {
+	case matched == 1:
+		status = "1 file"
+	case matched > 1 || len(c.pending) > 0:
+		status = fmt.Sprintf("%d files", matched)
 	}
-	return ""
+	if len(c.pending) > 0 {
+		status += fmt.Sprintf(", %d pending", len(c.pending))
+	}
+	return status
 }
 
+// DiffLookup returns the diff for a path, or false if it's still loading
+type DiffLookup func(path string) (string, bool)
+
 // SearchAllFiles runs fzf search across all diffs and returns matching file indices
 // diffs is a map from file path to diff content
 // files is the ordered list of file paths to preserve ordering
 func (c *Controller) SearchAllFiles(query string, files []string, diffs map[string]string) {
+	c.SearchFiles(query, files, func(path string) (string, bool) {
+		return diffs[path], true
+	})
+}
+
+// SearchFiles is like SearchAllFiles but fetches each diff through lookup,
+// so callers can serve diffs that aren't held in memory. Files whose diffs
+// are still loading are kept as pending until Resolve searches them.
+func (c *Controller) SearchFiles(query string, files []string, lookup DiffLookup) {
 	c.query = query
 	c.fzfError = ""
+	c.pending = nil
 
 	if query == "" {
 		c.filteredIdxs = nil
@@ -149,8 +176,13 @@ func (c *Controller) SearchAllFiles(query string, files []string, diffs map[stri
 
 	// Search each file's diff
 	for i, filePath := range files {
-		diffContent, ok := diffs[filePath]
-		if !ok || diffContent == "" {
+		diffContent, ok := lookup(filePath)
+		if !ok {
+			matchingIdxs = append(matchingIdxs, i)
+			c.pending = append(c.pending, i)
+			continue
+		}
+		if diffContent == "" {
 			continue
 		}
 
@@ -159,11 +191,38 @@ func (c *Controller) SearchAllFiles(query string, files []string, diffs map[stri
 		}
 	}
 
-	if len(matchingIdxs) == 0 {
+	c.setFiltered(matchingIdxs)
+}
+
+// Resolve searches a pending file once its diff has loaded, keeping it in
+// the results if it matches
+func (c *Controller) Resolve(index int, diffContent string) {
+	at := sort.SearchInts(c.pending, index)
+	if at == len(c.pending) || c.pending[at] != index {
+		return
+	}
+	c.pending = append(c.pending[:at:at], c.pending[at+1:]...)
+
+	fzfPath, err := exec.LookPath("fzf")
+	if err == nil && diffContent != "" && c.diffContainsMatch(fzfPath, c.query, diffContent) {
+		return
+	}
+	var kept []int
+	for _, i := range c.filteredIdxs {
+		if i != index {
+			kept = append(kept, i)
+		}
+	}
+	c.setFiltered(kept)
+}
+
+// setFiltered sets the files matched, or no matches when there are none
+func (c *Controller) setFiltered(indices []int) {
+	if len(indices) == 0 {
 		c.filteredIdxs = nil
 		c.noMatches = true
 	} else {
-		c.filteredIdxs = matchingIdxs
+		c.filteredIdxs = indices
 		c.noMatches = false
 	}
 }
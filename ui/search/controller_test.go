@@ -1,6 +1,8 @@
 package search
 
 import (
+	"os/exec"
+	"reflect"
 	"testing"
 )
 
@@ -115,6 +117,44 @@ func TestController_SearchAllFiles_NoMatches(t *testing.T) {
 	}
 }
 
+func TestController_SearchFiles_Pending(t *testing.T) {
+	if _, err := exec.LookPath("fzf"); err != nil {
+		t.Skip("fzf not installed")
+	}
+	c := NewController()
+	c.Activate()
+
+	files := []string{"a.go", "b.go", "c.go", "d.go"}
+	loaded := map[string]string{
+		"a.go": "func main() { foo() }",
+		"c.go": "func test() { bar() }",
+	}
+	c.SearchFiles("foo", files, func(path string) (string, bool) {
+		content, ok := loaded[path]
+		return content, ok
+	})
+
+	// Files still loading are kept, pending, until searched
+	if got := c.FilteredIndices(); !reflect.DeepEqual(got, []int{0, 1, 3}) {
+		t.Errorf("expected matches and pending files [0 1 3], got %v", got)
+	}
+	if got := c.Pending(); !reflect.DeepEqual(got, []int{1, 3}) {
+		t.Errorf("expected pending [1 3], got %v", got)
+	}
+	if got := c.Status(); got != "1 file, 2 pending" {
+		t.Errorf("expected '1 file, 2 pending', got %q", got)
+	}
+
+	c.Resolve(1, "func b() { foo() }")
+	c.Resolve(3, "func d() {}")
+	if got := c.FilteredIndices(); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("expected [0 1] once resolved, got %v", got)
+	}
+	if len(c.Pending()) != 0 || c.Status() != "2 files" {
+		t.Errorf("expected nothing pending and '2 files', got %v, %q", c.Pending(), c.Status())
+	}
+}
+
 func TestController_Status(t *testing.T) {
 	c := NewController()
 
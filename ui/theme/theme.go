@@ -2,142 +2,292 @@ package theme
 
 import "github.com/charmbracelet/lipgloss"
 
-// Monokai Pro color palette
+// Palette is a named set of base colors from which all styles are built
+type Palette struct {
+	Yellow     lipgloss.Color
+	Orange     lipgloss.Color
+	Red        lipgloss.Color
+	Magenta    lipgloss.Color
+	Blue       lipgloss.Color
+	Green      lipgloss.Color
+	White      lipgloss.Color
+	DimWhite   lipgloss.Color
+	Background lipgloss.Color
+	Surface    lipgloss.Color
+	Overlay    lipgloss.Color
+	MatchLine  lipgloss.Color // Background for search-matched lines
+	AddWord    lipgloss.Color // Background for words changed in added lines
+	RemoveWord lipgloss.Color // Background for words changed in removed lines
+}
+
+// Palettes holds the available themes by name
+var Palettes = map[string]Palette{
+	// Monokai Pro color palette
+	"monokai": {
+		Yellow:     lipgloss.Color("#FFD866"),
+		Orange:     lipgloss.Color("#FC9867"),
+		Red:        lipgloss.Color("#FF6188"),
+		Magenta:    lipgloss.Color("#AB9DF2"),
+		Blue:       lipgloss.Color("#78DCE8"),
+		Green:      lipgloss.Color("#A9DC76"),
+		White:      lipgloss.Color("#FCFCFA"),
+		DimWhite:   lipgloss.Color("#939293"),
+		Background: lipgloss.Color("#2D2A2E"),
+		Surface:    lipgloss.Color("#403E41"),
+		Overlay:    lipgloss.Color("#5B595C"),
+		MatchLine:  lipgloss.Color("#3D3A3E"), // Slightly lighter than background
+		AddWord:    lipgloss.Color("#3B4D31"),
+		RemoveWord: lipgloss.Color("#5A2D3A"),
+	},
+	// Light palette for light terminal backgrounds
+	"light": {
+		Yellow:     lipgloss.Color("#B58900"),
+		Orange:     lipgloss.Color("#CB4B16"),
+		Red:        lipgloss.Color("#DC322F"),
+		Magenta:    lipgloss.Color("#6C71C4"),
+		Blue:       lipgloss.Color("#268BD2"),
+		Green:      lipgloss.Color("#5F8700"),
+		White:      lipgloss.Color("#1C1C1C"),
+		DimWhite:   lipgloss.Color("#6C6C6C"),
+		Background: lipgloss.Color("#FDF6E3"),
+		Surface:    lipgloss.Color("#EEE8D5"),
+		Overlay:    lipgloss.Color("#D6CFBA"),
+		MatchLine:  lipgloss.Color("#F5EFDC"),
+		AddWord:    lipgloss.Color("#DCEBC0"),
+		RemoveWord: lipgloss.Color("#F6D3CF"),
+	},
+}
+
+// DefaultTheme is the palette used when none is configured
+const DefaultTheme = "monokai"
+
+// Base colors of the active palette
 var (
-	ColorYellow     = lipgloss.Color("#FFD866")
-	ColorOrange     = lipgloss.Color("#FC9867")
-	ColorRed        = lipgloss.Color("#FF6188")
-	ColorMagenta    = lipgloss.Color("#AB9DF2")
-	ColorBlue       = lipgloss.Color("#78DCE8")
-	ColorGreen      = lipgloss.Color("#A9DC76")
-	ColorWhite      = lipgloss.Color("#FCFCFA")
-	ColorDimWhite   = lipgloss.Color("#939293")
-	ColorBackground = lipgloss.Color("#2D2A2E")
-	ColorSurface    = lipgloss.Color("#403E41")
-	ColorOverlay    = lipgloss.Color("#5B595C")
+	ColorYellow     lipgloss.Color
+	ColorOrange     lipgloss.Color
+	ColorRed        lipgloss.Color
+	ColorMagenta    lipgloss.Color
+	ColorBlue       lipgloss.Color
+	ColorGreen      lipgloss.Color
+	ColorWhite      lipgloss.Color
+	ColorDimWhite   lipgloss.Color
+	ColorBackground lipgloss.Color
+	ColorSurface    lipgloss.Color
+	ColorOverlay    lipgloss.Color
 )
 
 // Panel styles
 var (
 	// Focused panel border
-	FocusedBorder = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(ColorYellow)
+	FocusedBorder lipgloss.Style
 
 	// Unfocused panel border
-	UnfocusedBorder = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(ColorDimWhite)
+	UnfocusedBorder lipgloss.Style
 
 	// Panel title style
-	TitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(ColorWhite).
-			Background(ColorSurface).
-			Padding(0, 1)
+	TitleStyle lipgloss.Style
 
 	// Focused title style
-	FocusedTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(ColorBackground).
-				Background(ColorYellow).
-				Padding(0, 1)
+	FocusedTitleStyle lipgloss.Style
 )
 
 // List item styles
 var (
 	// Selected item in a list
-	SelectedItemStyle = lipgloss.NewStyle().
-				Foreground(ColorYellow).
-				Bold(true)
+	SelectedItemStyle lipgloss.Style
 
 	// Normal item in a list
-	NormalItemStyle = lipgloss.NewStyle().
-			Foreground(ColorWhite)
+	NormalItemStyle lipgloss.Style
 
 	// Dimmed/secondary text
-	DimmedStyle = lipgloss.NewStyle().
-			Foreground(ColorDimWhite)
+	DimmedStyle lipgloss.Style
 )
 
 // File status styles
 var (
-	ModifiedStyle = lipgloss.NewStyle().Foreground(ColorOrange)
-	AddedStyle    = lipgloss.NewStyle().Foreground(ColorGreen)
-	DeletedStyle  = lipgloss.NewStyle().Foreground(ColorRed)
-	RenamedStyle  = lipgloss.NewStyle().Foreground(ColorBlue)
-	ConflictStyle = lipgloss.NewStyle().Foreground(ColorMagenta).Bold(true)
+	ModifiedStyle lipgloss.Style
+	AddedStyle    lipgloss.Style
+	DeletedStyle  lipgloss.Style
+	RenamedStyle  lipgloss.Style
+	ConflictStyle lipgloss.Style
+	ModeStyle     lipgloss.Style // Permissions changed, contents not
+
+	// Bar showing how many lines a file changes, next to the most changed
+	HeatStyle lipgloss.Style
 )
 
 // Diff styles
 var (
-	DiffAddLine     = lipgloss.NewStyle().Foreground(ColorGreen)
-	DiffRemoveLine  = lipgloss.NewStyle().Foreground(ColorRed)
-	DiffContextLine = lipgloss.NewStyle().Foreground(ColorDimWhite)
-	DiffHunkHeader  = lipgloss.NewStyle().Foreground(ColorBlue).Bold(true)
+	DiffAddLine     lipgloss.Style
+	DiffRemoveLine  lipgloss.Style
+	DiffContextLine lipgloss.Style
+	DiffHunkHeader  lipgloss.Style
+
+	// Words changed within a paired removed and added line
+	DiffAddWord    lipgloss.Style
+	DiffRemoveWord lipgloss.Style
+
+	// Gutter mark on lines commented in this review
+	CommentMarkStyle lipgloss.Style
+
+	// Gentle note that feedback is over a soft limit
+	BudgetStyle lipgloss.Style
 )
 
 // Cursor highlight styles - using Reverse for guaranteed visibility over text
 var (
-	CursorLineStyle       = lipgloss.NewStyle().Reverse(true)
-	CursorAddLineStyle    = lipgloss.NewStyle().Reverse(true).Foreground(ColorGreen)
-	CursorRemoveLineStyle = lipgloss.NewStyle().Reverse(true).Foreground(ColorRed)
-	CursorContextStyle    = lipgloss.NewStyle().Reverse(true).Foreground(ColorDimWhite)
-	CursorHunkStyle       = lipgloss.NewStyle().Reverse(true).Foreground(ColorBlue).Bold(true)
+	CursorLineStyle       lipgloss.Style
+	CursorAddLineStyle    lipgloss.Style
+	CursorRemoveLineStyle lipgloss.Style
+	CursorContextStyle    lipgloss.Style
+	CursorHunkStyle       lipgloss.Style
 )
 
 // Search styles
 var (
 	// Background highlight for matched lines
-	SearchMatchLineStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("#3D3A3E")) // Slightly lighter than background
+	SearchMatchLineStyle lipgloss.Style
 
 	// Current match line (more prominent)
-	SearchCurrentLineStyle = lipgloss.NewStyle().
-				Background(ColorSurface).
-				Bold(true)
+	SearchCurrentLineStyle lipgloss.Style
 
 	// Search bar styles
-	SearchBarStyle = lipgloss.NewStyle().
-			Foreground(ColorDimWhite).
-			Background(ColorSurface)
-
-	SearchPromptStyle = lipgloss.NewStyle().
-				Foreground(ColorYellow).
-				Bold(true)
-
-	SearchStatusStyle = lipgloss.NewStyle().
-				Foreground(ColorDimWhite)
+	SearchBarStyle    lipgloss.Style
+	SearchPromptStyle lipgloss.Style
+	SearchStatusStyle lipgloss.Style
 )
 
 // Floating window styles
 var (
-	FloatingWindowStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(ColorYellow).
-				Background(ColorBackground)
-
-	FloatingTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(ColorBackground).
-				Background(ColorYellow).
-				Padding(0, 1)
+	FloatingWindowStyle lipgloss.Style
+	FloatingTitleStyle  lipgloss.Style
 )
 
 // Help bar style
 var (
-	HelpBarStyle = lipgloss.NewStyle().
-			Foreground(ColorDimWhite)
-
-	HelpKeyStyle = lipgloss.NewStyle().
-			Foreground(ColorYellow).
-			Bold(true)
-
-	HelpDescStyle = lipgloss.NewStyle().
-			Foreground(ColorDimWhite)
+	HelpBarStyle  lipgloss.Style
+	HelpKeyStyle  lipgloss.Style
+	HelpDescStyle lipgloss.Style
 )
 
 // Layout constants
 const (
 	SidebarWidth = 30
 )
+
+// generation counts Apply calls so renderers can drop cached output
+var generation int
+
+func init() {
+	Apply(DefaultTheme)
+}
+
+// Generation returns a value that changes every time the theme is applied
+func Generation() int {
+	return generation
+}
+
+// Apply switches the active palette and rebuilds every style from it.
+// Unknown names fall back to the default theme. Returns the name applied.
+func Apply(name string) string {
+	p, ok := Palettes[name]
+	if !ok {
+		name = DefaultTheme
+		p = Palettes[name]
+	}
+
+	ColorYellow = p.Yellow
+	ColorOrange = p.Orange
+	ColorRed = p.Red
+	ColorMagenta = p.Magenta
+	ColorBlue = p.Blue
+	ColorGreen = p.Green
+	ColorWhite = p.White
+	ColorDimWhite = p.DimWhite
+	ColorBackground = p.Background
+	ColorSurface = p.Surface
+	ColorOverlay = p.Overlay
+
+	FocusedBorder = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorYellow)
+	UnfocusedBorder = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorDimWhite)
+	TitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ColorWhite).
+		Background(ColorSurface).
+		Padding(0, 1)
+	FocusedTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ColorBackground).
+		Background(ColorYellow).
+		Padding(0, 1)
+
+	SelectedItemStyle = lipgloss.NewStyle().
+		Foreground(ColorYellow).
+		Bold(true)
+	NormalItemStyle = lipgloss.NewStyle().
+		Foreground(ColorWhite)
+	DimmedStyle = lipgloss.NewStyle().
+		Foreground(ColorDimWhite)
+
+	ModifiedStyle = lipgloss.NewStyle().Foreground(ColorOrange)
+	AddedStyle = lipgloss.NewStyle().Foreground(ColorGreen)
+	DeletedStyle = lipgloss.NewStyle().Foreground(ColorRed)
+	RenamedStyle = lipgloss.NewStyle().Foreground(ColorBlue)
+	ConflictStyle = lipgloss.NewStyle().Foreground(ColorMagenta).Bold(true)
+	ModeStyle = lipgloss.NewStyle().Foreground(ColorYellow)
+	HeatStyle = lipgloss.NewStyle().Foreground(ColorOrange)
+
+	DiffAddLine = lipgloss.NewStyle().Foreground(ColorGreen)
+	DiffRemoveLine = lipgloss.NewStyle().Foreground(ColorRed)
+	DiffContextLine = lipgloss.NewStyle().Foreground(ColorDimWhite)
+	DiffHunkHeader = lipgloss.NewStyle().Foreground(ColorBlue).Bold(true)
+	DiffAddWord = lipgloss.NewStyle().Background(p.AddWord).Bold(true)
+	DiffRemoveWord = lipgloss.NewStyle().Background(p.RemoveWord).Bold(true)
+	CommentMarkStyle = lipgloss.NewStyle().Foreground(ColorMagenta).Bold(true)
+	BudgetStyle = lipgloss.NewStyle().Foreground(ColorYellow)
+
+	CursorLineStyle = lipgloss.NewStyle().Reverse(true)
+	CursorAddLineStyle = lipgloss.NewStyle().Reverse(true).Foreground(ColorGreen)
+	CursorRemoveLineStyle = lipgloss.NewStyle().Reverse(true).Foreground(ColorRed)
+	CursorContextStyle = lipgloss.NewStyle().Reverse(true).Foreground(ColorDimWhite)
+	CursorHunkStyle = lipgloss.NewStyle().Reverse(true).Foreground(ColorBlue).Bold(true)
+
+	SearchMatchLineStyle = lipgloss.NewStyle().
+		Background(p.MatchLine)
+	SearchCurrentLineStyle = lipgloss.NewStyle().
+		Background(ColorSurface).
+		Bold(true)
+	SearchBarStyle = lipgloss.NewStyle().
+		Foreground(ColorDimWhite).
+		Background(ColorSurface)
+	SearchPromptStyle = lipgloss.NewStyle().
+		Foreground(ColorYellow).
+		Bold(true)
+	SearchStatusStyle = lipgloss.NewStyle().
+		Foreground(ColorDimWhite)
+
+	FloatingWindowStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(ColorYellow).
+		Background(ColorBackground)
+	FloatingTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(ColorBackground).
+		Background(ColorYellow).
+		Padding(0, 1)
+
+	HelpBarStyle = lipgloss.NewStyle().
+		Foreground(ColorDimWhite)
+	HelpKeyStyle = lipgloss.NewStyle().
+		Foreground(ColorYellow).
+		Bold(true)
+	HelpDescStyle = lipgloss.NewStyle().
+		Foreground(ColorDimWhite)
+
+	generation++
+	return name
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/findings"
+	"github.com/gerunddev/tcr/risk"
+	"github.com/gerunddev/tcr/ui/panels"
+	"github.com/gerunddev/tcr/ui/theme"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+// RenderView renders every changed file's diff for tcr view: one after
+// another under a separator line, as in quick mode, each prepared and
+// styled as the diff panel shows it. A positive width wraps or cuts lines
+// to it.
+func RenderView(ctx context.Context, v vcs.VCS, cfg config.Config, width int) (string, error) {
+	theme.Apply(cfg.Theme)
+	files, err := v.ChangedFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	panel := panels.NewDiffPanel()
+	panel.SetWrap(cfg.Wrap)
+	panel.SetWordDiff(cfg.WordDiff)
+	panel.SetHighlights(highlightRules(cfg))
+	files = vcs.SortChanges(files, vcs.Order(cfg.FileOrder))
+	if cfg.FileOrder == "risk" {
+		stats, err := vcs.DiffStat(ctx, v)
+		if err != nil {
+			return "", err
+		}
+		churn, err := vcs.Churn(ctx, v)
+		if err != nil {
+			return "", err
+		}
+		files = risk.Sort(files, risk.Scores(files, stats, churn))
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		content, err := v.Diff(ctx, f.Path)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", f.Path, err)
+		}
+		b.WriteString(theme.DiffHunkHeader.Bold(true).Render(quickSeparator(f)) + "\n")
+		content, noise := prepareDiff(f.Path, strings.TrimRight(content, "\n"), cfg, false, false, nil)
+		if content == "" {
+			continue
+		}
+		panel.SetDiff(f.Path, content)
+		panel.SetFindings(findings.Unicode(content))
+		panel.SetFormatNoise(noise)
+		b.WriteString(panel.Print(width) + "\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,253 @@
+// Package update checks GitHub releases for newer tcr builds and installs them
+package update
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Repo is the GitHub repository releases are published to
+const Repo = "gerunddev/tcr"
+
+// APIBase is the GitHub API root, overridable for tests
+var APIBase = "https://api.github.com"
+
+// Asset is a downloadable file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Release is the subset of the GitHub release payload tcr uses
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	URL    string  `json:"html_url"`
+	Assets []Asset `json:"assets"`
+}
+
+// Latest fetches the most recent published release
+func Latest(ctx context.Context, client *http.Client) (Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", APIBase, Repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Release{}, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("failed to check for updates: %s", resp.Status)
+	}
+
+	var r Release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return Release{}, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return r, nil
+}
+
+// AssetFor returns the release asset built for the given platform.
+// Assets are expected to be named tcr_<os>_<arch>, optionally with
+// .exe or .tar.gz appended.
+func (r Release) AssetFor(goos, goarch string) (Asset, bool) {
+	prefix := fmt.Sprintf("tcr_%s_%s", goos, goarch)
+	for _, a := range r.Assets {
+		if !strings.HasPrefix(a.Name, prefix) {
+			continue
+		}
+		switch strings.TrimPrefix(a.Name, prefix) {
+		case "", ".exe", ".tar.gz":
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// ChecksumsAsset returns the release's SHA-256 checksums file, named
+// checksums.txt or ending in _checksums.txt
+func (r Release) ChecksumsAsset() (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == "checksums.txt" || strings.HasSuffix(a.Name, "_checksums.txt") {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Newer reports whether latest is a higher semantic version than current.
+// Unparseable versions (such as "dev") are never considered older.
+func Newer(current, latest string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range c {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses "v1.2.3" (pre-release suffixes ignored)
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
+
+// Install downloads asset from rel and atomically replaces the binary at
+// exePath. The download must match its SHA-256 in the release's checksums
+// file; without one, or on a mismatch, nothing is replaced.
+func Install(ctx context.Context, client *http.Client, rel Release, asset Asset, exePath string) error {
+	sums, ok := rel.ChecksumsAsset()
+	if !ok {
+		return fmt.Errorf("release %s has no checksums file to verify %s against", rel.Tag, asset.Name)
+	}
+	want, err := checksum(ctx, client, sums, asset.Name)
+	if err != nil {
+		return err
+	}
+
+	resp, err := download(ctx, client, asset)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Hash the download as it's read, archive and all
+	hash := sha256.New()
+	raw := io.TeeReader(resp.Body, hash)
+	body := raw
+	if strings.HasSuffix(asset.Name, ".tar.gz") {
+		body, err = extractBinary(raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write next to the target so the rename stays on one filesystem
+	tmp, err := os.CreateTemp(filepath.Dir(exePath), ".tcr-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// The rest of an archive past the binary counts toward the checksum
+	if _, err := io.Copy(io.Discard, raw); err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", asset.Name, want, got)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), exePath); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", exePath, err)
+	}
+	return nil
+}
+
+// download starts fetching asset
+func download(ctx context.Context, client *http.Client, asset Asset) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", asset.Name, resp.Status)
+	}
+	return resp, nil
+}
+
+// checksum returns name's SHA-256 from a checksums file of
+// "<hex digest>  <file name>" lines, as sha256sum writes them
+func checksum(ctx context.Context, client *http.Client, sums Asset, name string) (string, error) {
+	resp, err := download(ctx, client, sums)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", sums.Name, err)
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", sums.Name, name)
+}
+
+// extractBinary returns the tcr executable from a gzipped tarball
+func extractBinary(r io.Reader) (io.Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("archive does not contain a tcr binary")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		name := filepath.Base(hdr.Name)
+		if hdr.Typeflag == tar.TypeReg && (name == "tcr" || name == "tcr.exe") {
+			return tr, nil
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.0.0", "v1.0.1", true},
+		{"v1.2.0", "v1.10.0", true},
+		{"1.2.3", "v2.0.0", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v1.3.0", "v1.2.9", false},
+		{"v1.2.3-rc1", "v1.2.3", false},
+		{"dev", "v1.0.0", false},
+		{"v1.0.0", "nightly", false},
+	}
+
+	for _, tt := range tests {
+		if got := Newer(tt.current, tt.latest); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestAssetFor(t *testing.T) {
+	r := Release{Assets: []Asset{
+		{Name: "checksums.txt"},
+		{Name: "tcr_linux_amd64.tar.gz"},
+		{Name: "tcr_linux_arm64"},
+		{Name: "tcr_windows_amd64.exe"},
+	}}
+
+	tests := []struct {
+		goos, goarch, want string
+	}{
+		{"linux", "amd64", "tcr_linux_amd64.tar.gz"},
+		{"linux", "arm64", "tcr_linux_arm64"},
+		{"windows", "amd64", "tcr_windows_amd64.exe"},
+		{"darwin", "arm64", ""},
+	}
+
+	for _, tt := range tests {
+		a, ok := r.AssetFor(tt.goos, tt.goarch)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%s/%s: expected no asset, got %q", tt.goos, tt.goarch, a.Name)
+			}
+			continue
+		}
+		if !ok || a.Name != tt.want {
+			t.Errorf("%s/%s: expected %q, got %q (ok=%v)", tt.goos, tt.goarch, tt.want, a.Name, ok)
+		}
+	}
+}
+
+// releaseServer serves a release with a linux/amd64 archive holding
+// payload, and checksums unless sums is ""; "%x" in sums is replaced by
+// the archive's SHA-256
+func releaseServer(t *testing.T, payload []byte, sums string) *httptest.Server {
+	t.Helper()
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "tcr", Mode: 0755, Size: int64(len(payload)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(payload)
+	_ = tw.Close()
+	_ = gz.Close()
+	sums = strings.ReplaceAll(sums, "%x", fmt.Sprintf("%x", sha256.Sum256(archive.Bytes())))
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + Repo + "/releases/latest":
+			assets := fmt.Sprintf(`{"name":"tcr_linux_amd64.tar.gz","browser_download_url":"%s/dl"}`, srv.URL)
+			if sums != "" {
+				assets += fmt.Sprintf(`,{"name":"tcr_9.9.9_checksums.txt","browser_download_url":"%s/sums"}`, srv.URL)
+			}
+			fmt.Fprintf(w, `{"tag_name":"v9.9.9","assets":[%s]}`, assets)
+		case "/dl":
+			_, _ = w.Write(archive.Bytes())
+		case "/sums":
+			_, _ = fmt.Fprint(w, sums)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	old := APIBase
+	APIBase = srv.URL
+	t.Cleanup(func() { APIBase = old })
+	return srv
+}
+
+func TestLatestAndInstall(t *testing.T) {
+	payload := []byte("new binary")
+	srv := releaseServer(t, payload, "0000  tcr_darwin_arm64.tar.gz\n%x  tcr_linux_amd64.tar.gz\n")
+
+	rel, err := Latest(context.Background(), srv.Client())
+	if err != nil {
+		t.Fatalf("Latest failed: %v", err)
+	}
+	if rel.Tag != "v9.9.9" {
+		t.Errorf("expected tag v9.9.9, got %q", rel.Tag)
+	}
+
+	asset, ok := rel.AssetFor("linux", "amd64")
+	if !ok {
+		t.Fatal("expected linux/amd64 asset")
+	}
+
+	exe := filepath.Join(t.TempDir(), "tcr")
+	if err := os.WriteFile(exe, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Install(context.Background(), srv.Client(), rel, asset, exe); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	got, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(payload) {
+		t.Errorf("expected replaced binary %q, got %q", payload, got)
+	}
+}
+
+func TestInstallRefusesUnverified(t *testing.T) {
+	tests := []struct {
+		name string
+		sums string
+		want string
+	}{
+		{"no checksums file", "", "no checksums file"},
+		{"mismatch", strings.Repeat("ab", 32) + "  tcr_linux_amd64.tar.gz\n", "checksum mismatch"},
+		{"not listed", "%x  tcr_darwin_arm64.tar.gz\n", "no checksum for tcr_linux_amd64.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := releaseServer(t, []byte("new binary"), tt.sums)
+			rel, err := Latest(context.Background(), srv.Client())
+			if err != nil {
+				t.Fatalf("Latest failed: %v", err)
+			}
+			asset, _ := rel.AssetFor("linux", "amd64")
+
+			exe := filepath.Join(t.TempDir(), "tcr")
+			if err := os.WriteFile(exe, []byte("old binary"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			err = Install(context.Background(), srv.Client(), rel, asset, exe)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+			if got, _ := os.ReadFile(exe); string(got) != "old binary" {
+				t.Errorf("expected the binary left alone, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package vcs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// archiveKinds maps archive file extensions to how they're unpacked
+var archiveKinds = []struct {
+	ext  string
+	kind string
+}{
+	{".tar.gz", "tgz"}, {".tgz", "tgz"},
+	{".tar.bz2", "tbz"}, {".tbz2", "tbz"},
+	{".tar.zst", "tzst"}, {".tzst", "tzst"},
+	{".tar", "tar"},
+	{".zip", "zip"}, {".jar", "zip"}, {".whl", "zip"},
+}
+
+// archiveKind returns how to unpack the archive at p, or "" if p isn't a
+// recognised archive
+func archiveKind(p string) string {
+	lower := strings.ToLower(p)
+	for _, k := range archiveKinds {
+		if strings.HasSuffix(lower, k.ext) {
+			return k.kind
+		}
+	}
+	return ""
+}
+
+// IsArchive reports whether p names a regular file tcr can unpack for
+// comparison
+func IsArchive(p string) bool {
+	if archiveKind(p) == "" {
+		return false
+	}
+	info, err := os.Stat(p)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// extractArchive unpacks the archive at src into a new temporary directory
+// and returns the directory to compare: the archive's single top-level
+// directory if it has one, as release tarballs usually do, so that
+// pkg-1.0/ and pkg-1.1/ line up. The caller removes tmp when done.
+func extractArchive(src string) (root, tmp string, err error) {
+	tmp, err = os.MkdirTemp("", "tcr-archive-*")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	switch archiveKind(src) {
+	case "zip":
+		err = extractZip(src, tmp)
+	default:
+		err = extractTar(src, tmp)
+	}
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", "", fmt.Errorf("failed to extract %s: %w", src, err)
+	}
+
+	root = tmp
+	if entries, err := os.ReadDir(tmp); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(tmp, entries[0].Name())
+	}
+	return root, tmp, nil
+}
+
+// extractTar unpacks a plain or compressed tarball. Only directories and
+// regular files are extracted; links and devices are skipped.
+func extractTar(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch archiveKind(src) {
+	case "tgz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case "tbz":
+		r = bzip2.NewReader(f)
+	case "tzst":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := entryPath(dst, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeEntry(target, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// extractZip unpacks a zip archive (including jars and wheels)
+func extractZip(src, dst string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		target, err := entryPath(dst, f.Name)
+		if err != nil {
+			return err
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeEntry(target, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryPath resolves an archive entry name under dst, refusing absolute
+// names and names that climb out of it
+func entryPath(dst, name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("unsafe path in archive: %s", name)
+	}
+	return filepath.Join(dst, filepath.FromSlash(clean)), nil
+}
+
+// writeEntry writes one extracted file, creating its parent directories
+func writeEntry(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
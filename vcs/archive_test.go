@@ -0,0 +1,144 @@
+package vcs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTarGz writes a gzipped tarball of name -> content
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeZip writes a zip archive of name -> content
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareArchives(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+	dir := t.TempDir()
+	oldArchive := filepath.Join(dir, "pkg-1.0.tar.gz")
+	newArchive := filepath.Join(dir, "pkg-1.1.zip")
+
+	// Differently named top-level directories still line up
+	writeTarGz(t, oldArchive, map[string]string{
+		"pkg-1.0/README":      "pkg\n",
+		"pkg-1.0/src/main.go": "package main\n\nfunc main() {}\n",
+	})
+	writeZip(t, newArchive, map[string]string{
+		"pkg-1.1/README":      "pkg\n",
+		"pkg-1.1/src/main.go": "package main\n\nfunc main() { run(context.Background(), ) }\n",
+		"pkg-1.1/src/run.go":  "package main\n",
+	})
+
+	c, err := NewCompare(oldArchive, newArchive, Options{})
+	if err != nil {
+		t.Fatalf("NewCompare: %v", err)
+	}
+	oldDir, newDir := c.Dirs()
+
+	changes, err := c.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	want := []FileChange{
+		{Path: "src/main.go", Status: StatusModified},
+		{Path: "src/run.go", Status: StatusAdded},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("ChangedFiles = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %v, want %v", i, changes[i], want[i])
+		}
+	}
+	if diff, _ := c.Diff(context.Background(), "src/main.go"); !strings.Contains(diff, "+func main() { run(context.Background(), ) }") {
+		t.Errorf("diff:\n%s", diff)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for _, d := range []string{oldDir, newDir} {
+		if _, err := os.Stat(d); !os.IsNotExist(err) {
+			t.Errorf("%s not removed by Close", d)
+		}
+	}
+}
+
+func TestExtractArchiveRejectsEscapes(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil.tar.gz")
+	writeTarGz(t, archive, map[string]string{"../escape.txt": "x"})
+
+	if _, _, err := extractArchive(archive); err == nil {
+		t.Error("expected an error for an entry outside the archive root")
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.tar", "a.TGZ", "a.tar.zst", "a.jar"} {
+		writeTestFile(t, dir, name, "")
+		if !IsArchive(filepath.Join(dir, name)) {
+			t.Errorf("IsArchive(%q) = false", name)
+		}
+	}
+	writeTestFile(t, dir, "a.txt", "")
+	if IsArchive(filepath.Join(dir, "a.txt")) {
+		t.Error("IsArchive(a.txt) = true")
+	}
+	if IsArchive(filepath.Join(dir, "missing.zip")) {
+		t.Error("IsArchive should be false for missing files")
+	}
+}
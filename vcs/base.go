@@ -0,0 +1,85 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// BaseError reports a base revision that doesn't resolve, as in a shallow
+// clone without the commit a branch started from, or a jj repository
+// without a trunk bookmark. The working copy alone needs no base, and
+// Fallback holds the options that review it instead.
+type BaseError struct {
+	Base     string   // The revision or revset that didn't resolve
+	Shallow  bool     // The repository is a shallow clone
+	Err      error    // Why it didn't resolve
+	Hints    []string // What would make it resolve
+	Fallback Options  // The options reviewing the working copy alone
+}
+
+func (e *BaseError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Err.Error())
+	for _, hint := range e.Hints {
+		b.WriteString("\nHint: " + hint)
+	}
+	return b.String()
+}
+
+func (e *BaseError) Unwrap() error {
+	return e.Err
+}
+
+// baseError explains a range whose start doesn't resolve, or returns nil
+// for one that does, having failed for another reason
+func (g *Git) baseError(ctx context.Context) error {
+	opts := g.Options()
+	args := g.rangeArgs(ctx)
+	from := args[0]
+	if from == emptyTree || g.shortRev(ctx, from) != "" || ctx.Err() != nil {
+		return nil
+	}
+
+	output, _ := run(ctx, g.dir, "git", "rev-parse", "--is-shallow-repository")
+	shallow := strings.TrimSpace(string(output)) == "true"
+	var hints []string
+	if shallow {
+		hints = append(hints, "This is a shallow clone, which may not have "+from+": git fetch --unshallow fetches the rest of the history")
+	} else {
+		hints = append(hints, "Check that "+from+" names a branch, tag or commit in this repository")
+	}
+	if branch, ok := strings.CutPrefix(from, "origin/"); ok {
+		hints = append(hints, fmt.Sprintf("Fetch the branch: git fetch origin %s:refs/remotes/origin/%s", branch, branch))
+	} else {
+		hints = append(hints, "A commit only on the remote needs fetching first: git fetch origin")
+	}
+
+	fallback := opts
+	fallback.From, fallback.To = "", ""
+	return &BaseError{
+		Base:     from,
+		Shallow:  shallow,
+		Err:      fmt.Errorf("failed to resolve base revision: unknown revision %s", from),
+		Hints:    hints,
+		Fallback: fallback,
+	}
+}
+
+// baseError explains a base revset that doesn't resolve, with hint first
+// among the remedies
+func (j *JJ) baseError(revset string, err error, hint string) *BaseError {
+	opts := j.Options()
+	hints := []string{hint}
+	if opts.BaseRevset == "" {
+		hints = append(hints, "Bookmarks on the remote, trunk() among them, come with: jj git fetch")
+	}
+	fallback := opts
+	fallback.From, fallback.To, fallback.BaseRevset = "", "", "@-"
+	return &BaseError{
+		Base:     revset,
+		Err:      err,
+		Hints:    hints,
+		Fallback: fallback,
+	}
+}
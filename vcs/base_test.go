@@ -0,0 +1,38 @@
+package vcs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestBaseError(t *testing.T) {
+	err := &BaseError{
+		Base:  "trunk()",
+		Err:   fs.ErrNotExist,
+		Hints: []string{"Create a bookmark", "jj git fetch"},
+	}
+	if want := "file does not exist\nHint: Create a bookmark\nHint: jj git fetch"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected the cause to unwrap")
+	}
+}
+
+func TestJJBaseError(t *testing.T) {
+	j := &JJ{opts: Options{To: "@", ContextLines: 5}}
+	err := j.baseError("trunk()", errors.New("no base revision found"), "Create a bookmark")
+	if len(err.Hints) != 2 || err.Hints[0] != "Create a bookmark" {
+		t.Errorf("expected the hint, then fetching, got %q", err.Hints)
+	}
+	// The working copy against its parent, keeping the other options
+	if f := err.Fallback; f.From != "" || f.To != "" || f.BaseRevset != "@-" || f.ContextLines != 5 {
+		t.Errorf("Fallback = %+v", err.Fallback)
+	}
+
+	j.opts.BaseRevset = "main"
+	if err := j.baseError("main", errors.New("revset is empty"), "Check the revset"); len(err.Hints) != 1 {
+		t.Errorf("a revset given needs no fetching, got %q", err.Hints)
+	}
+}
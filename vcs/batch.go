@@ -0,0 +1,115 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gerunddev/tcr/notebook"
+)
+
+// BatchDiffer is implemented by backends that diff several files with one
+// command, faster than a command per file
+type BatchDiffer interface {
+	DiffFiles(ctx context.Context, paths []string) (map[string]string, error) // Diff per path
+}
+
+// DiffFiles returns the diffs of paths, by path, in one command if v
+// supports it or else one file at a time
+func DiffFiles(ctx context.Context, v VCS, paths []string) (map[string]string, error) {
+	if b, ok := v.(BatchDiffer); ok {
+		return b.DiffFiles(ctx, paths)
+	}
+	diffs := make(map[string]string, len(paths))
+	for _, path := range paths {
+		diff, err := v.Diff(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		diffs[path] = diff
+	}
+	return diffs, nil
+}
+
+// splitDiffs diffs paths with one command through diff, which is given
+// the paths to diff, and splits its output by file. Paths that need their
+// own diff, and any the output leaves out, go through single instead.
+func splitDiffs(ctx context.Context, name string, opts Options, paths []string, own func(path string) bool,
+	diff func(paths []string) (string, error), single func(ctx context.Context, path string) (string, error)) (map[string]string, error) {
+	diffs := make(map[string]string, len(paths))
+	var batch, rest []string
+	for _, path := range paths {
+		// Diff tools and notebooks render each file themselves
+		if own(path) || toolFor(opts, path) != "" || notebook.Recognized(path) {
+			rest = append(rest, path)
+		} else {
+			batch = append(batch, path)
+		}
+	}
+
+	if len(batch) > 0 {
+		output, err := diff(batch)
+		if err != nil {
+			return nil, err
+		}
+		// An empty diff has no sections, which NewPatch reports as an error
+		sections := make(map[string]string)
+		if patch, err := NewPatch(name, output); err == nil {
+			sections = patch.diffs
+		}
+		for _, path := range batch {
+			if section, ok := sections[path]; ok {
+				diffs[path] = section
+			} else {
+				// Untracked, quoted by git, or not changed at all
+				rest = append(rest, path)
+			}
+		}
+	}
+
+	for _, path := range rest {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		diff, err := single(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		diffs[path] = diff
+	}
+	return diffs, nil
+}
+
+// DiffFiles diffs paths with one git diff, except renamed and copied files,
+// which need the file they came from, and untracked ones
+func (g *Git) DiffFiles(ctx context.Context, paths []string) (map[string]string, error) {
+	opts := g.Options()
+	moved := func(path string) bool { return len(g.renames.paths(path)) > 1 }
+	diff := func(batch []string) (string, error) {
+		// Like a diff per file, which sees no other file to pair as a rename
+		return g.diff(ctx, append([]string{"--no-renames", "--"}, batch...)...)
+	}
+	return splitDiffs(ctx, "git", opts, paths, moved, diff, g.Diff)
+}
+
+// DiffFiles diffs paths, and where renamed and copied files came from,
+// with one jj diff
+func (j *JJ) DiffFiles(ctx context.Context, paths []string) (map[string]string, error) {
+	opts := j.Options()
+	base, err := j.base(ctx)
+	if err != nil {
+		return nil, err
+	}
+	none := func(string) bool { return false }
+	diff := func(batch []string) (string, error) {
+		var args []string
+		for _, path := range batch {
+			args = append(args, j.renames.paths(path)...)
+		}
+		output, err := run(ctx, j.dir, "jj", j.diffArgs(base, args...)...)
+		if err != nil {
+			return "", fmt.Errorf("jj diff failed: %w", err)
+		}
+		return string(output), nil
+	}
+	return splitDiffs(ctx, "jj", opts, paths, none, diff, j.Diff)
+}
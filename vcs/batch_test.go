@@ -0,0 +1,52 @@
+package vcs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSplitDiffs(t *testing.T) {
+	var batched []string
+	diff := func(paths []string) (string, error) {
+		batched = paths
+		return "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+A\n" +
+			"diff --git a/b.go b/b.go\ndeleted file mode 100644\n--- a/b.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-b\n", nil
+	}
+	var single []string
+	one := func(ctx context.Context, path string) (string, error) {
+		single = append(single, path)
+		return "single " + path, nil
+	}
+	moved := func(path string) bool { return path == "moved.go" }
+
+	opts := Options{DiffTools: map[string]string{"csv": "csvdiff"}}
+	diffs, err := splitDiffs(context.Background(), "git", opts, []string{"a.go", "b.go", "moved.go", "data.csv", "untracked.go"}, moved, diff, one)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.go", "b.go", "untracked.go"}; !reflect.DeepEqual(batched, want) {
+		t.Errorf("batched %v, want %v", batched, want)
+	}
+	// Moved files and diff tools go alone, as does anything the batch missed
+	if want := []string{"moved.go", "data.csv", "untracked.go"}; !reflect.DeepEqual(single, want) {
+		t.Errorf("diffed alone %v, want %v", single, want)
+	}
+	if diffs["a.go"] != "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+A\n" || diffs["untracked.go"] != "single untracked.go" || len(diffs) != 5 {
+		t.Errorf("unexpected diffs %q", diffs)
+	}
+}
+
+func TestDiffFiles_Fallback(t *testing.T) {
+	p, err := NewPatch("patch", "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := DiffFiles(context.Background(), p, []string{"x"})
+	if err != nil || diffs["x"] != p.diffs["x"] {
+		t.Errorf("DiffFiles = %q, %v", diffs, err)
+	}
+	if _, err := DiffFiles(context.Background(), p, []string{"missing"}); err == nil {
+		t.Error("expected an error for a file not in the patch")
+	}
+}
@@ -0,0 +1,66 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Blamer is implemented by backends that can say when each line of a file
+// was last changed
+type Blamer interface {
+	// Blame returns when each line of path, numbered from 1, was last
+	// committed, as of the base the review diffs against
+	Blame(ctx context.Context, path string) (map[int]time.Time, error)
+}
+
+// Blame blames path as of the review's base: From, To's parent, or HEAD.
+// A renamed or copied file is blamed where it came from.
+func (g *Git) Blame(ctx context.Context, path string) (map[int]time.Time, error) {
+	opts := g.Options()
+	rev := "HEAD"
+	if opts.HasRange() {
+		rev = g.rangeArgs(ctx)[0]
+	}
+	path = g.renames.paths(path)[0]
+	output, err := run(ctx, g.dir, "git", "blame", "--line-porcelain", rev, "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s failed: %s", path, failureText(output, err))
+	}
+	return parseBlame(string(output))
+}
+
+// parseBlame reads when each line was committed from git blame
+// --line-porcelain output. Each line's entry starts with a header giving
+// the commit and the line's number, and ends with the line itself after
+// a tab.
+func parseBlame(output string) (map[int]time.Time, error) {
+	times := make(map[int]time.Time)
+	line, header := 0, true
+	for _, text := range strings.Split(output, "\n") {
+		switch {
+		case text == "":
+		case header:
+			fields := strings.Fields(text)
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unexpected blame header %q", text)
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected blame header %q", text)
+			}
+			line, header = n, false
+		case strings.HasPrefix(text, "\t"):
+			header = true
+		case strings.HasPrefix(text, "committer-time "):
+			seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "committer-time "), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected blame time %q", text)
+			}
+			times[line] = time.Unix(seconds, 0)
+		}
+	}
+	return times, nil
+}
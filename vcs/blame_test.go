@@ -0,0 +1,42 @@
+package vcs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBlame(t *testing.T) {
+	output := `8d2f1c0e3b7a4f6d9c5e2b1a0f8e7d6c5b4a3f2e 1 1 2
+author Ann
+author-time 1700000000
+committer-time 1700000500
+summary First
+filename main.go
+	package main
+8d2f1c0e3b7a4f6d9c5e2b1a0f8e7d6c5b4a3f2e 2 2
+author Ann
+author-time 1700000000
+committer-time 1700000500
+filename main.go
+	
+0a1b2c3d4e5f60718293a4b5c6d7e8f901234567 1 3 1
+author Bob
+committer-time 1710000000
+boundary
+filename old.go
+	func main() {}
+`
+	want := map[int]time.Time{1: time.Unix(1700000500, 0), 2: time.Unix(1700000500, 0), 3: time.Unix(1710000000, 0)}
+	got, err := parseBlame(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBlame = %v, want %v", got, want)
+	}
+
+	if _, err := parseBlame("garbage\n"); err == nil {
+		t.Error("expected a malformed header to be reported")
+	}
+}
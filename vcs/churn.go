@@ -0,0 +1,71 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ChurnDepth is how many recent commits, or jj changes, churn is counted
+// over
+const ChurnDepth = 500
+
+// Churner is implemented by backends that can say how often files changed
+// in the history
+type Churner interface {
+	// Churn counts the commits among the latest limit, up to the head of
+	// the review, that touched each file
+	Churn(ctx context.Context, limit int) (map[string]int, error)
+}
+
+// Churn counts how many of the latest ChurnDepth commits touched each file,
+// or returns nil for backends with no history
+func Churn(ctx context.Context, v VCS) (map[string]int, error) {
+	if c, ok := v.(Churner); ok {
+		return c.Churn(ctx, ChurnDepth)
+	}
+	return nil, nil
+}
+
+func (g *Git) Churn(ctx context.Context, limit int) (map[string]int, error) {
+	opts := g.Options()
+	rev := "HEAD"
+	if opts.To != "" {
+		rev = opts.To
+	}
+	output, err := run(ctx, g.dir, "git", "log", "-z", "-n", strconv.Itoa(limit), "--format=", "--name-only", "--no-renames", rev, "--")
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %s", failureText(output, err))
+	}
+	return parseChurn(string(output)), nil
+}
+
+func (j *JJ) Churn(ctx context.Context, limit int) (map[string]int, error) {
+	revset := "::" + j.head() + " ~ root()"
+	output, err := run(ctx, j.dir, "jj", "log", "--no-graph", "-r", revset, "--limit", strconv.Itoa(limit), "-T", `""`, "--summary")
+	if err != nil {
+		return nil, fmt.Errorf("jj log failed: %s", failureText(output, err))
+	}
+	changes, err := parseJJSummary(string(output))
+	if err != nil {
+		return nil, err
+	}
+	churn := make(map[string]int)
+	for _, c := range changes {
+		churn[c.Path]++
+	}
+	return churn, nil
+}
+
+// parseChurn counts the paths in git log -z --name-only output, listed
+// once for each commit that touched them
+func parseChurn(output string) map[string]int {
+	churn := make(map[string]int)
+	for _, path := range strings.Split(output, "\x00") {
+		if path = strings.TrimLeft(path, "\n"); path != "" {
+			churn[path]++
+		}
+	}
+	return churn
+}
@@ -0,0 +1,195 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Compare implements VCS for two directory trees, like diff -ru: files only
+// in the old tree are deleted, files only in the new tree are added
+type Compare struct {
+	oldDir string
+	newDir string
+	opts   Options
+	temps  []string // Directories archives were extracted to, removed by Close
+}
+
+// NewCompare compares the tree at newPath against the tree at oldPath. Each
+// may be a directory or an archive (tarball or zip), which is extracted to a
+// temporary directory until Close.
+func NewCompare(oldPath, newPath string, opts Options) (*Compare, error) {
+	c := &Compare{opts: opts}
+	var err error
+	if c.oldDir, err = c.resolve(oldPath); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	if c.newDir, err = c.resolve(newPath); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// resolve returns the directory to compare for p, extracting archives
+func (c *Compare) resolve(p string) (string, error) {
+	if !IsArchive(p) {
+		return compareDir(p)
+	}
+	root, tmp, err := extractArchive(p)
+	if err != nil {
+		return "", err
+	}
+	c.temps = append(c.temps, tmp)
+	return root, nil
+}
+
+// Close removes any extracted archives
+func (c *Compare) Close() error {
+	var errs []error
+	for _, tmp := range c.temps {
+		errs = append(errs, os.RemoveAll(tmp))
+	}
+	c.temps = nil
+	return errors.Join(errs...)
+}
+
+// compareDir resolves dir and checks that it is a directory
+func compareDir(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("cannot compare %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("cannot compare %s: not a directory or supported archive", dir)
+	}
+	return abs, nil
+}
+
+func (c *Compare) Name() string {
+	return "compare"
+}
+
+func (c *Compare) Options() Options {
+	return c.opts
+}
+
+func (c *Compare) SetOptions(opts Options) {
+	c.opts = opts
+}
+
+// Dirs returns the old and new directories being compared
+func (c *Compare) Dirs() (oldDir, newDir string) {
+	return c.oldDir, c.newDir
+}
+
+func (c *Compare) ChangedFiles(ctx context.Context) ([]FileChange, error) {
+	oldFiles, err := walkFiles(c.oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := walkFiles(c.newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	inOld := make(map[string]bool, len(oldFiles))
+	for _, rel := range oldFiles {
+		inOld[rel] = true
+	}
+	inNew := make(map[string]bool, len(newFiles))
+
+	var changes []FileChange
+	for _, rel := range newFiles {
+		inNew[rel] = true
+		if !inOld[rel] {
+			changes = append(changes, FileChange{Path: rel, Status: StatusAdded})
+			continue
+		}
+		same, err := sameContents(c.oldPath(rel), c.newPath(rel))
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s: %w", rel, err)
+		}
+		if !same {
+			changes = append(changes, FileChange{Path: rel, Status: StatusModified})
+		}
+	}
+	for _, rel := range oldFiles {
+		if !inNew[rel] {
+			changes = append(changes, FileChange{Path: rel, Status: StatusDeleted})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return filterChanges(c.opts, changes), nil
+}
+
+// FileContents reads path from the old or new tree
+func (c *Compare) FileContents(ctx context.Context, path string, rev Rev) (string, error) {
+	if rev == RevBase {
+		return readFile(c.oldPath(path))
+	}
+	return readFile(c.newPath(path))
+}
+
+func (c *Compare) Diff(ctx context.Context, path string) (string, error) {
+	if output, ok := toolDiff(ctx, c, c.newDir, c.opts, path); ok {
+		return output, nil
+	}
+	oldPath, oldLabel := c.oldPath(path), "a/"+path
+	if _, err := os.Stat(oldPath); err != nil {
+		oldPath, oldLabel = os.DevNull, "/dev/null"
+	}
+	newPath, newLabel := c.newPath(path), "b/"+path
+	if _, err := os.Stat(newPath); err != nil {
+		newPath, newLabel = os.DevNull, "/dev/null"
+	}
+	return diffFiles(ctx, c.newDir, path, oldPath, oldLabel, newPath, newLabel, c.opts)
+}
+
+func (c *Compare) DiffAll(ctx context.Context) (string, error) {
+	return concatDiffs(ctx, c)
+}
+
+func (c *Compare) oldPath(rel string) string {
+	return filepath.Join(c.oldDir, filepath.FromSlash(rel))
+}
+
+func (c *Compare) newPath(rel string) string {
+	return filepath.Join(c.newDir, filepath.FromSlash(rel))
+}
+
+// sameContents reports whether two files hold the same bytes, checking
+// sizes before hashing
+func sameContents(a, b string) (bool, error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+	aHash, err := hashFile(a)
+	if err != nil {
+		return false, err
+	}
+	bHash, err := hashFile(b)
+	if err != nil {
+		return false, err
+	}
+	return aHash == bHash, nil
+}
@@ -0,0 +1,91 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+	oldDir, newDir := t.TempDir(), t.TempDir()
+
+	writeTestFile(t, oldDir, "same.txt", "same\n")
+	writeTestFile(t, newDir, "same.txt", "same\n")
+	writeTestFile(t, oldDir, "lib/edit.txt", "one\ntwo\n")
+	writeTestFile(t, newDir, "lib/edit.txt", "one\nTWO\n")
+	writeTestFile(t, oldDir, "gone.txt", "bye\n")
+	writeTestFile(t, newDir, "new.txt", "hello\n")
+
+	c, err := NewCompare(oldDir, newDir, Options{})
+	if err != nil {
+		t.Fatalf("NewCompare: %v", err)
+	}
+
+	changes, err := c.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	want := []FileChange{
+		{Path: "gone.txt", Status: StatusDeleted},
+		{Path: "lib/edit.txt", Status: StatusModified},
+		{Path: "new.txt", Status: StatusAdded},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("ChangedFiles = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %v, want %v", i, changes[i], want[i])
+		}
+	}
+
+	diff, err := c.Diff(context.Background(), "lib/edit.txt")
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	for _, s := range []string{"--- a/lib/edit.txt", "+++ b/lib/edit.txt", "-two", "+TWO"} {
+		if !strings.Contains(diff, s) {
+			t.Errorf("diff missing %q:\n%s", s, diff)
+		}
+	}
+	if diff, _ := c.Diff(context.Background(), "new.txt"); !strings.Contains(diff, "--- /dev/null") {
+		t.Errorf("added file diff:\n%s", diff)
+	}
+	if diff, _ := c.Diff(context.Background(), "gone.txt"); !strings.Contains(diff, "+++ /dev/null") {
+		t.Errorf("deleted file diff:\n%s", diff)
+	}
+
+	if got, err := c.FileContents(context.Background(), "lib/edit.txt", RevBase); err != nil || got != "one\ntwo\n" {
+		t.Errorf("base lib/edit.txt = %q, %v", got, err)
+	}
+	if _, err := c.FileContents(context.Background(), "new.txt", RevBase); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("base of added file: %v, want fs.ErrNotExist", err)
+	}
+
+	all, err := c.DiffAll(context.Background())
+	if err != nil {
+		t.Fatalf("DiffAll: %v", err)
+	}
+	if n := strings.Count(all, "+++ "); n != 3 {
+		t.Errorf("DiffAll covers %d files, want 3:\n%s", n, all)
+	}
+}
+
+func TestNewCompareRejectsFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "file.txt", "x\n")
+
+	if _, err := NewCompare(dir, filepath.Join(dir, "file.txt"), Options{}); err == nil {
+		t.Error("expected an error comparing against a file")
+	}
+	if _, err := NewCompare(filepath.Join(dir, "missing"), dir, Options{}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,134 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Describer is implemented by backends that know the commit messages or
+// change descriptions of the revisions reviewed
+type Describer interface {
+	Description(ctx context.Context) (string, error) // Empty when there's nothing to describe
+}
+
+// revisionDescription is one reviewed revision's message
+type revisionDescription struct {
+	id      string // Short commit or change ID
+	message string
+}
+
+// joinDescriptions shows one revision's message as is, or several each
+// under a line naming the revision, kind being "commit" or "change"
+func joinDescriptions(kind string, revs []revisionDescription) string {
+	if len(revs) == 1 {
+		return revs[0].message
+	}
+	parts := make([]string, len(revs))
+	for i, r := range revs {
+		parts[i] = kind + " " + r.id + "\n\n" + r.message
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// parseDescriptions parses log output formatted as the ID, a NUL, the
+// message and a \x01 for each revision
+func parseDescriptions(output string) []revisionDescription {
+	var revs []revisionDescription
+	for _, entry := range strings.Split(output, "\x01") {
+		id, message, ok := strings.Cut(strings.TrimLeft(entry, "\n"), "\x00")
+		if !ok {
+			continue
+		}
+		message = strings.TrimSpace(message)
+		if message == "" {
+			message = "(no description set)"
+		}
+		revs = append(revs, revisionDescription{id: id, message: message})
+	}
+	return revs
+}
+
+// Description returns the messages of the commits reviewed: To's, or those
+// from From up to To or HEAD. Uncommitted changes have none.
+func (g *Git) Description(ctx context.Context) (string, error) {
+	opts := g.Options()
+	var args []string
+	switch {
+	case opts.From != "" && opts.To != "":
+		args = []string{opts.From + ".." + opts.To}
+	case opts.From != "":
+		args = []string{opts.From + "..HEAD"}
+	case opts.To != "":
+		args = []string{"-1", opts.To}
+	default:
+		return "", nil
+	}
+	args = append([]string{"log", "--reverse", "--format=%h%x00%B%x01"}, args...)
+	output, err := run(ctx, g.dir, "git", args...)
+	if err != nil {
+		return "", fmt.Errorf("git log failed: %s", failureText(output, err))
+	}
+	return joinDescriptions("commit", parseDescriptions(string(output))), nil
+}
+
+// Description returns the descriptions of the changes reviewed, from the
+// base up to the working copy or To; for an interdiff, the later version's
+func (j *JJ) Description(ctx context.Context) (string, error) {
+	opts := j.Options()
+	revset := j.head()
+	if !opts.Interdiff {
+		base, err := j.base(ctx)
+		if err != nil {
+			return "", err
+		}
+		revset = "(" + base + ")..(" + j.head() + ")"
+	}
+	template := `change_id.short() ++ "\x00" ++ description ++ "\x01"`
+	output, err := run(ctx, j.dir, "jj", "log", "--no-graph", "--reversed", "-r", revset, "-T", template)
+	if err != nil {
+		return "", fmt.Errorf("jj log failed: %s", failureText(output, err))
+	}
+	return joinDescriptions("change", parseDescriptions(string(output))), nil
+}
+
+// Description returns the commit message a git format-patch patch starts
+// with: its subject, less the [PATCH] tag, and body
+func (p *Patch) Description(ctx context.Context) (string, error) {
+	return p.description, nil
+}
+
+// patchDescription reads the message from the mail headers and body before
+// a format-patch patch's first diff, up to the "---" over the diffstat
+func patchDescription(preamble []string) string {
+	title, inSubject := "", false
+	i := 0
+	for ; i < len(preamble) && preamble[i] != ""; i++ {
+		line := preamble[i]
+		switch {
+		case strings.HasPrefix(line, "Subject: "):
+			title, inSubject = strings.TrimPrefix(line, "Subject: "), true
+		case inSubject && strings.HasPrefix(line, " "):
+			// A folded header continues the subject
+			title += line
+		default:
+			inSubject = false
+		}
+	}
+	if title == "" {
+		return ""
+	}
+	if strings.HasPrefix(title, "[") {
+		if _, rest, ok := strings.Cut(title, "] "); ok {
+			title = rest
+		}
+	}
+	var body []string
+	for i++; i < len(preamble) && preamble[i] != "---"; i++ {
+		body = append(body, preamble[i])
+	}
+	if text := strings.TrimSpace(strings.Join(body, "\n")); text != "" {
+		return title + "\n\n" + text
+	}
+	return title
+}
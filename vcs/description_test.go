@@ -0,0 +1,68 @@
+package vcs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseDescriptions(t *testing.T) {
+	output := "abc1234\x00Add greeting\n\nSays hello.\n\x01\ndef5678\x00\n\x01\n"
+	revs := parseDescriptions(output)
+	if len(revs) != 2 {
+		t.Fatalf("expected 2 revisions, got %+v", revs)
+	}
+	if revs[0] != (revisionDescription{id: "abc1234", message: "Add greeting\n\nSays hello."}) {
+		t.Errorf("unexpected first revision: %+v", revs[0])
+	}
+	if revs[1].message != "(no description set)" {
+		t.Errorf("expected an empty description marked, got %q", revs[1].message)
+	}
+
+	if got := joinDescriptions("commit", revs[:1]); got != "Add greeting\n\nSays hello." {
+		t.Errorf("expected one message as is, got %q", got)
+	}
+	want := "commit abc1234\n\nAdd greeting\n\nSays hello.\n\ncommit def5678\n\n(no description set)"
+	if got := joinDescriptions("commit", revs); got != want {
+		t.Errorf("expected each message under its commit, got %q", got)
+	}
+}
+
+func TestPatchDescription(t *testing.T) {
+	patch := `From 1234567 Mon Sep 17 00:00:00 2001
+From: A U Thor <author@example.com>
+Date: Tue, 1 Oct 2024 10:00:00 +0000
+Subject: [PATCH v2] Fix the parser for
+ folded subjects
+
+Long lines broke it.
+
+Change-Id: I123
+---
+ main.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-var x = 1
++var x = 2
+`
+	p, err := NewPatch("gerrit", patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Fix the parser for folded subjects\n\nLong lines broke it.\n\nChange-Id: I123"
+	if got, _ := p.Description(context.Background()); got != want {
+		t.Errorf("unexpected description %q", got)
+	}
+
+	// A plain diff has no message
+	p, err = NewPatch("gitea", "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := p.Description(context.Background()); got != "" {
+		t.Errorf("expected no description, got %q", got)
+	}
+}
@@ -0,0 +1,118 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gerunddev/tcr/notebook"
+)
+
+// toolFor returns the external diff command configured for path's
+// extension, or ""
+func toolFor(opts Options, path string) string {
+	if len(opts.DiffTools) == 0 {
+		return ""
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return ""
+	}
+	for key, command := range opts.DiffTools {
+		if strings.ToLower("."+strings.TrimPrefix(key, ".")) == ext {
+			return command
+		}
+	}
+	return ""
+}
+
+// toolDiff renders path with the external diff tool configured for its
+// extension. The command runs through the shell with {old}, {new} and
+// {path} replaced by quoted file paths; without placeholders the old and
+// new files are appended. Each side is written to a temporary file with the
+// original name, so tools can detect the language. ok is false if no tool
+// is configured, or it failed or printed nothing, and the caller falls back
+// to its own diff. Notebooks without a configured tool are rendered as
+// cell changes instead of a JSON diff.
+func toolDiff(ctx context.Context, r ContentReader, dir string, opts Options, path string) (diff string, ok bool) {
+	command := toolFor(opts, path)
+	if command == "" {
+		if notebook.Recognized(path) {
+			return notebookDiff(ctx, r, path)
+		}
+		return "", false
+	}
+
+	tmp, err := os.MkdirTemp("", "tcr-difftool-*")
+	if err != nil {
+		return "", false
+	}
+	defer os.RemoveAll(tmp)
+
+	var files [2]string
+	for i, rev := range []Rev{RevBase, RevHead} {
+		content, err := r.FileContents(ctx, path, rev)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", false
+		}
+		side := filepath.Join(tmp, [2]string{"old", "new"}[i])
+		if err := os.Mkdir(side, 0700); err != nil {
+			return "", false
+		}
+		files[i] = filepath.Join(side, filepath.Base(path))
+		if err := os.WriteFile(files[i], []byte(content), 0600); err != nil {
+			return "", false
+		}
+	}
+
+	if strings.Contains(command, "{old}") || strings.Contains(command, "{new}") {
+		command = strings.NewReplacer(
+			"{old}", shellQuote(files[0]),
+			"{new}", shellQuote(files[1]),
+			"{path}", shellQuote(path),
+		).Replace(command)
+	} else {
+		command = strings.ReplaceAll(command, "{path}", shellQuote(path)) +
+			" " + shellQuote(files[0]) + " " + shellQuote(files[1])
+	}
+
+	output, err := run(ctx, dir, "sh", "-c", command)
+	if err != nil {
+		// Like diff, tools may exit 1 to say the files differ
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", false
+		}
+	}
+	if len(strings.TrimSpace(string(output))) == 0 {
+		return "", false
+	}
+	return string(output), true
+}
+
+// notebookDiff renders a notebook's cell changes. ok is false if either
+// side can't be read or parsed, so the raw JSON diff is shown instead.
+func notebookDiff(ctx context.Context, r ContentReader, path string) (string, bool) {
+	var sources [2]string
+	for i, rev := range []Rev{RevBase, RevHead} {
+		content, err := r.FileContents(ctx, path, rev)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", false
+		}
+		sources[i] = content
+	}
+	diff, err := notebook.Diff(sources[0], sources[1])
+	if err != nil {
+		return "", false
+	}
+	return diff, true
+}
+
+// shellQuote quotes s for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,92 @@
+package vcs
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestToolFor(t *testing.T) {
+	opts := Options{DiffTools: map[string]string{".go": "difft", "IPYNB": "nbdiff"}}
+	tests := map[string]string{
+		"main.go":            "difft",
+		"a/b/notebook.ipynb": "nbdiff",
+		"README.md":          "",
+		"Makefile":           "",
+	}
+	for path, want := range tests {
+		if got := toolFor(opts, path); got != want {
+			t.Errorf("toolFor(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if got := toolFor(Options{}, "main.go"); got != "" {
+		t.Errorf("no tools configured, got %q", got)
+	}
+}
+
+func TestDiffTools(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	writeTestFile(t, oldDir, "lib/a.txt", "old\n")
+	writeTestFile(t, newDir, "lib/a.txt", "new\n")
+	writeTestFile(t, newDir, "b.md", "added\n")
+	writeTestFile(t, oldDir, "c.bad", "x\n")
+	writeTestFile(t, newDir, "c.bad", "y\n")
+
+	c, err := NewCompare(oldDir, newDir, Options{DiffTools: map[string]string{
+		// Placeholders, with the original base name kept for the tool
+		"txt": `printf 'tool %s: %s %s -> %s\n' {path} "$(basename {old})" "$(cat {old})" "$(cat {new})"`,
+		// No placeholders: the files are appended
+		"md": "cat",
+		// A failing tool falls back to the built-in diff
+		"bad": "exit 3",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := c.Diff(context.Background(), "lib/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "tool lib/a.txt: a.txt old -> new\n" {
+		t.Errorf("placeholder tool output = %q", diff)
+	}
+
+	diff, _ = c.Diff(context.Background(), "b.md")
+	if diff != "added\n" {
+		t.Errorf("appended-args tool output = %q (the missing old side should be empty)", diff)
+	}
+
+	diff, _ = c.Diff(context.Background(), "c.bad")
+	if !strings.Contains(diff, "--- a/c.bad") || !strings.Contains(diff, "+y") {
+		t.Errorf("expected the built-in diff after the tool failed:\n%s", diff)
+	}
+}
+
+func TestNotebookDiff(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	writeTestFile(t, oldDir, "nb.ipynb", `{"cells": [{"cell_type": "code", "source": "x = 1", "outputs": []}]}`)
+	writeTestFile(t, newDir, "nb.ipynb", `{"cells": [{"cell_type": "code", "source": "x = 2", "outputs": []}]}`)
+	writeTestFile(t, oldDir, "bad.ipynb", "{\n")
+	writeTestFile(t, newDir, "bad.ipynb", "{\n}\n")
+
+	c, err := NewCompare(oldDir, newDir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, _ := c.Diff(context.Background(), "nb.ipynb")
+	if diff != "@@ cell 1 · code · modified @@\n-x = 1\n+x = 2" {
+		t.Errorf("expected cell changes, got %q", diff)
+	}
+	diff, _ = c.Diff(context.Background(), "bad.ipynb")
+	if !strings.Contains(diff, "+}") {
+		t.Errorf("an unparseable notebook should fall back to the raw diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,56 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CommandHook, if set, is called after every VCS subprocess with the
+// command, its arguments and how long it ran
+var CommandHook func(name string, args []string, elapsed time.Duration)
+
+// run runs a VCS command in dir and returns its stdout. Errors are
+// returned unchanged so callers can inspect *exec.ExitError.
+func run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+
+	start := time.Now()
+	out, err := cmd.Output()
+	if hook := CommandHook; hook != nil {
+		hook(name, args, time.Since(start))
+	}
+	return out, err
+}
+
+// runInput runs a VCS command like run, with input on its stdin
+func runInput(ctx context.Context, dir, input, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+
+	start := time.Now()
+	out, err := cmd.Output()
+	if hook := CommandHook; hook != nil {
+		hook(name, args, time.Since(start))
+	}
+	return out, err
+}
+
+// failureText explains a failed command: its stderr, or its stdout for
+// tools like git commit that report some failures there, or the error
+func failureText(stdout []byte, err error) string {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return msg
+		}
+	}
+	if msg := strings.TrimSpace(string(stdout)); msg != "" {
+		return msg
+	}
+	return err.Error()
+}
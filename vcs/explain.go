@@ -0,0 +1,178 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Comparison says what a backend diffs, with revisions resolved
+type Comparison struct {
+	Compared string   // What was diffed against what
+	Hints    []string // What to try instead, should nothing have changed
+}
+
+// Explainer is implemented by backends that can say what they diff and,
+// for when there are no changes, what to review instead
+type Explainer interface {
+	Explain(ctx context.Context) Comparison
+}
+
+// Explain says what is diffed: the working tree, index or range
+// against their base, named with its short commit ID
+func (g *Git) Explain(ctx context.Context) Comparison {
+	opts := g.Options()
+	var from string
+	if opts.HasRange() {
+		from = g.rangeArgs(ctx)[0]
+	}
+	state := explainGit(opts, from, func(rev string) string { return g.shortRev(ctx, rev) }, func() string { return g.defaultBranch(ctx) })
+	g.optsMu.Lock()
+	fallback := g.fallback
+	g.optsMu.Unlock()
+	if fallback != "" {
+		state.Compared += ", " + fallback
+	}
+	return state
+}
+
+// explainGit explains a git comparison for opts, with from the base of a
+// range, resolving revisions to short commit IDs with shortRev ("" if one
+// doesn't resolve)
+func explainGit(opts Options, from string, shortRev func(rev string) string, defaultBranch func() string) Comparison {
+	if opts.HasRange() {
+		to := "the working tree"
+		if opts.To != "" {
+			to = revLabel(opts.To, shortRev(opts.To))
+		}
+		if from == emptyTree {
+			return Comparison{
+				Compared: to + " against an empty tree (no parent commit in this clone)",
+				Hints:    []string{"A shallow clone has only the latest commits; git fetch --deepen=1 fetches the parent"},
+			}
+		}
+		return Comparison{
+			Compared: to + " against " + revLabel(from, shortRev(from)),
+			Hints:    []string{"Both have the same files; check the revisions given to --from and --to"},
+		}
+	}
+
+	head := shortRev("HEAD")
+	if head == "" {
+		return Comparison{
+			Compared: "the working tree of a repository with no commits",
+			Hints:    []string{"Create or copy in some files to review them"},
+		}
+	}
+	switch opts.Scope {
+	case ScopeStaged:
+		return Comparison{
+			Compared: "the staged changes against HEAD (" + head + ")",
+			Hints:    []string{"Nothing is staged; git add some changes, or review the unstaged and untracked ones with --scope unstaged"},
+		}
+	case ScopeUnstaged:
+		return Comparison{
+			Compared: "the working tree against the index",
+			Hints:    []string{"Everything is staged; review it with --scope staged"},
+		}
+	}
+	return Comparison{
+		Compared: "the working tree, untracked files included, against HEAD (" + head + ")",
+		Hints: []string{
+			"Review the last commit with --to HEAD",
+			"Review a branch against where it started with --from " + defaultBranch(),
+		},
+	}
+}
+
+// revLabel names a revision as given, with its short commit ID if that
+// differs
+func revLabel(rev, short string) string {
+	if short == "" || strings.HasPrefix(rev, short) {
+		return rev
+	}
+	return fmt.Sprintf("%s (%s)", rev, short)
+}
+
+// shortRev resolves a revision to its short commit ID, "" if it doesn't
+// resolve
+func (g *Git) shortRev(ctx context.Context, rev string) string {
+	output, err := run(ctx, g.dir, "git", "rev-parse", "--verify", "--quiet", "--short", rev+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// defaultBranch guesses the branch work starts from: origin's default
+// branch, or main
+func (g *Git) defaultBranch(ctx context.Context) string {
+	output, err := run(ctx, g.dir, "git", "rev-parse", "--abbrev-ref", "origin/HEAD")
+	if branch := strings.TrimSpace(string(output)); err == nil && branch != "" && branch != "origin/HEAD" {
+		return branch
+	}
+	return "main"
+}
+
+// Explain says which change is diffed against which base, named
+// with their short change IDs
+func (j *JJ) Explain(ctx context.Context) Comparison {
+	opts := j.Options()
+	if opts.Interdiff {
+		return Comparison{
+			Compared: opts.From + " → " + opts.To,
+			Hints:    []string{"The two versions make the same changes"},
+		}
+	}
+
+	head := "the working copy"
+	if opts.To != "" {
+		head = opts.To
+	}
+	head = j.changeLabel(ctx, head, j.head())
+	var base string
+	switch {
+	case opts.From != "":
+		base = j.changeLabel(ctx, opts.From, opts.From)
+	case opts.To != "":
+		base = "its parent"
+	default:
+		revset := opts.BaseRevset
+		if revset == "" {
+			revset = "the nearest bookmark or trunk()"
+		}
+		rev, err := j.base(ctx)
+		if err != nil {
+			return Comparison{Compared: head + " against " + revset}
+		}
+		base = j.changeLabel(ctx, revset, rev)
+	}
+
+	state := Comparison{Compared: head + " against " + base}
+	if opts.HasRange() {
+		state.Hints = []string{"Both have the same files; check the revisions given to --from and --to"}
+	} else {
+		state.Hints = []string{
+			"If the working copy is a new, empty change, review its parent with --to @-",
+			"Diff against another base with --revset, e.g. --revset 'trunk()'",
+		}
+	}
+	return state
+}
+
+// changeLabel names a revision with its short change ID
+func (j *JJ) changeLabel(ctx context.Context, name, rev string) string {
+	output, err := run(ctx, j.dir, "jj", "log", "--no-graph", "--limit", "1", "-r", rev, "-T", "change_id.short()")
+	if id := strings.TrimSpace(string(output)); err == nil && id != "" && id != name {
+		return fmt.Sprintf("%s (%s)", name, id)
+	}
+	return name
+}
+
+// Explain says when the snapshot diffed against was taken
+func (s *Snapshot) Explain(ctx context.Context) Comparison {
+	return Comparison{
+		Compared: "the directory against its snapshot from " + s.base.Time.Local().Format("2006-01-02 15:04"),
+		Hints:    []string{"Files added, modified or deleted since then show here; tcr snapshot records a new baseline"},
+	}
+}
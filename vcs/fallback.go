@@ -0,0 +1,87 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// emptyTree is the ID git gives a tree with no files, to diff a commit
+// that has no parent against
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// parent returns what a range given only To diffs against: To's parent,
+// or the empty tree when To has none in this clone, as the first commit or
+// the oldest one a shallow clone fetched. A To that doesn't resolve keeps
+// its parent, for git to report.
+func (g *Git) parent(ctx context.Context, to string) string {
+	g.parentMu.Lock()
+	defer g.parentMu.Unlock()
+	if from, ok := g.parents[to]; ok {
+		return from
+	}
+	from := to + "^"
+	if g.shortRev(ctx, from) == "" && g.shortRev(ctx, to) != "" {
+		from = emptyTree
+	}
+	if ctx.Err() == nil {
+		if g.parents == nil {
+			g.parents = make(map[string]string)
+		}
+		g.parents[to] = from
+	}
+	return from
+}
+
+// fallBack picks a base for a detached HEAD with nothing uncommitted, as
+// in a CI checkout, where the working tree against HEAD is empty: where
+// HEAD branched from origin's default branch, or failing that (no such
+// branch, HEAD is on it, or a shallow clone without their common history)
+// HEAD's last commit. Options given explicitly are left alone.
+func (g *Git) fallBack(ctx context.Context) {
+	if opts := g.Options(); opts.HasRange() || opts.Scope != ScopeAll {
+		return
+	}
+	// symbolic-ref exits 1 for a detached HEAD
+	var exitErr *exec.ExitError
+	if _, err := run(ctx, g.dir, "git", "symbolic-ref", "-q", "HEAD"); !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return
+	}
+	head := g.shortRev(ctx, "HEAD")
+	if head == "" {
+		return
+	}
+	if status, err := run(ctx, g.dir, "git", "status", "--porcelain"); err != nil || strings.TrimSpace(string(status)) != "" {
+		return
+	}
+
+	for _, branch := range g.defaultBranches(ctx) {
+		output, err := run(ctx, g.dir, "git", "merge-base", "HEAD", branch)
+		base := g.shortRev(ctx, strings.TrimSpace(string(output)))
+		if err == nil && base != "" && base != head {
+			g.optsMu.Lock()
+			g.opts.From = base
+			g.fallback = "where HEAD branched from " + branch + ", as it's detached with nothing uncommitted"
+			g.optsMu.Unlock()
+			return
+		}
+	}
+	g.optsMu.Lock()
+	g.opts.To = "HEAD"
+	g.fallback = "as HEAD is detached with nothing uncommitted"
+	g.optsMu.Unlock()
+}
+
+// defaultBranches lists the remote branches work likely started from:
+// origin's default branch, then origin/main and origin/master
+func (g *Git) defaultBranches(ctx context.Context) []string {
+	var branches []string
+	for _, branch := range []string{g.defaultBranch(ctx), "origin/main", "origin/master"} {
+		if strings.HasPrefix(branch, "origin/") && !slices.Contains(branches, branch) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
@@ -0,0 +1,116 @@
+package vcs
+
+import (
+	"path"
+	"strings"
+)
+
+// generatedSuffixes are the file name endings protoc plugins give the code
+// they generate from foo.proto, longest first so foo_grpc.pb.go isn't read
+// as a foo_grpc.proto sibling
+var generatedSuffixes = []string{
+	".pb.validate.go",
+	"_grpc.pb.go",
+	".pb.gw.go",
+	".connect.go",
+	".pb.go",
+	"_pb2_grpc.py",
+	"_pb2.pyi",
+	"_pb2.py",
+	"_grpc_pb.d.ts",
+	"_grpc_pb.js",
+	"_pb.d.ts",
+	"_pb.js",
+	".pb.cc",
+	".pb.h",
+	".pb.ts",
+}
+
+// protoStem returns the name of the .proto a generated file comes from,
+// e.g. "foo.proto" for "api/foo_grpc.pb.go"
+func protoStem(p string) (string, bool) {
+	base := path.Base(p)
+	for _, suffix := range generatedSuffixes {
+		if stem, ok := strings.CutSuffix(base, suffix); ok && stem != "" {
+			return stem + ".proto", true
+		}
+	}
+	return "", false
+}
+
+// GeneratedSources pairs changed generated files with the changed .proto
+// they were generated from, returning a map from generated path to proto
+// path. A proto in the same directory wins; otherwise the one whose
+// directory shares the longest tail with the generated file's, as in
+// proto/foo/v1 and gen/go/foo/v1. Files with no proto in the change, or
+// two equally likely ones, are left out.
+func GeneratedSources(changes []FileChange) map[string]string {
+	protos := make(map[string][]string) // Base name -> paths
+	for _, c := range changes {
+		if path.Ext(c.Path) == ".proto" {
+			protos[path.Base(c.Path)] = append(protos[path.Base(c.Path)], c.Path)
+		}
+	}
+	if len(protos) == 0 {
+		return nil
+	}
+
+	sources := make(map[string]string)
+	for _, c := range changes {
+		name, ok := protoStem(c.Path)
+		if !ok {
+			continue
+		}
+		best, bestScore, tie := "", -1, false
+		for _, proto := range protos[name] {
+			score := sharedDirTail(path.Dir(proto), path.Dir(c.Path))
+			switch {
+			case score > bestScore:
+				best, bestScore, tie = proto, score, false
+			case score == bestScore:
+				tie = true
+			}
+		}
+		if best != "" && !tie {
+			sources[c.Path] = best
+		}
+	}
+	return sources
+}
+
+// sharedDirTail counts the trailing directory names a and b have in common,
+// scoring identical directories above any partial match
+func sharedDirTail(a, b string) int {
+	if a == b {
+		return 1 << 16
+	}
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	n := 0
+	for n < len(as) && n < len(bs) && as[len(as)-1-n] == bs[len(bs)-1-n] {
+		n++
+	}
+	return n
+}
+
+// GroupGenerated returns changes with each generated file moved to just
+// after the proto it came from, keeping the order of everything else
+func GroupGenerated(changes []FileChange, sources map[string]string) []FileChange {
+	if len(sources) == 0 {
+		return changes
+	}
+	children := make(map[string][]FileChange)
+	for _, c := range changes {
+		if proto, ok := sources[c.Path]; ok {
+			children[proto] = append(children[proto], c)
+		}
+	}
+	grouped := make([]FileChange, 0, len(changes))
+	for _, c := range changes {
+		if _, ok := sources[c.Path]; ok {
+			continue
+		}
+		grouped = append(grouped, c)
+		grouped = append(grouped, children[c.Path]...)
+	}
+	return grouped
+}
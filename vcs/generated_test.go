@@ -0,0 +1,54 @@
+package vcs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratedSources(t *testing.T) {
+	changes := []FileChange{
+		{Path: "api/user.pb.go"},
+		{Path: "README.md"},
+		{Path: "api/user.proto"},
+		{Path: "api/user_grpc.pb.go"},
+		{Path: "proto/billing/v1/invoice.proto"},
+		{Path: "gen/go/billing/v1/invoice.pb.go"},
+		{Path: "py/invoice_pb2.py"},
+		{Path: "a/dup.proto"},
+		{Path: "b/dup.proto"},
+		{Path: "c/dup.pb.go"},
+		{Path: "orphan.pb.go"},
+	}
+
+	got := GeneratedSources(changes)
+	want := map[string]string{
+		"api/user.pb.go":                  "api/user.proto",
+		"api/user_grpc.pb.go":             "api/user.proto",
+		"gen/go/billing/v1/invoice.pb.go": "proto/billing/v1/invoice.proto",
+		"py/invoice_pb2.py":               "proto/billing/v1/invoice.proto",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for gen, proto := range want {
+		if got[gen] != proto {
+			t.Errorf("%s: got source %q, want %q", gen, got[gen], proto)
+		}
+	}
+
+	grouped := GroupGenerated(changes, got)
+	var paths []string
+	for _, c := range grouped {
+		paths = append(paths, c.Path)
+	}
+	wantOrder := "README.md api/user.proto api/user.pb.go api/user_grpc.pb.go " +
+		"proto/billing/v1/invoice.proto gen/go/billing/v1/invoice.pb.go py/invoice_pb2.py " +
+		"a/dup.proto b/dup.proto c/dup.pb.go orphan.pb.go"
+	if strings.Join(paths, " ") != wantOrder {
+		t.Errorf("grouped order:\n got %v\nwant %s", paths, wantOrder)
+	}
+
+	if GeneratedSources([]FileChange{{Path: "x.pb.go"}}) != nil {
+		t.Error("no protos changed, expected no pairs")
+	}
+}
@@ -0,0 +1,116 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// HunkStager is implemented by backends that can stage part of a file's
+// changes
+type HunkStager interface {
+	// CanStage reports whether the hunks shown can be staged as they are:
+	// they're the working tree's changes against the index, unaltered
+	CanStage() bool
+	// StageHunk adds a patch of one file's hunk, as put together by Hunk,
+	// to the index
+	StageHunk(ctx context.Context, patch string) error
+}
+
+// CanStage reports whether the unstaged changes are shown on their own,
+// with whitespace: only then is each hunk the index-to-worktree patch git
+// apply --cached needs. Against HEAD a hunk may carry staged changes too,
+// and with -w its lines needn't match the index.
+func (g *Git) CanStage() bool {
+	opts := g.Options()
+	return !opts.HasRange() && opts.Scope == ScopeUnstaged && !opts.IgnoreWhitespace
+}
+
+// StageHunk applies patch to the index with git apply --cached
+func (g *Git) StageHunk(ctx context.Context, patch string) error {
+	if !g.CanStage() {
+		return fmt.Errorf("can only stage hunks of the unstaged changes, shown with whitespace")
+	}
+	if output, err := runInput(ctx, g.dir, patch, "git", "apply", "--cached", "-"); err != nil {
+		return fmt.Errorf("git apply failed: %s", failureText(output, err))
+	}
+	return nil
+}
+
+// HunkHeader returns the "@@ -10,4 +10,6 @@" part of a hunk header line,
+// without the function git adds after it
+func HunkHeader(line string) (string, bool) {
+	if !strings.HasPrefix(line, "@@") {
+		return "", false
+	}
+	if end := strings.Index(line[2:], "@@"); end >= 0 {
+		return line[:end+4], true
+	}
+	return line, true
+}
+
+// Hunk finds the hunk with the given header in one file's diff. It returns
+// the file's header lines, "diff --git" through "+++", and the hunk from
+// its "@@" line on, which together make a patch of that hunk alone.
+func Hunk(diff, header string) (fileHeader, hunk string, ok bool) {
+	lines := strings.SplitAfter(diff, "\n")
+	first, start := -1, -1
+	for i, line := range lines {
+		h, isHeader := HunkHeader(strings.TrimRight(line, "\n"))
+		if !isHeader {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if h == header {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return "", "", false
+	}
+	end := start + 1
+	for end < len(lines) && !isHunkBoundary(lines[end]) {
+		end++
+	}
+	hunk = strings.Join(lines[start:end], "")
+	if !strings.HasSuffix(hunk, "\n") {
+		hunk += "\n"
+	}
+	return strings.Join(lines[:first], ""), hunk, true
+}
+
+// FoldHunks replaces the body of each hunk whose header is in folded with
+// one line saying how many lines were hidden, keeping the header
+func FoldHunks(diff string, folded map[string]bool) string {
+	if len(folded) == 0 {
+		return diff
+	}
+	lines := strings.Split(diff, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		out = append(out, lines[i])
+		h, ok := HunkHeader(lines[i])
+		if !ok || !folded[h] {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && !isHunkBoundary(lines[end]) {
+			end++
+		}
+		// A diff's trailing newline leaves an empty last line to keep
+		if end == len(lines) && lines[end-1] == "" && end-1 > i {
+			end--
+		}
+		out = append(out, fmt.Sprintf(" ⋯ %d lines folded", end-i-1))
+		i = end - 1
+	}
+	return strings.Join(out, "\n")
+}
+
+// isHunkBoundary reports whether a diff line ends the hunk before it
+func isHunkBoundary(line string) bool {
+	return strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff ")
+}
@@ -0,0 +1,103 @@
+package vcs
+
+import (
+	"context"
+	"testing"
+)
+
+const twoHunks = `diff --git a/a.go b/a.go
+index 1..2 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@ package a
+ one
+-two
++TWO
+ three
+@@ -10,2 +10,3 @@ func f() {
+ ten
++ten and a half
+ eleven
+`
+
+func TestHunkHeader(t *testing.T) {
+	if h, ok := HunkHeader("@@ -1,3 +1,3 @@ package a"); !ok || h != "@@ -1,3 +1,3 @@" {
+		t.Errorf("HunkHeader = %q, %v", h, ok)
+	}
+	if h, ok := HunkHeader("@@ -1 +1"); !ok || h != "@@ -1 +1" {
+		t.Errorf("HunkHeader without closing @@ = %q, %v", h, ok)
+	}
+	if _, ok := HunkHeader(" @@ context"); ok {
+		t.Error("expected a context line not to be a header")
+	}
+}
+
+func TestHunk(t *testing.T) {
+	fileHeader, hunk, ok := Hunk(twoHunks, "@@ -10,2 +10,3 @@")
+	if !ok {
+		t.Fatal("expected the second hunk found")
+	}
+	if want := "diff --git a/a.go b/a.go\nindex 1..2 100644\n--- a/a.go\n+++ b/a.go\n"; fileHeader != want {
+		t.Errorf("file header = %q, want %q", fileHeader, want)
+	}
+	if want := "@@ -10,2 +10,3 @@ func f() {\n ten\n+ten and a half\n eleven\n"; hunk != want {
+		t.Errorf("hunk = %q, want %q", hunk, want)
+	}
+
+	_, hunk, _ = Hunk(twoHunks, "@@ -1,3 +1,3 @@")
+	if want := "@@ -1,3 +1,3 @@ package a\n one\n-two\n+TWO\n three\n"; hunk != want {
+		t.Errorf("first hunk = %q, want %q", hunk, want)
+	}
+
+	if _, _, ok := Hunk(twoHunks, "@@ -5 +5 @@"); ok {
+		t.Error("expected no hunk for a header not in the diff")
+	}
+}
+
+func TestFoldHunks(t *testing.T) {
+	got := FoldHunks(twoHunks, map[string]bool{"@@ -1,3 +1,3 @@": true, "@@ -10,2 +10,3 @@": true})
+	want := `diff --git a/a.go b/a.go
+index 1..2 100644
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@ package a
+ ⋯ 4 lines folded
+@@ -10,2 +10,3 @@ func f() {
+ ⋯ 3 lines folded
+`
+	if got != want {
+		t.Errorf("FoldHunks =\n%s\nwant\n%s", got, want)
+	}
+
+	if FoldHunks(twoHunks, nil) != twoHunks {
+		t.Error("expected nothing folded without headers")
+	}
+}
+
+func TestGitCanStage(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want bool
+	}{
+		{"unstaged", Options{Scope: ScopeUnstaged}, true},
+		{"all against HEAD", Options{Scope: ScopeAll}, false},
+		{"staged", Options{Scope: ScopeStaged}, false},
+		{"unstaged ignoring whitespace", Options{Scope: ScopeUnstaged, IgnoreWhitespace: true}, false},
+		{"range", Options{From: "main"}, false},
+	}
+	for _, tt := range tests {
+		g := &Git{dir: t.TempDir(), opts: tt.opts}
+		if got := g.CanStage(); got != tt.want {
+			t.Errorf("%s: CanStage = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGitStageHunkRefusesScopeAll(t *testing.T) {
+	g := &Git{dir: t.TempDir()}
+	header, hunk, _ := Hunk(twoHunks, "@@ -1,3 +1,3 @@")
+	if err := g.StageHunk(context.Background(), header+hunk); err == nil {
+		t.Error("expected a hunk diffed against HEAD not staged")
+	}
+}
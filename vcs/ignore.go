@@ -0,0 +1,115 @@
+package vcs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile names the file at the top of a repository listing, in
+// gitignore syntax, the files left out of reviews
+const IgnoreFile = ".tcrignore"
+
+// Ignore is a parsed .tcrignore. The nil Ignore ignores nothing.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+// ignoreRule is one pattern line of a .tcrignore
+type ignoreRule struct {
+	segments []string // The pattern split at slashes
+	anchored bool     // Matched from the top, having had a leading or middle slash
+	dirOnly  bool     // Matches directories only, having had a trailing slash
+	negate   bool     // Re-includes what earlier rules ignore, having had a leading !
+}
+
+// LoadIgnore reads the .tcrignore in dir, or returns nil if there is none
+func LoadIgnore(dir string) (*Ignore, error) {
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+	return ParseIgnore(string(data)), nil
+}
+
+// ParseIgnore parses patterns in gitignore syntax: one per line, # for
+// comments, ! to re-include, a trailing slash for directories only, and
+// a leading or middle slash to match from the top rather than at any depth
+func ParseIgnore(text string) *Ignore {
+	ig := &Ignore{}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		// Trailing spaces are dropped unless escaped
+		if trimmed := strings.TrimRight(line, " "); strings.HasSuffix(trimmed, `\`) && trimmed != line {
+			line = trimmed + " "
+		} else {
+			line = trimmed
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		rule.segments = strings.Split(line, "/")
+		ig.rules = append(ig.rules, rule)
+	}
+	return ig
+}
+
+// Ignores reports whether a slash-separated path from the top of the
+// repository is ignored. As in git, the last rule matching a path decides,
+// and nothing inside an ignored directory can be re-included.
+func (ig *Ignore) Ignores(name string) bool {
+	if ig == nil {
+		return false
+	}
+	segments := strings.Split(name, "/")
+	for i := 1; i <= len(segments); i++ {
+		if ig.ignored(segments[:i], i < len(segments)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored reports whether the last rule matching a file, or a directory,
+// ignores it
+func (ig *Ignore) ignored(segments []string, dir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+		var match bool
+		if rule.anchored {
+			match = matchSegments(rule.segments, segments)
+		} else {
+			match, _ = path.Match(rule.segments[0], segments[len(segments)-1])
+		}
+		if match {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,94 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	ig := ParseIgnore(`# Lockfiles and generated code
+*.lock
+package-lock.json
+*.pb.go
+!keep.pb.go
+/dist
+build/
+docs/**/*.png
+\#notes.md
+trailing.txt   
+`)
+	tests := map[string]bool{
+		"Cargo.lock":                  true,
+		"web/yarn.lock":               true,
+		"web/package-lock.json":       true,
+		"api/v1/service.pb.go":        true,
+		"api/v1/keep.pb.go":           false,
+		"dist/app.js":                 true,
+		"web/dist/app.js":             false,
+		"build/out.o":                 true,
+		"cmd/build/main.go":           true,
+		"build":                       false, // A file, not a directory
+		"docs/img/arch.png":           true,
+		"docs/arch.png":               true,
+		"img/arch.png":                false,
+		"#notes.md":                   true,
+		"trailing.txt":                true,
+		"main.go":                     false,
+		"api/v1/service.go":           false,
+		"lock":                        false,
+		"vendor/github.com/x/y.pb.go": true,
+	}
+	for name, want := range tests {
+		if got := ig.Ignores(name); got != want {
+			t.Errorf("Ignores(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	// Files in an ignored directory stay ignored, as in git
+	if ParseIgnore("gen/\n!gen/keep.go\n").Ignores("gen/keep.go") != true {
+		t.Error("expected a file in an ignored directory to stay ignored")
+	}
+	if ParseIgnore("gen/*\n!gen/keep.go\n").Ignores("gen/keep.go") != false {
+		t.Error("expected a file re-included from an ignored directory's contents")
+	}
+
+	var none *Ignore
+	if none.Ignores("main.go") {
+		t.Error("expected the nil Ignore to ignore nothing")
+	}
+}
+
+func TestLoadIgnore(t *testing.T) {
+	dir := t.TempDir()
+	if ig, err := LoadIgnore(dir); ig != nil || err != nil {
+		t.Errorf("LoadIgnore without a file = %v, %v; want nil, nil", ig, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("*.lock\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := LoadIgnore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ig.Ignores("go.lock") || ig.Ignores("go.mod") {
+		t.Errorf("unexpected rules loaded: %+v", ig.rules)
+	}
+}
+
+func TestFilterDiff(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/yarn.lock b/yarn.lock\n--- a/yarn.lock\n+++ b/yarn.lock\n@@ -1 +1 @@\n-1\n+2\n"
+	opts := Options{Ignore: ParseIgnore("*.lock")}
+	if got, want := filterDiff(opts, diff), diff[:strings.Index(diff, "diff --git a/yarn")]; got != want {
+		t.Errorf("filterDiff = %q, want %q", got, want)
+	}
+	opts.ShowIgnored = true
+	if got := filterDiff(opts, diff); got != diff {
+		t.Errorf("expected ignored files shown, got %q", got)
+	}
+	if got := filterDiff(Options{Ignore: ParseIgnore("*.lock")}, ""); got != "" {
+		t.Errorf("expected an empty diff kept empty, got %q", got)
+	}
+}
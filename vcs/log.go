@@ -0,0 +1,102 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LogEntry is one commit, or jj change, in the history
+type LogEntry struct {
+	ID      string // Short commit or change ID, to review with Options.To
+	Author  string
+	Date    string // YYYY-MM-DD
+	Subject string
+}
+
+// Logger is implemented by backends with a history to browse
+type Logger interface {
+	Log(ctx context.Context, limit int) ([]LogEntry, error) // Newest first
+}
+
+// parseLog parses log output formatted as the ID, author, date and
+// subject separated by NULs, with a \x01 after each entry
+func parseLog(output string) []LogEntry {
+	var entries []LogEntry
+	for _, record := range strings.Split(output, "\x01") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		entries = append(entries, LogEntry{ID: fields[0], Author: fields[1], Date: fields[2], Subject: fields[3]})
+	}
+	return entries
+}
+
+// Log lists the latest commits reachable from HEAD
+func (g *Git) Log(ctx context.Context, limit int) ([]LogEntry, error) {
+	output, err := run(ctx, g.dir, "git", "log", "-n", strconv.Itoa(limit), "--date=short", "--format=%h%x00%an%x00%ad%x00%s%x01")
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %s", failureText(output, err))
+	}
+	return parseLog(string(output)), nil
+}
+
+// Log lists the latest changes in the working copy's ancestry, the working
+// copy first
+func (j *JJ) Log(ctx context.Context, limit int) ([]LogEntry, error) {
+	template := `change_id.short() ++ "\x00" ++ author.name() ++ "\x00" ++ author.timestamp().format("%Y-%m-%d") ++ "\x00" ++ description.first_line() ++ "\x01"`
+	output, err := run(ctx, j.dir, "jj", "log", "--no-graph", "-r", "::@ ~ root()", "--limit", strconv.Itoa(limit), "-T", template)
+	if err != nil {
+		return nil, fmt.Errorf("jj log failed: %s", failureText(output, err))
+	}
+	return parseLog(string(output)), nil
+}
+
+// Ref is a branch, or jj bookmark, to diff against
+type Ref struct {
+	Name string
+	ID   string // Short commit ID it points to
+}
+
+// RefLister is implemented by backends with named branches to pick a base
+// from
+type RefLister interface {
+	Refs(ctx context.Context) ([]Ref, error) // Most recently updated first, for git
+}
+
+// parseRefs parses lines of a ref name and commit ID separated by a NUL
+func parseRefs(output string) []Ref {
+	var refs []Ref
+	for _, line := range strings.Split(output, "\n") {
+		name, id, ok := strings.Cut(line, "\x00")
+		// A symbolic ref like origin/HEAD names another ref in the list
+		if !ok || name == "" || strings.HasSuffix(name, "/HEAD") {
+			continue
+		}
+		refs = append(refs, Ref{Name: name, ID: id})
+	}
+	return refs
+}
+
+// Refs lists the local and remote-tracking branches, most recently
+// committed to first
+func (g *Git) Refs(ctx context.Context) ([]Ref, error) {
+	output, err := run(ctx, g.dir, "git", "for-each-ref", "--sort=-committerdate", "--format=%(refname:short)%00%(objectname:short)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref failed: %s", failureText(output, err))
+	}
+	return parseRefs(string(output)), nil
+}
+
+// Refs lists the local bookmarks. A conflicted bookmark has no single
+// commit, so it's listed without one.
+func (j *JJ) Refs(ctx context.Context) ([]Ref, error) {
+	template := `name ++ "\x00" ++ if(normal_target, normal_target.commit_id().short()) ++ "\n"`
+	output, err := run(ctx, j.dir, "jj", "bookmark", "list", "-T", template)
+	if err != nil {
+		return nil, fmt.Errorf("jj bookmark list failed: %s", failureText(output, err))
+	}
+	return parseRefs(string(output)), nil
+}
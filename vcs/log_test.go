@@ -0,0 +1,37 @@
+package vcs
+
+import "testing"
+
+func TestParseLog(t *testing.T) {
+	output := "abc1234\x00Ann\x002024-10-01\x00Fix the parser\x01\ndef5678\x00Bob\x002024-09-30\x00\x01\n"
+	entries := parseLog(output)
+	want := []LogEntry{
+		{ID: "abc1234", Author: "Ann", Date: "2024-10-01", Subject: "Fix the parser"},
+		{ID: "def5678", Author: "Bob", Date: "2024-09-30"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+	if entries := parseLog(""); entries != nil {
+		t.Errorf("expected no entries, got %+v", entries)
+	}
+}
+
+func TestParseRefs(t *testing.T) {
+	output := "main\x001a2b3c4\norigin/HEAD\x001a2b3c4\norigin/main\x001a2b3c4\nfeature\x00\n\n"
+	refs := parseRefs(output)
+	want := []Ref{{Name: "main", ID: "1a2b3c4"}, {Name: "origin/main", ID: "1a2b3c4"}, {Name: "feature"}}
+	if len(refs) != len(want) {
+		t.Fatalf("expected %d refs, got %+v", len(want), refs)
+	}
+	for i := range want {
+		if refs[i] != want[i] {
+			t.Errorf("ref %d = %+v, want %+v", i, refs[i], want[i])
+		}
+	}
+}
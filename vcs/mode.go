@@ -0,0 +1,97 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// markModeChanges marks the modified files whose mode alone changed. git
+// lists them as modified, so those with a mode change among the diffed
+// revisions given by args are checked for changed lines.
+func (g *Git) markModeChanges(ctx context.Context, args []string, changes []FileChange) ([]FileChange, error) {
+	command := append(append([]string{"diff"}, args...), "--summary", "--no-renames")
+	output, err := run(ctx, g.dir, "git", command...)
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %s", strings.Join(command, " "), failureText(output, err))
+	}
+	modes := parseModeChanges(string(output))
+	var paths []string
+	for _, c := range changes {
+		if modes[c.Path] && c.Status == StatusModified {
+			paths = append(paths, c.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return changes, nil
+	}
+
+	command = append(append(append([]string{"diff"}, args...), "--numstat", "--no-renames", "--"), paths...)
+	output, err = run(ctx, g.dir, "git", command...)
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %s", strings.Join(command, " "), failureText(output, err))
+	}
+	unchanged := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		if path, ok := strings.CutPrefix(line, "0\t0\t"); ok {
+			unchanged[path] = true
+		}
+	}
+	for i, c := range changes {
+		if unchanged[c.Path] && c.Status == StatusModified {
+			changes[i].Status = StatusModeChanged
+		}
+	}
+	return changes, nil
+}
+
+// parseModeChanges reads the paths whose mode changed from git diff
+// --summary, as " mode change 100644 => 100755 path"
+func parseModeChanges(output string) map[string]bool {
+	modes := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 6)
+		if len(fields) == 6 && fields[0] == "mode" && fields[1] == "change" && fields[3] == "=>" {
+			modes[fields[5]] = true
+		}
+	}
+	return modes
+}
+
+// ReadableModes replaces the "old mode" and "new mode" lines of a git diff,
+// easily missed among its headers, with a line saying what changed, as
+// "Mode changed: 100644 → 100755 (made executable)"
+func ReadableModes(diff string) string {
+	if !strings.Contains(diff, "\nold mode ") {
+		return diff
+	}
+	lines := strings.Split(diff, "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		oldMode, ok := strings.CutPrefix(lines[i], "old mode ")
+		if !ok || i+1 == len(lines) {
+			out = append(out, lines[i])
+			continue
+		}
+		newMode, ok := strings.CutPrefix(lines[i+1], "new mode ")
+		if !ok {
+			out = append(out, lines[i])
+			continue
+		}
+		out = append(out, "Mode changed: "+oldMode+" → "+newMode+modeEffect(oldMode, newMode))
+		i++
+	}
+	return strings.Join(out, "\n")
+}
+
+// modeEffect explains a change of a file's mode in words
+func modeEffect(oldMode, newMode string) string {
+	executable := func(mode string) bool { return strings.HasSuffix(mode, "755") }
+	switch {
+	case !executable(oldMode) && executable(newMode):
+		return " (made executable)"
+	case executable(oldMode) && !executable(newMode):
+		return " (no longer executable)"
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package vcs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseModeChanges(t *testing.T) {
+	output := ` mode change 100644 => 100755 run.sh
+ mode change 100755 => 100644 scripts/my tool.py
+ rename c => d (100%)
+ mode change 100644 => 100755
+ create mode 100644 new.go
+`
+	want := map[string]bool{"run.sh": true, "scripts/my tool.py": true}
+	if got := parseModeChanges(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseModeChanges = %v, want %v", got, want)
+	}
+}
+
+func TestReadableModes(t *testing.T) {
+	diff := "diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n"
+	want := "diff --git a/run.sh b/run.sh\nMode changed: 100644 → 100755 (made executable)\n"
+	if got := ReadableModes(diff); got != want {
+		t.Errorf("ReadableModes = %q, want %q", got, want)
+	}
+
+	diff = "diff --git a/x b/x\nold mode 100755\nnew mode 100644\nindex 1..2\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-old mode 1\n+b\n"
+	want = "diff --git a/x b/x\nMode changed: 100755 → 100644 (no longer executable)\nindex 1..2\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-old mode 1\n+b\n"
+	if got := ReadableModes(diff); got != want {
+		t.Errorf("ReadableModes = %q, want %q", got, want)
+	}
+
+	if plain := "@@ -1 +1 @@\n-a\n+b\n"; ReadableModes(plain) != plain {
+		t.Error("expected a diff without mode lines left alone")
+	}
+}
+
+func TestPatch_ModeChanged(t *testing.T) {
+	p, err := NewPatch("patch", "diff --git a/run.sh b/run.sh\nold mode 100644\nnew mode 100755\n"+
+		"diff --git a/edit.sh b/edit.sh\nold mode 100644\nnew mode 100755\n--- a/edit.sh\n+++ b/edit.sh\n@@ -1 +1 @@\n-a\n+b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{{Path: "run.sh", Status: StatusModeChanged}, {Path: "edit.sh", Status: StatusModified}}
+	if !reflect.DeepEqual(p.files, want) {
+		t.Errorf("files = %+v, want %+v", p.files, want)
+	}
+}
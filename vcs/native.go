@@ -0,0 +1,438 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Native implements VCS for git in-process with go-git, listing and
+// diffing the changes without running a git binary. Renames show as a
+// delete and an add, and every file has mode 100644.
+type Native struct {
+	dir  string
+	repo *git.Repository
+
+	// opts is guarded by optsMu, as loads read it while SetOptions runs;
+	// each method reads one copy through Options
+	optsMu sync.Mutex
+	opts   Options
+
+	// go-git's object storage isn't safe for concurrent use, and the UI
+	// diffs several files at once
+	mu sync.Mutex
+}
+
+// NewNative opens the git repository in dir
+func NewNative(dir string, opts Options) (*Native, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository %s: %w", dir, err)
+	}
+	return &Native{dir: dir, opts: opts, repo: repo}, nil
+}
+
+func (n *Native) Name() string {
+	return "git"
+}
+
+// Options returns a copy of the options, safe to read while SetOptions
+// runs on another goroutine
+func (n *Native) Options() Options {
+	n.optsMu.Lock()
+	defer n.optsMu.Unlock()
+	return n.opts
+}
+
+func (n *Native) SetOptions(opts Options) {
+	n.optsMu.Lock()
+	defer n.optsMu.Unlock()
+	n.opts = opts
+}
+
+// Scopes lists the staging scopes, of which a range of revisions has none
+func (n *Native) Scopes() []Scope {
+	if n.Options().HasRange() {
+		return []Scope{ScopeAll}
+	}
+	return []Scope{ScopeAll, ScopeStaged, ScopeUnstaged}
+}
+
+// nativeSide is one side of a native diff: a revision's tree, the index
+// or the working tree. With none of them it has no files, like HEAD
+// before the first commit.
+type nativeSide struct {
+	tree  *object.Tree
+	index *index.Index
+	work  bool
+}
+
+// read returns path's content on the side, and whether it's there
+func (n *Native) read(side nativeSide, path string) (string, bool, error) {
+	switch {
+	case side.work:
+		content, err := readFile(filepath.Join(n.dir, filepath.FromSlash(path)))
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", false, nil
+		}
+		return content, err == nil, err
+	case side.index != nil:
+		entry, err := side.index.Entry(path)
+		if errors.Is(err, index.ErrEntryNotFound) {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+		blob, err := n.repo.BlobObject(entry.Hash)
+		if err != nil {
+			return "", false, err
+		}
+		r, err := blob.Reader()
+		if err != nil {
+			return "", false, err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		return string(data), err == nil, err
+	case side.tree != nil:
+		file, err := side.tree.File(path)
+		if errors.Is(err, object.ErrFileNotFound) {
+			return "", false, nil
+		} else if err != nil {
+			return "", false, err
+		}
+		content, err := file.Contents()
+		return content, err == nil, err
+	}
+	return "", false, nil
+}
+
+// tree resolves a revision, such as a branch or HEAD~2, to its tree, nil
+// for the empty tree
+func (n *Native) tree(rev string) (*object.Tree, error) {
+	if rev == emptyTree {
+		return nil, nil
+	}
+	hash, err := n.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s: %w", rev, err)
+	}
+	commit, err := n.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+	return commit.Tree()
+}
+
+// rangeFrom returns the base of the range: From, or To's parent, or the
+// empty tree when To has none in this clone
+func (n *Native) rangeFrom(opts Options) string {
+	if opts.From != "" {
+		return opts.From
+	}
+	if _, err := n.repo.ResolveRevision(plumbing.Revision(opts.To + "^")); err != nil {
+		if _, err := n.repo.ResolveRevision(plumbing.Revision(opts.To)); err == nil {
+			return emptyTree
+		}
+	}
+	return opts.To + "^"
+}
+
+// headTree returns HEAD's tree, or nil before the first commit
+func (n *Native) headTree() (*object.Tree, error) {
+	if _, err := n.repo.Head(); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+	return n.tree("HEAD")
+}
+
+// sides returns what opts' scope diffs: HEAD, the index or the working
+// tree, or the revisions of the range being reviewed
+func (n *Native) sides(opts Options) (base, head nativeSide, err error) {
+	if opts.HasRange() {
+		if base.tree, err = n.tree(n.rangeFrom(opts)); err != nil {
+			return base, head, err
+		}
+		if opts.To == "" {
+			head.work = true
+		} else {
+			head.tree, err = n.tree(opts.To)
+		}
+		return base, head, err
+	}
+
+	var idx *index.Index
+	if opts.Scope != ScopeAll {
+		if idx, err = n.repo.Storer.Index(); err != nil {
+			return base, head, fmt.Errorf("failed to read the index: %w", err)
+		}
+	}
+	switch opts.Scope {
+	case ScopeStaged:
+		base.tree, err = n.headTree()
+		head.index = idx
+	case ScopeUnstaged:
+		base.index = idx
+		head.work = true
+	default:
+		base.tree, err = n.headTree()
+		head.work = true
+	}
+	return base, head, err
+}
+
+// candidates lists the paths that may differ between the sides, and
+// which of them git doesn't track. Each is checked by content after.
+func (n *Native) candidates(ctx context.Context, opts Options) (paths []string, untracked map[string]bool, err error) {
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	if opts.HasRange() {
+		fromTree, err := n.tree(n.rangeFrom(opts))
+		if err != nil {
+			return nil, nil, err
+		}
+		toTree, err := n.headTree()
+		if opts.To != "" {
+			toTree, err = n.tree(opts.To)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if fromTree == nil {
+			fromTree = &object.Tree{}
+		}
+		if toTree == nil {
+			toTree = &object.Tree{}
+		}
+		changes, err := object.DiffTreeContext(ctx, fromTree, toTree)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, c := range changes {
+			add(c.From.Name)
+			add(c.To.Name)
+		}
+		if opts.To != "" {
+			return paths, nil, nil
+		}
+		// Against the working tree: what differs from HEAD, then what was
+		// changed since
+	}
+
+	wt, err := n.repo.Worktree()
+	if err != nil {
+		return nil, nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the status: %w", err)
+	}
+	untracked = make(map[string]bool)
+	for path, s := range status {
+		if s.Staging == git.Untracked {
+			untracked[path] = true
+		}
+		staged := s.Staging != git.Unmodified && s.Staging != git.Untracked
+		switch {
+		case opts.HasRange(), opts.Scope == ScopeAll:
+			add(path)
+		case opts.Scope == ScopeStaged && staged:
+			add(path)
+		case opts.Scope == ScopeUnstaged && s.Worktree != git.Unmodified:
+			add(path)
+		}
+	}
+	return paths, untracked, ctx.Err()
+}
+
+func (n *Native) ChangedFiles(ctx context.Context) ([]FileChange, error) {
+	opts := n.Options()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	base, head, err := n.sides(opts)
+	if err != nil {
+		return nil, err
+	}
+	paths, untracked, err := n.candidates(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	paths = slices.DeleteFunc(paths, func(path string) bool { return !opts.Keeps(path) })
+	// Untracked files last, as git lists them
+	sort.Slice(paths, func(i, j int) bool {
+		if untracked[paths[i]] != untracked[paths[j]] {
+			return untracked[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+
+	var changes []FileChange
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		before, inBase, err := n.read(base, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		after, inHead, err := n.read(head, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		switch {
+		case inBase && inHead && before != after:
+			changes = append(changes, FileChange{Path: path, Status: StatusModified})
+		case inBase && !inHead:
+			changes = append(changes, FileChange{Path: path, Status: StatusDeleted})
+		case !inBase && inHead && head.work && untracked[path]:
+			changes = append(changes, FileChange{Path: path, Status: StatusUntracked})
+		case !inBase && inHead:
+			changes = append(changes, FileChange{Path: path, Status: StatusAdded})
+		}
+	}
+
+	if head.tree == nil {
+		// A merge leaves its conflicts in the index, as entries in stages
+		// 1 to 3 (go-git's index.Merged is 1, though merged entries are 0)
+		if idx, err := n.repo.Storer.Index(); err == nil {
+			var conflicted []string
+			for _, e := range idx.Entries {
+				if e.Stage > 0 && !slices.Contains(conflicted, e.Name) {
+					conflicted = append(conflicted, e.Name)
+				}
+			}
+			changes = markConflicts(changes, conflicted)
+		}
+	}
+	return changes, nil
+}
+
+// FileContents reads path on one side of the current scope: HEAD, the
+// index or the working tree, or a revision of the range being reviewed
+func (n *Native) FileContents(ctx context.Context, path string, rev Rev) (string, error) {
+	opts := n.Options()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	base, head, err := n.sides(opts)
+	if err != nil {
+		return "", err
+	}
+	side := head
+	if rev == RevBase {
+		side = base
+	}
+	content, ok, err := n.read(side, path)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+	return content, nil
+}
+
+func (n *Native) Diff(ctx context.Context, path string) (string, error) {
+	opts := n.Options()
+	if output, ok := toolDiff(ctx, n, n.dir, opts, path); ok {
+		return output, nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	base, head, err := n.sides(opts)
+	if err != nil {
+		return "", err
+	}
+	before, inBase, err := n.read(base, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	after, inHead, err := n.read(head, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return unifiedDiff(path, before, after, inBase, inHead, opts), nil
+}
+
+func (n *Native) DiffAll(ctx context.Context) (string, error) {
+	return concatDiffs(ctx, n)
+}
+
+// Log lists the latest commits reachable from HEAD
+func (n *Native) Log(ctx context.Context, limit int) ([]LogEntry, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	commits, err := n.repo.Log(&git.LogOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the log: %w", err)
+	}
+	defer commits.Close()
+
+	var entries []LogEntry
+	for len(entries) < limit {
+		c, err := commits.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		entries = append(entries, LogEntry{
+			ID:      c.Hash.String()[:7],
+			Author:  c.Author.Name,
+			Date:    c.Author.When.Format("2006-01-02"),
+			Subject: subject,
+		})
+	}
+	return entries, ctx.Err()
+}
+
+// Explain says what is diffed: the working tree, index or range
+// against their base, named with its short commit ID
+func (n *Native) Explain(ctx context.Context) Comparison {
+	opts := n.Options()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	shortRev := func(rev string) string {
+		hash, err := n.repo.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return ""
+		}
+		return hash.String()[:7]
+	}
+	defaultBranch := func() string {
+		ref, err := n.repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), true)
+		if err != nil {
+			return "main"
+		}
+		return ref.Name().Short()
+	}
+	var from string
+	if opts.HasRange() {
+		from = n.rangeFrom(opts)
+	}
+	return explainGit(opts, from, shortRev, defaultBranch)
+}
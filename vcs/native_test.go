@@ -0,0 +1,245 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	after := "one\nTWO\nthree\nfour\nfive\nsix\nseven\neight\nnine\nTEN\n"
+
+	want := `diff --git a/f.txt b/f.txt
+--- a/f.txt
++++ b/f.txt
+@@ -1,5 +1,5 @@
+ one
+-two
++TWO
+ three
+ four
+ five
+@@ -7,4 +7,4 @@
+ seven
+ eight
+ nine
+-ten
++TEN
+`
+	if got := unifiedDiff("f.txt", before, after, true, true, Options{}); got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+
+	// More context merges the hunks
+	if got := unifiedDiff("f.txt", before, after, true, true, Options{ContextLines: 4}); strings.Count(got, "@@ ") != 1 || !strings.Contains(got, "@@ -1,10 +1,10 @@") {
+		t.Errorf("expected one hunk with 4 lines of context, got:\n%s", got)
+	}
+
+	added := unifiedDiff("new.txt", "", "hi", false, true, Options{})
+	if want := "diff --git a/new.txt b/new.txt\nnew file mode 100644\n--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+hi\n\\ No newline at end of file\n"; added != want {
+		t.Errorf("added file diff =\n%q\nwant\n%q", added, want)
+	}
+	deleted := unifiedDiff("old.txt", "a\nb\n", "", true, false, Options{})
+	if !strings.Contains(deleted, "deleted file mode 100644\n--- a/old.txt\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n") {
+		t.Errorf("unexpected deleted file diff:\n%s", deleted)
+	}
+	if binary := unifiedDiff("b.bin", "a\x00", "b\x00", true, true, Options{}); !strings.HasSuffix(binary, "Binary files a/b.bin and b/b.bin differ\n") {
+		t.Errorf("unexpected binary diff:\n%s", binary)
+	}
+	if same := unifiedDiff("f.txt", before, before, true, true, Options{}); same != "" {
+		t.Errorf("expected no diff for an unchanged file, got:\n%s", same)
+	}
+}
+
+func TestUnifiedDiff_IgnoreWhitespace(t *testing.T) {
+	before := "func f() {\nreturn  x\n}\n"
+	after := "func f() {\n\treturn x\n\treturn y\n}\n"
+	ignore := Options{IgnoreWhitespace: true}
+
+	// Lines changed only in whitespace are context, as they are after
+	want := `diff --git a/f.go b/f.go
+--- a/f.go
++++ b/f.go
+@@ -1,3 +1,4 @@
+ func f() {
+ 	return x
++	return y
+ }
+`
+	if got := unifiedDiff("f.go", before, after, true, true, ignore); got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("f.go", before, "func f() {\n\treturn x\n}\n", true, true, ignore); got != "" {
+		t.Errorf("expected no diff for a whitespace-only change, got:\n%s", got)
+	}
+	if got := unifiedDiff("f.go", before, "func f() {\n\treturn x\n}\n", true, true, Options{}); !strings.Contains(got, "-return  x\n+\treturn x\n") {
+		t.Errorf("expected whitespace changes without the option, got:\n%s", got)
+	}
+}
+
+func TestNative(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit := func(msg string) {
+		t.Helper()
+		if err := wt.AddGlob("."); err != nil {
+			t.Fatal(err)
+		}
+		sig := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+		if _, err := wt.Commit(msg, &git.CommitOptions{Author: sig}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeTestFile(t, dir, ".gitignore", "*.log\n")
+	writeTestFile(t, dir, "edit.txt", "one\ntwo\n")
+	writeTestFile(t, dir, "gone.txt", "bye\n")
+	commit("Initial commit")
+
+	writeTestFile(t, dir, "edit.txt", "one\n2\n")
+	writeTestFile(t, dir, "staged.txt", "staged\n")
+	if _, err := wt.Add("staged.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Remove("gone.txt"); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "dir/new.txt", "new\n")
+	writeTestFile(t, dir, "debug.log", "ignored\n")
+
+	v, err := DetectWithOptions(dir, Options{Native: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.(*Native); !ok || v.Name() != "git" {
+		t.Fatalf("expected the native git backend, got %T", v)
+	}
+
+	changes := func(opts Options) []FileChange {
+		t.Helper()
+		v.(Configurable).SetOptions(opts)
+		changes, err := v.ChangedFiles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return changes
+	}
+	want := []FileChange{
+		{Path: "edit.txt", Status: StatusModified},
+		{Path: "gone.txt", Status: StatusDeleted},
+		{Path: "staged.txt", Status: StatusAdded},
+		{Path: "dir/new.txt", Status: StatusUntracked},
+	}
+	if got := changes(Options{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles = %+v, want %+v", got, want)
+	}
+	if got := changes(Options{Scope: ScopeStaged}); !reflect.DeepEqual(got, want[1:3]) {
+		t.Errorf("staged ChangedFiles = %+v, want %+v", got, want[1:3])
+	}
+	if got := changes(Options{Scope: ScopeUnstaged}); !reflect.DeepEqual(got, []FileChange{want[0], want[3]}) {
+		t.Errorf("unstaged ChangedFiles = %+v", got)
+	}
+
+	v.(Configurable).SetOptions(Options{})
+	diff, err := v.Diff(context.Background(), "edit.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "@@ -1,2 +1,2 @@\n one\n-two\n+2\n") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+	if base, err := v.(ContentReader).FileContents(context.Background(), "edit.txt", RevBase); err != nil || base != "one\ntwo\n" {
+		t.Errorf("base contents = %q, %v", base, err)
+	}
+	if _, err := v.(ContentReader).FileContents(context.Background(), "gone.txt", RevHead); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a deleted file to be missing, got %v", err)
+	}
+
+	// The changes once committed, as a range
+	commit("Second commit")
+	if got := changes(Options{To: "HEAD"}); !reflect.DeepEqual(got, []FileChange{
+		{Path: "dir/new.txt", Status: StatusAdded},
+		{Path: "edit.txt", Status: StatusModified},
+		{Path: "gone.txt", Status: StatusDeleted},
+		{Path: "staged.txt", Status: StatusAdded},
+	}) {
+		t.Errorf("range ChangedFiles = %+v", got)
+	}
+	v.(Configurable).SetOptions(Options{To: "HEAD"})
+	if compared := v.(Explainer).Explain(context.Background()).Compared; !strings.HasPrefix(compared, "HEAD (") || !strings.Contains(compared, " against HEAD^ (") {
+		t.Errorf("unexpected comparison %q", compared)
+	}
+	if got := changes(Options{}); len(got) != 0 {
+		t.Errorf("expected a clean working tree, got %+v", got)
+	}
+	v.(Configurable).SetOptions(Options{From: "nope"})
+	if _, err := v.ChangedFiles(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown revision nope") {
+		t.Errorf("expected an unknown revision error, got %v", err)
+	}
+
+	entries, err := v.(Logger).Log(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Subject != "Second commit" || entries[1].Date != "2024-05-01" || entries[1].Author != "Test User" {
+		t.Errorf("unexpected log %+v", entries)
+	}
+}
+
+// Run with -race: the UI toggles options while preloads diff
+func TestNativeSetOptionsDuringDiff(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "f.txt", "one\ntwo\n")
+	if _, err := wt.Add("f.txt"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	if _, err := wt.Commit("Initial commit", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "f.txt", "one\n  two\n")
+
+	n, err := NewNative(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 50 {
+			n.SetOptions(Options{IgnoreWhitespace: i%2 == 0, ContextLines: i})
+		}
+	}()
+	for range 50 {
+		if _, err := n.Diff(context.Background(), "f.txt"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+	if got := n.Options().ContextLines; got != 49 {
+		t.Errorf("expected the last options to stick, got ContextLines %d", got)
+	}
+}
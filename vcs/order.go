@@ -0,0 +1,53 @@
+package vcs
+
+import (
+	"sort"
+	"strings"
+)
+
+// Order says how a list of changed files is arranged
+type Order string
+
+const (
+	// OrderDiff keeps the order the files appear in the raw diff
+	OrderDiff Order = "diff"
+	// OrderPath sorts by full path, like a forge's flat file list
+	OrderPath Order = "path"
+	// OrderTree sorts by path with directories before the files beside
+	// them, like the GitHub and GitLab file trees
+	OrderTree Order = "tree"
+)
+
+// SortChanges returns changes arranged by order. The input is left
+// untouched; unknown orders behave like OrderDiff.
+func SortChanges(changes []FileChange, order Order) []FileChange {
+	sorted := append([]FileChange(nil), changes...)
+	switch order {
+	case OrderPath:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Path < sorted[j].Path
+		})
+	case OrderTree:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return treeLess(sorted[i].Path, sorted[j].Path)
+		})
+	}
+	return sorted
+}
+
+// treeLess compares paths component by component, putting a directory
+// ahead of a file at the same level
+func treeLess(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		aDir, bDir := i < len(as)-1, i < len(bs)-1
+		if aDir != bDir {
+			return aDir
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,313 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Annotation is an existing review comment on one side of a file
+type Annotation struct {
+	Line    int // 1-based line on that side, 0 for the whole file
+	Side    Rev
+	Author  string
+	Message string
+}
+
+// Annotator is implemented by backends that know of review comments made
+// elsewhere, such as on a code review server, to show alongside the diff
+type Annotator interface {
+	Annotations(path string) []Annotation
+}
+
+// Patch is a backend over a fixed unified patch, such as one downloaded
+// from a code review server. It has no working tree; its diffs are the
+// patch's file sections as given.
+type Patch struct {
+	name        string
+	files       []FileChange
+	diffs       map[string]string
+	description string // Commit message of a format-patch patch
+}
+
+// NewPatch splits a git-style patch (as from git diff or git format-patch)
+// into per-file diffs, or failing that a plain unified diff (as from
+// diff -u or svn diff). name is what Name reports.
+func NewPatch(name, patch string) (*Patch, error) {
+	p := &Patch{name: name, diffs: make(map[string]string)}
+
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	start := -1
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && !strings.HasPrefix(lines[i], "diff --git ") {
+			continue
+		}
+		if start >= 0 {
+			p.addSection(lines[start:i])
+		} else if i < len(lines) {
+			p.description = patchDescription(lines[:i])
+		}
+		start = i
+	}
+	if len(p.files) == 0 && !strings.Contains(patch, "diff --git ") {
+		p.addUnified(lines)
+	}
+	if len(p.files) == 0 {
+		return nil, fmt.Errorf("patch has no file changes")
+	}
+	return p, nil
+}
+
+// hunkSizes reads the old and new line counts from a hunk header
+var hunkSizes = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// addUnified records the files of a plain unified diff. Each starts at its
+// ---/+++ lines and runs through its hunks, so whatever tools print between
+// files (diff -r's "Only in", svn's "Index:") is left out.
+func (p *Patch) addUnified(lines []string) {
+	for i := 0; i+1 < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") || !strings.HasPrefix(lines[i+1], "+++ ") {
+			continue
+		}
+		end := i + 2
+		for end < len(lines) {
+			m := hunkSizes.FindStringSubmatch(lines[end])
+			if m == nil {
+				break
+			}
+			oldLines, newLines := hunkSize(m[1]), hunkSize(m[2])
+			for end++; end < len(lines) && (oldLines > 0 || newLines > 0); end++ {
+				switch {
+				case strings.HasPrefix(lines[end], "-"):
+					oldLines--
+				case strings.HasPrefix(lines[end], "+"):
+					newLines--
+				case strings.HasPrefix(lines[end], "\\"):
+				default:
+					oldLines--
+					newLines--
+				}
+			}
+			for end < len(lines) && strings.HasPrefix(lines[end], "\\") {
+				end++
+			}
+		}
+		p.addUnifiedSection(lines[i:end])
+		i = end - 1
+	}
+}
+
+// hunkSize reads a hunk header's line count, which is 1 when left out
+func hunkSize(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// addUnifiedSection records one file of a plain unified diff
+func (p *Patch) addUnifiedSection(section []string) {
+	oldPath := unifiedPath(strings.TrimPrefix(section[0], "--- "))
+	newPath := unifiedPath(strings.TrimPrefix(section[1], "+++ "))
+
+	change := FileChange{Path: newPath, Status: StatusModified}
+	switch {
+	case oldPath == "/dev/null":
+		change.Path, change.Status = strings.TrimPrefix(newPath, "b/"), StatusAdded
+	case newPath == "/dev/null":
+		change.Path, change.Status = strings.TrimPrefix(oldPath, "a/"), StatusDeleted
+	default:
+		// Like patch -p1, drop the a/ and b/ (or old/ and new/) the two
+		// sides are told apart by
+		oldDir, _, oldOK := strings.Cut(oldPath, "/")
+		newDir, newRest, newOK := strings.Cut(newPath, "/")
+		if oldOK && newOK && oldDir != newDir {
+			change.Path = newRest
+		}
+		// diff -N compares a missing file as an empty one
+		if len(section) > 2 {
+			switch {
+			case strings.HasPrefix(section[2], "@@ -0,0 "):
+				change.Status = StatusAdded
+			case strings.Contains(section[2], " +0,0 @@"):
+				change.Status = StatusDeleted
+			}
+		}
+	}
+	if change.Path == "" || change.Path == "/dev/null" {
+		return
+	}
+	if _, dup := p.diffs[change.Path]; dup {
+		return
+	}
+	p.files = append(p.files, change)
+	p.diffs[change.Path] = strings.Join(section, "\n") + "\n"
+}
+
+// unifiedPath reads the path off a ---/+++ line, without the timestamp
+// diff -u puts after a tab
+func unifiedPath(label string) string {
+	path, _, _ := strings.Cut(label, "\t")
+	return strings.TrimSpace(path)
+}
+
+// addSection records one "diff --git" section of the patch
+func (p *Patch) addSection(section []string) {
+	// format-patch ends with a "-- " signature line and the git version
+	for i := len(section) - 1; i > 0; i-- {
+		if section[i] == "-- " {
+			section = section[:i]
+			break
+		}
+	}
+	for len(section) > 0 && section[len(section)-1] == "" {
+		section = section[:len(section)-1]
+	}
+
+	change := FileChange{Status: StatusModified}
+	oldPath, newPath := "", ""
+	modeChanged, hunks := false, false
+headers:
+	for _, line := range section {
+		switch {
+		case strings.HasPrefix(line, "@@"), strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+			// Past the headers, lines can look like anything
+			hunks = true
+			break headers
+		case strings.HasPrefix(line, "new mode "):
+			modeChanged = true
+		case strings.HasPrefix(line, "new file mode"):
+			change.Status = StatusAdded
+		case strings.HasPrefix(line, "deleted file mode"):
+			change.Status = StatusDeleted
+		case strings.HasPrefix(line, "rename from "):
+			change.OldPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			change.Status = StatusRenamed
+			newPath = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "copy from "):
+			change.OldPath = strings.TrimPrefix(line, "copy from ")
+		case strings.HasPrefix(line, "copy to "):
+			change.Status = StatusCopied
+			newPath = strings.TrimPrefix(line, "copy to ")
+		case strings.HasPrefix(line, "--- a/"):
+			oldPath = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "+++ b/"):
+			newPath = strings.TrimPrefix(line, "+++ b/")
+		}
+	}
+	change.Path = newPath
+	if change.Path == "" {
+		change.Path = oldPath
+	}
+	if modeChanged && !hunks && change.Status == StatusModified {
+		change.Status = StatusModeChanged
+	}
+	if change.OldPath != "" {
+		change.NewPath = change.Path
+	}
+	if change.Path == "" {
+		// Binary or mode-only changes have no ---/+++ lines; "diff --git
+		// a/x b/x" names the file twice
+		header := strings.TrimPrefix(section[0], "diff --git a/")
+		if half := (len(header) - len(" b/")) / 2; half > 0 && header[half:half+3] == " b/" {
+			change.Path = header[half+3:]
+		} else {
+			return
+		}
+	}
+	p.files = append(p.files, change)
+	p.diffs[change.Path] = strings.Join(section, "\n") + "\n"
+}
+
+// Name returns the name given to NewPatch
+func (p *Patch) Name() string {
+	return p.name
+}
+
+// ChangedFiles returns the files in the patch, in patch order
+func (p *Patch) ChangedFiles(ctx context.Context) ([]FileChange, error) {
+	return p.files, nil
+}
+
+// Diff returns the patch section for path
+func (p *Patch) Diff(ctx context.Context, path string) (string, error) {
+	diff, ok := p.diffs[path]
+	if !ok {
+		return "", fmt.Errorf("%s is not in the patch", path)
+	}
+	return diff, nil
+}
+
+// DiffAll returns every file's section
+func (p *Patch) DiffAll(ctx context.Context) (string, error) {
+	return concatDiffs(ctx, p)
+}
+
+// ErrPartialFile is wrapped by the errors of FileContents for files the
+// backend has only part of, such as the lines a patch shows around its
+// changes
+var ErrPartialFile = errors.New("only part of the file is available")
+
+// FileContents rebuilds a side of path from its patch section. Only added
+// and deleted files are in a patch whole; the rest have just the lines
+// around each change.
+func (p *Patch) FileContents(ctx context.Context, path string, rev Rev) (string, error) {
+	var change *FileChange
+	for i := range p.files {
+		if p.files[i].Path == path {
+			change = &p.files[i]
+		}
+	}
+	if change == nil {
+		return "", fmt.Errorf("%s is not in the patch: %w", path, fs.ErrNotExist)
+	}
+	switch {
+	case rev == RevBase && change.Status == StatusAdded, rev == RevHead && change.Status == StatusDeleted:
+		return "", fmt.Errorf("%s on that side of the patch: %w", path, fs.ErrNotExist)
+	case change.Status != StatusAdded && change.Status != StatusDeleted:
+		return "", fmt.Errorf("%s in the patch: %w", path, ErrPartialFile)
+	}
+	content, ok := patchSide(p.diffs[path], rev)
+	if !ok {
+		return "", fmt.Errorf("%s in the patch: %w", path, ErrPartialFile)
+	}
+	return content, nil
+}
+
+// patchSide puts together the lines of one side of a file's diff: context
+// and removed lines for the base, context and added lines for the head. It
+// fails for a diff without hunks, such as a binary file's.
+func patchSide(diff string, rev Rev) (string, bool) {
+	drop := "+"
+	if rev == RevHead {
+		drop = "-"
+	}
+	var b strings.Builder
+	hunks, ours := false, false
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			hunks = true
+		case !hunks:
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" follows the line it's about
+			if ours {
+				content := strings.TrimSuffix(b.String(), "\n")
+				b.Reset()
+				b.WriteString(content)
+			}
+		case strings.HasPrefix(line, drop):
+			ours = false
+		default:
+			b.WriteString(line[min(1, len(line)):] + "\n")
+			ours = true
+		}
+	}
+	return b.String(), hunks
+}
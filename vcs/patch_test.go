@@ -0,0 +1,192 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const formatPatch = `From 1234abcd Mon Sep 17 00:00:00 2001
+From: A U Thor <author@example.com>
+Subject: [PATCH] Rework config
+
+Change-Id: I1234567890abcdef1234567890abcdef12345678
+---
+ config.go | 2 +-
+ new.txt   | 1 +
+ 2 files changed
+
+diff --git a/config.go b/config.go
+index 1111111..2222222 100644
+--- a/config.go
++++ b/config.go
+@@ -1,3 +1,3 @@
+ package config
+--- removed comment line that looks like a header
++++ added line that looks like a header
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/a.go b/b.go
+similarity index 90%
+rename from a.go
+rename to b.go
+diff --git a/a.go b/c.go
+similarity index 100%
+copy from a.go
+copy to c.go
+diff --git a/logo.png b/logo.png
+index 4444444..5555555 100644
+Binary files a/logo.png and b/logo.png differ
+-- 
+2.39.0
+`
+
+func TestNewPatch(t *testing.T) {
+	p, err := NewPatch("gerrit", formatPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := p.ChangedFiles(context.Background())
+	want := []FileChange{
+		{Path: "config.go", Status: StatusModified},
+		{Path: "new.txt", Status: StatusAdded},
+		{Path: "old.txt", Status: StatusDeleted},
+		{Path: "b.go", Status: StatusRenamed, OldPath: "a.go", NewPath: "b.go"},
+		{Path: "c.go", Status: StatusCopied, OldPath: "a.go", NewPath: "c.go"},
+		{Path: "logo.png", Status: StatusModified},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("file %d = %v, want %v", i, files[i], want[i])
+		}
+	}
+
+	diff, _ := p.Diff(context.Background(), "config.go")
+	if !strings.HasPrefix(diff, "diff --git a/config.go") || !strings.HasSuffix(diff, "+++ added line that looks like a header\n") {
+		t.Errorf("config.go diff:\n%s", diff)
+	}
+	diff, _ = p.Diff(context.Background(), "logo.png")
+	if strings.Contains(diff, "2.39.0") || strings.Contains(diff, "-- ") {
+		t.Errorf("format-patch signature should be dropped:\n%s", diff)
+	}
+	if _, err := p.Diff(context.Background(), "missing.go"); err == nil {
+		t.Error("expected an error for a file outside the patch")
+	}
+	if all, _ := p.DiffAll(context.Background()); strings.Count(all, "diff --git") != 6 {
+		t.Errorf("DiffAll should hold every file:\n%s", all)
+	}
+
+	if _, err := NewPatch("gerrit", "just a message\n"); err == nil {
+		t.Error("expected an error for a patch without files")
+	}
+}
+
+const unifiedPatch = `Only in old: gone.txt
+diff -ruN old/main.go new/main.go
+--- old/main.go	2024-05-01 10:00:00.000000000 +0200
++++ new/main.go	2024-05-02 10:00:00.000000000 +0200
+@@ -1,3 +1,3 @@
+ package main
+--- not a header, a removed line
++++ not a header, an added line
+ func main() {}
+@@ -10 +10 @@
+-x
++y
+\ No newline at end of file
+diff -ruN old/notes.txt new/notes.txt
+--- old/notes.txt	1970-01-01 01:00:00.000000000 +0100
++++ new/notes.txt	2024-05-02 10:00:00.000000000 +0200
+@@ -0,0 +1,2 @@
++one
++two
+--- /dev/null
++++ b/added.go
+@@ -0,0 +1 @@
++package added
+Index: lib/util.go
+===================================================================
+--- lib/util.go	(revision 12)
++++ lib/util.go	(working copy)
+@@ -1 +1 @@
+-a
++b
+`
+
+func TestPatch_FileContents(t *testing.T) {
+	p, err := NewPatch("patch", formatPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if got, err := p.FileContents(ctx, "new.txt", RevHead); err != nil || got != "hello\n" {
+		t.Errorf("new.txt = %q, %v", got, err)
+	}
+	if got, err := p.FileContents(ctx, "old.txt", RevBase); err != nil || got != "bye\n" {
+		t.Errorf("old.txt = %q, %v", got, err)
+	}
+	if _, err := p.FileContents(ctx, "new.txt", RevBase); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected an added file missing from the base, got %v", err)
+	}
+	if _, err := p.FileContents(ctx, "config.go", RevHead); !errors.Is(err, ErrPartialFile) {
+		t.Errorf("expected a modified file to be partial, got %v", err)
+	}
+	if _, err := p.FileContents(ctx, "nope.go", RevHead); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a file not in the patch to be missing, got %v", err)
+	}
+
+	p, err = NewPatch("patch", "diff --git a/a.txt b/a.txt\nnew file mode 100644\n--- /dev/null\n+++ b/a.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n\\ No newline at end of file\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := p.FileContents(ctx, "a.txt", RevHead); got != "one\ntwo" {
+		t.Errorf("a.txt = %q, want no final newline", got)
+	}
+}
+
+func TestNewPatch_Unified(t *testing.T) {
+	p, err := NewPatch("patch", unifiedPatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := p.ChangedFiles(context.Background())
+	want := []FileChange{
+		{Path: "main.go", Status: StatusModified},
+		{Path: "notes.txt", Status: StatusAdded},
+		{Path: "added.go", Status: StatusAdded},
+		{Path: "lib/util.go", Status: StatusModified},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("got %v, want %v", files, want)
+	}
+
+	diff, _ := p.Diff(context.Background(), "main.go")
+	if !strings.HasPrefix(diff, "--- old/main.go") || !strings.HasSuffix(diff, "\\ No newline at end of file\n") {
+		t.Errorf("main.go diff:\n%s", diff)
+	}
+	diff, _ = p.Diff(context.Background(), "notes.txt")
+	if strings.Contains(diff, "added.go") || strings.Contains(diff, "diff -ruN") {
+		t.Errorf("notes.txt diff should stop at its hunk:\n%s", diff)
+	}
+	diff, _ = p.Diff(context.Background(), "lib/util.go")
+	if !strings.HasPrefix(diff, "--- lib/util.go") || !strings.HasSuffix(diff, "+b\n") {
+		t.Errorf("lib/util.go diff:\n%s", diff)
+	}
+}
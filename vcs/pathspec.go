@@ -0,0 +1,138 @@
+package vcs
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether a slash-separated path matches a glob. A
+// pattern with a slash matches from the top of the repository, with **
+// standing for any number of directories and a trailing slash for
+// everything in a directory, as in src/** or **/*_test.go. A pattern
+// without one, like *.pb.go or vendor, matches any file or directory of
+// that name, and so everything inside a directory.
+func MatchGlob(pattern, name string) bool {
+	segments := strings.Split(name, "/")
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range segments {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), segments)
+}
+
+// matchSegments matches the directories and name of a path against those
+// of a pattern
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// CheckGlob reports a malformed glob, such as one with an unclosed [
+func CheckGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Keeps reports whether opts leave a path in the review: it matches one of
+// the Include globs, if any, none of the Exclude globs, and isn't ignored
+func (o Options) Keeps(name string) bool {
+	if !o.ShowIgnored && o.Ignore.Ignores(name) {
+		return false
+	}
+	for _, pattern := range o.Exclude {
+		if MatchGlob(pattern, name) {
+			return false
+		}
+	}
+	if len(o.Include) == 0 {
+		return true
+	}
+	for _, pattern := range o.Include {
+		if MatchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// filters reports whether opts leave any path out of the review
+func (o Options) filters() bool {
+	return len(o.Include) > 0 || len(o.Exclude) > 0 || (o.Ignore != nil && !o.ShowIgnored)
+}
+
+// keepsChange reports whether opts leave a change in the review. A renamed
+// or copied file stays if either of its paths is kept.
+func (o Options) keepsChange(c FileChange) bool {
+	return o.Keeps(c.Path) || (c.Moved() && o.Keeps(c.OldPath))
+}
+
+// filterChanges drops the changes opts leave out of the review
+func filterChanges(opts Options, changes []FileChange) []FileChange {
+	if !opts.filters() {
+		return changes
+	}
+	var kept []FileChange
+	for _, c := range changes {
+		if opts.keepsChange(c) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// filterDiff drops the sections of a whole git-style diff for the files
+// opts leave out of the review
+func filterDiff(opts Options, diff string) string {
+	if !opts.filters() {
+		return diff
+	}
+	patch, err := NewPatch("", diff)
+	if err != nil {
+		// Nothing changed
+		return diff
+	}
+	var kept strings.Builder
+	for _, c := range patch.files {
+		if opts.keepsChange(c) {
+			kept.WriteString(patch.diffs[c.Path])
+		}
+	}
+	return kept.String()
+}
+
+// filterStats drops the counts of files opts leave out of the review
+func filterStats(opts Options, stats map[string]Stat) {
+	for name := range stats {
+		if !opts.Keeps(name) {
+			delete(stats, name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package vcs
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"src/**", "src/main.go", true},
+		{"src/**", "src/ui/app.go", true},
+		{"src/**", "lib/src/main.go", false},
+		{"src/", "src/ui/app.go", true},
+		{"**/*_test.go", "main_test.go", true},
+		{"**/*_test.go", "ui/panels/diff_test.go", true},
+		{"**/*_test.go", "ui/panels/diff.go", false},
+		{"ui/**/diff.go", "ui/diff.go", true},
+		{"ui/**/diff.go", "ui/panels/diff.go", true},
+		{"ui/*.go", "ui/panels/diff.go", false},
+		{"/main.go", "main.go", true},
+		{"*.pb.go", "api/v1/service.pb.go", true},
+		{"vendor", "vendor/github.com/x/y.go", true},
+		{"vendor", "internal/vendor/y.go", true},
+		{"vendor", "vendored.go", false},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+
+	if err := CheckGlob("src/[a-"); err == nil {
+		t.Error("expected a malformed pattern to be reported")
+	}
+	if err := CheckGlob("src/**/*.go"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFilterChanges(t *testing.T) {
+	changes := []FileChange{
+		{Path: "src/main.go", Status: StatusModified},
+		{Path: "src/main_test.go", Status: StatusModified},
+		{Path: "docs/guide.md", Status: StatusAdded},
+		{Path: "lib/util.go", Status: StatusRenamed, OldPath: "src/util.go", NewPath: "lib/util.go"},
+	}
+	opts := Options{Include: []string{"src/**"}, Exclude: []string{"**/*_test.go"}}
+	want := []FileChange{changes[0], changes[3]}
+	if got := filterChanges(opts, changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterChanges = %+v, want %+v", got, want)
+	}
+	if got := filterChanges(Options{}, changes); len(got) != len(changes) {
+		t.Errorf("expected no globs to keep every change, got %+v", got)
+	}
+
+	stats := map[string]Stat{"src/main.go": {Added: 1}, "docs/guide.md": {Added: 2}}
+	filterStats(opts, stats)
+	if !reflect.DeepEqual(stats, map[string]Stat{"src/main.go": {Added: 1}}) {
+		t.Errorf("unexpected stats left: %v", stats)
+	}
+}
+
+func TestCompare_Exclude(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	writeTestFile(t, newDir, "main.go", "package main\n")
+	writeTestFile(t, newDir, "vendor/dep/dep.go", "package dep\n")
+
+	c, err := NewCompare(oldDir, newDir, Options{Exclude: []string{"vendor/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := c.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "main.go" {
+		t.Errorf("expected vendored code left out, got %+v", changes)
+	}
+}
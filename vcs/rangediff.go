@@ -0,0 +1,166 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// RangeDiff compares two versions of a git branch, as before and after a
+// rebase, with git range-diff. Each commit that changed, was dropped or was
+// added is one entry in the file list: a changed commit's diff is the
+// range-diff of its patch, a dropped or added commit's is its own patch.
+type RangeDiff struct {
+	dir   string
+	pairs []rangePair
+}
+
+// rangePair is one line of git range-diff's commit pairing with the
+// interdiff shown under it
+type rangePair struct {
+	header  string // The pairing line, as git printed it
+	oldNum  string // Position in the old range, "-" for an added commit
+	oldSHA  string
+	kind    byte // '=' unchanged, '!' changed, '<' dropped, '>' added
+	newNum  string
+	newSHA  string
+	subject string
+	body    []string // The interdiff, unindented
+}
+
+// path names the pair in the file list by its positions in both ranges
+func (p rangePair) path() string {
+	return p.oldNum + ":" + p.newNum + " " + p.subject
+}
+
+// NewRangeDiff pairs the commits of oldRange and newRange ("base..tip"
+// each) in the git repository at dir
+func NewRangeDiff(ctx context.Context, dir, oldRange, newRange string) (*RangeDiff, error) {
+	output, err := run(ctx, dir, "git", "range-diff", "--no-color", oldRange, newRange)
+	if err != nil {
+		return nil, fmt.Errorf("git range-diff failed: %s", failureText(output, err))
+	}
+	pairs, err := parseRangeDiff(string(output))
+	if err != nil {
+		return nil, err
+	}
+	return &RangeDiff{dir: dir, pairs: pairs}, nil
+}
+
+// rangePairLine matches a pairing line: "1:  abc1234 ! 1:  def5678 Subject",
+// with "-" and dashes for the missing side of a dropped or added commit
+var rangePairLine = regexp.MustCompile(`^(-|\d+):\s+([0-9a-f]+|-+) ([=!<>]) (-|\d+):\s+([0-9a-f]+|-+) ?(.*)$`)
+
+// parseRangeDiff parses output from "git range-diff --no-color": pairing
+// lines, each followed by its interdiff indented four spaces
+func parseRangeDiff(output string) ([]rangePair, error) {
+	var pairs []rangePair
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		m := rangePairLine.FindStringSubmatch(line)
+		if m == nil {
+			if len(pairs) == 0 {
+				if line == "" {
+					continue
+				}
+				return nil, fmt.Errorf("unexpected range-diff line %q", line)
+			}
+			p := &pairs[len(pairs)-1]
+			p.body = append(p.body, strings.TrimPrefix(line, "    "))
+			continue
+		}
+		pairs = append(pairs, rangePair{
+			header: line,
+			oldNum: m[1], oldSHA: m[2],
+			kind:   m[3][0],
+			newNum: m[4], newSHA: m[5],
+			subject: m[6],
+		})
+	}
+	return pairs, nil
+}
+
+// Name returns "range-diff"
+func (r *RangeDiff) Name() string {
+	return "range-diff"
+}
+
+// ChangedFiles lists the commits that differ between the two ranges, in
+// range-diff order: changed ones modified, dropped ones deleted and new
+// ones added
+func (r *RangeDiff) ChangedFiles(ctx context.Context) ([]FileChange, error) {
+	var changes []FileChange
+	for _, p := range r.pairs {
+		var status FileStatus
+		switch p.kind {
+		case '!':
+			status = StatusModified
+		case '<':
+			status = StatusDeleted
+		case '>':
+			status = StatusAdded
+		default:
+			continue
+		}
+		changes = append(changes, FileChange{Path: p.path(), Status: status})
+	}
+	return changes, nil
+}
+
+// Diff returns the pairing line, then how the commit's patch changed, or
+// the patch of a dropped or added commit
+func (r *RangeDiff) Diff(ctx context.Context, path string) (string, error) {
+	for _, p := range r.pairs {
+		if p.path() != path {
+			continue
+		}
+		if p.kind == '!' {
+			return p.header + "\n" + strings.Join(p.body, "\n") + "\n", nil
+		}
+		sha := p.newSHA
+		if p.kind == '<' {
+			sha = p.oldSHA
+		}
+		patch, err := r.commitPatch(ctx, sha)
+		if err != nil {
+			return "", err
+		}
+		return p.header + "\n" + patch, nil
+	}
+	return "", fmt.Errorf("%s is not in the range-diff", path)
+}
+
+// FileContents returns a commit's patch as it was in the old range, or is
+// in the new one, to set the two side by side. A dropped commit has no
+// patch in the new range, and an added one none in the old.
+func (r *RangeDiff) FileContents(ctx context.Context, path string, rev Rev) (string, error) {
+	for _, p := range r.pairs {
+		if p.path() != path {
+			continue
+		}
+		sha := p.newSHA
+		if rev == RevBase {
+			sha = p.oldSHA
+		}
+		if strings.Trim(sha, "-") == "" {
+			return "", fmt.Errorf("%s on that side of the range-diff: %w", path, fs.ErrNotExist)
+		}
+		return r.commitPatch(ctx, sha)
+	}
+	return "", fmt.Errorf("%s is not in the range-diff: %w", path, fs.ErrNotExist)
+}
+
+// commitPatch returns the patch a commit makes
+func (r *RangeDiff) commitPatch(ctx context.Context, sha string) (string, error) {
+	output, err := run(ctx, r.dir, "git", "show", "--no-color", "--format=", sha)
+	if err != nil {
+		return "", fmt.Errorf("git show %s failed: %s", sha, failureText(output, err))
+	}
+	return string(output), nil
+}
+
+// DiffAll returns every changed, dropped and added commit's diff
+func (r *RangeDiff) DiffAll(ctx context.Context) (string, error) {
+	return concatDiffs(ctx, r)
+}
@@ -0,0 +1,59 @@
+package vcs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseRangeDiff(t *testing.T) {
+	output := `1:  9ae91ae ! 1:  b50245a Add greeting
+    @@ hello.go
+      func main() {
+    -+	println("hello")
+    ++	println("hello, world")
+      }
+2:  d11e6c1 = 2:  aaa675d Add x
+3:  03d18fa < -:  ------- Add y
+-:  ------- > 3:  bf57f9e Add z
+`
+	pairs, err := parseRangeDiff(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 4 {
+		t.Fatalf("expected 4 pairs, got %d", len(pairs))
+	}
+	p := pairs[0]
+	if p.kind != '!' || p.oldSHA != "9ae91ae" || p.newSHA != "b50245a" || p.path() != "1:1 Add greeting" {
+		t.Errorf("unexpected first pair: %+v", p)
+	}
+	if len(p.body) != 5 || p.body[0] != "@@ hello.go" || p.body[2] != "-+\tprintln(\"hello\")" {
+		t.Errorf("expected the interdiff unindented, got %q", p.body)
+	}
+	if pairs[1].body != nil {
+		t.Errorf("expected an unchanged commit to have no interdiff, got %q", pairs[1].body)
+	}
+	if pairs[2].path() != "3:- Add y" || pairs[2].kind != '<' {
+		t.Errorf("unexpected dropped commit: %+v", pairs[2])
+	}
+	if pairs[3].path() != "-:3 Add z" || pairs[3].kind != '>' || pairs[3].newSHA != "bf57f9e" {
+		t.Errorf("unexpected added commit: %+v", pairs[3])
+	}
+
+	r := &RangeDiff{pairs: pairs}
+	changes, _ := r.ChangedFiles(context.Background())
+	if len(changes) != 3 || changes[0].Status != StatusModified || changes[1].Status != StatusDeleted || changes[2].Status != StatusAdded {
+		t.Errorf("expected the unchanged commit left out, got %+v", changes)
+	}
+	diff, err := r.Diff(context.Background(), "1:1 Add greeting")
+	if err != nil || diff[:len(p.header)] != p.header {
+		t.Errorf("expected the diff to start with the pairing line, got %q, %v", diff, err)
+	}
+	if _, err := r.Diff(context.Background(), "9:9 Nothing"); err == nil {
+		t.Error("expected an unknown commit to fail")
+	}
+
+	if _, err := parseRangeDiff("fatal: something\n"); err == nil {
+		t.Error("expected output that isn't a range-diff to fail")
+	}
+}
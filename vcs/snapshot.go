@@ -0,0 +1,339 @@
+package vcs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Manifest records the content hash of every file in a snapshotted directory
+type Manifest struct {
+	Root  string            `json:"root"`
+	Time  time.Time         `json:"time"`
+	Files map[string]string `json:"files"` // Slash-separated relative path to SHA-256
+}
+
+const (
+	manifestFile = "manifest.json"
+	blobsDir     = "blobs"
+)
+
+// SnapshotDir returns where the snapshot of root is stored: a directory
+// under the user cache named after root's absolute path
+func SnapshotDir(root string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	base := os.Getenv("TCR_SNAPSHOT_DIR")
+	if base == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot locate cache directory: %w", err)
+		}
+		base = filepath.Join(cache, "tcr", "snapshots")
+	}
+	sum := sha256.Sum256([]byte(absRoot))
+	return filepath.Join(base, hex.EncodeToString(sum[:8])), nil
+}
+
+// HasSnapshot reports whether root has a saved snapshot
+func HasSnapshot(root string) bool {
+	dir, err := SnapshotDir(root)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, manifestFile))
+	return err == nil
+}
+
+// TakeSnapshot records the current contents of root as the baseline later
+// runs diff against, replacing any earlier snapshot
+func TakeSnapshot(root string) (*Manifest, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	dir, err := SnapshotDir(absRoot)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clear old snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, blobsDir), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	files, err := walkFiles(absRoot)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Root: absRoot, Time: time.Now(), Files: make(map[string]string, len(files))}
+	for _, rel := range files {
+		hash, err := copyBlob(filepath.Join(absRoot, filepath.FromSlash(rel)), filepath.Join(dir, blobsDir))
+		if err != nil {
+			return nil, err
+		}
+		m.Files[rel] = hash
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return m, nil
+}
+
+// LoadManifest reads the snapshot manifest for root
+func LoadManifest(root string) (*Manifest, error) {
+	dir, err := SnapshotDir(root)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no snapshot of %s\nHint: run `tcr snapshot` there first", root)
+		}
+		return nil, fmt.Errorf("failed to read snapshot manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// walkFiles lists the regular files under root as sorted slash-separated
+// relative paths, skipping VCS metadata directories
+func walkFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			switch d.Name() {
+			case ".git", ".jj", ".hg", ".svn":
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// hashFile returns the hex SHA-256 of a file's contents
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyBlob stores a copy of path in blobs named by its hash and returns the
+// hash. Identical files share one blob.
+func copyBlob(path, blobs string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	dst := filepath.Join(blobs, hash)
+	if _, err := os.Stat(dst); err == nil {
+		return hash, nil
+	}
+	if err := os.WriteFile(dst, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+	return hash, nil
+}
+
+// Snapshot implements VCS for a directory outside version control by
+// diffing it against a snapshot taken earlier with TakeSnapshot
+type Snapshot struct {
+	dir   string
+	store string
+	opts  Options
+	base  *Manifest
+}
+
+// NewSnapshot opens the saved snapshot of dir
+func NewSnapshot(dir string, opts Options) (*Snapshot, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	store, err := SnapshotDir(absDir)
+	if err != nil {
+		return nil, err
+	}
+	base, err := LoadManifest(absDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{dir: absDir, store: store, opts: opts, base: base}, nil
+}
+
+func (s *Snapshot) Name() string {
+	return "snapshot"
+}
+
+func (s *Snapshot) Options() Options {
+	return s.opts
+}
+
+func (s *Snapshot) SetOptions(opts Options) {
+	s.opts = opts
+}
+
+// Taken returns when the snapshot was taken
+func (s *Snapshot) Taken() time.Time {
+	return s.base.Time
+}
+
+func (s *Snapshot) ChangedFiles(ctx context.Context) ([]FileChange, error) {
+	live, err := walkFiles(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FileChange
+	seen := make(map[string]bool, len(live))
+	for _, rel := range live {
+		seen[rel] = true
+		old, ok := s.base.Files[rel]
+		if !ok {
+			changes = append(changes, FileChange{Path: rel, Status: StatusAdded})
+			continue
+		}
+		hash, err := hashFile(filepath.Join(s.dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		if hash != old {
+			changes = append(changes, FileChange{Path: rel, Status: StatusModified})
+		}
+	}
+	for rel := range s.base.Files {
+		if !seen[rel] {
+			changes = append(changes, FileChange{Path: rel, Status: StatusDeleted})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return filterChanges(s.opts, changes), nil
+}
+
+// FileContents reads path from the snapshot, or from the live directory
+func (s *Snapshot) FileContents(ctx context.Context, path string, rev Rev) (string, error) {
+	if rev == RevHead {
+		return readFile(filepath.Join(s.dir, filepath.FromSlash(path)))
+	}
+	hash, ok := s.base.Files[path]
+	if !ok {
+		return "", fmt.Errorf("%s in snapshot: %w", path, fs.ErrNotExist)
+	}
+	return readFile(filepath.Join(s.store, blobsDir, hash))
+}
+
+func (s *Snapshot) Diff(ctx context.Context, path string) (string, error) {
+	if output, ok := toolDiff(ctx, s, s.dir, s.opts, path); ok {
+		return output, nil
+	}
+	oldPath, oldLabel := os.DevNull, "/dev/null"
+	if hash, ok := s.base.Files[path]; ok {
+		oldPath, oldLabel = filepath.Join(s.store, blobsDir, hash), "a/"+path
+	}
+	newPath, newLabel := filepath.Join(s.dir, filepath.FromSlash(path)), "b/"+path
+	if _, err := os.Stat(newPath); errors.Is(err, fs.ErrNotExist) {
+		newPath, newLabel = os.DevNull, "/dev/null"
+	}
+
+	return diffFiles(ctx, s.dir, path, oldPath, oldLabel, newPath, newLabel, s.opts)
+}
+
+// diffFiles runs diff -u on two files, labelling them like git does, with
+// opts' context lines (0 uses diff's default) and whitespace setting
+func diffFiles(ctx context.Context, dir, path, oldPath, oldLabel, newPath, newLabel string, opts Options) (string, error) {
+	unified := 3
+	if opts.ContextLines > 0 {
+		unified = opts.ContextLines
+	}
+	args := []string{"-U" + strconv.Itoa(unified)}
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	args = append(args, "--label", oldLabel, "--label", newLabel, oldPath, newPath)
+	output, err := run(ctx, dir, "diff", args...)
+	if err != nil {
+		// diff exits 1 when the files differ
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("diff %s failed: %s", path, failureText(output, err))
+		}
+	}
+	return string(output), nil
+}
+
+func (s *Snapshot) DiffAll(ctx context.Context) (string, error) {
+	return concatDiffs(ctx, s)
+}
+
+// concatDiffs builds a full diff from the per-file diffs, for backends with
+// no native whole-tree diff
+func concatDiffs(ctx context.Context, v VCS) (string, error) {
+	changes, err := v.ChangedFiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	var all strings.Builder
+	for _, c := range changes {
+		diff, err := v.Diff(ctx, c.Path)
+		if err != nil {
+			return "", err
+		}
+		all.WriteString(diff)
+	}
+	return all.String(), nil
+}
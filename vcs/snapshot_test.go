@@ -0,0 +1,136 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, dir, rel, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+	t.Setenv("TCR_SNAPSHOT_DIR", t.TempDir())
+	dir := t.TempDir()
+
+	writeTestFile(t, dir, "keep.txt", "same\n")
+	writeTestFile(t, dir, "edit.txt", "one\ntwo\n")
+	writeTestFile(t, dir, "gone.txt", "bye\n")
+	writeTestFile(t, dir, ".hg/store", "ignored\n")
+
+	if HasSnapshot(dir) {
+		t.Fatal("HasSnapshot before TakeSnapshot")
+	}
+	m, err := TakeSnapshot(dir)
+	if err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	if len(m.Files) != 3 {
+		t.Errorf("snapshot has %d files, want 3 (VCS metadata skipped): %v", len(m.Files), m.Files)
+	}
+
+	writeTestFile(t, dir, "edit.txt", "one\nTWO\n")
+	writeTestFile(t, dir, "sub/new.txt", "hello\n")
+	if err := os.Remove(filepath.Join(dir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Detect(dir)
+	if err != nil {
+		t.Fatalf("Detect: %v", err)
+	}
+	if v.Name() != "snapshot" {
+		t.Fatalf("Detect chose %s, want snapshot", v.Name())
+	}
+
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	want := []FileChange{
+		{Path: "edit.txt", Status: StatusModified},
+		{Path: "gone.txt", Status: StatusDeleted},
+		{Path: "sub/new.txt", Status: StatusAdded},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("ChangedFiles = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %v, want %v", i, changes[i], want[i])
+		}
+	}
+
+	diff, err := v.Diff(context.Background(), "edit.txt")
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	for _, s := range []string{"--- a/edit.txt", "+++ b/edit.txt", "-two", "+TWO"} {
+		if !strings.Contains(diff, s) {
+			t.Errorf("edit diff missing %q:\n%s", s, diff)
+		}
+	}
+
+	diff, _ = v.Diff(context.Background(), "sub/new.txt")
+	if !strings.Contains(diff, "--- /dev/null") || !strings.Contains(diff, "+hello") {
+		t.Errorf("added file diff:\n%s", diff)
+	}
+	diff, _ = v.Diff(context.Background(), "gone.txt")
+	if !strings.Contains(diff, "+++ /dev/null") || !strings.Contains(diff, "-bye") {
+		t.Errorf("deleted file diff:\n%s", diff)
+	}
+
+	cr := v.(ContentReader)
+	if got, err := cr.FileContents(context.Background(), "edit.txt", RevBase); err != nil || got != "one\ntwo\n" {
+		t.Errorf("base edit.txt = %q, %v", got, err)
+	}
+	if got, err := cr.FileContents(context.Background(), "edit.txt", RevHead); err != nil || got != "one\nTWO\n" {
+		t.Errorf("head edit.txt = %q, %v", got, err)
+	}
+	if _, err := cr.FileContents(context.Background(), "sub/new.txt", RevBase); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("base of added file: %v, want fs.ErrNotExist", err)
+	}
+	if _, err := cr.FileContents(context.Background(), "gone.txt", RevHead); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("head of deleted file: %v, want fs.ErrNotExist", err)
+	}
+
+	all, err := v.DiffAll(context.Background())
+	if err != nil {
+		t.Fatalf("DiffAll: %v", err)
+	}
+	if n := strings.Count(all, "+++ "); n != 3 {
+		t.Errorf("DiffAll covers %d files, want 3:\n%s", n, all)
+	}
+
+	// Retaking the snapshot makes the directory clean again
+	if _, err := TakeSnapshot(dir); err != nil {
+		t.Fatalf("TakeSnapshot: %v", err)
+	}
+	v, _ = Detect(dir)
+	if changes, _ := v.ChangedFiles(context.Background()); len(changes) != 0 {
+		t.Errorf("changes after retaking snapshot: %v", changes)
+	}
+}
+
+func TestNewSnapshotMissing(t *testing.T) {
+	t.Setenv("TCR_SNAPSHOT_DIR", t.TempDir())
+	if _, err := NewSnapshot(t.TempDir(), Options{}); err == nil {
+		t.Error("expected an error without a snapshot")
+	}
+}
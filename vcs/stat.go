@@ -0,0 +1,177 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Stat counts the lines a change adds and removes in a file
+type Stat struct {
+	Added   int
+	Removed int
+	Binary  bool // A binary file, whose lines aren't counted
+}
+
+// Add returns the sum of two stats
+func (s Stat) Add(o Stat) Stat {
+	return Stat{Added: s.Added + o.Added, Removed: s.Removed + o.Removed, Binary: s.Binary || o.Binary}
+}
+
+// Stater is implemented by backends that count changed lines themselves,
+// faster than reading each file's diff
+type Stater interface {
+	DiffStat(ctx context.Context) (map[string]Stat, error) // Stat per changed file's Path
+}
+
+// DiffStat counts the changed lines of each file v lists, by its Path
+func DiffStat(ctx context.Context, v VCS) (map[string]Stat, error) {
+	if s, ok := v.(Stater); ok {
+		return s.DiffStat(ctx)
+	}
+	changes, err := v.ChangedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]Stat, len(changes))
+	for _, c := range changes {
+		diff, err := v.Diff(ctx, c.Path)
+		if err != nil {
+			return nil, err
+		}
+		stats[c.Path] = CountStat(diff)
+	}
+	return stats, nil
+}
+
+// Total sums the stats of every file
+func Total(stats map[string]Stat) Stat {
+	var total Stat
+	for _, s := range stats {
+		total = total.Add(s)
+	}
+	return total
+}
+
+// CountStat counts the added and removed lines in a file's unified diff
+func CountStat(diff string) Stat {
+	var s Stat
+	inHunks := false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunks = true
+		case !inHunks:
+			if strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch" {
+				s.Binary = true
+			}
+		case strings.HasPrefix(line, "+"):
+			s.Added++
+		case strings.HasPrefix(line, "-"):
+			s.Removed++
+		}
+	}
+	return s
+}
+
+// DiffStat counts changed lines with git diff --numstat, and the lines of
+// each untracked file
+func (g *Git) DiffStat(ctx context.Context) (map[string]Stat, error) {
+	opts := g.Options()
+	output, err := g.diff(ctx, "--numstat", "-z", "-M", "--find-copies")
+	if err != nil {
+		return nil, err
+	}
+	stats, err := parseGitNumstat(output)
+	if err != nil {
+		return nil, err
+	}
+	filterStats(opts, stats)
+	if !g.showsUntracked() {
+		return stats, nil
+	}
+	untracked, err := g.untracked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range filterChanges(opts, untracked) {
+		data, err := os.ReadFile(filepath.Join(g.dir, c.Path))
+		if err != nil {
+			continue
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			stats[c.Path] = Stat{Binary: true}
+			continue
+		}
+		lines := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			lines++
+		}
+		stats[c.Path] = Stat{Added: lines}
+	}
+	return stats, nil
+}
+
+// parseGitNumstat parses output from "git diff --numstat -z". Each entry
+// is "added\tremoved\tpath", with "-" counts for binary files; a rename or
+// copy leaves the path empty and follows with the old and new paths as
+// separate fields. A path listed twice, as when diffing the staged and
+// unstaged changes of a repository with no commits, is summed.
+func parseGitNumstat(output string) (map[string]Stat, error) {
+	stats := make(map[string]Stat)
+	fields := strings.Split(output, "\x00")
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "" {
+			continue
+		}
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("unexpected numstat line %q", fields[i])
+		}
+		path := parts[2]
+		if path == "" && i+2 < len(fields) {
+			path = fields[i+2] // The new path, after the old one
+			i += 2
+		}
+		var s Stat
+		if parts[0] == "-" {
+			s.Binary = true
+		} else {
+			s.Added, _ = strconv.Atoi(parts[0])
+			s.Removed, _ = strconv.Atoi(parts[1])
+		}
+		stats[path] = stats[path].Add(s)
+	}
+	return stats, nil
+}
+
+// DiffStat counts changed lines from jj's git-format diff, whatever the
+// configured diff format
+func (j *JJ) DiffStat(ctx context.Context) (map[string]Stat, error) {
+	opts := j.Options()
+	base, err := j.base(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args := append(j.diffCommand(base), "--git")
+	output, err := run(ctx, j.dir, "jj", args...)
+	if err != nil {
+		return nil, fmt.Errorf("jj %s --git failed: %s", args[0], failureText(output, err))
+	}
+	stats := make(map[string]Stat)
+	if strings.TrimSpace(string(output)) == "" {
+		return stats, nil
+	}
+	patch, err := NewPatch("jj", string(output))
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range filterChanges(opts, patch.files) {
+		stats[c.Path] = CountStat(patch.diffs[c.Path])
+	}
+	return stats, nil
+}
@@ -0,0 +1,64 @@
+package vcs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountStat(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+ package a
+-var x = 1
++var x = 2
++var y = 3
+`
+	if got := CountStat(diff); got != (Stat{Added: 2, Removed: 1}) {
+		t.Errorf("CountStat = %+v", got)
+	}
+
+	binary := "diff --git a/logo.png b/logo.png\nBinary files a/logo.png and b/logo.png differ\n"
+	if got := CountStat(binary); !got.Binary {
+		t.Errorf("expected a binary stat, got %+v", got)
+	}
+}
+
+func TestParseGitNumstat(t *testing.T) {
+	output := "3\t1\tmain.go\x00-\t-\tlogo.png\x002\t0\t\x00old.go\x00new.go\x001\t1\tmain.go\x00"
+	stats, err := parseGitNumstat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Stat{
+		"main.go":  {Added: 4, Removed: 2},
+		"logo.png": {Binary: true},
+		"new.go":   {Added: 2},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("expected %d files, got %+v", len(want), stats)
+	}
+	for path, s := range want {
+		if stats[path] != s {
+			t.Errorf("%s: expected %+v, got %+v", path, s, stats[path])
+		}
+	}
+}
+
+func TestDiffStatFromDiffs(t *testing.T) {
+	p, err := NewPatch("test", "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1,2 @@\n-a\n+b\n+c\ndiff --git a/b.go b/b.go\n--- a/b.go\n+++ b/b.go\n@@ -1 +1 @@\n-x\n+y\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := DiffStat(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["a.go"] != (Stat{Added: 2, Removed: 1}) || stats["b.go"] != (Stat{Added: 1, Removed: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if total := Total(stats); total != (Stat{Added: 3, Removed: 2}) {
+		t.Errorf("Total = %+v", total)
+	}
+}
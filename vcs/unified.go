@@ -0,0 +1,180 @@
+package vcs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// lineOp is one line of a line diff: kept (' '), removed ('-') or added
+// ('+'), with its newline unless it's a last line without one
+type lineOp struct {
+	kind byte
+	line string
+}
+
+// lineOps diffs two texts line by line
+func lineOps(before, after string, ignoreWhitespace bool) []lineOp {
+	if ignoreWhitespace {
+		return lineOpsIgnoringSpace(before, after)
+	}
+	var ops []lineOp
+	for _, d := range diff.Do(before, after) {
+		kind := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			kind = '-'
+		case diffmatchpatch.DiffInsert:
+			kind = '+'
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line != "" {
+				ops = append(ops, lineOp{kind: kind, line: line})
+			}
+		}
+	}
+	return ops
+}
+
+// lineOpsIgnoringSpace diffs two texts line by line as git diff -w does:
+// lines that differ only in whitespace are kept, shown as they are after
+func lineOpsIgnoringSpace(before, after string) []lineOp {
+	oldLines, newLines := strings.SplitAfter(before, "\n"), strings.SplitAfter(after, "\n")
+	ops := lineOps(withoutSpace(oldLines), withoutSpace(newLines), false)
+	o, n := 0, 0
+	for k := range ops {
+		switch ops[k].kind {
+		case '-':
+			ops[k].line = oldLines[o]
+			o++
+		case '+':
+			ops[k].line = newLines[n]
+			n++
+		default:
+			ops[k].line = newLines[n]
+			o++
+			n++
+		}
+	}
+	return ops
+}
+
+// withoutSpace joins lines with the whitespace in each removed, keeping
+// their newlines
+func withoutSpace(lines []string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		body, newline := strings.CutSuffix(line, "\n")
+		for _, field := range strings.Fields(body) {
+			b.WriteString(field)
+		}
+		if newline {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// unifiedDiff renders the change to path the way git diff does, with opts'
+// context lines around each change (0 for git's 3) and whitespace setting.
+// inBase and inHead say whether the file exists on each side; it's empty
+// when nothing changed.
+func unifiedDiff(path, before, after string, inBase, inHead bool, opts Options) string {
+	if (!inBase && !inHead) || (inBase && inHead && before == after) {
+		return ""
+	}
+	contextLines := opts.ContextLines
+	if contextLines <= 0 {
+		contextLines = 3
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", path, path)
+	oldLabel, newLabel := "a/"+path, "b/"+path
+	switch {
+	case !inBase:
+		b.WriteString("new file mode 100644\n")
+		oldLabel = "/dev/null"
+	case !inHead:
+		b.WriteString("deleted file mode 100644\n")
+		newLabel = "/dev/null"
+	}
+	if isBinary(before) || isBinary(after) {
+		fmt.Fprintf(&b, "Binary files %s and %s differ\n", oldLabel, newLabel)
+		return b.String()
+	}
+	if before == after {
+		// An empty file added or deleted has no hunks
+		return b.String()
+	}
+	ops := lineOps(before, after, opts.IgnoreWhitespace)
+	if inBase && inHead && !slices.ContainsFunc(ops, func(op lineOp) bool { return op.kind != ' ' }) {
+		// Only whitespace changed, which git diff -w leaves out entirely
+		return ""
+	}
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldLabel, newLabel)
+	writeHunks(&b, ops, contextLines)
+	return b.String()
+}
+
+// isBinary reports whether content looks binary, as git decides: a NUL
+// in the first 8000 bytes
+func isBinary(content string) bool {
+	return strings.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+// writeHunks writes the changes in ops as hunks with contextLines of
+// unchanged lines around them, merging hunks whose context would overlap
+func writeHunks(b *strings.Builder, ops []lineOp, contextLines int) {
+	// Line numbers on each side before each op
+	oldAt := make([]int, len(ops)+1)
+	newAt := make([]int, len(ops)+1)
+	var changes []int
+	for i, op := range ops {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		if op.kind != '+' {
+			oldAt[i+1]++
+		}
+		if op.kind != '-' {
+			newAt[i+1]++
+		}
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j]-1 <= 2*contextLines {
+			j++
+		}
+		start := max(changes[i]-contextLines, 0)
+		end := min(changes[j]+contextLines+1, len(ops))
+		fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldAt[start], oldAt[end]), hunkRange(newAt[start], newAt[end]))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = j + 1
+	}
+}
+
+// hunkRange formats the lines from start (0-based) to end of one side of a
+// hunk header as git does: "start,count", just "start" for one line, and
+// the line before for none
+func hunkRange(start, end int) string {
+	switch count := end - start; count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
@@ -2,10 +2,15 @@ package vcs
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -18,139 +23,583 @@ const (
 	StatusAdded    FileStatus = "A"
 	StatusDeleted  FileStatus = "D"
 	StatusRenamed  FileStatus = "R"
+	StatusCopied   FileStatus = "C"
+
+	// StatusUntracked marks a new file git doesn't track yet
+	StatusUntracked FileStatus = "?"
+
+	// StatusConflict marks a file with unresolved merge conflicts, as
+	// left by a merge or rebase
+	StatusConflict FileStatus = "U"
+
+	// StatusModeChanged marks a file whose permissions changed, as by
+	// chmod +x, but not its contents
+	StatusModeChanged FileStatus = "P"
 )
 
 // FileChange represents a changed file
 type FileChange struct {
-	Path   string
+	Path   string // Where the file is now, or was if it was deleted
 	Status FileStatus
+
+	// OldPath and NewPath are where a renamed or copied file was and is
+	// now; both are empty for other changes
+	OldPath string
+	NewPath string
+}
+
+// Moved reports whether the change renamed or copied the file
+func (c FileChange) Moved() bool {
+	return c.OldPath != ""
+}
+
+// renameTable remembers where each renamed or copied file came from, as of
+// the last listing, so its diff can take in both paths
+type renameTable struct {
+	mu  sync.Mutex
+	old map[string]string // New path -> old path
+}
+
+// record replaces the table with the moves among changes
+func (r *renameTable) record(changes []FileChange) {
+	old := make(map[string]string)
+	for _, c := range changes {
+		if c.Moved() {
+			old[c.NewPath] = c.OldPath
+		}
+	}
+	r.mu.Lock()
+	r.old = old
+	r.mu.Unlock()
+}
+
+// paths returns the paths to diff for path: its old path too if it moved
+func (r *renameTable) paths(path string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.old[path]; ok {
+		return []string{old, path}
+	}
+	return []string{path}
 }
 
 // VCS defines the interface for version control systems
 type VCS interface {
-	Name() string                        // "jj" or "git"
-	ChangedFiles() ([]FileChange, error) // List of changed files
-	Diff(path string) (string, error)    // Diff for specific file
-	DiffAll() (string, error)            // Full diff
+	Name() string                                           // "jj" or "git"
+	ChangedFiles(ctx context.Context) ([]FileChange, error) // List of changed files
+	Diff(ctx context.Context, path string) (string, error)  // Diff for specific file
+	DiffAll(ctx context.Context) (string, error)            // Full diff
+
+	// FileContents returns a whole file before or after the changes, for
+	// views of more than the diff, such as side by side
+	FileContents(ctx context.Context, path string, rev Rev) (string, error)
+}
+
+// Options tunes how a backend produces diffs
+type Options struct {
+	ContextLines int               // Unchanged lines around each change, 0 uses the VCS default
+	Scope        Scope             // Which uncommitted changes to show, for backends with a staging area
+	DiffTools    map[string]string // External diff command per file extension, replacing the built-in diff
+
+	// IgnoreWhitespace leaves out changes that only add, remove or change
+	// whitespace, as git diff -w does
+	IgnoreWhitespace bool
+
+	// Include and Exclude narrow the changed files to those matching an
+	// Include glob, when there are any, and no Exclude glob (see MatchGlob)
+	Include []string
+	Exclude []string
+
+	// Ignore leaves out the files the repository's .tcrignore lists,
+	// unless ShowIgnored
+	Ignore      *Ignore
+	ShowIgnored bool
+
+	// From and To review the changes between two revisions instead of
+	// the working copy's, for git and jj. An empty To is the working
+	// copy; an empty From is To's parent, or the usual base when To is
+	// empty too.
+	From string
+	To   string
+
+	// BaseRevset overrides the revset jj diffs the working copy against
+	BaseRevset string
+
+	// Interdiff takes From and To as two versions of a jj change, as
+	// before and after a rebase, and shows how the change itself evolved
+	// rather than how the trees differ (jj interdiff)
+	Interdiff bool
+
+	// Native diffs a git repository in-process with go-git instead of
+	// running git, and picks git over jj where both exist
+	Native bool
+}
+
+// HasRange reports whether opts select a range of revisions
+func (o Options) HasRange() bool {
+	return o.From != "" || o.To != ""
+}
+
+// errRangeCommit is returned when committing while reviewing a range,
+// which has nothing uncommitted to record
+var errRangeCommit = fmt.Errorf("can't commit while reviewing a range of revisions")
+
+// Scope selects between git's staged and unstaged changes
+type Scope string
+
+const (
+	ScopeAll      Scope = ""         // Staged and unstaged together, against HEAD
+	ScopeStaged   Scope = "staged"   // The index against HEAD
+	ScopeUnstaged Scope = "unstaged" // The working tree against the index
+)
+
+// String names the scope for display
+func (s Scope) String() string {
+	if s == ScopeAll {
+		return "all"
+	}
+	return string(s)
+}
+
+// ParseScope reads a scope by name: all, staged or unstaged
+func ParseScope(name string) (Scope, error) {
+	for _, s := range []Scope{ScopeAll, ScopeStaged, ScopeUnstaged} {
+		if name == s.String() {
+			return s, nil
+		}
+	}
+	return ScopeAll, fmt.Errorf("unknown scope %q (want all, staged or unstaged)", name)
+}
+
+// Configurable is implemented by backends whose options can change at runtime.
+// Callers must drop any cached diffs after calling SetOptions.
+type Configurable interface {
+	Options() Options
+	SetOptions(opts Options)
+}
+
+// Scoped is implemented by backends with a staging area, whose changes can
+// be narrowed with Options.Scope
+type Scoped interface {
+	Configurable
+	Scopes() []Scope // Supported scopes in switching order
+}
+
+// Committer is implemented by backends that can record the reviewed changes
+type Committer interface {
+	Commit(ctx context.Context, message string) error
+}
+
+// Rev selects which side of the changes FileContents reads
+type Rev int
+
+const (
+	RevBase Rev = iota // The file before the changes
+	RevHead            // The file with the changes
+)
+
+// ContentReader reads whole files on either side of the diff, as every
+// VCS does. A file missing on that side (added or deleted) gives an error
+// wrapping fs.ErrNotExist; one the backend only has part of, such as a
+// patch's, gives an error wrapping ErrPartialFile.
+type ContentReader interface {
+	FileContents(ctx context.Context, path string, rev Rev) (string, error)
 }
 
 // Detect finds the appropriate VCS for the given directory
-// Prefers jj over git if both exist
+// Prefers jj over git if both exist, then a saved snapshot
 func Detect(dir string) (VCS, error) {
+	return DetectWithOptions(dir, Options{})
+}
+
+// DetectWithOptions is like Detect but configures the backend with opts
+func DetectWithOptions(dir string, opts Options) (VCS, error) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve directory: %w", err)
 	}
 
-	// Check for jj first
-	jjDir := filepath.Join(absDir, ".jj")
-	if _, err := os.Stat(jjDir); err == nil {
-		return &JJ{dir: absDir}, nil
+	// Check for jj first, then git, in the directory itself
+	v, err := detectRepo(absDir, opts)
+	if v != nil || err != nil {
+		return v, err
+	}
+
+	// Not under version control: diff against a snapshot if one was taken
+	if HasSnapshot(absDir) {
+		opts, err := withIgnore(absDir, opts)
+		if err != nil {
+			return nil, err
+		}
+		return NewSnapshot(absDir, opts)
+	}
+
+	// Then in the directories above, so tcr works from a subdirectory
+	for dir := filepath.Dir(absDir); ; dir = filepath.Dir(dir) {
+		v, err := detectRepo(dir, opts)
+		if v != nil || err != nil {
+			return v, err
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("no VCS found (looking for .jj or .git in %s and above)\nHint: run `tcr snapshot` to review later changes to a directory outside version control", absDir)
+}
+
+// detectRepo returns the backend for a repository rooted at dir, or nil if
+// dir has no .jj or .git
+func detectRepo(dir string, opts Options) (VCS, error) {
+	if info, err := os.Stat(filepath.Join(dir, ".jj")); err == nil && info.IsDir() && !opts.Native {
+		opts, err := withIgnore(dir, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &JJ{dir: dir, opts: opts}, nil
+	}
+
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	if err != nil {
+		return nil, nil
+	}
+	// Linked worktrees and submodules have a .git file pointing at the
+	// real git directory
+	if !info.IsDir() {
+		if _, err := readGitFile(filepath.Join(dir, ".git")); err != nil {
+			return nil, err
+		}
+	}
+	if opts, err = withIgnore(dir, opts); err != nil {
+		return nil, err
 	}
+	if opts.Native {
+		return NewNative(dir, opts)
+	}
+	g := &Git{dir: dir, opts: opts}
+	g.fallBack(context.Background())
+	return g, nil
+}
 
-	// Fall back to git
-	gitDir := filepath.Join(absDir, ".git")
-	if _, err := os.Stat(gitDir); err == nil {
-		return &Git{dir: absDir}, nil
+// withIgnore sets opts.Ignore, unless given, from the .tcrignore at the
+// top of a repository in dir
+func withIgnore(dir string, opts Options) (Options, error) {
+	if opts.Ignore != nil {
+		return opts, nil
 	}
+	ignore, err := LoadIgnore(dir)
+	opts.Ignore = ignore
+	return opts, err
+}
 
-	return nil, fmt.Errorf("no VCS found (looking for .jj or .git in %s)", absDir)
+// readGitFile reads the "gitdir: PATH" line of a .git file, resolving a
+// relative path against the file's directory, and checks that it exists
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(line), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s is a file but not a gitdir pointer", path)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s points to %s, which doesn't exist\nHint: if the repository moved, run `git worktree repair` from it", path, gitDir)
+	}
+	return gitDir, nil
 }
 
 // JJ implements VCS for jujutsu
 type JJ struct {
 	dir      string
-	baseRev  string    // Cached base revision
-	baseErr  error     // Cached error if resolution failed
-	baseOnce sync.Once // Ensures base resolution happens only once
+	opts     Options // Guarded by optsMu; read through Options
+	optsMu   sync.Mutex
+	baseRev  string     // Cached base revision
+	baseErr  error      // Cached error if resolution failed
+	baseDone bool       // Whether the base has been resolved
+	baseMu   sync.Mutex // Guards the cached base
+	renames  renameTable
 }
 
 func (j *JJ) Name() string {
 	return "jj"
 }
 
+// Options returns a copy of the options, safe to read while SetOptions
+// runs on another goroutine
+func (j *JJ) Options() Options {
+	j.optsMu.Lock()
+	defer j.optsMu.Unlock()
+	return j.opts
+}
+
+func (j *JJ) SetOptions(opts Options) {
+	j.optsMu.Lock()
+	changed := opts.BaseRevset != j.opts.BaseRevset
+	j.opts = opts
+	j.optsMu.Unlock()
+	if changed {
+		j.baseMu.Lock()
+		j.baseDone, j.baseRev, j.baseErr = false, "", nil
+		j.baseMu.Unlock()
+	}
+}
+
+// diffCommand is "jj diff --from base --to @", or jj interdiff between the
+// two versions of a change
+func (j *JJ) diffCommand(base string) []string {
+	opts := j.Options()
+	if opts.Interdiff {
+		return []string{"interdiff", "--from", opts.From, "--to", opts.To}
+	}
+	return []string{"diff", "--from", base, "--to", j.head()}
+}
+
+// diffArgs builds the diff command plus option flags and extra args
+func (j *JJ) diffArgs(base string, extra ...string) []string {
+	opts := j.Options()
+	args := j.diffCommand(base)
+	if opts.ContextLines > 0 {
+		args = append(args, "--context", strconv.Itoa(opts.ContextLines))
+	}
+	if opts.IgnoreWhitespace {
+		args = append(args, "--ignore-all-space")
+	}
+	return append(args, extra...)
+}
+
 // baseRevset is the revset expression to find the base revision for diffing.
 // It finds the nearest bookmark ancestor, or falls back to trunk().
 const baseRevset = "coalesce(heads(::@ & bookmarks()), trunk())"
 
+// head is the revision whose changes are reviewed: To, or the working copy
+func (j *JJ) head() string {
+	opts := j.Options()
+	if opts.To != "" {
+		return opts.To
+	}
+	return "@"
+}
+
+// base is the revision diffed against: From, To's parent, or the nearest
+// bookmark
+func (j *JJ) base(ctx context.Context) (string, error) {
+	opts := j.Options()
+	switch {
+	case opts.From != "":
+		return opts.From, nil
+	case opts.To != "":
+		return "(" + opts.To + ")-", nil
+	}
+	return j.resolveBase(ctx)
+}
+
 // resolveBase determines the base revision for diffing.
-// It returns the commit ID of the nearest bookmark ancestor, or trunk() as fallback.
-// The result is cached so only one jj command is executed per session.
-func (j *JJ) resolveBase() (string, error) {
-	j.baseOnce.Do(func() {
-		cmd := exec.Command("jj", "log", "-r", baseRevset, "-T", "commit_id", "--no-graph", "--limit", "1")
-		cmd.Dir = j.dir
-		output, err := cmd.Output()
-		if err != nil {
-			// Check if it's an exit error with stderr
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				stderr := string(exitErr.Stderr)
-				j.baseErr = fmt.Errorf("failed to resolve base revision: %s\nHint: Create a bookmark at your branch point, or ensure a 'main', 'master', or 'trunk' bookmark exists", strings.TrimSpace(stderr))
-			} else {
-				j.baseErr = fmt.Errorf("failed to resolve base revision: %w\nHint: Create a bookmark at your branch point, or ensure a 'main', 'master', or 'trunk' bookmark exists", err)
-			}
-			return
-		}
+// It returns the commit ID of the nearest bookmark ancestor, or trunk() as
+// fallback, unless Options.BaseRevset overrides it.
+// The result is cached so only one jj command is executed per session,
+// unless ctx is cancelled before it finishes.
+func (j *JJ) resolveBase(ctx context.Context) (string, error) {
+	opts := j.Options()
+	j.baseMu.Lock()
+	defer j.baseMu.Unlock()
+	if j.baseDone {
+		return j.baseRev, j.baseErr
+	}
+
+	revset, hint := baseRevset, "Create a bookmark at your branch point, or ensure a 'main', 'master', or 'trunk' bookmark exists"
+	if opts.BaseRevset != "" {
+		revset, hint = opts.BaseRevset, "Check the revset given with --revset or jj_base_revset"
+	}
 
-		commitID := strings.TrimSpace(string(output))
-		if commitID == "" {
-			j.baseErr = fmt.Errorf("no base revision found: no bookmarks in ancestry and trunk() not found\nHint: Create a bookmark at your branch point, or ensure a 'main', 'master', or 'trunk' bookmark exists")
-			return
+	output, err := run(ctx, j.dir, "jj", "log", "-r", revset, "-T", "commit_id", "--no-graph", "--limit", "1")
+	if ctx.Err() != nil {
+		// Cancelled: leave it for the next caller to resolve
+		return "", ctx.Err()
+	}
+	j.baseDone = true
+	if err != nil {
+		// Check if it's an exit error with stderr
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr := string(exitErr.Stderr)
+			j.baseErr = j.baseError(revset, fmt.Errorf("failed to resolve base revision: %s", strings.TrimSpace(stderr)), hint)
+		} else {
+			j.baseErr = j.baseError(revset, fmt.Errorf("failed to resolve base revision: %w", err), hint)
 		}
+		return "", j.baseErr
+	}
 
-		j.baseRev = commitID
-	})
-	return j.baseRev, j.baseErr
+	commitID := strings.TrimSpace(string(output))
+	if commitID == "" && opts.BaseRevset != "" {
+		j.baseErr = j.baseError(revset, fmt.Errorf("no base revision found: revset %q is empty", revset), hint)
+		return "", j.baseErr
+	}
+	if commitID == "" {
+		j.baseErr = j.baseError(revset, errors.New("no base revision found: no bookmarks in ancestry and trunk() not found"), hint)
+		return "", j.baseErr
+	}
+
+	j.baseRev = commitID
+	return j.baseRev, nil
+}
+
+// Commit describes the working-copy change with message and starts a new
+// change on top of it (jj commit)
+func (j *JJ) Commit(ctx context.Context, message string) error {
+	opts := j.Options()
+	if opts.HasRange() {
+		return errRangeCommit
+	}
+	if output, err := run(ctx, j.dir, "jj", "commit", "-m", message); err != nil {
+		return fmt.Errorf("jj commit failed: %s", failureText(output, err))
+	}
+	return nil
 }
 
-func (j *JJ) ChangedFiles() ([]FileChange, error) {
-	base, err := j.resolveBase()
+// FileContents reads path at the base revision, or from the working copy
+// (or To, when reviewing a range). An interdiff's base is From rebased onto
+// To's parents, which exists only inside jj, so it has no base files.
+func (j *JJ) FileContents(ctx context.Context, path string, rev Rev) (string, error) {
+	opts := j.Options()
+	if rev == RevBase && opts.Interdiff {
+		return "", errors.New("an interdiff has no base files to read")
+	}
+	if rev == RevHead && opts.To == "" {
+		return readFile(filepath.Join(j.dir, filepath.FromSlash(path)))
+	}
+	revision := j.head()
+	if rev == RevBase {
+		base, err := j.base(ctx)
+		if err != nil {
+			return "", err
+		}
+		revision = base
+	}
+	output, err := run(ctx, j.dir, "jj", "file", "show", "-r", revision, "--", path)
+	if err != nil {
+		msg := failureText(output, err)
+		if strings.Contains(msg, "No such path") {
+			return "", fmt.Errorf("%s at base: %w", path, fs.ErrNotExist)
+		}
+		return "", fmt.Errorf("jj file show %s failed: %s", path, msg)
+	}
+	return string(output), nil
+}
+
+func (j *JJ) ChangedFiles(ctx context.Context) ([]FileChange, error) {
+	opts := j.Options()
+	base, err := j.base(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	cmd := exec.Command("jj", "diff", "--from", base, "--to", "@", "--summary")
-	cmd.Dir = j.dir
-	output, err := cmd.Output()
+	args := append(j.diffCommand(base), "--summary")
+	output, err := run(ctx, j.dir, "jj", args...)
 	if err != nil {
-		return nil, fmt.Errorf("jj diff --summary failed: %w", err)
+		return nil, fmt.Errorf("jj %s --summary failed: %s", args[0], failureText(output, err))
 	}
 
-	return parseJJSummary(string(output))
+	changes, err := parseJJSummary(string(output))
+	if err != nil {
+		return nil, err
+	}
+	changes = filterChanges(opts, changes)
+	j.renames.record(changes)
+	return markConflicts(changes, j.conflicts(ctx)), nil
+}
+
+// conflicts lists the files with unresolved conflicts in the reviewed
+// revision. jj fails when there are none.
+func (j *JJ) conflicts(ctx context.Context) []string {
+	output, err := run(ctx, j.dir, "jj", "resolve", "--list", "-r", j.head())
+	if err != nil {
+		return nil
+	}
+	return parseJJConflicts(string(output))
+}
+
+// parseJJConflicts parses output from "jj resolve --list"
+// Format: path/to/file    2-sided conflict
+func parseJJConflicts(output string) []string {
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		path, _, _ := strings.Cut(line, "  ")
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// markConflicts gives the changes to conflicted paths StatusConflict, and
+// adds conflicted paths the changes don't list. A path listed more than
+// once, as git diff lists an unmerged file, is kept once.
+func markConflicts(changes []FileChange, conflicted []string) []FileChange {
+	if len(conflicted) == 0 {
+		return changes
+	}
+	listed := make(map[string]bool, len(conflicted))
+	for _, path := range conflicted {
+		listed[path] = false
+	}
+	var marked []FileChange
+	for _, c := range changes {
+		if seen, ok := listed[c.Path]; ok {
+			if seen {
+				continue
+			}
+			listed[c.Path] = true
+			c.Status = StatusConflict
+		}
+		marked = append(marked, c)
+	}
+	for _, path := range conflicted {
+		if !listed[path] {
+			marked = append(marked, FileChange{Path: path, Status: StatusConflict})
+			listed[path] = true
+		}
+	}
+	return marked
 }
 
-func (j *JJ) Diff(path string) (string, error) {
-	base, err := j.resolveBase()
+func (j *JJ) Diff(ctx context.Context, path string) (string, error) {
+	opts := j.Options()
+	if output, ok := toolDiff(ctx, j, j.dir, opts, path); ok {
+		return output, nil
+	}
+	base, err := j.base(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	cmd := exec.Command("jj", "diff", "--from", base, "--to", "@", path)
-	cmd.Dir = j.dir
-	output, err := cmd.Output()
+	output, err := run(ctx, j.dir, "jj", j.diffArgs(base, j.renames.paths(path)...)...)
 	if err != nil {
 		return "", fmt.Errorf("jj diff %s failed: %w", path, err)
 	}
 	return string(output), nil
 }
 
-func (j *JJ) DiffAll() (string, error) {
-	base, err := j.resolveBase()
+func (j *JJ) DiffAll(ctx context.Context) (string, error) {
+	opts := j.Options()
+	base, err := j.base(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	cmd := exec.Command("jj", "diff", "--from", base, "--to", "@")
-	cmd.Dir = j.dir
-	output, err := cmd.Output()
+	output, err := run(ctx, j.dir, "jj", j.diffArgs(base)...)
 	if err != nil {
 		return "", fmt.Errorf("jj diff failed: %w", err)
 	}
-	return string(output), nil
+	return filterDiff(opts, string(output)), nil
 }
 
 // parseJJSummary parses output from "jj diff --summary"
-// Format: M path/to/file
+// Format: M path/to/file, or R path/{old => new}/file for renames and copies
 func parseJJSummary(output string) ([]FileChange, error) {
 	var changes []FileChange
 	lines := strings.Split(strings.TrimSpace(output), "\n")
@@ -167,57 +616,176 @@ func parseJJSummary(output string) ([]FileChange, error) {
 			continue
 		}
 
-		status := FileStatus(strings.TrimSpace(parts[0]))
-		path := strings.TrimSpace(parts[1])
-
-		changes = append(changes, FileChange{
-			Path:   path,
-			Status: status,
-		})
+		change := FileChange{
+			Path:   strings.TrimSpace(parts[1]),
+			Status: FileStatus(strings.TrimSpace(parts[0])),
+		}
+		if change.Status == StatusRenamed || change.Status == StatusCopied {
+			if oldPath, newPath, ok := splitJJRename(change.Path); ok {
+				change.Path, change.OldPath, change.NewPath = newPath, oldPath, newPath
+			}
+		}
+		changes = append(changes, change)
 	}
 
 	return changes, nil
 }
 
+// splitJJRename expands jj's rename notation, where the part that changed
+// is braced: "src/{a => b}/c.go" is src/a/c.go moved to src/b/c.go, and
+// "{ => sub}/c.go" is c.go moved to sub/c.go
+func splitJJRename(path string) (oldPath, newPath string, ok bool) {
+	open := strings.Index(path, "{")
+	end := strings.LastIndex(path, "}")
+	if open < 0 || end < open {
+		return "", "", false
+	}
+	before, after, found := strings.Cut(path[open+1:end], " => ")
+	if !found {
+		return "", "", false
+	}
+	join := func(middle string) string {
+		p := path[:open] + middle + path[end+1:]
+		// An empty side leaves a doubled or leading slash
+		p = strings.ReplaceAll(p, "//", "/")
+		return strings.TrimPrefix(p, "/")
+	}
+	return join(before), join(after), true
+}
+
 // Git implements VCS for git
 type Git struct {
-	dir string
+	dir     string
+	renames renameTable
+
+	// opts and fallback are guarded by optsMu, as loads read them while
+	// SetOptions runs; read opts through Options. fallback says why the
+	// review isn't the working tree against HEAD, as when HEAD is
+	// detached with nothing uncommitted; see fallBack
+	optsMu   sync.Mutex
+	opts     Options
+	fallback string
+
+	// parents caches the base of ranges given only To, by To
+	parentMu sync.Mutex
+	parents  map[string]string
 }
 
 func (g *Git) Name() string {
 	return "git"
 }
 
-func (g *Git) ChangedFiles() ([]FileChange, error) {
-	// Get both staged and unstaged changes
-	var changes []FileChange
+// Options returns a copy of the options, safe to read while SetOptions
+// runs on another goroutine
+func (g *Git) Options() Options {
+	g.optsMu.Lock()
+	defer g.optsMu.Unlock()
+	return g.opts
+}
+
+func (g *Git) SetOptions(opts Options) {
+	g.optsMu.Lock()
+	defer g.optsMu.Unlock()
+	g.opts = opts
+	g.fallback = ""
+}
+
+// diffArgs builds "git diff" plus option flags and extra args. Counts
+// with --numstat take no context lines: -U would add the patch after them.
+func (g *Git) diffArgs(extra ...string) []string {
+	opts := g.Options()
+	args := []string{"diff"}
+	if opts.ContextLines > 0 && !slices.Contains(extra, "--numstat") {
+		args = append(args, "-U"+strconv.Itoa(opts.ContextLines))
+	}
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	return append(args, extra...)
+}
 
-	// Staged changes
-	cmd := exec.Command("git", "diff", "--cached", "--name-status")
-	cmd.Dir = g.dir
-	stagedOutput, err := cmd.Output()
+// Scopes lists the staging scopes, of which a range of revisions has none
+func (g *Git) Scopes() []Scope {
+	opts := g.Options()
+	if opts.HasRange() {
+		return []Scope{ScopeAll}
+	}
+	return []Scope{ScopeAll, ScopeStaged, ScopeUnstaged}
+}
+
+// rangeArgs returns the revisions to diff for a range: From (or To's
+// parent) and To, which is left out to diff against the working tree
+func (g *Git) rangeArgs(ctx context.Context) []string {
+	opts := g.Options()
+	from := opts.From
+	if from == "" {
+		from = g.parent(ctx, opts.To)
+	}
+	if opts.To == "" {
+		return []string{from}
+	}
+	return []string{from, opts.To}
+}
+
+func (g *Git) ChangedFiles(ctx context.Context) ([]FileChange, error) {
+	opts := g.Options()
+	changes, err := g.trackedChanges(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("git diff --cached failed: %w", err)
+		if opts.HasRange() {
+			if baseErr := g.baseError(ctx); baseErr != nil {
+				return nil, baseErr
+			}
+		}
+		return nil, err
+	}
+	changes = filterChanges(opts, changes)
+	g.renames.record(changes)
+	if g.showsIndex() {
+		conflicted, err := g.conflicts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		changes = markConflicts(changes, conflicted)
+	}
+	if !g.showsUntracked() {
+		return changes, nil
 	}
-	staged, err := parseGitNameStatus(string(stagedOutput))
+	untracked, err := g.untracked(ctx)
 	if err != nil {
 		return nil, err
 	}
-	changes = append(changes, staged...)
+	return append(changes, filterChanges(opts, untracked)...), nil
+}
+
+// trackedChanges lists the changes to files git tracks
+func (g *Git) trackedChanges(ctx context.Context) ([]FileChange, error) {
+	opts := g.Options()
+	if opts.HasRange() {
+		return g.nameStatus(ctx, g.rangeArgs(ctx)...)
+	}
+	switch opts.Scope {
+	case ScopeStaged:
+		return g.nameStatus(ctx, "--cached")
+	case ScopeUnstaged:
+		return g.nameStatus(ctx)
+	}
 
-	// Unstaged changes (only if not already in staged)
-	cmd = exec.Command("git", "diff", "--name-status")
-	cmd.Dir = g.dir
-	unstagedOutput, err := cmd.Output()
+	// Against HEAD, a file with both staged and unstaged edits is one change
+	if changes, err := g.nameStatus(ctx, "HEAD"); err == nil {
+		return changes, nil
+	}
+
+	// No commits yet: list staged changes, then unstaged ones not already listed
+	staged, err := g.nameStatus(ctx, "--cached")
 	if err != nil {
-		return nil, fmt.Errorf("git diff failed: %w", err)
+		return nil, err
 	}
-	unstaged, err := parseGitNameStatus(string(unstagedOutput))
+	unstaged, err := g.nameStatus(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Add unstaged files that aren't already in the list
+	changes := staged
 	stagedPaths := make(map[string]bool)
 	for _, c := range staged {
 		stagedPaths[c.Path] = true
@@ -231,53 +799,267 @@ func (g *Git) ChangedFiles() ([]FileChange, error) {
 	return changes, nil
 }
 
-func (g *Git) Diff(path string) (string, error) {
-	var output bytes.Buffer
-	var errs []string
+// showsUntracked reports whether the scope reaches the working tree, where
+// untracked files live
+func (g *Git) showsUntracked() bool {
+	opts := g.Options()
+	return opts.Scope != ScopeStaged && (!opts.HasRange() || opts.To == "")
+}
+
+// showsIndex reports whether the changes reach the index, where a merge
+// leaves its conflicts
+func (g *Git) showsIndex() bool {
+	opts := g.Options()
+	return !opts.HasRange() || opts.To == ""
+}
 
-	// Get staged diff
-	cmd := exec.Command("git", "diff", "--cached", "--", path)
-	cmd.Dir = g.dir
-	stagedOutput, err := cmd.Output()
+// conflicts lists the files with unmerged entries in the index
+func (g *Git) conflicts(ctx context.Context) ([]string, error) {
+	output, err := run(ctx, g.dir, "git", "ls-files", "--unmerged", "-z")
 	if err != nil {
-		errs = append(errs, fmt.Sprintf("staged diff: %v", err))
+		return nil, fmt.Errorf("git ls-files --unmerged failed: %s", failureText(output, err))
 	}
-	output.Write(stagedOutput)
+	var paths []string
+	seen := make(map[string]bool)
+	// Each entry is "mode object stage\tpath", one per conflict stage
+	for _, entry := range strings.Split(string(output), "\x00") {
+		_, path, ok := strings.Cut(entry, "\t")
+		if ok && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
 
-	// Get unstaged diff
-	cmd = exec.Command("git", "diff", "--", path)
-	cmd.Dir = g.dir
-	unstagedOutput, err := cmd.Output()
+// untracked lists the files git doesn't track and doesn't ignore
+func (g *Git) untracked(ctx context.Context, paths ...string) ([]FileChange, error) {
+	args := append([]string{"ls-files", "--others", "--exclude-standard", "-z", "--"}, paths...)
+	output, err := run(ctx, g.dir, "git", args...)
 	if err != nil {
-		errs = append(errs, fmt.Sprintf("unstaged diff: %v", err))
+		return nil, fmt.Errorf("git ls-files --others failed: %s", failureText(output, err))
 	}
-	output.Write(unstagedOutput)
+	var changes []FileChange
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path != "" {
+			changes = append(changes, FileChange{Path: path, Status: StatusUntracked})
+		}
+	}
+	return changes, nil
+}
 
-	// Only return error if both failed and we got no output
-	if len(errs) == 2 && output.Len() == 0 {
-		return "", fmt.Errorf("git diff failed: %s", strings.Join(errs, "; "))
+// untrackedDiff shows an untracked file as added, like git diff would
+// once it's added
+func (g *Git) untrackedDiff(ctx context.Context, path string) (string, error) {
+	output, err := run(ctx, g.dir, "git", g.diffArgs("--no-index", "--", os.DevNull, path)...)
+	if err != nil {
+		// Like diff, git diff --no-index exits 1 when the files differ
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("git diff --no-index %s failed: %s", path, failureText(output, err))
+		}
+	}
+	return string(output), nil
+}
+
+// Commit records the changes in the current scope: just the index when
+// viewing staged changes, otherwise every change shown, including
+// untracked files (git add, then git commit -a)
+func (g *Git) Commit(ctx context.Context, message string) error {
+	opts := g.Options()
+	if opts.HasRange() {
+		return errRangeCommit
+	}
+	args := []string{"commit", "-m", message}
+	if opts.Scope != ScopeStaged {
+		args = append(args, "-a")
+		untracked, err := g.untracked(ctx)
+		if err != nil {
+			return err
+		}
+		if len(untracked) > 0 {
+			add := []string{"add", "--"}
+			for _, c := range untracked {
+				add = append(add, c.Path)
+			}
+			if output, err := run(ctx, g.dir, "git", add...); err != nil {
+				return fmt.Errorf("git add failed: %s", failureText(output, err))
+			}
+		}
 	}
+	if output, err := run(ctx, g.dir, "git", args...); err != nil {
+		return fmt.Errorf("git commit failed: %s", failureText(output, err))
+	}
+	return nil
+}
 
-	return output.String(), nil
+// FileContents reads path on one side of the current scope: HEAD, the
+// index or the working tree, or a revision of the range being reviewed
+func (g *Git) FileContents(ctx context.Context, path string, rev Rev) (string, error) {
+	opts := g.Options()
+	var object string // "" reads the working tree
+	switch revs := g.rangeArgs(ctx); {
+	case opts.HasRange() && rev == RevBase:
+		object = revs[0] + ":" + path
+	case opts.HasRange() && opts.To != "":
+		object = revs[1] + ":" + path
+	case opts.HasRange():
+		// The working tree
+	case rev == RevBase && opts.Scope == ScopeUnstaged:
+		object = ":" + path
+	case rev == RevBase:
+		object = "HEAD:" + path
+	case opts.Scope == ScopeStaged:
+		object = ":" + path
+	}
+	if object == "" {
+		return readFile(filepath.Join(g.dir, filepath.FromSlash(path)))
+	}
+
+	output, err := run(ctx, g.dir, "git", "show", object)
+	if err != nil {
+		msg := failureText(output, err)
+		// Not in the tree or index, or no commits yet
+		for _, missing := range []string{"does not exist", "exists on disk, but not in", "invalid object name"} {
+			if strings.Contains(msg, missing) {
+				return "", fmt.Errorf("%s: %w", object, fs.ErrNotExist)
+			}
+		}
+		return "", fmt.Errorf("git show %s failed: %s", object, msg)
+	}
+	return string(output), nil
+}
+
+// readFile reads a file from disk as a string
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// nameStatus lists the files changed by "git diff <args>"
+func (g *Git) nameStatus(ctx context.Context, args ...string) ([]FileChange, error) {
+	command := append(append([]string{"diff"}, args...), "--name-status", "-M", "--find-copies")
+	output, err := run(ctx, g.dir, "git", command...)
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %s", strings.Join(command, " "), failureText(output, err))
+	}
+	changes, err := parseGitNameStatus(string(output))
+	if err != nil {
+		return nil, err
+	}
+	return g.markModeChanges(ctx, args, changes)
 }
 
-func (g *Git) DiffAll() (string, error) {
+func (g *Git) Diff(ctx context.Context, path string) (string, error) {
+	opts := g.Options()
+	if output, ok := toolDiff(ctx, g, g.dir, opts, path); ok {
+		return output, nil
+	}
+	paths := g.renames.paths(path)
+	if len(paths) > 1 {
+		return g.movedDiff(ctx, path, paths)
+	}
+	output, err := g.diff(ctx, "--", path)
+	if err != nil || output != "" || !g.showsUntracked() {
+		return output, err
+	}
+	// Nothing to diff for a tracked file: it may be an untracked one
+	if untracked, err := g.untracked(ctx, path); err == nil && len(untracked) == 1 && untracked[0].Path == path {
+		return g.untrackedDiff(ctx, path)
+	}
+	return output, nil
+}
+
+// movedDiff diffs a renamed or copied file along with the file it came
+// from, which git needs to see it as moved rather than new. A copy's source
+// may have changed too, so only the section for path is kept.
+func (g *Git) movedDiff(ctx context.Context, path string, paths []string) (string, error) {
+	output, err := g.diff(ctx, append([]string{"-M", "--find-copies", "--"}, paths...)...)
+	if err != nil || output == "" {
+		return output, err
+	}
+	patch, err := NewPatch("git", output)
+	if err != nil {
+		return output, nil
+	}
+	if section, err := patch.Diff(ctx, path); err == nil {
+		return section, nil
+	}
+	return output, nil
+}
+
+func (g *Git) DiffAll(ctx context.Context) (string, error) {
+	opts := g.Options()
+	output, err := g.diff(ctx)
+	if err != nil {
+		return "", err
+	}
+	output = filterDiff(opts, output)
+	if !g.showsUntracked() {
+		return output, nil
+	}
+	untracked, err := g.untracked(ctx)
+	if err != nil {
+		return "", err
+	}
+	var all strings.Builder
+	all.WriteString(output)
+	for _, c := range filterChanges(opts, untracked) {
+		diff, err := g.untrackedDiff(ctx, c.Path)
+		if err != nil {
+			return "", err
+		}
+		all.WriteString(diff)
+	}
+	return all.String(), nil
+}
+
+// diff runs git diff for the current scope, with extra args (such as a
+// pathspec) appended
+func (g *Git) diff(ctx context.Context, extra ...string) (string, error) {
+	opts := g.Options()
+	if opts.HasRange() {
+		revs := g.rangeArgs(ctx)
+		output, err := run(ctx, g.dir, "git", g.diffArgs(append(revs, extra...)...)...)
+		if err != nil {
+			return "", fmt.Errorf("git diff %s failed: %s", strings.Join(revs, " "), failureText(output, err))
+		}
+		return string(output), nil
+	}
+	switch opts.Scope {
+	case ScopeStaged:
+		output, err := run(ctx, g.dir, "git", g.diffArgs(append([]string{"--cached"}, extra...)...)...)
+		if err != nil {
+			return "", fmt.Errorf("git diff --cached failed: %w", err)
+		}
+		return string(output), nil
+	case ScopeUnstaged:
+		output, err := run(ctx, g.dir, "git", g.diffArgs(extra...)...)
+		if err != nil {
+			return "", fmt.Errorf("git diff failed: %w", err)
+		}
+		return string(output), nil
+	}
+
+	// Against HEAD, staged and unstaged edits come out as one set of hunks
+	if output, err := run(ctx, g.dir, "git", g.diffArgs(append([]string{"HEAD"}, extra...)...)...); err == nil {
+		return string(output), nil
+	}
+
+	// No commits yet: concatenate the staged and unstaged diffs
 	var output bytes.Buffer
 	var errs []string
 
-	// Get staged diff
-	cmd := exec.Command("git", "diff", "--cached")
-	cmd.Dir = g.dir
-	stagedOutput, err := cmd.Output()
+	stagedOutput, err := run(ctx, g.dir, "git", g.diffArgs(append([]string{"--cached"}, extra...)...)...)
 	if err != nil {
 		errs = append(errs, fmt.Sprintf("staged diff: %v", err))
 	}
 	output.Write(stagedOutput)
 
-	// Get unstaged diff
-	cmd = exec.Command("git", "diff")
-	cmd.Dir = g.dir
-	unstagedOutput, err := cmd.Output()
+	unstagedOutput, err := run(ctx, g.dir, "git", g.diffArgs(extra...)...)
 	if err != nil {
 		errs = append(errs, fmt.Sprintf("unstaged diff: %v", err))
 	}
@@ -291,7 +1073,10 @@ func (g *Git) DiffAll() (string, error) {
 	return output.String(), nil
 }
 
-// parseGitNameStatus parses output from "git diff --name-status"
+// parseGitNameStatus parses output from "git diff --name-status -M
+// --find-copies".
+// Renames and copies carry a similarity score and both paths, as in
+// "R087\told.go\tnew.go".
 // Format: M\tpath/to/file
 func parseGitNameStatus(output string) ([]FileChange, error) {
 	var changes []FileChange
@@ -309,13 +1094,19 @@ func parseGitNameStatus(output string) ([]FileChange, error) {
 			continue
 		}
 
-		status := FileStatus(strings.TrimSpace(parts[0]))
-		path := strings.TrimSpace(parts[1])
-
-		changes = append(changes, FileChange{
-			Path:   path,
-			Status: status,
-		})
+		change := FileChange{
+			Path:   strings.TrimSpace(parts[1]),
+			Status: FileStatus(strings.TrimSpace(parts[0])),
+		}
+		if s := change.Status; len(s) > 1 && (s[0] == 'R' || s[0] == 'C') {
+			change.Status = s[:1]
+		}
+		if (change.Status == StatusRenamed || change.Status == StatusCopied) && len(parts) >= 3 {
+			change.OldPath = change.Path
+			change.NewPath = strings.TrimSpace(parts[2])
+			change.Path = change.NewPath
+		}
+		changes = append(changes, change)
 	}
 
 	return changes, nil
@@ -14,11 +14,18 @@ package vcs
 //   2. Run: go test -tags=integration -v ./vcs/...
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestJJIntegration(t *testing.T) {
@@ -64,7 +71,7 @@ func TestJJIntegration(t *testing.T) {
 	}
 
 	// Test ChangedFiles
-	changes, err := vcs.ChangedFiles()
+	changes, err := vcs.ChangedFiles(context.Background())
 	if err != nil {
 		t.Fatalf("ChangedFiles failed: %v", err)
 	}
@@ -85,7 +92,7 @@ func TestJJIntegration(t *testing.T) {
 	}
 
 	// Test Diff for specific file
-	diff, err := vcs.Diff("test.txt")
+	diff, err := vcs.Diff(context.Background(), "test.txt")
 	if err != nil {
 		t.Fatalf("Diff failed: %v", err)
 	}
@@ -94,7 +101,7 @@ func TestJJIntegration(t *testing.T) {
 	}
 
 	// Test DiffAll
-	diffAll, err := vcs.DiffAll()
+	diffAll, err := vcs.DiffAll(context.Background())
 	if err != nil {
 		t.Fatalf("DiffAll failed: %v", err)
 	}
@@ -175,7 +182,7 @@ func TestGitIntegration(t *testing.T) {
 	}
 
 	// Test ChangedFiles
-	changes, err := vcs.ChangedFiles()
+	changes, err := vcs.ChangedFiles(context.Background())
 	if err != nil {
 		t.Fatalf("ChangedFiles failed: %v", err)
 	}
@@ -196,7 +203,7 @@ func TestGitIntegration(t *testing.T) {
 	}
 
 	// Test Diff for specific file
-	diff, err := vcs.Diff("test.txt")
+	diff, err := vcs.Diff(context.Background(), "test.txt")
 	if err != nil {
 		t.Fatalf("Diff failed: %v", err)
 	}
@@ -205,7 +212,7 @@ func TestGitIntegration(t *testing.T) {
 	}
 
 	// Test DiffAll
-	diffAll, err := vcs.DiffAll()
+	diffAll, err := vcs.DiffAll(context.Background())
 	if err != nil {
 		t.Fatalf("DiffAll failed: %v", err)
 	}
@@ -237,7 +244,7 @@ func TestJJResolveBaseWithNoBookmarks(t *testing.T) {
 	jj := &JJ{dir: tmpDir}
 
 	// This should fail or return trunk() as fallback
-	_, err = jj.resolveBase()
+	_, err = jj.resolveBase(context.Background())
 	// We expect this might fail if no trunk exists, or succeed with trunk
 	// The important thing is it doesn't panic
 	if err != nil {
@@ -247,3 +254,1240 @@ func TestJJResolveBaseWithNoBookmarks(t *testing.T) {
 		}
 	}
 }
+
+func TestGitScopesIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("one\ntwo\n")
+	git("add", "file.txt")
+	git("commit", "-m", "Initial commit")
+
+	// Stage one edit, then make another on top of it
+	write("one staged\ntwo\n")
+	git("add", "file.txt")
+	write("one staged\ntwo unstaged\n")
+
+	v, err := Detect(tmpDir)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	g, ok := v.(Scoped)
+	if !ok {
+		t.Fatal("git backend should support scopes")
+	}
+
+	tests := []struct {
+		scope   Scope
+		want    []string
+		notWant []string
+	}{
+		{ScopeAll, []string{"+one staged", "+two unstaged", "-one", "-two"}, nil},
+		{ScopeStaged, []string{"+one staged", "-one"}, []string{"unstaged"}},
+		{ScopeUnstaged, []string{"+two unstaged", "-two"}, []string{"-one"}},
+	}
+	contents := map[Scope][2]string{
+		ScopeAll:      {"one\ntwo\n", "one staged\ntwo unstaged\n"},
+		ScopeStaged:   {"one\ntwo\n", "one staged\ntwo\n"},
+		ScopeUnstaged: {"one staged\ntwo\n", "one staged\ntwo unstaged\n"},
+	}
+
+	for _, tt := range tests {
+		g.SetOptions(Options{Scope: tt.scope})
+
+		changes, err := v.ChangedFiles(context.Background())
+		if err != nil {
+			t.Fatalf("%s: ChangedFiles failed: %v", tt.scope, err)
+		}
+		if len(changes) != 1 || changes[0].Path != "file.txt" {
+			t.Errorf("%s: expected only file.txt, got %+v", tt.scope, changes)
+		}
+
+		diff, err := v.Diff(context.Background(), "file.txt")
+		if err != nil {
+			t.Fatalf("%s: Diff failed: %v", tt.scope, err)
+		}
+		if n := strings.Count(diff, "diff --git"); n != 1 {
+			t.Errorf("%s: expected one file header, got %d:\n%s", tt.scope, n, diff)
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(diff, s+"\n") {
+				t.Errorf("%s: diff missing %q:\n%s", tt.scope, s, diff)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(diff, s) {
+				t.Errorf("%s: diff should not contain %q:\n%s", tt.scope, s, diff)
+			}
+		}
+
+		for i, rev := range []Rev{RevBase, RevHead} {
+			got, err := v.(ContentReader).FileContents(context.Background(), "file.txt", rev)
+			if err != nil || got != contents[tt.scope][i] {
+				t.Errorf("%s: FileContents(%d) = %q, %v; want %q", tt.scope, rev, got, err, contents[tt.scope][i])
+			}
+		}
+	}
+
+	if _, err := v.(ContentReader).FileContents(context.Background(), "missing.txt", RevBase); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("FileContents of a missing file: %v, want fs.ErrNotExist", err)
+	}
+
+	g.SetOptions(Options{Scope: ScopeStaged})
+	if state := v.(Explainer).Explain(context.Background()); !strings.Contains(state.Compared, "staged changes against HEAD (") || len(state.Hints) == 0 {
+		t.Errorf("Explain(staged) = %+v", state)
+	}
+	g.SetOptions(Options{})
+	if state := v.(Explainer).Explain(context.Background()); !strings.Contains(state.Compared, "against HEAD (") || len(state.Hints) != 2 {
+		t.Errorf("Explain(all) = %+v", state)
+	}
+}
+
+func TestGitCommitIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	file := filepath.Join(tmpDir, "file.txt")
+	if err := os.WriteFile(file, []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "file.txt")
+	git("commit", "-m", "Initial commit")
+
+	v, err := Detect(tmpDir)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	c, ok := v.(Committer)
+	if !ok {
+		t.Fatal("git backend should support committing")
+	}
+
+	// Nothing changed: git's reason comes back in the error
+	if err := c.Commit(context.Background(), "empty"); err == nil || !strings.Contains(err.Error(), "nothing") {
+		t.Errorf("expected a nothing-to-commit error, got %v", err)
+	}
+
+	// Unstaged edits are included when viewing all changes
+	if err := os.WriteFile(file, []byte("two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Commit(context.Background(), "Reviewed change\n\nDetails"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if got := git("log", "-1", "--format=%B"); !strings.HasPrefix(got, "Reviewed change\n\nDetails") {
+		t.Errorf("unexpected commit message %q", got)
+	}
+	if changes, _ := v.ChangedFiles(context.Background()); len(changes) != 0 {
+		t.Errorf("expected no changes after commit, got %+v", changes)
+	}
+}
+
+func TestGitRangeIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	for i, content := range []string{"one\n", "two\n", "three\n"} {
+		write(content)
+		git("add", "file.txt")
+		git("commit", "-m", "Commit "+string(rune('1'+i)))
+	}
+	write("uncommitted\n")
+
+	tests := []struct {
+		from, to    string
+		base, head  string
+		description string
+	}{
+		{"HEAD~2", "HEAD", "one\n", "three\n", "Commit 2\n\ncommit "},
+		{"", "HEAD~1", "one\n", "two\n", "Commit 2"},
+		{"HEAD~1", "", "two\n", "uncommitted\n", "Commit 3"},
+	}
+	for _, tt := range tests {
+		v, err := DetectWithOptions(tmpDir, Options{From: tt.from, To: tt.to})
+		if err != nil {
+			t.Fatalf("Detect failed: %v", err)
+		}
+		name := tt.from + ".." + tt.to
+
+		changes, err := v.ChangedFiles(context.Background())
+		if err != nil || len(changes) != 1 || changes[0].Path != "file.txt" {
+			t.Errorf("%s: ChangedFiles = %+v, %v", name, changes, err)
+		}
+		diff, err := v.Diff(context.Background(), "file.txt")
+		if err != nil {
+			t.Fatalf("%s: Diff failed: %v", name, err)
+		}
+		if !strings.Contains(diff, "-"+tt.base) || !strings.Contains(diff, "+"+tt.head) {
+			t.Errorf("%s: unexpected diff:\n%s", name, diff)
+		}
+		for i, rev := range []Rev{RevBase, RevHead} {
+			want := []string{tt.base, tt.head}[i]
+			if got, err := v.(ContentReader).FileContents(context.Background(), "file.txt", rev); err != nil || got != want {
+				t.Errorf("%s: FileContents(%d) = %q, %v; want %q", name, rev, got, err, want)
+			}
+		}
+		if scopes := v.(Scoped).Scopes(); len(scopes) != 1 {
+			t.Errorf("%s: a range should have no staging scopes, got %v", name, scopes)
+		}
+		if err := v.(Committer).Commit(context.Background(), "nope"); err == nil {
+			t.Errorf("%s: expected committing a range to fail", name)
+		}
+		if got, err := v.(Describer).Description(context.Background()); err != nil || !strings.Contains(got, tt.description) {
+			t.Errorf("%s: Description = %q, %v; want it to contain %q", name, got, err, tt.description)
+		}
+	}
+
+	// The working copy's changes aren't committed, so have no message
+	v, _ := DetectWithOptions(tmpDir, Options{})
+	if got, err := v.(Describer).Description(context.Background()); err != nil || got != "" {
+		t.Errorf("expected no description of the working copy, got %q, %v", got, err)
+	}
+
+	log, err := v.(Logger).Log(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 2 || log[0].Subject != "Commit 3" || log[1].Subject != "Commit 2" || log[0].Author != "Test User" {
+		t.Errorf("expected the latest two commits, newest first, got %+v", log)
+	}
+
+	refs, err := v.(RefLister).Refs(context.Background())
+	if err != nil || len(refs) != 1 || refs[0].ID != log[0].ID {
+		t.Errorf("expected the one branch, at the latest commit, got %+v, %v", refs, err)
+	}
+
+	v, _ = DetectWithOptions(tmpDir, Options{From: "no-such-rev"})
+	if _, err := v.ChangedFiles(context.Background()); err == nil || !strings.Contains(err.Error(), "no-such-rev") {
+		t.Errorf("expected git's complaint about the revision, got %v", err)
+	}
+}
+
+func TestJJBaseRevsetIntegration(t *testing.T) {
+	if _, err := exec.LookPath("jj"); err != nil {
+		t.Skip("jj not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	jj := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("jj", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("jj %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	jj("git", "init")
+	jj("bookmark", "create", "trunk", "-r", "root()")
+	if err := os.WriteFile(filepath.Join(tmpDir, "first.txt"), []byte("first\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jj("commit", "-m", "first")
+	if err := os.WriteFile(filepath.Join(tmpDir, "second.txt"), []byte("second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The default base is trunk, so both files show
+	v := &JJ{dir: tmpDir}
+	if changes, err := v.ChangedFiles(context.Background()); err != nil || len(changes) != 2 {
+		t.Errorf("default base: expected 2 changes, got %+v, %v", changes, err)
+	}
+
+	// Against the parent, only the working-copy change shows
+	v = &JJ{dir: tmpDir, opts: Options{BaseRevset: "@-"}}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil || len(changes) != 1 || changes[0].Path != "second.txt" {
+		t.Errorf("@- base: expected only second.txt, got %+v, %v", changes, err)
+	}
+
+	v = &JJ{dir: tmpDir, opts: Options{BaseRevset: "none()"}}
+	if _, err := v.ChangedFiles(context.Background()); err == nil || !strings.Contains(err.Error(), "--revset") {
+		t.Errorf("empty revset: expected a hint about --revset, got %v", err)
+	}
+}
+
+func TestGitUntrackedIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write(".gitignore", "*.log\n")
+	write("tracked.txt", "one\n")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	write("tracked.txt", "two\n")
+	write("dir/new file.txt", "hello\nworld\n")
+	write("debug.log", "ignored\n")
+
+	v, err := Detect(tmpDir)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{{Path: "tracked.txt", Status: StatusModified}, {Path: "dir/new file.txt", Status: StatusUntracked}}
+	if len(changes) != 2 || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("ChangedFiles = %+v, want %+v", changes, want)
+	}
+
+	diff, err := v.Diff(context.Background(), "dir/new file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "new file mode") || !strings.Contains(diff, "+hello\n+world\n") {
+		t.Errorf("expected an added-file diff, got:\n%s", diff)
+	}
+	all, err := v.DiffAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(all, "diff --git"); n != 2 || strings.Contains(all, "debug.log") {
+		t.Errorf("expected both files and no ignored ones in DiffAll:\n%s", all)
+	}
+	stats, err := DiffStat(context.Background(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["tracked.txt"] != (Stat{Added: 1, Removed: 1}) || stats["dir/new file.txt"] != (Stat{Added: 2}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	// Untracked files aren't staged
+	v.(Scoped).SetOptions(Options{Scope: ScopeStaged})
+	if changes, _ := v.ChangedFiles(context.Background()); len(changes) != 0 {
+		t.Errorf("staged scope should show nothing, got %+v", changes)
+	}
+
+	// Committing everything shown includes the untracked file
+	v.(Scoped).SetOptions(Options{})
+	if err := v.(Committer).Commit(context.Background(), "Add file"); err != nil {
+		t.Fatal(err)
+	}
+	if files := git("show", "--name-only", "--format="); !strings.Contains(files, "dir/new file.txt") || strings.Contains(files, "debug.log") {
+		t.Errorf("unexpected committed files:\n%s", files)
+	}
+}
+
+func TestGitWorktreeIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	repo := filepath.Join(tmpDir, "repo")
+	worktree := filepath.Join(tmpDir, "feature")
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(repo, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(repo, "init")
+	git(repo, "config", "user.email", "test@example.com")
+	git(repo, "config", "user.name", "Test User")
+	if err := os.WriteFile(filepath.Join(repo, "pkg", "a.txt"), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(repo, "add", ".")
+	git(repo, "commit", "-m", "Initial commit")
+	git(repo, "worktree", "add", "-b", "feature", worktree)
+
+	if err := os.WriteFile(filepath.Join(worktree, "pkg", "a.txt"), []byte("two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Run from inside the linked worktree, below its root
+	v, err := Detect(filepath.Join(worktree, "pkg"))
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "pkg/a.txt" {
+		t.Fatalf("expected pkg/a.txt changed in the worktree, got %+v", changes)
+	}
+	diff, err := v.Diff(context.Background(), "pkg/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+two") {
+		t.Errorf("expected the worktree's change in the diff:\n%s", diff)
+	}
+}
+
+func TestGitRenameIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	content := "package main\n\nfunc one() {}\nfunc two() {}\nfunc three() {}\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "old.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+	git("mv", "old.go", "new.go")
+	if err := os.WriteFile(filepath.Join(tmpDir, "new.go"), []byte(content+"func four() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &Git{dir: tmpDir}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FileChange{Path: "new.go", Status: StatusRenamed, OldPath: "old.go", NewPath: "new.go"}
+	if len(changes) != 1 || changes[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, changes)
+	}
+
+	stats, err := v.DiffStat(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats["new.go"] != (Stat{Added: 1}) {
+		t.Errorf("expected the rename to count one added line, got %+v", stats)
+	}
+
+	diff, err := v.Diff(context.Background(), "new.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "rename from old.go") || !strings.Contains(diff, "+func four() {}") {
+		t.Errorf("expected a rename diff with the one added line:\n%s", diff)
+	}
+	if strings.Contains(diff, "+func one() {}") {
+		t.Errorf("renamed file diffed as new:\n%s", diff)
+	}
+}
+
+func TestGitCopyIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	content := "package main\n\nfunc one() {}\nfunc two() {}\nfunc three() {}\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	// git looks for copies among the files the change modifies
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte(content+"func four() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "b.go"), []byte(content+"func five() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+
+	v := &Git{dir: tmpDir}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{
+		{Path: "a.go", Status: StatusModified},
+		{Path: "b.go", Status: StatusCopied, OldPath: "a.go", NewPath: "b.go"},
+	}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, changes)
+	}
+
+	diff, err := v.Diff(context.Background(), "b.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "copy from a.go") || !strings.Contains(diff, "+func five() {}") {
+		t.Errorf("expected a copy diff with the one added line:\n%s", diff)
+	}
+	if strings.Contains(diff, "+func four() {}") || strings.Contains(diff, "+func one() {}") {
+		t.Errorf("copy diff holds more than the copy:\n%s", diff)
+	}
+}
+
+func TestGitConflictIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("both.go", "package main\n\nvar x = 1\n")
+	write("clean.go", "package main\n")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+	git("checkout", "-b", "feature")
+	write("both.go", "package main\n\nvar x = 2\n")
+	git("commit", "-am", "Feature")
+	git("checkout", "main")
+	write("both.go", "package main\n\nvar x = 3\n")
+	write("clean.go", "package main\n\nvar y = 1\n")
+	git("commit", "-am", "Main")
+	// The merge fails, leaving both.go conflicted and nothing else changed
+	cmd := exec.Command("git", "merge", "feature")
+	cmd.Dir = tmpDir
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+
+	for _, scope := range []Scope{ScopeAll, ScopeStaged, ScopeUnstaged} {
+		v := &Git{dir: tmpDir, opts: Options{Scope: scope}}
+		changes, err := v.ChangedFiles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := FileChange{Path: "both.go", Status: StatusConflict}
+		if len(changes) != 1 || changes[0] != want {
+			t.Errorf("%s: expected only %+v, got %+v", scope, want, changes)
+		}
+	}
+
+	// A committed range has no conflicts to show
+	v := &Git{dir: tmpDir, opts: Options{To: "HEAD"}}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range changes {
+		if c.Status != StatusModified {
+			t.Errorf("expected the last commit's changes, got %+v", changes)
+		}
+	}
+}
+
+func TestGitRangeDiffIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-b", "main")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("main.go", "package main\n\nfunc main() {\n}\n")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	// Two versions of a branch: the greeting reworded, "Add y" dropped
+	// for "Add z", "Add x" the same in both
+	git("checkout", "-b", "v1")
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	git("commit", "-am", "Add greeting")
+	write("x.txt", "x\n")
+	git("add", ".")
+	git("commit", "-m", "Add x")
+	write("y.txt", "y\n")
+	git("add", ".")
+	git("commit", "-m", "Add y")
+	git("checkout", "-b", "v2", "main")
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hello, world\")\n}\n")
+	git("commit", "-am", "Add greeting")
+	write("x.txt", "x\n")
+	git("add", ".")
+	git("commit", "-m", "Add x")
+	write("z.txt", "z\n")
+	git("add", ".")
+	git("commit", "-m", "Add z")
+
+	r, err := NewRangeDiff(context.Background(), tmpDir, "main..v1", "main..v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := r.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{
+		{Path: "1:1 Add greeting", Status: StatusModified},
+		{Path: "3:- Add y", Status: StatusDeleted},
+		{Path: "-:3 Add z", Status: StatusAdded},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("unexpected commits: %+v", changes)
+	}
+
+	diff, err := r.Diff(context.Background(), "1:1 Add greeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-+\tprintln(\"hello\")") || !strings.Contains(diff, "++\tprintln(\"hello, world\")") {
+		t.Errorf("expected the reworded line in the range-diff, got:\n%s", diff)
+	}
+	diff, err = r.Diff(context.Background(), "-:3 Add z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+++ b/z.txt") {
+		t.Errorf("expected the added commit's patch, got:\n%s", diff)
+	}
+
+	oldPatch, err := r.FileContents(context.Background(), "1:1 Add greeting", RevBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPatch, err := r.FileContents(context.Background(), "1:1 Add greeting", RevHead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(oldPatch, "+\tprintln(\"hello\")") || !strings.Contains(newPatch, "+\tprintln(\"hello, world\")") {
+		t.Errorf("expected each side's patch, got:\n%s\n%s", oldPatch, newPatch)
+	}
+	if _, err := r.FileContents(context.Background(), "-:3 Add z", RevBase); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected an added commit to have no old patch, got %v", err)
+	}
+
+	if _, err := NewRangeDiff(context.Background(), tmpDir, "main..v1", "main..nope"); err == nil {
+		t.Error("expected an unknown revision to fail")
+	}
+}
+
+func TestNativeMatchesGitIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write(".gitignore", "*.log\n")
+	write("edit.txt", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n")
+	write("gone.txt", "bye\n")
+	write("no-newline.txt", "x")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+	git("tag", "base")
+
+	write("edit.txt", "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\neleven\n12\n13\n")
+	write("no-newline.txt", "y")
+	write("staged.txt", "staged\n")
+	git("add", "staged.txt")
+	git("rm", "-q", "gone.txt")
+	write("dir/new.txt", "new\n")
+	write("debug.log", "ignored\n")
+
+	// git's index lines and the function context after hunk headers
+	// aren't reproduced
+	normalize := regexp.MustCompile(`(?m)^index .*\n|(@@ [^@]* @@).*`)
+	for _, opts := range []Options{{}, {Scope: ScopeStaged}, {Scope: ScopeUnstaged}, {ContextLines: 1}, {From: "base"}} {
+		var got [2]string
+		for i, native := range []bool{false, true} {
+			opts.Native = native
+			v, err := DetectWithOptions(tmpDir, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes, err := v.ChangedFiles(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			all, err := v.DiffAll(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got[i] = fmt.Sprintf("%+v\n%+v\n%s", v.(Explainer).Explain(context.Background()), changes, normalize.ReplaceAllString(all, "$1"))
+		}
+		if got[0] != got[1] {
+			t.Errorf("with %+v native differs from git:\n%s\nnative:\n%s", opts, got[0], got[1])
+		}
+	}
+}
+
+func TestGitDetachedIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	upstream := filepath.Join(tmpDir, "upstream")
+	if err := os.Mkdir(upstream, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(upstream, "init", "-b", "main")
+	git(upstream, "config", "user.email", "test@example.com")
+	git(upstream, "config", "user.name", "Test User")
+	if err := os.WriteFile(filepath.Join(upstream, "main.txt"), []byte("main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(upstream, "add", ".")
+	git(upstream, "commit", "-m", "Initial commit")
+	git(upstream, "checkout", "-b", "feature")
+	for _, name := range []string{"one.txt", "two.txt"} {
+		if err := os.WriteFile(filepath.Join(upstream, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git(upstream, "add", name)
+		git(upstream, "commit", "-m", "Add "+name)
+	}
+	git(upstream, "checkout", "main")
+
+	changes := func(dir string) ([]FileChange, string) {
+		t.Helper()
+		v, err := Detect(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes, err := v.ChangedFiles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return changes, v.(Explainer).Explain(context.Background()).Compared
+	}
+
+	// A CI checkout of the branch: everything since it left main
+	full := filepath.Join(tmpDir, "full")
+	git(tmpDir, "clone", "-q", upstream, full)
+	git(full, "checkout", "-q", "--detach", "origin/feature")
+	got, compared := changes(full)
+	if want := []FileChange{{Path: "one.txt", Status: StatusAdded}, {Path: "two.txt", Status: StatusAdded}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("detached ChangedFiles = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(compared, "where HEAD branched from origin/main, as it's detached") {
+		t.Errorf("unexpected comparison %q", compared)
+	}
+
+	// Uncommitted changes are reviewed as usual
+	if err := os.WriteFile(filepath.Join(full, "one.txt"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := changes(full); len(got) != 1 || got[0].Path != "one.txt" || got[0].Status != StatusModified {
+		t.Errorf("expected the uncommitted change, got %+v", got)
+	}
+
+	// A shallow clone has only the last commit, without its parent
+	shallow := filepath.Join(tmpDir, "shallow")
+	git(tmpDir, "clone", "-q", "--depth", "1", "--branch", "feature", "file://"+upstream, shallow)
+	git(shallow, "checkout", "-q", "--detach")
+	got, compared = changes(shallow)
+	if len(got) != 3 || !strings.Contains(compared, "against an empty tree") {
+		t.Errorf("expected every file against the empty tree, got %+v (%s)", got, compared)
+	}
+}
+
+func TestGitMissingBaseIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	upstream := filepath.Join(tmpDir, "upstream")
+	if err := os.Mkdir(upstream, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(upstream, "init", "-b", "main")
+	git(upstream, "config", "user.email", "test@example.com")
+	git(upstream, "config", "user.name", "Test User")
+	for _, name := range []string{"one.txt", "two.txt"} {
+		if err := os.WriteFile(filepath.Join(upstream, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git(upstream, "add", name)
+		git(upstream, "commit", "-m", "Add "+name)
+	}
+
+	// A shallow clone of main alone has no origin/feature, nor main's
+	// first commit
+	shallow := filepath.Join(tmpDir, "shallow")
+	git(tmpDir, "clone", "-q", "--depth", "1", "--branch", "main", "file://"+upstream, shallow)
+	v, err := DetectWithOptions(shallow, Options{From: "origin/feature"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = v.ChangedFiles(context.Background())
+	var baseErr *BaseError
+	if !errors.As(err, &baseErr) {
+		t.Fatalf("expected a BaseError, got %v", err)
+	}
+	if baseErr.Base != "origin/feature" || !baseErr.Shallow {
+		t.Errorf("expected the shallow clone missing origin/feature, got %+v", baseErr)
+	}
+	if hints := strings.Join(baseErr.Hints, "\n"); !strings.Contains(hints, "git fetch --unshallow") || !strings.Contains(hints, "git fetch origin feature:refs/remotes/origin/feature") {
+		t.Errorf("expected hints to unshallow or fetch the branch, got %q", hints)
+	}
+
+	// Falling back reviews the working copy
+	if err := os.WriteFile(filepath.Join(shallow, "two.txt"), []byte("edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v.(Configurable).SetOptions(baseErr.Fallback)
+	got, err := v.ChangedFiles(context.Background())
+	if err != nil || len(got) != 1 || got[0].Path != "two.txt" {
+		t.Errorf("expected the working copy's change, got %+v, %v", got, err)
+	}
+
+	// A full clone just doesn't have the name
+	v, _ = DetectWithOptions(upstream, Options{From: "no-such-rev"})
+	if _, err := v.ChangedFiles(context.Background()); !errors.As(err, &baseErr) || baseErr.Shallow {
+		t.Errorf("expected a BaseError outside a shallow clone, got %v", err)
+	}
+}
+
+func TestGitDiffFilesIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	write("edit.txt", "one\n")
+	write("gone.txt", "bye\n")
+	write("old.go", "package main\n\nfunc one() {}\nfunc two() {}\nfunc three() {}\n")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	write("edit.txt", "two\n")
+	git("rm", "-q", "gone.txt")
+	git("mv", "old.go", "new.go")
+	write("untracked.txt", "new\n")
+
+	v, err := Detect(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("expected 4 changed files, got %+v", changes)
+	}
+
+	diffs, err := DiffFiles(context.Background(), v, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		want, err := v.Diff(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diffs[path] != want {
+			t.Errorf("batched diff of %s differs:\n%s\nalone:\n%s", path, diffs[path], want)
+		}
+	}
+}
+
+func TestGitChurnIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	for i, files := range [][]string{{"app.go", "my file.go"}, {"app.go"}, {"app.go", "app_test.go"}} {
+		for _, name := range files {
+			writeTestFile(t, tmpDir, name, strings.Repeat("x\n", i+1))
+		}
+		git("add", ".")
+		git("commit", "-m", fmt.Sprintf("Change %d", i))
+	}
+
+	v, err := Detect(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	churn, err := Churn(context.Background(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"app.go": 3, "my file.go": 1, "app_test.go": 1}
+	if !reflect.DeepEqual(churn, want) {
+		t.Errorf("Churn = %v, want %v", churn, want)
+	}
+
+	churn, err = v.(Churner).Churn(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"app.go": 1, "app_test.go": 1}; !reflect.DeepEqual(churn, want) {
+		t.Errorf("Churn over the latest commit = %v, want %v", churn, want)
+	}
+
+	// Line counts ignore the context lines set for diffs
+	writeTestFile(t, tmpDir, "app.go", "y\n")
+	c := v.(Configurable)
+	opts := c.Options()
+	opts.ContextLines = 5
+	c.SetOptions(opts)
+	stats, err := DiffStat(context.Background(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]Stat{"app.go": {Added: 1, Removed: 3}}; !reflect.DeepEqual(stats, want) {
+		t.Errorf("DiffStat = %v, want %v", stats, want)
+	}
+}
+
+func TestGitModeChangeIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	writeTestFile(t, tmpDir, "run.sh", "echo hi\n")
+	writeTestFile(t, tmpDir, "edit.sh", "echo hi\n")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	for _, name := range []string{"run.sh", "edit.sh"} {
+		if err := os.Chmod(filepath.Join(tmpDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, tmpDir, "edit.sh", "echo bye\n")
+
+	v, err := Detect(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := v.ChangedFiles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{{Path: "edit.sh", Status: StatusModified}, {Path: "run.sh", Status: StatusModeChanged}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("ChangedFiles = %+v, want %+v", changes, want)
+	}
+}
+
+func TestGitBlameIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	git("", "init")
+	git("", "config", "user.email", "test@example.com")
+	git("", "config", "user.name", "Test User")
+	writeTestFile(t, tmpDir, "old.go", "one\ntwo\n")
+	git("", "add", ".")
+	git("2020-01-01T00:00:00Z", "commit", "-m", "Old")
+	writeTestFile(t, tmpDir, "old.go", "one\ntwo\nthree\n")
+	git("", "add", ".")
+	git("2024-06-01T00:00:00Z", "commit", "-m", "Newer")
+	git("", "mv", "old.go", "new.go")
+	writeTestFile(t, tmpDir, "new.go", "one\ntwo\nthree\nfour\n")
+
+	v, err := Detect(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.ChangedFiles(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	times, err := v.(Blamer).Blame(context.Background(), "new.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, newer := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	if len(times) != 3 || !times[1].Equal(old) || !times[2].Equal(old) || !times[3].Equal(newer) {
+		t.Errorf("Blame = %v, want lines 1-2 from 2020 and line 3 from 2024", times)
+	}
+}
+
+func TestGitStageHunkIntegration(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping integration test")
+	}
+
+	tmpDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	git("init")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test User")
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	writeTestFile(t, tmpDir, "a.txt", strings.Join(lines, "\n")+"\n")
+	git("add", ".")
+	git("commit", "-m", "Initial commit")
+
+	lines[1], lines[17] = "line two", "line eighteen"
+	writeTestFile(t, tmpDir, "a.txt", strings.Join(lines, "\n")+"\n")
+
+	v, err := DetectWithOptions(tmpDir, Options{Scope: ScopeUnstaged})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	diff, err := v.Diff(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, hunk, ok := Hunk(diff, "@@ -15,6 +15,6 @@")
+	if !ok {
+		t.Fatalf("second hunk not found in:\n%s", diff)
+	}
+	stager := v.(HunkStager)
+	if !stager.CanStage() {
+		t.Fatal("expected unstaged changes stageable")
+	}
+	if err := stager.StageHunk(ctx, header+hunk); err != nil {
+		t.Fatal(err)
+	}
+
+	staged := git("diff", "--cached")
+	if !strings.Contains(staged, "+line eighteen") || strings.Contains(staged, "+line two") {
+		t.Errorf("expected only the second hunk staged:\n%s", staged)
+	}
+
+	v.(Configurable).SetOptions(Options{Scope: ScopeStaged})
+	if stager.CanStage() {
+		t.Error("expected staged changes not stageable again")
+	}
+}
@@ -7,10 +7,15 @@ package vcs
 // and run with: go test -tags=integration ./vcs/...
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseJJSummary(t *testing.T) {
@@ -163,15 +168,13 @@ func TestJJResolveBaseCaching(t *testing.T) {
 	// on a JJ instance with pre-set values.
 
 	jj := &JJ{
-		dir:     "/nonexistent",
-		baseRev: "abc123", // Pre-set to simulate successful resolution
+		dir:      "/nonexistent",
+		baseRev:  "abc123", // Pre-set to simulate successful resolution
+		baseDone: true,     // Mark that resolution has already happened
 	}
 
-	// Mark that resolution has already happened
-	jj.baseOnce.Do(func() {})
-
 	// First call should return cached value
-	rev1, err1 := jj.resolveBase()
+	rev1, err1 := jj.resolveBase(context.Background())
 	if err1 != nil {
 		t.Errorf("Expected no error, got %v", err1)
 	}
@@ -180,7 +183,7 @@ func TestJJResolveBaseCaching(t *testing.T) {
 	}
 
 	// Second call should return same cached value
-	rev2, err2 := jj.resolveBase()
+	rev2, err2 := jj.resolveBase(context.Background())
 	if err2 != nil {
 		t.Errorf("Expected no error, got %v", err2)
 	}
@@ -189,18 +192,55 @@ func TestJJResolveBaseCaching(t *testing.T) {
 	}
 }
 
+func TestJJResolveBaseCancelled(t *testing.T) {
+	jj := &JJ{dir: t.TempDir()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := jj.resolveBase(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if jj.baseDone {
+		t.Error("a cancelled resolution should not be cached")
+	}
+}
+
+func TestRunCancelKillsCommand(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not installed")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := run(ctx, t.TempDir(), "sleep", "10"); err == nil {
+		t.Error("expected an error from a killed command")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelling should kill the command, but it ran %v", elapsed)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	if _, err := runInput(ctx, t.TempDir(), "", "sleep", "10"); err == nil {
+		t.Error("expected an error from a killed command")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelling should kill the command, but it ran %v", elapsed)
+	}
+}
+
 func TestJJResolveBaseCachesError(t *testing.T) {
 	// Test that resolveBase also caches errors
 	jj := &JJ{
-		dir:     "/nonexistent",
-		baseErr: fmt.Errorf("cached error"),
+		dir:      "/nonexistent",
+		baseErr:  fmt.Errorf("cached error"),
+		baseDone: true, // Mark that resolution has already happened
 	}
 
-	// Mark that resolution has already happened
-	jj.baseOnce.Do(func() {})
-
 	// Call should return cached error
-	_, err := jj.resolveBase()
+	_, err := jj.resolveBase(context.Background())
 	if err == nil {
 		t.Error("Expected cached error, got nil")
 	}
@@ -240,8 +280,23 @@ func TestParseJJSummaryEdgeCases(t *testing.T) {
 		},
 		{
 			name:     "renamed file",
-			input:    "R old.go -> new.go",
-			expected: []FileChange{{Path: "old.go -> new.go", Status: StatusRenamed}},
+			input:    "R {old.go => new.go}",
+			expected: []FileChange{{Path: "new.go", Status: StatusRenamed, OldPath: "old.go", NewPath: "new.go"}},
+		},
+		{
+			name:     "renamed within a directory",
+			input:    "R src/{a => b}/main.go",
+			expected: []FileChange{{Path: "src/b/main.go", Status: StatusRenamed, OldPath: "src/a/main.go", NewPath: "src/b/main.go"}},
+		},
+		{
+			name:     "moved into a directory",
+			input:    "R { => sub}/main.go",
+			expected: []FileChange{{Path: "sub/main.go", Status: StatusRenamed, OldPath: "main.go", NewPath: "sub/main.go"}},
+		},
+		{
+			name:     "copied file",
+			input:    "C src/{main.go => main_test.go}",
+			expected: []FileChange{{Path: "src/main_test.go", Status: StatusCopied, OldPath: "src/main.go", NewPath: "src/main_test.go"}},
 		},
 		{
 			name:     "path with spaces",
@@ -270,7 +325,7 @@ func TestParseJJSummaryEdgeCases(t *testing.T) {
 				t.Fatalf("expected %d changes, got %d", len(tt.expected), len(result))
 			}
 			for i, c := range result {
-				if c.Path != tt.expected[i].Path || c.Status != tt.expected[i].Status {
+				if c != tt.expected[i] {
 					t.Errorf("change %d: expected %+v, got %+v", i, tt.expected[i], c)
 				}
 			}
@@ -301,8 +356,13 @@ func TestParseGitNameStatusEdgeCases(t *testing.T) {
 		},
 		{
 			name:     "renamed file",
-			input:    "R\told.go\tnew.go",
-			expected: []FileChange{{Path: "old.go", Status: StatusRenamed}},
+			input:    "R087\told.go\tnew.go",
+			expected: []FileChange{{Path: "new.go", Status: StatusRenamed, OldPath: "old.go", NewPath: "new.go"}},
+		},
+		{
+			name:     "copied file",
+			input:    "C100\tmain.go\tmain_copy.go",
+			expected: []FileChange{{Path: "main_copy.go", Status: StatusCopied, OldPath: "main.go", NewPath: "main_copy.go"}},
 		},
 		{
 			name:  "mixed statuses",
@@ -326,7 +386,7 @@ func TestParseGitNameStatusEdgeCases(t *testing.T) {
 				t.Fatalf("expected %d changes, got %d", len(tt.expected), len(result))
 			}
 			for i, c := range result {
-				if c.Path != tt.expected[i].Path || c.Status != tt.expected[i].Status {
+				if c != tt.expected[i] {
 					t.Errorf("change %d: expected %+v, got %+v", i, tt.expected[i], c)
 				}
 			}
@@ -347,3 +407,174 @@ func TestGitName(t *testing.T) {
 		t.Errorf("expected 'git', got %q", git.Name())
 	}
 }
+
+func TestDiffArgsContextLines(t *testing.T) {
+	git := &Git{dir: "/tmp", opts: Options{ContextLines: 10}}
+	if got := strings.Join(git.diffArgs("--cached"), " "); got != "diff -U10 --cached" {
+		t.Errorf("unexpected git args: %q", got)
+	}
+
+	jj := &JJ{dir: "/tmp", opts: Options{ContextLines: 5}}
+	if got := strings.Join(jj.diffArgs("abc", "file.go"), " "); got != "diff --from abc --to @ --context 5 file.go" {
+		t.Errorf("unexpected jj args: %q", got)
+	}
+
+	git.SetOptions(Options{IgnoreWhitespace: true})
+	if got := strings.Join(git.diffArgs("--cached"), " "); got != "diff -w --cached" {
+		t.Errorf("unexpected git args ignoring whitespace: %q", got)
+	}
+	jj.SetOptions(Options{IgnoreWhitespace: true})
+	if got := strings.Join(jj.diffArgs("abc"), " "); got != "diff --from abc --to @ --ignore-all-space" {
+		t.Errorf("unexpected jj args ignoring whitespace: %q", got)
+	}
+
+	// Zero leaves the VCS default in place
+	git.SetOptions(Options{})
+	if got := strings.Join(git.diffArgs(), " "); got != "diff" {
+		t.Errorf("unexpected git args with default context: %q", got)
+	}
+}
+
+func TestDiffArgsInterdiff(t *testing.T) {
+	jj := &JJ{dir: "/tmp", opts: Options{From: "old", To: "new", Interdiff: true}}
+	if got := strings.Join(jj.diffArgs("old", "file.go"), " "); got != "interdiff --from old --to new file.go" {
+		t.Errorf("unexpected jj args: %q", got)
+	}
+	if _, err := jj.FileContents(context.Background(), "file.go", RevBase); err == nil {
+		t.Error("expected an interdiff to have no base files")
+	}
+}
+
+func TestSortChanges(t *testing.T) {
+	changes := []FileChange{
+		{Path: "z.go"},
+		{Path: "cmd/tcr/main.go"},
+		{Path: "README.md"},
+		{Path: "cmd/main.go"},
+		{Path: "a.go"},
+	}
+
+	tests := []struct {
+		order Order
+		want  []string
+	}{
+		{OrderDiff, []string{"z.go", "cmd/tcr/main.go", "README.md", "cmd/main.go", "a.go"}},
+		{OrderPath, []string{"README.md", "a.go", "cmd/main.go", "cmd/tcr/main.go", "z.go"}},
+		{OrderTree, []string{"cmd/tcr/main.go", "cmd/main.go", "README.md", "a.go", "z.go"}},
+	}
+
+	for _, tt := range tests {
+		sorted := SortChanges(changes, tt.order)
+		var got []string
+		for _, c := range sorted {
+			got = append(got, c.Path)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s order: got %v, want %v", tt.order, got, tt.want)
+		}
+	}
+
+	if changes[0].Path != "z.go" {
+		t.Error("SortChanges modified its input")
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	for name, want := range map[string]Scope{"all": ScopeAll, "staged": ScopeStaged, "unstaged": ScopeUnstaged} {
+		if got, err := ParseScope(name); err != nil || got != want {
+			t.Errorf("ParseScope(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseScope("index"); err == nil {
+		t.Error("expected an error for an unknown scope")
+	}
+}
+
+func TestDetectGitFile(t *testing.T) {
+	root := t.TempDir()
+	gitDir := filepath.Join(root, "repo", ".git", "worktrees", "feature")
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(root, "feature")
+	if err := os.MkdirAll(filepath.Join(worktree, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	gitFile := filepath.Join(worktree, ".git")
+	if err := os.WriteFile(gitFile, []byte("gitdir: ../repo/.git/worktrees/feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// From the worktree and from a directory inside it
+	for _, dir := range []string{worktree, filepath.Join(worktree, "sub")} {
+		v, err := Detect(dir)
+		if err != nil {
+			t.Fatalf("Detect(%s) failed: %v", dir, err)
+		}
+		if g, ok := v.(*Git); !ok || g.dir != worktree {
+			t.Errorf("Detect(%s) = %#v, want git rooted at the worktree", dir, v)
+		}
+	}
+
+	// A pointer to a missing gitdir says so
+	if err := os.WriteFile(gitFile, []byte("gitdir: /nonexistent/.git/worktrees/feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Detect(worktree); err == nil || !strings.Contains(err.Error(), "git worktree repair") {
+		t.Errorf("expected a missing gitdir error, got %v", err)
+	}
+
+	// So does a .git file that isn't a pointer
+	if err := os.WriteFile(gitFile, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Detect(worktree); err == nil || !strings.Contains(err.Error(), "not a gitdir pointer") {
+		t.Errorf("expected a bad pointer error, got %v", err)
+	}
+}
+
+func TestParseJJConflicts(t *testing.T) {
+	output := "src/main.go    2-sided conflict\nnotes with space.txt    2-sided conflict including 1 deletion\n"
+	got := parseJJConflicts(output)
+	want := []string{"src/main.go", "notes with space.txt"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("parseJJConflicts = %q, want %q", got, want)
+	}
+}
+
+func TestMarkConflicts(t *testing.T) {
+	changes := []FileChange{
+		{Path: "a.go", Status: StatusModified},
+		{Path: "b.go", Status: "U"},
+		{Path: "b.go", Status: StatusModified},
+	}
+	got := markConflicts(changes, []string{"b.go", "gone.go"})
+	want := []FileChange{
+		{Path: "a.go", Status: StatusModified},
+		{Path: "b.go", Status: StatusConflict},
+		{Path: "gone.go", Status: StatusConflict},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("markConflicts = %+v, want %+v", got, want)
+	}
+}
+
+func TestGitSetOptionsConcurrent(t *testing.T) {
+	g := &Git{dir: t.TempDir()}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			g.SetOptions(Options{ContextLines: i})
+		}
+	}()
+	for range 100 {
+		g.diffArgs("--numstat")
+		g.Scopes()
+	}
+	<-done
+	if got := g.Options().ContextLines; got != 99 {
+		t.Errorf("expected the last options to stick, got ContextLines %d", got)
+	}
+}
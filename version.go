@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/update"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// currentVersion returns the build version, preferring the ldflags value
+// and falling back to the module version recorded by go install
+func currentVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		v := info.Main.Version
+		if v != "" && v != "(devel)" && !pseudoVersion.MatchString(v) {
+			return v
+		}
+	}
+	return version
+}
+
+// pseudoVersion matches Go pseudo-versions stamped on untagged builds
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// runVersion prints version and build information
+func runVersion() int {
+	fmt.Printf("tcr %s\n", currentVersion())
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		settings := map[string]string{}
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+		if rev := settings["vcs.revision"]; rev != "" {
+			if settings["vcs.modified"] == "true" {
+				rev += " (modified)"
+			}
+			fmt.Printf("  commit:   %s\n", rev)
+		}
+		if t := settings["vcs.time"]; t != "" {
+			fmt.Printf("  built:    %s\n", t)
+		}
+	}
+	fmt.Printf("  go:       %s\n", runtime.Version())
+	fmt.Printf("  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	return 0
+}
+
+// runUpdate checks GitHub for a newer release and installs it.
+// With --check it only reports whether an update is available.
+func runUpdate(args []string) int {
+	checkOnly := len(args) > 0 && args[0] == "--check"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	client, err := newHTTPClient(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	current := currentVersion()
+	rel, err := update.Latest(ctx, client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if !update.Newer(current, rel.Tag) {
+		if current == "dev" {
+			fmt.Printf("Running a development build; latest release is %s\n", rel.Tag)
+		} else {
+			fmt.Printf("tcr %s is up to date\n", current)
+		}
+		return 0
+	}
+
+	fmt.Printf("Update available: %s -> %s\n", current, rel.Tag)
+	if checkOnly {
+		if rel.URL != "" {
+			fmt.Println(rel.URL)
+		}
+		return 0
+	}
+
+	asset, ok := rel.AssetFor(runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: no release build for %s/%s\n", runtime.GOOS, runtime.GOARCH)
+		return 1
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: cannot locate tcr binary: %v\n", err)
+		return 1
+	}
+
+	if err := update.Install(ctx, client, rel, asset, exe); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Updated %s to %s\n", exe, rel.Tag)
+	return 0
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/gerunddev/tcr/config"
+	"github.com/gerunddev/tcr/ui"
+	"github.com/gerunddev/tcr/vcs"
+)
+
+const viewUsage = `Usage: tcr view [--from REV] [--to REV] [--revset REVSET] [--scope SCOPE] [--backend BACKEND] [--ignore-whitespace] [--include GLOB] [--exclude GLOB] [--no-pager]
+
+Prints the changes styled as tcr shows them, without reviewing them. With a
+diff on stdin, as when set as git's pager, it styles that instead. Output
+taller than the terminal goes through $PAGER (default "less -R").
+`
+
+// runView implements "tcr view": it renders the diff of the working copy or
+// a range, or of a patch on stdin, and exits
+func runView(args []string) int {
+	noPager, args := boolFlag(args, "--no-pager")
+	ignoreWhitespace, args := boolFlag(args, "--ignore-whitespace")
+	from, args, fromErr := valueFlag(args, "--from")
+	to, args, toErr := valueFlag(args, "--to")
+	revset, args, revsetErr := valueFlag(args, "--revset")
+	scopeName, args, scopeErr := valueFlag(args, "--scope")
+	backend, args, backendErr := valueFlag(args, "--backend")
+	include, args, includeErr := globFlag(args, "--include")
+	exclude, args, excludeErr := globFlag(args, "--exclude")
+	if err := errors.Join(includeErr, excludeErr); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := errors.Join(fromErr, toErr, revsetErr, scopeErr, backendErr); err != nil || len(args) > 0 {
+		fmt.Fprint(os.Stderr, viewUsage)
+		return 1
+	}
+	scope, err := vcs.ParseScope(scopeName)
+	if scopeName != "" && err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --scope: %v\n", err)
+		return 1
+	}
+	native, err := parseBackend(backend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --backend: %v\n", err)
+		return 1
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var v vcs.VCS
+	var raw string
+	if !isTerminal(os.Stdin) {
+		v, raw, err = stdinPatch()
+	} else {
+		opts := vcs.Options{ContextLines: cfg.ContextLines, DiffTools: cfg.DiffTools, From: from, To: to, BaseRevset: cfg.JJRevset, Scope: scope, Native: native, IgnoreWhitespace: ignoreWhitespace, Include: include, Exclude: exclude}
+		if revset != "" {
+			opts.BaseRevset = revset
+		}
+		v, err = vcs.DetectWithOptions(".", opts)
+	}
+	if errors.Is(err, errEmptyPatch) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	text := raw
+	if v != nil {
+		width := 0
+		if isTerminal(os.Stdout) {
+			if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil {
+				width = w
+			}
+		}
+		text, err = ui.RenderView(context.Background(), v, cfg, width)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	if noPager || !isTerminal(os.Stdout) || !tallerThanTerminal(text) {
+		fmt.Print(text)
+		return 0
+	}
+	if err := page(text); err != nil {
+		fmt.Print(text)
+	}
+	return 0
+}
+
+// errEmptyPatch is returned for empty stdin, which git pipes to its pager
+// when there's nothing to show
+var errEmptyPatch = errors.New("empty patch")
+
+// stdinPatch reads a diff from stdin to view, as git's pager gets it. Other
+// output git pages, like a log or --stat, has no diff and comes back as is.
+func stdinPatch() (vcs.VCS, string, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return nil, "", errEmptyPatch
+	}
+	p, err := vcs.NewPatch("stdin", string(data))
+	if err != nil {
+		return nil, string(data), nil
+	}
+	return p, "", nil
+}
+
+// tallerThanTerminal reports whether text has more lines than the
+// terminal on stdout
+func tallerThanTerminal(text string) bool {
+	_, height, err := term.GetSize(os.Stdout.Fd())
+	return err != nil || strings.Count(text, "\n") >= height
+}
+
+// page shows text through $PAGER, or less
+func page(text string) error {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -R"
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// less keeps the colors without being told, like git sets it up
+	cmd.Env = append(os.Environ(), "LESS=FRX"+os.Getenv("LESS"))
+	return cmd.Run()
+}
@@ -0,0 +1,200 @@
+// Package worddiff finds the words that changed between the removed and
+// added lines of a diff, like git diff --word-diff, so they can be
+// highlighted within the lines
+package worddiff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Span is a changed run of a line, as byte offsets from Start up to but
+// not including End
+type Span struct {
+	Start, End int
+}
+
+// maxTokens bounds the lines compared, whose cost grows with the product
+// of their lengths; longer lines (minified code, data) aren't highlighted
+const maxTokens = 400
+
+// minSimilarity is the share of text two lines must have in common for
+// their changes to be highlighted. Below it the lines were rewritten, not
+// edited, and highlighting nearly everything only adds noise.
+const minSimilarity = 0.4
+
+// Pairs matches removed lines with the added lines that replaced them. In a
+// run of removed lines followed by a run of added lines, the first removed
+// line pairs with the first added one, and so on; lines left over in the
+// longer run are unpaired. The result maps each paired line's index to its
+// partner's, both ways.
+func Pairs(lines []string) map[int]int {
+	pairs := make(map[int]int)
+	for i := 0; i < len(lines); {
+		removed := i
+		for i < len(lines) && isRemoved(lines[i]) {
+			i++
+		}
+		n := i - removed
+		// "\ No newline at end of file" may follow the last removed line
+		if n > 0 && i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+			i++
+		}
+		added := i
+		for i < len(lines) && isAdded(lines[i]) {
+			i++
+		}
+		n = min(n, i-added)
+		for k := 0; k < n; k++ {
+			pairs[removed+k] = added + k
+			pairs[added+k] = removed + k
+		}
+		if i == removed {
+			i++
+		}
+	}
+	return pairs
+}
+
+// isRemoved reports whether a diff line is a removed line, not a header
+func isRemoved(line string) bool {
+	return strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---")
+}
+
+// isAdded reports whether a diff line is an added line, not a header
+func isAdded(line string) bool {
+	return strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++")
+}
+
+// Changes compares two versions of a line by words, returning the spans of
+// each that the other doesn't have. Spans separated only by whitespace are
+// joined. Both are nil when the lines have too little in common, or are
+// too long to compare.
+func Changes(old, new string) (oldSpans, newSpans []Span) {
+	a, b := tokenize(old), tokenize(new)
+	if len(a) > maxTokens || len(b) > maxTokens {
+		return nil, nil
+	}
+	keepA, keepB := common(a, b)
+
+	same, total := 0, 0
+	for i, t := range a {
+		total += weight(t)
+		if keepA[i] {
+			same += 2 * weight(t)
+		}
+	}
+	for _, t := range b {
+		total += weight(t)
+	}
+	if total == 0 || float64(same)/float64(total) < minSimilarity {
+		return nil, nil
+	}
+	return spans(a, keepA), spans(b, keepB)
+}
+
+// token is a word, a run of whitespace or a single other character, at an
+// offset in its line
+type token struct {
+	text  string
+	start int
+}
+
+// tokenize splits a line into tokens
+func tokenize(s string) []token {
+	var tokens []token
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		end := i + size
+		switch {
+		case isWord(r):
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !isWord(r) {
+					break
+				}
+				end += size
+			}
+		case unicode.IsSpace(r):
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				end += size
+			}
+		}
+		tokens = append(tokens, token{text: s[i:end], start: i})
+		i = end
+	}
+	return tokens
+}
+
+// isWord reports whether r is part of an identifier or number
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// weight is how much a token counts toward similarity: its length, except
+// whitespace, which lines have plenty of in common without being alike
+func weight(t token) int {
+	if strings.TrimSpace(t.text) == "" {
+		return 0
+	}
+	return len(t.text)
+}
+
+// common finds a longest common subsequence of two token lists, marking
+// the tokens of each that are in it
+func common(a, b []token) (keepA, keepB []bool) {
+	// lengths[i][j] is the LCS length of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	keepA, keepB = make([]bool, len(a)), make([]bool, len(b))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].text == b[j].text:
+			keepA[i], keepB[j] = true, true
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return keepA, keepB
+}
+
+// spans joins the tokens not kept into changed spans
+func spans(tokens []token, kept []bool) []Span {
+	var result []Span
+	joinable := false // Only whitespace since the last span
+	for i, t := range tokens {
+		if kept[i] {
+			joinable = joinable && weight(t) == 0
+			continue
+		}
+		end := t.start + len(t.text)
+		if joinable {
+			result[len(result)-1].End = end
+		} else {
+			result = append(result, Span{Start: t.start, End: end})
+		}
+		joinable = true
+	}
+	return result
+}
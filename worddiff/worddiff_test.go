@@ -0,0 +1,67 @@
+package worddiff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPairs(t *testing.T) {
+	lines := []string{
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -1,5 +1,5 @@",
+		" package main",
+		"-x := 1",
+		"-y := 2",
+		"-z := 3",
+		"+x := 10",
+		"+y := 20",
+		" ok",
+		"+added",
+		"-last",
+		`\ No newline at end of file`,
+		"+last",
+	}
+	want := map[int]int{4: 7, 7: 4, 5: 8, 8: 5, 11: 13, 13: 11}
+	if got := Pairs(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairs = %v, want %v", got, want)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		old, new           string
+		oldSpans, newSpans []Span
+	}{
+		{
+			old:      "return fmt.Errorf(\"read %s: %w\", path, err)",
+			new:      "return fmt.Errorf(\"load %s: %w\", name, err)",
+			oldSpans: []Span{{19, 23}, {33, 37}},
+			newSpans: []Span{{19, 23}, {33, 37}},
+		},
+		{
+			// Changes separated by a space join into one span
+			old:      "if a == nil {",
+			new:      "if a.Ready() && b != nil {",
+			oldSpans: []Span{{5, 6}},
+			newSpans: []Span{{4, 19}},
+		},
+		{
+			old:      "x := 1",
+			new:      "x := 1 // one",
+			oldSpans: nil,
+			newSpans: []Span{{6, 13}},
+		},
+		{
+			// Rewritten rather than edited
+			old: "for _, f := range files {",
+			new: "return errors.New(\"nope\")",
+		},
+	}
+	for _, tt := range tests {
+		oldSpans, newSpans := Changes(tt.old, tt.new)
+		if !reflect.DeepEqual(oldSpans, tt.oldSpans) || !reflect.DeepEqual(newSpans, tt.newSpans) {
+			t.Errorf("Changes(%q, %q) = %v, %v; want %v, %v", tt.old, tt.new, oldSpans, newSpans, tt.oldSpans, tt.newSpans)
+		}
+	}
+}
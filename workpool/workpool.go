@@ -0,0 +1,56 @@
+// Package workpool bounds concurrent background work such as VCS
+// subprocesses and drops duplicate requests for work already in flight
+package workpool
+
+import "sync"
+
+// Pool runs keyed jobs with at most size running at once
+type Pool struct {
+	sem chan struct{}
+
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+// New creates a pool allowing size concurrent jobs (minimum 1)
+func New(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	return &Pool{
+		sem:      make(chan struct{}, size),
+		inflight: make(map[string]bool),
+	}
+}
+
+// Do runs fn on the calling goroutine once a slot is free. If a job with
+// the same key is already queued or running, Do returns false immediately
+// without running fn.
+func (p *Pool) Do(key string, fn func()) bool {
+	p.mu.Lock()
+	if p.inflight[key] {
+		p.mu.Unlock()
+		return false
+	}
+	p.inflight[key] = true
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.inflight, key)
+		p.mu.Unlock()
+	}()
+
+	p.sem <- struct{}{}
+	defer func() { <-p.sem }()
+
+	fn()
+	return true
+}
+
+// InFlight reports whether a job with key is queued or running
+func (p *Pool) InFlight(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inflight[key]
+}
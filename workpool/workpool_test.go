@@ -0,0 +1,68 @@
+package workpool
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	p := New(2)
+
+	var running, peak int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.Do(strconv.Itoa(i), func() {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					old := atomic.LoadInt32(&peak)
+					if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent jobs, saw %d", peak)
+	}
+}
+
+func TestPool_DropsDuplicateKeys(t *testing.T) {
+	p := New(1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan bool)
+	go func() {
+		done <- p.Do("a", func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	if !p.InFlight("a") {
+		t.Error("expected a to be in flight")
+	}
+	if p.Do("a", func() { t.Error("duplicate job ran") }) {
+		t.Error("expected duplicate Do to return false")
+	}
+
+	close(release)
+	if !<-done {
+		t.Error("expected first Do to report it ran")
+	}
+	if p.InFlight("a") {
+		t.Error("expected a to be finished")
+	}
+}